@@ -0,0 +1,59 @@
+package portfolio
+
+import (
+	"fmt"
+	"iter"
+)
+
+// DefaultAccount is the cash account of the transactions that do not name one.
+const DefaultAccount = "default"
+
+// cashAccount returns the name of a cash account, the default account if empty.
+func cashAccount(account string) string {
+	if account == "" {
+		return DefaultAccount
+	}
+	return account
+}
+
+// accountNote returns the mention of a cash account in validation errors, if
+// not the default account.
+func accountNote(account string) string {
+	if cashAccount(account) == DefaultAccount {
+		return ""
+	}
+	return fmt.Sprintf(" in account %q", account)
+}
+
+// Accounts iterates over the cash accounts used up to the snapshot's date, in
+// order of first use.
+func (s *Snapshot) Accounts() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		seen := make(map[string]bool)
+		for e := range s.events() {
+			var account string
+			switch v := e.(type) {
+			case creditCash:
+				account = v.cashAccount()
+			case debitCash:
+				account = v.cashAccount()
+			default:
+				continue
+			}
+			if seen[account] {
+				continue
+			}
+			seen[account] = true
+			if !yield(account) {
+				return
+			}
+		}
+	}
+}
+
+// AccountCash returns the balance of a specific currency in a cash account on
+// the snapshot's date. The cash of the transactions that do not name an account
+// is in the DefaultAccount.
+func (s *Snapshot) AccountCash(account, currency string) Money {
+	return s.cash(cashAccount(account), currency, false)
+}
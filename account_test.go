@@ -0,0 +1,62 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestLedger_CashAccounts(t *testing.T) {
+	ibDeposit := NewDeposit(NewDate(2025, 1, 2), "", USD(1000), "")
+	ibDeposit.Account = "ib"
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(500), ""),
+		ibDeposit,
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	buy := NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(1), USD(800))
+	if _, err := ledger.Validate(buy); err == nil {
+		t.Errorf("Validate(buy) beyond the default account: expected an error")
+	}
+	buy.Account = "ib"
+	valid, err := ledger.Validate(buy)
+	if err != nil {
+		t.Fatalf("Validate(buy) from the ib account error = %v", err)
+	}
+	if err := ledger.Append(valid); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	withdraw := NewWithdraw(NewDate(2025, 1, 4), "", M(0, "USD"))
+	withdraw.Account = "ib"
+	if valid, err := ledger.Validate(withdraw); err != nil || !valid.(Withdraw).Amount.Equal(USD(200)) {
+		t.Errorf("Validate(withdraw all from ib) = %v, %v, want 200 USD", valid, err)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2025, 1, 31))
+	if got := slices.Collect(s.Accounts()); !slices.Equal(got, []string{DefaultAccount, "ib"}) {
+		t.Errorf("Accounts() = %v, want [default ib]", got)
+	}
+	if got := s.AccountCash("", "USD"); !got.Equal(USD(500)) {
+		t.Errorf("AccountCash(default) = %v, want 500 USD", got)
+	}
+	if got := s.AccountCash("ib", "USD"); !got.Equal(USD(200)) {
+		t.Errorf("AccountCash(ib) = %v, want 200 USD", got)
+	}
+	if got := s.Cash("USD"); !got.Equal(USD(700)) {
+		t.Errorf("Cash() = %v, want the total of the accounts 700 USD", got)
+	}
+
+	data, err := json.Marshal(valid)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Buy
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded.Equal(valid) {
+		t.Errorf("json round trip = %v, %v, want %v", decoded, err, valid)
+	}
+}
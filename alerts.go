@@ -0,0 +1,65 @@
+package portfolio
+
+import "github.com/shopspring/decimal"
+
+// PriceAlert is an active alert on the price of a security.
+type PriceAlert struct {
+	Security  string          `json:"security"`
+	Direction string          `json:"direction"` // Direction is AlertAbove or AlertBelow.
+	Threshold decimal.Decimal `json:"threshold"`
+	Set       Date            `json:"set"`             // Set is the date the alert was set.
+	Expiry    Date            `json:"expiry,omitzero"` // Expiry is the last day of the alert, none if zero.
+	Memo      string          `json:"memo,omitempty"`
+}
+
+// Triggered reports whether a price of the security reaches the threshold of
+// the alert. A zero price, unknown, never triggers it.
+func (a PriceAlert) Triggered(price Money) bool {
+	if price.IsZero() {
+		return false
+	}
+	if a.Direction == AlertBelow {
+		return price.value.LessThanOrEqual(a.Threshold)
+	}
+	return price.value.GreaterThanOrEqual(a.Threshold)
+}
+
+// PriceAlerts returns the alerts set and not cleared on a date, nor expired,
+// in the order they were set. Setting again an active alert, with the same
+// direction and threshold, replaces it.
+func (l *Ledger) PriceAlerts(on Date) []PriceAlert {
+	var alerts []PriceAlert
+	find := func(security, direction string, threshold decimal.Decimal) int {
+		for i, a := range alerts {
+			if a.Security == security && a.Direction == direction && a.Threshold.Equal(threshold) {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, tx := range l.Query().Command(CmdSetPriceAlert, CmdClearPriceAlert).All() {
+		if tx.When().After(on) {
+			break
+		}
+		switch v := tx.(type) {
+		case SetPriceAlert:
+			a := PriceAlert{Security: v.Security, Direction: v.Direction, Threshold: v.Threshold, Set: v.Date, Expiry: v.Expiry, Memo: v.Memo}
+			if i := find(v.Security, v.Direction, v.Threshold); i >= 0 {
+				alerts[i] = a
+				continue
+			}
+			alerts = append(alerts, a)
+		case ClearPriceAlert:
+			if i := find(v.Security, v.Direction, v.Threshold); i >= 0 {
+				alerts = append(alerts[:i], alerts[i+1:]...)
+			}
+		}
+	}
+	var active []PriceAlert
+	for _, a := range alerts {
+		if a.Expiry.IsZero() || !a.Expiry.Before(on) {
+			active = append(active, a)
+		}
+	}
+	return active
+}
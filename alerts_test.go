@@ -0,0 +1,82 @@
+package portfolio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLedger_PriceAlerts(t *testing.T) {
+	ledger := NewLedger()
+	above := NewSetPriceAlert(NewDate(2025, 1, 2), "take profits", "FUND", AlertAbove, decimal.NewFromInt(120), Date{})
+	below := NewSetPriceAlert(NewDate(2025, 1, 2), "", "FUND", AlertBelow, decimal.NewFromInt(80), NewDate(2025, 1, 31))
+	if err := ledger.Append(NewDeclare(NewDate(2025, 1, 1), "", "FUND", AAPL, "EUR"), above, below); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if got := ledger.PriceAlerts(NewDate(2025, 1, 1)); len(got) != 0 {
+		t.Errorf("PriceAlerts() before they are set = %v, want none", got)
+	}
+	got := ledger.PriceAlerts(NewDate(2025, 1, 10))
+	if len(got) != 2 || got[0].Direction != AlertAbove || got[0].Memo != "take profits" || got[1].Direction != AlertBelow {
+		t.Fatalf("PriceAlerts() = %v, want the above and the below alerts", got)
+	}
+	if got := ledger.PriceAlerts(NewDate(2025, 2, 1)); len(got) != 1 || got[0].Direction != AlertAbove {
+		t.Errorf("PriceAlerts() after the expiry = %v, want the above alert only", got)
+	}
+
+	for _, tt := range []struct {
+		alert PriceAlert
+		price Money
+		want  bool
+	}{
+		{got[0], EUR(119), false},
+		{got[0], EUR(120), true},
+		{got[1], EUR(81), false},
+		{got[1], EUR(80), true},
+		{got[1], Money{}, false},
+	} {
+		if triggered := tt.alert.Triggered(tt.price); triggered != tt.want {
+			t.Errorf("%s %v: Triggered(%v) = %v, want %v", tt.alert.Direction, tt.alert.Threshold, tt.price, triggered, tt.want)
+		}
+	}
+
+	cleared := NewClearPriceAlert(NewDate(2025, 1, 15), "", "FUND", AlertAbove, decimal.NewFromInt(120))
+	if _, err := ledger.Validate(cleared); err != nil {
+		t.Fatalf("Validate(clear active alert) error = %v", err)
+	}
+	if err := ledger.Append(cleared); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got := ledger.PriceAlerts(NewDate(2025, 1, 15)); len(got) != 1 || got[0].Direction != AlertBelow {
+		t.Errorf("PriceAlerts() after the clear = %v, want the below alert only", got)
+	}
+	if _, err := ledger.Validate(NewClearPriceAlert(NewDate(2025, 1, 16), "", "FUND", AlertAbove, decimal.NewFromInt(120))); err == nil {
+		t.Errorf("Validate(clear of a cleared alert) succeeded, want an error")
+	}
+
+	for _, tx := range []Transaction{
+		NewSetPriceAlert(NewDate(2025, 1, 2), "", "FUND", "sideways", decimal.NewFromInt(1), Date{}),
+		NewSetPriceAlert(NewDate(2025, 1, 2), "", "FUND", AlertAbove, decimal.Zero, Date{}),
+		NewSetPriceAlert(NewDate(2025, 1, 2), "", "FUND", AlertAbove, decimal.NewFromInt(1), NewDate(2025, 1, 1)),
+	} {
+		if _, err := ledger.Validate(tx); err == nil {
+			t.Errorf("Validate(%v) succeeded, want an error", tx)
+		}
+	}
+
+	for _, tx := range []Transaction{above, below, cleared} {
+		var b bytes.Buffer
+		if err := EncodeTransaction(&b, tx); err != nil {
+			t.Fatalf("EncodeTransaction() error = %v", err)
+		}
+		decoded, err := NewDecoder(&b).Decode()
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if !decoded.Equal(tx) {
+			t.Errorf("Decode() = %v, want %v", decoded, tx)
+		}
+	}
+}
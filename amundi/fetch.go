@@ -1,6 +1,7 @@
 package amundi
 
 import (
+	"context"
 	"log"
 	"maps"
 	"net/http"
@@ -13,6 +14,10 @@ import (
 
 const amundiPrefix = "Amundi-"
 
+// Source is the source of the market data fetched from Amundi, see
+// portfolio.UpdatePrice.
+const Source = "amundi"
+
 // AmundiID convert an Amundi code (codeFond in their slang) to a portfolio.ID
 func AmundiID(code string) portfolio.ID {
 	return portfolio.ID(amundiPrefix + code)
@@ -28,8 +33,8 @@ func isAmundi(id portfolio.ID) bool { return strings.HasPrefix(string(id), amund
 // Fetch Amundi Holding reports and create updated prices.
 // it fetches reports from today going backwards.
 // If eager, it fetches all reports, otherwise it stops as soon as fetched reports stop providing new prices.
-func Fetch(headers http.Header, ledger *portfolio.Ledger, eager bool) ([]Change, []portfolio.Transaction, error) {
-	products, err := getProducts(headers)
+func Fetch(ctx context.Context, headers http.Header, ledger *portfolio.Ledger, eager bool) ([]Change, []portfolio.Transaction, error) {
+	products, err := getProducts(ctx, headers)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -41,7 +46,7 @@ func Fetch(headers http.Header, ledger *portfolio.Ledger, eager bool) ([]Change,
 	// Extract from the ledger the required information relative to Amundi's asset.
 	inceptionDate, knownPrices, securities := amundiInfo(ledger)
 
-	updatePoints, err := fetchAll(headers, products, portfolio.Today(), inceptionDate, knownPrices, eager)
+	updatePoints, err := fetchAll(ctx, headers, products, portfolio.Today(), inceptionDate, knownPrices, eager)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,12 +106,14 @@ func mergeUpdates(updatePoints map[point]Change, securities map[portfolio.ID][]p
 
 		// Update the UpdatePrice accordingly
 		if up, ok := m[k.Date]; !ok {
-			m[k.Date] = portfolio.NewUpdatePrices(k.Date, upd)
+			up := portfolio.NewUpdatePrices(k.Date, upd)
+			up.Source = Source
+			m[k.Date] = up
 		} else {
 			maps.Copy(up.Prices, upd) // that should be ok, since up is a copy, but Prices is a pointer.
 		}
 	}
-	return slices.Collect(maps.Values(m))
+	return slices.SortedFunc(maps.Values(m), func(a, b portfolio.UpdatePrice) int { return a.Date.Compare(b.Date) })
 }
 
 // amundiInfo scan the ledger for info relative to Amundi Assets.
@@ -126,7 +133,7 @@ func amundiInfo(ledger *portfolio.Ledger) (inceptionDate portfolio.Date, known m
 
 	// Scan all transactions to fill the known map, and to compute the Amundi inception date.
 	inceptionDate = portfolio.Today() // default to today.
-	for _, tx := range ledger.Transactions(portfolio.AcceptAll) {
+	for _, tx := range ledger.Query().All() {
 		switch v := tx.(type) {
 		case portfolio.UpdatePrice:
 			for ticker, price := range v.PricesIter() {
@@ -145,7 +152,7 @@ func amundiInfo(ledger *portfolio.Ledger) (inceptionDate portfolio.Date, known m
 	return inceptionDate, known, securities
 }
 
-func fetchAll(headers http.Header, products []Product, start portfolio.Date, end portfolio.Date, known map[point]decimal.Decimal, eager bool) (updatePoints map[point]Change, err error) {
+func fetchAll(ctx context.Context, headers http.Header, products []Product, start portfolio.Date, end portfolio.Date, known map[point]decimal.Decimal, eager bool) (updatePoints map[point]Change, err error) {
 
 	// This map will store the new price changes.
 	updatePoints = make(map[point]Change)
@@ -174,7 +181,7 @@ func fetchAll(headers http.Header, products []Product, start portfolio.Date, end
 				continue
 			}
 
-			updates, err := getProductHolding(headers, p, d)
+			updates, err := getProductHolding(ctx, headers, p, d)
 			if err != nil {
 				// Fail all if there is one error.
 				// Otherwise, it would create "gaps" in the data, making the backward strategy
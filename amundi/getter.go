@@ -1,42 +1,25 @@
 package amundi
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"net/url"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/fundplatform"
 	"github.com/shopspring/decimal"
 )
 
-// wget little helper to retrieve payload from http.
-func wget(uri string, header http.Header) ([]byte, error) {
-	r, err := http.NewRequest(http.MethodGet, uri, nil)
-	if err != nil {
-		log.Printf("URI=%s", uri)
-		return nil, fmt.Errorf("cannot create http request %q: %w", uri, err)
-	}
-	r.Header = header
-
-	resp, err := http.DefaultClient.Do(r)
-	if err != nil {
-		log.Printf("URI=%s, status=%q", uri, resp.Status)
-		return nil, fmt.Errorf("cannot execute http request: %w", err)
+// wget little helper to retrieve payload from http, see fundplatform.Get.
+func wget(ctx context.Context, uri string, header http.Header) ([]byte, error) {
+	data, err := fundplatform.Get(ctx, platform, uri, header)
+	if errors.Is(err, fundplatform.ErrSessionExpired) {
+		return nil, fmt.Errorf("%w: run 'pcs amundi login' again", err)
 	}
-	body := resp.Body
-	defer body.Close()
-
-	// reading in a buffer to be able to print the json in debug mode
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, body); err != nil {
-		return nil, fmt.Errorf("cannot read receiving http body: %w", err)
-	}
-
-	return buf.Bytes(), nil
+	return data, err
 }
 
 // Product represent Amundi Accounting product like P.E.E or P.E.R etc.
@@ -48,7 +31,7 @@ type Product struct {
 }
 
 // getProducts fetchs all products for the logged in user.
-func getProducts(headers http.Header) (products []Product, err error) {
+func getProducts(ctx context.Context, headers http.Header) (products []Product, err error) {
 	const uriProducts = "https://epargnant.amundi-ee.com/api/individu/produitsEpargne?codeRegroupement=ER%2CRC%2CES"
 	// query uriProducts
 	// sample of response (I faked the numbers)
@@ -76,7 +59,7 @@ func getProducts(headers http.Header) (products []Product, err error) {
 	//     }
 	// ]
 
-	data, err := wget(uriProducts, headers)
+	data, err := wget(ctx, uriProducts, headers)
 	if err != nil {
 		return nil, fmt.Errorf("error querying products: %w", err)
 	}
@@ -108,7 +91,7 @@ func (u AssetHolding) Price() decimal.Decimal { return u.Value }
 // Caveat1: AssetHolding are not necessarily on the day requested.
 //
 // Caveat2: AssetHolding are also available for funds that are not held.
-func getProductHolding(headers http.Header, p Product, day portfolio.Date) (prices []AssetHolding, err error) {
+func getProductHolding(ctx context.Context, headers http.Header, p Product, day portfolio.Date) (prices []AssetHolding, err error) {
 	uris := map[string]string{
 		"idDispositif": "https://epargnant.amundi-ee.com/api/individu/produitsEpargne/idDispositif/",
 		"affiliation":  "https://epargnant.amundi-ee.com/api/individu/produitsEpargne/affiliation/",
@@ -130,20 +113,18 @@ func getProductHolding(headers http.Header, p Product, day portfolio.Date) (pric
 
 	uri, known := uris[p.Type]
 	if !known {
-		log.Printf("Product=%#v", p)
 		return nil, fmt.Errorf("unknown product type %q", p.Type)
 	}
 	// and they all have the same arguments
 	uri = uri + url.PathEscape(p.ID) + "?date=" + url.QueryEscape(day.Format("2006-01-02T15:04:05Z"))
 
-	data, err := wget(uri, headers)
+	data, err := wget(ctx, uri, headers)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := json.Unmarshal(data, &snapshot); err != nil {
-		log.Printf("json=```\n\n%s\n\n```", string(data))
-		return nil, fmt.Errorf("could not decode amundi snapshot json: %w", err)
+		return nil, portfolio.ProviderError(fmt.Errorf("could not decode amundi snapshot json of product %s: %w", p.ID, err))
 	}
 
 	// HOT FIX:
@@ -1,31 +1,26 @@
 package amundi
 
 import (
-	"bufio"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+
+	"github.com/etnz/portfolio/fundplatform"
 )
 
-const amundiSessionFile = "pcs-amundi-session"
+// platform is the name of Amundi in the fund platforms, for the sessions.
+const platform = "Amundi"
+
+// SaveHeaders stores the session headers, one "Name: value" per line, see
+// fundplatform.SaveSession.
+func SaveHeaders(headers []string) error {
+	return fundplatform.SaveSession(platform, headers)
+}
 
+// LoadHeaders loads the session headers stored by SaveHeaders.
 func LoadHeaders() (http.Header, error) {
-	sessionPath := filepath.Join(os.TempDir(), amundiSessionFile)
-	headerData, err := os.ReadFile(sessionPath)
+	headers, err := fundplatform.LoadSession(platform)
 	if err != nil {
 		return nil, fmt.Errorf("amundi session not found. Please run 'pcs amundi login' first: %w", err)
 	}
-
-	headers := make(http.Header)
-	scanner := bufio.NewScanner(strings.NewReader(string(headerData)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) == 2 {
-			headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-		}
-	}
 	return headers, nil
 }
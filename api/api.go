@@ -0,0 +1,165 @@
+// Package api is the stable, read-only API of the portfolio library, for
+// building tools on top of the ledgers: dashboards, exports, editors.
+//
+// It exposes accessors for the ledgers, their snapshots on a date, their
+// reviews over a period, and their market data, without any way to modify
+// them. The types of the values (dates, amounts, quantities, transactions...)
+// are those of the portfolio package.
+//
+// # Stability
+//
+// The package follows semantic versioning: within a major version, its
+// exported identifiers are neither removed nor changed in an incompatible way,
+// and the meaning of the values they return is kept. New accessors may be
+// added in minor versions. The rest of the library carries no such guarantee,
+// and may change as pcs evolves.
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/etnz/portfolio"
+)
+
+// Value types, shared with the portfolio package.
+type (
+	Date            = portfolio.Date
+	Range           = portfolio.Range
+	Period          = portfolio.Period
+	Money           = portfolio.Money
+	Quantity        = portfolio.Quantity
+	Percent         = portfolio.Percent
+	ID              = portfolio.ID
+	Security        = portfolio.Security
+	Transaction     = portfolio.Transaction
+	CostBasisMethod = portfolio.CostBasisMethod
+)
+
+// Cost basis methods.
+const (
+	AverageCost = portfolio.AverageCost
+	FIFO        = portfolio.FIFO
+)
+
+// Periods.
+const (
+	Daily     = portfolio.Daily
+	Weekly    = portfolio.Weekly
+	Monthly   = portfolio.Monthly
+	Quarterly = portfolio.Quarterly
+	Yearly    = portfolio.Yearly
+)
+
+// Functions to create and parse values.
+var (
+	NewDate    = portfolio.NewDate
+	Today      = portfolio.Today
+	ParseDate  = portfolio.ParseDate
+	NewRange   = portfolio.NewRange
+	ParseRange = portfolio.ParseRange
+)
+
+// Ledger is a read-only ledger.
+type Ledger struct {
+	l *portfolio.Ledger
+}
+
+// Open opens a ledger of a portfolio directory by name, its relative path
+// without the .jsonl extension, or the only ledger if the name is empty.
+func Open(path, name string) (*Ledger, error) {
+	ledgers, err := portfolio.FindLedgers(context.Background(), path, name)
+	if err != nil {
+		return nil, err
+	}
+	switch len(ledgers) {
+	case 0:
+		return nil, fmt.Errorf("could not find ledger %q in %q", name, path)
+	case 1:
+		return &Ledger{l: ledgers[0]}, nil
+	default:
+		return nil, fmt.Errorf("multiple ledgers found for %q in %q", name, path)
+	}
+}
+
+// OpenAll opens all the ledgers of a portfolio directory.
+func OpenAll(path string) ([]*Ledger, error) {
+	ledgers, err := portfolio.FindLedgers(context.Background(), path, "")
+	if err != nil {
+		return nil, err
+	}
+	all := make([]*Ledger, len(ledgers))
+	for i, l := range ledgers {
+		all[i] = &Ledger{l: l}
+	}
+	return all, nil
+}
+
+// Read reads a ledger from its JSON Lines content.
+func Read(r io.Reader) (*Ledger, error) {
+	l, err := portfolio.DecodeLedger(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Ledger{l: l}, nil
+}
+
+// Name returns the name of the ledger, its relative path in the portfolio
+// directory without the .jsonl extension.
+func (l *Ledger) Name() string { return l.l.Name() }
+
+// Currency returns the reporting currency of the ledger.
+func (l *Ledger) Currency() string { return l.l.Currency() }
+
+// Inception returns the date of the first transaction of the ledger.
+func (l *Ledger) Inception() Date { return l.l.GlobalInceptionDate() }
+
+// Securities returns the securities declared in the ledger, sorted by ticker.
+func (l *Ledger) Securities() []Security {
+	var securities []Security
+	for sec := range l.l.AllSecurities() {
+		securities = append(securities, sec)
+	}
+	return securities
+}
+
+// Security returns the security declared with a ticker.
+func (l *Ledger) Security(ticker string) (Security, bool) {
+	sec := l.l.Security(ticker)
+	if sec == nil {
+		return Security{}, false
+	}
+	return *sec, true
+}
+
+// Transactions returns the transactions of the ledger in chronological order.
+func (l *Ledger) Transactions() []Transaction {
+	return l.l.Query().Collect()
+}
+
+// Snapshot returns the state of the ledger at the end of a day.
+func (l *Ledger) Snapshot(on Date) *Snapshot {
+	return &Snapshot{s: l.l.NewSnapshot(on)}
+}
+
+// Review returns the review of the ledger over a period.
+func (l *Ledger) Review(r Range) *Review {
+	return &Review{r: l.l.NewReview(r)}
+}
+
+// Price is a price of a security on a date.
+type Price struct {
+	Date  Date
+	Price Money
+}
+
+// Prices returns the prices of a security known in the ledger over a period,
+// in chronological order.
+func (l *Ledger) Prices(ticker string, r Range) []Price {
+	var prices []Price
+	for on, price := range l.l.PriceHistory(ticker, r) {
+		prices = append(prices, Price{Date: on, Price: price})
+	}
+	return prices
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const ledgerJSONL = `{"command":"init","date":"2025-01-01","currency":"EUR"}
+{"command":"declare","date":"2025-01-01","ticker":"VWCE","id":"IE00BK5BQT80.XETR","currency":"EUR"}
+{"command":"deposit","date":"2025-01-02","currency":"EUR","amount":2000}
+{"command":"buy","date":"2025-01-03","security":"VWCE","quantity":10,"currency":"EUR","amount":1200}
+{"command":"update-price","date":"2025-01-31","prices":{"VWCE":125}}
+`
+
+func TestLedger(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.jsonl"), []byte(ledgerJSONL), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := Open(dir, "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := ledger.Name(); got != "main" {
+		t.Errorf("Name() = %q, want main", got)
+	}
+	if _, err := Open(dir, "other"); err == nil {
+		t.Error("Open() of an unknown ledger succeeded, want an error")
+	}
+	if got := ledger.Securities(); len(got) != 1 || got[0].Ticker() != "VWCE" {
+		t.Errorf("Securities() = %v, want VWCE", got)
+	}
+	if got := len(ledger.Transactions()); got != 5 {
+		t.Errorf("Transactions() = %d transactions, want 5", got)
+	}
+
+	s := ledger.Snapshot(NewDate(2025, 1, 31))
+	if got := s.TotalPortfolio().String(); got != "€2,050.00" {
+		t.Errorf("TotalPortfolio() = %s, want €2,050.00", got)
+	}
+	if got := s.CostBasis("VWCE", FIFO).String(); got != "€1,200.00" {
+		t.Errorf("CostBasis(VWCE) = %s, want €1,200.00", got)
+	}
+
+	r := ledger.Review(NewRange(NewDate(2025, 1, 1), NewDate(2025, 1, 31)))
+	if got := r.CashFlow().String(); got != "€2,000.00" {
+		t.Errorf("CashFlow() = %s, want €2,000.00", got)
+	}
+	if got := r.MarketGain().String(); got != "€50.00" {
+		t.Errorf("MarketGain() = %s, want €50.00", got)
+	}
+
+	prices := ledger.Prices("VWCE", NewRange(NewDate(2025, 1, 1), NewDate(2025, 1, 31)))
+	if len(prices) == 0 || prices[len(prices)-1].Price.String() != "€125.00" {
+		t.Errorf("Prices(VWCE) = %v, want to end at €125.00", prices)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import "github.com/etnz/portfolio"
+
+// Review is the performance of a ledger over a period. The amounts are in the
+// reporting currency.
+type Review struct {
+	r *portfolio.Review
+}
+
+// Range returns the period of the review.
+func (r *Review) Range() Range { return r.r.Range() }
+
+// Start returns the snapshot at the start of the period, the end of the day
+// before it.
+func (r *Review) Start() *Snapshot { return &Snapshot{s: r.r.Start()} }
+
+// End returns the snapshot at the end of the period.
+func (r *Review) End() *Snapshot { return &Snapshot{s: r.r.End()} }
+
+// Transactions returns the transactions of the period in chronological order.
+func (r *Review) Transactions() []Transaction { return r.r.Transactions() }
+
+// CashFlow returns the net amount deposited (positive) or withdrawn (negative).
+func (r *Review) CashFlow() Money { return r.r.CashFlow() }
+
+// PortfolioChange returns the change of the total value of the portfolio.
+func (r *Review) PortfolioChange() Money { return r.r.PortfolioChange() }
+
+// MarketGain returns the gain from the change of the market prices.
+func (r *Review) MarketGain() Money { return r.r.MarketGain() }
+
+// Dividends returns the dividends received.
+func (r *Review) Dividends() Money { return r.r.Dividends() }
+
+// RealizedGains returns the gains realized by the sales.
+func (r *Review) RealizedGains(method CostBasisMethod) Money { return r.r.RealizedGains(method) }
+
+// TotalReturn returns the market gain plus the income of the period.
+func (r *Review) TotalReturn() Money { return r.r.TotalReturn() }
+
+// TimeWeightedReturn returns the time-weighted return of the portfolio.
+func (r *Review) TimeWeightedReturn() Percent { return r.r.TimeWeightedReturn() }
+
+// AssetTimeWeightedReturn returns the time-weighted return of a security.
+func (r *Review) AssetTimeWeightedReturn(ticker string) Percent {
+	return r.r.AssetTimeWeightedReturn(ticker)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/etnz/portfolio"
+)
+
+// Snapshot is the state of a ledger at the end of a day. The totals are in the
+// reporting currency, the values of a security in its currency.
+type Snapshot struct {
+	s *portfolio.Snapshot
+}
+
+// Date returns the date of the snapshot.
+func (s *Snapshot) Date() Date { return s.s.On() }
+
+// Currency returns the reporting currency.
+func (s *Snapshot) Currency() string { return s.s.ReportingCurrency() }
+
+// Securities returns the tickers of the securities declared on the date.
+func (s *Snapshot) Securities() []string { return slices.Collect(s.s.Securities()) }
+
+// Currencies returns the currencies used on the date.
+func (s *Snapshot) Currencies() []string { return slices.Collect(s.s.Currencies()) }
+
+// Position returns the quantity of a security held.
+func (s *Snapshot) Position(ticker string) Quantity { return s.s.Position(ticker) }
+
+// Price returns the last known price of a security.
+func (s *Snapshot) Price(ticker string) Money { return s.s.Price(ticker) }
+
+// MarketValue returns the market value of the position in a security.
+func (s *Snapshot) MarketValue(ticker string) Money { return s.s.MarketValue(ticker) }
+
+// CostBasis returns the cost basis of the position in a security.
+func (s *Snapshot) CostBasis(ticker string, method CostBasisMethod) Money {
+	return s.s.CostBasis(ticker, method)
+}
+
+// UnrealizedGains returns the unrealized gains of the position in a security.
+func (s *Snapshot) UnrealizedGains(ticker string, method CostBasisMethod) Money {
+	return s.s.UnrealizedGains(ticker, method)
+}
+
+// Cash returns the cash balance in a currency.
+func (s *Snapshot) Cash(currency string) Money { return s.s.Cash(currency) }
+
+// Convert converts an amount to the reporting currency, at the exchange rates
+// of the date.
+func (s *Snapshot) Convert(amount Money) Money { return s.s.Convert(amount) }
+
+// TotalMarket returns the market value of all the positions.
+func (s *Snapshot) TotalMarket() Money { return s.s.TotalMarket() }
+
+// TotalCash returns the value of all the cash balances.
+func (s *Snapshot) TotalCash() Money { return s.s.TotalCash() }
+
+// TotalPortfolio returns the total value of the portfolio.
+func (s *Snapshot) TotalPortfolio() Money { return s.s.TotalPortfolio() }
@@ -0,0 +1,168 @@
+package portfolio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AuditCommand is the command line recorded in the audit log of the ledgers
+// saved by this process.
+var AuditCommand string
+
+// AuditEntry records a modification of a ledger: the transactions added and
+// removed by a single save. A transaction modified in place, like a price
+// merged by a market data update, is both removed and added.
+type AuditEntry struct {
+	Time    time.Time         `json:"time"`
+	Command string            `json:"command,omitempty"`
+	Added   []json.RawMessage `json:"added,omitempty"`
+	Removed []json.RawMessage `json:"removed,omitempty"`
+}
+
+// auditFile returns the path of the audit log of a ledger file.
+func auditFile(ledgerFile string) string {
+	return strings.TrimSuffix(ledgerFile, ".jsonl") + ".audit.jsonl"
+}
+
+// isAuditFile returns true if the file is the audit log of a ledger.
+func isAuditFile(file string) bool { return strings.HasSuffix(file, ".audit.jsonl") }
+
+// ReadAuditLog returns the audit log of a ledger, oldest first. A ledger
+// without audit log has no entry.
+func ReadAuditLog(path string, ledger *Ledger) ([]AuditEntry, error) {
+	f, err := os.Open(auditFile(filepath.Join(path, ledger.Name()+".jsonl")))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, &LineError{Line: line, Err: err}
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// LedgerChanges compares the transactions saved in the portfolio for the
+// ledger with its current ones, and returns the difference as an audit entry
+// for the current command.
+func LedgerChanges(path string, ledger *Ledger) (AuditEntry, error) {
+	return ledgerChanges(filepath.Join(path, ledger.Name()+".jsonl"), ledger)
+}
+
+// ledgerChanges compares the transactions saved in the ledger file with the
+// current ones of the ledger.
+func ledgerChanges(filePath string, ledger *Ledger) (AuditEntry, error) {
+	ledger = ledger.view()
+	e := AuditEntry{Time: time.Now().UTC().Truncate(time.Second), Command: AuditCommand}
+	saved, err := savedLines(filePath)
+	if err != nil {
+		return e, err
+	}
+	count := make(map[string]int)
+	for _, line := range saved {
+		count[line]++
+	}
+	kept := make(map[string]int)
+	for _, tx := range ledger.transactions {
+		line, err := encodeLine(tx)
+		if err != nil {
+			return e, err
+		}
+		if kept[line] < count[line] {
+			kept[line]++
+			continue
+		}
+		e.Added = append(e.Added, json.RawMessage(line))
+	}
+	for _, line := range saved {
+		if kept[line] > 0 {
+			kept[line]--
+			continue
+		}
+		e.Removed = append(e.Removed, json.RawMessage(line))
+	}
+	return e, nil
+}
+
+// appendAuditEntry appends the changes of a ledger since its last save, see
+// ledgerChanges, to the audit log of the ledger file.
+func appendAuditEntry(filePath string, e AuditEntry) error {
+	if len(e.Added) == 0 && len(e.Removed) == 0 {
+		return nil
+	}
+
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode audit entry: %w", err)
+	}
+	f, err := os.OpenFile(auditFile(filePath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(entry, '\n')); err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	return nil
+}
+
+// savedLines returns the encoded transactions of a ledger file, or nil if the
+// file does not exist yet.
+func savedLines(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open ledger file %q: %w", filePath, err)
+	}
+	defer f.Close()
+	ledger, err := NewDecoder(f).decodeLedger()
+	if err != nil {
+		return nil, fmt.Errorf("could not decode ledger file %q: %w", filePath, err)
+	}
+	if info, err := os.Stat(pricesDir(filePath)); err == nil && info.IsDir() {
+		prices, err := decodePriceFiles(pricesDir(filePath))
+		if err != nil {
+			return nil, err
+		}
+		ledger.transactions = append(ledger.transactions, prices...)
+	}
+
+	lines := make([]string, 0, len(ledger.transactions))
+	for _, tx := range ledger.transactions {
+		line, err := encodeLine(tx)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// encodeLine returns the canonical JSON encoding of a transaction, without newline.
+func encodeLine(tx Transaction) (string, error) {
+	var b bytes.Buffer
+	if err := EncodeTransaction(&b, tx); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
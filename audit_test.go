@@ -0,0 +1,94 @@
+package portfolio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLedger_AuditLog(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewUpdatePrice(NewDate(2025, 1, 2), "AAPL", USD(100)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	AuditCommand = "pcs declare"
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+
+	// The audit log is not a ledger.
+	ledger, err = FindLedger(context.Background(), dir, "")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	// A price merged in place is both removed and added.
+	if _, err := ledger.UpdateMarketData(NewUpdatePrice(NewDate(2025, 1, 2), "AAPL", USD(101))); err != nil {
+		t.Fatalf("UpdateMarketData() error = %v", err)
+	}
+	// Changes can be previewed without saving.
+	if c, err := LedgerChanges(dir, ledger); err != nil || len(c.Added) != 1 || len(c.Removed) != 1 {
+		t.Errorf("LedgerChanges() = %+v, %v, want 1 transaction replaced", c, err)
+	}
+	AuditCommand = "pcs eodhd fetch"
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	// Saving an unchanged ledger records nothing.
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	AuditCommand = ""
+
+	entries, err := ReadAuditLog(dir, ledger)
+	if err != nil {
+		t.Fatalf("ReadAuditLog() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ReadAuditLog() = %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Command != "pcs declare" || len(e.Added) != 2 || len(e.Removed) != 0 {
+		t.Errorf("entries[0] = %+v, want 2 transactions added by pcs declare", e)
+	}
+	e := entries[1]
+	if e.Command != "pcs eodhd fetch" || len(e.Added) != 1 || len(e.Removed) != 1 {
+		t.Fatalf("entries[1] = %+v, want 1 transaction replaced by pcs eodhd fetch", e)
+	}
+	if !strings.Contains(string(e.Removed[0]), "100") || !strings.Contains(string(e.Added[0]), "101") {
+		t.Errorf("entries[1] removed %s and added %s, want the price 100 replaced by 101", e.Removed[0], e.Added[0])
+	}
+}
+
+func TestSaveLedger_AuditLogFailedSave(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	ledger.SetSplitPrices(true)
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewUpdatePrice(NewDate(2025, 1, 2), "AAPL", USD(100)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	// A file in place of the price directory makes the save fail.
+	if err := os.WriteFile(filepath.Join(dir, "ledger.prices"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveLedger(dir, ledger); err == nil {
+		t.Fatal("SaveLedger() = nil, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ledger.audit.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("audit log of a failed save exists, want none: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ledger.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("ledger file of a failed save exists, want none: %v", err)
+	}
+}
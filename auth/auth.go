@@ -0,0 +1,191 @@
+// Package auth implements the credential store used by market data providers.
+//
+// Credentials are stored in the OS keyring when one is available:
+//   - on macOS, the login Keychain through the "security" tool;
+//   - on Linux, the Secret Service (GNOME Keyring, KWallet) through the
+//     "secret-tool" tool.
+//
+// The keyring always takes precedence. When a credential is not in the keyring,
+// or no keyring is available, it is read from the provider's environment
+// variable in plain text (e.g. EODHD_API_KEY).
+package auth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a credential is neither in the keyring nor in the environment.
+	ErrNotFound = errors.New("credential not found")
+	// ErrNoKeyring is returned when no keyring is available on this system.
+	ErrNoKeyring = errors.New("no keyring available on this system")
+)
+
+// service is the keyring service under which all credentials are stored.
+const service = "pcs"
+
+// Provider describes the credential of a market data provider.
+type Provider struct {
+	Name        string // Name is the provider name, also used as the keyring account.
+	Env         string // Env is the environment variable used as a fallback.
+	Description string
+}
+
+// Providers lists the providers that use the credential store.
+var Providers = []Provider{
+	{Name: "eodhd", Env: "EODHD_API_KEY", Description: "EODHD.com API key"},
+	{Name: "amundi", Env: "PCS_AMUNDI_SESSION", Description: "Amundi session headers, see 'pcs amundi login'"},
+	{Name: "ibflex", Env: "IBFLEX_TOKEN", Description: "Interactive Brokers Flex Web Service token"},
+}
+
+// Register adds a provider to Providers, unless a provider of that name is
+// already registered. It is used by the providers configured at run time, e.g.
+// the fund platforms.
+func Register(p Provider) {
+	if _, err := Lookup(p.Name); err == nil {
+		return
+	}
+	Providers = append(Providers, p)
+}
+
+// Lookup returns the provider with that name.
+func Lookup(name string) (Provider, error) {
+	for _, p := range Providers {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	names := make([]string, len(Providers))
+	for i, p := range Providers {
+		names[i] = p.Name
+	}
+	return Provider{}, fmt.Errorf("unknown provider %q, valid providers are: %s", name, strings.Join(names, ", "))
+}
+
+// Keyring stores secrets.
+type Keyring interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// DefaultKeyring is the system keyring.
+var DefaultKeyring Keyring = systemKeyring{}
+
+// Get returns the credential of a provider, from the keyring, or from its
+// environment variable.
+func Get(provider string) (string, error) {
+	p, err := Lookup(provider)
+	if err != nil {
+		return "", err
+	}
+	if secret, err := DefaultKeyring.Get(p.Name); err == nil {
+		return secret, nil
+	}
+	if secret := os.Getenv(p.Env); secret != "" {
+		return secret, nil
+	}
+	return "", fmt.Errorf("%s: %w, use 'pcs auth set %s' or the %s environment variable", p.Name, ErrNotFound, p.Name, p.Env)
+}
+
+// Source returns where the credential of a provider is found: "keyring", "env" or "".
+func Source(provider string) string {
+	p, err := Lookup(provider)
+	if err != nil {
+		return ""
+	}
+	if _, err := DefaultKeyring.Get(p.Name); err == nil {
+		return "keyring"
+	}
+	if os.Getenv(p.Env) != "" {
+		return "env"
+	}
+	return ""
+}
+
+// Set stores the credential of a provider in the keyring.
+func Set(provider, secret string) error {
+	p, err := Lookup(provider)
+	if err != nil {
+		return err
+	}
+	return DefaultKeyring.Set(p.Name, secret)
+}
+
+// Delete removes the credential of a provider from the keyring.
+func Delete(provider string) error {
+	p, err := Lookup(provider)
+	if err != nil {
+		return err
+	}
+	return DefaultKeyring.Delete(p.Name)
+}
+
+// systemKeyring is the OS keyring, accessed through the platform's command line tool.
+type systemKeyring struct{}
+
+func (systemKeyring) Get(account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := run("", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+		return strings.TrimSuffix(out, "\n"), err
+	case "linux":
+		out, err := run("", "secret-tool", "lookup", "service", service, "account", account)
+		if err == nil && out == "" {
+			return "", ErrNotFound
+		}
+		return out, err
+	}
+	return "", ErrNoKeyring
+}
+
+func (systemKeyring) Set(account, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// The secret is never an argument, any user can read those from the
+		// process list: the command is read by security from stdin, with the
+		// secret in hexadecimal so that it needs no quoting.
+		cmd := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n", service, account, hex.EncodeToString([]byte(secret)))
+		_, err := run(cmd, "security", "-i")
+		return err
+	case "linux":
+		_, err := run(secret, "secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		return err
+	}
+	return ErrNoKeyring
+}
+
+func (systemKeyring) Delete(account string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := run("", "security", "delete-generic-password", "-s", service, "-a", account)
+		return err
+	case "linux":
+		_, err := run("", "secret-tool", "clear", "service", service, "account", account)
+		return err
+	}
+	return ErrNoKeyring
+}
+
+// run executes a keyring tool with stdin, and returns its output.
+func run(stdin string, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s not found", ErrNoKeyring, name)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
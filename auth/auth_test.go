@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+// memoryKeyring is an in-memory Keyring for tests.
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(account string) (string, error) {
+	if s, ok := k[account]; ok {
+		return s, nil
+	}
+	return "", ErrNotFound
+}
+func (k memoryKeyring) Set(account, secret string) error { k[account] = secret; return nil }
+func (k memoryKeyring) Delete(account string) error      { delete(k, account); return nil }
+
+func TestGet(t *testing.T) {
+	saved := DefaultKeyring
+	defer func() { DefaultKeyring = saved }()
+	DefaultKeyring = memoryKeyring{}
+
+	t.Setenv("EODHD_API_KEY", "")
+	if _, err := Get("eodhd"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if got := Source("eodhd"); got != "" {
+		t.Errorf("Source() = %q, want none", got)
+	}
+
+	t.Setenv("EODHD_API_KEY", "from-env")
+	if got, err := Get("eodhd"); err != nil || got != "from-env" {
+		t.Errorf("Get() = %q, %v, want from-env", got, err)
+	}
+	if got := Source("eodhd"); got != "env" {
+		t.Errorf("Source() = %q, want env", got)
+	}
+
+	// The keyring takes precedence over the environment.
+	if err := Set("eodhd", "from-keyring"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := Get("eodhd"); err != nil || got != "from-keyring" {
+		t.Errorf("Get() = %q, %v, want from-keyring", got, err)
+	}
+	if got := Source("eodhd"); got != "keyring" {
+		t.Errorf("Source() = %q, want keyring", got)
+	}
+
+	if err := Delete("eodhd"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, _ := Get("eodhd"); got != "from-env" {
+		t.Errorf("Get() after Delete() = %q, want from-env", got)
+	}
+
+	if _, err := Get("unknown"); err == nil {
+		t.Errorf("Get(unknown) expected an error")
+	}
+}
@@ -0,0 +1,114 @@
+package portfolio_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/portfoliotest"
+)
+
+// The benchmarks run on synthetic ledgers of increasing sizes, to make visible
+// the cost of computing every metric by scanning the journal events.
+//
+// Run them with:
+//
+//	go test -run XXX -bench . -benchmem
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+// benchLedgers caches the generated ledgers, by size.
+var benchLedgers = map[int]*portfolio.Ledger{}
+
+func benchLedger(b *testing.B, n int) *portfolio.Ledger {
+	b.Helper()
+	if ledger, ok := benchLedgers[n]; ok {
+		return ledger
+	}
+	// Some metrics log their intermediate results.
+	log.SetOutput(io.Discard)
+	ledger, err := portfoliotest.NewGenerator(1).Ledger(n)
+	if err != nil {
+		b.Fatalf("generating a ledger of %d transactions: %v", n, err)
+	}
+	benchLedgers[n] = ledger
+	return ledger
+}
+
+// runSizes runs f as a sub-benchmark for each ledger size.
+func runSizes(b *testing.B, f func(b *testing.B, ledger *portfolio.Ledger)) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			ledger := benchLedger(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			f(b, ledger)
+		})
+	}
+}
+
+// BenchmarkDecodeLedger measures decoding a ledger, including building its journal.
+func BenchmarkDecodeLedger(b *testing.B) {
+	runSizes(b, func(b *testing.B, ledger *portfolio.Ledger) {
+		b.StopTimer()
+		var buf bytes.Buffer
+		if err := portfolio.EncodeLedger(&buf, ledger); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		for b.Loop() {
+			if _, err := portfolio.DecodeLedger(bytes.NewReader(buf.Bytes())); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkLedger_Append measures recording one more transaction, which
+// rebuilds the journal.
+func BenchmarkLedger_Append(b *testing.B) {
+	runSizes(b, func(b *testing.B, ledger *portfolio.Ledger) {
+		for b.Loop() {
+			if err := ledger.Append(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkSnapshot_TotalPortfolio measures a total metric, computed for each
+// security and currency.
+func BenchmarkSnapshot_TotalPortfolio(b *testing.B) {
+	runSizes(b, func(b *testing.B, ledger *portfolio.Ledger) {
+		on := ledger.NewestTransactionDate()
+		for b.Loop() {
+			ledger.NewSnapshot(on).TotalPortfolio()
+		}
+	})
+}
+
+// BenchmarkSnapshot_CostBasis measures a per-security metric based on lots.
+func BenchmarkSnapshot_CostBasis(b *testing.B) {
+	runSizes(b, func(b *testing.B, ledger *portfolio.Ledger) {
+		on := ledger.NewestTransactionDate()
+		for b.Loop() {
+			ledger.NewSnapshot(on).CostBasis("AAA", portfolio.FIFO)
+		}
+	})
+}
+
+// BenchmarkReview measures the main metrics of a yearly review.
+func BenchmarkReview(b *testing.B) {
+	runSizes(b, func(b *testing.B, ledger *portfolio.Ledger) {
+		period := portfolio.Yearly.Range(ledger.NewestTransactionDate())
+		for b.Loop() {
+			r := ledger.NewReview(period)
+			r.TimeWeightedReturn()
+			r.CashFlow()
+			r.MarketGain()
+			r.Dividends()
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package portfolio
+
+import "fmt"
+
+// OpeningPosition is a position held before the start of a ledger, with its
+// total cost basis, in the currency of the security.
+type OpeningPosition struct {
+	Ticker   string
+	ID       ID
+	Quantity Quantity
+	Cost     Money
+	Acquired Date // Acquired is the acquisition date, the opening date if zero.
+}
+
+// Bootstrap returns a new ledger, in the reporting currency, opened on a date
+// with positions and cash balances.
+//
+// The ledger is initialized on the earliest of the opening and acquisition
+// dates, with the declaration of each security. Each position is transferred
+// in on its acquisition date, as a deposit of its cost followed by its
+// purchase, so that its cost basis and holding period are kept. Each cash
+// balance is deposited on the opening date.
+func Bootstrap(currency string, on Date, positions []OpeningPosition, cash []Money) (*Ledger, error) {
+	if err := ValidateCurrency(currency); err != nil {
+		return nil, err
+	}
+	inception := on
+	for _, p := range positions {
+		if !p.Acquired.IsZero() && p.Acquired.Before(inception) {
+			inception = p.Acquired
+		}
+	}
+
+	ledger := NewLedger()
+	ledger.currency = currency
+	ledger.transactions = append(ledger.transactions, NewInit(inception, "bootstrap", currency))
+	declared := make(map[string]ID)
+	for _, p := range positions {
+		if id, ok := declared[p.Ticker]; ok {
+			if id != p.ID {
+				return nil, fmt.Errorf("ticker %q is used for both %s and %s", p.Ticker, id, p.ID)
+			}
+			continue
+		}
+		declared[p.Ticker] = p.ID
+		ledger.transactions = append(ledger.transactions, NewDeclare(inception, "", p.Ticker, p.ID, p.Cost.Currency()))
+	}
+	for _, p := range positions {
+		acquired := p.Acquired
+		if acquired.IsZero() {
+			acquired = on
+		}
+		ledger.transactions = append(ledger.transactions,
+			NewDeposit(acquired, fmt.Sprintf("bootstrap: transfer in of %s", p.Ticker), p.Cost, ""),
+			NewBuy(acquired, "", p.Ticker, p.Quantity, p.Cost),
+		)
+	}
+	for _, amount := range cash {
+		ledger.transactions = append(ledger.transactions, NewDeposit(on, "opening balance", amount, ""))
+	}
+	// Fmt sorts the transactions stably: each deposit stays before its purchase.
+	return ledger.Fmt()
+}
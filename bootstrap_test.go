@@ -0,0 +1,38 @@
+package portfolio
+
+import "testing"
+
+func TestBootstrap(t *testing.T) {
+	on := NewDate(2025, 6, 1)
+	positions := []OpeningPosition{
+		{Ticker: "AAPL", ID: AAPL, Quantity: Q(10), Cost: USD(1500), Acquired: NewDate(2020, 3, 2)},
+		{Ticker: "MSFT", ID: MSFT, Quantity: Q(5), Cost: USD(1000)},
+	}
+	ledger, err := Bootstrap("EUR", on, positions, []Money{EUR(2000), USD(300)})
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	if got := ledger.OldestTransactionDate(); got != NewDate(2020, 3, 2) {
+		t.Errorf("OldestTransactionDate() = %v, want the earliest acquisition date 2020-03-02", got)
+	}
+
+	s := ledger.NewSnapshot(on)
+	if got := s.Position("AAPL"); !got.Equal(Q(10)) {
+		t.Errorf("Position(AAPL) = %v, want 10", got)
+	}
+	if got := s.CostBasis("MSFT", AverageCost); !got.Equal(USD(1000)) {
+		t.Errorf("CostBasis(MSFT) = %v, want 1000", got)
+	}
+	// The transfers in leave no cash behind: only the opening balances remain.
+	if got := s.Cash("USD"); !got.Equal(USD(300)) {
+		t.Errorf("Cash(USD) = %v, want 300", got)
+	}
+	if got := s.Cash("EUR"); !got.Equal(EUR(2000)) {
+		t.Errorf("Cash(EUR) = %v, want 2000", got)
+	}
+
+	positions = append(positions, OpeningPosition{Ticker: "AAPL", ID: GOOG, Quantity: Q(1), Cost: USD(100)})
+	if _, err := Bootstrap("EUR", on, positions, nil); err == nil {
+		t.Error("Bootstrap() with a ticker used for two securities succeeded, want an error")
+	}
+}
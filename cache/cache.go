@@ -0,0 +1,281 @@
+// Package cache implements the HTTP disk cache shared by all market data providers.
+//
+// Responses are stored in a single directory, one file per request, grouped by
+// provider name. By default the directory is "pcs" under the user's cache
+// directory (e.g. $XDG_CACHE_HOME/pcs on Linux). It can be overridden with the
+// PCS_CACHE_DIR environment variable.
+//
+// Each client is created with a time-to-live expressed as a portfolio.Period: an
+// entry is fresh as long as it has been stored in the same period as today. For
+// instance, a Daily client refetches every day, a Monthly client every month.
+// The time-to-live is chosen per endpoint by the providers, which use a client
+// of each time-to-live they need, e.g. a Monthly one for the ticker lists that
+// rarely change and a Daily one for the prices, splits and dividends, that can
+// be published any day.
+//
+// In offline mode (see network.Offline), expired entries are still served.
+//
+// The total size of the cache is bounded by MaxSize. The cache is pruned once
+// per process, after its first new entry, and by Prune: the oldest entries are
+// evicted first.
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/network"
+)
+
+const (
+	envDir     = "PCS_CACHE_DIR"
+	envMaxSize = "PCS_CACHE_MAX_SIZE"
+
+	// DefaultMaxSize is the default maximum size of the cache in bytes.
+	DefaultMaxSize int64 = 100 << 20
+)
+
+// Dir returns the cache directory.
+//
+// It follows this order of precedence:
+// 1. PCS_CACHE_DIR environment variable
+// 2. "pcs" folder in the user cache directory
+// 3. "pcs-cache" folder in the temporary directory
+func Dir() string {
+	if dir := os.Getenv(envDir); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "pcs")
+	}
+	return filepath.Join(os.TempDir(), "pcs-cache")
+}
+
+// MaxSize returns the maximum size of the cache in bytes.
+//
+// It can be configured with the PCS_CACHE_MAX_SIZE environment variable, in bytes.
+func MaxSize() int64 {
+	if s := os.Getenv(envMaxSize); s != "" {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("invalid %s value %q (ignored)", envMaxSize, s)
+	}
+	return DefaultMaxSize
+}
+
+// Transport implements an http.RoundTripper that stores successful responses on disk.
+type Transport struct {
+	Base     http.RoundTripper // Base is the underlying transport, network.DefaultTransport if nil.
+	Provider string            // Provider is the name of the folder where entries are stored.
+	TTL      portfolio.Period  // TTL is the period during which an entry is fresh.
+}
+
+// NewClient returns an http.Client caching responses for the given provider, with
+// entries valid for the given period.
+func NewClient(provider string, ttl portfolio.Period) *http.Client {
+	return &http.Client{Transport: &Transport{Provider: provider, TTL: ttl}}
+}
+
+// RoundTrip implements the http.RoundTripper interface. It checks for a fresh cached
+// response on disk first. If none is found, it proceeds with the actual HTTP request
+// and caches the new response if it's successful.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	file := t.path(req)
+	if resp, err := t.get(file, req); err == nil {
+		return resp, nil
+	}
+
+	base := t.Base
+	if base == nil {
+		base = network.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%v %v/%v %v", resp.Request.Method, resp.Request.URL.Host, resp.Request.URL.Path, resp.Status)
+	if resp.StatusCode >= 300 || req.Method != http.MethodGet {
+		return resp, nil
+	}
+
+	if err := put(file, resp); err != nil {
+		log.Printf("cache write err (ignored): %v\n", err)
+	}
+	pruneOnce.Do(func() {
+		if err := Prune(MaxSize()); err != nil {
+			log.Printf("cache prune err (ignored): %v\n", err)
+		}
+	})
+	return resp, nil
+}
+
+// pruneOnce prunes the cache once per process: walking the cache directory on
+// every new entry would make a fetch of many securities quadratic.
+var pruneOnce sync.Once
+
+// path returns the file path of the entry for req.
+func (t *Transport) path(req *http.Request) string {
+	key := fmt.Sprintf("%s %s", req.Method, req.URL.String())
+	return filepath.Join(Dir(), t.Provider, fmt.Sprintf("%x", sha1.Sum([]byte(key))))
+}
+
+// get retrieves a fresh cached response from disk.
+func (t *Transport) get(file string, req *http.Request) (*http.Response, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	// In offline mode, a stale entry is better than none.
+	if !network.Offline && !t.TTL.Range(portfolio.Today()).Contains(portfolio.NewDate(info.ModTime().Date())) {
+		return nil, fmt.Errorf("cache entry %q has expired", file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewBuffer(content)), req)
+}
+
+// put stores a response to disk.
+func put(file string, resp *http.Response) error {
+	content, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0o644)
+}
+
+// entry is a file in the cache.
+type entry struct {
+	path     string
+	provider string
+	size     int64
+	modTime  time.Time
+}
+
+// entries lists all the files in the cache directory.
+func entries() ([]entry, error) {
+	root := Dir()
+	var list []entry
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		provider, _ := filepath.Rel(root, filepath.Dir(path))
+		list = append(list, entry{path: path, provider: provider, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return list, err
+}
+
+// Prune removes the oldest entries until the cache size is at most maxSize bytes.
+func Prune(maxSize int64) error {
+	list, err := entries()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, e := range list {
+		total += e.size
+	}
+	if total <= maxSize {
+		return nil
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].modTime.Before(list[j].modTime) })
+	for _, e := range list {
+		if total <= maxSize {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			return err
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+// Clear removes all the entries of a provider. The provider is the name of a
+// folder of the cache, it cannot be empty, nor a path.
+func Clear(provider string) error {
+	if provider == "" || provider == "." || strings.ContainsAny(provider, `/\`) || strings.Contains(provider, "..") {
+		return fmt.Errorf("invalid provider name %q", provider)
+	}
+	root := filepath.Clean(Dir())
+	dir := filepath.Join(root, provider)
+	if rel, err := filepath.Rel(root, dir); err != nil || rel != provider {
+		return fmt.Errorf("invalid provider name %q: not in the cache directory", provider)
+	}
+	return os.RemoveAll(dir)
+}
+
+// ClearAll removes all the entries of the cache.
+func ClearAll() error {
+	return os.RemoveAll(Dir())
+}
+
+// ProviderStats holds statistics about the entries of a provider.
+type ProviderStats struct {
+	Provider string
+	Entries  int
+	Size     int64
+	Oldest   time.Time
+	Newest   time.Time
+}
+
+// Stats returns statistics for each provider in the cache, sorted by provider name.
+func Stats() ([]ProviderStats, error) {
+	list, err := entries()
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*ProviderStats)
+	for _, e := range list {
+		s, ok := stats[e.provider]
+		if !ok {
+			s = &ProviderStats{Provider: e.provider, Oldest: e.modTime, Newest: e.modTime}
+			stats[e.provider] = s
+		}
+		s.Entries++
+		s.Size += e.size
+		if e.modTime.Before(s.Oldest) {
+			s.Oldest = e.modTime
+		}
+		if e.modTime.After(s.Newest) {
+			s.Newest = e.modTime
+		}
+	}
+	res := make([]ProviderStats, 0, len(stats))
+	for _, s := range stats {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Provider < res[j].Provider })
+	return res, nil
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etnz/portfolio"
+)
+
+func TestTransport(t *testing.T) {
+	t.Setenv(envDir, t.TempDir())
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	get := func() string {
+		t.Helper()
+		resp, err := NewClient("test", portfolio.Daily).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if got, want := get(), "hello"; got != want {
+		t.Errorf("first Get() = %q, want %q", got, want)
+	}
+	if got, want := get(), "hello"; got != want {
+		t.Errorf("cached Get() = %q, want %q", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("server calls = %d, want 1", calls)
+	}
+
+	// Age the entry to yesterday: it must be refetched.
+	stats, err := Stats()
+	if err != nil || len(stats) != 1 || stats[0].Entries != 1 {
+		t.Fatalf("Stats() = %v, %v, want a single entry", stats, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(Dir(), "test", "*"))
+	yesterday := time.Now().Add(-24 * time.Hour)
+	for _, f := range files {
+		os.Chtimes(f, yesterday, yesterday)
+	}
+	get()
+	if calls != 2 {
+		t.Errorf("server calls after expiry = %d, want 2", calls)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv(envDir, t.TempDir())
+	now := time.Now()
+	for i, name := range []string{"a/old", "b/mid", "a/new"} {
+		file := filepath.Join(Dir(), name)
+		os.MkdirAll(filepath.Dir(file), 0o755)
+		if err := os.WriteFile(file, make([]byte, 10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mod := now.Add(time.Duration(i-3) * time.Hour)
+		os.Chtimes(file, mod, mod)
+	}
+
+	if err := Prune(20); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "a/old")); !os.IsNotExist(err) {
+		t.Errorf("oldest entry has not been pruned")
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "a/new")); err != nil {
+		t.Errorf("newest entry has been pruned: %v", err)
+	}
+
+	if err := Clear("b"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	stats, _ := Stats()
+	if len(stats) != 1 || stats[0].Provider != "a" || stats[0].Size != 10 {
+		t.Errorf("Stats() = %+v, want only provider a with 10 bytes", stats)
+	}
+	for _, provider := range []string{"", ".", "..", "../..", "a/..", "/tmp", `a\b`} {
+		if err := Clear(provider); err == nil {
+			t.Errorf("Clear(%q) = nil, want an error", provider)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "a/new")); err != nil {
+		t.Errorf("entry removed by an invalid Clear(): %v", err)
+	}
+	if err := ClearAll(); err != nil {
+		t.Fatalf("ClearAll() error = %v", err)
+	}
+	if stats, _ := Stats(); len(stats) != 0 {
+		t.Errorf("Stats() after ClearAll() = %+v, want none", stats)
+	}
+}
@@ -0,0 +1,267 @@
+package portfolio
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// calendar returns the public holidays of an exchange during a year, the days
+// it does not trade besides its weekend.
+//
+// The calendars embed the rules of the holidays common to all years: fixed
+// dates, Easter based dates and "n-th weekday of the month" dates. Exceptional
+// closures, e.g. national days of mourning, are not known.
+type calendar func(year int) []Date
+
+// IsTradingDay reports whether the exchange trades on a day: neither its
+// weekend nor a holiday.
+func (e Exchange) IsTradingDay(day Date) bool {
+	return !e.isWeekend(day) && !e.IsHoliday(day)
+}
+
+// IsHoliday reports whether the exchange does not trade on a day of the week
+// it usually trades.
+func (e Exchange) IsHoliday(day Date) bool {
+	if e.holidays == nil || e.isWeekend(day) {
+		return false
+	}
+	return slices.Contains(e.holidays(day.Year()), day)
+}
+
+// Holidays returns the holidays of the exchange during a year, in
+// chronological order.
+func (e Exchange) Holidays(year int) []Date {
+	var days []Date
+	if e.holidays != nil {
+		for _, day := range e.holidays(year) {
+			if !e.isWeekend(day) && !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+	}
+	slices.SortFunc(days, Date.Compare)
+	return days
+}
+
+// isWeekend reports whether a day is in the weekend of the exchange.
+func (e Exchange) isWeekend(day Date) bool {
+	if e.Weekend == nil {
+		return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+	}
+	return slices.Contains(e.Weekend, day.Weekday())
+}
+
+// easter returns the date of Easter Sunday in the Gregorian calendar, see
+// https://en.wikipedia.org/wiki/Date_of_Easter#Anonymous_Gregorian_algorithm.
+func easter(year int) Date {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return NewDate(year, time.Month(month), day)
+}
+
+// nthWeekday returns the n-th weekday of a month, e.g. the third Monday of
+// January, or the last one if n is -1.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) Date {
+	if n < 0 {
+		last := NewDate(year, month+1, 0)
+		return last.Add(-(int(last.Weekday()-weekday) + 7) % 7)
+	}
+	first := NewDate(year, month, 1)
+	return first.Add((int(weekday-first.Weekday())+7)%7 + 7*(n-1))
+}
+
+// observed moves a holiday on a Saturday to the Friday before, and on a Sunday
+// to the Monday after, as in the United States.
+func observed(day Date) Date {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.Add(-1)
+	case time.Sunday:
+		return day.Add(1)
+	}
+	return day
+}
+
+// substitute moves the holidays on a weekend to the next weekdays that are not
+// holidays yet, as in the United Kingdom and Canada.
+func substitute(days ...Date) []Date {
+	var moved []Date
+	for _, holiday := range days {
+		day := holiday
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || slices.Contains(moved, day) || day != holiday && slices.Contains(days, day) {
+			day = day.Add(1)
+		}
+		moved = append(moved, day)
+	}
+	return moved
+}
+
+// nyseHolidays are the holidays of the New York Stock Exchange and Nasdaq.
+func nyseHolidays(year int) []Date {
+	days := []Date{
+		nthWeekday(year, time.January, time.Monday, 3),  // Martin Luther King Jr. Day.
+		nthWeekday(year, time.February, time.Monday, 3), // Washington's Birthday.
+		easter(year).Add(-2),                            // Good Friday.
+		nthWeekday(year, time.May, time.Monday, -1),     // Memorial Day.
+		observed(NewDate(year, time.July, 4)),
+		nthWeekday(year, time.September, time.Monday, 1),  // Labor Day.
+		nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving.
+		observed(NewDate(year, time.December, 25)),
+	}
+	// New Year's Day on a Saturday is not observed on the last trading day of
+	// the year before.
+	if newYear := NewDate(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		days = append(days, observed(newYear))
+	}
+	if year >= 2022 {
+		days = append(days, observed(NewDate(year, time.June, 19))) // Juneteenth.
+	}
+	return days
+}
+
+// tsxHolidays are the holidays of the Toronto Stock Exchange.
+func tsxHolidays(year int) []Date {
+	return append(substitute(
+		NewDate(year, time.January, 1),
+		NewDate(year, time.July, 1), // Canada Day.
+		NewDate(year, time.December, 25),
+		NewDate(year, time.December, 26),
+	),
+		nthWeekday(year, time.February, time.Monday, 3), // Family Day.
+		easter(year).Add(-2),
+		victoriaDay(year),
+		nthWeekday(year, time.August, time.Monday, 1),    // Civic Holiday.
+		nthWeekday(year, time.September, time.Monday, 1), // Labour Day.
+		nthWeekday(year, time.October, time.Monday, 2),   // Thanksgiving.
+	)
+}
+
+// victoriaDay returns the Monday before May 25.
+func victoriaDay(year int) Date {
+	day := NewDate(year, time.May, 24)
+	return day.Add(-(int(day.Weekday()-time.Monday) + 7) % 7)
+}
+
+// londonHolidays are the holidays of the London Stock Exchange, the bank
+// holidays of England.
+func londonHolidays(year int) []Date {
+	return append(substitute(
+		NewDate(year, time.January, 1),
+		NewDate(year, time.December, 25),
+		NewDate(year, time.December, 26),
+	),
+		easter(year).Add(-2),
+		easter(year).Add(1),
+		nthWeekday(year, time.May, time.Monday, 1),     // Early May bank holiday.
+		nthWeekday(year, time.May, time.Monday, -1),    // Spring bank holiday.
+		nthWeekday(year, time.August, time.Monday, -1), // Summer bank holiday.
+	)
+}
+
+// euronextHolidays are the holidays of the Euronext exchanges.
+func euronextHolidays(year int) []Date {
+	return []Date{
+		NewDate(year, time.January, 1),
+		easter(year).Add(-2),
+		easter(year).Add(1),
+		NewDate(year, time.May, 1),
+		NewDate(year, time.December, 25),
+		NewDate(year, time.December, 26),
+	}
+}
+
+// xetraHolidays are the holidays of the Frankfurt Stock Exchange and Xetra.
+func xetraHolidays(year int) []Date {
+	return append(euronextHolidays(year), NewDate(year, time.December, 24), NewDate(year, time.December, 31))
+}
+
+// madridHolidays are the holidays of the Bolsa de Madrid.
+func madridHolidays(year int) []Date { return xetraHolidays(year) }
+
+// milanHolidays are the holidays of Borsa Italiana.
+func milanHolidays(year int) []Date {
+	return append(xetraHolidays(year), NewDate(year, time.August, 15))
+}
+
+// sixHolidays are the holidays of the SIX Swiss Exchange.
+func sixHolidays(year int) []Date {
+	return append(xetraHolidays(year),
+		NewDate(year, time.January, 2),
+		easter(year).Add(39), // Ascension.
+		easter(year).Add(50), // Whit Monday.
+		NewDate(year, time.August, 1),
+	)
+}
+
+// tokyoHolidays are the new year holidays of the Tokyo Stock Exchange. The
+// other national holidays of Japan are not known.
+func tokyoHolidays(year int) []Date {
+	return []Date{
+		NewDate(year, time.January, 1),
+		NewDate(year, time.January, 2),
+		NewDate(year, time.January, 3),
+		NewDate(year, time.December, 31),
+	}
+}
+
+// christmasEasterHolidays are the holidays shared by the exchanges of Hong
+// Kong and Australia. Their other holidays are not known.
+func christmasEasterHolidays(year int) []Date {
+	return []Date{
+		NewDate(year, time.January, 1),
+		easter(year).Add(-2),
+		easter(year).Add(1),
+		NewDate(year, time.December, 25),
+		NewDate(year, time.December, 26),
+	}
+}
+
+// tradingDay returns whether a security trades on a day: on the trading days of
+// its exchange if known, on weekdays otherwise.
+func (l *Ledger) tradingDay(ticker string) func(day Date) bool {
+	if sec := l.Security(ticker); sec != nil {
+		if e, ok := sec.Exchange(); ok {
+			return e.IsTradingDay
+		}
+	}
+	return func(day Date) bool { return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday }
+}
+
+// ClosedExchanges returns the exchanges of the securities held at the end of
+// the period that do not trade on any day of the period, sorted by MIC, e.g.
+// all of them for the daily review of a Sunday.
+func (r *Review) ClosedExchanges() []Exchange {
+	var closed []Exchange
+	for ticker := range r.end.Securities() {
+		sec, ok := r.end.SecurityDetails(ticker)
+		if !ok || r.end.Position(ticker).IsZero() {
+			continue
+		}
+		e, ok := sec.Exchange()
+		if !ok || slices.ContainsFunc(closed, func(c Exchange) bool { return c.MIC == e.MIC }) {
+			continue
+		}
+		trading := false
+		for day := range r.Range().Days() {
+			if e.IsTradingDay(day) {
+				trading = true
+				break
+			}
+		}
+		if !trading {
+			closed = append(closed, e)
+		}
+	}
+	slices.SortFunc(closed, func(a, b Exchange) int { return strings.Compare(a.MIC, b.MIC) })
+	return closed
+}
@@ -0,0 +1,103 @@
+package portfolio
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEaster(t *testing.T) {
+	for year, want := range map[int]Date{
+		2024: NewDate(2024, time.March, 31),
+		2025: NewDate(2025, time.April, 20),
+		2026: NewDate(2026, time.April, 5),
+		2038: NewDate(2038, time.April, 25),
+	} {
+		if got := easter(year); got != want {
+			t.Errorf("easter(%d) = %s, want %s", year, got, want)
+		}
+	}
+}
+
+func TestExchange_Holidays(t *testing.T) {
+	tests := []struct {
+		mic  string
+		year int
+		want []string
+	}{
+		{"XNYS", 2025, []string{"2025-01-01", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26", "2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25"}},
+		// New Year's Day is on a Saturday, Christmas is observed on Friday.
+		{"XNYS", 2022, []string{"2022-01-17", "2022-02-21", "2022-04-15", "2022-05-30", "2022-06-20", "2022-07-04", "2022-09-05", "2022-11-24", "2022-12-26"}},
+		// Christmas and Boxing Day on a weekend are substituted by the next weekdays.
+		{"XLON", 2021, []string{"2021-01-01", "2021-04-02", "2021-04-05", "2021-05-03", "2021-05-31", "2021-08-30", "2021-12-27", "2021-12-28"}},
+		{"XETR", 2025, []string{"2025-01-01", "2025-04-18", "2025-04-21", "2025-05-01", "2025-12-24", "2025-12-25", "2025-12-26", "2025-12-31"}},
+		{"XPAR", 2025, []string{"2025-01-01", "2025-04-18", "2025-04-21", "2025-05-01", "2025-12-25", "2025-12-26"}},
+	}
+	for _, test := range tests {
+		e, _ := LookupExchange(test.mic)
+		var got []string
+		for _, day := range e.Holidays(test.year) {
+			got = append(got, day.String())
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s.Holidays(%d) = %v, want %v", test.mic, test.year, got, test.want)
+		}
+	}
+}
+
+func TestExchange_IsTradingDay(t *testing.T) {
+	xnys, _ := LookupExchange("XNYS")
+	xsau, _ := LookupExchange("XSAU")
+	tests := []struct {
+		e    Exchange
+		day  Date
+		want bool
+	}{
+		{xnys, NewDate(2025, time.July, 3), true},
+		{xnys, NewDate(2025, time.July, 4), false}, // Independence Day.
+		{xnys, NewDate(2025, time.July, 5), false}, // Saturday.
+		{xsau, NewDate(2025, time.July, 4), false}, // Friday.
+		{xsau, NewDate(2025, time.July, 6), true},  // Sunday.
+	}
+	for _, test := range tests {
+		if got := test.e.IsTradingDay(test.day); got != test.want {
+			t.Errorf("%s.IsTradingDay(%s) = %v, want %v", test.e.MIC, test.day, got, test.want)
+		}
+	}
+	if xnys.InSession(time.Date(2025, time.July, 4, 15, 0, 0, 0, time.UTC)) {
+		t.Error("XNYS.InSession() on Independence Day = true, want false")
+	}
+}
+
+func TestLedger_PriceGaps_Holidays(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, time.December, 1), "", "USD"),
+		NewDeclare(NewDate(2025, time.December, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, time.December, 1), "", USD(1000), ""),
+		NewBuy(NewDate(2025, time.December, 22), "", "AAPL", Q(1), USD(100)),
+		NewUpdatePrice(NewDate(2025, time.December, 24), "AAPL", USD(100)),
+		NewUpdatePrice(NewDate(2025, time.December, 26), "AAPL", USD(100)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	// Christmas is not a gap.
+	got := ledger.PriceGaps("AAPL", NewRange(NewDate(2025, time.December, 24), NewDate(2025, time.December, 26)))
+	if len(got) != 0 {
+		t.Errorf("PriceGaps() = %v, want none", got)
+	}
+
+	f := ledger.NewSnapshot(NewDate(2025, time.December, 25)).PriceFreshness()
+	if len(f) != 1 || f[0].Age != 1 || f[0].Holidays != 1 || f[0].Stale(0) {
+		t.Errorf("PriceFreshness() = %v, want a price 1 day old on a holiday, not stale", f)
+	}
+
+	christmas := NewDate(2025, time.December, 25)
+	if closed := ledger.NewReview(NewRange(christmas, christmas)).ClosedExchanges(); len(closed) != 1 || closed[0].MIC != "XNAS" {
+		t.Errorf("ClosedExchanges() on Christmas = %v, want XNAS", closed)
+	}
+	if closed := ledger.NewReview(NewRange(christmas, christmas.Add(1))).ClosedExchanges(); len(closed) != 0 {
+		t.Errorf("ClosedExchanges() on Christmas and the next day = %v, want none", closed)
+	}
+}
@@ -0,0 +1,84 @@
+package portfolio
+
+import "math"
+
+// ClosedPosition is a position in a security from its opening, the first
+// purchase, to its closing, the sale of the last share. A security bought
+// again after being sold out opens a new position.
+type ClosedPosition struct {
+	Security string
+	Opened   Date
+	Closed   Date
+	// Invested is the cost of the shares bought, fees included.
+	Invested Money
+	// Proceeds are the amounts received for the shares sold, fees deducted.
+	Proceeds Money
+	// Dividends are the dividends received while the position was open.
+	Dividends Money
+}
+
+// Days returns the holding period of the position, in days.
+func (p ClosedPosition) Days() int { return p.Closed.Sub(p.Opened) }
+
+// RealizedGain returns the gain of the sales of the position: its proceeds
+// less its cost.
+func (p ClosedPosition) RealizedGain() Money { return p.Proceeds.Sub(p.Invested) }
+
+// TotalGain returns the realized gain of the position, and its dividends.
+func (p ClosedPosition) TotalGain() Money { return p.RealizedGain().Add(p.Dividends) }
+
+// AnnualizedReturn returns the total return of the position, compounded per
+// year of holding period. It is zero for a position closed the day it was
+// opened, or without investment.
+func (p ClosedPosition) AnnualizedReturn() Percent {
+	invested := p.Invested.AsFloat()
+	if p.Days() <= 0 || invested <= 0 {
+		return 0
+	}
+	growth := p.Proceeds.Add(p.Dividends).AsFloat() / invested
+	if growth <= 0 {
+		return -100
+	}
+	return Percent(100 * (math.Pow(growth, 365/float64(p.Days())) - 1))
+}
+
+// ClosedPositions returns the positions closed up to the snapshot's date, in
+// the order they were closed. Amounts are in the currency of the securities.
+func (s *Snapshot) ClosedPositions() []ClosedPosition {
+	open := make(map[string]*ClosedPosition)
+	positions := make(map[string]Quantity)
+	var closed []ClosedPosition
+	for e := range s.events() {
+		switch v := e.(type) {
+		case acquireLot:
+			p, ok := open[v.security]
+			if !ok {
+				zero := M(0, v.cost.Currency())
+				p = &ClosedPosition{Security: v.security, Opened: v.on, Invested: zero, Proceeds: zero, Dividends: zero}
+				open[v.security] = p
+			}
+			p.Invested = p.Invested.Add(v.cost)
+			positions[v.security] = positions[v.security].Add(v.quantity)
+		case splitShare:
+			num, den := Q(v.numerator), Q(v.denominator)
+			positions[v.security] = positions[v.security].Mul(num).Div(den)
+		case receiveDividend:
+			if p, ok := open[v.security]; ok {
+				p.Dividends = p.Dividends.Add(v.amount.Mul(positions[v.security]))
+			}
+		case disposeLot:
+			p, ok := open[v.security]
+			if !ok {
+				continue
+			}
+			p.Proceeds = p.Proceeds.Add(v.proceeds)
+			positions[v.security] = positions[v.security].Sub(v.quantity)
+			if positions[v.security].IsZero() {
+				p.Closed = v.on
+				closed = append(closed, *p)
+				delete(open, v.security)
+			}
+		}
+	}
+	return closed
+}
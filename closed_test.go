@@ -0,0 +1,50 @@
+package portfolio
+
+import "testing"
+
+func TestSnapshot_ClosedPositions(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2023, 1, 1), "", "EUR"),
+		NewDeclare(NewDate(2023, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2023, 1, 1), "", EUR(10000), ""),
+		NewBuy(NewDate(2023, 1, 2), "", "AAPL", Q(10), EUR(1000)),
+		NewBuy(NewDate(2023, 6, 1), "", "AAPL", Q(10), EUR(1200)),
+		NewDividend(NewDate(2023, 9, 1), "", "AAPL", EUR(2)),
+		NewSell(NewDate(2023, 12, 1), "", "AAPL", Q(5), EUR(700)),
+		NewSplit(NewDate(2024, 1, 2), "AAPL", 2, 1),
+		NewSell(NewDate(2025, 1, 1), "", "AAPL", Q(30), EUR(2500)),
+		// Bought again: a new position, still open.
+		NewBuy(NewDate(2025, 3, 1), "", "AAPL", Q(1), EUR(100)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	closed := ledger.NewSnapshot(NewDate(2025, 6, 1)).ClosedPositions()
+	if len(closed) != 1 {
+		t.Fatalf("ClosedPositions() = %v, want 1 position", closed)
+	}
+	p := closed[0]
+	if p.Opened != NewDate(2023, 1, 2) || p.Closed != NewDate(2025, 1, 1) || p.Days() != 730 {
+		t.Errorf("ClosedPositions() held from %s to %s, %d days, want from 2023-01-02 to 2025-01-01", p.Opened, p.Closed, p.Days())
+	}
+	for _, tc := range []struct {
+		name      string
+		got, want Money
+	}{
+		{"Invested", p.Invested, EUR(2200)},
+		{"Proceeds", p.Proceeds, EUR(3200)},
+		{"Dividends", p.Dividends, EUR(40)},
+		{"RealizedGain", p.RealizedGain(), EUR(1000)},
+		{"TotalGain", p.TotalGain(), EUR(1040)},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("ClosedPositions() %s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+	// (3240/2200)^(365/730) - 1
+	if got := p.AnnualizedReturn(); got < 21.35 || got > 21.37 {
+		t.Errorf("AnnualizedReturn() = %v, want 21.36%%", got)
+	}
+}
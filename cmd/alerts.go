@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// alertsCmd holds the flags for the 'alerts' subcommand.
+type alertsCmd struct {
+	date       string
+	keep       bool
+	ledgerFile string
+}
+
+func (*alertsCmd) Name() string { return "alerts" }
+func (*alertsCmd) Synopsis() string {
+	return "check the price alerts and mark the triggered ones done"
+}
+func (*alertsCmd) Usage() string {
+	return `pcs alerts [-d <date>] [-keep] [-l <ledger>]
+
+  Checks the active price alerts against the last known prices of their
+  securities, updated with the intraday prices for today, and lists them.
+
+  The triggered alerts are marked done in the ledger with a clear-price-alert
+  transaction, so that they are reported once. With -keep, the ledger is left
+  unchanged.
+
+  Alerts are set with 'pcs set-price-alert'. 'pcs rpc' serves them to the
+  graphical interfaces with the Portfolio.Alerts method.
+
+Usage Examples:
+$ pcs set-price-alert -s AAPL -below 150 -until 2025-12-31
+$ pcs alerts
+`
+}
+
+func (c *alertsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the prices. See the user manual for supported date formats.")
+	f.BoolVar(&c.keep, "keep", false, "Do not mark the triggered alerts done.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger of the alerts. Defaults to the only ledger if one exists.")
+}
+
+func (c *alertsCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	alerts := ledger.PriceAlerts(on)
+	if len(alerts) == 0 {
+		fmt.Println("No active price alerts, set them with 'pcs set-price-alert'.")
+		return subcommands.ExitSuccess
+	}
+	if on.IsToday() {
+		if err := ledger.UpdateIntraday(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not update intraday prices: %v\n", err)
+		}
+	}
+
+	s := ledger.NewSnapshot(on)
+	var triggered []portfolio.Transaction
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Price Alerts on %s\n\n", on)
+	fmt.Fprintln(&b, "| Ticker | Alert | Price | Until | Status | Memo |")
+	fmt.Fprintln(&b, "|:---|:---|---:|:---|:---|:---|")
+	for _, a := range alerts {
+		price := s.Price(a.Security)
+		threshold := portfolio.M(a.Threshold, price.Currency())
+		until, status := "-", "waiting"
+		if !a.Expiry.IsZero() {
+			until = a.Expiry.String()
+		}
+		if a.Triggered(price) {
+			status = "🔔 triggered"
+			memo := fmt.Sprintf("triggered at %s", price)
+			triggered = append(triggered, portfolio.NewClearPriceAlert(on, memo, a.Security, a.Direction, a.Threshold))
+		}
+		fmt.Fprintf(&b, "| %s | %s %s | %s | %s | %s | %s |\n", a.Security, a.Direction, threshold, price, until, status, cell(a.Memo))
+	}
+	printMarkdown(b.String())
+
+	if c.keep || len(triggered) == 0 {
+		return subcommands.ExitSuccess
+	}
+	for _, tx := range triggered {
+		if _, err := appendTransaction(ledger, tx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s on %s: %v\n", tx.What(), tx.When(), err)
+			return exitStatus(err, subcommands.ExitFailure)
+		}
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Marked %d triggered alerts done in ledger %q.\n", len(triggered), ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/etnz/portfolio"
 	"github.com/etnz/portfolio/amundi"
 	"github.com/google/subcommands"
 )
@@ -34,7 +33,7 @@ func (c *amundiFetchCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledgerFile, "l", "", "ledger name to update. update all ledgers by default.")
 }
 
-func (c *amundiFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *amundiFetchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 
 	// load headers
 	headers, err := amundi.LoadHeaders()
@@ -43,7 +42,7 @@ func (c *amundiFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 		return subcommands.ExitFailure
 	}
 
-	ledgers, err := DecodeLedgers(c.ledgerFile)
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
 		return subcommands.ExitFailure
@@ -54,12 +53,14 @@ func (c *amundiFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 		return subcommands.ExitSuccess
 	}
 
+	status := subcommands.ExitSuccess
 	for _, ledger := range ledgers {
 		ledgerName := ledger.Name()
 		fmt.Fprintf(os.Stderr, "Processing ledger %q...\n", ledgerName)
-		changes, updates, err := amundi.Fetch(headers, ledger, c.inception)
+		changes, updates, err := amundi.Fetch(ctx, headers, ledger, c.inception)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: could not fetch from Amundi for ledger %q: %v\n", ledgerName, err)
+			status = exitStatus(err, subcommands.ExitFailure)
 			continue
 		}
 
@@ -70,7 +71,7 @@ func (c *amundiFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 			continue
 		}
 
-		if err := portfolio.SaveLedger(PortfolioPath(), ledger); err != nil {
+		if err := saveLedger(ledger); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing updated ledger file for %q: %v\n", ledgerName, err)
 			continue
 		}
@@ -89,6 +90,9 @@ func (c *amundiFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 		}
 	}
 
+	if status != subcommands.ExitSuccess {
+		return status
+	}
 	fmt.Fprintf(os.Stderr, "✅ Successfully fetched from Amundi and updated ledgers.\n")
 	return subcommands.ExitSuccess
 }
@@ -5,14 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/etnz/portfolio/amundi"
 	"github.com/google/subcommands"
 )
 
-const amundiSessionFile = "pcs-amundi-session"
-
 type headerFlags []string
 
 func (h *headerFlags) String() string {
@@ -59,8 +57,7 @@ func (c *amundiLoginCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 		return subcommands.ExitUsageError
 	}
 
-	sessionPath := filepath.Join(os.TempDir(), amundiSessionFile)
-	if err := os.WriteFile(sessionPath, []byte(strings.Join(c.headers, "\n")), 0600); err != nil {
+	if err := amundi.SaveHeaders(c.headers); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to save Amundi session: %v\n", err)
 		return subcommands.ExitFailure
 	}
@@ -2,47 +2,136 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+	"github.com/etnz/portfolio/notify"
+	"github.com/etnz/portfolio/renderer"
 	"github.com/google/subcommands"
 	"github.com/shopspring/decimal"
 )
 
+// transactionCommands returns the commands recording a single transaction.
+func transactionCommands() []transactionCommand {
+	return []transactionCommand{
+		&initCmd{},
+		&buyCmd{},
+		&sellCmd{},
+		&dividendCmd{},
+		&depositCmd{},
+		&declareCmd{},
+		&declareCurrencyCmd{},
+		&withdrawCmd{},
+		&convertCmd{},
+		&accrueCmd{},
+		&priceCmd{},
+		&splitCmd{},
+		&expireCmd{},
+		&assignCmd{},
+		&lendCmd{},
+		&recallCmd{},
+		&lendingFeeCmd{},
+		&grantCmd{},
+		&vestCmd{},
+		&noteCmd{},
+		&setPriceAlertCmd{},
+		&clearPriceAlertCmd{},
+	}
+}
+
+// hiddenGroup is the group of the commands not listed by 'pcs help'.
+const hiddenGroup = "hidden"
+
 // Register registers all the application's subcommands with the provided Commander.
 // A main package will call Register() to set up the CLI.
 func Register(c *subcommands.Commander) {
 	c.Register(&amundiCmd{}, "providers")
+	c.Register(&platformCmd{}, "providers")
+	c.Register(&ibflexCmd{}, "providers")
+	c.Register(&syncCmd{}, "providers")
 	c.Register(&eodhdCmd{}, "providers")
 	c.Register(&inseeCmd{}, "providers")
+	c.Register(&fetchCmd{}, "providers")
+	c.Register(&conflictsCmd{}, "providers")
 
-	c.Register(&initCmd{}, "transactions")
-	c.Register(&buyCmd{}, "transactions")
-	c.Register(&sellCmd{}, "transactions")
-	c.Register(&dividendCmd{}, "transactions")
-	c.Register(&depositCmd{}, "transactions")
-	c.Register(&declareCmd{}, "transactions")
-	c.Register(&withdrawCmd{}, "transactions")
-	c.Register(&convertCmd{}, "transactions")
-	c.Register(&accrueCmd{}, "transactions")
-	c.Register(&priceCmd{}, "transactions")
-	c.Register(&splitCmd{}, "transactions")
+	for _, cmd := range transactionCommands() {
+		c.Register(cmd, "transactions")
+	}
 
+	c.Register(&applyCmd{}, "tools")
+	c.Register(&importCmd{}, "tools")
+	c.Register(&whatifCmd{}, "tools")
 	c.Register(&fmtCmd{}, "tools")
+	c.Register(&editCmd{}, "tools")
 	c.Register(&AssistCmd{}, "tools")
+	c.Register(&cacheCmd{}, "tools")
+	c.Register(&authCmd{}, "tools")
 
 	c.Register(&summaryCmd{}, "reports")
 	c.Register(&holdingCmd{}, "reports")
+	c.Register(&exposureCmd{}, "reports")
+	c.Register(&watchlistCmd{}, "reports")
+	c.Register(&securityCmd{}, "reports")
 	c.Register(&historyCmd{}, "reports")
+	c.Register(&pricesCmd{}, "reports")
+	c.Register(&intradayCmd{}, "reports")
+	c.Register(&statementCmd{}, "reports")
+	c.Register(&reportCmd{}, "reports")
+	c.Register(&publishCmd{}, "reports")
+	c.Register(&digestCmd{}, "reports")
+	c.Register(&vestingCmd{}, "reports")
+	c.Register(&reconcileCmd{}, "tools")
+	c.Register(&cleanupDustCmd{}, "tools")
+	c.Register(&compactCmd{}, "tools")
+	c.Register(&migrateLedgerCmd{}, "tools")
+	c.Register(&bootstrapCmd{}, "tools")
+	c.Register(&splitLedgerCmd{}, "tools")
+	c.Register(&mergeLedgersCmd{}, "tools")
+	c.Register(&backfillCmd{}, "tools")
+	c.Register(&auditSplitsCmd{}, "tools")
+	c.Register(&doctorCmd{}, "tools")
+	c.Register(&grepCmd{}, "tools")
+	c.Register(&rpcCmd{}, "tools")
 	c.Register(&txCmd{}, "reports")
 	c.Register(&reviewCmd{}, "reports")
+	c.Register(&logCmd{}, "reports")
+	c.Register(&projectCmd{}, "reports")
+	c.Register(&feesCmd{}, "reports")
+	c.Register(&scoresCmd{}, "reports")
+	c.Register(&closedPositionsCmd{}, "reports")
+	c.Register(&ltEligibleCmd{}, "reports")
+	c.Register(&journalCmd{}, "reports")
+	c.Register(&alertsCmd{}, "reports")
+	c.Register(&contributionsCmd{}, "reports")
+	c.Register(&fireCmd{}, "reports")
+	c.Register(&taxesCmd{}, "reports")
 
 	c.Register(&topicCmd{}, "documentation")
 
+	// Commands for maintainers are not listed by 'pcs help'.
+	c.Register(&benchCmd{}, hiddenGroup)
+	explainGroup := c.ExplainGroup
+	c.ExplainGroup = func(w io.Writer, g *subcommands.CommandGroup) {
+		if g.Name() != hiddenGroup {
+			explainGroup(w, g)
+		}
+	}
+
 }
 
 // As a CLI application, it has a very short-lived lifecycle, so it is ok to use global variables for flags.
@@ -52,8 +141,145 @@ var (
 	Verbose         = flag.Bool("v", false, "enable verbose logging")
 	noRender        = flag.Bool("no-render", false, "disable markdown rendering in terminal output")
 	portfolioPath   = flag.String("portfolio", "", "Path to the portfolio directory (overrides PORTFOLIO_PATH env var)")
+	Offline         = flag.Bool("offline", false, "disable network access: providers only use cached responses and fail fast otherwise")
+	templateDir     = flag.String("template-dir", "", "Directory of user templates overriding the report templates (defaults to ~/.config/pcs/templates)")
+	locale          = flag.String("locale", "", "Locale of the reports: en, fr or de (overrides the \"locale\" of the config.json file)")
+	pivotCurrency   = flag.String("pivot-currency", "", "Currency preferred to derive the exchange rates missing in the ledger (defaults to the reporting currency)")
+	dryRun          = flag.Bool("dry-run", false, "print the transactions that commands would add or remove, without saving the ledgers")
+	settlementLag   = flag.Int("settlement-lag", 0, "business days between the trade and the settlement of buys and sells, e.g. 2 for T+2, for the cash available to new transactions")
+	Timeout         = flag.Duration("timeout", 0, "time limit of the command, e.g. 30s, cancelling the network requests and long computations (no limit by default)")
+	skipDuplicates  = flag.Bool("skip-duplicates", false, "do not record transactions already in the ledger, with the same key or equal")
+	timeZoneName    = flag.String("timezone", "", "IANA time zone of the trading day, e.g. America/New_York, telling today's date (overrides the \"timezone\" of the config.json file and of the ledger)")
+	conflicts       = flag.String("conflicts", "", "Price to keep when providers disagree: last, existing, order:<providers>, volume or review (overrides the \"conflicts\" of the config.json file, defaults to last)")
 )
 
+func init() {
+	flag.BoolVar(dryRun, "n", false, "shorthand for -dry-run")
+}
+
+// configEntry returns an entry of the config.json file in the portfolio
+// directory, empty if there is none.
+func configEntry(key string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(PortfolioPath(), notify.ConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var cfg map[string]json.RawMessage
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return "", fmt.Errorf("invalid configuration file: %w", err)
+	}
+	var value string
+	if raw, ok := cfg[key]; ok {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", fmt.Errorf("invalid %q in the configuration file: %w", key, err)
+		}
+	}
+	return value, nil
+}
+
+// LoadLocale sets the locale of the reports from the -locale flag, or from the
+// "locale" entry of the config.json file in the portfolio directory.
+func LoadLocale() error {
+	name := *locale
+	if name == "" {
+		var err error
+		if name, err = configEntry("locale"); err != nil || name == "" {
+			return err
+		}
+	}
+	return format.SetLocale(name)
+}
+
+// timeZoneSet reports whether the time zone is set by the -timezone flag or the
+// config.json file, instead of the ledgers.
+var timeZoneSet bool
+
+// LoadTimeZone sets the time zone of the trading day from the -timezone flag,
+// the "timezone" entry of the config.json file, or the time zone of the ledger
+// if the portfolio has a single one, see portfolio.SetTimeZone. It is the local
+// time zone otherwise.
+//
+// With several ledgers, the time zone of a ledger is only known once it is
+// loaded: the default dates of the commands, e.g. the date of a transaction,
+// are in the time zone of the flag or the config.json file.
+func LoadTimeZone() error {
+	name := *timeZoneName
+	if name == "" {
+		var err error
+		if name, err = configEntry("timezone"); err != nil {
+			return err
+		}
+	}
+	if name == "" {
+		loc, err := portfolio.LedgerTimeZone(PortfolioPath(), "")
+		if err != nil {
+			return err
+		}
+		if loc != nil {
+			portfolio.SetTimeZone(loc)
+		}
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid time zone %q, want an IANA name like America/New_York: %w", name, err)
+	}
+	portfolio.SetTimeZone(loc)
+	timeZoneSet = true
+	return nil
+}
+
+// useLedgerTimeZone sets the time zone of the trading day to the time zone of
+// a ledger, unless it is set by the -timezone flag or the config.json file.
+func useLedgerTimeZone(ledger *portfolio.Ledger) {
+	if loc := ledger.TimeZone(); loc != nil && !timeZoneSet {
+		portfolio.SetTimeZone(loc)
+	}
+}
+
+// conflictPolicy is the policy applied to the ledgers when providers disagree
+// on a price.
+var conflictPolicy portfolio.ConflictPolicy
+
+// LoadConflictPolicy sets the policy applied when providers disagree on a price
+// from the -conflicts flag, or from the "conflicts" entry of the config.json
+// file in the portfolio directory.
+func LoadConflictPolicy() error {
+	name := *conflicts
+	if name == "" {
+		var err error
+		if name, err = configEntry("conflicts"); err != nil || name == "" {
+			return err
+		}
+	}
+	p, err := portfolio.ParseConflictPolicy(name)
+	if err != nil {
+		return err
+	}
+	conflictPolicy = p
+	return nil
+}
+
+// LoadTemplates loads the user report templates from the -template-dir flag, or
+// from the pcs/templates folder of the user configuration directory if it exists.
+func LoadTemplates() error {
+	dir := *templateDir
+	if dir == "" {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(config, "pcs", "templates")
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+	return renderer.LoadTemplates(dir)
+}
+
 // PortfolioPath resolves the path to the portfolio directory.
 // It follows this order of precedence:
 // 1. --portfolio flag
@@ -71,34 +297,192 @@ func PortfolioPath() string {
 
 // DecodeLedger decodes the ledger from the application's default ledger file.
 // If the file does not exist, it returns a new empty ledger.
-func DecodeLedger(query string) (*portfolio.Ledger, error) {
+func DecodeLedger(ctx context.Context, query string) (*portfolio.Ledger, error) {
 	path := PortfolioPath()
-	return portfolio.FindLedger(path, query)
+	ledger, err := portfolio.FindLedger(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	ledger.SetPivotCurrency(*pivotCurrency)
+	ledger.SetSettlementLag(*settlementLag)
+	ledger.SetConflictPolicy(conflictPolicy)
+	useLedgerTimeZone(ledger)
+	return ledger, nil
 }
 
 // DecodeLedgers decodes all ledgers from the portfolio path.
-func DecodeLedgers(query string) ([]*portfolio.Ledger, error) {
+func DecodeLedgers(ctx context.Context, query string) ([]*portfolio.Ledger, error) {
 	path := PortfolioPath()
-	return portfolio.FindLedgers(path, query)
+	ledgers, err := portfolio.FindLedgers(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, ledger := range ledgers {
+		ledger.SetPivotCurrency(*pivotCurrency)
+		ledger.SetSettlementLag(*settlementLag)
+		ledger.SetConflictPolicy(conflictPolicy)
+	}
+	if len(ledgers) == 1 {
+		useLedgerTimeZone(ledgers[0])
+	}
+	return ledgers, nil
+}
+
+// subPortfolios returns the sub-portfolio with a label of each ledger, see
+// portfolio.Ledger.SubPortfolio. The ledgers without that sub-portfolio are
+// left out, and it is an error if none has it.
+func subPortfolios(ledgers []*portfolio.Ledger, label string) ([]*portfolio.Ledger, error) {
+	var subs []*portfolio.Ledger
+	for _, ledger := range ledgers {
+		if !slices.Contains(ledger.SubPortfolios(), strings.ToLower(label)) {
+			continue
+		}
+		sub, err := ledger.SubPortfolio(label)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("no sub-portfolio %q, label its transactions with %q in their memo", label, portfolio.SubPortfolioTag+"="+label)
+	}
+	return subs, nil
 }
 
 // EncodeTransaction validates a transaction against the market data and existing
 // ledger, then appends it to the ledger file.
 func EncodeTransaction(ledger *portfolio.Ledger, tx portfolio.Transaction) (portfolio.Transaction, error) {
-	validatedTx, err := ledger.Validate(tx)
+	validatedTx, err := appendTransaction(ledger, tx)
 	if err != nil {
 		return nil, err
 	}
+	if err := declareCurrencyPairs(ledger); err != nil {
+		return nil, err
+	}
+
+	if err := saveLedger(ledger); err != nil {
+		return nil, fmt.Errorf("could not save ledger: %w", err)
+	}
+
+	return validatedTx, nil
+}
+
+// errDuplicate is returned for a transaction skipped with -skip-duplicates.
+var errDuplicate = errors.New("transaction already recorded")
 
+// appendTransaction validates a transaction against the ledger and appends it.
+//
+// With -skip-duplicates, a transaction already recorded in the ledger, with the
+// same dedup key or equal once validated, is not appended and errDuplicate is
+// returned.
+func appendTransaction(ledger *portfolio.Ledger, tx portfolio.Transaction) (portfolio.Transaction, error) {
+	if *skipDuplicates && ledger.Duplicate(tx) != nil {
+		return nil, errDuplicate
+	}
+	validatedTx, err := ledger.Validate(tx)
+	if err != nil {
+		return nil, err
+	}
+	if *skipDuplicates && ledger.Duplicate(validatedTx) != nil {
+		return nil, errDuplicate
+	}
 	if err := ledger.Append(validatedTx); err != nil {
 		return nil, fmt.Errorf("could not append transaction: %w", err)
 	}
+	return validatedTx, nil
+}
 
-	if err := portfolio.SaveLedger(PortfolioPath(), ledger); err != nil {
-		return nil, fmt.Errorf("could not save ledger: %w", err)
+// saveLedger saves the ledger in the portfolio.
+//
+// With -dry-run, the ledger is not saved: the transactions it would add are
+// printed to stdout as canonical JSONL, and a summary of the changes with the
+// resulting balances is printed to stderr.
+func saveLedger(ledger *portfolio.Ledger) error {
+	if !*dryRun {
+		return portfolio.SaveLedger(PortfolioPath(), ledger)
+	}
+	changes, err := portfolio.LedgerChanges(PortfolioPath(), ledger)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Dry run: ledger %q not saved.\n", ledger.Name())
+	if len(changes.Added) == 0 && len(changes.Removed) == 0 {
+		fmt.Fprintln(os.Stderr, "No changes.")
+		return nil
 	}
 
-	return validatedTx, nil
+	var added []portfolio.Transaction
+	summarize := func(sign string, lines []json.RawMessage) {
+		for _, line := range lines {
+			tx, err := portfolio.NewDecoder(bytes.NewReader(line)).Decode()
+			if err != nil {
+				continue
+			}
+			if sign == "+" {
+				added = append(added, tx)
+			}
+			fmt.Fprintf(os.Stderr, "%s %s %s\n", sign, tx.When(), renderer.Transaction(tx))
+		}
+	}
+	summarize("-", changes.Removed)
+	summarize("+", changes.Added)
+	for _, line := range changes.Added {
+		fmt.Println(string(line))
+	}
+
+	on := portfolio.Today()
+	if newest := ledger.NewestTransactionDate(); newest.After(on) {
+		on = newest
+	}
+	s := ledger.NewSnapshot(on)
+	fmt.Fprintf(os.Stderr, "Resulting balances on %s:\n", on)
+	for cur := range s.Currencies() {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", cur, s.Cash(cur))
+	}
+	for ticker := range s.Securities() {
+		touched := slices.ContainsFunc(added, portfolio.BySecurity(ticker))
+		if touched && !s.Position(ticker).IsZero() {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", ticker, s.Position(ticker))
+		}
+	}
+	return nil
+}
+
+// createLedgerFile writes the ledger to a new file, it is an error if the file
+// already exists.
+//
+// With -dry-run, the file is not created: the ledger is printed to stdout
+// instead.
+func createLedgerFile(file string, ledger *portfolio.Ledger) error {
+	if *dryRun {
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("open %s: %w", file, fs.ErrExist)
+		}
+		fmt.Fprintf(os.Stderr, "Dry run: ledger %q not created.\n", file)
+		return portfolio.EncodeLedger(os.Stdout, ledger)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := portfolio.EncodeLedger(f, ledger); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %q: %w", file, err)
+	}
+	return f.Close()
+}
+
+// declareCurrencyPairs declares the currency pairs needed to convert the new
+// currencies of the ledger into its reporting currency.
+func declareCurrencyPairs(ledger *portfolio.Ledger) error {
+	txs, err := ledger.DeclareCurrencyPairs()
+	if err != nil {
+		return fmt.Errorf("could not declare currency pairs: %w", err)
+	}
+	for _, tx := range txs {
+		fmt.Fprintf(os.Stderr, "Declared currency pair %s.\n", tx.(portfolio.Declare).Ticker)
+	}
+	return nil
 }
 
 // printMarkdown renders a markdown string to stdout with appropriate styling.
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// applyCmd holds the flags for the 'apply' subcommand.
+type applyCmd struct {
+	ledgerFile string
+}
+
+func (*applyCmd) Name() string     { return "apply" }
+func (*applyCmd) Synopsis() string { return "record a batch of transactions from stdin or a file" }
+func (*applyCmd) Usage() string {
+	return `pcs apply [-l <ledger>] - | <file>
+
+  Records a batch of transactions read from stdin (-) or a file, one per line,
+  either as a JSON line in the ledger format, or as a transaction command of
+  pcs, with or without the leading "pcs". Blank lines and lines starting with #
+  are ignored. The -l flag of the commands is ignored: all transactions go to
+  the ledger of the apply command.
+
+  Transactions are validated in order, each against the ledger state resulting
+  from the previous ones. They are only recorded if they are all valid,
+  otherwise the errors are reported for each line and the ledger is unchanged.
+
+  JSON lines may carry a "key" identifying the transaction, e.g. the reference
+  of the operation at the broker: a key can only be recorded once. Use the
+  -skip-duplicates global flag to re-apply a batch safely: the transactions
+  already recorded, with the same key or equal, are skipped.
+
+Usage Examples:
+$ pcs apply - <<END
+deposit -d 2025-01-02 -a 1000 -c USD
+pcs buy -d 2025-01-03 -s AAPL -q 4 -a 980
+{"command":"sell","date":"2025-02-03","security":"AAPL","quantity":2,"amount":500}
+END
+$ pcs -skip-duplicates apply operations.jsonl
+`
+}
+
+func (c *applyCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to add the transactions to.")
+}
+
+func (c *applyCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: a file, or - for stdin, is required.")
+		return subcommands.ExitUsageError
+	}
+	var r io.Reader = os.Stdin
+	if name := f.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		defer file.Close()
+		r = file
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	var failed, count, skipped int
+	status := subcommands.ExitFailure // the status of the first invalid transaction.
+	scanner := bufio.NewScanner(r)
+	// The transactions are recorded in a single batch, published only if they
+	// are all valid.
+	errInvalid := errors.New("invalid transactions")
+	err = ledger.Batch(func(b *portfolio.Ledger) error {
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			err := applyLine(b, text)
+			if errors.Is(err, errDuplicate) {
+				skipped++
+				continue
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", &portfolio.LineError{Line: line, Err: err})
+				if failed == 0 {
+					status = exitStatus(err, subcommands.ExitFailure)
+				}
+				failed++
+				continue
+			}
+			count++
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return errInvalid
+		}
+		return nil
+	})
+	if errors.Is(err, errInvalid) {
+		fmt.Fprintf(os.Stderr, "%d invalid transactions, nothing recorded.\n", failed)
+		return status
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading transactions: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d transactions already recorded, skipped.\n", skipped)
+	}
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No transactions to record.")
+		return subcommands.ExitSuccess
+	}
+
+	if err := declareCurrencyPairs(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully recorded %d transactions in ledger %q.\n", count, ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
+
+// applyLine parses a transaction, as a JSON line or a pcs command, validates
+// it against the ledger, and appends it. It returns errDuplicate for a
+// transaction skipped with -skip-duplicates.
+func applyLine(ledger *portfolio.Ledger, text string) error {
+	var tx portfolio.Transaction
+	var err error
+	if strings.HasPrefix(text, "{") {
+		tx, err = portfolio.NewDecoder(bytes.NewReader([]byte(text))).Decode()
+	} else {
+		tx, err = parseTransactionCommand(text)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = appendTransaction(ledger, tx)
+	return err
+}
+
+// parseTransactionCommand parses a transaction command line of pcs, e.g.
+// "buy -s AAPL -q 10 -a 2500", into its transaction.
+func parseTransactionCommand(text string) (portfolio.Transaction, error) {
+	args, err := splitCommandLine(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "pcs" {
+		args = args[1:]
+	}
+	return parseTransactionArgs(args)
+}
+
+// parseTransactionArgs parses the arguments of a transaction command of pcs,
+// e.g. ["buy", "-s", "AAPL", "-q", "10", "-a", "2500"], into its transaction.
+func parseTransactionArgs(args []string) (portfolio.Transaction, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing command")
+	}
+	var cmd transactionCommand
+	for _, c := range transactionCommands() {
+		if c.Name() == args[0] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		return nil, fmt.Errorf("unknown transaction command %q", args[0])
+	}
+
+	f := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	cmd.SetFlags(f)
+	if err := f.Parse(args[1:]); err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Name(), err)
+	}
+	tx, err := cmd.transaction()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Name(), err)
+	}
+	return tx, nil
+}
+
+// splitCommandLine splits a command line into arguments separated by spaces,
+// honoring single and double quotes.
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+func TestParseTransactionCommand(t *testing.T) {
+	tx, err := parseTransactionCommand(`pcs buy -d 2025-01-06 -s AAPL -q 4 -a 980 -m "first lot"`)
+	if err != nil {
+		t.Fatalf("parseTransactionCommand() error = %v", err)
+	}
+	want := portfolio.NewBuy(portfolio.NewDate(2025, 1, 6), "first lot", "AAPL", portfolio.Q(4), portfolio.M(980, ""))
+	if !tx.Equal(want) {
+		t.Errorf("parseTransactionCommand() = %v, want %v", tx, want)
+	}
+
+	for _, line := range []string{"frobnicate -x", "buy -s AAPL", "buy -unknown 1", `buy -m "unterminated`} {
+		if _, err := parseTransactionCommand(line); err == nil {
+			t.Errorf("parseTransactionCommand(%q) expected an error", line)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/subcommands"
+)
+
+// auditSplitsCmd holds the flags for the 'audit-splits' subcommand.
+type auditSplitsCmd struct {
+	tolerance  float64
+	ledgerFile string
+}
+
+func (*auditSplitsCmd) Name() string { return "audit-splits" }
+func (*auditSplitsCmd) Synopsis() string {
+	return "check that stored prices are not adjusted for splits"
+}
+func (*auditSplitsCmd) Usage() string {
+	return `pcs audit-splits [-tolerance <ratio>] [-l <ledger>]
+
+  Cross-checks the stored prices around each split for the discontinuity of raw
+  prices: on the day of a 2:1 split, the price halves. Prices adjusted for
+  splits by a data provider show no discontinuity, and distort valuations.
+
+  The splits whose prices do not move as expected, within the relative
+  tolerance, are reported with a diagnosis. Splits without prices within 10
+  days on both sides cannot be checked.
+
+Usage Examples:
+$ pcs audit-splits
+`
+}
+
+func (c *auditSplitsCmd) SetFlags(f *flag.FlagSet) {
+	f.Float64Var(&c.tolerance, "tolerance", 0.2, "Relative tolerance on the price ratio across a split")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to audit. Defaults to the only ledger if one exists.")
+}
+
+func (c *auditSplitsCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.tolerance <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -tolerance must be positive.")
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	anomalies := ledger.AuditSplits(c.tolerance)
+	if len(anomalies) == 0 {
+		fmt.Fprintln(os.Stderr, "✅ Prices around all checked splits are consistent.")
+		return subcommands.ExitSuccess
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "# Suspect Splits")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "| Date | Security | Split | Price Before | Price After | Ratio | Expected | Diagnosis |")
+	fmt.Fprintln(&b, "|:---|:---|---:|---:|---:|---:|---:|:---|")
+	for _, a := range anomalies {
+		diagnosis := "unexplained price move"
+		if a.Adjusted {
+			diagnosis = "prices look adjusted"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d:%d | %s (%s) | %s (%s) | %.3f | %.3f | %s |\n",
+			a.Split.Date, a.Split.Security, a.Split.Numerator, a.Split.Denominator,
+			a.Before, a.BeforeDate, a.After, a.AfterDate, a.Ratio(), a.ExpectedRatio(), diagnosis)
+	}
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+
+	"github.com/google/subcommands"
+)
+
+// authCmd is a container for the credential store subcommands.
+type authCmd struct{}
+
+func (*authCmd) Name() string     { return "auth" }
+func (*authCmd) Synopsis() string { return "manage provider credentials" }
+func (*authCmd) Usage() string {
+	return `auth <subcommand> [args]
+
+  Manages the credentials of market data providers in the OS keyring (macOS
+  Keychain, or Secret Service on Linux). When a credential is not in the keyring,
+  providers fall back to their environment variable (e.g. EODHD_API_KEY).
+
+Commands:
+  set    - Store a provider credential in the keyring.
+  list   - List providers and where their credential is found.
+  delete - Remove a provider credential from the keyring.
+`
+}
+
+func (c *authCmd) SetFlags(f *flag.FlagSet) {}
+func (c *authCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	loadPlatforms() // Registers the sessions of the fund platforms, errors are reported by their commands.
+	commander := subcommands.NewCommander(f, "auth")
+	commander.Register(&authSetCmd{}, "")
+	commander.Register(&authListCmd{}, "")
+	commander.Register(&authDeleteCmd{}, "")
+	return commander.Execute(ctx, args...)
+}
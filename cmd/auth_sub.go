@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio/auth"
+	"github.com/google/subcommands"
+	"golang.org/x/term"
+)
+
+// authSetCmd implements the "auth set" command.
+type authSetCmd struct{}
+
+func (*authSetCmd) Name() string     { return "set" }
+func (*authSetCmd) Synopsis() string { return "stores a provider credential in the keyring" }
+func (*authSetCmd) Usage() string {
+	return `pcs auth set <provider>
+
+  Stores the credential of a provider in the OS keyring. The credential is
+  prompted for without echo, or read from the standard input when it is not a
+  terminal.
+
+Usage Examples:
+$ pcs auth set eodhd
+$ echo $EODHD_API_KEY | pcs auth set eodhd
+`
+}
+
+func (c *authSetCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c *authSetCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: a single provider name is required.")
+		return subcommands.ExitUsageError
+	}
+	p, err := auth.Lookup(f.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	var secret string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "%s: ", p.Description)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read credential: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		secret = string(b)
+	} else {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read credential: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		secret = strings.TrimSpace(string(b))
+	}
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "Error: empty credential.")
+		return subcommands.ExitUsageError
+	}
+
+	if err := auth.Set(p.Name, secret); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not store credential: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Successfully stored %s credential in the keyring.\n", p.Name)
+	return subcommands.ExitSuccess
+}
+
+// authListCmd implements the "auth list" command.
+type authListCmd struct{}
+
+func (*authListCmd) Name() string     { return "list" }
+func (*authListCmd) Synopsis() string { return "lists providers and where their credential is found" }
+func (*authListCmd) Usage() string {
+	return `pcs auth list
+
+  Lists the providers using the credential store, and where their credential is
+  found: in the keyring, in the environment, or nowhere. Credentials are never
+  printed.
+`
+}
+
+func (c *authListCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c *authListCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var b strings.Builder
+	fmt.Fprintln(&b, "| Provider | Credential | Source | Environment |")
+	fmt.Fprintln(&b, "|:---|:---|:---|:---|")
+	for _, p := range auth.Providers {
+		source := auth.Source(p.Name)
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", p.Name, p.Description, source, p.Env)
+	}
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
+
+// authDeleteCmd implements the "auth delete" command.
+type authDeleteCmd struct{}
+
+func (*authDeleteCmd) Name() string     { return "delete" }
+func (*authDeleteCmd) Synopsis() string { return "removes a provider credential from the keyring" }
+func (*authDeleteCmd) Usage() string {
+	return `pcs auth delete <provider>
+
+  Removes the credential of a provider from the OS keyring.
+`
+}
+
+func (c *authDeleteCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c *authDeleteCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: a single provider name is required.")
+		return subcommands.ExitUsageError
+	}
+	if err := auth.Delete(f.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not delete credential: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Successfully deleted %s credential.\n", f.Arg(0))
+	return subcommands.ExitSuccess
+}
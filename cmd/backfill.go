@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/auth"
+	"github.com/etnz/portfolio/eodhd"
+	"github.com/google/subcommands"
+)
+
+// backfillCmd holds the flags for the 'backfill' subcommand.
+type backfillCmd struct {
+	eodhdApiFlag string
+	tickers      stringSliceFlag
+	from         string
+	to           string
+	ledgerFile   string
+}
+
+func (*backfillCmd) Name() string     { return "backfill" }
+func (*backfillCmd) Synopsis() string { return "fetch the missing days of the price history" }
+func (*backfillCmd) Usage() string {
+	return `pcs backfill [-s <security>]... [-from <date>] [-to <date>] [-l <ledger>]
+
+  Detects the gaps in the stored price history of the securities, that is the
+  business days without a price while the security was held, and fetches only
+  the missing prices from the providers.
+
+  Requests are batched: all the gaps of a security are fetched at once. Some
+  gaps may remain, for market holidays or securities without a provider.
+
+  Requires an EODHD API key, see 'pcs eodhd fetch'.
+
+Usage Examples:
+# Fill the gaps of the AAPL price history since 2020.
+$ pcs backfill -s AAPL -from 2020-01-01
+
+# Preview the prices that would fill the gaps of all securities.
+$ pcs -n backfill
+`
+}
+
+func (c *backfillCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.eodhdApiFlag, "eodhd-api-key", "", "EODHD API key. This flag takes precedence over the keyring (see pcs auth) and the "+eodhd_api_key+" environment variable.")
+	f.Var(&c.tickers, "s", "Security ticker to backfill (can be specified multiple times). If empty, all are backfilled.")
+	f.StringVar(&c.from, "from", "", "Start date of the history. Defaults to the first transaction of each security. See the user manual for supported date formats.")
+	f.StringVar(&c.to, "to", "", "End date of the history. Defaults to yesterday. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to backfill. Defaults to the only ledger if one exists.")
+}
+
+func (c *backfillCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+	var from portfolio.Date
+	if c.from != "" {
+		if from, err = portfolio.ParseDate(c.from); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing start date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	to := portfolio.Today().Add(-1)
+	if c.to != "" {
+		if to, err = portfolio.ParseDate(c.to); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing end date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	tickers := c.tickers
+	if len(tickers) == 0 {
+		for sec := range ledger.AllSecurities() {
+			tickers = append(tickers, sec.Ticker())
+		}
+	}
+	slices.Sort(tickers)
+	for _, ticker := range tickers {
+		if ledger.Security(ticker) == nil {
+			fmt.Fprintf(os.Stderr, "Error: security %q not declared\n", ticker)
+			return subcommands.ExitFailure
+		}
+	}
+
+	r := portfolio.NewRange(from, to)
+	gaps := make(map[string][]portfolio.Range)
+	for _, ticker := range tickers {
+		gaps[ticker] = ledger.PriceGaps(ticker, r)
+		for _, gap := range gaps[ticker] {
+			fmt.Printf("%s: %s to %s (%d missing days)\n", ticker, gap.From, gap.To, businessDays(gap))
+		}
+	}
+	missing := missingDays(gaps)
+	if missing == 0 {
+		fmt.Fprintln(os.Stderr, "No gaps found in the price history.")
+		return subcommands.ExitSuccess
+	}
+	if c.eodhdApiFlag == "" {
+		c.eodhdApiFlag, _ = auth.Get("eodhd")
+	}
+	if c.eodhdApiFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: EODHD API key is not set. Use -eodhd-api-key flag, 'pcs auth set eodhd' or EODHD_API_KEY environment variable\n")
+		return subcommands.ExitFailure
+	}
+	updates, err := eodhd.FetchGaps(ctx, c.eodhdApiFlag, ledger, gaps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not fetch from eodhd.com: %v\n", err)
+		return exitStatus(err, subcommands.ExitFailure)
+	}
+	if _, err := ledger.UpdateMarketData(updates...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not add market data to the ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	// A gap is filled when none of the remaining gaps overlaps it.
+	remaining := make(map[string][]portfolio.Range)
+	total, filled := 0, 0
+	for _, ticker := range tickers {
+		remaining[ticker] = ledger.PriceGaps(ticker, r)
+		for _, gap := range gaps[ticker] {
+			total++
+			if !slices.ContainsFunc(remaining[ticker], func(rem portfolio.Range) bool {
+				return !rem.From.After(gap.To) && !rem.To.Before(gap.From)
+			}) {
+				filled++
+			}
+		}
+	}
+	fmt.Printf("Filled %d of %d gaps (%d of %d missing days).\n", filled, total, missing-missingDays(remaining), missing)
+	return subcommands.ExitSuccess
+}
+
+// missingDays returns the number of business days in the gaps.
+func missingDays(gaps map[string][]portfolio.Range) int {
+	n := 0
+	for _, ranges := range gaps {
+		for _, gap := range ranges {
+			n += businessDays(gap)
+		}
+	}
+	return n
+}
+
+// businessDays returns the number of weekdays in the range.
+func businessDays(r portfolio.Range) int {
+	n := 0
+	for day := range r.Days() {
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n++
+		}
+	}
+	return n
+}
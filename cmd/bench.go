@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// benchCmd holds the flags for the hidden 'bench' subcommand.
+type benchCmd struct {
+	ledgerFile string
+}
+
+func (*benchCmd) Name() string     { return "bench" }
+func (*benchCmd) Synopsis() string { return "measure the performance of the engine on a ledger" }
+func (*benchCmd) Usage() string {
+	return `pcs bench [-l <ledger>]
+
+  Measures the time taken by the core operations of the engine on a real
+  ledger, and compares it to the performance budget.
+
+  The budget grows linearly with the number of transactions, as each metric
+  scans the journal events. An operation over budget is a performance
+  regression worth reporting, with the number of transactions of the ledger.
+`
+}
+
+// benchBudget is the performance budget of an operation, per 1000 transactions.
+type benchBudget struct {
+	name   string
+	budget time.Duration
+	run    func(ledger *portfolio.Ledger, encoded []byte)
+}
+
+var benchBudgets = []benchBudget{
+	{"decode ledger", 10 * time.Millisecond, func(_ *portfolio.Ledger, encoded []byte) {
+		portfolio.DecodeLedger(bytes.NewReader(encoded))
+	}},
+	{"append transaction", 1 * time.Millisecond, func(ledger *portfolio.Ledger, _ []byte) {
+		ledger.Append()
+	}},
+	{"snapshot total", 5 * time.Millisecond, func(ledger *portfolio.Ledger, _ []byte) {
+		ledger.NewSnapshot(portfolio.Today()).TotalPortfolio()
+	}},
+	{"yearly review", 10 * time.Millisecond, func(ledger *portfolio.Ledger, _ []byte) {
+		r := ledger.NewReview(portfolio.Yearly.Range(portfolio.Today()))
+		r.TimeWeightedReturn()
+		r.CashFlow()
+		r.MarketGain()
+		r.Dividends()
+	}},
+}
+
+func (c *benchCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to measure. Defaults to the only ledger if one exists.")
+}
+
+func (c *benchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	var encoded bytes.Buffer
+	if err := portfolio.EncodeLedger(&encoded, ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	n := len(ledger.Query().Collect())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Benchmark of %q (%d transactions)\n\n", ledger.Name(), n)
+	fmt.Fprintln(&b, "| Operation | Time | Budget | Allocations | Status |")
+	fmt.Fprintln(&b, "|:---|---:|---:|---:|:---|")
+	over := false
+	for _, op := range benchBudgets {
+		fmt.Fprintf(os.Stderr, "Measuring %s...\n", op.name)
+		res := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				op.run(ledger, encoded.Bytes())
+			}
+		})
+		elapsed := time.Duration(res.NsPerOp())
+		budget := op.budget * time.Duration(max(n, 1000)) / 1000
+		status := "✅"
+		if elapsed > budget {
+			status, over = "❌ over budget", true
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", op.name, elapsed.Round(time.Microsecond), budget, res.AllocsPerOp(), status)
+	}
+	printMarkdown(b.String())
+	if over {
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
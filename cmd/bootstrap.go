@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+	"github.com/shopspring/decimal"
+)
+
+// bootstrapCmd holds the flags for the 'bootstrap' subcommand.
+type bootstrapCmd struct {
+	file     string
+	currency string
+	date     string
+	out      string
+}
+
+func (*bootstrapCmd) Name() string { return "bootstrap" }
+func (*bootstrapCmd) Synopsis() string {
+	return "create a ledger from the current positions and cash balances"
+}
+func (*bootstrapCmd) Usage() string {
+	return `pcs bootstrap -out <file> [-f <file.csv>] [-c <currency>] [-d <date>]
+
+  Creates a new ledger, ready to use, from the current positions and cash
+  balances, instead of recording their whole history.
+
+  Each position is transferred in on its acquisition date, at its cost basis:
+  a deposit of the cost followed by the purchase. Each cash balance is
+  deposited on the opening date. The ledger starts on the earliest of these
+  dates, with the declaration of each security and the needed currency pairs.
+
+  The positions and balances are read from a CSV file with the columns:
+    ticker,id,quantity,amount,currency,date
+  where amount is the total cost basis of the position, and date its
+  acquisition date, the opening date if empty. A line without ticker is a cash
+  balance. Without -f, they are asked for on the terminal.
+
+  The output file must not exist.
+
+Usage Examples:
+$ pcs bootstrap -f positions.csv -c EUR -out ledger.jsonl
+$ pcs bootstrap -d 2025-06-01 -out ledger.jsonl
+
+With positions.csv:
+  ticker,id,quantity,amount,currency,date
+  AAPL,US0378331005.XNAS,10,1500,USD,2020-03-02
+  ,,,2000,EUR,
+`
+}
+
+func (c *bootstrapCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.file, "f", "", "CSV file of the positions and cash balances. Asked for on the terminal by default.")
+	f.StringVar(&c.currency, "c", "EUR", "Reporting currency of the ledger")
+	f.StringVar(&c.date, "d", "0d", "Opening date of the ledger. See the user manual for supported date formats.")
+	f.StringVar(&c.out, "out", "", "File to write the new ledger to")
+}
+
+func (c *bootstrapCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.out == "" {
+		fmt.Fprintln(os.Stderr, "Error: the -out flag is required.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	var positions []portfolio.OpeningPosition
+	var cash []portfolio.Money
+	if c.file != "" {
+		file, err := os.Open(c.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		defer file.Close()
+		positions, cash, err = readOpeningBalances(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %q: %v\n", c.file, err)
+			return subcommands.ExitFailure
+		}
+	} else {
+		positions, cash = askOpeningBalances()
+	}
+
+	ledger, err := portfolio.Bootstrap(c.currency, on, positions, cash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := declareCurrencyPairs(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	if err := createLedgerFile(c.out, ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if *dryRun {
+		return subcommands.ExitSuccess
+	}
+	fmt.Fprintf(os.Stderr, "✅ Bootstrapped %q with %d positions and %d cash balances.\n", c.out, len(positions), len(cash))
+	return subcommands.ExitSuccess
+}
+
+// readOpeningBalances reads the positions and cash balances of a CSV file with
+// the columns ticker, id, quantity, amount, currency and date.
+func readOpeningBalances(r io.Reader) ([]portfolio.OpeningPosition, []portfolio.Money, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 6
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var positions []portfolio.OpeningPosition
+	var cash []portfolio.Money
+	for i, record := range records {
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+		amount, err := decimal.NewFromString(record[3])
+		if err != nil {
+			if i == 0 {
+				continue // header line
+			}
+			return nil, nil, fmt.Errorf("line %d: invalid amount %q: %w", i+1, record[3], err)
+		}
+		if err := portfolio.ValidateCurrency(record[4]); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if record[0] == "" {
+			cash = append(cash, portfolio.M(amount, record[4]))
+			continue
+		}
+		p, err := openingPosition(record[0], record[1], record[2], record[5], portfolio.M(amount, record[4]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		positions = append(positions, p)
+	}
+	return positions, cash, nil
+}
+
+// openingPosition parses the fields of an opening position.
+func openingPosition(ticker, id, quantity, date string, cost portfolio.Money) (portfolio.OpeningPosition, error) {
+	p := portfolio.OpeningPosition{Ticker: ticker, Cost: cost}
+	var err error
+	if p.ID, err = portfolio.ParseID(id); err != nil {
+		return p, fmt.Errorf("invalid id %q for %q: %w", id, ticker, err)
+	}
+	q, err := decimal.NewFromString(quantity)
+	if err != nil {
+		return p, fmt.Errorf("invalid quantity %q for %q: %w", quantity, ticker, err)
+	}
+	p.Quantity = portfolio.Q(q)
+	if date != "" {
+		if p.Acquired, err = portfolio.ParseDate(date); err != nil {
+			return p, fmt.Errorf("invalid acquisition date %q for %q: %w", date, ticker, err)
+		}
+	}
+	return p, nil
+}
+
+// askOpeningBalances asks for the positions and cash balances on the terminal,
+// until an empty ticker and an empty currency.
+func askOpeningBalances() ([]portfolio.OpeningPosition, []portfolio.Money) {
+	var positions []portfolio.OpeningPosition
+	for {
+		ticker := ask("Ticker of a position (empty to continue with cash): ")
+		if ticker == "" {
+			break
+		}
+		id := ask("  Security ID (e.g. US0378331005.XNAS): ")
+		quantity := ask("  Quantity: ")
+		amount, currency := ask("  Total cost basis: "), ask("  Currency: ")
+		date := ask("  Acquisition date (empty for the opening date): ")
+		cost, err := askedMoney(amount, currency)
+		if err == nil {
+			var p portfolio.OpeningPosition
+			if p, err = openingPosition(ticker, id, quantity, date, cost); err == nil {
+				positions = append(positions, p)
+				continue
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v, position skipped.\n", err)
+	}
+	var cash []portfolio.Money
+	for {
+		currency := ask("Currency of a cash balance (empty to finish): ")
+		if currency == "" {
+			break
+		}
+		balance, err := askedMoney(ask("  Balance: "), currency)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v, balance skipped.\n", err)
+			continue
+		}
+		cash = append(cash, balance)
+	}
+	return positions, cash
+}
+
+// ask asks a question on the terminal and returns the trimmed answer, empty
+// when there is nothing more to read.
+func ask(question string) string {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := stdin.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	return strings.TrimSpace(answer)
+}
+
+// askedMoney parses an amount and currency answered on the terminal.
+func askedMoney(amount, currency string) (portfolio.Money, error) {
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return portfolio.Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if err := portfolio.ValidateCurrency(currency); err != nil {
+		return portfolio.Money{}, err
+	}
+	return portfolio.M(value, currency), nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+
+	"github.com/google/subcommands"
+)
+
+// cacheCmd is a container for the HTTP cache subcommands.
+type cacheCmd struct{}
+
+func (*cacheCmd) Name() string     { return "cache" }
+func (*cacheCmd) Synopsis() string { return "manage the HTTP cache shared by providers" }
+func (*cacheCmd) Usage() string {
+	return `cache <subcommand> [args]
+
+  Manages the HTTP cache shared by all market data providers.
+
+  The cache directory defaults to "pcs" in the user cache directory and can be
+  overridden with the PCS_CACHE_DIR environment variable. Its size is bounded by
+  PCS_CACHE_MAX_SIZE (in bytes, 100MiB by default).
+
+Commands:
+  stats - Print the cache location and usage per provider.
+  clear - Remove cached responses.
+`
+}
+
+func (c *cacheCmd) SetFlags(f *flag.FlagSet) {}
+func (c *cacheCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	commander := subcommands.NewCommander(f, "cache")
+	commander.Register(&cacheStatsCmd{}, "")
+	commander.Register(&cacheClearCmd{}, "")
+	return commander.Execute(ctx, args...)
+}
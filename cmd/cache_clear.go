@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/cache"
+	"github.com/google/subcommands"
+)
+
+// cacheClearCmd implements the "cache clear" command.
+type cacheClearCmd struct {
+	provider string
+}
+
+func (*cacheClearCmd) Name() string     { return "clear" }
+func (*cacheClearCmd) Synopsis() string { return "removes cached responses" }
+func (*cacheClearCmd) Usage() string {
+	return `pcs cache clear [-provider <name>]
+
+  Removes all cached responses, or only those of a single provider.
+
+Usage Examples:
+# Forces the next eodhd fetch to query the API again.
+$ pcs cache clear -provider eodhd
+`
+}
+
+func (c *cacheClearCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.provider, "provider", "", "Provider whose entries are removed (e.g. eodhd, insee). Clears all by default.")
+}
+
+func (c *cacheClearCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	remove := cache.ClearAll
+	if c.provider != "" {
+		remove = func() error { return cache.Clear(c.provider) }
+	}
+	if err := remove(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not clear cache: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Successfully cleared cache.\n")
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/cache"
+	"github.com/google/subcommands"
+)
+
+// cacheStatsCmd implements the "cache stats" command.
+type cacheStatsCmd struct{}
+
+func (*cacheStatsCmd) Name() string     { return "stats" }
+func (*cacheStatsCmd) Synopsis() string { return "prints the cache location and usage per provider" }
+func (*cacheStatsCmd) Usage() string {
+	return `pcs cache stats
+
+  Prints the cache directory, its maximum size, and for each provider the
+  number of cached responses, their total size and age.
+`
+}
+
+func (c *cacheStatsCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c *cacheStatsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	stats, err := cache.Stats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not read cache: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf("Directory: %s\n", cache.Dir())
+	fmt.Printf("Max size:  %s\n", byteSize(cache.MaxSize()))
+	fmt.Println()
+	fmt.Printf("%-12s %8s %10s  %-16s  %-16s\n", "Provider", "Entries", "Size", "Oldest", "Newest")
+	var entries int
+	var size int64
+	for _, s := range stats {
+		fmt.Printf("%-12s %8d %10s  %-16s  %-16s\n", s.Provider, s.Entries, byteSize(s.Size), s.Oldest.Format("2006-01-02 15:04"), s.Newest.Format("2006-01-02 15:04"))
+		entries += s.Entries
+		size += s.Size
+	}
+	fmt.Printf("%-12s %8d %10s\n", "Total", entries, byteSize(size))
+	return subcommands.ExitSuccess
+}
+
+// byteSize formats a size in bytes using binary units.
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+	"github.com/shopspring/decimal"
+)
+
+// cleanupDustCmd holds the flags for the 'cleanup-dust' subcommand.
+type cleanupDustCmd struct {
+	on         string
+	threshold  decimal.Decimal
+	ledgerFile string
+}
+
+func (*cleanupDustCmd) Name() string     { return "cleanup-dust" }
+func (*cleanupDustCmd) Synopsis() string { return "dispose of negligible residual positions" }
+func (*cleanupDustCmd) Usage() string {
+	return `pcs cleanup-dust [-on <date>] [-threshold <quantity>] [-l <ledger>]
+
+  Detects dust positions, the tiny residual quantities that linger after many
+  sells (e.g. 0.000000001 shares), and records a sale of each of them for no
+  proceeds, with a memo for the audit trail.
+
+  A position is dust when its quantity is not zero and not greater than the
+  threshold.
+
+Usage Examples:
+# Preview the disposals of the dust positions, without changing the ledger.
+$ pcs -n cleanup-dust
+
+# Dispose of positions up to 0.001 shares.
+$ pcs cleanup-dust -threshold 0.001
+`
+}
+
+func (c *cleanupDustCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.on, "on", portfolio.Today().String(), "Date of the disposals. See the user manual for supported date formats.")
+	f.Var(DecimalVar(&c.threshold, "0.0001"), "threshold", "Maximum quantity of a dust position")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to clean up. Defaults to the only ledger if one exists.")
+}
+
+func (c *cleanupDustCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.on)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	if !c.threshold.IsPositive() {
+		fmt.Fprintln(os.Stderr, "Error: -threshold must be positive.")
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	txs := ledger.CleanupDust(on, portfolio.Q(c.threshold))
+	if len(txs) == 0 {
+		fmt.Fprintln(os.Stderr, "No dust positions found.")
+		return subcommands.ExitSuccess
+	}
+	for _, tx := range txs {
+		sell := tx.(portfolio.Sell)
+		fmt.Printf("%s: %s\n", sell.Security, sell.Quantity)
+	}
+	for _, tx := range txs {
+		valid, err := ledger.Validate(tx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		if err := ledger.Append(valid); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not append transaction: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully disposed of %d dust positions in ledger %q.\n", len(txs), ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// closedPositionsCmd holds the flags for the 'closed-positions' subcommand.
+type closedPositionsCmd struct {
+	period     string
+	date       string
+	ledgerFile string
+}
+
+func (*closedPositionsCmd) Name() string { return "closed-positions" }
+func (*closedPositionsCmd) Synopsis() string {
+	return "list the performance of the positions fully sold"
+}
+func (*closedPositionsCmd) Usage() string {
+	return `pcs closed-positions [-period <period>] [-d <date>] [-l <ledger>]
+
+  Lists the positions fully sold up to the date, grouped by the period they
+  were closed in: their holding period, the amount invested, the proceeds of
+  the sales, the dividends received, the realized gain and the annualized
+  return, dividends included.
+
+  A position opens with the first purchase of a security, and closes with the
+  sale of its last share. A security bought again later opens a new position.
+  The amounts are in the currency of the security, the totals of each period
+  in the reporting currency.
+
+Usage Examples:
+$ pcs closed-positions -period yearly
+$ pcs closed-positions -period quarterly -d 2025-12-31 -l retirement
+`
+}
+
+func (c *closedPositionsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.period, "period", portfolio.Yearly.String(), "Period of the groups of positions (day, week, month, quarter, year)")
+	f.StringVar(&c.period, "p", portfolio.Yearly.String(), "Alias for -period")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the report. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *closedPositionsCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	period, err := portfolio.ParsePeriod(c.period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderClosedPositions(renderer.NewClosedPositions(ledger.NewSnapshot(on), period)))
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// compactCmd holds the flags for the 'compact' subcommand.
+type compactCmd struct {
+	keep       string
+	before     string
+	ledgerFile string
+}
+
+func (*compactCmd) Name() string     { return "compact" }
+func (*compactCmd) Synopsis() string { return "thin out the old price history of a ledger" }
+func (*compactCmd) Usage() string {
+	return `pcs compact [-keep <period>] [-before <date>] [-l <ledger>]
+
+  Thins out the price updates older than a date down to the last price of each
+  week, month, quarter or year, for each security. This reduces the size of the
+  ledger, and the time to load it, when daily prices have been fetched for years.
+
+  Prices on the dates of other transactions are always kept, so that the values
+  of the portfolio at the end of each period and on each transaction date are
+  unchanged. In between, values use the last kept price.
+
+Usage Examples:
+# Keep weekly prices for the history older than one year.
+$ pcs compact -keep weekly
+
+# Preview the prices that monthly compaction would remove.
+$ pcs -n compact -keep monthly -before 2024-01-01
+`
+}
+
+func (c *compactCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.keep, "keep", portfolio.Weekly.String(), "Period of the prices kept (week, month, quarter, year)")
+	f.StringVar(&c.before, "before", "-1y", "Only compact the prices before this date. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to compact. Defaults to the only ledger if one exists.")
+}
+
+func (c *compactCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	period, err := portfolio.ParsePeriod(c.keep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	before, err := portfolio.ParseDate(c.before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	compacted, err := ledger.Compact(before, period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error compacting ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	prices := func(l *portfolio.Ledger) int {
+		return len(l.Query().Command(portfolio.CmdUpdatePrice).Collect())
+	}
+	removed := prices(ledger) - prices(compacted)
+	fmt.Printf("%d of %d price updates before %s removed, keeping one per %s.\n", removed, prices(ledger), before, period.Name())
+	if removed == 0 {
+		return subcommands.ExitSuccess
+	}
+
+	if err := saveLedger(compacted); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully compacted ledger %q.\n", ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// conflictsCmd holds the flags for the 'conflicts' subcommand.
+type conflictsCmd struct {
+	date       string
+	security   string
+	accept     bool
+	dismiss    bool
+	ledgerFile string
+}
+
+func (*conflictsCmd) Name() string { return "conflicts" }
+func (*conflictsCmd) Synopsis() string {
+	return "list and resolve the prices on which providers disagree"
+}
+func (*conflictsCmd) Usage() string {
+	return `pcs conflicts [-d <date>] [-s <security>] [-accept | -dismiss] [-l <ledger>]
+
+  Lists the unresolved discrepancies between market data providers: the days
+  a provider returned a price different from the price of another provider.
+
+  Conflicts are only queued with the "review" conflict policy (see the global
+  -conflicts flag): the price recorded first is kept until the conflict is
+  resolved. With -accept, the incoming price replaces it; with -dismiss, it is
+  kept. Both resolve all the conflicts listed, filter them with -d and -s.
+
+Usage Examples:
+$ pcs -conflicts review fetch
+$ pcs conflicts
+$ pcs conflicts -s AAPL -d 2025-01-02 -accept
+$ pcs conflicts -dismiss
+`
+}
+
+func (c *conflictsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", "", "Date of the conflicts. Lists all the dates by default. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker. Lists all the securities by default.")
+	f.BoolVar(&c.accept, "accept", false, "Replace the existing prices with the incoming ones")
+	f.BoolVar(&c.dismiss, "dismiss", false, "Keep the existing prices")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to review. Defaults to the only ledger if one exists.")
+}
+
+func (c *conflictsCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.accept && c.dismiss {
+		fmt.Fprintln(os.Stderr, "Error: -accept and -dismiss are mutually exclusive.")
+		return subcommands.ExitUsageError
+	}
+	var on portfolio.Date
+	if c.date != "" {
+		var err error
+		if on, err = portfolio.ParseDate(c.date); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	var selected []portfolio.PriceConflict
+	for _, conflict := range ledger.Conflicts() {
+		if c.date != "" && conflict.Date != on || c.security != "" && conflict.Security != c.security {
+			continue
+		}
+		selected = append(selected, conflict)
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(os.Stderr, "✅ No unresolved price conflicts.")
+		return subcommands.ExitSuccess
+	}
+
+	if !c.accept && !c.dismiss {
+		var b strings.Builder
+		fmt.Fprintln(&b, "# Price Conflicts")
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "| Date | Security | Existing | Source | Incoming | Source |")
+		fmt.Fprintln(&b, "|:---|:---|---:|:---|---:|:---|")
+		for _, conflict := range selected {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				conflict.Date, conflict.Security, conflict.Existing, conflict.ExistingSource, conflict.Incoming, conflict.IncomingSource)
+		}
+		printMarkdown(b.String())
+		return subcommands.ExitSuccess
+	}
+
+	for _, conflict := range selected {
+		if err := ledger.ResolveConflict(conflict, c.accept); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not resolve the conflict on %s on %s: %v\n", conflict.Security, conflict.Date, err)
+			return subcommands.ExitFailure
+		}
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Resolved %d price conflicts.\n", len(selected))
+	return subcommands.ExitSuccess
+}
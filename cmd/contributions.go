@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// contributionsCmd holds the flags for the 'contributions' subcommand.
+type contributionsCmd struct {
+	ledgerFile string
+}
+
+func (*contributionsCmd) Name() string     { return "contributions" }
+func (*contributionsCmd) Synopsis() string { return "report the cash deposited each year, by source" }
+func (*contributionsCmd) Usage() string {
+	return `pcs contributions [-l <ledger>]
+
+  Reports the cash deposited in the portfolio each year, by source: salary,
+  bonus, gift or transfert, to tell regular savings from windfalls over the
+  long term. Deposits are valued in the reporting currency on their day.
+
+  The source of a deposit is set when recording it, e.g.:
+
+    pcs deposit -a 2000 -c EUR -source salary
+
+Usage Examples:
+$ pcs contributions
+$ pcs contributions -l retirement
+`
+}
+
+func (c *contributionsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *contributionsCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderContributions(renderer.NewContributions(ledger)))
+	return subcommands.ExitSuccess
+}
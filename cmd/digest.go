@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/notify"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// digestCmd holds the flags for the 'digest' subcommand.
+type digestCmd struct {
+	period     string
+	date       string
+	email      string
+	method     string
+	ledgerFile string
+}
+
+func (*digestCmd) Name() string { return "digest" }
+func (*digestCmd) Synopsis() string {
+	return "email the review of the last period, to be run from cron"
+}
+func (*digestCmd) Usage() string {
+	return `pcs digest [-period daily|weekly|monthly|quarterly|yearly] [-email <addresses>] [-d <date>] [-method average|fifo] [-l <ledger>]
+
+  Emails the review of the last complete period before the date, today by
+  default: run weekly on Mondays, it sends the review of the previous week.
+  The review is sent as an HTML email, with its markdown as the plain text
+  alternative.
+
+  The email is sent through the SMTP server of the "email" channel of the
+  "notifications" section of the config.json file in the portfolio directory
+  (see pcs review -notify). With -email, it is sent to these comma separated
+  addresses instead of the configured ones. The sections of the review are
+  the ones of the "reviewSections" entry of the config.json file, if any.
+
+  With the -dry-run global flag, the email is printed instead of being sent.
+
+  The digest is meant to be run from cron, e.g. every Monday at 7:00:
+
+    0 7 * * 1 pcs --portfolio ~/portfolio digest -period weekly
+
+Usage Examples:
+$ pcs digest -period weekly -email me@example.com
+$ pcs digest -period monthly
+`
+}
+
+func (c *digestCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.period, "period", portfolio.Weekly.String(), "Period of the review (daily, weekly, monthly, quarterly, yearly)")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the digest, the review is the one of the period before. See the user manual for supported date formats.")
+	f.StringVar(&c.email, "email", "", "Comma separated addresses to send the digest to. Defaults to the configured ones.")
+	f.StringVar(&c.method, "method", "fifo", "Cost basis method (average, fifo)")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *digestCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	period, err := portfolio.ParsePeriod(c.period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	method, err := portfolio.ParseCostBasisMethod(c.method)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing cost basis method: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	sections, err := configEntry("reviewSections")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	opts := renderer.ReviewRenderOptions{TopMovers: 5}
+	if opts.Sections, err = renderer.ParseReviewSections(sections); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	cfg, err := notify.LoadConfig(filepath.Join(PortfolioPath(), notify.ConfigFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading notifications config: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if cfg.Email == nil {
+		fmt.Fprintln(os.Stderr, `Error: no "email" channel in the "notifications" section of the config.json file.`)
+		return subcommands.ExitFailure
+	}
+	email := *cfg.Email
+	if c.email != "" {
+		email.To = strings.Split(c.email, ",")
+		for i, to := range email.To {
+			email.To[i] = strings.TrimSpace(to)
+		}
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	// The last complete period is the one of the day before.
+	rng := period.Range(on.Add(-1))
+	review := renderer.NewReview(ledger.NewReview(rng), method)
+	md := renderer.RenderReview(review, opts)
+	subject := fmt.Sprintf("Portfolio digest for %s", rng.Identifier())
+	var page bytes.Buffer
+	if err := renderer.HTML(&page, subject, nil, md); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	msg := notify.Message{Subject: subject, Body: md, HTML: page.String(), Payload: review}
+
+	if *dryRun {
+		fmt.Printf("To: %s\nSubject: %s\n\n", strings.Join(email.To, ", "), subject)
+		printMarkdown(md)
+		return subcommands.ExitSuccess
+	}
+	if err := email.Send(ctx, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending the digest: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Sent the digest for %s to %s.\n", rng.Identifier(), strings.Join(email.To, ", "))
+	return subcommands.ExitSuccess
+}
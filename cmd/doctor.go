@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// doctorCmd holds the flags for the 'doctor' subcommand.
+type doctorCmd struct {
+	ledgerFile string
+}
+
+func (*doctorCmd) Name() string { return "doctor" }
+func (*doctorCmd) Synopsis() string {
+	return "report ledger statistics and structural problems"
+}
+func (*doctorCmd) Usage() string {
+	return `pcs doctor [-l <ledger>]
+
+  Reports the statistics of each ledger file: the number of transactions of
+  each command, the dates they span, the securities declared, and the time and
+  memory it takes to load the ledger.
+
+  It also looks for the structural problems of the file, and suggests how to
+  fix them:
+    - several init transactions;
+    - securities used before their declaration;
+    - transactions out of order;
+    - invalid transactions;
+    - a long daily price history, worth compacting.
+
+  The command fails if a problem is found.
+
+Usage Examples:
+$ pcs doctor
+$ pcs doctor -l family/joint
+`
+}
+
+func (c *doctorCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to diagnose. Defaults to all the ledgers.")
+}
+
+func (c *doctorCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	diags, err := portfolio.DiagnoseLedgers(PortfolioPath(), c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if len(diags) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no ledger found in %q.\n", PortfolioPath())
+		return subcommands.ExitFailure
+	}
+
+	var b strings.Builder
+	problems := 0
+	for _, d := range diags {
+		problems += len(d.Problems)
+		fmt.Fprintf(&b, "# Ledger %q\n\n", d.Name)
+		fmt.Fprintf(&b, "- Transactions: %d, from %s to %s\n", d.Transactions, d.From, d.To)
+		fmt.Fprintf(&b, "- Securities: %d\n", d.Securities)
+		fmt.Fprintf(&b, "- Load: %s to read, %s to build the journal, %.1f MiB allocated\n\n", d.Load.Round(time.Microsecond), d.Build.Round(time.Microsecond), float64(d.Allocated)/(1<<20))
+
+		fmt.Fprintln(&b, "| Command | Transactions |")
+		fmt.Fprintln(&b, "|:---|---:|")
+		commands := slices.Sorted(maps.Keys(d.ByCommand))
+		slices.SortStableFunc(commands, func(a, b portfolio.CommandType) int { return d.ByCommand[b] - d.ByCommand[a] })
+		for _, cmd := range commands {
+			fmt.Fprintf(&b, "| %s | %d |\n", cmd, d.ByCommand[cmd])
+		}
+		fmt.Fprintln(&b)
+
+		if len(d.Problems) == 0 {
+			fmt.Fprintln(&b, "No problems found.")
+			fmt.Fprintln(&b)
+			continue
+		}
+		fmt.Fprintln(&b, "## Problems")
+		fmt.Fprintln(&b)
+		for _, p := range d.Problems {
+			fmt.Fprintf(&b, "- %s. Fix: %s.\n", p, p.Fix)
+		}
+		fmt.Fprintln(&b)
+	}
+	printMarkdown(b.String())
+
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found.\n", problems)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintln(os.Stderr, "✅ No problems found.")
+	return subcommands.ExitSuccess
+}
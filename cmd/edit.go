@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// editCmd holds the flags for the 'edit' subcommand.
+type editCmd struct {
+	last       bool
+	date       string
+	key        string
+	query      string
+	ledgerFile string
+}
+
+func (*editCmd) Name() string     { return "edit" }
+func (*editCmd) Synopsis() string { return "edit a transaction of the ledger in your editor" }
+func (*editCmd) Usage() string {
+	return `pcs edit [-last] [-d <date>] [-key <key>] [-q <filter>] [-l <ledger>]
+
+  Opens a transaction of the ledger, in its JSON format, in your editor (the
+  VISUAL or EDITOR environment variable, vi by default). When the editor is
+  closed, the edited transaction is validated along with all the transactions
+  of the ledger, and replaces the original one. It is the safe alternative to
+  editing the ledger file by hand.
+
+  The transaction is selected with the flags below, and must be unique: -last
+  selects the last of the transactions selected by the other flags.
+
+  If the edited transaction is invalid, you can edit it again; otherwise the
+  ledger is left unchanged. An empty or unchanged transaction leaves the ledger
+  unchanged too.
+
+Usage Examples:
+# Fix the last recorded transaction.
+$ pcs edit -last
+
+# Edit the AAPL buy of a day.
+$ pcs edit -d 2025-01-06 -q "command=buy and security=AAPL"
+
+# Edit an imported transaction by its key.
+$ pcs edit -key op-42
+`
+}
+
+func (c *editCmd) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.last, "last", false, "Select the last transaction")
+	f.StringVar(&c.date, "d", "", "Select the transactions of this date. See the user manual for supported date formats.")
+	f.StringVar(&c.key, "key", "", "Select the transaction with this key")
+	f.StringVar(&c.query, "q", "", "Select the transactions matching this filter expression, see 'pcs tx'.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to edit. Defaults to the only ledger if one exists.")
+}
+
+func (c *editCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if !c.last && c.date == "" && c.key == "" && c.query == "" {
+		fmt.Fprintln(os.Stderr, "Error: select a transaction with -last, -d, -key or -q.")
+		return subcommands.ExitUsageError
+	}
+	query, err := c.selection()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	var indexes []int
+	var selected []portfolio.Transaction
+	for i, tx := range ledger.Transactions(query...) {
+		indexes, selected = append(indexes, i), append(selected, tx)
+	}
+	switch {
+	case len(selected) == 0:
+		fmt.Fprintln(os.Stderr, "Error: no transaction selected.")
+		return subcommands.ExitFailure
+	case len(selected) > 1 && !c.last:
+		fmt.Fprintf(os.Stderr, "Error: %d transactions selected, select one with more flags or with -last:\n", len(selected))
+		for _, tx := range selected {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", tx.When(), renderer.Transaction(tx))
+		}
+		return subcommands.ExitFailure
+	}
+	index, original := indexes[len(indexes)-1], selected[len(selected)-1]
+
+	var line bytes.Buffer
+	if err := portfolio.EncodeTransaction(&line, original); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	var text bytes.Buffer
+	if err := json.Indent(&text, line.Bytes(), "", "  "); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	before := text.String()
+
+	edited := before
+	for {
+		edited, err = editText(edited)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		if strings.TrimSpace(edited) == "" || edited == before {
+			fmt.Fprintln(os.Stderr, "No changes.")
+			return subcommands.ExitSuccess
+		}
+		tx, edit, err := replaceTransaction(ledger, index, edited)
+		if err == nil {
+			if err := saveLedger(edit); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			if !*dryRun {
+				fmt.Fprintf(os.Stderr, "✅ Successfully edited the transaction in ledger %q: %s %s\n", ledger.Name(), tx.When(), renderer.Transaction(tx))
+			}
+			return subcommands.ExitSuccess
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !strings.HasPrefix(strings.ToLower(ask("Edit again? [y/N] ")), "y") {
+			fmt.Fprintln(os.Stderr, "Ledger unchanged.")
+			return exitStatus(err, subcommands.ExitFailure)
+		}
+	}
+}
+
+// selection returns the predicates selecting the transactions to edit.
+func (c *editCmd) selection() ([]func(portfolio.Transaction) bool, error) {
+	var accepts []func(portfolio.Transaction) bool
+	if c.date != "" {
+		on, err := portfolio.ParseDate(c.date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		accepts = append(accepts, func(tx portfolio.Transaction) bool { return tx.When() == on })
+	}
+	if c.key != "" {
+		accepts = append(accepts, func(tx portfolio.Transaction) bool {
+			k, ok := tx.(interface{ DedupKey() string })
+			return ok && k.DedupKey() == c.key
+		})
+	}
+	if c.query != "" {
+		accept, err := portfolio.ParseFilter(c.query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		accepts = append(accepts, accept)
+	}
+	// The predicates of Transactions are alternatives, all must accept here.
+	return []func(portfolio.Transaction) bool{func(tx portfolio.Transaction) bool {
+		for _, accept := range accepts {
+			if !accept(tx) {
+				return false
+			}
+		}
+		return true
+	}}, nil
+}
+
+// replaceTransaction returns the ledger where the transaction of index i is
+// replaced by the edited transaction, in JSON.
+func replaceTransaction(ledger *portfolio.Ledger, i int, edited string) (portfolio.Transaction, *portfolio.Ledger, error) {
+	var line bytes.Buffer
+	if err := json.Compact(&line, []byte(edited)); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	tx, err := portfolio.NewDecoder(&line).Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	replaced, err := ledger.Replace(i, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, replaced, nil
+}
+
+// editText opens a text in the user's editor and returns the edited text.
+func editText(text string) (string, error) {
+	file, err := os.CreateTemp("", "pcs-edit-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(text)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor can come with arguments, like "code --wait".
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	edited, err := os.ReadFile(file.Name())
+	return string(edited), err
+}
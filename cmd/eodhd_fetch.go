@@ -7,7 +7,7 @@ import (
 	"os"
 	"strings"
 
-	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/auth"
 	"github.com/etnz/portfolio/eodhd"
 	"github.com/etnz/portfolio/renderer"
 	"github.com/google/subcommands"
@@ -48,7 +48,7 @@ func (*eodhdFetchCmd) Usage() string {
 }
 func (c *eodhdFetchCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledgerFile, "l", "", "Ledger name to update. Updates all ledgers by default.")
-	f.StringVar(&c.eodhdApiFlag, "eodhd-api-key", "", "EODHD API key to use for consuming EODHD.com API. This flag takes precedence over the "+eodhd_api_key+" environment variable. You can get one at https://eodhd.com/")
+	f.StringVar(&c.eodhdApiFlag, "eodhd-api-key", "", "EODHD API key to use for consuming EODHD.com API. This flag takes precedence over the keyring (see pcs auth) and the "+eodhd_api_key+" environment variable. You can get one at https://eodhd.com/")
 	f.BoolVar(&c.inception, "inception", false, "ignore existing prices in ledger, and fetch all from inception date")
 	f.Var(&c.tickers, "s", "security ticker to update (can be specified multiple times). If empty, all are updated.")
 	f.BoolVar(&c.fetchForex, "forex", false, "fetch data for currency pairs")
@@ -59,23 +59,23 @@ func (c *eodhdFetchCmd) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.fetchDividends, "dividends", false, "fetch dividend data")
 }
 
-// eodhdApiKey retrieves the EODHD API key from the command-line flag or the environment variable.
-// It prioritizes the flag over the environment variable.
+// eodhdApiKey retrieves the EODHD API key from the command-line flag or the credential store.
+// It prioritizes the flag over the keyring, and the keyring over the environment variable.
 func (c *eodhdFetchCmd) eodhdApiKey() string {
-	// If the flag is not set, we try to read it from the environment variable.
+	// If the flag is not set, we try to read it from the credential store.
 	if c.eodhdApiFlag == "" {
-		c.eodhdApiFlag = os.Getenv(eodhd_api_key)
+		c.eodhdApiFlag, _ = auth.Get("eodhd")
 	}
 	return c.eodhdApiFlag
 }
-func (c *eodhdFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *eodhdFetchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	key := c.eodhdApiKey()
 	if key == "" {
-		fmt.Fprintf(os.Stderr, "Error: EODHD API key is not set. Use -eodhd-api-key flag or EODHD_API_KEY environment variable\n")
+		fmt.Fprintf(os.Stderr, "Error: EODHD API key is not set. Use -eodhd-api-key flag, 'pcs auth set eodhd' or EODHD_API_KEY environment variable\n")
 		return subcommands.ExitFailure
 	}
 
-	ledgers, err := DecodeLedgers(c.ledgerFile)
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
 		return subcommands.ExitFailure
@@ -106,12 +106,14 @@ func (c *eodhdFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 		options |= eodhd.FetchDividends
 	}
 
+	status := subcommands.ExitSuccess
 	for _, ledger := range ledgers {
 		ledgerName := ledger.Name()
 		fmt.Fprintf(os.Stderr, "Processing ledger %q...\n", ledgerName)
-		_, updates, err := eodhd.Fetch(key, ledger, c.inception, options, c.tickers...)
+		_, updates, err := eodhd.Fetch(ctx, key, ledger, c.inception, options, c.tickers...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: could not fetch from eodhd.com for ledger %q: %v\n", ledgerName, err)
+			status = exitStatus(err, subcommands.ExitFailure)
 			continue // Continue to the next ledger
 		}
 
@@ -122,7 +124,7 @@ func (c *eodhdFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 			continue
 		}
 
-		if err := portfolio.SaveLedger(PortfolioPath(), ledger); err != nil {
+		if err := saveLedger(ledger); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing updated ledger file for %q: %v\n", ledgerName, err)
 			continue
 		}
@@ -135,6 +137,9 @@ func (c *eodhdFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 		}
 	}
 
+	if status != subcommands.ExitSuccess {
+		return status
+	}
 	fmt.Fprintf(os.Stderr, "✅ Successfully fetched from eodhd.com and updated ledgers.\n")
 	return subcommands.ExitSuccess
 }
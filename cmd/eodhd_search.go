@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/auth"
 	"github.com/etnz/portfolio/eodhd"
 	"github.com/google/subcommands"
 )
@@ -32,21 +33,21 @@ func (*eodhdSearchCmd) Usage() string {
 }
 
 func (c *eodhdSearchCmd) SetFlags(f *flag.FlagSet) {
-	flag.StringVar(&c.eodhdApiFlag, "eodhd-api-key", "", "EODHD API key to use for consuming EODHD.com API. This flag takes precedence over the "+eodhd_api_key+" environment variable. You can get one at https://eodhd.com/")
+	flag.StringVar(&c.eodhdApiFlag, "eodhd-api-key", "", "EODHD API key to use for consuming EODHD.com API. This flag takes precedence over the keyring (see pcs auth) and the "+eodhd_api_key+" environment variable. You can get one at https://eodhd.com/")
 	f.BoolVar(&c.showErrors, "show-errors", false, "Display entries with invalid ISINs and print error messages")
 }
 
-// eodhdApiKey retrieves the EODHD API key from the command-line flag or the environment variable.
-// It prioritizes the flag over the environment variable.
+// eodhdApiKey retrieves the EODHD API key from the command-line flag or the credential store.
+// It prioritizes the flag over the keyring, and the keyring over the environment variable.
 func (c *eodhdSearchCmd) eodhdApiKey() string {
-	// If the flag is not set, we try to read it from the environment variable.
+	// If the flag is not set, we try to read it from the credential store.
 	if c.eodhdApiFlag == "" {
-		c.eodhdApiFlag = os.Getenv(eodhd_api_key)
+		c.eodhdApiFlag, _ = auth.Get("eodhd")
 	}
 	return c.eodhdApiFlag
 }
 
-func (c *eodhdSearchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *eodhdSearchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if f.NArg() == 0 {
 		fmt.Fprintln(os.Stderr, "Error: a search term is required.")
 		return subcommands.ExitUsageError
@@ -55,14 +56,14 @@ func (c *eodhdSearchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interf
 
 	key := c.eodhdApiKey()
 	if key == "" {
-		fmt.Fprintf(os.Stderr, "Error: EODHD API key is not set. Use -eodhd-api-key flag or EODHD_API_KEY environment variable\n")
+		fmt.Fprintf(os.Stderr, "Error: EODHD API key is not set. Use -eodhd-api-key flag, 'pcs auth set eodhd' or EODHD_API_KEY environment variable\n")
 		return subcommands.ExitFailure
 	}
 
-	results, err := eodhd.Search(key, searchTerm)
+	results, err := eodhd.Search(ctx, key, searchTerm)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error searching securities: %v\n", err)
-		return subcommands.ExitFailure
+		return exitStatus(err, subcommands.ExitFailure)
 	}
 
 	if len(results) == 0 {
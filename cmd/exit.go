@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// Exit statuses of the commands failing on an error of a known kind, after the
+// generic ExitFailure (1) and ExitUsageError (2), so that scripts can tell them
+// apart.
+const (
+	ExitValidation       subcommands.ExitStatus = 3 // a transaction is invalid.
+	ExitInsufficientCash subcommands.ExitStatus = 4 // a transaction pays more than the cash balance.
+	ExitUnknownSecurity  subcommands.ExitStatus = 5 // a ticker is not declared in the ledger.
+	ExitProvider         subcommands.ExitStatus = 6 // a market data provider failed.
+)
+
+// exitStatus returns the exit status of a command failing on err: the status of
+// its kind, or otherwise the status given.
+func exitStatus(err error, otherwise subcommands.ExitStatus) subcommands.ExitStatus {
+	switch {
+	case errors.Is(err, portfolio.ErrInsufficientCash):
+		return ExitInsufficientCash
+	case errors.Is(err, portfolio.ErrUnknownSecurity):
+		return ExitUnknownSecurity
+	case errors.Is(err, portfolio.ErrProvider):
+		return ExitProvider
+	case errors.Is(err, portfolio.ErrValidation):
+		return ExitValidation
+	}
+	return otherwise
+}
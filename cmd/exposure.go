@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/lookthrough"
+	"github.com/google/subcommands"
+)
+
+// exposureCmd holds the flags for the 'exposure' subcommand.
+type exposureCmd struct {
+	lookthrough bool
+	dir         string
+	top         int
+	date        string
+	ledgerFile  string
+}
+
+func (*exposureCmd) Name() string { return "exposure" }
+func (*exposureCmd) Synopsis() string {
+	return "display the exposure to companies, sectors and regions"
+}
+func (*exposureCmd) Usage() string {
+	return `pcs exposure [-lookthrough] [-dir <directory>] [-top <n>] [-d <date>] [-l <ledger>]
+
+  Displays the exposure of the securities held to companies, sectors and
+  regions, in the reporting currency. Cash and cash equivalents (see pcs
+  declare -cash) are not included.
+
+  Without -lookthrough, each security is its own exposure. With -lookthrough,
+  the funds are decomposed into their constituents, and the exposures to the
+  same company through several funds, or held directly, are added up.
+
+  The constituents of a security are read from the CSV file named after its
+  ticker in the constituents directory of the portfolio, e.g.
+  "constituents/VWCE.csv", with a header and one row per constituent:
+
+    name,weight,sector,region
+    Apple,4.8,Technology,United States
+
+  The weight is in percent of the fund, the sector and the region are
+  optional. The file is written from the fund factsheet, or by a provider.
+
+Usage Examples:
+$ pcs exposure
+$ pcs exposure -lookthrough -top 10
+`
+}
+
+func (c *exposureCmd) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.lookthrough, "lookthrough", false, "Decompose the funds into their constituents")
+	f.StringVar(&c.dir, "dir", "", "Directory of the constituents files. Defaults to 'constituents' in the portfolio directory.")
+	f.IntVar(&c.top, "top", 20, "Number of companies to list, 0 for all")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the holdings. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *exposureCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	s := ledger.NewSnapshot(on)
+	values := make(map[string]float64)
+	for ticker := range s.Securities() {
+		if s.Position(ticker).IsZero() || s.IsDust(ticker) {
+			continue
+		}
+		if sec, ok := s.SecurityDetails(ticker); ok && sec.CashEquivalent() {
+			continue
+		}
+		values[ticker] = s.Convert(s.MarketValue(ticker)).AsFloat()
+	}
+	var breakdowns map[string][]lookthrough.Constituent
+	if c.lookthrough {
+		dir := c.dir
+		if dir == "" {
+			dir = filepath.Join(PortfolioPath(), "constituents")
+		}
+		breakdowns, err = lookthrough.Load(dir, slices.Sorted(maps.Keys(values))...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+	r := lookthrough.Aggregate(values, breakdowns)
+
+	cur := s.ReportingCurrency()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Exposure of %s on %s\n\n", ledger.Name(), on)
+	fmt.Fprintf(&b, "Securities held: %s", portfolio.M(r.Total, cur))
+	if c.lookthrough {
+		fmt.Fprintf(&b, ", %d of %d decomposed into their constituents", len(breakdowns), len(values))
+	}
+	fmt.Fprintln(&b, ".")
+	table := func(title, column string, exposures []lookthrough.Exposure, top int) {
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		fmt.Fprintf(&b, "| %s | Value | Weight |\n", column)
+		fmt.Fprintln(&b, "|:---|---:|---:|")
+		for i, e := range exposures {
+			if top > 0 && i == top {
+				fmt.Fprintf(&b, "| *%d more* | | |\n", len(exposures)-top)
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", e.Name, portfolio.M(e.Value, cur), portfolio.Percent(e.Weight))
+		}
+	}
+	if !c.lookthrough {
+		table("Securities", "Security", r.Companies, 0)
+		printMarkdown(b.String())
+		return subcommands.ExitSuccess
+	}
+	table("Companies", "Company", r.Companies, c.top)
+	table("Sectors", "Sector", r.Sectors, 0)
+	table("Regions", "Region", r.Regions, 0)
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
@@ -40,11 +40,7 @@ func RunExtension(subcommand string, args []string) (bool, int) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	// Pass global flags as environment variables
-	cmd.Env = os.Environ() // Start with existing environment variables
-	cmd.Env = append(cmd.Env, EnvPortfolioPath+"="+PortfolioPath())
-	cmd.Env = append(cmd.Env, EnvDefaultCurrency+"="+*defaultCurrency)
-	cmd.Env = append(cmd.Env, EnvVerbose+"="+strconv.FormatBool(*Verbose))
+	cmd.Env = extensionEnv()
 
 	if err := cmd.Run(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -60,3 +56,13 @@ func RunExtension(subcommand string, args []string) (bool, int) {
 
 	return true, 0 // External command executed successfully with exit code 0
 }
+
+// extensionEnv returns the environment of the extensions: the existing
+// environment variables, and the global flags.
+func extensionEnv() []string {
+	env := os.Environ()
+	env = append(env, EnvPortfolioPath+"="+PortfolioPath())
+	env = append(env, EnvDefaultCurrency+"="+*defaultCurrency)
+	env = append(env, EnvVerbose+"="+strconv.FormatBool(*Verbose))
+	return env
+}
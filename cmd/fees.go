@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// feesCmd holds the flags for the 'fees' subcommand.
+type feesCmd struct {
+	date       string
+	ledgerFile string
+}
+
+func (*feesCmd) Name() string     { return "fees" }
+func (*feesCmd) Synopsis() string { return "estimate the annual fees of the funds held" }
+func (*feesCmd) Usage() string {
+	return `pcs fees [-d <date>] [-l <ledger>]
+
+  Estimates the annual fees paid by the portfolio: the total expense ratio
+  (TER) of each security held, applied to its market value for a year, and the
+  expense ratio of the whole portfolio of securities, weighted by their value.
+
+  The TER of a fund is set when declaring it, e.g.:
+
+    pcs declare -s VWCE -id IE00BK5BQT80.XETR -c EUR -ter 0.22
+
+Usage Examples:
+$ pcs fees
+$ pcs fees -d 2025-12-31 -l retirement
+`
+}
+
+func (c *feesCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the holdings. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *feesCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderFees(renderer.NewFees(ledger.NewSnapshot(on))))
+	return subcommands.ExitSuccess
+}
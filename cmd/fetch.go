@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/amundi"
+	"github.com/etnz/portfolio/auth"
+	"github.com/etnz/portfolio/eodhd"
+	"github.com/etnz/portfolio/fundplatform"
+	"github.com/etnz/portfolio/insee"
+	"github.com/google/subcommands"
+)
+
+// fetchStatusFile is the default status file of the fetch daemon, in the
+// portfolio directory.
+const fetchStatusFile = "fetch-status.json"
+
+// fetchCmd implements the "fetch" command.
+type fetchCmd struct {
+	ledgerFile string
+	daemon     bool
+	every      time.Duration
+	statusFile string
+}
+
+func (*fetchCmd) Name() string { return "fetch" }
+func (*fetchCmd) Synopsis() string {
+	return "fetch market data from all the configured providers, once or periodically"
+}
+func (*fetchCmd) Usage() string {
+	return `pcs fetch [-l <ledger>] [-daemon [-every <duration>]] [-status <file>]
+
+  Fetches market data from all the configured providers and updates the
+  ledgers: eodhd.com if an API key is set (see pcs auth), data.insee.fr, and
+  Amundi if logged in (see pcs amundi login), and the fund platforms logged in
+  (see pcs platform login).
+
+  With -daemon, it keeps running and fetches again every period, 24h by
+  default. A fetch never runs while an exchange of the securities is trading:
+  it waits for the close, so that the prices fetched are the final end-of-day
+  prices. Ledgers are reloaded before each fetch, to pick up the transactions
+  recorded in the meantime.
+
+  The daemon writes the last success and the last error of each provider to a
+  JSON status file, fetch-status.json in the portfolio directory by default,
+  for monitoring.
+
+Usage Examples:
+$ pcs fetch
+$ pcs fetch -daemon -every 24h
+$ pcs fetch -daemon -every 6h -status /var/lib/pcs/status.json
+`
+}
+
+func (c *fetchCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger name to update. Updates all ledgers by default.")
+	f.BoolVar(&c.daemon, "daemon", false, "keep running and fetch periodically")
+	f.DurationVar(&c.every, "every", 24*time.Hour, "period of the fetches in daemon mode")
+	f.StringVar(&c.statusFile, "status", "", "status file to write, defaults to "+fetchStatusFile+" in the portfolio directory in daemon mode")
+}
+
+// fetchProvider is a provider of market data for the fetch command.
+type fetchProvider struct {
+	name  string
+	fetch func(ctx context.Context, ledger *portfolio.Ledger) ([]portfolio.Transaction, error)
+}
+
+// fetchProviders returns the providers configured, in order.
+func fetchProviders() []fetchProvider {
+	var providers []fetchProvider
+	if key, err := auth.Get("eodhd"); err == nil && key != "" {
+		providers = append(providers, fetchProvider{"eodhd", func(ctx context.Context, ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+			_, updates, err := eodhd.Fetch(ctx, key, ledger, false, 0)
+			return updates, err
+		}})
+	}
+	providers = append(providers, fetchProvider{"insee", func(ctx context.Context, ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+		return insee.Fetch(ctx, ledger, false)
+	}})
+	if headers, err := amundi.LoadHeaders(); err == nil {
+		providers = append(providers, fetchProvider{"amundi", func(ctx context.Context, ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+			_, updates, err := amundi.Fetch(ctx, headers, ledger, false)
+			return updates, err
+		}})
+	}
+	platforms, err := loadPlatforms()
+	if err != nil {
+		log.Printf("fund platforms ignored: %v", err)
+	}
+	for _, p := range platforms {
+		if headers, err := fundplatform.LoadSession(p.Name); err == nil {
+			providers = append(providers, fetchProvider{p.Source(), func(ctx context.Context, ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+				return p.Fetch(ctx, headers, ledger, false)
+			}})
+		}
+	}
+	return providers
+}
+
+// fetchStatus is the content of the status file.
+type fetchStatus struct {
+	LastRun   time.Time                  `json:"lastRun,omitzero"`
+	NextRun   time.Time                  `json:"nextRun,omitzero"`
+	Providers map[string]*providerStatus `json:"providers"`
+}
+
+// providerStatus is the status of a provider in the status file.
+type providerStatus struct {
+	LastSuccess time.Time `json:"lastSuccess,omitzero"`
+	LastError   time.Time `json:"lastError,omitzero"`
+	Error       string    `json:"error,omitempty"` // Error is the message of the last error.
+	Updates     int       `json:"updates"`         // Updates is the number of updates applied by the last fetch.
+}
+
+// readFetchStatus reads the status file, empty if it does not exist.
+func readFetchStatus(name string) (*fetchStatus, error) {
+	status := &fetchStatus{Providers: make(map[string]*providerStatus)}
+	content, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, status); err != nil {
+		return nil, fmt.Errorf("invalid status file %q: %w", name, err)
+	}
+	if status.Providers == nil {
+		status.Providers = make(map[string]*providerStatus)
+	}
+	return status, nil
+}
+
+// write writes the status file, replacing it atomically.
+func (s *fetchStatus) write(name string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (c *fetchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.every <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -every must be a positive duration, e.g. 24h.")
+		return subcommands.ExitUsageError
+	}
+	if c.statusFile == "" && c.daemon {
+		c.statusFile = filepath.Join(PortfolioPath(), fetchStatusFile)
+	}
+	status := &fetchStatus{Providers: make(map[string]*providerStatus)}
+	if c.statusFile != "" {
+		var err error
+		if status, err = readFetchStatus(c.statusFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+
+	if !c.daemon {
+		return c.run(ctx, status)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	for {
+		c.run(ctx, status)
+		next := time.Now().Add(c.every)
+		if ledgers, err := DecodeLedgers(ctx, c.ledgerFile); err == nil {
+			var mics []string
+			for _, ledger := range ledgers {
+				mics = append(mics, ledger.Exchanges()...)
+			}
+			slices.Sort(mics)
+			next = portfolio.AfterMarketClose(next, slices.Compact(mics)...)
+		}
+		status.NextRun = next
+		if err := status.write(c.statusFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the status file: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Next fetch at %s.\n", next.Format(time.DateTime))
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "Fetch daemon stopped.")
+			return subcommands.ExitSuccess
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// run fetches the market data of all the providers for all the ledgers, once,
+// and records the outcome of each provider in the status.
+func (c *fetchCmd) run(ctx context.Context, status *fetchStatus) subcommands.ExitStatus {
+	status.LastRun = time.Now()
+	exit := subcommands.ExitSuccess
+	defer func() {
+		if c.statusFile == "" {
+			return
+		}
+		if err := status.write(c.statusFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the status file: %v\n", err)
+		}
+	}()
+
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if len(ledgers) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no ledgers found to update.\n")
+		return subcommands.ExitSuccess
+	}
+
+	for _, provider := range fetchProviders() {
+		ps, ok := status.Providers[provider.name]
+		if !ok {
+			ps = &providerStatus{}
+			status.Providers[provider.name] = ps
+		}
+		var errs []error
+		updated := 0
+		for _, ledger := range ledgers {
+			updates, err := provider.fetch(ctx, ledger)
+			if err == nil {
+				var summary portfolio.MarketDataUpdate
+				if summary, err = ledger.UpdateMarketData(updates...); err == nil {
+					updated += summary.Total()
+					err = saveLedger(ledger)
+				}
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("ledger %q: %w", ledger.Name(), err))
+				fmt.Fprintf(os.Stderr, "Error: could not fetch from %s for ledger %q: %v\n", provider.name, ledger.Name(), err)
+				if exit == subcommands.ExitSuccess {
+					exit = exitStatus(err, subcommands.ExitFailure)
+				}
+			}
+		}
+
+		ps.Updates = updated
+		if err := errors.Join(errs...); err != nil {
+			ps.LastError, ps.Error = time.Now(), err.Error()
+			continue
+		}
+		ps.LastSuccess = time.Now()
+		fmt.Fprintf(os.Stderr, "Fetched from %s, %d updates applied.\n", provider.name, updated)
+	}
+
+	if exit == subcommands.ExitSuccess {
+		fmt.Fprintf(os.Stderr, "✅ Successfully fetched market data and updated ledgers.\n")
+	}
+	return exit
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/projection"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// fireCmd holds the flags for the 'fire' subcommand.
+type fireCmd struct {
+	spend        float64
+	swr          string
+	contribution string
+	returns      string
+	horizon      int
+	paths        int
+	seed         uint64
+	date         string
+	ledgerFile   string
+}
+
+func (*fireCmd) Name() string { return "fire" }
+func (*fireCmd) Synopsis() string {
+	return "report the progress toward financial independence"
+}
+func (*fireCmd) Usage() string {
+	return `pcs fire -spend <amount> [-swr <rate>] [-contribution <amount>[/month|/year]] [-returns <list>] [-horizon <years>] [-paths <n>] [-seed <n>] [-d <date>] [-l <ledger>]
+
+  Reports the progress of the portfolio toward financial independence: the
+  target value funds the annual spending, in the reporting currency, by
+  withdrawing a safe share of the portfolio every year (the safe withdrawal
+  rate). With a spending of 36000 and a rate of 3.5%, the target is 1028571.
+
+  The crossover date, when the portfolio reaches the target, is projected with
+  regular contributions:
+
+  - by Monte Carlo simulation of the monthly returns estimated from the price
+    history of the portfolio held on the date (see pcs project): the dates by
+    which 5%, 25%, 50%, 75% and 95% of the simulations reached the target.
+  - with constant annual returns, for the historical return and each of the
+    returns of the -returns flag, to show the sensitivity to the returns.
+
+  These are estimates based on past prices, not predictions.
+
+Usage Examples:
+$ pcs fire -spend 36000 -swr 3.5%
+$ pcs fire -spend 36000 -contribution 24000/year -returns 2,4,6,8 -seed 42
+`
+}
+
+func (c *fireCmd) SetFlags(f *flag.FlagSet) {
+	f.Float64Var(&c.spend, "spend", 0, "Annual spending to fund, in the reporting currency (required)")
+	f.StringVar(&c.swr, "swr", "4%", "Safe withdrawal rate, the share of the portfolio withdrawn every year")
+	f.StringVar(&c.contribution, "contribution", "0", "Regular contribution, per month by default (e.g. 500/month, 6000/year)")
+	f.StringVar(&c.returns, "returns", "0,3,5,7", "Comma separated list of constant annual returns, in percent")
+	f.IntVar(&c.horizon, "horizon", 50, "Number of years beyond which the target is considered not reached")
+	f.IntVar(&c.paths, "paths", 10000, "Number of simulated paths")
+	f.Uint64Var(&c.seed, "seed", 0, "Seed of the simulation, for reproducible results. Random by default.")
+	f.StringVar(&c.date, "d", "0d", "Date of the report. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *fireCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.spend <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -spend is required and must be positive.")
+		return subcommands.ExitUsageError
+	}
+	swr, err := strconv.ParseFloat(strings.TrimSuffix(c.swr, "%"), 64)
+	if err != nil || swr <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid withdrawal rate %q, want a positive percentage.\n", c.swr)
+		return subcommands.ExitUsageError
+	}
+	contribution, err := parseContribution(c.contribution)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	var returns []float64
+	for _, r := range strings.Split(c.returns, ",") {
+		ret, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(r), "%"), 64)
+		if err != nil || ret <= -100 {
+			fmt.Fprintf(os.Stderr, "Error: invalid annual return %q.\n", r)
+			return subcommands.ExitUsageError
+		}
+		returns = append(returns, ret)
+	}
+	if c.horizon <= 0 || c.paths <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -horizon and -paths must be positive.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	s := ledger.NewSnapshot(on)
+	cur := s.ReportingCurrency()
+	value := s.TotalPortfolio().AsFloat()
+	target := c.spend / (swr / 100)
+	months := 12 * c.horizon
+	report := &renderer.Fire{
+		Name:           ledger.Name(),
+		Date:           on,
+		Spend:          portfolio.M(c.spend, cur),
+		WithdrawalRate: portfolio.Percent(swr),
+		Target:         portfolio.M(target, cur),
+		Value:          s.TotalPortfolio(),
+		Progress:       portfolio.Percent(100 * value / target),
+		Contribution:   portfolio.M(contribution, cur),
+		Horizon:        c.horizon,
+	}
+	// crossover returns the end of the month of a crossover.
+	crossover := func(m int) portfolio.Date {
+		if m < 0 {
+			return portfolio.Date{}
+		}
+		return on.StartOf(portfolio.Monthly).AddMonth(m).EndOf(portfolio.Monthly)
+	}
+
+	// Without enough price history, only the constant returns are reported.
+	history, err := projection.History(ctx, ledger, on, portfolio.NewRange(ledger.GlobalInceptionDate(), on))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if mean, volatility, err := projection.Estimate(history); err == nil {
+		seed := c.seed
+		if seed == 0 {
+			seed = uint64(time.Now().UnixNano())
+		}
+		params := projection.Params{
+			Value:        value,
+			Contribution: contribution,
+			Mean:         mean,
+			Volatility:   volatility,
+			Paths:        c.paths,
+			Seed:         seed,
+		}
+		for i, m := range projection.Crossover(params, target, months) {
+			report.Crossovers = append(report.Crossovers, renderer.FireCrossover{
+				Chance: portfolio.Percent(projection.Percentiles[i]),
+				Date:   crossover(m),
+				Months: m,
+			})
+		}
+		m := projection.MonthsTo(value, contribution, mean, target, months)
+		report.Scenarios = append(report.Scenarios, renderer.FireScenario{
+			Name:   "Historical",
+			Return: portfolio.Percent(100 * (math.Exp(12*mean) - 1)),
+			Date:   crossover(m),
+			Months: m,
+		})
+	}
+	for _, ret := range returns {
+		m := projection.MonthsTo(value, contribution, math.Log1p(ret/100)/12, target, months)
+		report.Scenarios = append(report.Scenarios, renderer.FireScenario{
+			Name:   "Fixed",
+			Return: portfolio.Percent(ret),
+			Date:   crossover(m),
+			Months: m,
+		})
+	}
+	printMarkdown(renderer.RenderFire(report))
+	return subcommands.ExitSuccess
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/etnz/portfolio"
 	"github.com/google/subcommands"
 )
 
 type fmtCmd struct {
 	ledgerFile string
+	layout     string
 }
 
 func (*fmtCmd) Name() string { return "fmt" }
@@ -19,26 +19,44 @@ func (*fmtCmd) Synopsis() string {
 	return "validates and formats the ledger file into a canonical form"
 }
 func (*fmtCmd) Usage() string {
-	return `pcs fmt [-l <ledger_name>]
+	return `pcs fmt [-l <ledger_name>] [-layout inline|split]
 
   Validates and formats the ledger file. This command reads all transactions,
   validates them, applies available quick-fixes (like resolving "sell all"),
   sorts them by date, and writes them back in a canonical JSONL format.
+  Missing currency pairs, needed to convert foreign currencies into the
+  reporting currency, are declared.
   By default, it formats all ledgers in-place. Use -l to specify a single ledger.
 
+  With -layout split, price updates are moved out of the ledger file into
+  one CSV file per year, in a directory next to it (e.g. ledger.prices/2024.csv),
+  which keeps the ledger file small. Price updates with a memo stay in the
+  ledger file. With -layout inline, they are moved back into the ledger file.
+  By default, each ledger keeps its current layout.
+
 Usage Examples:
 # Writes to the default ledger file.
 $ pcs fmt
 
+# Store the price updates in per-year files.
+$ pcs fmt -layout split
+
 `
 }
 
 func (p *fmtCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&p.ledgerFile, "l", "", "Ledger to format. Formats all by default.")
+	f.StringVar(&p.layout, "layout", "", "Storage of price updates: 'inline' in the ledger file, or 'split' in per-year files. Keeps the current layout by default.")
 }
 
-func (p *fmtCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	ledgers, err := DecodeLedgers(p.ledgerFile)
+func (p *fmtCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	switch p.layout {
+	case "", "inline", "split":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid layout %q, must be 'inline' or 'split'\n", p.layout)
+		return subcommands.ExitUsageError
+	}
+	ledgers, err := DecodeLedgers(ctx, p.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
 		return subcommands.ExitFailure
@@ -59,8 +77,15 @@ func (p *fmtCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) s
 			fmt.Fprintf(os.Stderr, "Error formatting ledger %q: %v\n", ledgerName, err)
 			continue
 		}
+		if err := declareCurrencyPairs(formattedLedger); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting ledger %q: %v\n", ledgerName, err)
+			continue
+		}
+		if p.layout != "" {
+			formattedLedger.SetSplitPrices(p.layout == "split")
+		}
 
-		if err := portfolio.SaveLedger(PortfolioPath(), formattedLedger); err != nil {
+		if err := saveLedger(formattedLedger); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving formatted ledger %q: %v\n", ledgerName, err)
 			continue
 		}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// grepCmd holds the flags for the 'grep' subcommand.
+type grepCmd struct {
+	ignoreCase bool
+	fixed      bool
+	ledgerFile string
+}
+
+func (*grepCmd) Name() string     { return "grep" }
+func (*grepCmd) Synopsis() string { return "search memos, counterparties and tickers" }
+func (*grepCmd) Usage() string {
+	return `pcs grep [-i] [-F] [-l <ledger>] <pattern>
+
+  Searches the memos, counterparty names and tickers of the transactions of all
+  ledgers, or of a single ledger with -l. The pattern is a regular expression.
+
+  Each match is printed with its ledger file, line number, date and a summary of
+  the transaction.
+
+Usage Examples:
+# Find all transactions mentioning a rebalancing.
+$ pcs grep -i rebalanc
+
+# Find the transactions of the tax account in John's ledgers.
+$ pcs grep -F -l john/bnp TaxAccount
+`
+}
+
+func (c *grepCmd) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.ignoreCase, "i", false, "Ignore case")
+	f.BoolVar(&c.fixed, "F", false, "Interpret the pattern as a fixed string, not a regular expression")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to search. Defaults to all ledgers.")
+}
+
+func (c *grepCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: exactly one pattern is required.")
+		return subcommands.ExitUsageError
+	}
+	pattern := f.Arg(0)
+	if c.fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if c.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid pattern: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	matches, err := portfolio.Grep(PortfolioPath(), c.ledgerFile, re)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	for _, m := range matches {
+		memo := ""
+		if r, ok := m.Transaction.(interface{ Rationale() string }); ok && r.Rationale() != "" {
+			memo = fmt.Sprintf(" (%s)", r.Rationale())
+		}
+		fmt.Printf("%s:%d: %s: %s%s\n", m.File, m.Line, m.Transaction.When(), renderer.Transaction(m.Transaction), memo)
+	}
+	if len(matches) == 0 {
+		// Like grep, report that nothing matched with the exit status.
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
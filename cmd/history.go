@@ -17,28 +17,45 @@ import (
 type historyCmd struct {
 	security   string
 	currency   string
+	last       int
 	ledgerFile string
 }
 
 func (*historyCmd) Name() string     { return "history" }
-func (*historyCmd) Synopsis() string { return "display asset value history" }
+func (*historyCmd) Synopsis() string { return "display asset value history, or ledger changes" }
 func (*historyCmd) Usage() string {
-	return `pcs history -s <security> | -c <currency> [-l <ledger>]\n\n  Displays the value of a single asset or cash account over time.`
+	return `pcs history [-s <security> | -c <currency>] [-last <n>] [-l <ledger>]
+
+  Displays the value of a single asset or cash account over time.
+
+  Without -s nor -c, displays the audit log of the ledger instead: the
+  transactions added (+) or removed (-) by each pcs command, and when. A
+  transaction modified in place, like a price merged by a market data update,
+  is both removed and added.
+
+Usage Examples:
+# Value of the AAPL position over time.
+$ pcs history -s AAPL
+
+# Last 10 changes of the ledger.
+$ pcs history -last 10
+`
 }
 
 func (c *historyCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.security, "s", "", "security ticker to report on")
 	f.StringVar(&c.currency, "c", "", "currency of cash account to report on")
+	f.IntVar(&c.last, "last", 0, "number of most recent changes of the audit log to display, 0 for all")
 	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
 }
 
-func (c *historyCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	if (c.security == "" && c.currency == "") || (c.security != "" && c.currency != "") {
-		fmt.Fprintln(os.Stderr, "either -s or -c must be provided")
+func (c *historyCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.security != "" && c.currency != "" {
+		fmt.Fprintln(os.Stderr, "-s and -c are mutually exclusive")
 		return subcommands.ExitUsageError
 	}
 
-	ledger, err := DecodeLedger(c.ledgerFile)
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			fmt.Fprintln(os.Stderr, "Ledger file not found. Nothing to report.")
@@ -48,6 +65,19 @@ func (c *historyCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 		return subcommands.ExitFailure
 	}
 
+	if c.security == "" && c.currency == "" {
+		entries, err := portfolio.ReadAuditLog(PortfolioPath(), ledger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading audit log: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		if c.last > 0 && len(entries) > c.last {
+			entries = entries[len(entries)-c.last:]
+		}
+		printMarkdown(renderer.AuditMarkdown(ledger.Name(), entries))
+		return subcommands.ExitSuccess
+	}
+
 	var predicate func(portfolio.Transaction) bool
 	if c.security != "" {
 		predicate = portfolio.BySecurity(c.security)
@@ -57,7 +87,7 @@ func (c *historyCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 
 	// Build a list of all unique days where there was a significant transaction.
 	dates := make(map[portfolio.Date]struct{})
-	for _, tx := range ledger.Transactions(predicate) {
+	for _, tx := range ledger.Query().Where(predicate).All() {
 		dates[tx.When()] = struct{}{}
 	}
 
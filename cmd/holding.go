@@ -9,30 +9,48 @@ import (
 	"github.com/etnz/portfolio"
 	"github.com/etnz/portfolio/renderer"
 	"github.com/google/subcommands"
+	"github.com/shopspring/decimal"
 )
 
 // holdingCmd holds the flags for the 'holding' subcommand.
 type holdingCmd struct {
 	date       string
 	update     bool
+	dust       decimal.Decimal
+	stale      int
+	sub        string
 	ledgerFile string
 }
 
 func (*holdingCmd) Name() string     { return "holding" }
 func (*holdingCmd) Synopsis() string { return "displays portfolio holdings on a specific date" }
 func (*holdingCmd) Usage() string {
-	return `pcs holding [-d <date>] [-l <ledger>]
+	return `pcs holding [-d <date>] [-dust <quantity>] [-stale <days>] [-sub <label>] [-l <ledger>]
 
   Displays the portfolio's holdings (positions and cash balances) as of a specific date.
+
+  Securities are valued with their last price on that date. The securities
+  whose price is older than -stale days, or missing, are listed after the
+  holdings, as their value may be far off.
+
+  With -dust, negligible positions up to that quantity are not listed. Use
+  'pcs cleanup-dust' to remove them from the ledger.
+
+  With -sub, the holdings are the ones of a sub-portfolio: the transactions
+  labelled with "portfolio=<label>" in their memo. Its cash balances are
+  virtual, funded by its own deposits only.
 `
 }
 
 func (c *holdingCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date for the holdings report. See the user manual for supported date formats.")
+	f.Var(DecimalVar(&c.dust, "0"), "dust", "Do not list positions up to this quantity")
+	f.IntVar(&c.stale, "stale", 7, "List the prices older than this number of days, 0 to not list them")
+	f.StringVar(&c.sub, "sub", "", "Report on the sub-portfolio with this label")
 	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
 }
 
-func (c *holdingCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *holdingCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	on, err := portfolio.ParseDate(c.date)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
@@ -43,27 +61,44 @@ func (c *holdingCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 		c.update = true
 	}
 
-	ledgers, err := DecodeLedgers(c.ledgerFile)
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
 		return subcommands.ExitFailure
 	}
 
-	var snaps []*portfolio.Snapshot
 	for _, ledger := range ledgers {
 		if c.update {
-			err := ledger.UpdateIntraday()
+			err := ledger.UpdateIntraday(ctx)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: could not update intraday prices: %v\n", err)
 				// Continue without failing
 			}
 		}
-		snaps = append(snaps, ledger.NewSnapshot(on))
+	}
+	if c.sub != "" {
+		if ledgers, err = subPortfolios(ledgers, c.sub); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+	var snaps []*portfolio.Snapshot
+	for _, ledger := range ledgers {
+		snap := ledger.NewSnapshot(on).IgnoreDust(portfolio.Q(c.dust))
+		if err := snap.CheckTotals(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: ledger %q: %v\n", ledger.Name(), err)
+			return subcommands.ExitFailure
+		}
+		snaps = append(snaps, snap)
 	}
 
 	var md string
 	if len(snaps) == 1 {
-		md = renderer.RenderHolding(renderer.NewHolding(snaps[0]))
+		h := renderer.NewHolding(snaps[0])
+		if c.stale > 0 {
+			h.CheckPrices(snaps[0], c.stale)
+		}
+		md = renderer.RenderHolding(h)
 	} else {
 		md = renderer.RenderConsolidatedHolding(renderer.NewConsolidatedHolding(snaps))
 	}
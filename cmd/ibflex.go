@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/auth"
+	"github.com/etnz/portfolio/ibflex"
+	"github.com/google/subcommands"
+)
+
+// ibflexCmd holds the flags for the 'ibflex' subcommand.
+type ibflexCmd struct {
+	query      string
+	file       string
+	ledgerFile string
+}
+
+func (*ibflexCmd) Name() string { return "ibflex" }
+func (*ibflexCmd) Synopsis() string {
+	return "record the trades and cash movements of an Interactive Brokers account"
+}
+func (*ibflexCmd) Usage() string {
+	return `pcs ibflex [-q <query id>] [-file <statement.xml>] [-l <ledger>]
+
+  Runs a Flex Query on the Interactive Brokers Flex Web Service and records
+  its trades, dividends, fees and cash movements in the ledger.
+
+  Define the Flex Query in the IB Client Portal (Performance & Reports > Flex
+  Queries) with the Trades and Cash Transactions sections, in XML. Its ID is
+  given with -q, or the "ibflexQuery" entry of the config.json file. The Flex
+  Web Service token is a credential: store it with 'pcs auth set ibflex' or in
+  the IBFLEX_TOKEN environment variable.
+
+  The securities are matched by ISIN, or by ticker, with the securities of the
+  ledger. The transactions already recorded are skipped: run the command
+  regularly on a query covering the last days.
+
+  With -file, the statement is read from a file downloaded from the Client
+  Portal instead.
+
+Usage Examples:
+$ pcs ibflex -q 123456
+$ pcs ibflex -file statement.xml -l ib
+`
+}
+
+func (c *ibflexCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.query, "q", "", "Flex Query ID. Defaults to the \"ibflexQuery\" of the config.json file.")
+	f.StringVar(&c.file, "file", "", "Flex statement file, in XML, instead of the Flex Web Service.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to add the transactions to.")
+}
+
+func (c *ibflexCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var data []byte
+	if c.file != "" {
+		var err error
+		if data, err = os.ReadFile(c.file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	} else {
+		if c.query == "" {
+			var err error
+			if c.query, err = configEntry("ibflexQuery"); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return subcommands.ExitFailure
+			}
+		}
+		if c.query == "" {
+			fmt.Fprintln(os.Stderr, "Error: a Flex Query ID is required (-q).")
+			return subcommands.ExitUsageError
+		}
+		token, err := auth.Get("ibflex")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		fmt.Fprintln(os.Stderr, "Requesting the Flex statement...")
+		if data, err = ibflex.Fetch(ctx, token, c.query); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not fetch the Flex statement: %v\n", err)
+			return exitStatus(err, subcommands.ExitFailure)
+		}
+	}
+	statement, err := ibflex.Parse(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	txs, err := statement.Convert(ledger)
+	if err != nil {
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		for _, err := range joined.Unwrap() {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d invalid transactions, nothing recorded.\n", len(joined.Unwrap()))
+		return subcommands.ExitFailure
+	}
+
+	var failed, count, skipped int
+	status := subcommands.ExitFailure // the status of the first invalid transaction.
+	for _, tx := range txs {
+		if ledger.Duplicate(tx) != nil {
+			skipped++
+			continue
+		}
+		if _, err := appendTransaction(ledger, tx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s on %s: %v\n", tx.What(), tx.When(), err)
+			if failed == 0 {
+				status = exitStatus(err, subcommands.ExitFailure)
+			}
+			failed++
+			continue
+		}
+		count++
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d invalid transactions, nothing recorded.\n", failed)
+		return status
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d transactions already recorded, skipped.\n", skipped)
+	}
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No transactions to record.")
+		return subcommands.ExitSuccess
+	}
+
+	if err := declareCurrencyPairs(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully recorded %d transactions in ledger %q.\n", count, ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
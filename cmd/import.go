@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/csvmap"
+	"github.com/etnz/portfolio/frbroker"
+	"github.com/google/subcommands"
+)
+
+// importCmd holds the flags for the 'import' subcommand.
+type importCmd struct {
+	ledgerFile string
+	mapping    string
+	broker     string
+}
+
+func (*importCmd) Name() string { return "import" }
+func (*importCmd) Synopsis() string {
+	return "record the transactions of a broker CSV export using a mapping file"
+}
+func (*importCmd) Usage() string {
+	return `pcs import (-map <mapping.json> | -broker <broker>) [-l <ledger>] <file.csv>
+
+  Records the transactions of a CSV file exported by any broker or bank,
+  converted by a mapping file: which column holds each transaction field, the
+  date format, the decimal separator, how to translate the operation names
+  into commands, and which rows to keep. For instance:
+
+    {
+      "comma": ";",
+      "dateFormat": "02/01/2006",
+      "decimal": ",",
+      "columns": {"command": "Operation", "date": "Date", "security": "ISIN",
+                  "quantity": "Quantity", "amount": "Net amount", "key": "Reference"},
+      "values": {"currency": "EUR"},
+      "commands": {"Achat": "buy", "Vente": "sell", "Virement": "deposit"},
+      "securities": {"IE00BK5BQT80": "VWCE"},
+      "filters": [{"column": "Status", "equals": "Executed"}]
+    }
+
+  The fields are command, date, security, quantity, amount, currency, memo,
+  key and account, with the meaning of the ledger format. Rows whose operation
+  is not listed in "commands" are errors: filter them out explicitly.
+
+  The exports of French PEA and CTO brokers have a dedicated importer, select
+  it with -broker instead of a mapping: boursedirect or boursorama. The
+  securities are matched by ISIN with the securities of the ledger, and the
+  financial transaction tax (TTF) is added to the cost of the purchase it
+  applies to.
+
+  Like apply, transactions are only recorded if they are all valid. Map the
+  reference of the operations to "key", and use the -skip-duplicates global
+  flag to import overlapping exports safely.
+
+Usage Examples:
+$ pcs import -map mybroker.json export.csv
+$ pcs -skip-duplicates import -map mybroker.json -l retirement export-2025.csv
+$ pcs import -broker boursedirect -l pea operations.csv
+`
+}
+
+func (c *importCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to add the transactions to.")
+	f.StringVar(&c.mapping, "map", "", "Mapping file describing the CSV file.")
+	f.StringVar(&c.broker, "broker", "", "French broker of the CSV export: boursedirect or boursorama.")
+}
+
+func (c *importCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if (c.mapping == "") == (c.broker == "") {
+		fmt.Fprintln(os.Stderr, "Error: either a mapping file (-map) or a broker (-broker) is required.")
+		return subcommands.ExitUsageError
+	}
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: a CSV file is required.")
+		return subcommands.ExitUsageError
+	}
+	var convert func(io.Reader, *portfolio.Ledger) ([]portfolio.Transaction, error)
+	if c.broker != "" {
+		broker, err := frbroker.Lookup(c.broker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		convert = broker.Convert
+	} else {
+		mapping, err := csvmap.LoadMapping(c.mapping)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		convert = func(r io.Reader, _ *portfolio.Ledger) ([]portfolio.Transaction, error) { return mapping.Convert(r) }
+	}
+	file, err := os.Open(f.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer file.Close()
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	txs, err := convert(file, ledger)
+	if err != nil {
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		for _, err := range joined.Unwrap() {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d invalid rows, nothing recorded.\n", len(joined.Unwrap()))
+		return subcommands.ExitFailure
+	}
+
+	var failed, count, skipped int
+	status := subcommands.ExitFailure // the status of the first invalid transaction.
+	for _, tx := range txs {
+		_, err := appendTransaction(ledger, tx)
+		if errors.Is(err, errDuplicate) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s on %s: %v\n", tx.What(), tx.When(), err)
+			if failed == 0 {
+				status = exitStatus(err, subcommands.ExitFailure)
+			}
+			failed++
+			continue
+		}
+		count++
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d invalid transactions, nothing recorded.\n", failed)
+		return status
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d transactions already recorded, skipped.\n", skipped)
+	}
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No transactions to record.")
+		return subcommands.ExitSuccess
+	}
+
+	if err := declareCurrencyPairs(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully imported %d transactions in ledger %q.\n", count, ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
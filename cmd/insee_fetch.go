@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/etnz/portfolio"
 	"github.com/etnz/portfolio/insee"
 	"github.com/etnz/portfolio/renderer"
 	"github.com/google/subcommands"
@@ -24,6 +23,11 @@ func (*inseeFetchCmd) Usage() string {
 	return `insee fetch:
 
 Fetches market data from data.insee.fr.
+
+Securities whose ID is "INSEE-" followed by the ID of a series are updated with
+its values, e.g. the French consumer price index, to review in real terms:
+
+  pcs declare -s CPI -id INSEE-001759970 -c EUR
 `
 }
 
@@ -32,8 +36,8 @@ func (c *inseeFetchCmd) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.inception, "inception", false, "ignore existing prices in ledger, and fetch all from inception date")
 }
 
-func (c *inseeFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	ledgers, err := DecodeLedgers(c.ledgerFile)
+func (c *inseeFetchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
 		return subcommands.ExitFailure
@@ -44,12 +48,14 @@ func (c *inseeFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 		return subcommands.ExitSuccess
 	}
 
+	status := subcommands.ExitSuccess
 	for _, ledger := range ledgers {
 		ledgerName := ledger.Name()
 		fmt.Fprintf(os.Stderr, "Processing ledger %q...\n", ledgerName)
-		updates, err := insee.Fetch(ledger, c.inception)
+		updates, err := insee.Fetch(ctx, ledger, c.inception)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: could not fetch from data.insee.fr for ledger %q: %v\n", ledgerName, err)
+			status = exitStatus(err, subcommands.ExitFailure)
 			continue
 		}
 
@@ -60,7 +66,7 @@ func (c *inseeFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 			continue
 		}
 
-		if err := portfolio.SaveLedger(PortfolioPath(), ledger); err != nil {
+		if err := saveLedger(ledger); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing updated ledger file for %q: %v\n", ledgerName, err)
 			continue
 		}
@@ -75,6 +81,9 @@ func (c *inseeFetchCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 		}
 	}
 
+	if status != subcommands.ExitSuccess {
+		return status
+	}
 	fmt.Fprintf(os.Stderr, "✅ Successfully fetched from data.insee.fr and updated ledgers.\n")
 	return subcommands.ExitSuccess
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// intradayCmd holds the flags for the 'intraday' subcommand.
+type intradayCmd struct {
+	date       string
+	security   string
+	noUpdate   bool
+	ledgerFile string
+}
+
+func (*intradayCmd) Name() string { return "intraday" }
+func (*intradayCmd) Synopsis() string {
+	return "record the current prices and display the price path of the day"
+}
+func (*intradayCmd) Usage() string {
+	return `pcs intraday [-d <date>] [-s <security>] [-no-update] [-l <ledger>]
+
+  Fetches the current prices of the securities held, records them as quotes
+  and displays the quotes of the day, one table per security.
+
+  The price of the day is the last quote: reports always value the securities
+  at their end-of-day price. The quotes are kept for the last 5 days in the
+  file <ledger>.intraday.csv next to the ledger, run the command periodically
+  during the trading day to record the price path.
+
+  Prices are only fetched for the current day, unless -no-update is set.
+
+Usage Examples:
+$ pcs intraday
+$ pcs intraday -s AAPL -d -1d
+# Record the prices every half hour, from cron.
+*/30 9-17 * * 1-5 pcs intraday > /dev/null
+`
+}
+
+func (c *intradayCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the quotes. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker. Displays all the securities by default.")
+	f.BoolVar(&c.noUpdate, "no-update", false, "Do not fetch the current prices")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to update. Defaults to the only ledger if one exists.")
+}
+
+func (c *intradayCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	var tickers []string
+	if c.security != "" {
+		if ledger.Security(c.security) == nil {
+			fmt.Fprintf(os.Stderr, "Error: security %q not declared\n", c.security)
+			return subcommands.ExitUsageError
+		}
+		tickers = append(tickers, c.security)
+	}
+
+	if on == ledger.Today() && !c.noUpdate {
+		if err := ledger.UpdateIntraday(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not update intraday prices: %v\n", err)
+		}
+		if err := saveLedger(ledger); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+
+	printMarkdown(renderer.IntradayMarkdown(on, ledger.Intraday(on, tickers...)))
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// journalCmd holds the flags for the 'journal' subcommand.
+type journalCmd struct {
+	security   string
+	date       string
+	ledgerFile string
+}
+
+func (*journalCmd) Name() string { return "journal" }
+func (*journalCmd) Synopsis() string {
+	return "review the trades and notes of a security with their price context"
+}
+func (*journalCmd) Usage() string {
+	return `pcs journal -s <security> [-d <date>] [-l <ledger>]
+
+  Lists in date order the trades, dividends and notes of a security, with
+  their memo, the price of the security on that day, and the change of the
+  price since then, to review the decisions made on the security.
+
+  Trades record their strategy with 'pcs buy -strategy', and notes record a
+  dated commentary with 'pcs note'.
+
+Usage Examples:
+$ pcs note -s AAPL -m "Services revenue keeps growing, hold."
+$ pcs journal -s AAPL
+`
+}
+
+func (c *journalCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the review. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *journalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.security == "" {
+		fmt.Fprintln(os.Stderr, "Error: -s flag is required.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	if ledger.Security(c.security) == nil {
+		fmt.Fprintf(os.Stderr, "Error: security %q is not declared in the ledger.\n", c.security)
+		return subcommands.ExitUsageError
+	}
+
+	last := ledger.NewSnapshot(on).Price(c.security)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Journal of %s on %s\n\n", c.security, on)
+	fmt.Fprintf(&b, "Price on %s: %s.\n\n", on, last)
+	fmt.Fprintln(&b, "| Date | Entry | Memo | Price | Since |")
+	fmt.Fprintln(&b, "|:---|:---|:---|---:|---:|")
+	entries := 0
+	for _, tx := range ledger.Transactions(portfolio.BySecurity(c.security)) {
+		if tx.When().After(on) {
+			break
+		}
+		var memo string
+		if m, ok := tx.(interface{ Rationale() string }); ok {
+			memo = m.Rationale()
+		}
+		switch tx.What() {
+		case portfolio.CmdDeclare, portfolio.CmdUpdatePrice:
+			continue
+		case portfolio.CmdNote:
+			memo = "" // Already the entry.
+		}
+		price, since := "-", "-" // No price known on that day.
+		if p := ledger.NewSnapshot(tx.When()).Price(c.security); !p.IsZero() {
+			price = p.String()
+			since = portfolio.Percent(100 * (last.AsFloat() - p.AsFloat()) / p.AsFloat()).SignedString()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", tx.When(), cell(renderer.Transaction(tx)), cell(memo), price, since)
+		entries++
+	}
+	if entries == 0 {
+		fmt.Fprintf(os.Stderr, "No trades or notes for %q up to %s.\n", c.security, on)
+		return subcommands.ExitSuccess
+	}
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
+
+// cell escapes a text for a markdown table cell.
+func cell(s string) string { return strings.ReplaceAll(s, "|", `\|`) }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// logCmd holds the flags for the 'log' subcommand.
+type logCmd struct {
+	period     string
+	last       int
+	date       string
+	sparkline  bool
+	ledgerFile string
+}
+
+func (*logCmd) Name() string     { return "log" }
+func (*logCmd) Synopsis() string { return "list the performance of consecutive periods" }
+func (*logCmd) Usage() string {
+	return `pcs log [-period <period>] [-last <n>] [-d <date>] [-sparkline] [-l <ledger>]
+
+  Lists the last periods up to the date, one row per period with its start
+  value, capital flows, gains, time-weighted return and end value.
+
+  With -sparkline, the returns are also summarized as a unicode sparkline.
+
+Usage Examples:
+# Monthly performance over the last year.
+$ pcs log -period monthly -last 12 -sparkline
+`
+}
+
+func (c *logCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.period, "period", portfolio.Monthly.String(), "Period of each row (day, week, month, quarter, year)")
+	f.StringVar(&c.period, "p", portfolio.Monthly.String(), "Alias for -period")
+	f.IntVar(&c.last, "last", 12, "Number of periods to list")
+	f.StringVar(&c.date, "d", "0d", "Date of the last period. See the user manual for supported date formats.")
+	f.BoolVar(&c.sparkline, "sparkline", false, "Summarize the returns as a unicode sparkline")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *logCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	period, err := portfolio.ParsePeriod(c.period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	if c.last <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -last must be positive.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	from := on
+	for range c.last - 1 {
+		from = from.StartOf(period).Add(-1)
+	}
+	reviews, err := ledger.GenerateLog(portfolio.NewRange(from, on), period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderLog(renderer.NewLog(reviews, period, c.sparkline)))
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/taxrules"
+	"github.com/google/subcommands"
+)
+
+// ltEligibleCmd holds the flags for the 'lt-eligible' subcommand.
+type ltEligibleCmd struct {
+	longTermDays int
+	jurisdiction string
+	within       int
+	date         string
+	ledgerFile   string
+}
+
+func (*ltEligibleCmd) Name() string { return "lt-eligible" }
+func (*ltEligibleCmd) Synopsis() string {
+	return "list the lots held and the date their gains become long-term"
+}
+func (*ltEligibleCmd) Usage() string {
+	return `pcs lt-eligible [-long-term-days <days>] [-jurisdiction <name>] [-within <days>] [-d <date>] [-l <ledger>]
+
+  Lists the lots of securities held, matched against the sales in FIFO order,
+  with their holding period, their unrealized gain, and the date from which
+  selling them is eligible for the long-term tax treatment, to plan
+  tax-efficient sales.
+
+  A lot is long-term when held for strictly more than -long-term-days, or the
+  threshold of the -jurisdiction (see pcs taxes). With -within, only the lots
+  already long-term or becoming long-term in that many days are listed.
+
+Usage Examples:
+$ pcs lt-eligible
+$ pcs lt-eligible -within 90 -long-term-days 730
+`
+}
+
+func (c *ltEligibleCmd) SetFlags(f *flag.FlagSet) {
+	f.IntVar(&c.longTermDays, "long-term-days", portfolio.DefaultTaxRules.LongTermDays, "Holding period in days above which a gain is long-term.")
+	f.StringVar(&c.jurisdiction, "jurisdiction", "", "Tax jurisdiction of the long-term threshold ("+strings.Join(taxrules.Names(), ", ")+"). Overrides -long-term-days.")
+	f.IntVar(&c.within, "within", -1, "Only list the lots long-term within that many days, all if negative.")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the holdings. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *ltEligibleCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	rules := portfolio.TaxRules{Method: portfolio.FIFO, LongTermDays: c.longTermDays}
+	if c.jurisdiction != "" {
+		jurisdiction, err := taxrules.Lookup(c.jurisdiction)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		rules = jurisdiction.Rules()
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	s := ledger.NewSnapshot(on)
+	var lots []portfolio.OpenLot
+	for ticker := range s.Securities() {
+		for _, l := range s.OpenLots(ticker) {
+			if c.within < 0 || !l.LongTermDate(rules).After(on.Add(c.within)) {
+				lots = append(lots, l)
+			}
+		}
+	}
+	slices.SortStableFunc(lots, func(a, b portfolio.OpenLot) int {
+		return a.LongTermDate(rules).Compare(b.LongTermDate(rules))
+	})
+
+	cur := s.ReportingCurrency()
+	longTerm, shortTerm := portfolio.M(0, cur), portfolio.M(0, cur)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Long-Term Eligibility of %s on %s\n\n", ledger.Name(), on)
+	fmt.Fprintf(&b, "Gains are long-term after %d days of holding.\n\n", rules.LongTermDays)
+	if len(lots) == 0 {
+		fmt.Fprintln(&b, "No lots held.")
+		printMarkdown(b.String())
+		return subcommands.ExitSuccess
+	}
+	fmt.Fprintln(&b, "| Ticker | Acquired | Quantity | Cost | Unrealized Gain | Held | Long-Term From |")
+	fmt.Fprintln(&b, "|:---|:---|---:|---:|---:|---:|:---|")
+	for _, l := range lots {
+		gain := s.Price(l.Security).Mul(l.Quantity).Sub(l.Cost)
+		status := fmt.Sprintf("%s (in %d days)", l.LongTermDate(rules), l.LongTermDate(rules).Sub(on))
+		if l.LongTerm(on, rules) {
+			status = fmt.Sprintf("%s ✅", l.LongTermDate(rules))
+			longTerm = longTerm.Add(s.Convert(gain))
+		} else {
+			shortTerm = shortTerm.Add(s.Convert(gain))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %d days | %s |\n", l.Security, l.Acquired, l.Quantity, l.Cost, gain.SignedString(), l.HoldingDays(on), status)
+	}
+	fmt.Fprintf(&b, "\nUnrealized gains: %s long-term, %s short-term.\n", longTerm, shortTerm)
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// mergeLedgersCmd holds the flags for the 'merge-ledgers' subcommand.
+type mergeLedgersCmd struct {
+	out     string
+	resolve string
+}
+
+func (*mergeLedgersCmd) Name() string     { return "merge-ledgers" }
+func (*mergeLedgersCmd) Synopsis() string { return "merge two ledger files into a new one" }
+func (*mergeLedgersCmd) Usage() string {
+	return `pcs merge-ledgers -out <file> [-resolve first|second|rename|ask] <first.jsonl> <second.jsonl>
+
+  Merges the transactions of two ledger files into a new ledger file, validated
+  in chronological order. The transactions of the second ledger already in the
+  first one are skipped. A ticker declared for the same security in both
+  ledgers is declared once. The ledgers must have the same reporting currency.
+
+  A ticker declared for different securities in the two ledgers (different IDs)
+  is a conflict. Without -resolve, the conflicts are reported and nothing is
+  written. Otherwise, each conflict is resolved by:
+    first   keeping the declaration of the first ledger for both;
+    second  keeping the declaration of the second ledger for both;
+    rename  keeping both securities, the ticker of the second one is suffixed
+            with "-2";
+    ask     asking for each conflict.
+
+  The source ledgers are not modified, and the output file must not exist.
+
+Usage Examples:
+$ pcs merge-ledgers -out merged.jsonl a.jsonl b.jsonl
+$ pcs merge-ledgers -resolve rename -out merged.jsonl a.jsonl b.jsonl
+`
+}
+
+func (c *mergeLedgersCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.out, "out", "", "File to write the merged ledger to")
+	f.StringVar(&c.resolve, "resolve", "", "Resolution of the conflicting declarations: first, second, rename or ask. Reports them by default.")
+}
+
+func (c *mergeLedgersCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 2 || c.out == "" {
+		fmt.Fprintln(os.Stderr, "Error: two ledger files and the -out flag are required.")
+		return subcommands.ExitUsageError
+	}
+	var resolve func(portfolio.Conflict) portfolio.Resolution
+	switch c.resolve {
+	case "", "ask":
+		resolve = askResolution
+	case "first":
+		resolve = func(portfolio.Conflict) portfolio.Resolution { return portfolio.KeepFirst }
+	case "second":
+		resolve = func(portfolio.Conflict) portfolio.Resolution { return portfolio.KeepSecond }
+	case "rename":
+		resolve = func(portfolio.Conflict) portfolio.Resolution { return portfolio.RenameSecond }
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid resolution %q, must be first, second, rename or ask.\n", c.resolve)
+		return subcommands.ExitUsageError
+	}
+
+	var ledgers [2]*portfolio.Ledger
+	for i, path := range f.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		ledgers[i], err = portfolio.DecodeLedger(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", path, err)
+			return subcommands.ExitFailure
+		}
+	}
+
+	conflicts := portfolio.Conflicts(ledgers[0], ledgers[1])
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "Conflict: %q is declared as %s in %s and as %s in %s.\n", conflict.Ticker, conflict.First.ID, f.Arg(0), conflict.Second.ID, f.Arg(1))
+	}
+	if len(conflicts) > 0 && c.resolve == "" {
+		fmt.Fprintln(os.Stderr, "Error: resolve the conflicts with -resolve first, second, rename or ask.")
+		return subcommands.ExitFailure
+	}
+	merged, err := portfolio.Merge(ledgers[0], ledgers[1], resolve)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error merging ledgers: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	if err := createLedgerFile(c.out, merged); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if *dryRun {
+		return subcommands.ExitSuccess
+	}
+	count := func(l *portfolio.Ledger) int { return len(l.Query().Collect()) }
+	skipped := count(ledgers[0]) + count(ledgers[1]) - count(merged)
+	fmt.Fprintf(os.Stderr, "✅ Merged %d transactions into %q, %d duplicates skipped.\n", count(merged), c.out, skipped)
+	return subcommands.ExitSuccess
+}
+
+// stdin reads the answers to the interactive questions.
+var stdin = bufio.NewReader(os.Stdin)
+
+// askResolution asks on the terminal how to resolve a conflict.
+func askResolution(c portfolio.Conflict) portfolio.Resolution {
+	for {
+		fmt.Fprintf(os.Stderr, "Keep %q as %s (1), as %s (2), or both renaming the second %q (r)? ", c.Ticker, c.First.ID, c.Second.ID, c.Renamed())
+		answer, err := stdin.ReadString('\n')
+		switch strings.TrimSpace(answer) {
+		case "1":
+			return portfolio.KeepFirst
+		case "2":
+			return portfolio.KeepSecond
+		case "r":
+			return portfolio.RenameSecond
+		}
+		if err != nil {
+			// No answer, keep both securities rather than mixing them up.
+			fmt.Fprintln(os.Stderr)
+			return portfolio.RenameSecond
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/migrations"
+	"github.com/google/subcommands"
+)
+
+// migrateLedgerCmd holds the flags for the 'migrate-ledger' subcommand.
+type migrateLedgerCmd struct {
+	list       bool
+	ledgerFile string
+}
+
+func (*migrateLedgerCmd) Name() string { return "migrate-ledger" }
+func (*migrateLedgerCmd) Synopsis() string {
+	return "upgrade a ledger file to the current schema version"
+}
+func (*migrateLedgerCmd) Usage() string {
+	return `pcs migrate-ledger [-list] [-l <ledger>]
+
+  Upgrades a ledger file written by an older version of pcs to the current
+  schema version, and records the version in its init transaction.
+
+  The lines of older versions are already upgraded in memory when a ledger is
+  loaded, so old files can be read by all the commands. This command rewrites
+  the file, so that it is also readable by the tools reading the ledger files
+  directly. With -list, the migrations of the schema are listed instead.
+
+Usage Examples:
+$ pcs migrate-ledger -list
+$ pcs migrate-ledger -l john/bnp
+$ pcs -dry-run migrate-ledger
+`
+}
+
+func (c *migrateLedgerCmd) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.list, "list", false, "List the migrations of the schema.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to migrate. Defaults to the only ledger if one exists.")
+}
+
+func (c *migrateLedgerCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.list {
+		if len(migrations.Migrations) == 0 {
+			fmt.Fprintf(os.Stderr, "The schema has no migration, its version is %d.\n", portfolio.SchemaVersion)
+		}
+		for _, m := range migrations.Migrations {
+			fmt.Printf("%d: %s\n", m.Version, m.Description)
+		}
+		return subcommands.ExitSuccess
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	if ledger.SchemaVersion() == portfolio.SchemaVersion {
+		fmt.Fprintf(os.Stderr, "Ledger %q is already at schema version %d.\n", ledger.Name(), portfolio.SchemaVersion)
+		return subcommands.ExitSuccess
+	}
+	from, err := ledger.Migrate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	for _, m := range migrations.Since(from) {
+		fmt.Printf("%d: %s\n", m.Version, m.Description)
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully migrated ledger %q from schema version %d to %d.\n", ledger.Name(), from, portfolio.SchemaVersion)
+	}
+	return subcommands.ExitSuccess
+}
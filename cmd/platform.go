@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio/fundplatform"
+	"github.com/etnz/portfolio/notify"
+	"github.com/google/subcommands"
+)
+
+// platformCmd is a container for the fund platform subcommands.
+type platformCmd struct{}
+
+func (*platformCmd) Name() string     { return "platform" }
+func (*platformCmd) Synopsis() string { return "fund platform commands, e.g. employer savings plans" }
+func (*platformCmd) Usage() string {
+	return `platform <subcommand> [args]
+
+  Fetches the NAVs of the funds of fund administrator portals configured in the
+  "platforms" section of the config.json file of the portfolio, see
+  'pcs topic global-flags'.
+
+Commands:
+  login - Store the session of a platform from a curl command.
+  fetch - Fetch the latest NAVs of the funds of the platforms.
+`
+}
+
+func (c *platformCmd) SetFlags(f *flag.FlagSet) {}
+func (c *platformCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	commander := subcommands.NewCommander(f, "platform")
+	commander.Register(&platformLoginCmd{}, "")
+	commander.Register(&platformFetchCmd{}, "")
+	return commander.Execute(ctx, args...)
+}
+
+// loadPlatforms returns the fund platforms configured in the config.json file
+// of the portfolio.
+func loadPlatforms() ([]fundplatform.Platform, error) {
+	return fundplatform.LoadConfig(filepath.Join(PortfolioPath(), notify.ConfigFile))
+}
+
+// selectPlatforms returns the configured platform with that name, or all the
+// configured platforms if name is empty.
+func selectPlatforms(name string) ([]fundplatform.Platform, error) {
+	platforms, err := loadPlatforms()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return platforms, nil
+	}
+	i := slices.IndexFunc(platforms, func(p fundplatform.Platform) bool { return strings.EqualFold(p.Name, name) })
+	if i < 0 {
+		names := make([]string, len(platforms))
+		for i, p := range platforms {
+			names[i] = p.Name
+		}
+		return nil, fmt.Errorf("unknown platform %q, configured platforms are: %s", name, strings.Join(names, ", "))
+	}
+	return platforms[i : i+1], nil
+}
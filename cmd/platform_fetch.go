@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/fundplatform"
+	"github.com/google/subcommands"
+)
+
+// platformFetchCmd implements the "platform fetch" command.
+type platformFetchCmd struct {
+	platform   string
+	inception  bool
+	ledgerFile string
+}
+
+func (*platformFetchCmd) Name() string     { return "fetch" }
+func (*platformFetchCmd) Synopsis() string { return "fetches the NAVs of the funds of fund platforms" }
+func (*platformFetchCmd) Usage() string {
+	return `pcs platform fetch [-p <platform>] [-inception] [-l <ledger>]
+
+Fetches the NAVs of the funds declared in the ledger with the ID
+"<platform>-<code>", and updates the ledger prices. It starts with today's
+NAVs and, as long as there are new prices, it continues backward.
+
+Usage Examples:
+$ pcs platform fetch
+$ pcs platform fetch -p Natixis -inception
+`
+}
+
+func (c *platformFetchCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.platform, "p", "", "Name of the platform to fetch. Fetches all the platforms by default.")
+	f.BoolVar(&c.inception, "inception", false, "ignore existing prices in ledger, and fetch all from today back to inception date")
+	f.StringVar(&c.ledgerFile, "l", "", "ledger name to update. update all ledgers by default.")
+}
+
+func (c *platformFetchCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	platforms, err := selectPlatforms(c.platform)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	if len(platforms) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no platforms configured in the config.json file.")
+		return subcommands.ExitUsageError
+	}
+
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not load ledgers: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if len(ledgers) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no ledgers found to update.\n")
+		return subcommands.ExitSuccess
+	}
+
+	status := subcommands.ExitSuccess
+	for _, p := range platforms {
+		headers, err := fundplatform.LoadSession(p.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: not logged in to %s, run pcs platform login -p %s first: %v\n", p.Name, p.Name, err)
+			status = subcommands.ExitFailure
+			continue
+		}
+		for _, ledger := range ledgers {
+			updates, err := p.Fetch(ctx, headers, ledger, c.inception)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not fetch from %s for ledger %q: %v\n", p.Name, ledger.Name(), err)
+				status = exitStatus(err, subcommands.ExitFailure)
+				continue
+			}
+			summary, err := ledger.UpdateMarketData(updates...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not add market data to the ledger %q: %v\n", ledger.Name(), err)
+				status = subcommands.ExitFailure
+				continue
+			}
+			if err := saveLedger(ledger); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing updated ledger file for %q: %v\n", ledger.Name(), err)
+				status = subcommands.ExitFailure
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Fetched %s for ledger %q, %d updates applied.\n", p.Name, ledger.Name(), summary.Total())
+		}
+	}
+
+	if status != subcommands.ExitSuccess {
+		return status
+	}
+	fmt.Fprintf(os.Stderr, "✅ Successfully fetched from the fund platforms and updated ledgers.\n")
+	return subcommands.ExitSuccess
+}
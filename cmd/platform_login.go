@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio/fundplatform"
+	"github.com/google/subcommands"
+)
+
+// platformLoginCmd implements the "platform login" command.
+type platformLoginCmd struct {
+	platform string
+	headers  headerFlags
+	// Deprecated flags for curl compatibility
+	curl string
+	body string
+}
+
+func (*platformLoginCmd) Name() string { return "login" }
+func (*platformLoginCmd) Synopsis() string {
+	return "stores the session of a fund platform from a curl command"
+}
+func (*platformLoginCmd) Usage() string {
+	return `pcs platform login -p <platform> -curl -H <header1> -H <header2> ...
+
+Stores the session headers of a fund platform for use by 'pcs platform fetch'.
+
+This command works well with the following workflow:
+  - in a browser, copy an authenticated request to the platform as 'curl'
+  - in a terminal type 'pcs platform login -p <platform> -' then paste the
+    curl command.
+
+Usage Examples:
+$ pcs platform login -p Natixis -H 'Authorization: Bearer ...'
+`
+}
+
+func (c *platformLoginCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.platform, "p", "", "Name of the platform, as configured in the config.json file")
+	f.Var(&c.headers, "H", "Header for the request (can be specified multiple times)")
+	// Deprecated flags for curl compatibility
+	f.StringVar(&c.curl, "curl", "", "ignored, for curl compatibility")
+	f.StringVar(&c.body, "b", "", "ignored, for curl compatibility")
+}
+
+func (c *platformLoginCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.platform == "" {
+		fmt.Fprintln(os.Stderr, "Error: -p is required.")
+		return subcommands.ExitUsageError
+	}
+	if len(c.headers) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: at least one -H flag is required.")
+		return subcommands.ExitUsageError
+	}
+	platforms, err := selectPlatforms(c.platform)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	if err := fundplatform.SaveSession(platforms[0].Name, c.headers); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to save %s session: %v\n", platforms[0].Name, err)
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf("✅ %s session credentials successfully stored.\n", platforms[0].Name)
+	return subcommands.ExitSuccess
+}
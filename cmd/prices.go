@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// pricesCmd holds the flags for the 'prices' subcommand.
+type pricesCmd struct {
+	security   string
+	from       string
+	to         string
+	period     string
+	compare    string
+	csv        bool
+	ledgerFile string
+}
+
+func (*pricesCmd) Name() string     { return "prices" }
+func (*pricesCmd) Synopsis() string { return "display the price history of a security" }
+func (*pricesCmd) Usage() string {
+	return `pcs prices -s <security> [-from <date>] [-to <date>] [-p <period>] [-compare <security>] [-csv] [-l <ledger>]
+
+  Lists the prices of a security stored in the ledger by update-price
+  transactions. With -p, prices are summarized by week, month, quarter or year
+  with their open, high, low and close.
+
+  Business days without a price are reported as gaps.
+
+  With -compare, the closing prices of another security, or of a currency pair
+  like USDEUR, are listed side by side.
+
+  Daily prices are listed with their source, the market data provider that
+  supplied them, empty for the prices recorded manually.
+
+  With -csv, the history is written as CSV with the columns:
+    date,open,high,low,close,missing,source[,<compared security>]
+
+Usage Examples:
+# AAPL prices over the last year.
+$ pcs prices -s AAPL -from -1y
+
+# Monthly AAPL prices, compared to the USD/EUR exchange rate.
+$ pcs prices -s AAPL -from -1y -p month -compare USDEUR
+
+# Export the AAPL prices to a spreadsheet.
+$ pcs prices -s AAPL -csv > aapl.csv
+`
+}
+
+func (c *pricesCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.StringVar(&c.from, "from", "", "Start date of the history. Defaults to the first price. See the user manual for supported date formats.")
+	f.StringVar(&c.to, "to", "", "End date of the history. Defaults to today. See the user manual for supported date formats.")
+	f.StringVar(&c.period, "p", portfolio.Daily.String(), "Period of each row (day, week, month, quarter, year)")
+	f.StringVar(&c.compare, "compare", "", "Security or currency pair to compare with")
+	f.BoolVar(&c.csv, "csv", false, "Write the history as CSV")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *pricesCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.security == "" {
+		fmt.Fprintln(os.Stderr, "-s must be provided")
+		return subcommands.ExitUsageError
+	}
+	period, err := portfolio.ParsePeriod(c.period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	to := portfolio.Today()
+	if c.to != "" {
+		if to, err = portfolio.ParseDate(c.to); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing end date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	var from portfolio.Date
+	if c.from != "" {
+		if from, err = portfolio.ParseDate(c.from); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing start date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	if from.After(to) {
+		fmt.Fprintf(os.Stderr, "Error: start date %s is after end date %s\n", from, to)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Ledger file not found. Nothing to report.")
+			return subcommands.ExitSuccess
+		}
+		fmt.Fprintf(os.Stderr, "Error decoding ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	for _, ticker := range []string{c.security, c.compare} {
+		if ticker != "" && ledger.Security(ticker) == nil {
+			fmt.Fprintf(os.Stderr, "Error: security %q not declared\n", ticker)
+			return subcommands.ExitFailure
+		}
+	}
+
+	r := portfolio.NewRange(from, to)
+	bars := ledger.OHLC(c.security, r, period)
+	var compared []portfolio.OHLC
+	if c.compare != "" {
+		compared = ledger.OHLC(c.compare, r, period)
+	}
+
+	if !c.csv {
+		printMarkdown(renderer.PricesMarkdown(c.security, period, bars, c.compare, compared))
+		return subcommands.ExitSuccess
+	}
+
+	closes := make(map[portfolio.Date]portfolio.Money)
+	for _, bar := range compared {
+		closes[bar.Period.From] = bar.Close
+	}
+	w := csv.NewWriter(os.Stdout)
+	header := []string{"date", "open", "high", "low", "close", "missing", "source"}
+	if c.compare != "" {
+		header = append(header, c.compare)
+	}
+	w.Write(header)
+	for _, bar := range bars {
+		record := []string{bar.Period.From.String(), csvPrice(bar.Open), csvPrice(bar.High), csvPrice(bar.Low), csvPrice(bar.Close), strconv.Itoa(bar.Missing), bar.Source}
+		if c.compare != "" {
+			record = append(record, "")
+			if close, ok := closes[bar.Period.From]; ok {
+				record[len(record)-1] = csvPrice(close)
+			}
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+// csvPrice formats a price for CSV, without currency symbol nor thousands separator.
+func csvPrice(m portfolio.Money) string {
+	return strconv.FormatFloat(m.AsFloat(), 'f', -1, 64)
+}
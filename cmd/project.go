@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/projection"
+	"github.com/google/subcommands"
+)
+
+// projectCmd holds the flags for the 'project' subcommand.
+type projectCmd struct {
+	contribution string
+	withdraw     string
+	inflation    float64
+	bootstrap    bool
+	years        string
+	paths        int
+	seed         uint64
+	date         string
+	ledgerFile   string
+}
+
+func (*projectCmd) Name() string { return "project" }
+func (*projectCmd) Synopsis() string {
+	return "project the future portfolio value by Monte Carlo simulation"
+}
+func (*projectCmd) Usage() string {
+	return `pcs project [-contribution <amount>[/month|/year]] [-withdraw <amount>[/month|/year] [-inflation <rate>]] [-bootstrap] [-years <list>] [-paths <n>] [-seed <n>] [-d <date>] [-l <ledger>]
+
+  Estimates the distribution of the future value of the portfolio, by Monte
+  Carlo simulation of monthly returns.
+
+  The mean and the volatility of the monthly returns are estimated from the
+  price history of the securities held on the date, weighted by their share of
+  the portfolio: cash has no return. Regular contributions, in the reporting
+  currency, are added every month.
+
+  The simulated values are reported at each horizon as percentiles: 5% of the
+  simulations end below the 5th percentile, half below the median. These are
+  estimates based on past prices, not predictions.
+
+  With -bootstrap, the simulated monthly returns are resampled from the
+  historical returns instead.
+
+  With -withdraw, the projection simulates a withdrawal plan instead: the amount
+  is withdrawn every month, growing by the annual -inflation rate, in percent,
+  or fixed by default. It reports the probability that the portfolio is
+  depleted at each horizon, and the sequence-of-returns risk: how much the
+  depletion at the last horizon depends on the returns of the first 5 years,
+  between the quarter of the simulations with the worst and the best returns.
+
+Usage Examples:
+$ pcs project -contribution 500/month
+$ pcs project -contribution 6000/year -years 1,5,10,20 -seed 42
+$ pcs project -withdraw 36000/year -inflation 2 -years 10,20,30
+`
+}
+
+func (c *projectCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.contribution, "contribution", "0", "Regular contribution, per month by default (e.g. 500/month, 6000/year)")
+	f.StringVar(&c.withdraw, "withdraw", "", "Regular withdrawal, per month by default (e.g. 3000/month, 36000/year)")
+	f.Float64Var(&c.inflation, "inflation", 0, "Annual growth of the withdrawal, in percent. Fixed withdrawal by default.")
+	f.BoolVar(&c.bootstrap, "bootstrap", false, "Resample the historical monthly returns instead of drawing from a lognormal distribution")
+	f.StringVar(&c.years, "years", "1,5,10", "Comma separated list of horizons, in years")
+	f.IntVar(&c.paths, "paths", 10000, "Number of simulated paths")
+	f.Uint64Var(&c.seed, "seed", 0, "Seed of the simulation, for reproducible results. Random by default.")
+	f.StringVar(&c.date, "d", "0d", "Date of the projection start. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to project. Defaults to the only ledger if one exists.")
+}
+
+func (c *projectCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	contribution, err := parseContribution(c.contribution)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	var withdrawal float64
+	if c.withdraw != "" {
+		if withdrawal, err = parseContribution(c.withdraw); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	var horizons []int
+	for _, y := range strings.Split(c.years, ",") {
+		years, err := strconv.Atoi(strings.TrimSpace(y))
+		if err != nil || years <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid horizon %q, want a positive number of years.\n", y)
+			return subcommands.ExitUsageError
+		}
+		horizons = append(horizons, 12*years)
+	}
+	if c.paths <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -paths must be positive.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	s := ledger.NewSnapshot(on)
+	returns, err := projection.History(ctx, ledger, on, portfolio.NewRange(ledger.GlobalInceptionDate(), on))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	mean, volatility, err := projection.Estimate(returns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	seed := c.seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	params := projection.Params{
+		Value:        s.TotalPortfolio().AsFloat(),
+		Contribution: contribution,
+		Mean:         mean,
+		Volatility:   volatility,
+		Paths:        c.paths,
+		Seed:         seed,
+	}
+	if c.bootstrap {
+		params.History = returns
+	}
+
+	cur := s.ReportingCurrency()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Projection for %s on %s\n\n", ledger.Name(), on)
+	fmt.Fprintf(&b, "Starting from %s with %s contributed per month, over %d simulations.\n", s.TotalPortfolio(), portfolio.M(contribution, cur), c.paths)
+	fmt.Fprintf(&b, "Based on %d months of price history: annual return %s, annual volatility %s.\n\n",
+		len(returns), portfolio.Percent(100*(math.Exp(12*mean)-1)), portfolio.Percent(100*volatility*math.Sqrt(12)))
+	if c.withdraw != "" {
+		inflation := math.Pow(1+c.inflation/100, 1.0/12) - 1
+		depletions, risk := projection.Decumulate(params, withdrawal, inflation, horizons...)
+		writeDecumulation(&b, cur, withdrawal, c.inflation, depletions, risk)
+		printMarkdown(b.String())
+		return subcommands.ExitSuccess
+	}
+
+	bands := projection.Run(params, horizons...)
+	fmt.Fprint(&b, "| Horizon | Contributed |")
+	for _, q := range projection.Percentiles {
+		fmt.Fprintf(&b, " %gth |", q)
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprint(&b, "|:---|---:|")
+	for range projection.Percentiles {
+		fmt.Fprint(&b, "---:|")
+	}
+	fmt.Fprintln(&b)
+	for _, band := range bands {
+		fmt.Fprintf(&b, "| %s | %s |", horizon(band.Months), portfolio.M(band.Contributed, cur))
+		for _, v := range band.Values {
+			fmt.Fprintf(&b, " %s |", portfolio.M(v, cur))
+		}
+		fmt.Fprintln(&b)
+	}
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
+
+// writeDecumulation writes the projection of a withdrawal plan.
+func writeDecumulation(b *strings.Builder, cur string, withdrawal, inflation float64, depletions []projection.Depletion, risk projection.SequenceRisk) {
+	if inflation != 0 {
+		fmt.Fprintf(b, "Withdrawing %s per month, growing by %s per year.\n\n", portfolio.M(withdrawal, cur), portfolio.Percent(inflation))
+	} else {
+		fmt.Fprintf(b, "Withdrawing a fixed %s per month.\n\n", portfolio.M(withdrawal, cur))
+	}
+	fmt.Fprint(b, "| Horizon | Withdrawn | Depleted |")
+	for _, q := range projection.Percentiles {
+		fmt.Fprintf(b, " %gth |", q)
+	}
+	fmt.Fprintln(b)
+	fmt.Fprint(b, "|:---|---:|---:|")
+	for range projection.Percentiles {
+		fmt.Fprint(b, "---:|")
+	}
+	fmt.Fprintln(b)
+	for _, d := range depletions {
+		fmt.Fprintf(b, "| %s | %s | %s |", horizon(d.Months), portfolio.M(d.Withdrawn, cur), portfolio.Percent(d.Depleted))
+		for _, v := range d.Values {
+			fmt.Fprintf(b, " %s |", portfolio.M(v, cur))
+		}
+		fmt.Fprintln(b)
+	}
+	if risk.Months == 0 {
+		return
+	}
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "## Sequence of Returns")
+	fmt.Fprintln(b)
+	fmt.Fprintf(b, "Depleted after %s, by annual return over the first %s:\n\n", horizon(depletions[len(depletions)-1].Months), horizon(risk.Months))
+	fmt.Fprintln(b, "| First Returns | Annual Return | Depleted |")
+	fmt.Fprintln(b, "|:---|---:|---:|")
+	fmt.Fprintf(b, "| Worst quarter | below %s | %s |\n", portfolio.Percent(100*risk.WorstReturn), portfolio.Percent(risk.Worst))
+	fmt.Fprintf(b, "| Best quarter | above %s | %s |\n", portfolio.Percent(100*risk.BestReturn), portfolio.Percent(risk.Best))
+}
+
+// horizon formats a number of months as a horizon in years, or in months if
+// it is not a whole number of years.
+func horizon(months int) string {
+	switch {
+	case months == 12:
+		return "1 year"
+	case months%12 == 0:
+		return fmt.Sprintf("%d years", months/12)
+	}
+	return fmt.Sprintf("%d months", months)
+}
+
+// parseContribution parses a regular contribution, as an amount per month
+// (e.g. "500" or "500/month") or per year (e.g. "6000/year"), and returns the
+// monthly amount.
+func parseContribution(s string) (float64, error) {
+	amount, unit, _ := strings.Cut(s, "/")
+	v, err := strconv.ParseFloat(amount, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid contribution %q", s)
+	}
+	switch unit {
+	case "", "month", "m":
+		return v, nil
+	case "year", "y":
+		return v / 12, nil
+	}
+	return 0, fmt.Errorf("invalid contribution period %q, want month or year", unit)
+}
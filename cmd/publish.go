@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// publishCmd holds the flags for the 'publish' subcommand.
+type publishCmd struct {
+	out        string
+	date       string
+	method     string
+	ledgerFile string
+}
+
+func (*publishCmd) Name() string     { return "publish" }
+func (*publishCmd) Synopsis() string { return "export the portfolio as a static HTML site" }
+func (*publishCmd) Usage() string {
+	return `pcs publish -o <dir> [-d <date>] [-method average|fifo] [-l <ledger>]
+
+  Generates a static HTML mini-site of the portfolio in a directory, to be
+  browsed locally or pushed to a private static hosting (e.g. GitHub Pages):
+
+    index.html                 the dashboard: the holdings on the date, with
+                               links to the other pages;
+    security-<ticker>.html     the report of each security;
+    review-<yyyy-mm>.html      the review of each month since inception.
+
+  The pages are self-contained, and the existing pages are overwritten.
+
+Usage Examples:
+$ pcs publish -o site/
+`
+}
+
+func (c *publishCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.out, "o", "", "Directory to write the site to")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the dashboard. See the user manual for supported date formats.")
+	f.StringVar(&c.method, "method", "fifo", "Cost basis method (average, fifo)")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to publish. Defaults to the only ledger if one exists.")
+}
+
+func (c *publishCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.out == "" {
+		fmt.Fprintln(os.Stderr, "Error: the -o flag is required.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	method, err := portfolio.ParseCostBasisMethod(c.method)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing cost basis method: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	if err := os.MkdirAll(c.out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	nav := []renderer.Link{{Title: "Dashboard", Href: "index.html"}}
+	pages := 0
+	write := func(file, title, md string) error {
+		var b bytes.Buffer
+		if err := renderer.HTML(&b, title, nav, md); err != nil {
+			return err
+		}
+		pages++
+		return os.WriteFile(filepath.Join(c.out, file), b.Bytes(), 0o644)
+	}
+
+	var index strings.Builder
+	index.WriteString(renderer.RenderHolding(renderer.NewHolding(ledger.NewSnapshot(on))))
+
+	index.WriteString("\n## Securities\n\n")
+	for sec := range ledger.AllSecurities() {
+		if sec.ID().IsCurrencyPair() {
+			continue
+		}
+		report, err := renderer.NewSecurityReport(ledger, on, sec.Ticker())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		file := "security-" + pageName(sec.Ticker()) + ".html"
+		if err := write(file, sec.Ticker(), renderer.RenderSecurityReport(report)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %q: %v\n", file, err)
+			return subcommands.ExitFailure
+		}
+		fmt.Fprintf(&index, "- [%s](%s)\n", sec.Ticker(), file)
+	}
+
+	// The review of each month, the last one up to the date.
+	var months []portfolio.Range
+	for start := ledger.GlobalInceptionDate().StartOf(portfolio.Monthly); !start.After(on); start = start.AddMonth(1) {
+		end := start.EndOf(portfolio.Monthly)
+		if end.After(on) {
+			end = on
+		}
+		months = append(months, portfolio.NewRange(start, end))
+	}
+	index.WriteString("\n## Monthly Reviews\n\n")
+	for _, month := range slices.Backward(months) {
+		review := renderer.NewReview(ledger.NewReview(month), method)
+		file := "review-" + month.From.Format("2006-01") + ".html"
+		title := month.From.Format("January 2006")
+		if err := write(file, title, renderer.RenderReview(review, renderer.ReviewRenderOptions{TopMovers: 5})); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %q: %v\n", file, err)
+			return subcommands.ExitFailure
+		}
+		fmt.Fprintf(&index, "- [%s](%s)\n", title, file)
+	}
+
+	if err := write("index.html", ledger.Name(), index.String()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %q: %v\n", "index.html", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Published %d pages to %q.\n", pages, c.out)
+	return subcommands.ExitSuccess
+}
+
+// unsafePageName matches the characters not to use in page file names.
+var unsafePageName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// pageName returns a name safe to use in page file names and links.
+func pageName(s string) string { return unsafePageName.ReplaceAllString(s, "_") }
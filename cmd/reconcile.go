@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+	"github.com/shopspring/decimal"
+)
+
+// reconcileCmd holds the flags for the 'reconcile' subcommand.
+type reconcileCmd struct {
+	on         string
+	security   string
+	quantity   decimal.Decimal
+	currency   string
+	amount     decimal.Decimal
+	file       string
+	fix        bool
+	ledgerFile string
+}
+
+func (*reconcileCmd) Name() string     { return "reconcile" }
+func (*reconcileCmd) Synopsis() string { return "compare broker balances against the ledger" }
+func (*reconcileCmd) Usage() string {
+	return `pcs reconcile [-on <date>] (-s <security> -q <quantity> | -c <currency> -a <amount> | -f <file.csv>) [-fix] [-l <ledger>]
+
+  Compares the positions and cash balances reported by a broker or a bank
+  against the ledger state on a date, and reports the discrepancies.
+
+  With -f, balances are read from a CSV file with one balance per line:
+    date,asset,balance
+  where asset is either a security ticker or a currency code. A header line is
+  allowed.
+
+  With -fix, adjusting transactions are recorded in the ledger, each with a memo
+  for the audit trail: a deposit or a withdrawal for cash, and a transfer of
+  shares valued at the ledger's price (deposit and buy, or sell and withdraw)
+  for positions.
+
+Usage Examples:
+# Check the AAPL position reported on the broker statement.
+$ pcs reconcile -s AAPL -q 85 -on 2025-03-01
+
+# Check all balances of a broker export and fix the ledger.
+$ pcs reconcile -f broker.csv -fix
+`
+}
+
+func (c *reconcileCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.on, "on", portfolio.Today().String(), "Date of the broker balance. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.Var(DecimalVar(&c.quantity, "0"), "q", "Position reported by the broker")
+	f.StringVar(&c.currency, "c", "", "Currency of the cash account")
+	f.Var(DecimalVar(&c.amount, "0"), "a", "Cash balance reported by the broker")
+	f.StringVar(&c.file, "f", "", "CSV file of broker balances")
+	f.BoolVar(&c.fix, "fix", false, "Record adjusting transactions in the ledger")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to reconcile. Defaults to the only ledger if one exists.")
+}
+
+func (c *reconcileCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	modes := 0
+	for _, set := range []bool{c.security != "", c.currency != "", c.file != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of -s, -c or -f must be provided.")
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	var balances []portfolio.BrokerBalance
+	if c.file != "" {
+		file, err := os.Open(c.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		defer file.Close()
+		balances, err = readBrokerBalances(ledger, file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %q: %v\n", c.file, err)
+			return subcommands.ExitFailure
+		}
+	} else {
+		on, err := portfolio.ParseDate(c.on)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if c.security != "" {
+			balances = append(balances, portfolio.BrokerBalance{On: on, Security: c.security, Quantity: portfolio.Q(c.quantity)})
+		} else {
+			balances = append(balances, portfolio.BrokerBalance{On: on, Cash: portfolio.M(c.amount, c.currency)})
+		}
+	}
+
+	discrepancies := ledger.Reconcile(balances...)
+	printMarkdown(renderer.ReconcileMarkdown(len(balances), discrepancies))
+
+	if !c.fix || len(discrepancies) == 0 {
+		return subcommands.ExitSuccess
+	}
+	for _, d := range discrepancies {
+		adjustments, err := d.Adjustments(ledger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adjusting %s: %v\n", d.Reported.Asset(), err)
+			return subcommands.ExitFailure
+		}
+		for _, tx := range adjustments {
+			valid, err := ledger.Validate(tx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			if err := ledger.Append(valid); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not append transaction: %v\n", err)
+				return subcommands.ExitFailure
+			}
+		}
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Successfully recorded adjustments in ledger %q.\n", ledger.Name())
+	return subcommands.ExitSuccess
+}
+
+// readBrokerBalances reads broker balances from CSV lines "date,asset,balance".
+func readBrokerBalances(ledger *portfolio.Ledger, r io.Reader) ([]portfolio.BrokerBalance, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []portfolio.BrokerBalance
+	for i, record := range records {
+		on, err := portfolio.ParseDate(record[0])
+		if err != nil {
+			if i == 0 {
+				continue // header line
+			}
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		asset := strings.TrimSpace(record[1])
+		value, err := decimal.NewFromString(strings.TrimSpace(record[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid balance %q: %w", i+1, record[2], err)
+		}
+		switch {
+		case ledger.Security(asset) != nil:
+			balances = append(balances, portfolio.BrokerBalance{On: on, Security: asset, Quantity: portfolio.Q(value)})
+		case portfolio.ValidateCurrency(asset) == nil:
+			balances = append(balances, portfolio.BrokerBalance{On: on, Cash: portfolio.M(value, asset)})
+		default:
+			return nil, fmt.Errorf("line %d: %q is neither a declared security nor a currency", i+1, asset)
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// reportCmd holds the flags for the 'report' subcommand.
+type reportCmd struct {
+	period     string
+	date       string
+	method     string
+	format     string
+	out        string
+	ledgerFile string
+}
+
+func (*reportCmd) Name() string { return "report" }
+func (*reportCmd) Synopsis() string {
+	return "write a statement of the portfolio, in markdown or PDF"
+}
+func (*reportCmd) Usage() string {
+	return `pcs report [-p <period>] [-d <date>] [-method average|fifo] [-format md|pdf] [-o <file>] [-l <ledger>]
+
+  Writes a statement of the portfolio, to be archived or shared: the review of
+  the period ending on the date, followed by the holdings on that date. It is
+  the year-end statement by default.
+
+  The statement is written in markdown on the standard output, or to a file
+  with -o. A PDF statement, with -format pdf, requires -o.
+
+Usage Examples:
+$ pcs report -d 2025-12-31 -format pdf -o 2025.pdf
+$ pcs report -p quarter -o q3.md
+`
+}
+
+func (c *reportCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.period, "p", portfolio.Yearly.String(), "period for the review (day, week, month, quarter, year, wtd, mtd, qtd, ytd, <n>d, inception)")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the statement. See the user manual for supported date formats.")
+	f.StringVar(&c.method, "method", "fifo", "Cost basis method (average, fifo)")
+	f.StringVar(&c.format, "format", "md", "Format of the statement (md, pdf)")
+	f.StringVar(&c.out, "o", "", "File to write the statement to. Defaults to the standard output.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *reportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	switch {
+	case c.format != "md" && c.format != "pdf":
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q, must be md or pdf.\n", c.format)
+		return subcommands.ExitUsageError
+	case c.format == "pdf" && c.out == "":
+		fmt.Fprintln(os.Stderr, "Error: -o is required for the pdf format.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	method, err := portfolio.ParseCostBasisMethod(c.method)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing cost basis method: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	rng, err := portfolio.ParseRange(c.period, on, ledger.GlobalInceptionDate())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing period: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	review := renderer.RenderReview(renderer.NewReview(ledger.NewReview(rng), method), renderer.ReviewRenderOptions{TopMovers: 5})
+	holding := renderer.RenderHolding(renderer.NewHolding(ledger.NewSnapshot(rng.To)))
+	if c.out == "" {
+		printMarkdown(review + "\n" + holding)
+		return subcommands.ExitSuccess
+	}
+
+	file, err := os.Create(c.out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer file.Close()
+	if c.format == "pdf" {
+		err = renderer.PDF(file, review, holding)
+	} else {
+		_, err = fmt.Fprint(file, review+"\n"+holding)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %q: %v\n", c.out, err)
+		return subcommands.ExitFailure
+	}
+	fmt.Fprintf(os.Stderr, "✅ Wrote the statement from %s to %s to %q.\n", rng.From, rng.To, c.out)
+	return subcommands.ExitSuccess
+}
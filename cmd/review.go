@@ -3,9 +3,13 @@ package cmd
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/notify"
 	"github.com/etnz/portfolio/renderer"
 	"github.com/google/subcommands"
 )
@@ -17,6 +21,12 @@ type reviewCmd struct {
 	start      string
 	method     string
 	update     bool
+	notify     bool
+	compare    bool
+	real       bool
+	cpi        string
+	sections   string
+	sub        string
 	ledgerFile string
 	opts       renderer.ReviewRenderOptions
 }
@@ -25,23 +35,72 @@ func (*reviewCmd) Name() string { return "review" }
 
 func (*reviewCmd) Synopsis() string { return "review a portfolio performance" }
 func (*reviewCmd) Usage() string {
-	return `pcs review [-p <period>| -start <date>] [-d <date>] [-l <ledger>] [-s]
+	return `pcs review [-p <period>| -from <date>] [-to <date>] [-l <ledger>] [-s] [-compare] [-by-currency] [-real [-cpi <ticker>]] [-sections <sections>] [-sub <label>] [-notify]
 	
   Review the portfolio for a given period.
+
+  The period is either a standard period containing the date (day, week,
+  month, quarter, year), a period to date (wtd, mtd, qtd, ytd), a number of
+  trailing days (e.g. 90d), or "inception" for the whole life of the portfolio.
+  A custom range between two dates is set with -from and -to, and is not
+  snapped to calendar periods.
+
+  With -compare, the review is compared with the previous period (e.g. "vs last
+  month" for a monthly review).
+
+  With -by-currency, the review also reviews the holdings (cash, securities
+  and counterparties) of each currency in that currency, before their
+  conversion to the reporting currency: their value, flows (including
+  conversions between currencies) and gains, next to their converted value
+  and gains. It separates the local performance of a USD sleeve from the
+  exchange rate effect.
+
+  With -real, the review is also expressed in real terms: deflated by the
+  inflation of a consumer price index (CPI) over the period. The CPI is a
+  security declared in the ledger, whose prices are the values of the index,
+  e.g. the French CPI fetched from INSEE:
+
+    pcs declare -s CPI -id INSEE-001759970 -c EUR
+    pcs insee fetch -inception
+
+  With -sections, only the listed sections are rendered, in that order, among
+  summary, accounts, assets, attribution, movers and transactions, e.g.
+  "-sections summary,movers". The default sections are set by the
+  "reviewSections" entry of the config.json file in the portfolio directory,
+  all of them otherwise.
+
+  With -sub, the review is the one of a sub-portfolio: the transactions
+  labelled with "portfolio=<label>" in their memo, e.g. "portfolio=kids-savings".
+  The declarations and market data are shared with the ledger, but the cash
+  balances are virtual: the cash of the labelled transactions only.
+
+  With -notify, the review is also delivered through the channels (email,
+  webhook, ntfy) configured in the "notifications" section of the config.json
+  file in the portfolio directory.
 `
 }
 
 func (c *reviewCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.date, "d", "", "Date for the report. See the user manual for supported date formats.")
-	f.StringVar(&c.period, "p", portfolio.Daily.String(), "period for the review (day, week, month, quarter, year)")
+	f.StringVar(&c.date, "to", "", "End date of the reporting period, alias for -d.")
+	f.StringVar(&c.period, "p", portfolio.Daily.String(), "period for the review (day, week, month, quarter, year, wtd, mtd, qtd, ytd, <n>d, inception)")
 	f.BoolVar(&c.opts.SimplifiedView, "s", false, "provide a simplified asset review")
 	f.BoolVar(&c.opts.SkipTransactions, "t", false, "skip transactions in the report")
+	f.IntVar(&c.opts.TopMovers, "movers", 5, "number of top movers to list, 0 to skip the section")
 	f.StringVar(&c.start, "start", "", "Start date of the reporting period. Overrides -p.")
+	f.StringVar(&c.start, "from", "", "Start date of the reporting period, alias for -start.")
 	f.StringVar(&c.method, "method", "fifo", "Cost basis method (average, fifo)")
 	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+	f.BoolVar(&c.compare, "compare", false, "compare with the previous period")
+	f.BoolVar(&c.opts.ByCurrency, "by-currency", false, "also review each currency before conversion to the reporting currency")
+	f.BoolVar(&c.real, "real", false, "also review in real terms, deflated by a consumer price index")
+	f.StringVar(&c.cpi, "cpi", "CPI", "Ticker of the consumer price index security, for -real")
+	f.StringVar(&c.sections, "sections", "", "comma separated sections to render, in that order (e.g. summary,movers)")
+	f.StringVar(&c.sub, "sub", "", "review the sub-portfolio with this label")
+	f.BoolVar(&c.notify, "notify", false, "deliver the review through the configured notification channels")
 }
 
-func (c *reviewCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *reviewCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if c.date == "" {
 		c.date = portfolio.Today().String()
 	}
@@ -51,6 +110,12 @@ func (c *reviewCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 		return subcommands.ExitUsageError
 	}
 
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
+	if err != nil {
+		log.Printf("Error decoding ledgers: %v", err)
+		return subcommands.ExitFailure
+	}
+
 	var rng portfolio.Range
 	if c.start != "" {
 		// Custom range using start and end dates
@@ -61,13 +126,18 @@ func (c *reviewCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 		}
 		rng = portfolio.NewRange(startDate, endDate)
 	} else {
-		// Predefined period
-		p, err := portfolio.ParsePeriod(c.period)
+		// Predefined period, or a custom range ending on the date.
+		var inception portfolio.Date
+		for _, ledger := range ledgers {
+			if d := ledger.GlobalInceptionDate(); inception.IsZero() || d.Before(inception) {
+				inception = d
+			}
+		}
+		rng, err = portfolio.ParseRange(c.period, endDate, inception)
 		if err != nil {
 			log.Printf("Error parsing period: %v", err)
 			return subcommands.ExitUsageError
 		}
-		rng = p.Range(endDate)
 	}
 
 	if !rng.To.Before(portfolio.Today()) {
@@ -80,31 +150,84 @@ func (c *reviewCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 		return subcommands.ExitUsageError
 	}
 
-	ledgers, err := DecodeLedgers(c.ledgerFile)
-	if err != nil {
-		log.Printf("Error decoding ledgers: %v", err)
-		return subcommands.ExitFailure
+	if c.sections == "" {
+		if c.sections, err = configEntry("reviewSections"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+	if c.opts.Sections, err = renderer.ParseReviewSections(c.sections); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
 	}
 
-	var reviews []*portfolio.Review
 	for _, ledger := range ledgers {
 		if c.update {
-			if err := ledger.UpdateIntraday(); err != nil {
+			if err := ledger.UpdateIntraday(ctx); err != nil {
 				log.Printf("Warning: could not update some intraday prices for ledger %q: %v\n", ledger.Name(), err)
 			}
 		}
-		reviews = append(reviews, ledger.NewReview(rng))
+	}
+	if c.sub != "" {
+		if ledgers, err = subPortfolios(ledgers, c.sub); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+
+	var reviews []*portfolio.Review
+	for _, ledger := range ledgers {
+		if c.compare {
+			reviews = append(reviews, ledger.NewComparativeReview(rng))
+		} else {
+			reviews = append(reviews, ledger.NewReview(rng))
+		}
+		if err := reviews[len(reviews)-1].CheckTotals(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: ledger %q: %v\n", ledger.Name(), err)
+			return subcommands.ExitFailure
+		}
+	}
+
+	if c.real && len(reviews) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: -real requires a single ledger, use -l to select it.")
+		return subcommands.ExitUsageError
 	}
 
 	var md string
+	var payload any
 	if len(reviews) == 1 {
 		r := renderer.NewReview(reviews[0], parsedMethod)
-		md = renderer.RenderReview(r, c.opts)
+		if c.real {
+			inflation, err := reviews[0].Inflation(c.cpi)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			r.Real = renderer.NewRealTerms(r, c.cpi, inflation)
+		}
+		md, payload = renderer.RenderReview(r, c.opts), r
 	} else {
 		cr := renderer.NewConsolidatedReview(reviews, parsedMethod)
-		md = renderer.RenderConsolidatedReview(cr, c.opts)
+		md, payload = renderer.RenderConsolidatedReview(cr, c.opts), cr
 	}
 	printMarkdown(md)
 
+	if c.notify {
+		cfg, err := notify.LoadConfig(filepath.Join(PortfolioPath(), notify.ConfigFile))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading notifications config: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		senders := cfg.Senders()
+		if len(senders) == 0 {
+			fmt.Fprintln(os.Stderr, "Warning: no notification channel configured.")
+		}
+		msg := notify.Message{Subject: fmt.Sprintf("Portfolio review for %s", rng.Name()), Body: md, Payload: payload}
+		if err := notify.Send(ctx, senders, msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending notifications: %v\n", err)
+			return subcommands.ExitFailure
+		}
+	}
+
 	return subcommands.ExitSuccess
 }
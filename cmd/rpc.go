@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/rpc"
+	"github.com/google/subcommands"
+)
+
+// rpcCmd holds the flags for the 'rpc' subcommand.
+type rpcCmd struct {
+	socket string
+}
+
+func (*rpcCmd) Name() string     { return "rpc" }
+func (*rpcCmd) Synopsis() string { return "serve the ledgers over JSON-RPC on a unix socket" }
+func (*rpcCmd) Usage() string {
+	return `pcs rpc [-socket <path>]
+
+  Serves the query API of the ledgers as a JSON-RPC 1.0 service on a local
+  unix socket, until interrupted, so that editors and graphical interfaces can
+  query them without running a command per query.
+
+  The methods of the service take the name of the ledger, the only ledger if
+  empty, and are:
+    Portfolio.Holding       {"ledger", "date"}: the holdings on the date;
+    Portfolio.Review        {"ledger", "period", "date", "method"}: the review
+                            of the period ending on the date;
+    Portfolio.Transactions  {"ledger", "filter"}: the transactions matching the
+                            filter expression, see 'pcs tx';
+    Portfolio.Validate      {"ledger", "transaction"}: the transaction, a ledger
+                            line, validated without being recorded;
+    Portfolio.Alerts        {"ledger", "date"}: the active price alerts, with
+                            the last prices and whether they are triggered.
+
+  The ledgers are read on each call. Go programs use the client of the package
+  github.com/etnz/portfolio/rpc.
+
+Usage Examples:
+$ pcs rpc
+$ echo '{"method":"Portfolio.Holding","params":[{}],"id":1}' | nc -U .pcs.sock
+`
+}
+
+func (c *rpcCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.socket, "socket", "", "Path of the unix socket. Defaults to .pcs.sock in the portfolio directory.")
+}
+
+func (c *rpcCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	socket := c.socket
+	if socket == "" {
+		socket = filepath.Join(PortfolioPath(), ".pcs.sock")
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	// The service closes the listener on interrupt, which removes the socket file.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Serving the ledgers on %s, interrupt to stop.\n", socket)
+	if err := rpc.Serve(l, rpc.NewService(ctx, func(ctx context.Context, name string) (*portfolio.Ledger, error) { return DecodeLedger(ctx, name) })); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
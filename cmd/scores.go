@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// scoresCmd holds the flags for the 'scores' subcommand.
+type scoresCmd struct {
+	score      string
+	threshold  float64
+	file       string
+	date       string
+	ledgerFile string
+}
+
+func (*scoresCmd) Name() string { return "scores" }
+func (*scoresCmd) Synopsis() string {
+	return "display the portfolio score, e.g. ESG, weighted by the value of the securities held"
+}
+func (*scoresCmd) Usage() string {
+	return `pcs scores [-score <name>] [-threshold <value>] [-file <scores.csv>] [-d <date>] [-l <ledger>]
+
+  Displays a score of the securities held, e.g. an ESG rating or a personal
+  conviction, and the score of the portfolio: the average score of the
+  securities weighted by their value. The securities scoring below the
+  threshold are flagged.
+
+  The scores of a security are set when declaring it, e.g.:
+
+    pcs declare -s VWCE -id IE00BK5BQT80.XETR -c EUR -score esg=62 -score conviction=8
+
+  or in the scores.csv file of the portfolio directory, with a header and one
+  row per security, which overrides the declared scores:
+
+    ticker,esg,conviction
+    VWCE,62,8
+    XOM,31,
+
+Usage Examples:
+$ pcs scores
+$ pcs scores -score conviction -threshold 5 -l retirement
+`
+}
+
+func (c *scoresCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.score, "score", "esg", "Name of the score to report on.")
+	f.Float64Var(&c.threshold, "threshold", 0, "Flag the securities scoring below this value.")
+	f.StringVar(&c.file, "file", "", "Scores file. Defaults to 'scores.csv' in the portfolio directory.")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the holdings. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *scoresCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	c.score = strings.ToLower(c.score)
+	file := c.file
+	if file == "" {
+		file = filepath.Join(PortfolioPath(), "scores.csv")
+	}
+	scores, err := loadScores(file, c.file != "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderScores(renderer.NewScores(ledger.NewSnapshot(on), c.score, c.threshold, scores[c.score])))
+	return subcommands.ExitSuccess
+}
+
+// loadScores reads the scores file, if it exists or is required.
+func loadScores(file string, required bool) (map[string]map[string]float64, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scores, err := readScores(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scores file %q: %w", file, err)
+	}
+	return scores, nil
+}
+
+// readScores reads the scores of securities from CSV: a "ticker" column, and
+// a column per score. It returns the scores by name, then by ticker. Empty
+// cells are securities without that score.
+func readScores(r io.Reader) (map[string]map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing csv header")
+	}
+	header := records[0]
+	ticker := -1
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(h))
+		if header[i] == "ticker" {
+			ticker = i
+		}
+	}
+	if ticker < 0 {
+		return nil, errors.New(`missing column "ticker" in csv header`)
+	}
+
+	scores := make(map[string]map[string]float64)
+	for line, record := range records[1:] {
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if i == ticker || i >= len(header) || cell == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s score %q", line+2, header[i], cell)
+			}
+			if scores[header[i]] == nil {
+				scores[header[i]] = make(map[string]float64)
+			}
+			scores[header[i]][strings.TrimSpace(record[ticker])] = v
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// securityCmd holds the flags for the 'security' subcommand.
+type securityCmd struct {
+	date       string
+	ledgerFile string
+}
+
+func (*securityCmd) Name() string     { return "security" }
+func (*securityCmd) Synopsis() string { return "displays the detailed report of a security" }
+func (*securityCmd) Usage() string {
+	return `pcs security [-d <date>] [-l <ledger>] <ticker>
+
+  Displays everything about a security as of a specific date: its declaration,
+  position and market value, cost basis and gains by method, dividend history,
+  transactions, and a sparkline of its price over the last year.
+
+Usage Examples:
+$ pcs security AAPL
+`
+}
+
+func (c *securityCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date for the security report. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *securityCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: exactly one ticker is required.")
+		return subcommands.ExitUsageError
+	}
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	report, err := renderer.NewSecurityReport(ledger, on, f.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	printMarkdown(renderer.RenderSecurityReport(report))
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/subcommands"
+)
+
+// splitLedgerCmd holds the flags for the 'split-ledger' subcommand.
+type splitLedgerCmd struct {
+	securities string
+	out        string
+	ledgerFile string
+}
+
+func (*splitLedgerCmd) Name() string { return "split-ledger" }
+func (*splitLedgerCmd) Synopsis() string {
+	return "extract the history of some securities into a new ledger"
+}
+func (*splitLedgerCmd) Usage() string {
+	return `pcs split-ledger -securities <tickers> -out <file> [-l <ledger>]
+
+  Extracts a coherent sub-ledger with the history of a selection of securities,
+  e.g. to separate accounts or to share a partial history. The new ledger has
+  the securities declarations, trades and market data, and the exchange rates.
+  The underlying securities of selected options are selected too.
+
+  The other cash movements are left out: an opening deposit funds each cash
+  account instead, with the smallest amount that keeps it from going negative.
+  The source ledger is not modified, and the output file must not exist.
+
+Usage Examples:
+$ pcs split-ledger -securities AAPL,MSFT -out stocks.jsonl
+`
+}
+
+func (c *splitLedgerCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.securities, "securities", "", "Comma separated list of the tickers to extract")
+	f.StringVar(&c.out, "out", "", "File to write the extracted ledger to")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to extract from. Defaults to the only ledger if one exists.")
+}
+
+func (c *splitLedgerCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.securities == "" || c.out == "" {
+		fmt.Fprintln(os.Stderr, "Error: -securities and -out flags are required.")
+		return subcommands.ExitUsageError
+	}
+	var tickers []string
+	for _, ticker := range strings.Split(c.securities, ",") {
+		tickers = append(tickers, strings.TrimSpace(ticker))
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	extracted, err := ledger.Extract(tickers...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	if err := createLedgerFile(c.out, extracted); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if *dryRun {
+		return subcommands.ExitSuccess
+	}
+	count := len(extracted.Query().Collect())
+	fmt.Fprintf(os.Stderr, "✅ Extracted %d transactions of %s into %q.\n", count, strings.Join(tickers, ", "), c.out)
+	return subcommands.ExitSuccess
+}
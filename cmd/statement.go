@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// statementCmd holds the flags for the 'statement' subcommand.
+type statementCmd struct {
+	currency   string
+	from       string
+	to         string
+	ledgerFile string
+}
+
+func (*statementCmd) Name() string     { return "statement" }
+func (*statementCmd) Synopsis() string { return "display the statement of a cash account" }
+func (*statementCmd) Usage() string {
+	return `pcs statement -c <currency> [-from <date>] [-to <date>] [-l <ledger>]
+
+  Lists every movement of a cash account in chronological order (deposits,
+  withdrawals, buys, sells, conversions and settlements) with the running
+  balance, to be reconciled against bank or broker statements.
+
+Usage Examples:
+# Statement of the EUR account for the current month.
+$ pcs statement -c EUR
+
+# Statement of the USD account for 2024.
+$ pcs statement -c USD -from 2024-01-01 -to 2024-12-31
+`
+}
+
+func (c *statementCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.currency, "c", "", "currency of the cash account")
+	f.StringVar(&c.from, "from", "", "Start date of the statement. Defaults to the start of the month. See the user manual for supported date formats.")
+	f.StringVar(&c.to, "to", "", "End date of the statement. Defaults to today. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *statementCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.currency == "" {
+		fmt.Fprintln(os.Stderr, "-c must be provided")
+		return subcommands.ExitUsageError
+	}
+
+	to := portfolio.Today()
+	if c.to != "" {
+		var err error
+		if to, err = portfolio.ParseDate(c.to); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing end date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	from := to.StartOf(portfolio.Monthly)
+	if c.from != "" {
+		var err error
+		if from, err = portfolio.ParseDate(c.from); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing start date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	if from.After(to) {
+		fmt.Fprintf(os.Stderr, "Error: start date %s is after end date %s\n", from, to)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Ledger file not found. Nothing to report.")
+			return subcommands.ExitSuccess
+		}
+		fmt.Fprintf(os.Stderr, "Error decoding ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	period := portfolio.NewRange(from, to)
+	opening := ledger.NewSnapshot(from.Add(-1)).Cash(c.currency)
+	var entries []portfolio.CashEntry
+	for e := range ledger.Journal().CashEntries(c.currency, period) {
+		entries = append(entries, e)
+	}
+
+	printMarkdown(renderer.StatementMarkdown(c.currency, period, opening, entries))
+	return subcommands.ExitSuccess
+}
@@ -33,7 +33,7 @@ func (c *summaryCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
 }
 
-func (c *summaryCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *summaryCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	on, err := portfolio.ParseDate(c.date)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
@@ -44,14 +44,14 @@ func (c *summaryCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 		c.update = true
 	}
 
-	ledger, err := DecodeLedger(c.ledgerFile)
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error decoding ledger %q: %v\n", c.ledgerFile, err)
 		return subcommands.ExitFailure
 	}
 
 	if c.update {
-		err := ledger.UpdateIntraday()
+		err := ledger.UpdateIntraday(ctx)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error updating intraday prices: %v\n", err)
 			return subcommands.ExitFailure
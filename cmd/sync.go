@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// syncCmd holds the flags for the 'sync' subcommand.
+type syncCmd struct {
+	provider   string
+	from       string
+	ledgerFile string
+	yes        bool
+}
+
+func (*syncCmd) Name() string { return "sync" }
+func (*syncCmd) Synopsis() string {
+	return "record the cash movements of a bank account, after review"
+}
+func (*syncCmd) Usage() string {
+	return `pcs sync -p <provider> [-from <date>] [-l <ledger>] [-yes] [-- <extension args>]
+
+  Synchronizes the cash of the ledger with a bank account: runs the
+  pcs-sync-<provider> extension, e.g. pcs-sync-plaid, and records the deposits
+  and withdrawals it returns, after an interactive review.
+
+  Each movement is either accepted (y), rejected (n), accepted with all the
+  next ones (a), or the review stops (q), rejecting the remaining ones. The
+  movements already recorded, recognized by their key, are not proposed again.
+  With -yes, all the movements are accepted.
+
+  The movements are requested from the -from date, by default the date of the
+  newest transaction of the ledger. The arguments after '--' are passed to the
+  extension. See docs/extending-pcs.md for the contract of the extensions.
+
+Usage Examples:
+$ pcs sync -p plaid -l checking
+$ pcs sync -p plaid -from 2025-01-01 -yes -- -account 1234
+`
+}
+
+func (c *syncCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.provider, "p", "", "Provider of the bank account, run as the pcs-sync-<provider> extension.")
+	f.StringVar(&c.from, "from", "", "Date of the first movement to request. Defaults to the date of the newest transaction of the ledger.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to add the transactions to.")
+	f.BoolVar(&c.yes, "yes", false, "Accept all the movements without review.")
+}
+
+func (c *syncCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if c.provider == "" {
+		fmt.Fprintln(os.Stderr, "Error: a provider is required (-p).")
+		return subcommands.ExitUsageError
+	}
+	name := "pcs-sync-" + c.provider
+	extension, err := exec.LookPath(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: no %s extension in the PATH.\n", name)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	from := ledger.NewestTransactionDate()
+	if c.from != "" {
+		if from, err = portfolio.ParseDate(c.from); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -from date: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+	args := f.Args()
+	if !from.IsZero() {
+		args = append([]string{"-from", from.String()}, args...)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, extension, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = extensionEnv()
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s failed: %v\n", name, err)
+		return subcommands.ExitFailure
+	}
+	candidates, err := readCandidates(&stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid output of %s: %v\n", name, err)
+		return subcommands.ExitFailure
+	}
+
+	var fresh []portfolio.Transaction
+	for _, tx := range candidates {
+		if ledger.Duplicate(tx) == nil {
+			fresh = append(fresh, tx)
+		}
+	}
+	if skipped := len(candidates) - len(fresh); skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d movements already recorded, skipped.\n", skipped)
+	}
+	accepted := fresh
+	if !c.yes {
+		accepted = reviewCandidates(fresh, stdin)
+	}
+	if len(accepted) == 0 {
+		fmt.Fprintln(os.Stderr, "No transactions to record.")
+		return subcommands.ExitSuccess
+	}
+
+	for _, tx := range accepted {
+		if _, err := appendTransaction(ledger, tx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s on %s: %v\n", tx.What(), tx.When(), err)
+			fmt.Fprintln(os.Stderr, "Nothing recorded.")
+			return exitStatus(err, subcommands.ExitFailure)
+		}
+	}
+	if err := declareCurrencyPairs(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if err := saveLedger(ledger); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not save ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully recorded %d of %d movements in ledger %q.\n", len(accepted), len(fresh), ledger.Name())
+	}
+	return subcommands.ExitSuccess
+}
+
+// readCandidates reads the movements returned by a sync extension: deposits
+// and withdrawals, one per line, each with a key.
+func readCandidates(r io.Reader) ([]portfolio.Transaction, error) {
+	decoder := portfolio.NewDecoder(r)
+	var txs []portfolio.Transaction
+	var errs []error
+	for {
+		tx, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tx.What() {
+		case portfolio.CmdDeposit, portfolio.CmdWithdraw:
+		default:
+			errs = append(errs, &portfolio.LineError{Line: decoder.Line(), Err: fmt.Errorf("%s is not a cash movement", tx.What())})
+			continue
+		}
+		if dedupKey(tx) == "" {
+			errs = append(errs, &portfolio.LineError{Line: decoder.Line(), Err: errors.New("missing key")})
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return txs, nil
+}
+
+// dedupKey returns the key of a transaction, or "" if it has none.
+func dedupKey(tx portfolio.Transaction) string {
+	if k, ok := tx.(interface{ DedupKey() string }); ok {
+		return k.DedupKey()
+	}
+	return ""
+}
+
+// reviewCandidates asks for each movement whether to record it, reading the
+// answers from r, and returns the accepted ones. Without an answer, the
+// remaining movements are rejected.
+func reviewCandidates(txs []portfolio.Transaction, r *bufio.Reader) []portfolio.Transaction {
+	var accepted []portfolio.Transaction
+	for i, tx := range txs {
+		switch askCandidate(tx, r) {
+		case "y":
+			accepted = append(accepted, tx)
+		case "a":
+			return append(accepted, txs[i:]...)
+		case "q":
+			return accepted
+		}
+	}
+	return accepted
+}
+
+// askCandidate asks whether to record a movement: "y", "n", "a" or "q", and
+// "q" without an answer.
+func askCandidate(tx portfolio.Transaction, r *bufio.Reader) string {
+	for {
+		fmt.Fprintf(os.Stderr, "%s %s. Record it? [y,n,a,q] ", tx.When(), renderer.Transaction(tx))
+		answer, err := r.ReadString('\n')
+		switch answer = strings.TrimSpace(answer); answer {
+		case "y", "n", "a", "q":
+			return answer
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			return "q"
+		}
+	}
+}
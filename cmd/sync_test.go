@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadCandidates(t *testing.T) {
+	txs, err := readCandidates(strings.NewReader(`{"command":"deposit","date":"2025-01-02","key":"bank:1","currency":"EUR","amount":1000}
+{"command":"withdraw","date":"2025-01-03","memo":"rent","key":"bank:2","currency":"EUR","amount":800}
+`))
+	if err != nil {
+		t.Fatalf("readCandidates() error = %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("readCandidates() = %v, want 2 movements", txs)
+	}
+
+	_, err = readCandidates(strings.NewReader(`{"command":"deposit","date":"2025-01-02","currency":"EUR","amount":1000}
+{"command":"buy","date":"2025-01-03","key":"bank:2","security":"AAPL","quantity":1,"currency":"EUR","amount":200}
+`))
+	if err == nil || !strings.Contains(err.Error(), "missing key") || !strings.Contains(err.Error(), "not a cash movement") {
+		t.Errorf("readCandidates() error = %v, want the missing key and the buy", err)
+	}
+}
+
+func TestReviewCandidates(t *testing.T) {
+	txs, err := readCandidates(strings.NewReader(`{"command":"deposit","date":"2025-01-02","key":"bank:1","currency":"EUR","amount":1}
+{"command":"deposit","date":"2025-01-03","key":"bank:2","currency":"EUR","amount":2}
+{"command":"deposit","date":"2025-01-04","key":"bank:3","currency":"EUR","amount":3}
+{"command":"deposit","date":"2025-01-05","key":"bank:4","currency":"EUR","amount":4}
+`))
+	if err != nil {
+		t.Fatalf("readCandidates() error = %v", err)
+	}
+	for _, tc := range []struct {
+		answers string
+		want    []string
+	}{
+		{"y\nn\nwhat?\na\n", []string{"bank:1", "bank:3", "bank:4"}},
+		{"n\ny\nq\n", []string{"bank:2"}},
+		{"y\n", []string{"bank:1"}}, // No answer rejects the remaining ones.
+	} {
+		accepted := reviewCandidates(txs, bufio.NewReader(strings.NewReader(tc.answers)))
+		var got []string
+		for _, tx := range accepted {
+			got = append(got, dedupKey(tx))
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("reviewCandidates(%q) = %v, want %v", tc.answers, got, tc.want)
+		}
+	}
+}
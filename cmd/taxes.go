@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/etnz/portfolio/taxrules"
+	"github.com/google/subcommands"
+)
+
+// taxesCmd holds the flags for the 'taxes' subcommand.
+type taxesCmd struct {
+	year         int
+	method       string
+	longTermDays int
+	jurisdiction string
+	funds        string
+	ledgerFile   string
+}
+
+func (*taxesCmd) Name() string     { return "taxes" }
+func (*taxesCmd) Synopsis() string { return "generates the capital gains report of a fiscal year" }
+func (*taxesCmd) Usage() string {
+	return `pcs taxes [-year <year>] [-method <method>] [-long-term-days <days>] [-jurisdiction <name>] [-l <ledger>]
+
+  Generates the capital gains and dividend income report for a fiscal year.
+
+  Each sale is matched against its acquisition lots (in FIFO order) to report
+  the acquisition date, holding period, proceeds, cost and gain. Gains are
+  split into short-term and long-term using the -long-term-days threshold.
+
+  With -jurisdiction, the cost basis method and threshold are those of the
+  jurisdiction, and the report ends with an estimate of the taxes due:
+    fr - French flat tax (PFU, 30%) on net gains and dividends.
+    de - German Abgeltungsteuer on capital income, and Vorabpauschale of the
+         accumulating funds listed with -funds.
+
+Usage Examples:
+# Last year's report using FIFO cost basis.
+$ pcs taxes
+
+# 2024 report using average cost.
+$ pcs taxes -year 2024 -method average
+
+# 2024 German tax estimate, with an accumulating equity fund.
+$ pcs taxes -year 2024 -jurisdiction de -funds IWDA
+`
+}
+
+func (c *taxesCmd) SetFlags(f *flag.FlagSet) {
+	f.IntVar(&c.year, "year", portfolio.Today().Year()-1, "Fiscal year of the report.")
+	f.StringVar(&c.method, "method", "fifo", "Cost basis method (average, fifo)")
+	f.IntVar(&c.longTermDays, "long-term-days", portfolio.DefaultTaxRules.LongTermDays, "Holding period in days above which a gain is long-term.")
+	f.StringVar(&c.jurisdiction, "jurisdiction", "", "Tax jurisdiction used to estimate taxes ("+strings.Join(taxrules.Names(), ", ")+"). Overrides -method and -long-term-days.")
+	f.StringVar(&c.funds, "funds", "", "Comma-separated tickers of investment funds (for the de jurisdiction).")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Reports on all ledgers by default.")
+}
+
+func (c *taxesCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	method, err := portfolio.ParseCostBasisMethod(c.method)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing cost basis method: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	rules := portfolio.TaxRules{Method: method, LongTermDays: c.longTermDays}
+
+	var jurisdiction portfolio.TaxJurisdiction
+	if c.jurisdiction != "" {
+		jurisdiction, err = taxrules.Lookup(c.jurisdiction)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if de, ok := jurisdiction.(*taxrules.Germany); ok && c.funds != "" {
+			de.Funds = strings.Split(c.funds, ",")
+		}
+	}
+
+	ledgers, err := DecodeLedgers(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	var reports []string
+	for _, ledger := range ledgers {
+		if jurisdiction == nil {
+			st := portfolio.TaxReport(ledger.Journal(), c.year, rules)
+			reports = append(reports, renderer.RenderTaxes(renderer.NewTaxes(ledger.Name(), st, "", nil)))
+			continue
+		}
+		st, assessments, err := portfolio.AssessTaxes(ledger, c.year, jurisdiction)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on ledger %q: %v\n", ledger.Name(), err)
+			return subcommands.ExitFailure
+		}
+		reports = append(reports, renderer.RenderTaxes(renderer.NewTaxes(ledger.Name(), st, jurisdiction.Name(), assessments)))
+	}
+	printMarkdown(strings.Join(reports, "\n\n"))
+	return subcommands.ExitSuccess
+}
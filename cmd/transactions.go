@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/etnz/portfolio"
 	"github.com/google/subcommands"
@@ -20,15 +25,21 @@ type buyCmd struct {
 	quantity decimal.Decimal
 	amount   decimal.Decimal
 	memo     string
+	account  string
+	venue    string
+	strategy string
 	ledger   string
 }
 
 func (*buyCmd) Name() string     { return "buy" }
 func (*buyCmd) Synopsis() string { return "record the purchase of a security" }
 func (*buyCmd) Usage() string {
-	return `pcs buy -d <date> -s <security> -q <quantity> -a <amount> [-m <memo>]
+	return `pcs buy -d <date> -s <security> -q <quantity> -a <amount> [-m <memo>] [-account <account>] [-venue <venue>] [-strategy <strategy>]
 	
 	Purchases shares of a security. The total cost is debited from the cash account in the security's currency.
+	With -account, it is debited from the named cash account instead of the default one.
+	The optional -venue records the broker or the exchange of the trade, to filter the trades by venue.
+	The optional -strategy records the investment strategy of the trade, reviewed with pcs journal.
 `
 }
 
@@ -38,23 +49,31 @@ func (c *buyCmd) SetFlags(f *flag.FlagSet) {
 	f.Var(DecimalVar(&c.quantity, "0"), "q", "Number of shares")
 	f.Var(DecimalVar(&c.amount, "0"), "a", "Total amount paid for the shares")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.account, "account", "", "Cash account paying the shares (e.g. a broker). Defaults to the default account.")
+	f.StringVar(&c.venue, "venue", "", "An optional broker or exchange of the trade")
+	f.StringVar(&c.strategy, "strategy", "", "An optional investment strategy of the trade (e.g. 'dividend', 'momentum')")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *buyCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *buyCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *buyCmd) transaction() (portfolio.Transaction, error) {
 	if c.security == "" || c.quantity.IsZero() || c.amount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -s, -q, and -a flags are all required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-s, -q, and -a flags are all required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewBuy(day, c.memo, c.security, portfolio.Q(c.quantity), portfolio.M(c.amount, ""))
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	tx.Account = c.account
+	tx.Venue = c.venue
+	tx.Strategy = c.strategy
+	return tx, nil
 }
 
 // --- Sell Command ---
@@ -66,15 +85,21 @@ type sellCmd struct {
 	quantity portfolio.Quantity
 	amount   decimal.Decimal
 	memo     string
+	account  string
+	venue    string
+	strategy string
 	ledger   string
 }
 
 func (*sellCmd) Name() string     { return "sell" }
 func (*sellCmd) Synopsis() string { return "record the sale of a security" }
 func (*sellCmd) Usage() string {
-	return `pcs sell -d <date> -s <security> -a <amount> [-q <quantity>] [-m <memo>]
+	return `pcs sell -d <date> -s <security> -a <amount> [-q <quantity>] [-m <memo>] [-account <account>] [-venue <venue>] [-strategy <strategy>]
 	
 	Sells shares of a security. The proceeds are credited to the cash account in the security's currency.
+	With -account, they are credited to the named cash account instead of the default one.
+	The optional -venue records the broker or the exchange of the trade, to filter the trades by venue.
+	The optional -strategy records the investment strategy of the trade, reviewed with pcs journal.
 	If -q is not specified, all shares of the security are sold.
 `
 }
@@ -84,21 +109,29 @@ func (c *sellCmd) SetFlags(f *flag.FlagSet) {
 	f.Var(QuantityVar(&c.quantity, "0"), "q", "Number of shares, if missing all shares are sold")
 	f.Var(DecimalVar(&c.amount, "0"), "a", "Total amount received for the shares")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.account, "account", "", "Cash account receiving the proceeds (e.g. a broker). Defaults to the default account.")
+	f.StringVar(&c.venue, "venue", "", "An optional broker or exchange of the trade")
+	f.StringVar(&c.strategy, "strategy", "", "An optional investment strategy of the trade (e.g. 'dividend', 'momentum')")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
-func (c *sellCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *sellCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *sellCmd) transaction() (portfolio.Transaction, error) {
 	if c.security == "" || c.amount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -s and -a flags are required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-s and -a flags are required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 	tx := portfolio.NewSell(day, c.memo, c.security, c.quantity, portfolio.M(c.amount, ""))
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	tx.Account = c.account
+	tx.Venue = c.venue
+	tx.Strategy = c.strategy
+	return tx, nil
 }
 
 // --- Dividend Command ---
@@ -130,20 +163,22 @@ func (c *dividendCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
-func (c *dividendCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *dividendCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *dividendCmd) transaction() (portfolio.Transaction, error) {
 	if c.security == "" || c.amount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -s and -a flags are required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-s and -a flags are required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewDividend(day, c.memo, c.security, portfolio.M(c.amount, c.currency))
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	return tx, nil
 }
 
 // --- Deposit Command ---
@@ -155,15 +190,21 @@ type depositCmd struct {
 	currency string
 	memo     string
 	settles  string
+	account  string
+	source   string
 	ledger   string
 }
 
 func (*depositCmd) Name() string     { return "deposit" }
 func (*depositCmd) Synopsis() string { return "record a cash deposit into the portfolio" }
 func (*depositCmd) Usage() string {
-	return `pcs deposit -d <date> -a <amount> -c <currency> [-m <memo>] [-settles <account>]
+	return `pcs deposit -d <date> -a <amount> -c <currency> [-m <memo>] [-settles <account>] [-account <account>] [-source <source>]
 	
 	Records a cash deposit into the portfolio's cash account.
+	With -account, the cash is deposited into the named cash account (e.g. a
+	broker or a bank) instead of the default one.
+	With -source, the deposit records where the cash comes from (salary, bonus,
+	gift or transfert), to tell savings from windfalls in 'pcs contributions'.
 `
 }
 func (c *depositCmd) SetFlags(f *flag.FlagSet) {
@@ -172,22 +213,28 @@ func (c *depositCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.currency, "c", "EUR", "Currency of the deposit (e.g., USD, EUR). Cash is kept in that currency")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note")
 	f.StringVar(&c.settles, "settles", "", "Settle a counterparty account")
+	f.StringVar(&c.account, "account", "", "Cash account (e.g. a broker or a bank). Defaults to the default account.")
+	f.StringVar(&c.source, "source", "", "Source of the cash (salary, bonus, gift, transfert)")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
-func (c *depositCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+func (c *depositCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *depositCmd) transaction() (portfolio.Transaction, error) {
 	if c.amount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -a flag is required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-a flag is required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewDeposit(day, c.memo, portfolio.M(c.amount, c.currency), c.settles)
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	tx.Account = c.account
+	tx.Source = c.source
+	return tx, nil
 }
 
 // --- Withdraw Command ---
@@ -199,15 +246,18 @@ type withdrawCmd struct {
 	currency string
 	memo     string
 	settles  string
+	account  string
 	ledger   string
 }
 
 func (*withdrawCmd) Name() string     { return "withdraw" }
 func (*withdrawCmd) Synopsis() string { return "record a cash withdrawal from the portfolio" }
 func (*withdrawCmd) Usage() string {
-	return `pcs withdraw -d <date> -a <amount> -c <currency> [-m <memo>] [-settles <account>]
+	return `pcs withdraw -d <date> -a <amount> -c <currency> [-m <memo>] [-settles <account>] [-account <account>]
 	
 	Records a cash withdrawal from the portfolio's cash account.
+	With -account, the cash is withdrawn from the named cash account instead of
+	the default one.
 `
 }
 func (c *withdrawCmd) SetFlags(f *flag.FlagSet) {
@@ -216,23 +266,27 @@ func (c *withdrawCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.currency, "c", "EUR", "Currency of the withdrawal (e.g., USD, EUR)")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note")
 	f.StringVar(&c.settles, "settles", "", "Settle a counterparty account")
+	f.StringVar(&c.account, "account", "", "Cash account (e.g. a broker or a bank). Defaults to the default account.")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
-func (c *withdrawCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *withdrawCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *withdrawCmd) transaction() (portfolio.Transaction, error) {
 	if c.amount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -a flag is required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-a flag is required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewWithdraw(day, c.memo, portfolio.M(c.amount, c.currency))
 	tx.Settles = c.settles
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	tx.Account = c.account
+	return tx, nil
 }
 
 // --- Convert Command ---
@@ -270,20 +324,22 @@ func (c *convertCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *convertCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *convertCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *convertCmd) transaction() (portfolio.Transaction, error) {
 	if c.fromCurrency == "" || c.fromAmount.IsZero() || c.toCurrency == "" || c.toAmount.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: -fc, -fa, -tc, and -ta flags are all required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-fc, -fa, -tc, and -ta flags are all required")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewConvert(day, c.memo, portfolio.M(c.fromAmount, c.fromCurrency), portfolio.M(c.toAmount, c.toCurrency))
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	return tx, nil
 }
 
 // --- Accrue Command ---
@@ -295,6 +351,7 @@ type accrueCmd struct {
 	receivable string
 	amount     decimal.Decimal
 	currency   string
+	rate       float64
 	memo       string
 	ledger     string
 }
@@ -302,9 +359,14 @@ type accrueCmd struct {
 func (*accrueCmd) Name() string     { return "accrue" }
 func (*accrueCmd) Synopsis() string { return "record a non-cash transaction with a counterparty" }
 func (*accrueCmd) Usage() string {
-	return `pcs accrue -d <date> (-payable <account> | -receivable <account>) -a <amount> -c <currency> [-m <memo>]
+	return `pcs accrue -d <date> (-payable <account> | -receivable <account>) -a <amount> -c <currency> [-rate <percent>] [-m <memo>]
 	
 	Records a non-cash transaction with a counterparty, such as a loan or rent.
+
+	With -rate, the counterparty account bears an annual interest rate from
+	that date on, e.g. a loan. The review reports the interest earned on a
+	receivable, or paid on a payable, over the period. The rate of an existing
+	account is changed with -a 0.
 `
 }
 
@@ -314,23 +376,39 @@ func (c *accrueCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.receivable, "receivable", "", "The counterparty account that owes money to the user")
 	f.Var(DecimalVar(&c.amount, "0"), "a", "Amount of cash to accrue")
 	f.StringVar(&c.currency, "c", "EUR", "Currency of the accrual (e.g., USD, EUR)")
+	f.Float64Var(&c.rate, "rate", 0, "Annual interest rate of the counterparty account, in percent (e.g. 3.5)")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *accrueCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *accrueCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	validatedTx, status := recordTransaction(ctx, c.ledger, c)
+	if status != subcommands.ExitSuccess {
+		return status
+	}
+
+	// Check if it's an Accrue transaction and if a new account was created
+	if accrueTx, ok := validatedTx.(portfolio.Accrue); ok {
+		if accrueTx.Create {
+			fmt.Printf("A new counterparty account '%s' has been created.\n", accrueTx.Counterparty)
+		}
+	}
+	return subcommands.ExitSuccess
+}
+
+func (c *accrueCmd) transaction() (portfolio.Transaction, error) {
 	if (c.payable == "" && c.receivable == "") || (c.payable != "" && c.receivable != "") {
-		fmt.Fprintln(os.Stderr, "Error: either -payable or -receivable must be specified.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("either -payable or -receivable must be specified")
+	}
+	if c.rate < 0 {
+		return nil, errors.New("-rate flag must not be negative")
 	}
-	if !c.amount.IsPositive() {
-		fmt.Fprintln(os.Stderr, "Error: -a flag must be a positive amount.")
-		return subcommands.ExitUsageError
+	if c.amount.IsNegative() || (c.amount.IsZero() && c.rate == 0) {
+		return nil, errors.New("-a flag must be a positive amount, or zero to set the -rate only")
 	}
 	day, err := portfolio.ParseDate(c.date) // Validate date format
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	var account string
@@ -345,20 +423,9 @@ func (c *accrueCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 	}
 
 	tx := portfolio.NewAccrue(day, c.memo, account, portfolio.M(amount, c.currency))
+	tx.Rate = portfolio.Percent(c.rate)
 
-	// Call handleTransaction and receive the validated transaction
-	validatedTx, status := handleTransaction(c.ledger, tx)
-	if status != subcommands.ExitSuccess {
-		return status
-	}
-
-	// Check if it's an Accrue transaction and if a new account was created
-	if accrueTx, ok := validatedTx.(portfolio.Accrue); ok {
-		if accrueTx.Create {
-			fmt.Printf("A new counterparty account '%s' has been created.\n", accrueTx.Counterparty)
-		}
-	}
-	return subcommands.ExitSuccess
+	return tx, nil
 }
 
 // --- Price Command ---
@@ -387,25 +454,26 @@ func (c *priceCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *priceCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *priceCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *priceCmd) transaction() (portfolio.Transaction, error) {
 	if c.ticker == "" {
-		fmt.Fprintln(os.Stderr, "Error: security ticker (-s) is required")
-		return subcommands.ExitUsageError
+		return nil, errors.New("security ticker (-s) is required")
 	}
 	if c.price.IsZero() {
-		fmt.Fprintln(os.Stderr, "Error: price (-p) is required and cannot be zero")
-		return subcommands.ExitUsageError
+		return nil, errors.New("price (-p) is required and cannot be zero")
 	}
 
 	date, err := portfolio.ParseDate(c.date)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewUpdatePrice(date, c.ticker, portfolio.M(c.price, ""))
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+	return tx, nil
 }
 
 // --- Split Command ---
@@ -437,96 +505,731 @@ func (c *splitCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *splitCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *splitCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *splitCmd) transaction() (portfolio.Transaction, error) {
 	if c.ticker == "" {
-		fmt.Fprintln(os.Stderr, "Error: security ticker (-s) is required")
-		return subcommands.ExitUsageError
+		return nil, errors.New("security ticker (-s) is required")
 	}
 	if c.num <= 0 {
-		fmt.Fprintln(os.Stderr, "Error: numerator (-num) must be a positive integer")
-		return subcommands.ExitUsageError
+		return nil, errors.New("numerator (-num) must be a positive integer")
 	}
 
 	date, err := portfolio.ParseDate(c.date)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 
 	tx := portfolio.NewSplit(date, c.ticker, c.num, c.den)
-	_, status := handleTransaction(c.ledger, tx)
+	return tx, nil
+}
+
+// --- Expire Command ---
+
+type expireCmd struct {
+	date     string
+	security string
+	memo     string
+	ledger   string
+}
+
+func (*expireCmd) Name() string     { return "expire" }
+func (*expireCmd) Synopsis() string { return "record the expiry of an option position" }
+func (*expireCmd) Usage() string {
+	return `pcs expire -s <option> [-d <date>] [-m <memo>]
+
+	Closes the whole position in an option at its expiry, without exercise.
+	A long position loses the premium paid, a short position keeps the premium received.
+`
+}
+
+func (c *expireCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Option ticker")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *expireCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
 	return status
 }
 
-// --- Init Command ---
+func (c *expireCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" {
+		return nil, errors.New("-s flag is required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewExpire(day, c.memo, c.security)
+	return tx, nil
+}
 
-// initCmd holds the flags for the 'init' subcommand.
-type initCmd struct {
+// --- Assign Command ---
+
+type assignCmd struct {
 	date     string
+	security string
+	quantity portfolio.Quantity
+	memo     string
+	ledger   string
+}
+
+func (*assignCmd) Name() string     { return "assign" }
+func (*assignCmd) Synopsis() string { return "record the exercise or assignment of option contracts" }
+func (*assignCmd) Usage() string {
+	return `pcs assign -s <option> [-q <contracts>] [-d <date>] [-m <memo>]
+
+	Settles option contracts by delivery of the underlying at the strike price:
+	when exercising a long position, or when a short position is assigned.
+	The underlying is bought for a long call or a short put, and sold for a long
+	put or a short call. If -q is not specified, the whole position is settled.
+`
+}
+
+func (c *assignCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Option ticker")
+	f.Var(QuantityVar(&c.quantity, "0"), "q", "Number of contracts, if missing the whole position is settled")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *assignCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *assignCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" {
+		return nil, errors.New("-s flag is required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewAssign(day, c.memo, c.security, c.quantity)
+	return tx, nil
+}
+
+// --- Lend Command ---
+
+type lendCmd struct {
+	date     string
+	security string
+	quantity portfolio.Quantity
+	memo     string
+	ledger   string
+}
+
+func (*lendCmd) Name() string     { return "lend" }
+func (*lendCmd) Synopsis() string { return "record shares of a security lent out" }
+func (*lendCmd) Usage() string {
+	return `pcs lend -s <security> -q <quantity> [-d <date>] [-m <memo>]
+
+	Records shares lent out to a borrower. Lent shares are still owned and valued,
+	but they are not held in the account until they are recalled.
+`
+}
+
+func (c *lendCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.Var(QuantityVar(&c.quantity, "0"), "q", "Number of shares lent")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *lendCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *lendCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" || c.quantity.IsZero() {
+		return nil, errors.New("-s and -q flags are required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewLend(day, c.memo, c.security, c.quantity)
+	return tx, nil
+}
+
+// --- Recall Command ---
+
+type recallCmd struct {
+	date     string
+	security string
+	quantity portfolio.Quantity
+	memo     string
+	ledger   string
+}
+
+func (*recallCmd) Name() string     { return "recall" }
+func (*recallCmd) Synopsis() string { return "record lent shares returned by the borrower" }
+func (*recallCmd) Usage() string {
+	return `pcs recall -s <security> [-q <quantity>] [-d <date>] [-m <memo>]
+
+	Records lent shares returned by the borrower.
+	If -q is not specified, all lent shares are returned.
+`
+}
+
+func (c *recallCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.Var(QuantityVar(&c.quantity, "0"), "q", "Number of shares returned, if missing all lent shares are returned")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *recallCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *recallCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" {
+		return nil, errors.New("-s flag is required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewRecall(day, c.memo, c.security, c.quantity)
+	return tx, nil
+}
+
+// --- Lending Fee Command ---
+
+type lendingFeeCmd struct {
+	date     string
+	security string
+	amount   decimal.Decimal
 	currency string
 	memo     string
 	ledger   string
 }
 
+func (*lendingFeeCmd) Name() string     { return "lending-fee" }
+func (*lendingFeeCmd) Synopsis() string { return "record the fee earned for lending a security" }
+func (*lendingFeeCmd) Usage() string {
+	return `pcs lending-fee -s <security> -a <amount> [-c <currency>] [-d <date>] [-m <memo>]
+
+	Records the total fee earned for lending shares of a security. Like a dividend, this is
+	an income event and does not affect the portfolio's cash balance.
+	The currency defaults to the security's currency.
+`
+}
+
+func (c *lendingFeeCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.Var(DecimalVar(&c.amount, "0"), "a", "Total fee earned")
+	f.StringVar(&c.currency, "c", "", "Currency of the fee, defaults to the security's currency")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *lendingFeeCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *lendingFeeCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" || c.amount.IsZero() {
+		return nil, errors.New("-s and -a flags are required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewLendingFee(day, c.memo, c.security, portfolio.M(c.amount, c.currency))
+	return tx, nil
+}
+
+// --- Note Command ---
+
+type noteCmd struct {
+	date     string
+	security string
+	memo     string
+	ledger   string
+}
+
+func (*noteCmd) Name() string     { return "note" }
+func (*noteCmd) Synopsis() string { return "record a dated commentary on a security" }
+func (*noteCmd) Usage() string {
+	return `pcs note -s <security> -m <commentary> [-d <date>]
+
+	Records a commentary on a security: a thesis, a doubt, a reason to sell.
+	Notes have no effect on the portfolio, they are reviewed with the trades of
+	the security by pcs journal.
+`
+}
+
+func (c *noteCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.StringVar(&c.memo, "m", "", "The commentary")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *noteCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *noteCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" || c.memo == "" {
+		return nil, errors.New("-s and -m flags are required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewNote(day, c.memo, c.security)
+	return tx, nil
+}
+
+// --- Price Alert Commands ---
+
+// alertFlags holds the direction and threshold flags of the price alert commands.
+type alertFlags struct {
+	above decimal.Decimal
+	below decimal.Decimal
+}
+
+func (c *alertFlags) SetFlags(f *flag.FlagSet) {
+	f.Var(DecimalVar(&c.above, "0"), "above", "Price at or above which the alert triggers")
+	f.Var(DecimalVar(&c.below, "0"), "below", "Price at or below which the alert triggers")
+}
+
+// alert returns the direction and threshold of the flags, exactly one of
+// -above or -below must be set.
+func (c *alertFlags) alert() (string, decimal.Decimal, error) {
+	switch {
+	case c.above.IsZero() == c.below.IsZero():
+		return "", decimal.Zero, errors.New("exactly one of -above or -below is required")
+	case !c.above.IsZero():
+		return portfolio.AlertAbove, c.above, nil
+	default:
+		return portfolio.AlertBelow, c.below, nil
+	}
+}
+
+type setPriceAlertCmd struct {
+	alertFlags
+	date     string
+	security string
+	until    string
+	memo     string
+	ledger   string
+}
+
+func (*setPriceAlertCmd) Name() string     { return "set-price-alert" }
+func (*setPriceAlertCmd) Synopsis() string { return "record an alert on the price of a security" }
+func (*setPriceAlertCmd) Usage() string {
+	return `pcs set-price-alert -s <security> (-above <price> | -below <price>) [-until <date>] [-d <date>] [-m <memo>]
+
+	Records an alert on the price of a security, in its currency, reaching a
+	threshold. The alert is active from the date until the -until date, or until
+	triggered. 'pcs alerts' reports the triggered alerts and marks them done.
+`
+}
+
+func (c *setPriceAlertCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	c.alertFlags.SetFlags(f)
+	f.StringVar(&c.until, "until", "", "An optional last day of the alert")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the alert")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *setPriceAlertCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *setPriceAlertCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" {
+		return nil, errors.New("-s flag is required")
+	}
+	direction, threshold, err := c.alert()
+	if err != nil {
+		return nil, err
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	var until portfolio.Date
+	if c.until != "" {
+		if until, err = portfolio.ParseDate(c.until); err != nil {
+			return nil, fmt.Errorf("-until: %w", err)
+		}
+	}
+	tx := portfolio.NewSetPriceAlert(day, c.memo, c.security, direction, threshold, until)
+	return tx, nil
+}
+
+type clearPriceAlertCmd struct {
+	alertFlags
+	date     string
+	security string
+	memo     string
+	ledger   string
+}
+
+func (*clearPriceAlertCmd) Name() string     { return "clear-price-alert" }
+func (*clearPriceAlertCmd) Synopsis() string { return "record the end of a price alert" }
+func (*clearPriceAlertCmd) Usage() string {
+	return `pcs clear-price-alert -s <security> (-above <price> | -below <price>) [-d <date>] [-m <memo>]
+
+	Records the end of the active price alert of a security with the same
+	direction and threshold, to cancel it. 'pcs alerts' records it for the
+	triggered alerts.
+`
+}
+
+func (c *clearPriceAlertCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	c.alertFlags.SetFlags(f)
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *clearPriceAlertCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *clearPriceAlertCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" {
+		return nil, errors.New("-s flag is required")
+	}
+	direction, threshold, err := c.alert()
+	if err != nil {
+		return nil, err
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewClearPriceAlert(day, c.memo, c.security, direction, threshold)
+	return tx, nil
+}
+
+// --- Grant Command ---
+
+type grantCmd struct {
+	date     string
+	security string
+	grant    string
+	plan     string
+	quantity decimal.Decimal
+	start    string
+	tranches int
+	every    int
+	memo     string
+	ledger   string
+}
+
+func (*grantCmd) Name() string     { return "grant" }
+func (*grantCmd) Synopsis() string { return "record an employee stock plan grant" }
+func (*grantCmd) Usage() string {
+	return `pcs grant -s <security> -g <grant> -q <quantity> [-plan rsu|espp] [-start <date>] [-n <tranches>] [-every <months>] [-d <date>] [-m <memo>]
+
+	Records shares granted by an employer stock plan (RSU or ESPP). The shares
+	vest in -n equal tranches, every -every months, starting on -start. Use the
+	'vest' command to record the shares when they vest.
+
+Usage Examples:
+# 400 RSUs vesting quarterly over 4 years, the first tranche after a year.
+$ pcs grant -d 2025-03-15 -s ACME -g RSU-2025 -q 400 -start 2026-03-15 -n 13 -every 3
+`
+}
+
+func (c *grantCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Grant date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.StringVar(&c.grant, "g", "", "Unique name of the grant")
+	f.StringVar(&c.plan, "plan", portfolio.PlanRSU, "Stock plan: rsu or espp")
+	f.Var(DecimalVar(&c.quantity, "0"), "q", "Total number of shares granted")
+	f.StringVar(&c.start, "start", "", "Date of the first vest. Defaults to the grant date.")
+	f.IntVar(&c.tranches, "n", 1, "Number of vesting tranches")
+	f.IntVar(&c.every, "every", 12, "Number of months between tranches")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *grantCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *grantCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" || c.grant == "" || c.quantity.IsZero() {
+		return nil, errors.New("-s, -g and -q flags are required")
+	}
+	if c.tranches <= 0 || c.every <= 0 {
+		return nil, errors.New("-n and -every must be positive")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	start := day
+	if c.start != "" {
+		if start, err = portfolio.ParseDate(c.start); err != nil {
+			return nil, fmt.Errorf("-start: %w", err)
+		}
+	}
+
+	// Equal whole tranches, the last one takes the remainder.
+	tranche := c.quantity.Div(decimal.NewFromInt(int64(c.tranches))).Floor()
+	schedule := make([]portfolio.VestingTranche, c.tranches)
+	remaining := c.quantity
+	for i := range schedule {
+		q := tranche
+		if i == len(schedule)-1 {
+			q = remaining
+		}
+		schedule[i] = portfolio.VestingTranche{Date: start.AddMonth(i * c.every), Quantity: portfolio.Q(q)}
+		remaining = remaining.Sub(q)
+	}
+
+	tx := portfolio.NewGrant(day, c.memo, c.security, c.grant, c.plan, schedule)
+	return tx, nil
+}
+
+// --- Vest Command ---
+
+type vestCmd struct {
+	date     string
+	security string
+	grant    string
+	quantity portfolio.Quantity
+	amount   decimal.Decimal
+	memo     string
+	ledger   string
+}
+
+func (*vestCmd) Name() string     { return "vest" }
+func (*vestCmd) Synopsis() string { return "record shares vested from an employee stock plan grant" }
+func (*vestCmd) Usage() string {
+	return `pcs vest -s <security> -g <grant> -q <quantity> -a <amount> [-d <date>] [-m <memo>]
+
+	Records shares vested from a grant. The shares are added to the portfolio with
+	their fair market value (-a) as cost basis, recorded as an external contribution.
+`
+}
+
+func (c *vestCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Vesting date. See the user manual for supported date formats.")
+	f.StringVar(&c.security, "s", "", "Security ticker")
+	f.StringVar(&c.grant, "g", "", "Name of the grant")
+	f.Var(QuantityVar(&c.quantity, "0"), "q", "Number of shares vested")
+	f.Var(DecimalVar(&c.amount, "0"), "a", "Fair market value of the vested shares")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *vestCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *vestCmd) transaction() (portfolio.Transaction, error) {
+	if c.security == "" || c.grant == "" || c.quantity.IsZero() || c.amount.IsZero() {
+		return nil, errors.New("-s, -g, -q and -a flags are required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewVest(day, c.memo, c.security, c.grant, c.quantity, portfolio.M(c.amount, ""))
+	return tx, nil
+}
+
+// --- Init Command ---
+
+// initCmd holds the flags for the 'init' subcommand.
+type initCmd struct {
+	date       string
+	currency   string
+	overdrafts overdraftsVar
+	timeZone   string
+	memo       string
+	ledger     string
+}
+
 func (*initCmd) Name() string { return "init" }
 func (*initCmd) Synopsis() string {
 	return "initializes the ledger with a base currency and inception date"
 }
 func (*initCmd) Usage() string {
-	return `pcs init -c <currency> [-d <date>] [-m <memo>]
+	return `pcs init -c <currency> [-d <date>] [-overdraft <currency>:<limit>[:<rate>]]... [-timezone <zone>] [-m <memo>]
 	
 Initializes the ledger. This command should be run first. It sets the
 ledger's reporting currency and its inception date. If run on an existing
 ledger, it will update or create the existing 'init' transaction.
+
+The -overdraft flag allows negative cash balances in a currency, e.g. for a
+margin account, down to the limit, with an optional annual interest rate in
+percent. Reviews report the periods of negative cash with their implied
+interest cost. It can be repeated for each currency, and replaces the
+overdrafts of an existing 'init' transaction.
+
+The -timezone flag sets the time zone of the trading day of the ledger, e.g.
+the time zone of the broker's market, telling today's date when recording
+transactions at night from another time zone.
+
+Usage Examples:
+$ pcs init -c EUR -d 2025-01-01
+$ pcs init -c EUR -overdraft USD:5000:8.5 -overdraft EUR:2000
+$ pcs init -c USD -timezone America/New_York
 `
 }
 
 func (c *initCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.date, "d", "", "Inception date of the ledger (defaults to today or day before first transaction).")
 	f.StringVar(&c.currency, "c", "", "The reporting currency for the entire ledger (e.g., EUR, USD).")
+	f.Var(&c.overdrafts, "overdraft", "Negative cash allowed in a currency, as <currency>:<limit>[:<rate>] (e.g. USD:5000:8.5). Can be repeated.")
+	f.StringVar(&c.timeZone, "timezone", "", "IANA time zone of the trading day of the ledger (e.g. America/New_York).")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction.")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
 }
 
-func (c *initCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *initCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *initCmd) transaction() (portfolio.Transaction, error) {
 	if c.currency == "" {
-		fmt.Fprintln(os.Stderr, "Error: -c flag for currency is required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-c flag for currency is required")
 	}
 	var day portfolio.Date
 	var err error
 	if c.date != "" {
 		day, err = portfolio.ParseDate(c.date)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-			return subcommands.ExitUsageError
+			return nil, err
 		}
 	}
 
 	tx := portfolio.NewInit(day, c.memo, c.currency)
+	tx.Overdrafts = c.overdrafts
+	tx.TimeZone = c.timeZone
+	return tx, nil
+}
 
-	_, status := handleTransaction(c.ledger, tx)
-	return status
+// overdraftsVar is a repeatable flag of overdrafts, as <currency>:<limit>[:<rate>].
+type overdraftsVar []portfolio.Overdraft
+
+func (o *overdraftsVar) String() string {
+	var s []string
+	for _, v := range *o {
+		s = append(s, fmt.Sprintf("%s:%s:%v", v.Currency, v.Limit, float64(v.Rate)))
+	}
+	return strings.Join(s, ", ")
+}
+
+func (o *overdraftsVar) Set(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid overdraft %q, want <currency>:<limit>[:<rate>]", s)
+	}
+	limit, err := decimal.NewFromString(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid overdraft limit %q: %w", parts[1], err)
+	}
+	od := portfolio.Overdraft{Currency: parts[0], Limit: limit}
+	if len(parts) == 3 {
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(parts[2], "%"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid overdraft rate %q: %w", parts[2], err)
+		}
+		od.Rate = portfolio.Percent(rate)
+	}
+	*o = append(*o, od)
+	return nil
+}
+
+// scoresVar is a repeatable flag of scores, as <name>=<value>.
+type scoresVar map[string]float64
+
+func (s *scoresVar) String() string {
+	var v []string
+	for _, name := range slices.Sorted(maps.Keys(*s)) {
+		v = append(v, fmt.Sprintf("%s=%v", name, (*s)[name]))
+	}
+	return strings.Join(v, ", ")
+}
+
+func (s *scoresVar) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid score %q, want <name>=<value>", v)
+	}
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid score value %q: %w", value, err)
+	}
+	if *s == nil {
+		*s = make(scoresVar)
+	}
+	(*s)[strings.ToLower(strings.TrimSpace(name))] = score
+	return nil
 }
 
 // declareCmd holds the flags for the 'declare' subcommand.
 type declareCmd struct {
-	ticker   string
-	id       string
-	currency string
-	date     string
-	memo     string
-	ledger   string
+	ticker    string
+	id        string
+	currency  string
+	precision int
+	ter       float64
+	watch     bool
+	cash      bool
+	scores    scoresVar
+	date      string
+	memo      string
+	ledger    string
 }
 
 func (*declareCmd) Name() string     { return "declare" }
 func (*declareCmd) Synopsis() string { return "declare a new security" }
 func (*declareCmd) Usage() string {
-	return `pcs declare -s <ticker> -id <security-id> -c <currency> [-d <date>] [-m <memo>]
+	return `pcs declare -s <ticker> -id <security-id> -c <currency> [-precision <decimals>] [-ter <percent>] [-watch] [-cash] [-score <name>=<value>]... [-d <date>] [-m <memo>]
 	
 	Declares a security, creating a mapping from a ledger-internal ticker to a
 	globally unique security ID and its currency. This declaration is required
 	before using the ticker in any transaction.
+
+	The total expense ratio (TER) of a fund is its annual fees, in percent of
+	its value (e.g. 0.2 for 0.2%). It is used to estimate the fees paid by the
+	portfolio (see pcs fees).
+
+	With -watch, the security is added to the watchlist: its market data is
+	fetched like any other security, but it cannot be held (see pcs watchlist).
+
+	With -cash, the security is a cash equivalent, like a money-market fund: the
+	holding report counts it with the cash, but its yield is still tracked like
+	the one of any other security.
+
+	Scores are named numeric ratings of the security, e.g. esg=62 for its ESG
+	rating, or conviction=8. They are reported on by pcs scores.
 	`
 }
 
@@ -534,6 +1237,11 @@ func (c *declareCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.ticker, "s", "", "Ledger-internal ticker to define (e.g., 'MY_AAPL')")
 	f.StringVar(&c.id, "id", "", "Full, unique security ID (e.g., 'US0378331005.XNAS')")
 	f.StringVar(&c.currency, "c", "", "The currency of the security (e.g., 'USD')")
+	f.IntVar(&c.precision, "precision", -1, "Number of decimals allowed in quantities (e.g., 0 for whole shares), unlimited if negative")
+	f.Float64Var(&c.ter, "ter", 0, "Total expense ratio of a fund, in percent per year (e.g., 0.2)")
+	f.BoolVar(&c.watch, "watch", false, "Add the security to the watchlist, it cannot be held")
+	f.BoolVar(&c.cash, "cash", false, "Declare a cash equivalent, like a money-market fund")
+	f.Var(&c.scores, "score", "Score of the security, as <name>=<value> (can be specified multiple times)")
 	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
 	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
 	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
@@ -548,27 +1256,110 @@ func (c *declareCmd) GenerateCommand(ticker, id, currency string) string {
 	return fmt.Sprintf("pcs declare -%s='%s' -%s='%s' -%s='%s'", "s", ticker, "id", id, "c", currency)
 }
 
-func (c *declareCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (c *declareCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
+	return status
+}
+
+func (c *declareCmd) transaction() (portfolio.Transaction, error) {
 	if c.ticker == "" || c.id == "" || c.currency == "" {
-		fmt.Fprintln(os.Stderr, "Error: -s, -id, and -c flags are all required.")
-		return subcommands.ExitUsageError
+		return nil, errors.New("-s, -id, and -c flags are all required")
 	}
 
 	day, err := portfolio.ParseDate(c.date)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, err
 	}
 	id, err := portfolio.ParseID(c.id)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid security ID: %v\n", err)
-		return subcommands.ExitUsageError
+		return nil, fmt.Errorf("invalid security ID: %w", err)
 	}
 	tx := portfolio.NewDeclare(day, c.memo, c.ticker, id, c.currency)
-	_, status := handleTransaction(c.ledger, tx)
+	if c.precision >= 0 {
+		tx.Precision = &c.precision
+	}
+	tx.TER = portfolio.Percent(c.ter)
+	tx.Watch = c.watch
+	tx.CashEquivalent = c.cash
+	if len(c.scores) > 0 {
+		tx.Scores = c.scores
+	}
+	return tx, nil
+}
+
+type declareCurrencyCmd struct {
+	code     string
+	symbol   string
+	decimals int
+	date     string
+	memo     string
+	ledger   string
+}
+
+func (*declareCurrencyCmd) Name() string { return "declare-currency" }
+func (*declareCurrencyCmd) Synopsis() string {
+	return "declare a currency that is not an ISO 4217 currency"
+}
+func (*declareCurrencyCmd) Usage() string {
+	return `pcs declare-currency -c <code> [-symbol <symbol>] [-decimals <decimals>] [-d <date>] [-m <memo>]
+
+	Declares a pseudo-currency, like loyalty points or a company scrip, so that
+	its code can be used by the transactions of the ledger like an ISO 4217
+	currency. Its amounts are rounded to its decimals, and formatted in reports
+	with its symbol, the code by default.
+
+	A currency pair of the currency, declared with pcs declare, prices it in the
+	other currencies.
+`
+}
+
+func (c *declareCurrencyCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.code, "c", "", "Code of the currency, 3 uppercase letters (e.g., 'PTS')")
+	f.StringVar(&c.symbol, "symbol", "", "Symbol of the amounts in reports (e.g., 'pts'). Defaults to the code.")
+	f.IntVar(&c.decimals, "decimals", 0, "Number of decimals of the amounts")
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Transaction date. See the user manual for supported date formats.")
+	f.StringVar(&c.memo, "m", "", "An optional rationale or note for the transaction")
+	f.StringVar(&c.ledger, "l", "", "Ledger to add the transaction to.")
+}
+
+func (c *declareCurrencyCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	_, status := recordTransaction(ctx, c.ledger, c)
 	return status
 }
 
+func (c *declareCurrencyCmd) transaction() (portfolio.Transaction, error) {
+	if c.code == "" {
+		return nil, errors.New("-c flag is required")
+	}
+	day, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		return nil, err
+	}
+	tx := portfolio.NewDeclareCurrency(day, c.memo, c.code, c.symbol, c.decimals)
+	return tx, nil
+}
+
+// transactionCommand is a command recording a single transaction. Building the
+// transaction from the flags is separate from recording it, so that 'pcs apply'
+// can record the transactions of many commands at once.
+type transactionCommand interface {
+	subcommands.Command
+	// transaction returns the transaction of the flags, or an error if they are
+	// invalid.
+	transaction() (portfolio.Transaction, error)
+}
+
+// recordTransaction builds the transaction of a command and records it in the
+// ledger, see handleTransaction.
+func recordTransaction(ctx context.Context, ledgerName string, cmd transactionCommand) (portfolio.Transaction, subcommands.ExitStatus) {
+	tx, err := cmd.transaction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, subcommands.ExitUsageError
+	}
+	return handleTransaction(ctx, ledgerName, tx)
+}
+
 // handleTransaction processes a transaction by validating it against the current
 // accounting system and then encoding it to the ledger file. It also manages
 // the CLI feedback, printing errors or a success message and returning the
@@ -577,18 +1368,24 @@ func (c *declareCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 // This function also applies "quick fixes" during validation, such as resolving
 // "sell all" quantities. The returned `portfolio.Transaction` is the validated
 // and potentially modified transaction.
-func handleTransaction(ledgerName string, tx portfolio.Transaction) (portfolio.Transaction, subcommands.ExitStatus) {
-	ledger, err := DecodeLedger(ledgerName)
+func handleTransaction(ctx context.Context, ledgerName string, tx portfolio.Transaction) (portfolio.Transaction, subcommands.ExitStatus) {
+	ledger, err := DecodeLedger(ctx, ledgerName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", ledgerName, err)
 		return nil, subcommands.ExitFailure
 	}
 
 	validatedTx, err := EncodeTransaction(ledger, tx)
+	if errors.Is(err, errDuplicate) {
+		fmt.Fprintf(os.Stderr, "Transaction already recorded in ledger %q, skipped.\n", ledger.Name())
+		return nil, subcommands.ExitSuccess
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return nil, subcommands.ExitUsageError
+		return nil, exitStatus(err, subcommands.ExitUsageError)
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "✅ Successfully recorded transaction in ledger %q.\n", ledger.Name())
 	}
-	fmt.Fprintf(os.Stderr, "✅ Successfully recorded transaction in ledger %q.\n", ledger.Name())
 	return validatedTx, subcommands.ExitSuccess
 }
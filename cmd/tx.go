@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/etnz/portfolio"
 	"github.com/etnz/portfolio/renderer"
@@ -17,15 +18,40 @@ type txCmd struct {
 	date       string
 	head       int
 	tail       int
+	query      string
+	byTag      bool
 	ledgerFile string
 }
 
 func (*txCmd) Name() string     { return "tx" }
 func (*txCmd) Synopsis() string { return "list all transactions in the ledger" }
 func (*txCmd) Usage() string {
-	return `pcs tx [-p <period> | -s <start_date>] [-d <end_date>] [-head <n>] [-tail <n>] [-l <ledger>]
+	return `pcs tx [-p <period> | -s <start_date>] [-d <end_date>] [-q <filter>] [-head <n>] [-tail <n>] [-by-tag] [-l <ledger>]
 
   Lists transactions from the ledger, with options for filtering and limiting the output.
+
+  The -q filter combines comparisons "field op value" with "and", "or", "not"
+  and parentheses. The fields are command, date, security, currency, amount,
+  quantity, memo, venue and tag. The operators are =, !=, <, <=, >, >= and ~
+  (contains).
+
+  Memos can carry tags: "#label" words and "key=value" pairs, e.g. "hotel
+  #vacation trip=japan". "tag:vacation" selects the transactions with a tag,
+  and "tag:trip=japan" those whose tag has a value. With -by-tag, the
+  transactions are totalled by tag instead of listed.
+
+Usage Examples:
+# List the large AAPL transactions since 2024.
+$ pcs tx -q "security=AAPL and date>=2024-01-01 and amount>1000"
+
+# List the trades with a memo about rebalancing.
+$ pcs tx -q "(command=buy or command=sell) and memo~rebalance"
+
+# List the trades made through a broker.
+$ pcs tx -q "venue=ib"
+
+# Total the transactions of the vacation by tag.
+$ pcs tx -q "tag:vacation" -by-tag
 `
 }
 
@@ -35,26 +61,37 @@ func (p *txCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&p.date, "d", "", "The end date for the range.")
 	f.IntVar(&p.head, "head", 0, "Show only the first N transactions.")
 	f.IntVar(&p.tail, "tail", 0, "Show only the last N transactions.")
+	f.StringVar(&p.query, "q", "", "Show only the transactions matching this filter expression.")
+	f.BoolVar(&p.byTag, "by-tag", false, "Total the transactions by memo tag instead of listing them.")
 	f.StringVar(&p.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
 }
 
-func (p *txCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (p *txCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if p.head > 0 && p.tail > 0 {
 		fmt.Fprintln(os.Stderr, "Error: -head and -tail flags cannot be used together.")
 		return subcommands.ExitUsageError
 	}
 
-	ledger, err := DecodeLedger(p.ledgerFile)
+	accept := portfolio.AcceptAll
+	if p.query != "" {
+		var err error
+		accept, err = portfolio.ParseFilter(p.query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing filter: %v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
+	ledger, err := DecodeLedger(ctx, p.ledgerFile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return subcommands.ExitFailure
 	}
 
-	var periodRange portfolio.Range
+	query := ledger.Query().Where(accept)
 	// If no date range flags are provided, use the full range of the ledger.
-	useFullRange := p.start == "" && p.date == "" && p.period == ""
-
-	if !useFullRange {
+	if p.start != "" || p.date != "" || p.period != "" {
+		var periodRange portfolio.Range
 		// Default end date to today if not provided
 		endDateStr := p.date
 		if endDateStr == "" {
@@ -81,22 +118,24 @@ func (p *txCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) su
 			}
 			periodRange = period.Range(endDate)
 		}
+		query.During(periodRange)
 	}
 
 	var transactions []portfolio.Transaction
-	for _, tx := range ledger.Transactions(portfolio.AcceptAll) {
-		if useFullRange || periodRange.Contains(tx.When()) {
-			transactions = append(transactions, tx)
-		}
+	switch {
+	case p.head > 0:
+		transactions = query.Limit(p.head).Collect()
+	case p.tail > 0:
+		transactions = query.Reverse().Limit(p.tail).Collect()
+		slices.Reverse(transactions)
+	default:
+		transactions = query.Collect()
 	}
 
-	if p.head > 0 && len(transactions) > p.head {
-		transactions = transactions[:p.head]
-	}
-	if p.tail > 0 && len(transactions) > p.tail {
-		transactions = transactions[len(transactions)-p.tail:]
+	if p.byTag {
+		printMarkdown(renderer.TagTotals(portfolio.TotalsByTag(transactions)))
+		return subcommands.ExitSuccess
 	}
-
 	printMarkdown(renderer.Transactions(transactions))
 
 	return subcommands.ExitSuccess
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// vestingCmd holds the flags for the 'vesting' subcommand.
+type vestingCmd struct {
+	date       string
+	ledgerFile string
+}
+
+func (*vestingCmd) Name() string     { return "vesting" }
+func (*vestingCmd) Synopsis() string { return "display employee stock plan grants and upcoming vests" }
+func (*vestingCmd) Usage() string {
+	return `pcs vesting [-d <date>] [-l <ledger>]
+
+  Lists the employee stock plan grants (RSU, ESPP) with their vested and
+  unvested shares, the value of the unvested shares at the latest known price,
+  and the upcoming vests.
+`
+}
+
+func (c *vestingCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the report. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to report on. Defaults to the only ledger if one exists.")
+}
+
+func (c *vestingCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Ledger file not found. Nothing to report.")
+			return subcommands.ExitSuccess
+		}
+		fmt.Fprintf(os.Stderr, "Error decoding ledger: %v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	printMarkdown(renderer.VestingMarkdown(ledger.NewSnapshot(on)))
+	return subcommands.ExitSuccess
+}
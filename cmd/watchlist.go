@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/google/subcommands"
+)
+
+// watchlistCmd holds the flags for the 'watchlist' subcommand.
+type watchlistCmd struct {
+	date       string
+	ledgerFile string
+}
+
+func (*watchlistCmd) Name() string { return "watchlist" }
+func (*watchlistCmd) Synopsis() string {
+	return "display the prices of the securities on the watchlist"
+}
+func (*watchlistCmd) Usage() string {
+	return `pcs watchlist [-d <date>] [-l <ledger>]
+
+  Displays the securities on the watchlist with their last price, the change
+  since the previous price, their lowest and highest prices over the last 52
+  weeks, and the change from the highest.
+
+  Securities are added to the watchlist with 'pcs declare -watch'. Their market
+  data is fetched by the providers like any other security, but they cannot be
+  held, so they never affect the holdings or the cash.
+
+Usage Examples:
+$ pcs declare -s NVDA -id US67066G1040.XNAS -c USD -watch
+$ pcs watchlist
+`
+}
+
+func (c *watchlistCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.date, "d", portfolio.Today().String(), "Date of the prices. See the user manual for supported date formats.")
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger of the watchlist. Defaults to the only ledger if one exists.")
+}
+
+func (c *watchlistCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	on, err := portfolio.ParseDate(c.date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing date: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+	if on.IsToday() {
+		if err := ledger.UpdateIntraday(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not update intraday prices: %v\n", err)
+		}
+	}
+
+	s := ledger.NewSnapshot(on)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Watchlist on %s\n\n", on)
+	fmt.Fprintln(&b, "| Ticker | Price | Change | 52-Week Low | 52-Week High | From High | Last Update |")
+	fmt.Fprintln(&b, "|:---|---:|---:|---:|---:|---:|:---|")
+	watched := 0
+	for sec := range ledger.AllSecurities() {
+		if !sec.Watched() {
+			continue
+		}
+		watched++
+		ticker := sec.Ticker()
+		price, last := s.Price(ticker), s.LastMarketDataDate(ticker)
+		stats := s.PriceStats(ticker, portfolio.Last52Weeks(on))
+		// The change is between the last two recorded prices.
+		var previous, latest portfolio.Money
+		for _, p := range ledger.PriceHistory(ticker, portfolio.Last52Weeks(on)) {
+			previous, latest = latest, p
+		}
+		change := "-"
+		if !previous.IsZero() {
+			change = portfolio.Percent(100 * (latest.AsFloat()/previous.AsFloat() - 1)).SignedString()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", ticker, price, change, stats.Low, stats.High, stats.FromHigh, last)
+	}
+	if watched == 0 {
+		fmt.Println("No securities on the watchlist, add them with 'pcs declare -watch'.")
+		return subcommands.ExitSuccess
+	}
+	printMarkdown(b.String())
+	return subcommands.ExitSuccess
+}
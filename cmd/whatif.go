@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+	"github.com/google/subcommands"
+)
+
+// whatifCmd holds the flags for the 'whatif' subcommand.
+type whatifCmd struct {
+	ledgerFile string
+}
+
+func (*whatifCmd) Name() string     { return "whatif" }
+func (*whatifCmd) Synopsis() string { return "simulate a transaction without recording it" }
+func (*whatifCmd) Usage() string {
+	return `pcs whatif [-l <ledger>] <transaction command>
+
+  Simulates a transaction command of pcs (buy, sell, deposit, ...) on the
+  ledger, without recording it, and shows the allocation of the portfolio and
+  the cash balances before and after it. The drift is the change of the weight
+  of each asset in the portfolio, in percentage points.
+
+  The -l flag of the transaction command is ignored: the transaction is
+  simulated on the ledger of the whatif command.
+
+Usage Examples:
+$ pcs whatif buy -s AAPL -q 10 -a 1800
+$ pcs whatif -l retirement sell -s MSFT -q 5 -a 2100
+`
+}
+
+func (c *whatifCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ledgerFile, "l", "", "Ledger to simulate the transaction on.")
+}
+
+func (c *whatifCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: a transaction command is required.")
+		return subcommands.ExitUsageError
+	}
+	tx, err := parseTransactionArgs(f.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	ledger, err := DecodeLedger(ctx, c.ledgerFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading ledger %q: %v\n", c.ledgerFile, err)
+		return subcommands.ExitFailure
+	}
+
+	// The transaction is validated first to report its quick fixes, e.g. the
+	// currency of the amount.
+	if valid, err := ledger.Validate(tx); err == nil {
+		tx = valid
+	}
+	after, err := portfolio.Simulate(ledger, tx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	before := ledger.NewSnapshot(after.On())
+	printMarkdown(whatifMarkdown(tx, before, after))
+	return subcommands.ExitSuccess
+}
+
+// whatifMarkdown renders the allocation and cash balances before and after a
+// simulated transaction.
+func whatifMarkdown(tx portfolio.Transaction, before, after *portfolio.Snapshot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# What If on %s\n\n", after.On())
+	fmt.Fprintf(&b, "%s\n\n", renderer.Transaction(tx))
+
+	weight := func(s *portfolio.Snapshot, value portfolio.Money) portfolio.Percent {
+		total := s.TotalPortfolio()
+		if total.IsZero() {
+			return 0
+		}
+		return portfolio.Percent(100 * value.AsFloat() / total.AsFloat())
+	}
+	row := func(name string, valueBefore, valueAfter portfolio.Money) {
+		wb, wa := weight(before, valueBefore), weight(after, valueAfter)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", name, valueBefore, wb, valueAfter, wa, (wa - wb).SignedString())
+	}
+
+	fmt.Fprintln(&b, "## Allocation")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "| Asset | Value Before | Weight Before | Value After | Weight After | Drift |")
+	fmt.Fprintln(&b, "|:---|---:|---:|---:|---:|---:|")
+	tickers := slices.Sorted(after.Securities())
+	for ticker := range before.Securities() {
+		if !slices.Contains(tickers, ticker) {
+			tickers = append(tickers, ticker)
+		}
+	}
+	for _, ticker := range tickers {
+		if sec, ok := after.SecurityDetails(ticker); ok && sec.CashEquivalent() {
+			continue // counted with the cash
+		}
+		valueBefore, valueAfter := before.Convert(before.MarketValue(ticker)), after.Convert(after.MarketValue(ticker))
+		if valueBefore.IsZero() && valueAfter.IsZero() {
+			continue
+		}
+		row(ticker, valueBefore, valueAfter)
+	}
+	currencies := slices.Collect(after.Currencies())
+	for _, cur := range currencies {
+		row("Cash "+cur, before.Convert(before.Cash(cur)), after.Convert(after.Cash(cur)))
+	}
+	if equivalentsBefore, equivalentsAfter := before.TotalCashEquivalents(), after.TotalCashEquivalents(); !equivalentsBefore.IsZero() || !equivalentsAfter.IsZero() {
+		row("Cash Equivalents", equivalentsBefore, equivalentsAfter)
+	}
+	fmt.Fprintf(&b, "| **Total** | **%s** | | **%s** | | |\n", before.TotalPortfolio(), after.TotalPortfolio())
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "## Cash")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "| Currency | Before | After | Change |")
+	fmt.Fprintln(&b, "|:---|---:|---:|---:|")
+	for _, cur := range currencies {
+		cashBefore, cashAfter := before.Cash(cur), after.Cash(cur)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", cur, cashBefore, cashAfter, cashAfter.Sub(cashBefore).SignedString())
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package portfolio
+
+import "github.com/shopspring/decimal"
+
+// Compact returns a copy of the ledger where the prices before a date are
+// thinned down to the last price of each period, for each security.
+//
+// Prices on the dates of other transactions and of price updates with a memo
+// are always kept, so that the values of the portfolio at the end of each
+// period and on each transaction date are unchanged. In between, a valuation
+// uses the last kept price instead of the actual one.
+func (l *Ledger) Compact(before Date, p Period) (*Ledger, error) {
+	l = l.clone()
+	l.stableSort()
+
+	// Dates whose prices are kept.
+	keepDates := make(map[Date]bool)
+	// last[ticker][period start] is the date of the last price of the period.
+	last := make(map[string]map[Date]Date)
+	for _, tx := range l.transactions {
+		u, ok := tx.(UpdatePrice)
+		if !ok || u.Memo != "" {
+			keepDates[tx.When()] = true
+			continue
+		}
+		if !u.Date.Before(before) {
+			continue
+		}
+		for ticker := range u.Prices {
+			if last[ticker] == nil {
+				last[ticker] = make(map[Date]Date)
+			}
+			last[ticker][p.Range(u.Date).From] = u.Date
+		}
+	}
+
+	compacted := NewLedger()
+	compacted.name = l.name
+	compacted.currency = l.currency
+	compacted.splitPrices = l.splitPrices
+	compacted.pivot = l.pivot
+	for _, tx := range l.transactions {
+		u, ok := tx.(UpdatePrice)
+		if !ok || !u.Date.Before(before) || keepDates[u.Date] {
+			compacted.transactions = append(compacted.transactions, tx)
+			continue
+		}
+		prices := make(map[string]decimal.Decimal)
+		for ticker, price := range u.Prices {
+			if last[ticker][p.Range(u.Date).From] == u.Date {
+				prices[ticker] = price
+			}
+		}
+		switch {
+		case len(prices) == len(u.Prices):
+			compacted.transactions = append(compacted.transactions, tx)
+		case len(prices) > 0:
+			u.Prices = prices
+			compacted.transactions = append(compacted.transactions, u)
+		}
+	}
+	return compacted, compacted.index()
+}
@@ -0,0 +1,65 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLedger_Compact(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "USD"
+	start, before := NewDate(2024, 1, 1), NewDate(2025, 1, 1)
+	txs := []Transaction{
+		NewDeclare(start, "", "AAPL", AAPL, "USD"),
+		NewDeposit(start, "", USD(10000), ""),
+		NewBuy(NewDate(2024, 3, 13), "", "AAPL", Q(10), USD(1700)),
+		NewBuy(NewDate(2024, 9, 18), "", "AAPL", Q(10), USD(2200)),
+	}
+	manual := NewUpdatePrice(NewDate(2024, 10, 2), "AAPL", USD(226.78))
+	manual.Memo = "closing auction"
+	txs = append(txs, manual)
+	// A daily price walk over 2024 and January 2025.
+	price, i := 180.0, 0
+	for day := range NewRange(start, NewDate(2025, 1, 31)).Days() {
+		i++
+		price *= 1 + 0.01*math.Sin(float64(i))
+		txs = append(txs, NewUpdatePrice(day, "AAPL", USD(math.Round(price*100)/100)))
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	compacted, err := ledger.Compact(before, Weekly)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	count := func(l *Ledger, r Range) int {
+		return len(l.Query().Command(CmdUpdatePrice).During(r).Collect())
+	}
+	// 53 weeks in 2024, plus the prices on the three dates of other transactions,
+	// and the two prices on the date of the manual price.
+	if got, want := count(compacted, NewRange(start, before.Add(-1))), 53+3+2; got != want {
+		t.Errorf("prices in 2024 after compaction = %d, want %d", got, want)
+	}
+	if got, want := count(compacted, NewRange(before, NewDate(2025, 1, 31))), 31; got != want {
+		t.Errorf("prices after %s = %d, want %d", before, got, want)
+	}
+
+	// Values are unchanged at the end of each week and on transaction dates, and
+	// do not drift much in between.
+	for day := range NewRange(start, NewDate(2025, 1, 31)).Days() {
+		want := ledger.NewSnapshot(day).TotalPortfolio().AsFloat()
+		got := compacted.NewSnapshot(day).TotalPortfolio().AsFloat()
+		exact := day == Weekly.Range(day).To || !day.Before(before)
+		for _, tx := range txs[:5] {
+			exact = exact || day == tx.When()
+		}
+		switch {
+		case exact && got != want:
+			t.Errorf("value on %s = %v, want %v", day, got, want)
+		case math.Abs(got-want) > 0.05*want:
+			t.Errorf("value on %s = %v, drifts more than 5%% from %v", day, got, want)
+		}
+	}
+}
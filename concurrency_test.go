@@ -0,0 +1,188 @@
+package portfolio
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The tests of this file are meant to be run with the race detector:
+//
+//	go test -race -run Concurrent .
+
+func TestLedger_Concurrent(t *testing.T) {
+	start := NewDate(2025, time.January, 1)
+	ledger := NewLedger()
+	if err := ledger.Append(NewDeclare(start, "", "AAPL", AAPL, "EUR")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	const days = 100
+	end := start.Add(days)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 1; i <= days; i++ {
+			on := start.Add(i)
+			if err := ledger.Append(NewDeposit(on, "", EUR(10), "")); err != nil {
+				t.Errorf("ledger.Append() error = %v", err)
+				return
+			}
+			if _, err := ledger.UpdateMarketData(NewUpdatePrice(on, "AAPL", EUR(float64(100+i)))); err != nil {
+				t.Errorf("ledger.UpdateMarketData() error = %v", err)
+				return
+			}
+			ledger.RecordQuote(time.Now(), "AAPL", EUR(float64(100+i)))
+		}
+	}()
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last Money
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// The cash of a snapshot only grows, as deposits are appended.
+				cash := ledger.NewSnapshot(end).Cash("EUR")
+				if cash.LessThan(last) {
+					t.Errorf("Cash() = %v after %v, want it to grow", cash, last)
+					return
+				}
+				last = cash
+				for range ledger.Query().Command(CmdDeposit).All() {
+				}
+				for range ledger.PriceHistory("AAPL", NewRange(start, end)) {
+				}
+				if ledger.Security("AAPL") == nil {
+					t.Errorf("Security(AAPL) = nil, want the declared security")
+					return
+				}
+				ledger.Intraday(ledger.Today(), "AAPL")
+				ledger.Validate(NewWithdraw(end, "", EUR(1)))
+				ledger.NewReview(NewRange(start, end)).End().Cash("EUR")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := ledger.NewSnapshot(end).Cash("EUR"); !got.Equal(EUR(10 * days)) {
+		t.Errorf("Cash() = %v, want %v", got, EUR(10*days))
+	}
+	if got := len(ledger.Intraday(ledger.Today(), "AAPL")); got != days {
+		t.Errorf("len(Intraday()) = %d, want %d", got, days)
+	}
+}
+
+func TestLedger_ConcurrentWriters(t *testing.T) {
+	on := NewDate(2025, time.January, 2)
+	ledger := NewLedger()
+	const writers, deposits = 4, 25
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range deposits {
+				if err := ledger.Append(NewDeposit(on, "", EUR(1), "")); err != nil {
+					t.Errorf("ledger.Append() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	// Settings are changed meanwhile, they must not undo the appends.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range deposits {
+			ledger.SetSettlementLag(i % 3)
+		}
+	}()
+	wg.Wait()
+
+	if got := ledger.NewSnapshot(on).Cash("EUR"); !got.Equal(EUR(writers * deposits)) {
+		t.Errorf("Cash() = %v, want %v", got, EUR(writers*deposits))
+	}
+}
+
+func TestLedger_SnapshotIsolation(t *testing.T) {
+	on := NewDate(2025, time.January, 2)
+	ledger := NewLedger()
+	if err := ledger.Append(NewDeposit(on, "", EUR(100), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	s := ledger.NewSnapshot(on)
+	txs := ledger.Query().All()
+
+	// An earlier transaction is sorted before the existing one.
+	if err := ledger.Append(NewDeposit(on.Add(-1), "", EUR(50), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if got := s.Cash("EUR"); !got.Equal(EUR(100)) {
+		t.Errorf("Cash() of a snapshot taken before the append = %v, want 100 EUR", got)
+	}
+	for _, tx := range txs {
+		if tx.When() != on {
+			t.Errorf("query run before the append yields the transaction of %s, want only the one of %s", tx.When(), on)
+		}
+	}
+	if got := ledger.NewSnapshot(on).Cash("EUR"); !got.Equal(EUR(150)) {
+		t.Errorf("Cash() after the append = %v, want 150 EUR", got)
+	}
+}
+
+func TestLedger_Batch(t *testing.T) {
+	on := NewDate(2025, time.January, 2)
+	ledger := NewLedger()
+	errStop := errors.New("stop")
+
+	// A failed batch leaves the ledger unchanged.
+	err := ledger.Batch(func(b *Ledger) error {
+		if err := b.Append(NewDeposit(on, "", EUR(100), "")); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Batch() error = %v, want %v", err, errStop)
+	}
+	for _, tx := range ledger.Transactions() {
+		t.Errorf("failed Batch() recorded the %s of %s, want none", tx.What(), tx.When())
+	}
+
+	s := ledger.NewSnapshot(on)
+	err = ledger.Batch(func(b *Ledger) error {
+		for i := range 3 {
+			if err := b.Append(NewDeposit(on.Add(i), "", EUR(100), "")); err != nil {
+				return err
+			}
+		}
+		// The changes of the batch are visible within the batch only.
+		if got := b.NewSnapshot(on.Add(2)).Cash("EUR"); !got.Equal(EUR(300)) {
+			t.Errorf("Cash() within the batch = %v, want 300 EUR", got)
+		}
+		if got := ledger.NewSnapshot(on.Add(2)).Cash("EUR"); !got.IsZero() {
+			t.Errorf("Cash() of the ledger during the batch = %v, want 0", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Batch() error = %v", err)
+	}
+	if got := ledger.NewSnapshot(on.Add(2)).Cash("EUR"); !got.Equal(EUR(300)) {
+		t.Errorf("Cash() after the batch = %v, want 300 EUR", got)
+	}
+	if got := s.Cash("EUR"); !got.IsZero() {
+		t.Errorf("Cash() of a snapshot taken before the batch = %v, want 0", got)
+	}
+}
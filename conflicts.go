@@ -0,0 +1,237 @@
+package portfolio
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ConflictRule tells which price to keep when two market data providers
+// disagree on the price of a security on a day.
+type ConflictRule string
+
+const (
+	// PreferLast keeps the price fetched last. It is the default rule.
+	PreferLast ConflictRule = "last"
+	// PreferExisting keeps the price recorded first.
+	PreferExisting ConflictRule = "existing"
+	// PreferOrder keeps the price of the provider ranked first in the order of
+	// the policy. Providers out of the order rank last.
+	PreferOrder ConflictRule = "order"
+	// PreferVolume keeps the price reported with the largest traded volume,
+	// see UpdatePrice.Volumes. Prices without a volume have a volume of 0.
+	PreferVolume ConflictRule = "volume"
+	// ReviewConflicts keeps the price recorded first and queues the conflict
+	// for a manual review, see Ledger.Conflicts.
+	ReviewConflicts ConflictRule = "review"
+)
+
+// ConflictPolicy is the way to settle the disagreements between market data
+// providers, see Ledger.UpdateMarketData. Manual prices are not concerned:
+// they always override the prices of the providers.
+type ConflictPolicy struct {
+	Rule  ConflictRule
+	Order []string // Order ranks the providers for PreferOrder, preferred first.
+}
+
+// ParseConflictPolicy parses a policy: "last", "existing", "volume", "review",
+// or "order:" followed by the providers, preferred first, separated by commas,
+// e.g. "order:eodhd,amundi".
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	rule, order, ok := strings.Cut(s, ":")
+	p := ConflictPolicy{Rule: ConflictRule(rule)}
+	switch p.Rule {
+	case PreferLast, PreferExisting, PreferVolume, ReviewConflicts:
+		if ok {
+			return p, fmt.Errorf("invalid conflict policy %q: only %q lists providers", s, PreferOrder)
+		}
+	case PreferOrder:
+		for provider := range strings.SplitSeq(order, ",") {
+			if provider = strings.TrimSpace(provider); provider != "" {
+				p.Order = append(p.Order, provider)
+			}
+		}
+		if len(p.Order) == 0 {
+			return p, fmt.Errorf("invalid conflict policy %q: want the providers, e.g. %q", s, "order:eodhd,amundi")
+		}
+	default:
+		return p, fmt.Errorf("invalid conflict policy %q, valid policies are: last, existing, order:<providers>, volume, review", s)
+	}
+	return p, nil
+}
+
+func (p ConflictPolicy) String() string {
+	if p.Rule == PreferOrder {
+		return string(p.Rule) + ":" + strings.Join(p.Order, ",")
+	}
+	return string(cmp.Or(p.Rule, PreferLast))
+}
+
+// SetConflictPolicy sets the policy applied when market data providers
+// disagree on a price, PreferLast by default.
+func (l *Ledger) SetConflictPolicy(p ConflictPolicy) {
+	l.update(func(w *Ledger) error { w.conflictPolicy = p; return nil })
+}
+
+// PriceConflict is a disagreement between two market data providers on the
+// price of a security on a day, queued for review by ReviewConflicts.
+type PriceConflict struct {
+	Date           Date   `json:"date"`
+	Security       string `json:"security"`
+	Existing       Money  `json:"-"` // Existing is the price kept.
+	ExistingSource string `json:"existingSource"`
+	Incoming       Money  `json:"-"` // Incoming is the price rejected.
+	IncomingSource string `json:"incomingSource"`
+}
+
+// Conflicts returns the conflicts queued for review, by date and security.
+func (l *Ledger) Conflicts() []PriceConflict {
+	l = l.view()
+	conflicts := slices.Clone(l.conflicts)
+	slices.SortStableFunc(conflicts, func(a, b PriceConflict) int {
+		return cmp.Or(a.Date.Compare(b.Date), strings.Compare(a.Security, b.Security))
+	})
+	return conflicts
+}
+
+// ResolveConflict removes a conflict from the review queue. If accept is true,
+// the incoming price replaces the existing one, otherwise the existing price is
+// kept.
+func (l *Ledger) ResolveConflict(c PriceConflict, accept bool) error {
+	return l.update(func(w *Ledger) error { return w.resolveConflict(c, accept) })
+}
+
+// resolveConflict removes a conflict from the review queue, see ResolveConflict.
+func (l *Ledger) resolveConflict(c PriceConflict, accept bool) error {
+	i := slices.IndexFunc(l.conflicts, func(q PriceConflict) bool {
+		return q.Date == c.Date && q.Security == c.Security
+	})
+	if i < 0 {
+		return fmt.Errorf("no conflict on the price of %s on %s", c.Security, c.Date)
+	}
+	l.conflicts = slices.Delete(l.conflicts, i, i+1)
+	if !accept {
+		return nil
+	}
+	policy := l.conflictPolicy
+	defer func() { l.conflictPolicy = policy }()
+	l.conflictPolicy = ConflictPolicy{Rule: PreferLast}
+	u := NewUpdatePrice(c.Date, c.Security, c.Incoming)
+	u.Source = c.IncomingSource
+	_, err := l.updateMarketData(u)
+	return err
+}
+
+// preferIncoming reports whether the price of a ticker in the incoming update
+// replaces the price of the existing update of another provider, according to
+// the conflict policy. Conflicts are queued for review by ReviewConflicts.
+func (l *Ledger) preferIncoming(existing, incoming UpdatePrice, ticker string) bool {
+	if existing.Prices[ticker].Equal(incoming.Prices[ticker]) {
+		return true
+	}
+	switch l.conflictPolicy.Rule {
+	case PreferExisting:
+		return false
+	case PreferOrder:
+		rank := func(source string) int {
+			if i := slices.Index(l.conflictPolicy.Order, source); i >= 0 {
+				return i
+			}
+			return len(l.conflictPolicy.Order)
+		}
+		return rank(incoming.Source) <= rank(existing.Source)
+	case PreferVolume:
+		return incoming.Volumes[ticker] >= existing.Volumes[ticker]
+	case ReviewConflicts:
+		currency := ""
+		if sec := l.Security(ticker); sec != nil {
+			currency = sec.Currency()
+		}
+		c := PriceConflict{
+			Date:           incoming.Date,
+			Security:       ticker,
+			Existing:       M(existing.Prices[ticker], currency),
+			ExistingSource: existing.Source,
+			Incoming:       M(incoming.Prices[ticker], currency),
+			IncomingSource: incoming.Source,
+		}
+		l.conflicts = slices.DeleteFunc(l.conflicts, func(q PriceConflict) bool {
+			return q.Date == c.Date && q.Security == c.Security
+		})
+		l.conflicts = append(l.conflicts, c)
+		return false
+	}
+	return true
+}
+
+// conflictsFile returns the file of the conflicts queued for review of a ledger
+// file.
+func conflictsFile(ledgerFile string) string {
+	return strings.TrimSuffix(ledgerFile, ".jsonl") + ".conflicts.jsonl"
+}
+
+// isConflictsFile reports whether a file is a conflicts file, not a ledger.
+func isConflictsFile(file string) bool { return strings.HasSuffix(file, ".conflicts.jsonl") }
+
+// conflictLine is a conflict in the conflicts file.
+type conflictLine struct {
+	PriceConflict
+	Existing decimal.Decimal `json:"existing"`
+	Incoming decimal.Decimal `json:"incoming"`
+}
+
+// encodeConflictsFile writes the conflicts in a file, one JSON object per
+// line, or removes it if there are none.
+func encodeConflictsFile(file string, conflicts []PriceConflict) error {
+	if len(conflicts) == 0 {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove conflicts file %q: %w", file, err)
+		}
+		return nil
+	}
+	err := writeFile(file, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		for _, c := range conflicts {
+			if err := enc.Encode(conflictLine{PriceConflict: c, Existing: c.Existing.value, Incoming: c.Incoming.value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not write conflicts file %q: %w", file, err)
+	}
+	return nil
+}
+
+// decodeConflictsFile reads the conflicts of a file. The currency of the prices
+// is the currency of their security, the conflicts of undeclared securities
+// are dropped.
+func (l *Ledger) decodeConflictsFile(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var c conflictLine
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return &LineError{Line: line, Err: err}
+		}
+		sec := l.Security(c.Security)
+		if sec == nil {
+			continue
+		}
+		c.PriceConflict.Existing = M(c.Existing, sec.Currency())
+		c.PriceConflict.Incoming = M(c.Incoming, sec.Currency())
+		l.conflicts = append(l.conflicts, c.PriceConflict)
+	}
+	return scanner.Err()
+}
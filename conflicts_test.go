@@ -0,0 +1,136 @@
+package portfolio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "last", want: "last"},
+		{in: "review", want: "review"},
+		{in: "order:eodhd, amundi", want: "order:eodhd,amundi"},
+		{in: "order:", wantErr: true},
+		{in: "volume:eodhd", wantErr: true},
+		{in: "first", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := ParseConflictPolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConflictPolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && p.String() != tt.want {
+			t.Errorf("ParseConflictPolicy(%q) = %q, want %q", tt.in, p, tt.want)
+		}
+	}
+}
+
+func TestLedger_ConflictPolicy(t *testing.T) {
+	day := NewDate(2025, time.January, 2)
+	sourced := func(price Money, source string, volume int64) UpdatePrice {
+		u := NewUpdatePrice(day, "AAPL", price)
+		u.Source = source
+		u.Volumes = map[string]int64{"AAPL": volume}
+		return u
+	}
+	tests := []struct {
+		policy string
+		want   Money
+	}{
+		{policy: "last", want: USD(101)},
+		{policy: "existing", want: USD(100)},
+		{policy: "order:tradegate,eodhd", want: USD(101)},
+		{policy: "order:eodhd", want: USD(100)},
+		{policy: "volume", want: USD(100)},
+		{policy: "review", want: USD(100)},
+	}
+	for _, tt := range tests {
+		ledger := NewLedger()
+		if err := ledger.Append(NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		p, err := ParseConflictPolicy(tt.policy)
+		if err != nil {
+			t.Fatalf("ParseConflictPolicy(%q) error = %v", tt.policy, err)
+		}
+		ledger.SetConflictPolicy(p)
+		if _, err := ledger.UpdateMarketData(sourced(USD(100), "eodhd", 2000)); err != nil {
+			t.Fatalf("UpdateMarketData() error = %v", err)
+		}
+		if _, err := ledger.UpdateMarketData(sourced(USD(101), "tradegate", 10)); err != nil {
+			t.Fatalf("UpdateMarketData() error = %v", err)
+		}
+		if got := ledger.NewSnapshot(day).Price("AAPL"); !got.Equal(tt.want) {
+			t.Errorf("%s: Price() = %v, want %v", tt.policy, got, tt.want)
+		}
+		wantConflicts := 0
+		if p.Rule == ReviewConflicts {
+			wantConflicts = 1
+		}
+		if got := ledger.Conflicts(); len(got) != wantConflicts {
+			t.Errorf("%s: Conflicts() = %v, want %d", tt.policy, got, wantConflicts)
+		}
+	}
+}
+
+func TestLedger_ResolveConflict(t *testing.T) {
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	day := NewDate(2025, time.January, 2)
+	if err := ledger.Append(NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	ledger.SetConflictPolicy(ConflictPolicy{Rule: ReviewConflicts})
+	for _, source := range []string{"eodhd", "tradegate"} {
+		u := NewUpdatePrice(day, "AAPL", USD(100))
+		if source == "tradegate" {
+			u = NewUpdatePrice(day, "AAPL", USD(101))
+		}
+		u.Source = source
+		if _, err := ledger.UpdateMarketData(u); err != nil {
+			t.Fatalf("UpdateMarketData() error = %v", err)
+		}
+	}
+
+	// Conflicts are saved apart and loaded back.
+	dir := t.TempDir()
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	loaded, err := FindLedger(context.Background(), dir, "ledger")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	conflicts := loaded.Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("loaded Conflicts() = %v, want 1 conflict", conflicts)
+	}
+	c := conflicts[0]
+	if c.Security != "AAPL" || !c.Existing.Equal(USD(100)) || c.ExistingSource != "eodhd" || !c.Incoming.Equal(USD(101)) || c.IncomingSource != "tradegate" {
+		t.Errorf("loaded Conflicts() = %+v, want the eodhd and tradegate prices", c)
+	}
+
+	if err := loaded.ResolveConflict(c, true); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	if got := loaded.NewSnapshot(day).Price("AAPL"); !got.Equal(USD(101)) {
+		t.Errorf("Price() = %v, want the accepted price", got)
+	}
+	if err := loaded.ResolveConflict(c, false); err == nil {
+		t.Errorf("ResolveConflict() of a resolved conflict succeeded, want an error")
+	}
+	if err := SaveLedger(dir, loaded); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ledger.conflicts.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("conflicts file kept without conflicts: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package portfolio
+
+// Contributions is the cash deposited in the portfolio during a calendar year,
+// by source. Amounts are in the reporting currency, at the exchange rate of the
+// day of each deposit.
+type Contributions struct {
+	Year int
+	// BySource is the cash deposited from each source, see DepositSources.
+	// Deposits without a source are under the empty source.
+	BySource map[string]Money
+	Total    Money
+}
+
+// Source returns the cash deposited from a source, zero if none.
+func (c Contributions) Source(source string) Money {
+	if m, ok := c.BySource[source]; ok {
+		return m
+	}
+	return M(0, c.Total.Currency())
+}
+
+// Contributions returns the contributions to the portfolio of each year with a
+// deposit, in chronological order. Deposits that settle a counterparty account
+// are not contributions: the cash was accounted for by the accrual.
+func (l *Ledger) Contributions() []Contributions {
+	l = l.view()
+	var years []Contributions
+	for _, tx := range l.transactions {
+		d, ok := tx.(Deposit)
+		if !ok || d.Settles != "" {
+			continue
+		}
+		if len(years) == 0 || years[len(years)-1].Year != d.When().Year() {
+			years = append(years, Contributions{
+				Year:     d.When().Year(),
+				BySource: make(map[string]Money),
+				Total:    M(0, l.currency),
+			})
+		}
+		c := &years[len(years)-1]
+		amount := l.NewSnapshot(d.When()).Convert(d.Amount)
+		c.BySource[d.Source] = c.Source(d.Source).Add(amount)
+		c.Total = c.Total.Add(amount)
+	}
+	return years
+}
@@ -0,0 +1,57 @@
+package portfolio
+
+import "testing"
+
+func TestLedger_Contributions(t *testing.T) {
+	salary := NewDeposit(NewDate(2024, 1, 31), "", EUR(1000), "")
+	salary.Source = SourceSalary
+	bonus := NewDeposit(NewDate(2024, 3, 15), "", USD(500), "")
+	bonus.Source = SourceBonus
+	gift := NewDeposit(NewDate(2025, 6, 1), "", EUR(200), "")
+	gift.Source = SourceGift
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2024, 1, 1), "", "EUR"),
+		NewDeclare(NewDate(2024, 1, 1), "", "USDEUR", USDEUR, "EUR"),
+		NewUpdatePrice(NewDate(2024, 3, 1), "USDEUR", EUR(0.9)),
+		NewUpdatePrice(NewDate(2024, 4, 1), "USDEUR", EUR(1)),
+		salary,
+		bonus,
+		NewDeposit(NewDate(2024, 5, 1), "", EUR(100), ""),
+		NewCreatedAccrue(NewDate(2025, 1, 1), "", "Loan", EUR(300)),
+		NewDeposit(NewDate(2025, 2, 1), "", EUR(300), "Loan"),
+		gift,
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	got := ledger.Contributions()
+	if len(got) != 2 {
+		t.Fatalf("len(Contributions()) = %d, want 2", len(got))
+	}
+	// The bonus is converted at the rate of its day, the loan repayment is not a contribution.
+	for _, tc := range []struct {
+		year      int
+		source    string
+		got, want Money
+	}{
+		{2024, SourceSalary, got[0].Source(SourceSalary), EUR(1000)},
+		{2024, SourceBonus, got[0].Source(SourceBonus), EUR(450)},
+		{2024, "", got[0].Source(""), EUR(100)},
+		{2024, "total", got[0].Total, EUR(1550)},
+		{2025, SourceGift, got[1].Source(SourceGift), EUR(200)},
+		{2025, SourceSalary, got[1].Source(SourceSalary), EUR(0)},
+		{2025, "total", got[1].Total, EUR(200)},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("Contributions() %d %q = %v, want %v", tc.year, tc.source, tc.got, tc.want)
+		}
+	}
+
+	invalid := NewDeposit(NewDate(2025, 7, 1), "", EUR(10), "")
+	invalid.Source = "lottery"
+	if _, err := ledger.Validate(invalid); err == nil {
+		t.Errorf("Validate(deposit) with an invalid source: expected an error")
+	}
+}
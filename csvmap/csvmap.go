@@ -0,0 +1,302 @@
+// Package csvmap converts the CSV exports of any broker or bank into
+// transactions, following a declarative mapping instead of a dedicated
+// importer.
+//
+// A mapping is a JSON file that tells which column holds each field of the
+// transactions, how dates and numbers are written, and which rows to keep:
+//
+//	{
+//	  "comma": ";",
+//	  "dateFormat": "02/01/2006",
+//	  "decimal": ",",
+//	  "columns": {
+//	    "command": "Operation",
+//	    "date": "Date",
+//	    "security": "ISIN",
+//	    "quantity": "Quantity",
+//	    "amount": "Net amount",
+//	    "currency": "Currency",
+//	    "key": "Reference"
+//	  },
+//	  "commands": {"Achat": "buy", "Vente": "sell", "Virement": "deposit"},
+//	  "securities": {"IE00BK5BQT80": "VWCE"},
+//	  "filters": [{"column": "Status", "equals": "Executed"}]
+//	}
+//
+// The fields are command, date, security, quantity, amount, currency, memo,
+// key and account, with the meaning of the ledger format. The command column
+// is translated by "commands", the security column by "securities" if the
+// value is listed. A field can have a fixed value for all the rows in
+// "values", e.g. {"command": "buy"} or {"currency": "EUR"}.
+//
+// Dates are in the Go layout of "dateFormat", or in any format accepted by
+// portfolio.ParseDate without it. Numbers use the "decimal" separator, "." by
+// default; the other of "." and "," and spaces are thousands separators. The
+// sign of numbers is dropped: the command tells the direction.
+//
+// Rows are kept if they pass all the "filters", each comparing a column with
+// "equals", "notEquals" or "contains". The first "skip" lines, before the
+// header, are ignored.
+package csvmap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/shopspring/decimal"
+)
+
+// Fields lists the transaction fields a mapping can fill.
+var Fields = []string{"command", "date", "security", "quantity", "amount", "currency", "memo", "key", "account"}
+
+// Mapping describes how to convert the rows of a CSV file into transactions.
+type Mapping struct {
+	Comma      string            `json:"comma,omitempty"`      // Comma is the field separator, "," by default.
+	Skip       int               `json:"skip,omitempty"`       // Skip is the number of lines before the header.
+	DateFormat string            `json:"dateFormat,omitempty"` // DateFormat is the Go layout of the dates.
+	Decimal    string            `json:"decimal,omitempty"`    // Decimal is the decimal separator, "." by default.
+	Columns    map[string]string `json:"columns"`              // Columns maps fields to column headers.
+	Values     map[string]string `json:"values,omitempty"`     // Values maps fields to fixed values.
+	Commands   map[string]string `json:"commands,omitempty"`   // Commands maps values of the command column to commands.
+	Securities map[string]string `json:"securities,omitempty"` // Securities maps values of the security column to tickers.
+	Filters    []Filter          `json:"filters,omitempty"`
+}
+
+// Filter keeps the rows whose column matches.
+type Filter struct {
+	Column    string `json:"column"`
+	Equals    string `json:"equals,omitempty"`
+	NotEquals string `json:"notEquals,omitempty"`
+	Contains  string `json:"contains,omitempty"` // Contains is case insensitive.
+}
+
+// match reports whether a value passes the filter.
+func (f Filter) match(value string) bool {
+	switch {
+	case f.Equals != "" && value != f.Equals:
+		return false
+	case f.NotEquals != "" && value == f.NotEquals:
+		return false
+	case f.Contains != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(f.Contains)):
+		return false
+	}
+	return true
+}
+
+// ReadMapping reads and checks a mapping in JSON.
+func ReadMapping(r io.Reader) (*Mapping, error) {
+	var m Mapping
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %w", err)
+	}
+	if err := m.check(); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %w", err)
+	}
+	return &m, nil
+}
+
+// LoadMapping reads a mapping file.
+func LoadMapping(name string) (*Mapping, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMapping(f)
+}
+
+// check checks the consistency of the mapping.
+func (m *Mapping) check() error {
+	for _, fields := range []map[string]string{m.Columns, m.Values} {
+		for _, field := range slices.Sorted(maps.Keys(fields)) {
+			if !slices.Contains(Fields, field) {
+				return fmt.Errorf("unknown field %q, valid fields are: %s", field, strings.Join(Fields, ", "))
+			}
+		}
+	}
+	for _, field := range []string{"command", "date"} {
+		if m.Columns[field] == "" && m.Values[field] == "" {
+			return fmt.Errorf("missing the %s column", field)
+		}
+	}
+	if len([]rune(m.Comma)) > 1 {
+		return fmt.Errorf("comma must be a single character, got %q", m.Comma)
+	}
+	if m.Decimal != "" && m.Decimal != "." && m.Decimal != "," {
+		return fmt.Errorf("decimal must be \".\" or \",\", got %q", m.Decimal)
+	}
+	return nil
+}
+
+// Convert reads a CSV file and converts its rows into transactions, in the
+// order of the file. Errors are reported with their line in the file, as a
+// portfolio.LineError, all at once.
+func (m *Mapping) Convert(r io.Reader) ([]portfolio.Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if m.Comma != "" {
+		reader.Comma = []rune(m.Comma)[0]
+	}
+	for range m.Skip {
+		if _, err := reader.Read(); err != nil {
+			return nil, fmt.Errorf("cannot skip %d lines: %w", m.Skip, err)
+		}
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing csv header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+	for _, name := range m.columnNames() {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q not found in the csv header", name)
+		}
+	}
+
+	var txs []portfolio.Transaction
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, err
+		}
+		cell := func(column string) string {
+			if i := columns[column]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if !slices.ContainsFunc(m.Filters, func(f Filter) bool { return !f.match(cell(f.Column)) }) {
+			tx, err := m.convert(cell)
+			if err != nil {
+				errs = append(errs, &portfolio.LineError{Line: line, Err: err})
+				continue
+			}
+			txs = append(txs, tx)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return txs, nil
+}
+
+// columnNames returns the names of the columns used by the mapping.
+func (m *Mapping) columnNames() []string {
+	var names []string
+	for _, field := range slices.Sorted(maps.Keys(m.Columns)) {
+		names = append(names, m.Columns[field])
+	}
+	for _, f := range m.Filters {
+		names = append(names, f.Column)
+	}
+	return names
+}
+
+// convert converts a row, given by the value of its cells, into a transaction.
+func (m *Mapping) convert(cell func(column string) string) (portfolio.Transaction, error) {
+	value := func(field string) string {
+		if v, ok := m.Values[field]; ok {
+			return v
+		}
+		if column, ok := m.Columns[field]; ok {
+			return cell(column)
+		}
+		return ""
+	}
+
+	obj := make(map[string]any)
+	command := value("command")
+	if _, ok := m.Columns["command"]; ok && m.Commands != nil {
+		c, ok := m.Commands[command]
+		if !ok {
+			return nil, fmt.Errorf("unknown command %q, add it to the commands of the mapping or filter it out", command)
+		}
+		command = c
+	}
+	obj["command"] = command
+
+	date, err := m.parseDate(value("date"))
+	if err != nil {
+		return nil, err
+	}
+	obj["date"] = date.String()
+
+	if security := value("security"); security != "" {
+		if ticker, ok := m.Securities[security]; ok {
+			security = ticker
+		}
+		obj["security"] = security
+	}
+	for _, field := range []string{"quantity", "amount"} {
+		v := value(field)
+		if v == "" {
+			continue
+		}
+		d, err := m.parseNumber(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		obj[field] = json.Number(d.Abs().String())
+	}
+	for _, field := range []string{"currency", "memo", "key", "account"} {
+		if v := value(field); v != "" {
+			obj[field] = v
+		}
+	}
+
+	line, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return portfolio.NewDecoder(bytes.NewReader(line)).Decode()
+}
+
+// parseDate parses a date in the format of the mapping.
+func (m *Mapping) parseDate(s string) (portfolio.Date, error) {
+	if m.DateFormat == "" {
+		return portfolio.ParseDate(s)
+	}
+	t, err := time.Parse(m.DateFormat, s)
+	if err != nil {
+		return portfolio.Date{}, fmt.Errorf("invalid date %q, expected the format %q", s, m.DateFormat)
+	}
+	return portfolio.NewDate(t.Year(), t.Month(), t.Day()), nil
+}
+
+// parseNumber parses a number with the separators of the mapping.
+func (m *Mapping) parseNumber(s string) (decimal.Decimal, error) {
+	thousands := ","
+	if m.Decimal == "," {
+		thousands = "."
+	}
+	s = strings.NewReplacer(thousands, "", " ", "", "\u00a0", "", "\u202f", "").Replace(s)
+	if m.Decimal == "," {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return d, fmt.Errorf("invalid number %q", s)
+	}
+	return d, nil
+}
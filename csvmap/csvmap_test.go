@@ -0,0 +1,90 @@
+package csvmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+const broker = `Export of 2025-03-31
+Date;Operation;ISIN;Quantity;Net amount;Status;Reference
+02/01/2025;Virement;;;1 000,00;Executed;R1
+03/01/2025;Achat;IE00BK5BQT80;10;-1.150,50;Executed;R2
+04/01/2025;Achat;IE00BK5BQT80;5;-575,00;Cancelled;R3
+05/02/2025;Vente;FR0000120271;2;120,4;Executed;R4
+`
+
+const brokerMapping = `{
+  "comma": ";",
+  "skip": 1,
+  "dateFormat": "02/01/2006",
+  "decimal": ",",
+  "columns": {"command": "Operation", "date": "Date", "security": "ISIN", "quantity": "Quantity", "amount": "Net amount", "key": "Reference"},
+  "values": {"currency": "EUR"},
+  "commands": {"Achat": "buy", "Vente": "sell", "Virement": "deposit"},
+  "securities": {"IE00BK5BQT80": "VWCE"},
+  "filters": [{"column": "Status", "equals": "Executed"}]
+}`
+
+func TestConvert(t *testing.T) {
+	m, err := ReadMapping(strings.NewReader(brokerMapping))
+	if err != nil {
+		t.Fatalf("ReadMapping() error = %v", err)
+	}
+	txs, err := m.Convert(strings.NewReader(broker))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("Convert() = %d transactions, want 3: the cancelled row is filtered out", len(txs))
+	}
+
+	deposit, ok := txs[0].(portfolio.Deposit)
+	if !ok || deposit.When() != portfolio.NewDate(2025, 1, 2) || !deposit.Amount.Equal(portfolio.M(1000, "EUR")) || deposit.Key != "R1" {
+		t.Errorf("Convert()[0] = %#v, want a deposit of 1000 EUR on 2025-01-02", txs[0])
+	}
+	buy, ok := txs[1].(portfolio.Buy)
+	if !ok || buy.Security != "VWCE" || !buy.Quantity.Equal(portfolio.Q(10)) || !buy.Amount.Equal(portfolio.M(1150.5, "EUR")) {
+		t.Errorf("Convert()[1] = %#v, want a buy of 10 VWCE for 1150.50 EUR", txs[1])
+	}
+	sell, ok := txs[2].(portfolio.Sell)
+	if !ok || sell.Security != "FR0000120271" || !sell.Amount.Equal(portfolio.M(120.4, "EUR")) {
+		t.Errorf("Convert()[2] = %#v, want a sell of FR0000120271 for 120.40 EUR", txs[2])
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	m, err := ReadMapping(strings.NewReader(brokerMapping))
+	if err != nil {
+		t.Fatalf("ReadMapping() error = %v", err)
+	}
+	_, err = m.Convert(strings.NewReader(broker + "06/02/2025;Coupon;FR0000120271;;3,2;Executed;R5\n31/02/2025;Achat;IE00BK5BQT80;1;-100;Executed;R6\n"))
+	var lineErr *portfolio.LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 7 {
+		t.Fatalf("Convert() error = %v, want an error on line 7", err)
+	}
+	if !strings.Contains(err.Error(), "Coupon") || !strings.Contains(err.Error(), "line 8") {
+		t.Errorf("Convert() error = %v, want the unknown command on line 7 and the invalid date on line 8", err)
+	}
+
+	if _, err := m.Convert(strings.NewReader("Date;Operation\n")); err == nil {
+		t.Error("Convert() expected an error for missing columns")
+	}
+}
+
+func TestReadMapping_Invalid(t *testing.T) {
+	for _, invalid := range []string{
+		``,
+		`{"columns": {"date": "Date"}}`,
+		`{"columns": {"command": "Op", "date": "Date", "price": "Price"}}`,
+		`{"columns": {"command": "Op", "date": "Date"}, "decimal": "_"}`,
+		`{"columns": {"command": "Op", "date": "Date"}, "comma": ";;"}`,
+		`{"columns": {"command": "Op", "date": "Date"}, "unknown": true}`,
+	} {
+		if _, err := ReadMapping(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ReadMapping(%q) expected an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package portfolio
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Rhymond/go-money"
+	"github.com/etnz/portfolio/format"
+)
+
+// Currency is a currency that is not an ISO 4217 currency, like loyalty
+// points or a company scrip, declared in a ledger by a DeclareCurrency.
+type Currency struct {
+	Code     string // Code is the 3 uppercase letters code of the currency.
+	Symbol   string // Symbol is the symbol of the amounts in reports.
+	Decimals int    // Decimals is the number of decimals of the amounts.
+}
+
+// registry holds the currencies declared by the ledgers, by code.
+var registry = struct {
+	sync.Mutex
+	currencies map[string]Currency
+}{currencies: make(map[string]Currency)}
+
+// RegisterCurrency registers a currency, so that its code is a valid currency,
+// and its amounts are rounded to its decimals and formatted with its symbol.
+//
+// Currencies are shared by all the ledgers: registering a currency again is a
+// no-op, but registering another currency with the same code is an error, and
+// so is registering an ISO 4217 currency.
+func RegisterCurrency(c Currency) error {
+	registry.Lock()
+	defer registry.Unlock()
+	if prev, ok := registry.currencies[c.Code]; ok {
+		if prev != c {
+			return fmt.Errorf("currency %q is already declared with symbol %q and %d decimals", c.Code, prev.Symbol, prev.Decimals)
+		}
+		return nil
+	}
+	if money.GetCurrency(c.Code) != nil {
+		return fmt.Errorf("currency %q is an ISO 4217 currency, it cannot be declared", c.Code)
+	}
+	registry.currencies[c.Code] = c
+	format.RegisterCurrency(c.Code, c.Symbol, c.Decimals)
+	return nil
+}
+
+// unregisterCurrency removes a registered currency, for the tests.
+func unregisterCurrency(code string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.currencies, code)
+	format.UnregisterCurrency(code)
+}
+
+// DeclaredCurrency returns the registered currency of a code, if any.
+func DeclaredCurrency(code string) (Currency, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	c, ok := registry.currencies[code]
+	return c, ok
+}
+
+// knownCurrency reports whether code is an ISO 4217 currency or a registered
+// one.
+func knownCurrency(code string) bool {
+	_, ok := format.LookupCurrency(code)
+	return ok
+}
@@ -0,0 +1,94 @@
+package portfolio
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLedger_DeclareCurrency(t *testing.T) {
+	on := NewDate(2025, time.January, 1)
+	ledger := NewLedger()
+	// Declared currencies are shared by the ledgers of the process.
+	t.Cleanup(func() { unregisterCurrency("QQP") })
+
+	if err := ValidateCurrency("CHF"); err != nil {
+		t.Fatalf("ValidateCurrency(CHF) error = %v, want nil for an ISO currency", err)
+	}
+	if err := ValidateCurrency("QQP"); err == nil {
+		t.Fatalf("ValidateCurrency(QQP) = nil, want an error before the declaration")
+	}
+
+	if err := ledger.Append(NewDeclareCurrency(on, "", "QQP", "pts", 0)); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if err := ValidateCurrency("QQP"); err != nil {
+		t.Errorf("ValidateCurrency(QQP) error = %v after the declaration", err)
+	}
+	if err := ledger.Append(NewDeposit(on, "", M(1200.4, "QQP"), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got, want := ledger.NewSnapshot(on).Cash("QQP").String(), "1,200 pts"; got != want {
+		t.Errorf("Cash(QQP) = %q, want %q", got, want)
+	}
+
+	for _, tc := range []struct {
+		name string
+		tx   DeclareCurrency
+	}{
+		{"iso currency", NewDeclareCurrency(on, "", "EUR", "", 2)},
+		{"invalid code", NewDeclareCurrency(on, "", "qqp", "", 0)},
+		{"invalid decimals", NewDeclareCurrency(on, "", "QQR", "", -1)},
+		{"already declared", NewDeclareCurrency(on, "", "QQP", "pts", 0)},
+	} {
+		if _, err := tc.tx.Validate(ledger); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", tc.name)
+		}
+	}
+	// Another ledger may declare the same currency, but not differently.
+	if _, err := NewDeclareCurrency(on, "", "QQP", "pts", 0).Validate(NewLedger()); err != nil {
+		t.Errorf("Validate() of the same currency in another ledger error = %v", err)
+	}
+	if _, err := NewDeclareCurrency(on, "", "QQP", "points", 2).Validate(NewLedger()); err == nil {
+		t.Errorf("Validate() of a conflicting currency in another ledger = nil, want an error")
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeLedger(&buf, ledger); err != nil {
+		t.Fatalf("EncodeLedger() error = %v", err)
+	}
+	decoded, err := DecodeValidateLedger(&buf)
+	if err != nil {
+		t.Fatalf("DecodeValidateLedger() error = %v", err)
+	}
+	var got []Transaction
+	for _, tx := range decoded.Query().Command(CmdDeclareCurrency).All() {
+		got = append(got, tx)
+	}
+	if len(got) != 1 || !got[0].Equal(NewDeclareCurrency(on, "", "QQP", "pts", 0)) {
+		t.Errorf("decoded declarations = %v, want the QQP one", got)
+	}
+}
+
+func TestRegisterCurrency_Concurrent(t *testing.T) {
+	t.Cleanup(func() { unregisterCurrency("QQC") })
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := RegisterCurrency(Currency{Code: "QQC", Symbol: "c", Decimals: 1}); err != nil {
+			t.Errorf("RegisterCurrency() error = %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			_ = M(1.25, "QQC").String()
+		}
+	}()
+	wg.Wait()
+	if got, want := M(1.25, "QQC").String(), "1.3 c"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package portfolio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Diagnosis is the health report of a ledger file: statistics about its
+// transactions and the cost of loading it, and the structural problems found.
+type Diagnosis struct {
+	Name         string              // Name is the name of the ledger, see Ledger.Name.
+	Transactions int                 // Transactions is the number of transactions.
+	ByCommand    map[CommandType]int // ByCommand is the number of transactions of each command.
+	From, To     Date                // From and To are the dates of the oldest and newest transactions.
+	Securities   int                 // Securities is the number of securities declared.
+	Load         time.Duration       // Load is the time to decode the file.
+	Build        time.Duration       // Build is the time to sort the transactions and build the journal.
+	Allocated    uint64              // Allocated is the number of bytes allocated to load the ledger.
+	Problems     []Problem
+}
+
+// Problem is a structural problem of a ledger file, with the way to fix it.
+type Problem struct {
+	Line    int    // Line is the line of the transaction in the file, 0 for the whole file.
+	Message string // Message describes the problem.
+	Fix     string // Fix suggests how to fix the problem.
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// compactionThreshold is the share of the transactions that compaction must
+// remove for it to be suggested.
+const compactionThreshold = 0.25
+
+// Diagnose reads a ledger file and reports its statistics and its structural
+// problems: several init transactions, securities used before their
+// declaration, transactions out of order, invalid transactions, manual prices
+// overriding different prices of a provider, and a price history worth
+// compacting.
+func Diagnose(r io.Reader) (*Diagnosis, error) { return diagnose(r, "") }
+
+// DiagnoseLedgers diagnoses the ledger files of a portfolio path matching the
+// query, like FindLedgers, along with their price files.
+func DiagnoseLedgers(path, query string) ([]*Diagnosis, error) {
+	ledgerPaths, err := findLedgerPaths(path, query)
+	if err != nil {
+		return nil, err
+	}
+	var diags []*Diagnosis
+	for _, fullPath := range ledgerPaths {
+		relPath, err := filepath.Rel(path, fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine relative path for %q: %w", fullPath, err)
+		}
+		f, err := os.Open(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not open ledger file %q: %w", fullPath, err)
+		}
+		diag, err := diagnose(f, pricesDir(fullPath))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode ledger file %q: %w", fullPath, err)
+		}
+		diag.Name = strings.TrimSuffix(relPath, ".jsonl")
+		diags = append(diags, diag)
+	}
+	return diags, nil
+}
+
+// diagnose diagnoses a ledger file, and its price files in the directory dir
+// if it exists.
+func diagnose(r io.Reader, dir string) (*Diagnosis, error) {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	d := NewDecoder(r)
+	ledger := NewLedger()
+	var lines []int
+	for {
+		tx, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ledger.transactions = append(ledger.transactions, tx)
+		lines = append(lines, d.Line())
+	}
+	// The transactions out of order are only looked for in the ledger file.
+	raw := slices.Clone(ledger.transactions)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		prices, err := decodePriceFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		ledger.transactions = append(ledger.transactions, prices...)
+		lines = append(lines, make([]int, len(prices))...) // no line in the ledger file
+	}
+	all := slices.Clone(ledger.transactions)
+	diag := &Diagnosis{
+		Transactions: len(ledger.transactions),
+		ByCommand:    make(map[CommandType]int),
+		Load:         time.Since(start),
+	}
+
+	start = time.Now()
+	if err := ledger.index(); err != nil {
+		return nil, err
+	}
+	diag.Build = time.Since(start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	diag.Allocated = after.TotalAlloc - before.TotalAlloc
+
+	diag.From, diag.To = ledger.OldestTransactionDate(), ledger.NewestTransactionDate()
+	diag.Securities = len(ledger.securities)
+
+	declared := make(map[string]Date)
+	for _, tx := range all {
+		if d, ok := tx.(Declare); ok {
+			if _, exists := declared[d.Ticker]; !exists {
+				declared[d.Ticker] = d.When()
+			}
+		}
+	}
+	inits := 0
+	reported := make(map[string]bool)
+	for i, tx := range all {
+		diag.ByCommand[tx.What()]++
+		if tx.What() == CmdInit {
+			inits++
+			if inits > 1 {
+				diag.Problems = append(diag.Problems, Problem{
+					Line:    lines[i],
+					Message: fmt.Sprintf("init transaction on %s after the first one", tx.When()),
+					Fix:     "keep a single init transaction, the first one of the ledger",
+				})
+			}
+		}
+		if tx.What() == CmdDeclare {
+			continue
+		}
+		for _, ticker := range securitiesOf(tx) {
+			on, ok := declared[ticker]
+			if !ok || !tx.When().Before(on) || reported[ticker] {
+				continue
+			}
+			reported[ticker] = true
+			diag.Problems = append(diag.Problems, Problem{
+				Line:    lines[i],
+				Message: fmt.Sprintf("%s uses %q on %s, before its declaration on %s", tx.What(), ticker, tx.When(), on),
+				Fix:     fmt.Sprintf("declare %q on or before %s, with 'pcs edit -q \"command=declare and security=%s\"'", ticker, tx.When(), ticker),
+			})
+		}
+	}
+	diag.Problems = append(diag.Problems, priceOverrides(all, lines)...)
+	for i := 1; i < len(raw); i++ {
+		if compareTransactions(raw[i-1], raw[i]) > 0 {
+			diag.Problems = append(diag.Problems, Problem{
+				Line:    lines[i],
+				Message: fmt.Sprintf("the %s of %s comes after the %s of %s in the file", raw[i].What(), raw[i].When(), raw[i-1].What(), raw[i-1].When()),
+				Fix:     "sort the ledger with 'pcs fmt'",
+			})
+			break
+		}
+	}
+
+	if _, err := ledger.Fmt(); err != nil {
+		diag.Problems = append(diag.Problems, Problem{
+			Message: err.Error(),
+			Fix:     "fix the transaction with 'pcs edit'",
+		})
+	}
+
+	if compacted, err := ledger.Compact(ledger.Today().Add(-365), Weekly); err == nil {
+		if removed := len(ledger.transactions) - len(compacted.transactions); removed > 0 && float64(removed) >= compactionThreshold*float64(len(ledger.transactions)) {
+			diag.Problems = append(diag.Problems, Problem{
+				Message: fmt.Sprintf("keeping weekly prices older than a year would remove %d of the %d transactions", removed, len(ledger.transactions)),
+				Fix:     "thin out the price history with 'pcs compact'",
+			})
+		}
+	}
+	return diag, nil
+}
+
+// priceOverrides returns the problems of the manual prices that override a
+// different price of a market data provider on the same day.
+func priceOverrides(txs []Transaction, lines []int) []Problem {
+	type key struct {
+		day    Date
+		ticker string
+	}
+	provided := make(map[key]UpdatePrice)
+	for _, tx := range txs {
+		if u, ok := tx.(UpdatePrice); ok && u.Source != "" {
+			for ticker := range u.Prices {
+				provided[key{u.Date, ticker}] = u
+			}
+		}
+	}
+	var problems []Problem
+	for i, tx := range txs {
+		u, ok := tx.(UpdatePrice)
+		if !ok || u.Source != "" {
+			continue
+		}
+		for ticker, price := range u.PricesIter() {
+			p, ok := provided[key{u.Date, ticker}]
+			if !ok || p.Prices[ticker].Equal(price) {
+				continue
+			}
+			problems = append(problems, Problem{
+				Line:    lines[i],
+				Message: fmt.Sprintf("the manual price %s of %q on %s overrides the %s price %s", price, ticker, u.Date, p.Source, p.Prices[ticker]),
+				Fix:     fmt.Sprintf("remove the manual price with 'pcs edit -d %s -q \"command=update-price\"' if the %s price is right", u.Date, p.Source),
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,70 @@
+package portfolio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	file := `{"command":"init","date":"2025-01-01","currency":"EUR"}
+{"command":"declare","date":"2025-01-01","ticker":"AAPL","id":"US0378331005.XNAS","currency":"USD"}
+{"command":"deposit","date":"2025-01-05","currency":"USD","amount":1000}
+{"command":"deposit","date":"2025-01-02","currency":"USD","amount":1000}
+{"command":"update-price","date":"2025-01-03","prices":{"MSFT":100}}
+{"command":"declare","date":"2025-01-04","ticker":"MSFT","id":"US5949181045.XNAS","currency":"USD"}
+{"command":"init","date":"2025-01-06","currency":"EUR"}
+`
+	d, err := Diagnose(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	if d.Transactions != 7 || d.ByCommand[CmdDeposit] != 2 || d.ByCommand[CmdInit] != 2 {
+		t.Errorf("Diagnose() = %d transactions, %v, want 7 with 2 deposits and 2 inits", d.Transactions, d.ByCommand)
+	}
+	if d.From != NewDate(2025, 1, 1) || d.To != NewDate(2025, 1, 6) || d.Securities != 2 {
+		t.Errorf("Diagnose() = from %s to %s with %d securities, want from 2025-01-01 to 2025-01-06 with 2", d.From, d.To, d.Securities)
+	}
+
+	// The problems found, by line, 0 for the invalid ledger.
+	want := map[int]string{
+		4: "comes after",
+		5: `uses "MSFT"`,
+		7: "init transaction",
+		0: "not declared",
+	}
+	if len(d.Problems) != len(want) {
+		t.Errorf("Diagnose() problems = %v, want %d", d.Problems, len(want))
+	}
+	for _, p := range d.Problems {
+		if !strings.Contains(p.Message, want[p.Line]) || p.Fix == "" {
+			t.Errorf("Diagnose() problem %q, want a message containing %q and a fix", p, want[p.Line])
+		}
+	}
+
+	healthy := `{"command":"init","date":"2025-01-01","currency":"EUR"}
+{"command":"deposit","date":"2025-01-02","currency":"EUR","amount":1000}
+`
+	if d, err := Diagnose(strings.NewReader(healthy)); err != nil || len(d.Problems) != 0 {
+		t.Errorf("Diagnose(healthy) = %v, %v, want no problems", d.Problems, err)
+	}
+
+	overridden := `{"command":"declare","date":"2025-01-01","ticker":"AAPL","id":"US0378331005.XNAS","currency":"USD"}
+{"command":"update-price","date":"2025-01-02","source":"eodhd","prices":{"AAPL":101}}
+{"command":"update-price","date":"2025-01-02","prices":{"AAPL":100}}
+{"command":"update-price","date":"2025-01-03","source":"eodhd","prices":{"AAPL":102}}
+{"command":"update-price","date":"2025-01-03","prices":{"AAPL":102}}
+`
+	d, err = Diagnose(strings.NewReader(overridden))
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	var overrides []Problem
+	for _, p := range d.Problems {
+		if strings.Contains(p.Message, "overrides the eodhd price") {
+			overrides = append(overrides, p)
+		}
+	}
+	if len(overrides) != 1 || overrides[0].Line != 3 {
+		t.Errorf("Diagnose(overridden) = %v, want the manual price of line 3", d.Problems)
+	}
+}
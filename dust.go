@@ -0,0 +1,36 @@
+package portfolio
+
+import "fmt"
+
+// IgnoreDust makes reports based on the snapshot skip dust positions, that is
+// positions whose absolute quantity is not greater than threshold.
+func (s *Snapshot) IgnoreDust(threshold Quantity) *Snapshot {
+	s.dust = threshold.Abs()
+	return s
+}
+
+// IsDust reports whether the position in a security is not zero but negligible,
+// according to the threshold set with IgnoreDust.
+func (s *Snapshot) IsDust(ticker string) bool {
+	pos := s.Position(ticker)
+	return !pos.IsZero() && !pos.Abs().GreaterThan(s.dust)
+}
+
+// CleanupDust returns the transactions that dispose of dust positions on a
+// date: the residual quantities, not greater than threshold, that linger after
+// many sells. Each long position is sold for no proceeds, with a memo to keep an
+// audit trail of the cleanup.
+func (l *Ledger) CleanupDust(on Date, threshold Quantity) []Transaction {
+	s := l.NewSnapshot(on).IgnoreDust(threshold)
+	var txs []Transaction
+	for ticker := range s.Securities() {
+		pos := s.Position(ticker)
+		if !s.IsDust(ticker) || !pos.IsPositive() {
+			continue
+		}
+		sec := l.Security(ticker)
+		memo := fmt.Sprintf("cleanup-dust: dispose of %s %s, threshold %s", pos, ticker, threshold)
+		txs = append(txs, NewSell(on, memo, ticker, pos, M(0, sec.Currency())))
+	}
+	return txs
+}
@@ -0,0 +1,52 @@
+package portfolio
+
+import "testing"
+
+func TestLedger_CleanupDust(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "USD"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "GOOG", GOOG, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(1000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(1.000000001), USD(100)),
+		NewBuy(NewDate(2025, 1, 3), "", "GOOG", Q(2), USD(200)),
+		NewSell(NewDate(2025, 1, 4), "", "AAPL", Q(1), USD(110)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	on := NewDate(2025, 1, 5)
+	s := ledger.NewSnapshot(on)
+	if s.IsDust("AAPL") {
+		t.Errorf("IsDust(AAPL) without threshold = true, want false")
+	}
+	s.IgnoreDust(Q(0.0001))
+	if !s.IsDust("AAPL") || s.IsDust("GOOG") {
+		t.Errorf("IsDust() = %v, %v, want true for AAPL only", s.IsDust("AAPL"), s.IsDust("GOOG"))
+	}
+
+	cleanup := ledger.CleanupDust(on, Q(0.0001))
+	if len(cleanup) != 1 {
+		t.Fatalf("CleanupDust() returned %d transactions, want 1", len(cleanup))
+	}
+	valid, err := ledger.Validate(cleanup[0])
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := ledger.Append(valid); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got := ledger.NewSnapshot(on).Position("AAPL"); !got.IsZero() {
+		t.Errorf("Position(AAPL) after cleanup = %v, want 0", got)
+	}
+	if got := ledger.CleanupDust(on, Q(0.0001)); len(got) != 0 {
+		t.Errorf("CleanupDust() after cleanup = %v, want none", got)
+	}
+
+	// A sale for no proceeds is only allowed to close a position.
+	if _, err := ledger.Validate(NewSell(on, "", "GOOG", Q(1), USD(0))); err == nil {
+		t.Errorf("Validate() of a partial sale for nothing: expected an error")
+	}
+}
@@ -3,9 +3,11 @@ package portfolio
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
+	"github.com/etnz/portfolio/migrations"
 	"github.com/shopspring/decimal"
 )
 
@@ -40,26 +42,171 @@ func (a convertCmd) ToMoney() Money {
 	return M(a.ToAmount, a.ToCurrency)
 }
 
-// DecodeLedger decodes transactions from a stream of JSONL data from an io.Reader,
-// decodes each line into the appropriate transaction struct, and returns a sorted Ledger.
-func DecodeLedger(r io.Reader) (*Ledger, error) {
-	ledger, err := decodeLedger(r)
+// DefaultMaxLineSize is the default maximum length of a ledger line, in bytes.
+// It is large enough for update-price transactions with thousands of tickers.
+const DefaultMaxLineSize = 16 << 20
+
+// ErrLineTooLong is returned for a ledger line longer than the maximum line size.
+var ErrLineTooLong = errors.New("line too long")
+
+// LineError is an error decoding a line of a ledger.
+type LineError struct {
+	Line int // Line is the line number, starting at 1.
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+func (e *LineError) Unwrap() error { return e.Err }
+
+// Decoder reads transactions from a stream of JSONL data, one line at a time,
+// without holding more than the current line in memory.
+//
+// Lines written with an older schema version, the version of the init
+// transaction of the stream, are upgraded to the current version, see package
+// migrations.
+type Decoder struct {
+	r       *bufio.Reader
+	line    int
+	buf     []byte
+	version int // the schema version of the stream.
+
+	// MaxLineSize is the maximum length of a line, in bytes. Zero means
+	// DefaultMaxLineSize.
+	MaxLineSize int
+	// SkipInvalid makes the decoder skip the lines that cannot be decoded,
+	// instead of failing. The skipped lines are recorded in Errors.
+	SkipInvalid bool
+	// Errors are the errors of the skipped lines.
+	Errors []*LineError
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Line returns the line number of the last decoded transaction.
+func (d *Decoder) Line() int { return d.line }
+
+// Decode returns the next transaction of the stream, or io.EOF at the end of
+// the stream. Errors about a line are *LineError.
+func (d *Decoder) Decode() (Transaction, error) {
+	for {
+		lineBytes, err := d.readLine()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		var lineErr *LineError
+		if err != nil && !errors.As(err, &lineErr) {
+			return nil, err // The stream cannot be read anymore.
+		}
+		if err == nil {
+			if len(lineBytes) == 0 {
+				continue // Skip empty lines
+			}
+			tx, err := d.decodeLine(lineBytes)
+			if err == nil {
+				return tx, nil
+			}
+			lineErr = &LineError{Line: d.line, Err: err}
+		}
+		if !d.SkipInvalid {
+			return nil, lineErr
+		}
+		d.Errors = append(d.Errors, lineErr)
+	}
+}
+
+// readLine returns the next line, without its end of line. The returned slice
+// is only valid until the next call.
+func (d *Decoder) readLine() ([]byte, error) {
+	maxSize := d.MaxLineSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxLineSize
+	}
+	d.buf = d.buf[:0]
+	tooLong := false
+	for {
+		chunk, isPrefix, err := d.r.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("error reading from input: %w", err)
+		}
+		if !tooLong {
+			d.buf = append(d.buf, chunk...)
+			tooLong = len(d.buf) > maxSize
+		}
+		if isPrefix {
+			continue
+		}
+		d.line++
+		if tooLong {
+			// The rest of the line has been consumed, the next line can be decoded.
+			return nil, &LineError{Line: d.line, Err: fmt.Errorf("%w, the maximum is %d bytes", ErrLineTooLong, maxSize)}
+		}
+		return d.buf, nil
+	}
+}
+
+// decodeLine decodes a single line of a ledger, upgraded from the schema
+// version of the stream.
+func (d *Decoder) decodeLine(lineBytes []byte) (Transaction, error) {
+	var identifier struct {
+		Command CommandType `json:"command"`
+		Version int         `json:"version"`
+	}
+	if err := json.Unmarshal(lineBytes, &identifier); err != nil {
+		return nil, fmt.Errorf("could not identify command in line %q: %w", string(lineBytes), err)
+	}
+	if identifier.Command == CmdInit {
+		d.version = identifier.Version
+	}
+	lineBytes, err := migrations.Upgrade(d.version, string(identifier.Command), lineBytes)
 	if err != nil {
 		return nil, err
 	}
+
+	tx, err := decodeTransaction(identifier.Command, lineBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// DecodeLedger decodes all the remaining transactions of the stream, and
+// returns a sorted Ledger.
+func (d *Decoder) DecodeLedger() (*Ledger, error) {
+	ledger, err := d.decodeLedger()
+	if err != nil {
+		return nil, err
+	}
+	return ledger, ledger.index()
+}
+
+// index sorts the raw transactions of a ledger, indexes securities and accounts
+// and builds the journal.
+func (ledger *Ledger) index() error {
 	// Perform a stable sort and index securities and accounts.
 	ledger.stableSort()
 	// fill up the maps
-	ledger.processTx(ledger.transactions...)
-	err = ledger.newJournal()
+	if err := ledger.processTx(ledger.transactions...); err != nil {
+		return err
+	}
+	return ledger.newJournal()
+}
 
-	return ledger, err
+// DecodeLedger decodes transactions from a stream of JSONL data from an io.Reader,
+// decodes each line into the appropriate transaction struct, and returns a sorted Ledger.
+func DecodeLedger(r io.Reader) (*Ledger, error) {
+	return NewDecoder(r).DecodeLedger()
 }
 
 // DecodeValidateLedger decode the ledger and validate every transactions.
 // if market is nil, skip all validations.
 func DecodeValidateLedger(r io.Reader) (*Ledger, error) {
-	ledger, err := decodeLedger(r)
+	ledger, err := NewDecoder(r).decodeLedger()
 	if err != nil {
 		return nil, err
 	}
@@ -71,41 +218,23 @@ func DecodeValidateLedger(r io.Reader) (*Ledger, error) {
 	return newLedger, nil
 }
 
-// decodeLedger read transactions from the reader, and simply append them to the ledger.
+// decodeLedger read transactions from the stream, and simply append them to the ledger.
 // this method is private, since the ledger could be unsorted, and with invalid indexes
 // of securities etc. It is meant to be called to either simply sort transactions and compute indexes
 // or perform a strict validation.
-func decodeLedger(r io.Reader) (*Ledger, error) {
+func (d *Decoder) decodeLedger() (*Ledger, error) {
 	ledger := NewLedger()
-
-	scanner := bufio.NewScanner(r)
-
-	for scanner.Scan() {
-		lineBytes := scanner.Bytes()
-		if len(lineBytes) == 0 {
-			continue // Skip empty lines
-		}
-
-		var identifier struct {
-			Command CommandType `json:"command"`
-		}
-		if err := json.Unmarshal(lineBytes, &identifier); err != nil {
-			return nil, fmt.Errorf("could not identify command in line %q: %w", string(lineBytes), err)
+	for {
+		tx, err := d.Decode()
+		if err == io.EOF {
+			return ledger, nil
 		}
-
-		decodedTx, err := decodeTransaction(identifier.Command, lineBytes)
 		if err != nil {
-			return nil, fmt.Errorf("error decoding transaction: %w", err)
+			return nil, err
 		}
-
 		// Raw append in this function.
-		ledger.transactions = append(ledger.transactions, decodedTx)
+		ledger.transactions = append(ledger.transactions, tx)
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading from input: %w", err)
-	}
-	return ledger, nil
 }
 
 // decodeTx is a generic function to decode a transaction of type T from JSON bytes.
@@ -134,13 +263,38 @@ func decodeTransaction(command CommandType, lineBytes []byte) (Transaction, erro
 		return decodeTx(lineBytes, &Convert{})
 	case CmdDeclare:
 		return decodeTx(lineBytes, &Declare{})
+	case CmdDeclareCurrency:
+		return decodeTx(lineBytes, &DeclareCurrency{})
 	case CmdAccrue:
 		return decodeTx(lineBytes, &Accrue{})
 	case CmdUpdatePrice:
 		return decodeTx(lineBytes, &UpdatePrice{})
 	case CmdSplit:
 		return decodeTx(lineBytes, &Split{})
+	case CmdExpire:
+		return decodeTx(lineBytes, &Expire{})
+	case CmdAssign:
+		return decodeTx(lineBytes, &Assign{})
+	case CmdLend:
+		return decodeTx(lineBytes, &Lend{})
+	case CmdRecall:
+		return decodeTx(lineBytes, &Recall{})
+	case CmdLendingFee:
+		return decodeTx(lineBytes, &LendingFee{})
+	case CmdGrant:
+		return decodeTx(lineBytes, &Grant{})
+	case CmdVest:
+		return decodeTx(lineBytes, &Vest{})
+	case CmdNote:
+		return decodeTx(lineBytes, &Note{})
+	case CmdSetPriceAlert:
+		return decodeTx(lineBytes, &SetPriceAlert{})
+	case CmdClearPriceAlert:
+		return decodeTx(lineBytes, &ClearPriceAlert{})
 	default:
+		if command.IsCustom() {
+			return decodeTx(lineBytes, &Custom{})
+		}
 		return nil, fmt.Errorf("unknown transaction command: %q", command)
 	}
 }
@@ -171,6 +325,7 @@ func EncodeLedger(w io.Writer, ledger *Ledger) error {
 	decimal.MarshalJSONWithoutQuotes = true
 
 	// Perform a stable sort on the ledger based on the transaction date to ensure order.
+	ledger = ledger.clone()
 	ledger.stableSort()
 
 	// 2. Iterate through the sorted transactions and write each one as a JSON line.
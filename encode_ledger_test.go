@@ -2,6 +2,9 @@ package portfolio
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -62,13 +65,13 @@ func TestEncodeLedger(t *testing.T) {
 	tx2 := NewDeposit(NewDate(2025, time.August, 1), "", USD(1000), "")
 	tx3 := NewSell(NewDate(2025, time.August, 1), "", "GOOG", Q(50), USD(14000.0)) // Same date as tx2
 
-	ledger := &Ledger{
+	ledger := &Ledger{ledgerState: ledgerState{
 		transactions: []Transaction{
 			tx1, // Should be last
 			tx2, // Should be first
 			tx3, // Should be second (stable sort)
 		},
-	}
+	}}
 
 	// Manually sort the transactions to build the expected output string.
 	expectedOrder := []Transaction{tx2, tx3, tx1}
@@ -94,3 +97,147 @@ func TestEncodeLedger(t *testing.T) {
 		t.Errorf("EncodeLedger() produced incorrect output.\nGot:\n%s\nWant:\n%s", got, expectedOutputBuffer.String())
 	}
 }
+
+func TestCustomTransaction(t *testing.T) {
+	jsonlStream := `{"command":"init","date":"2025-08-01","currency":"USD"}
+{"command":"declare","date":"2025-08-01","ticker":"AAPL","id":"US0378331005.XNAS","currency":"USD"}
+{"command":"deposit","date":"2025-08-01","currency":"USD","amount":5000}
+{"command":"x-option-exercise","date":"2025-08-02","memo":"put assigned","cash":[{"currency":"USD","amount":-1900},{"currency":"USD","amount":50}],"positions":[{"security":"AAPL","quantity":10,"currency":"USD","amount":1900}],"payload":{"contract":"AAPL250801P00190000","strike":190}}
+`
+	ledger, err := DecodeValidateLedger(strings.NewReader(jsonlStream))
+	if err != nil {
+		t.Fatalf("DecodeValidateLedger() returned an unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeLedger(&buf, ledger); err != nil {
+		t.Fatalf("EncodeLedger() returned an unexpected error: %v", err)
+	}
+	if got := buf.String(); got != jsonlStream {
+		t.Errorf("EncodeLedger() round trip mismatch:\ngot:\n%s\nwant:\n%s", got, jsonlStream)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2025, 8, 2))
+	if got, want := s.Cash("USD"), USD(3150); !got.Equal(want) {
+		t.Errorf("Cash(USD) = %v, want %v", got, want)
+	}
+	if got, want := s.Position("AAPL"), Q(10); !got.Equal(want) {
+		t.Errorf("Position(AAPL) = %v, want %v", got, want)
+	}
+	if got, want := s.CostBasis("AAPL", FIFO), USD(1900); !got.Equal(want) {
+		t.Errorf("CostBasis(AAPL) = %v, want %v", got, want)
+	}
+
+	// Disposing more than the position is rejected.
+	tx := NewCustom(NewDate(2025, 8, 3), "", "call-assigned", nil, []PositionEffect{{Security: "AAPL", Quantity: Q(-20), Amount: USD(4000)}}, nil)
+	if _, err := ledger.Validate(tx); err == nil {
+		t.Errorf("Validate() of an oversized disposal: expected an error")
+	}
+}
+
+func TestDecoder_LongLines(t *testing.T) {
+	// An update-price with many tickers is longer than bufio.Scanner's 64KiB limit.
+	var b strings.Builder
+	b.WriteString(`{"command":"update-price","date":"2025-01-02","prices":{`)
+	for i := range 10000 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"TICKER%05d":%d.25`, i, i)
+	}
+	b.WriteString("}}\n")
+	long := b.String()
+	stream := `{"command":"deposit","date":"2025-01-01","amount":100,"currency":"EUR"}` + "\n" + long
+
+	d := NewDecoder(strings.NewReader(stream))
+	var txs []Transaction
+	for {
+		tx, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("Decode() returned %d transactions, want 2", len(txs))
+	}
+	if got := len(txs[1].(UpdatePrice).Prices); got != 10000 {
+		t.Errorf("decoded %d prices, want 10000", got)
+	}
+
+	// With a smaller maximum, the long line is an error.
+	d = NewDecoder(strings.NewReader(stream))
+	d.MaxLineSize = 1024
+	if _, err := d.DecodeLedger(); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("DecodeLedger() error = %v, want %v", err, ErrLineTooLong)
+	}
+}
+
+func TestDecoder_SkipInvalid(t *testing.T) {
+	stream := `{"command":"deposit","date":"2025-01-01","amount":100,"currency":"EUR"}
+not json
+
+{"command":"unknown","date":"2025-01-02"}
+{"command":"withdraw","date":"2025-01-03","amount":50,"currency":"EUR"}
+`
+	if _, err := DecodeLedger(strings.NewReader(stream)); err == nil {
+		t.Fatal("DecodeLedger() succeeded on invalid lines, want an error")
+	}
+
+	d := NewDecoder(strings.NewReader(stream))
+	d.SkipInvalid = true
+	ledger, err := d.DecodeLedger()
+	if err != nil {
+		t.Fatalf("DecodeLedger() with SkipInvalid error = %v", err)
+	}
+	if got := len(ledger.transactions); got != 2 {
+		t.Errorf("decoded %d transactions, want 2", got)
+	}
+	var lines []int
+	for _, e := range d.Errors {
+		lines = append(lines, e.Line)
+	}
+	if want := []int{2, 4}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("skipped lines = %v, want %v", lines, want)
+	}
+}
+
+func TestDecoder_Migrations(t *testing.T) {
+	// A ledger of schema version 0, the files written before it was recorded.
+	stream := `{"command":"init","date":"2025-01-01","currency":"EUR"}
+{"command":"declare","date":"2025-01-01","ticker":"AI","id":"FR0000120073.XPAR","currency":"EUR"}
+{"command":"update-price","date":"2025-01-03","prices":{"AI":185}}
+`
+	ledger, err := DecodeLedger(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("DecodeLedger() error = %v", err)
+	}
+	if got := ledger.NewSnapshot(NewDate(2025, 1, 3)).Price("AI"); !got.Equal(EUR(185)) {
+		t.Errorf("Price() = %v, want %v", got, EUR(185))
+	}
+	if got := ledger.SchemaVersion(); got != 0 {
+		t.Errorf("SchemaVersion() = %d, want 0", got)
+	}
+	if from, err := ledger.Migrate(); err != nil || from != 0 || ledger.SchemaVersion() != SchemaVersion {
+		t.Errorf("Migrate() = %d, %v, then SchemaVersion() = %d, want 0, nil, then %d", from, err, ledger.SchemaVersion(), SchemaVersion)
+	}
+
+	var b strings.Builder
+	if err := EncodeLedger(&b, ledger); err != nil {
+		t.Fatalf("EncodeLedger() error = %v", err)
+	}
+	if b.String() != stream {
+		t.Errorf("EncodeLedger() of the migrated ledger =\n%s\nwant\n%s", b.String(), stream)
+	}
+
+	newer := `{"command":"init","date":"2025-01-01","currency":"EUR","version":99}`
+	if _, err := DecodeLedger(strings.NewReader(newer)); err == nil {
+		t.Error("DecodeLedger() of a newer schema version succeeded, want an error")
+	}
+	if _, err := NewLedger().Migrate(); err == nil {
+		t.Error("Migrate() of an empty ledger succeeded, want an error")
+	}
+}
@@ -0,0 +1,228 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Split price storage.
+//
+// Price updates dominate the size of long-lived ledgers. In the split layout,
+// they are stored apart from the ledger file "<name>.jsonl", in a directory
+// "<name>.prices" with one CSV file per year, e.g. "2024.csv". Each file has
+// one column per security and one row per day:
+//
+//	date,AAPL,MSFT
+//	2024-01-02,185.64,370.87
+//	2024-01-03,184.25,
+//
+// If some prices were supplied by a market data provider, a "source" column
+// follows the date, with one row per day and source.
+//
+// Price updates with a memo or traded volumes stay in the ledger file. When a ledger is loaded,
+// prices are merged back transparently, at the end of their day.
+
+// pricesDir returns the directory of the split price files of a ledger file.
+func pricesDir(ledgerFile string) string {
+	return strings.TrimSuffix(ledgerFile, ".jsonl") + ".prices"
+}
+
+// isSplitPrice reports whether a transaction is stored in the price files in
+// the split layout.
+func isSplitPrice(tx Transaction) bool {
+	u, ok := tx.(UpdatePrice)
+	return ok && u.Memo == "" && len(u.Volumes) == 0
+}
+
+// priceRow is a row of a price file: the prices of a day from a source.
+type priceRow struct {
+	day    Date
+	source string
+}
+
+// encodePriceFiles writes the price updates in per-year CSV files in dir, and
+// removes the files of the years without prices.
+func encodePriceFiles(dir string, txs []Transaction) error {
+	// prices by year, by row, by ticker.
+	years := make(map[int]map[priceRow]map[string]decimal.Decimal)
+	for _, tx := range txs {
+		u := tx.(UpdatePrice)
+		days, ok := years[u.Date.Year()]
+		if !ok {
+			days = make(map[priceRow]map[string]decimal.Decimal)
+			years[u.Date.Year()] = days
+		}
+		row := priceRow{u.Date, u.Source}
+		prices, ok := days[row]
+		if !ok {
+			prices = make(map[string]decimal.Decimal)
+			days[row] = prices
+		}
+		for ticker, price := range u.Prices {
+			prices[ticker] = price
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create price directory %q: %w", dir, err)
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return err
+	}
+	for _, file := range existing {
+		year, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".csv"))
+		if _, ok := years[year]; err == nil && !ok {
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("could not remove price file %q: %w", file, err)
+			}
+		}
+	}
+	for year, days := range years {
+		if err := encodePriceFile(filepath.Join(dir, fmt.Sprintf("%d.csv", year)), days); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodePriceFile writes the prices of a year in a CSV file.
+func encodePriceFile(file string, days map[priceRow]map[string]decimal.Decimal) error {
+	var tickers []string
+	sourced := false
+	for row, prices := range days {
+		sourced = sourced || row.source != ""
+		for ticker := range prices {
+			if !slices.Contains(tickers, ticker) {
+				tickers = append(tickers, ticker)
+			}
+		}
+	}
+	slices.Sort(tickers)
+	rows := slices.SortedFunc(maps.Keys(days), func(a, b priceRow) int {
+		if c := a.day.Compare(b.day); c != 0 {
+			return c
+		}
+		// Manual prices come last, as in the ledger, see compareTransactions.
+		if (a.source == "") != (b.source == "") {
+			if a.source == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.source, b.source)
+	})
+
+	err := writeFile(file, func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		header := []string{"date"}
+		if sourced {
+			header = append(header, "source")
+		}
+		w.Write(append(header, tickers...))
+		for _, row := range rows {
+			record := []string{row.day.String()}
+			if sourced {
+				record = append(record, row.source)
+			}
+			for _, ticker := range tickers {
+				cell := ""
+				if price, ok := days[row][ticker]; ok {
+					cell = price.String()
+				}
+				record = append(record, cell)
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		return fmt.Errorf("could not write price file %q: %w", file, err)
+	}
+	return nil
+}
+
+// decodePriceFiles reads the price updates of all the CSV files in dir. It
+// returns no transactions if dir does not exist.
+func decodePriceFiles(dir string) ([]Transaction, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	var txs []Transaction
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not open price file %q: %w", file, err)
+		}
+		fileTxs, err := decodePriceFile(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode price file %q: %w", file, err)
+		}
+		txs = append(txs, fileTxs...)
+	}
+	return txs, nil
+}
+
+// decodePriceFile reads the price updates of a CSV file, one per row.
+func decodePriceFile(r io.Reader) ([]Transaction, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) == 0 || header[0] != "date" {
+		return nil, errors.New(`the first column must be "date"`)
+	}
+	first := 1 // first ticker column
+	if len(header) > 1 && header[1] == "source" {
+		first = 2
+	}
+	var txs []Transaction
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return txs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		day, err := ParseDate(record[0])
+		if err != nil {
+			return nil, err
+		}
+		prices := make(map[string]decimal.Decimal)
+		for i, cell := range record[first:] {
+			if cell == "" {
+				continue
+			}
+			price, err := decimal.NewFromString(cell)
+			if err != nil {
+				return nil, fmt.Errorf("invalid price of %s on %s: %w", header[i+first], day, err)
+			}
+			prices[header[i+first]] = price
+		}
+		if len(prices) > 0 {
+			u := NewUpdatePrices(day, prices)
+			if first == 2 {
+				u.Source = record[1]
+			}
+			txs = append(txs, u)
+		}
+	}
+}
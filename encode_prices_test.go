@@ -0,0 +1,118 @@
+package portfolio
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLedger_SplitPrices(t *testing.T) {
+	jsonl := `{"command":"declare","date":"2024-12-30","ticker":"AAPL","id":"US0378331005.XNAS","currency":"USD"}
+{"command":"declare","date":"2024-12-30","ticker":"MSFT","id":"US5949181045.XNAS","currency":"USD"}
+{"command":"update-price","date":"2024-12-31","prices":{"AAPL":250.42,"MSFT":421.5}}
+{"command":"deposit","date":"2025-01-02","amount":1000,"currency":"USD"}
+{"command":"update-price","date":"2025-01-02","prices":{"AAPL":243.85}}
+{"command":"update-price","date":"2025-01-03","memo":"manual quote","prices":{"MSFT":423.35}}
+`
+	ledger, err := DecodeLedger(strings.NewReader(jsonl))
+	if err != nil {
+		t.Fatalf("DecodeLedger() error = %v", err)
+	}
+	ledger.name = "ledger"
+	ledger.SetSplitPrices(true)
+
+	dir := t.TempDir()
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+
+	main, err := os.ReadFile(filepath.Join(dir, "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(main), "update-price") != 1 {
+		t.Errorf("ledger file must only keep the price update with a memo, got:\n%s", main)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "ledger.prices", "2024.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "date,AAPL,MSFT\n2024-12-31,250.42,421.5\n"; string(got) != want {
+		t.Errorf("2024.csv = %q, want %q", got, want)
+	}
+
+	// Loading merges the prices back.
+	loaded, err := FindLedger(context.Background(), dir, "ledger")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	if !loaded.SplitPrices() {
+		t.Error("loaded ledger should split its prices")
+	}
+	var want, gotJSONL bytes.Buffer
+	EncodeLedger(&want, ledger)
+	EncodeLedger(&gotJSONL, loaded)
+	if want.String() != gotJSONL.String() {
+		t.Errorf("loaded ledger:\n%s\nwant:\n%s", gotJSONL.String(), want.String())
+	}
+
+	// Going back to the inline layout removes the price files.
+	loaded.SetSplitPrices(false)
+	if err := SaveLedger(dir, loaded); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ledger.prices")); !os.IsNotExist(err) {
+		t.Errorf("price directory should be removed, got %v", err)
+	}
+	inline, err := os.ReadFile(filepath.Join(dir, "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inline := string(inline); inline != want.String() {
+		t.Errorf("inline ledger file:\n%s\nwant:\n%s", inline, want.String())
+	}
+}
+
+func TestSaveLedger_SplitPricesVolumes(t *testing.T) {
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	ledger.SetSplitPrices(true)
+	traded := NewUpdatePrice(NewDate(2025, 1, 2), "AAPL", USD(243.85))
+	traded.Source = "eodhd"
+	traded.Volumes = map[string]int64{"AAPL": 55000000}
+	quoted := NewUpdatePrice(NewDate(2025, 1, 3), "AAPL", USD(243.36))
+	quoted.Source = "eodhd"
+	if err := ledger.Append(
+		NewDeclare(NewDate(2024, 12, 30), "", "AAPL", AAPL, "USD"),
+		traded,
+		quoted,
+	); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	main, err := os.ReadFile(filepath.Join(dir, "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(main), "update-price") != 1 || !strings.Contains(string(main), `"volumes":{"AAPL":55000000}`) {
+		t.Errorf("ledger file must keep the price update with volumes, got:\n%s", main)
+	}
+
+	loaded, err := FindLedger(context.Background(), dir, "ledger")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	var want, got bytes.Buffer
+	EncodeLedger(&want, ledger)
+	EncodeLedger(&got, loaded)
+	if want.String() != got.String() {
+		t.Errorf("loaded ledger:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+}
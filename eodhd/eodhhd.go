@@ -1,6 +1,7 @@
 package eodhd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/etnz/portfolio"
@@ -8,10 +9,10 @@ import (
 
 // nice to redirect to https://eodhd.com/financial-summary/00XN.XETRA
 
-func findPrices(apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, prices map[point]PriceChange) (err error) {
+func findPrices(ctx context.Context, apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, prices map[point]PriceChange) (err error) {
 	if id.IsCurrencyPair() {
 		open := make(map[point]PriceChange)
-		err = fetchPrices(apiKey, id, ticker, from.Add(1), to.Add(1), open, nil)
+		err = fetchPrices(ctx, apiKey, id, ticker, from.Add(1), to.Add(1), open, nil)
 		if err != nil {
 			return err
 		}
@@ -28,7 +29,7 @@ func findPrices(apiKey string, id portfolio.ID, ticker string, from, to portfoli
 		return nil
 	}
 
-	err = fetchPrices(apiKey, id, ticker, from, to, nil, prices)
+	err = fetchPrices(ctx, apiKey, id, ticker, from, to, nil, prices)
 	if err != nil {
 		return err
 	}
@@ -36,7 +37,7 @@ func findPrices(apiKey string, id portfolio.ID, ticker string, from, to portfoli
 	return nil
 }
 
-func findTicker(apiKey string, sec portfolio.Security) (ticker string, err error) {
+func findTicker(ctx context.Context, apiKey string, sec portfolio.Security) (ticker string, err error) {
 	id := sec.ID()
 
 	// Check all public types of securities.
@@ -53,7 +54,7 @@ func findTicker(apiKey string, sec portfolio.Security) (ticker string, err error
 	var isin string
 
 	if i, mic, err := id.MSSI(); err == nil {
-		mic2exchange, err := fetchMicToExchangeCode(apiKey)
+		mic2exchange, err := fetchMicToExchangeCode(ctx, apiKey)
 		if err != nil {
 			return "", err
 		}
@@ -68,10 +69,10 @@ func findTicker(apiKey string, sec portfolio.Security) (ticker string, err error
 	}
 
 	// now fetches all tickers from that exchange
-	tickers, err := fetchTickers(apiKey, exchange, false)
+	tickers, err := fetchTickers(ctx, apiKey, exchange, false)
 	if err != nil {
 		// try with delisted
-		tickers, err = fetchTickers(apiKey, exchange, true)
+		tickers, err = fetchTickers(ctx, apiKey, exchange, true)
 		if err != nil {
 			return "", err
 		}
@@ -1,6 +1,7 @@
 package eodhd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,7 +14,7 @@ import (
 // fetchMicToExchangeCode returns a map of MIC to EODHD's internal exchange code.
 //
 // This is required since EODHD use its own id for exchange places.
-func fetchMicToExchangeCode(apiKey string) (map[string]string, error) {
+func fetchMicToExchangeCode(ctx context.Context, apiKey string) (map[string]string, error) {
 	// https://eodhd.com/api/exchanges-list/?api_token=demo&fmt=json
 	// we can retrive the Code by MIC success (add MIC to the security information (isin+mic))
 	// [
@@ -38,7 +39,7 @@ func fetchMicToExchangeCode(apiKey string) (map[string]string, error) {
 	// that's the paylod
 	content := make([]Info, 0)
 	// query that endpoint at most once a day
-	if err := jwget(newDailyCachingClient(), addr, &content); err != nil {
+	if err := jwget(ctx, newDailyCachingClient(), addr, &content); err != nil {
 		return nil, err
 	}
 	result := make(map[string]string)
@@ -52,7 +53,7 @@ func fetchMicToExchangeCode(apiKey string) (map[string]string, error) {
 
 // fetchPrices fills the daily open and close prices for a given EODHD ticker.
 // The EODHD ticker format is typically "SYMBOL.EXCHANGECODE".
-func fetchPrices(apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, open, close map[point]PriceChange) (err error) {
+func fetchPrices(ctx context.Context, apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, open, close map[point]PriceChange) (err error) {
 	// https://eodhd.com/api/eod/NVD.F?api_token=demo&fmt=json
 	// [
 	//
@@ -77,12 +78,14 @@ func fetchPrices(apiKey string, id portfolio.ID, ticker string, from, to portfol
 		Date  portfolio.Date  `json:"date"`
 		Close decimal.Decimal `json:"close"`
 		Open  decimal.Decimal `json:"open"`
+		// Volume is a float for some exchanges.
+		Volume float64 `json:"volume"`
 		// AdjustedClose decimal.Decimal        `json:"adjusted_close"`
 	}
 
 	// that's the payload
 	content := make([]Info, 0)
-	if err := jwget(newDailyCachingClient(), addr, &content); err != nil {
+	if err := jwget(ctx, newDailyCachingClient(), addr, &content); err != nil {
 		//log.Printf("failed to jwget %s: %v", addr, err)
 		return err
 	}
@@ -90,10 +93,11 @@ func fetchPrices(apiKey string, id portfolio.ID, ticker string, from, to portfol
 	for _, info := range content {
 		if close != nil {
 			close[point{info.Date, id}] = PriceChange{
-				Date: info.Date,
-				ID:   id,
-				Old:  nil,
-				New:  info.Close,
+				Date:   info.Date,
+				ID:     id,
+				Old:    nil,
+				New:    info.Close,
+				Volume: int64(info.Volume),
 			}
 		}
 		if open != nil {
@@ -109,7 +113,7 @@ func fetchPrices(apiKey string, id portfolio.ID, ticker string, from, to portfol
 }
 
 // fetchSplits returns the split history for a given EODHD ticker.
-func fetchSplits(apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, splits map[point]SplitChange) error {
+func fetchSplits(ctx context.Context, apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, splits map[point]SplitChange) error {
 	addr := fmt.Sprintf("https://eodhd.com/api/splits/%s?fmt=json&api_token=%s&from=%s&to=%s", ticker, apiKey, from, to)
 
 	type apiSplit struct {
@@ -118,7 +122,7 @@ func fetchSplits(apiKey string, id portfolio.ID, ticker string, from, to portfol
 	}
 
 	content := make([]apiSplit, 0)
-	if err := jwget(newDailyCachingClient(), addr, &content); err != nil {
+	if err := jwget(ctx, newDailyCachingClient(), addr, &content); err != nil {
 		return err
 	}
 
@@ -149,7 +153,7 @@ func fetchSplits(apiKey string, id portfolio.ID, ticker string, from, to portfol
 }
 
 // fetchDividends returns the dividend history for a given EODHD ticker.
-func fetchDividends(apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, dividends map[point]DividendChange) error {
+func fetchDividends(ctx context.Context, apiKey string, id portfolio.ID, ticker string, from, to portfolio.Date, dividends map[point]DividendChange) error {
 	addr := fmt.Sprintf("https://eodhd.com/api/div/%s?fmt=json&api_token=%s&from=%s&to=%s", ticker, apiKey, from, to)
 
 	type apiDividend struct {
@@ -159,7 +163,7 @@ func fetchDividends(apiKey string, id portfolio.ID, ticker string, from, to port
 	}
 
 	content := make([]apiDividend, 0)
-	if err := jwget(newDailyCachingClient(), addr, &content); err != nil {
+	if err := jwget(ctx, newDailyCachingClient(), addr, &content); err != nil {
 		return err
 	}
 
@@ -186,7 +190,7 @@ type TickerInfo struct {
 }
 
 // fetchTickers retrieves the list of all tickers for a given exchange code.
-func fetchTickers(apiKey string, exchangeCode string, delisted bool) ([]TickerInfo, error) {
+func fetchTickers(ctx context.Context, apiKey string, exchangeCode string, delisted bool) ([]TickerInfo, error) {
 	// API Documentation: https://eodhd.com/api/exchange-symbol-list/{EXCHANGE_CODE}
 	// Example response
 	// [
@@ -216,7 +220,7 @@ func fetchTickers(apiKey string, exchangeCode string, delisted bool) ([]TickerIn
 	}
 
 	var content []TickerInfo
-	if err := jwget(newMonthlyCachingClient(), addr, &content); err != nil {
+	if err := jwget(ctx, newMonthlyCachingClient(), addr, &content); err != nil {
 		return nil, fmt.Errorf("failed to fetch tickers for exchange %s: %w", exchangeCode, err)
 	}
 
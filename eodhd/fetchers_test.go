@@ -1,6 +1,7 @@
 package eodhd
 
 import (
+	"context"
 	"testing"
 
 	"github.com/etnz/portfolio"
@@ -11,7 +12,7 @@ const EodhdApiDemoKey = "demo"
 func Test_fetchPrices(t *testing.T) {
 
 	prices := make(map[point]PriceChange)
-	err := fetchPrices(EodhdApiDemoKey, portfolio.ID("None"), "MCD.US", portfolio.Today().Add(-10), portfolio.Today().Add(-1), nil, prices)
+	err := fetchPrices(context.Background(), EodhdApiDemoKey, portfolio.ID("None"), "MCD.US", portfolio.Today().Add(-10), portfolio.Today().Add(-1), nil, prices)
 	if err != nil {
 		t.Errorf("eodhdDailyFrom() unexpected error = %v", err)
 	}
@@ -24,7 +25,7 @@ func Test_fetchMicToExchangeCode(t *testing.T) {
 	if EodhdApiDemoKey == "demo" {
 		t.Skip("not supported with demo key, use a real one.")
 	}
-	mic2exchange, err := fetchMicToExchangeCode(EodhdApiDemoKey)
+	mic2exchange, err := fetchMicToExchangeCode(context.Background(), EodhdApiDemoKey)
 	if err != nil {
 		t.Fatalf("fetchMicToExchangeCode() unexpected error = %v", err)
 	}
@@ -41,7 +42,7 @@ func Test_fetchSplits(t *testing.T) {
 	splits := make(map[point]SplitChange)
 	// Using AAPL.US as it has a known split history.
 	// The from/to dates are currently ignored by the function, but we pass them for future-proofing.
-	err := fetchSplits(EodhdApiDemoKey, portfolio.ID("None"), "AAPL.US", portfolio.NewDate(2000, 1, 1), portfolio.Today(), splits)
+	err := fetchSplits(context.Background(), EodhdApiDemoKey, portfolio.ID("None"), "AAPL.US", portfolio.NewDate(2000, 1, 1), portfolio.Today(), splits)
 	if err != nil {
 		t.Errorf("fetchSplits() unexpected error = %v", err)
 	}
@@ -54,7 +55,7 @@ func Test_fetchDividends(t *testing.T) {
 	dividends := make(map[point]DividendChange)
 	// Using AAPL.US as it has a known dividend history.
 	// The from/to dates are currently ignored by the function.
-	err := fetchDividends(EodhdApiDemoKey, portfolio.ID("None"), "AAPL.US", portfolio.NewDate(2023, 1, 1), portfolio.Today(), dividends)
+	err := fetchDividends(context.Background(), EodhdApiDemoKey, portfolio.ID("None"), "AAPL.US", portfolio.NewDate(2023, 1, 1), portfolio.Today(), dividends)
 	if err != nil {
 		t.Errorf("fetchDividends() unexpected error = %v", err)
 	}
@@ -68,7 +69,7 @@ func Test_fetchTickers(t *testing.T) {
 		t.Skip("not supported with demo key, use a real one.")
 	}
 	// Using "F" for Frankfurt Exchange
-	tickers, err := fetchTickers(EodhdApiDemoKey, "NYSE", false)
+	tickers, err := fetchTickers(context.Background(), EodhdApiDemoKey, "NYSE", false)
 	if err != nil {
 		t.Fatalf("fetchTickers() unexpected error = %v", err)
 	}
@@ -93,7 +94,7 @@ func Test_Search(t *testing.T) {
 	if EodhdApiDemoKey == "demo" {
 		t.Skip("not supported with demo key, use a real one.")
 	}
-	results, err := Search(EodhdApiDemoKey, "Apple")
+	results, err := Search(context.Background(), EodhdApiDemoKey, "Apple")
 	if err != nil {
 		t.Fatalf("Search() unexpected error = %v", err)
 	}
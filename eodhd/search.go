@@ -1,8 +1,11 @@
 package eodhd
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 
 	"github.com/etnz/portfolio"
 )
@@ -22,22 +25,22 @@ type SearchResult struct {
 }
 
 // Search searches for securities via EOD Historical Data API.
-func Search(apiKey string, searchTerm string) ([]SearchResult, error) {
+func Search(ctx context.Context, apiKey string, searchTerm string) ([]SearchResult, error) {
 	apiURL := fmt.Sprintf("https://eodhd.com/api/search/%s?api_token=%s&fmt=json", url.PathEscape(searchTerm), url.QueryEscape(apiKey))
 
 	var results []SearchResult
-	if err := jwget(newDailyCachingClient(), apiURL, &results); err != nil {
+	if err := jwget(ctx, newDailyCachingClient(), apiURL, &results); err != nil {
 		return nil, err
 	}
 	// Search results reference an exchange code that could match multiple MIC (only for the US apparently).
-	mic2Exchange, err := fetchMicToExchangeCode(apiKey)
+	mic2Exchange, err := fetchMicToExchangeCode(ctx, apiKey)
 	if err != nil {
 		return nil, err
 	}
 	// Reverse the map.
 	exchange2mic := make(map[string][]string)
-	for k, v := range mic2Exchange {
-		exchange2mic[v] = append(exchange2mic[v], k)
+	for _, k := range slices.Sorted(maps.Keys(mic2Exchange)) {
+		exchange2mic[mic2Exchange[k]] = append(exchange2mic[mic2Exchange[k]], k)
 	}
 
 	// Now we fully rebuild the search result list with potentially different MIC
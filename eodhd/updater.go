@@ -1,19 +1,35 @@
 package eodhd
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 
 	"github.com/etnz/portfolio"
 	"github.com/shopspring/decimal"
 )
 
+// Source is the source of the market data fetched from eodhd.com, see
+// portfolio.UpdatePrice.
+const Source = "eodhd"
+
 // point is a private struct to hold together the date and ID of a price update.
 type point struct {
 	Date portfolio.Date
 	ID   portfolio.ID
 }
 
+// sortedPoints returns the points of m by date then ID, so that the changes are
+// reported in the same order on each run.
+func sortedPoints[V any](m map[point]V) []point {
+	return slices.SortedFunc(maps.Keys(m), func(a, b point) int {
+		return cmp.Or(a.Date.Compare(b.Date), cmp.Compare(a.ID, b.ID))
+	})
+}
+
 type Change interface {
 	When() portfolio.Date
 	What() portfolio.ID
@@ -25,6 +41,7 @@ type PriceChange struct {
 	Old      *decimal.Decimal
 	New      decimal.Decimal
 	Currency string
+	Volume   int64 // Volume is the number of shares traded on the day, 0 if unknown.
 }
 
 func (c PriceChange) When() portfolio.Date { return c.Date }
@@ -77,6 +94,7 @@ const (
 // up to either today or the last day the asset was held.
 //
 // Parameters:
+//   - ctx: The context cancelling the API requests.
 //   - key: The EODHD API key.
 //   - ledger: The portfolio ledger containing the securities to update.
 //   - inception: If true, fetches data from the security's inception date. If false, fetches incrementally.
@@ -87,7 +105,7 @@ const (
 //   - A slice of Change interfaces, providing a detailed log of each data point fetched.
 //   - A slice of portfolio.Transaction objects (UpdatePrice, Split, Dividend) ready to be applied to a ledger.
 //   - An error if the API request or data processing fails.
-func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOptions, tickers ...string) ([]Change, []portfolio.Transaction, error) {
+func Fetch(ctx context.Context, key string, ledger *portfolio.Ledger, inception bool, options FetchOptions, tickers ...string) ([]Change, []portfolio.Transaction, error) {
 	if options == 0 {
 		options = FetchAll
 	}
@@ -106,6 +124,9 @@ func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOp
 	id2Sec := make(map[portfolio.ID][]portfolio.Security)
 
 	for sec := range ledger.AllSecurities() {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		// If a list of tickers is provided, only update those.
 		if len(tickersToUpdate) > 0 {
 			if _, shouldUpdate := tickersToUpdate[sec.Ticker()]; !shouldUpdate {
@@ -149,7 +170,7 @@ func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOp
 		}
 
 		// Compute the ticker for this security
-		ticker, err := findTicker(key, sec)
+		ticker, err := findTicker(ctx, key, sec)
 		if err != nil {
 			// temp, ignore that error
 			log.Println("warning", err)
@@ -158,19 +179,19 @@ func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOp
 		}
 
 		if options&FetchPrices != 0 {
-			if err := findPrices(key, id, ticker, from, to, prices); err != nil {
+			if err := findPrices(ctx, key, id, ticker, from, to, prices); err != nil {
 				return nil, nil, err
 			}
 		}
 
 		if id.IsMSSI() {
 			if options&FetchSplits != 0 {
-				if err := fetchSplits(key, id, ticker, from, to, splits); err != nil {
+				if err := fetchSplits(ctx, key, id, ticker, from, to, splits); err != nil {
 					return nil, nil, err
 				}
 			}
 			if options&FetchDividends != 0 {
-				if err := fetchDividends(key, id, ticker, from, to, dividends); err != nil {
+				if err := fetchDividends(ctx, key, id, ticker, from, to, dividends); err != nil {
 					return nil, nil, err
 				}
 			}
@@ -181,13 +202,17 @@ func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOp
 	changes := make([]Change, 0, len(prices)+len(splits)+len(dividends))
 	updates := make([]portfolio.Transaction, 0, len(prices)+len(splits)+len(dividends))
 
-	for _, v := range splits {
+	for _, p := range sortedPoints(splits) {
+		v := splits[p]
 		changes = append(changes, v)
 		for _, sec := range id2Sec[v.ID] {
-			updates = append(updates, portfolio.NewSplit(v.Date, sec.Ticker(), v.Numerator, v.Denominator))
+			split := portfolio.NewSplit(v.Date, sec.Ticker(), v.Numerator, v.Denominator)
+			split.Source = Source
+			updates = append(updates, split)
 		}
 	}
-	for _, v := range dividends {
+	for _, p := range sortedPoints(dividends) {
+		v := dividends[p]
 		changes = append(changes, v)
 		for _, sec := range id2Sec[v.ID] {
 			// The dividend currency from the API is the source of truth, as companies
@@ -195,18 +220,32 @@ func Fetch(key string, ledger *portfolio.Ledger, inception bool, options FetchOp
 			// security's trading currency on a specific exchange (e.g., a US company
 			// paying in USD for shares traded in EUR on XETRA).
 			// The portfolio ledger will correctly credit the cash to the corresponding currency account.
-			updates = append(updates, portfolio.NewDividend(v.Date, "fetched from eodhd.com", sec.Ticker(), portfolio.M(v.Amount, v.Currency)))
+			dividend := portfolio.NewDividend(v.Date, "fetched from eodhd.com", sec.Ticker(), portfolio.M(v.Amount, v.Currency))
+			dividend.Source = Source
+			updates = append(updates, dividend)
 		}
 	}
-	for _, v := range prices {
+	for _, p := range sortedPoints(prices) {
+		v := prices[p]
 		changes = append(changes, v)
 		for _, sec := range id2Sec[v.ID] {
-			updates = append(updates, portfolio.NewUpdatePrice(v.Date, sec.Ticker(), portfolio.M(v.New, sec.Currency())))
+			updates = append(updates, newUpdatePrice(sec, v))
 		}
 	}
 	return changes, updates, nil
 }
 
+// newUpdatePrice returns the update of the price of a security, with its
+// source and its volume.
+func newUpdatePrice(sec portfolio.Security, v PriceChange) portfolio.UpdatePrice {
+	update := portfolio.NewUpdatePrice(v.Date, sec.Ticker(), portfolio.M(v.New, sec.Currency()))
+	update.Source = Source
+	if v.Volume > 0 {
+		update.Volumes = map[string]int64{sec.Ticker(): v.Volume}
+	}
+	return update
+}
+
 func computeBounds(sec portfolio.Security, ledger *portfolio.Ledger, inception bool) (from, to portfolio.Date, err error) {
 	id := sec.ID()
 	if id.IsCurrencyPair() {
@@ -277,5 +316,81 @@ func assetBounds(sec portfolio.Security, ledger *portfolio.Ledger, inception boo
 		// there is the risk that there is no data for this range, and we will try for every.
 		// The sell day is very likely an open day with actual data. (but it is not guaranteed)
 	}
+
+	// There are no prices on the days the exchange does not trade: do not
+	// request a range made of them only, e.g. a weekend.
+	if e, ok := sec.Exchange(); ok {
+		for !from.After(to) && !e.IsTradingDay(from) {
+			from = from.Add(1)
+		}
+		for !from.After(to) && !e.IsTradingDay(to) {
+			to = to.Add(-1)
+		}
+	}
 	return from, to, nil
 }
+
+// FetchGaps retrieves from the EODHD API the prices of securities missing in the
+// gaps of their price history, as returned by portfolio.Ledger.PriceGaps.
+//
+// Requests are batched: the gaps of all the securities sharing an ID are fetched
+// by a single request, from the first gap to the last one, and only the prices
+// within the gaps are returned. Securities that are not traded assets or
+// currency pairs are skipped.
+func FetchGaps(ctx context.Context, key string, ledger *portfolio.Ledger, gaps map[string][]portfolio.Range) ([]portfolio.Transaction, error) {
+	// Group the gaps by ID.
+	id2Sec := make(map[portfolio.ID][]portfolio.Security)
+	id2Gaps := make(map[portfolio.ID][]portfolio.Range)
+	for ticker, ranges := range gaps {
+		sec := ledger.Security(ticker)
+		if sec == nil || len(ranges) == 0 {
+			continue
+		}
+		id := sec.ID()
+		if !(id.IsCurrencyPair() || id.IsISIN() || id.IsMSSI()) {
+			continue
+		}
+		id2Sec[id] = append(id2Sec[id], *sec)
+		id2Gaps[id] = append(id2Gaps[id], ranges...)
+	}
+
+	var updates []portfolio.Transaction
+	for _, id := range slices.Sorted(maps.Keys(id2Gaps)) {
+		ranges := id2Gaps[id]
+		from, to := ranges[0].From, ranges[0].To
+		for _, r := range ranges[1:] {
+			if r.From.Before(from) {
+				from = r.From
+			}
+			if r.To.After(to) {
+				to = r.To
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ticker, err := findTicker(ctx, key, id2Sec[id][0])
+		if err != nil {
+			log.Println("warning", err)
+			continue
+		}
+		prices := make(map[point]PriceChange)
+		if err := findPrices(ctx, key, id, ticker, from, to, prices); err != nil {
+			return nil, err
+		}
+		for _, p := range sortedPoints(prices) {
+			v := prices[p]
+			inGap := false
+			for _, r := range ranges {
+				inGap = inGap || r.Contains(v.Date)
+			}
+			if !inGap {
+				continue
+			}
+			for _, sec := range id2Sec[id] {
+				updates = append(updates, newUpdatePrice(sec, v))
+			}
+		}
+	}
+	return updates, nil
+}
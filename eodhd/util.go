@@ -1,115 +1,48 @@
 package eodhd
 
 import (
-	"bufio"
 	"bytes"
-	"crypto/sha1"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"math/big"
 	"net/http"
-	"net/http/httputil"
-	"os"
-	"path/filepath"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/cache"
 	"github.com/shopspring/decimal"
 )
 
-// diskCache implements a simple disk cache for HTTP responses
-type diskCache struct {
-	base   http.RoundTripper
-	period portfolio.Period // nil is daily
-}
-
-// RoundTrip implements the http.RoundTripper interface. It checks for a cached
-// response on disk first. If a fresh cached response is not found, it proceeds
-// with the actual HTTP request and caches the new response if it's successful.
-func (c *diskCache) RoundTrip(req *http.Request) (resp *http.Response, err error) {
-	// get from disk
-	// diskcache implements a unique key per day, so the local tmp expires every day.
-	rangeID := c.period.Range(portfolio.Today()).Identifier()
-	key := fmt.Sprintf("%s %s %s", rangeID, req.Method, req.URL.String())
-	key = fmt.Sprintf("%s-%x", c.period, sha1.Sum([]byte(key)))
-	//key = url.PathEscape(key)
-
-	cachedResp, err := c.get(key, req)
-	if err == nil { // Cache hit
-		return cachedResp, nil
-	}
-
-	resp, err = c.base.RoundTrip(req)
-	if err != nil {
-		return nil, err
-	}
-	log.Printf("%v %v/%v %v", resp.Request.Method, resp.Request.URL.Host, resp.Request.URL.Path, resp.Status)
-	if resp.StatusCode >= 300 {
-		return resp, nil
-	}
-	// otherwise attempt to store it in cache
-
-	err = c.put(key, resp)
-	if err != nil {
-		log.Printf("cache write err (ignored): %v\n", err)
-	}
-	return resp, nil
-}
-
-// get retrieves a cached response from disk
-func (c *diskCache) get(key string, req *http.Request) (resp *http.Response, err error) {
-	file := filepath.Join(os.TempDir(), key)
-	content, err := os.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
-	return http.ReadResponse(bufio.NewReader(bytes.NewBuffer(content)), req)
-}
-
-// put stores a response to disk cache
-func (c *diskCache) put(key string, resp *http.Response) (err error) {
-	file := filepath.Join(os.TempDir(), key)
-
-	content, err := httputil.DumpResponse(resp, true)
-	if err != nil {
-		return err
-	}
-
-	f, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-
-	_, err = f.Write(content)
-	f.Close()
-	return err
-}
-
-// newDailyCachingClient returns an http.Client that uses a disk cache where entries expire daily.
+// newDailyCachingClient returns an http.Client that uses the shared disk cache where entries expire daily.
+//
+// It is used for the prices, splits and dividends, as they can be published
+// any day, and for the search and exchanges list, queried at most once a day.
 func newDailyCachingClient() *http.Client {
-	client := new(http.Client)
-	client.Transport = &diskCache{base: http.DefaultTransport}
-	return client
+	return cache.NewClient("eodhd", portfolio.Daily)
 }
 
-// newDailyCachingClient returns an http.Client that uses a disk cache where entries expire daily.
+// newMonthlyCachingClient returns an http.Client that uses the shared disk cache where entries expire monthly.
+//
+// It is used for the tickers of an exchange, that rarely change.
 func newMonthlyCachingClient() *http.Client {
-	client := new(http.Client)
-	client.Transport = &diskCache{base: http.DefaultTransport, period: portfolio.Monthly}
-	return client
+	return cache.NewClient("eodhd", portfolio.Monthly)
 }
 
 // jwget performs an HTTP GET request to the given address and unmarshals the
 // JSON response body into the provided data structure. It uses the provided
-// http.Client for the request.
-func jwget(client *http.Client, addr string, data interface{}) error {
-	resp, err := client.Get(addr)
+// http.Client for the request, cancelled with the context.
+func jwget(ctx context.Context, client *http.Client, addr string, data interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
 	if err != nil {
 		return err
 	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return portfolio.ProviderError(err)
+	}
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("cannot http GET %v/%v: %v", resp.Request.URL.Host, resp.Request.URL.Path, resp.Status)
+		return portfolio.ProviderError(fmt.Errorf("cannot http GET %v/%v: %v", resp.Request.URL.Host, resp.Request.URL.Path, resp.Status))
 	}
 	var buf bytes.Buffer
 	_, err = io.Copy(&buf, resp.Body)
@@ -117,7 +50,7 @@ func jwget(client *http.Client, addr string, data interface{}) error {
 		return err
 	}
 	resp.Body.Close()
-	return json.Unmarshal(buf.Bytes(), data)
+	return portfolio.ProviderError(json.Unmarshal(buf.Bytes(), data))
 }
 
 // simplifyDecimalRatio converts a ratio of decimals into a simplified integer fraction.
@@ -0,0 +1,42 @@
+package portfolio
+
+import "errors"
+
+// The kinds of errors returned by the package, to be tested with errors.Is.
+// The errors of a kind keep their own message, the kind is not part of it.
+var (
+	// ErrValidation is the kind of the errors of the transactions rejected by
+	// Ledger.Validate.
+	ErrValidation = errors.New("invalid transaction")
+	// ErrInsufficientCash is the kind of the validation errors of transactions
+	// paying more than the cash balance, and its overdraft limit.
+	ErrInsufficientCash = errors.New("insufficient cash")
+	// ErrUnknownSecurity is the kind of the errors about a ticker that is not
+	// declared in the ledger.
+	ErrUnknownSecurity = errors.New("unknown security")
+	// ErrProvider is the kind of the errors of the market data providers, when
+	// the data cannot be retrieved.
+	ErrProvider = errors.New("market data provider error")
+)
+
+// kindError is an error of a kind, with the message of the error.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// withKind returns err as an error of a kind, or nil if err is nil. It is a
+// no-op if err is already of that kind.
+func withKind(kind, err error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// ProviderError returns err as an error of the ErrProvider kind, or nil if err
+// is nil. Market data providers use it for the errors of their remote calls.
+func ProviderError(err error) error { return withKind(ErrProvider, err) }
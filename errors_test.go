@@ -0,0 +1,58 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLedger_Validate_errorKinds(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(500), ""),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tx   Transaction
+		kind error
+	}{
+		{"buy beyond cash", NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1600)), ErrInsufficientCash},
+		{"withdraw beyond cash", NewWithdraw(NewDate(2025, 1, 3), "", USD(600)), ErrInsufficientCash},
+		{"undeclared security", NewBuy(NewDate(2025, 1, 3), "", "MSFT", Q(1), USD(100)), ErrUnknownSecurity},
+		{"undeclared price", NewUpdatePrice(NewDate(2025, 1, 3), "MSFT", USD(100)), ErrUnknownSecurity},
+		{"negative deposit", NewDeposit(NewDate(2025, 1, 3), "", USD(-1), ""), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ledger.Validate(tt.tx)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("Validate() error = %v, want an ErrValidation", err)
+			}
+			for _, kind := range []error{ErrInsufficientCash, ErrUnknownSecurity, ErrProvider} {
+				if got, want := errors.Is(err, kind), kind == tt.kind; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, kind, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	if ProviderError(nil) != nil {
+		t.Error("ProviderError(nil) != nil")
+	}
+	cause := errors.New("503 Service Unavailable")
+	err := fmt.Errorf("fetching AAPL: %w", ProviderError(cause))
+	if !errors.Is(err, ErrProvider) || !errors.Is(err, cause) {
+		t.Errorf("%v is not an ErrProvider wrapping its cause", err)
+	}
+	if got, want := err.Error(), "fetching AAPL: 503 Service Unavailable"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
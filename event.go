@@ -0,0 +1,234 @@
+package portfolio
+
+import "iter"
+
+// EventKind identifies the kind of a journal Event.
+type EventKind string
+
+const (
+	EventCreditCash          EventKind = "creditCash"
+	EventDebitCash           EventKind = "debitCash"
+	EventAcquireLot          EventKind = "acquireLot"
+	EventDisposeLot          EventKind = "disposeLot"
+	EventReceiveDividend     EventKind = "receiveDividend"
+	EventLendShares          EventKind = "lendShares"
+	EventRecallShares        EventKind = "recallShares"
+	EventReceiveLendingFee   EventKind = "receiveLendingFee"
+	EventDeclareGrant        EventKind = "declareGrant"
+	EventVestGrant           EventKind = "vestGrant"
+	EventDeclareCounterparty EventKind = "declareCounterparty"
+	EventCreditCounterparty  EventKind = "creditCounterparty"
+	EventDebitCounterparty   EventKind = "debitCounterparty"
+	EventSplitShare          EventKind = "splitShare"
+	EventDeclareSecurity     EventKind = "declareSecurity"
+	EventUpdatePrice         EventKind = "updatePrice"
+	EventUpdateForex         EventKind = "updateForex"
+)
+
+// Event is a read-only view of a journal event, the atomic facts all the
+// portfolio states are derived from. Tools can use them to compute custom
+// metrics without re-deriving the state from the transactions.
+//
+// Fields that do not apply to the kind of event are zero.
+type Event interface {
+	Date() Date
+	Kind() EventKind
+	// Transaction is the ledger transaction that created the event.
+	Transaction() Transaction
+	// Security is the ticker of the security the event is about.
+	Security() string
+	// Account is the counterparty account, or the grant ID for employee stock
+	// plan events.
+	Account() string
+	// Currency is the currency of the amount, of the declared security or
+	// counterparty, or the foreign currency of an exchange rate.
+	Currency() string
+	// Quantity is the number of shares moved, or the split ratio.
+	Quantity() Quantity
+	// Amount is always positive for cash and counterparty movements, the kind
+	// tells the direction. It is the cost of an acquired lot, the proceeds of a
+	// disposed lot, the dividend per share, the lending fee, the price, or the
+	// exchange rate.
+	Amount() Money
+	// External reports whether the cash or counterparty movement crosses the
+	// portfolio boundary.
+	External() bool
+}
+
+// Events returns the journal events during the period, in chronological order.
+func (j *Journal) Events(period Range) iter.Seq[Event] {
+	return func(yield func(Event) bool) {
+		for _, e := range j.events {
+			if e.date().After(period.To) {
+				return
+			}
+			if !period.Contains(e.date()) {
+				continue
+			}
+			if !yield(publicEvent{e: e, tx: j.txs[e.source()]}) {
+				return
+			}
+		}
+	}
+}
+
+// publicEvent implements Event on top of the journal's internal events.
+type publicEvent struct {
+	e  event
+	tx Transaction
+}
+
+func (p publicEvent) Date() Date               { return p.e.date() }
+func (p publicEvent) Transaction() Transaction { return p.tx }
+
+func (p publicEvent) Kind() EventKind {
+	switch p.e.(type) {
+	case creditCash:
+		return EventCreditCash
+	case debitCash:
+		return EventDebitCash
+	case acquireLot:
+		return EventAcquireLot
+	case disposeLot:
+		return EventDisposeLot
+	case receiveDividend:
+		return EventReceiveDividend
+	case lendShares:
+		return EventLendShares
+	case recallShares:
+		return EventRecallShares
+	case receiveLendingFee:
+		return EventReceiveLendingFee
+	case declareGrant:
+		return EventDeclareGrant
+	case vestGrant:
+		return EventVestGrant
+	case declareCounterparty:
+		return EventDeclareCounterparty
+	case creditCounterparty:
+		return EventCreditCounterparty
+	case debitCounterparty:
+		return EventDebitCounterparty
+	case splitShare:
+		return EventSplitShare
+	case declareSecurity:
+		return EventDeclareSecurity
+	case updatePrice:
+		return EventUpdatePrice
+	case updateForex:
+		return EventUpdateForex
+	}
+	return ""
+}
+
+func (p publicEvent) Security() string {
+	switch v := p.e.(type) {
+	case acquireLot:
+		return v.security
+	case disposeLot:
+		return v.security
+	case receiveDividend:
+		return v.security
+	case lendShares:
+		return v.security
+	case recallShares:
+		return v.security
+	case receiveLendingFee:
+		return v.security
+	case declareGrant:
+		return v.security
+	case splitShare:
+		return v.security
+	case declareSecurity:
+		return v.ticker
+	case updatePrice:
+		return v.security
+	}
+	return ""
+}
+
+func (p publicEvent) Account() string {
+	switch v := p.e.(type) {
+	case declareGrant:
+		return v.grant
+	case vestGrant:
+		return v.grant
+	case declareCounterparty:
+		return v.account
+	case creditCounterparty:
+		return v.account
+	case debitCounterparty:
+		return v.account
+	}
+	return ""
+}
+
+func (p publicEvent) Currency() string {
+	switch v := p.e.(type) {
+	case declareCounterparty:
+		return v.currency
+	case declareSecurity:
+		return v.currency
+	case updateForex:
+		return v.currency
+	}
+	return p.Amount().Currency()
+}
+
+func (p publicEvent) Quantity() Quantity {
+	switch v := p.e.(type) {
+	case acquireLot:
+		return v.quantity
+	case disposeLot:
+		return v.quantity
+	case lendShares:
+		return v.quantity
+	case recallShares:
+		return v.quantity
+	case vestGrant:
+		return v.quantity
+	case splitShare:
+		return Q(v.numerator).Div(Q(v.denominator))
+	}
+	return Quantity{}
+}
+
+func (p publicEvent) Amount() Money {
+	switch v := p.e.(type) {
+	case creditCash:
+		return v.amount
+	case debitCash:
+		return v.amount
+	case acquireLot:
+		return v.cost
+	case disposeLot:
+		return v.proceeds
+	case receiveDividend:
+		return v.amount
+	case receiveLendingFee:
+		return v.amount
+	case creditCounterparty:
+		return v.amount
+	case debitCounterparty:
+		return v.amount
+	case updatePrice:
+		return v.price
+	case updateForex:
+		return v.rate
+	}
+	return Money{}
+}
+
+func (p publicEvent) External() bool {
+	switch v := p.e.(type) {
+	case creditCash:
+		return v.external
+	case debitCash:
+		return v.external
+	case creditCounterparty:
+		return v.external
+	case debitCounterparty:
+		return v.external
+	}
+	return false
+}
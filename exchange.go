@@ -0,0 +1,118 @@
+package portfolio
+
+import (
+	"slices"
+	"time"
+)
+
+// Exchange describes the trading days and hours of a stock exchange, in its
+// local time.
+type Exchange struct {
+	MIC      string
+	Name     string
+	TimeZone string        // TimeZone is the IANA name of the time zone of the exchange.
+	Open     time.Duration // Open is the opening time, from midnight.
+	Close    time.Duration // Close is the closing time, from midnight.
+	// Weekend are the days of the week without trading, Saturday and Sunday if
+	// nil.
+	Weekend []time.Weekday
+
+	holidays calendar // holidays returns the holidays of a year, see calendar.go.
+}
+
+// exchanges are the major exchanges, by MIC.
+var exchanges = map[string]Exchange{
+	"XNYS": {MIC: "XNYS", Name: "New York Stock Exchange", TimeZone: "America/New_York", Open: hm(9, 30), Close: hm(16, 0), holidays: nyseHolidays},
+	"XNAS": {MIC: "XNAS", Name: "Nasdaq", TimeZone: "America/New_York", Open: hm(9, 30), Close: hm(16, 0), holidays: nyseHolidays},
+	"XTSE": {MIC: "XTSE", Name: "Toronto Stock Exchange", TimeZone: "America/Toronto", Open: hm(9, 30), Close: hm(16, 0), holidays: tsxHolidays},
+	"XLON": {MIC: "XLON", Name: "London Stock Exchange", TimeZone: "Europe/London", Open: hm(8, 0), Close: hm(16, 30), holidays: londonHolidays},
+	"XPAR": {MIC: "XPAR", Name: "Euronext Paris", TimeZone: "Europe/Paris", Open: hm(9, 0), Close: hm(17, 30), holidays: euronextHolidays},
+	"XAMS": {MIC: "XAMS", Name: "Euronext Amsterdam", TimeZone: "Europe/Amsterdam", Open: hm(9, 0), Close: hm(17, 30), holidays: euronextHolidays},
+	"XBRU": {MIC: "XBRU", Name: "Euronext Brussels", TimeZone: "Europe/Brussels", Open: hm(9, 0), Close: hm(17, 30), holidays: euronextHolidays},
+	"XMIL": {MIC: "XMIL", Name: "Borsa Italiana", TimeZone: "Europe/Rome", Open: hm(9, 0), Close: hm(17, 30), holidays: milanHolidays},
+	"XETR": {MIC: "XETR", Name: "Xetra", TimeZone: "Europe/Berlin", Open: hm(9, 0), Close: hm(17, 30), holidays: xetraHolidays},
+	"XFRA": {MIC: "XFRA", Name: "Frankfurt Stock Exchange", TimeZone: "Europe/Berlin", Open: hm(8, 0), Close: hm(22, 0), holidays: xetraHolidays},
+	"XSWX": {MIC: "XSWX", Name: "SIX Swiss Exchange", TimeZone: "Europe/Zurich", Open: hm(9, 0), Close: hm(17, 30), holidays: sixHolidays},
+	"XMAD": {MIC: "XMAD", Name: "Bolsa de Madrid", TimeZone: "Europe/Madrid", Open: hm(9, 0), Close: hm(17, 30), holidays: madridHolidays},
+	"XTKS": {MIC: "XTKS", Name: "Tokyo Stock Exchange", TimeZone: "Asia/Tokyo", Open: hm(9, 0), Close: hm(15, 30), holidays: tokyoHolidays},
+	"XHKG": {MIC: "XHKG", Name: "Hong Kong Stock Exchange", TimeZone: "Asia/Hong_Kong", Open: hm(9, 30), Close: hm(16, 0), holidays: christmasEasterHolidays},
+	"XSAU": {MIC: "XSAU", Name: "Saudi Exchange", TimeZone: "Asia/Riyadh", Open: hm(10, 0), Close: hm(15, 0), Weekend: []time.Weekday{time.Friday, time.Saturday}},
+	"XASX": {MIC: "XASX", Name: "Australian Securities Exchange", TimeZone: "Australia/Sydney", Open: hm(10, 0), Close: hm(16, 0), holidays: christmasEasterHolidays},
+}
+
+// hm returns the duration of h hours and m minutes.
+func hm(h, m int) time.Duration { return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute }
+
+// LookupExchange returns the exchange of a MIC, if known.
+func LookupExchange(mic string) (Exchange, bool) {
+	e, ok := exchanges[mic]
+	return e, ok
+}
+
+// location returns the time zone of the exchange, UTC if unknown to the
+// system.
+func (e Exchange) location() *time.Location {
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// InSession reports whether the exchange is trading at t: between its opening
+// and closing time on a trading day.
+func (e Exchange) InSession(t time.Time) bool {
+	t = t.In(e.location())
+	if !e.IsTradingDay(NewDate(t.Date())) {
+		return false
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	since := t.Sub(midnight)
+	return since >= e.Open && since < e.Close
+}
+
+// closeOf returns the closing time of the exchange on the local day of t.
+func (e Exchange) closeOf(t time.Time) time.Time {
+	t = t.In(e.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(e.Close)
+}
+
+// Exchange returns the exchange of the security, if it is identified by an
+// MSSI of a known exchange.
+func (s Security) Exchange() (Exchange, bool) {
+	return LookupExchange(s.ID().MIC())
+}
+
+// Exchanges returns the MICs of the exchanges of the securities of the ledger
+// identified by an MSSI, sorted.
+func (l *Ledger) Exchanges() []string {
+	var mics []string
+	for sec := range l.AllSecurities() {
+		if mic := sec.ID().MIC(); mic != "" && !slices.Contains(mics, mic) {
+			mics = append(mics, mic)
+		}
+	}
+	slices.Sort(mics)
+	return mics
+}
+
+// AfterMarketClose returns the first time on or after t when none of the
+// exchanges is in session, i.e. when their end-of-day prices are final.
+// Unknown MICs are ignored.
+func AfterMarketClose(t time.Time, mics ...string) time.Time {
+	// Each postponement moves to the close of a session, there are at most as
+	// many as exchanges.
+	for range len(mics) + 1 {
+		postponed := false
+		for _, mic := range mics {
+			if e, ok := LookupExchange(mic); ok && e.InSession(t) {
+				t = e.closeOf(t)
+				postponed = true
+			}
+		}
+		if !postponed {
+			break
+		}
+	}
+	return t
+}
@@ -0,0 +1,51 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExchange_InSession(t *testing.T) {
+	xetr, ok := LookupExchange("XETR")
+	if !ok {
+		t.Fatal("LookupExchange(XETR) not found")
+	}
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC), true},   // Wednesday 13:00 in Frankfurt.
+		{time.Date(2025, 3, 12, 16, 30, 0, 0, time.UTC), false}, // 17:30 in Frankfurt, closed.
+		{time.Date(2025, 3, 12, 7, 59, 0, 0, time.UTC), false},  // 8:59 in Frankfurt.
+		{time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC), false},  // Saturday.
+	}
+	for _, test := range tests {
+		if got := xetr.InSession(test.t); got != test.want {
+			t.Errorf("XETR.InSession(%v) = %v, want %v", test.t, got, test.want)
+		}
+	}
+	if _, ok := LookupExchange("ZZZZ"); ok {
+		t.Error("LookupExchange(ZZZZ) found an unknown exchange")
+	}
+}
+
+func TestAfterMarketClose(t *testing.T) {
+	noon := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		mics []string
+		want time.Time
+	}{
+		{"no exchange", nil, noon},
+		{"unknown exchange", []string{"ZZZZ"}, noon},
+		{"closed exchange", []string{"XTKS"}, noon},
+		{"open exchange", []string{"XETR"}, time.Date(2025, 3, 12, 16, 30, 0, 0, time.UTC)},
+		// Waiting for Xetra to close, New York has opened.
+		{"overlapping sessions", []string{"XETR", "XNYS"}, time.Date(2025, 3, 12, 20, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := AfterMarketClose(noon, test.mics...); !got.Equal(test.want) {
+			t.Errorf("%s: AfterMarketClose() = %v, want %v", test.name, got.UTC(), test.want)
+		}
+	}
+}
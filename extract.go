@@ -0,0 +1,144 @@
+package portfolio
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// Extract returns a new ledger with the history of a selection of securities:
+// their declarations, trades and market data, and the exchange rates. The
+// underlying securities of selected options are selected too.
+//
+// The cash of the other transactions is left out: instead, an opening deposit
+// on the inception date funds each cash account, in each currency, with the
+// smallest amount that keeps its balance from ever going negative.
+//
+// Extension transactions are kept with the positions of the selection only, and
+// with their cash only if they do not involve other securities.
+func (l *Ledger) Extract(tickers ...string) (*Ledger, error) {
+	l = l.clone()
+	l.stableSort()
+	selected := make(map[string]bool)
+	for _, ticker := range tickers {
+		sec := l.Security(ticker)
+		if sec == nil {
+			return nil, withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared in ledger", ticker))
+		}
+		selected[ticker] = true
+		if c, err := sec.ID().Option(); err == nil {
+			selected[c.Underlying] = true
+		}
+	}
+	keep := func(ticker string) bool {
+		if selected[ticker] {
+			return true
+		}
+		sec := l.Security(ticker)
+		return sec != nil && sec.ID().IsCurrencyPair()
+	}
+
+	extracted := NewLedger()
+	extracted.name = l.name
+	extracted.currency = l.currency
+	extracted.pivot = l.pivot
+	extracted.settlementLag = l.settlementLag
+	for _, tx := range l.transactions {
+		switch v := tx.(type) {
+		case Init:
+			extracted.transactions = append(extracted.transactions, tx)
+		case UpdatePrice:
+			prices := make(map[string]decimal.Decimal)
+			for ticker, price := range v.Prices {
+				if keep(ticker) {
+					prices[ticker] = price
+				}
+			}
+			switch {
+			case len(prices) == len(v.Prices):
+				extracted.transactions = append(extracted.transactions, tx)
+			case len(prices) > 0:
+				v.Prices = prices
+				extracted.transactions = append(extracted.transactions, v)
+			}
+		case Custom:
+			var positions []PositionEffect
+			for _, p := range v.Positions {
+				if selected[p.Security] {
+					positions = append(positions, p)
+				}
+			}
+			if len(positions) == 0 {
+				continue
+			}
+			if len(positions) < len(v.Positions) {
+				v.Positions, v.Cash = positions, nil
+			}
+			extracted.transactions = append(extracted.transactions, v)
+		default:
+			securities := securitiesOf(tx)
+			if len(securities) > 0 && slices.ContainsFunc(securities, keep) {
+				extracted.transactions = append(extracted.transactions, tx)
+			}
+		}
+	}
+	if err := extracted.index(); err != nil {
+		return nil, err
+	}
+
+	// The lowest balance of each cash account and currency, in order of use.
+	type account struct{ name, currency string }
+	var accounts []account
+	lowest := make(map[account]Money)
+	balances := make(map[account]Money)
+	for _, e := range extracted.journal.events {
+		var acc account
+		var amount Money
+		switch v := e.(type) {
+		case creditCash:
+			acc, amount = account{v.cashAccount(), v.currency()}, v.amount
+		case debitCash:
+			acc, amount = account{v.cashAccount(), v.currency()}, v.amount.Neg()
+		default:
+			continue
+		}
+		if _, ok := balances[acc]; !ok {
+			accounts = append(accounts, acc)
+			balances[acc], lowest[acc] = M(0, acc.currency), M(0, acc.currency)
+		}
+		balances[acc] = balances[acc].Add(amount)
+		if balances[acc].LessThan(lowest[acc]) {
+			lowest[acc] = balances[acc]
+		}
+	}
+
+	inception := extracted.OldestTransactionDate()
+	var deposits []Transaction
+	for _, acc := range accounts {
+		if !lowest[acc].IsNegative() {
+			continue
+		}
+		deposit := NewDeposit(inception, "opening deposit", lowest[acc].Neg(), "")
+		if acc.name != DefaultAccount {
+			deposit.Account = acc.name
+		}
+		deposits = append(deposits, deposit)
+	}
+	if len(deposits) == 0 {
+		return extracted, nil
+	}
+	// The deposits come before the first operation, to fund it.
+	first := slices.IndexFunc(extracted.transactions, func(tx Transaction) bool {
+		switch tx.What() {
+		case CmdInit, CmdDeclare, CmdDividend, CmdSplit, CmdUpdatePrice:
+			return false
+		}
+		return true
+	})
+	if first < 0 {
+		first = len(extracted.transactions)
+	}
+	extracted.transactions = slices.Insert(extracted.transactions, first, deposits...)
+	return extracted, extracted.index()
+}
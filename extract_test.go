@@ -0,0 +1,63 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLedger_Extract(t *testing.T) {
+	ibBuy := NewBuy(NewDate(2025, 1, 6), "", "MSFT", Q(2), USD(800))
+	ibBuy.Account = "ib"
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "MSFT", MSFT, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "GOOG", GOOG, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(5000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1500)),
+		NewBuy(NewDate(2025, 1, 3), "", "GOOG", Q(5), USD(1000)),
+		NewSell(NewDate(2025, 1, 5), "", "AAPL", Q(4), USD(700)),
+		NewBuy(NewDate(2025, 1, 6), "", "AAPL", Q(2), USD(400)),
+		ibBuy,
+		NewUpdatePrices(NewDate(2025, 1, 7), map[string]decimal.Decimal{"AAPL": decimal.NewFromInt(190), "GOOG": decimal.NewFromInt(210)}),
+		NewWithdraw(NewDate(2025, 1, 8), "", USD(100)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	extracted, err := ledger.Extract("AAPL", "MSFT")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if _, err := extracted.Fmt(); err != nil {
+		t.Errorf("Extract() is not a valid ledger: %v", err)
+	}
+	if extracted.Security("GOOG") != nil {
+		t.Errorf("Extract() declares GOOG, want only the selected securities")
+	}
+
+	s := extracted.NewSnapshot(NewDate(2025, 1, 31))
+	if got := s.Position("AAPL"); !got.Equal(Q(8)) {
+		t.Errorf("Position(AAPL) = %v, want 8", got)
+	}
+	if got := s.Price("AAPL"); !got.Equal(USD(190)) {
+		t.Errorf("Price(AAPL) = %v, want 190", got)
+	}
+	// The default account needs 1500 for the first buy, then ends with 1500 - 1500 + 700 - 400.
+	if got := s.AccountCash(DefaultAccount, "USD"); !got.Equal(USD(300)) {
+		t.Errorf("AccountCash(default) = %v, want 300 USD", got)
+	}
+	if got := s.AccountCash("ib", "USD"); !got.Equal(USD(0)) {
+		t.Errorf("AccountCash(ib) = %v, want 0 USD", got)
+	}
+	if got := len(extracted.Query().Command(CmdDeposit).Collect()); got != 2 {
+		t.Errorf("Extract() has %d deposits, want the 2 opening deposits", got)
+	}
+
+	if _, err := ledger.Extract("TSLA"); err == nil {
+		t.Errorf("Extract() of an undeclared security: expected an error")
+	}
+}
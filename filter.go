@@ -0,0 +1,471 @@
+package portfolio
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// ParseFilter parses a filter expression into a transaction predicate, to be
+// used with Ledger.Transactions.
+//
+// An expression is made of comparisons "field op value" combined with "and",
+// "or", "not" and parentheses, for instance:
+//
+//	security=AAPL and date>=2024-01-01 and amount>1000
+//	(command=buy or command=sell) and not memo~rebalance
+//
+// The fields are:
+//   - command: the transaction command (buy, sell, dividend, ...);
+//   - date: the transaction date, in any format accepted by ParseDate;
+//   - security: the ticker of a security the transaction is about;
+//   - currency: the currency of the transaction amounts;
+//   - amount: the transaction amount, regardless of its currency;
+//   - quantity: the number of shares or contracts;
+//   - memo: the transaction memo;
+//   - venue: the broker or the exchange of a trade;
+//   - tag: a label or a key of the memo tags, see ParseTags.
+//
+// The operators are =, !=, <, <=, >, >= and ~ (contains, case insensitive).
+//
+// A tag also has a shorthand: "tag:vacation" matches the transactions with the
+// tag vacation, and "tag:trip=japan" those whose tag trip is japan.
+// Values with spaces must be quoted. A transaction without the field, for
+// instance a deposit has no quantity, never matches a comparison on it.
+func ParseFilter(expr string) (func(Transaction) bool, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	accept, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos].text)
+	}
+	return accept, nil
+}
+
+// filterOperators lists the comparison operators, longest first.
+var filterOperators = []string{"<=", ">=", "!=", "=", "<", ">", "~"}
+
+// filterToken is a lexical token of a filter expression.
+type filterToken struct {
+	text   string
+	quoted bool // quoted tokens are always values.
+}
+
+// tokenizeFilter splits a filter expression into words, quoted strings,
+// operators and parentheses.
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	rs := []rune(expr)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, filterToken{text: string(r)})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(rs) && rs[end] != r {
+				end++
+			}
+			if end == len(rs) {
+				return nil, fmt.Errorf("unterminated string in filter %q", expr)
+			}
+			tokens = append(tokens, filterToken{text: string(rs[i+1 : end]), quoted: true})
+			i = end + 1
+		default:
+			if op := operatorAt(rs[i:]); op != "" {
+				tokens = append(tokens, filterToken{text: op})
+				i += len(op)
+				continue
+			}
+			end := i
+			for end < len(rs) && !unicode.IsSpace(rs[end]) && rs[end] != '(' && rs[end] != ')' && operatorAt(rs[end:]) == "" {
+				end++
+			}
+			tokens = append(tokens, filterToken{text: string(rs[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// operatorAt returns the operator at the beginning of rs, or "".
+func operatorAt(rs []rune) string {
+	for _, op := range filterOperators {
+		if strings.HasPrefix(string(rs[:min(len(rs), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// keyword reports whether the next token is the keyword kw, and consumes it.
+func (p *filterParser) keyword(kw string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// next consumes and returns the next token.
+func (p *filterParser) next() (filterToken, error) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) parseOr() (func(Transaction) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tx Transaction) bool { return l(tx) || right(tx) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (func(Transaction) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tx Transaction) bool { return l(tx) && right(tx) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (func(Transaction) bool, error) {
+	if p.keyword("not") {
+		accept, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(tx Transaction) bool { return !accept(tx) }, nil
+	}
+	if p.keyword("(") {
+		accept, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing ')' in filter")
+		}
+		return accept, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (func(Transaction) bool, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := cutTagPrefix(field); ok {
+		return p.parseTag(key)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted || !isFilterOperator(op.text) {
+		return nil, fmt.Errorf("expected an operator after %q, got %q", field.text, op.text)
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(strings.ToLower(field.text), op.text, value.text)
+}
+
+// cutTagPrefix returns the key of a "tag:key" token.
+func cutTagPrefix(t filterToken) (string, bool) {
+	if t.quoted || len(t.text) < len("tag:") || !strings.EqualFold(t.text[:len("tag:")], "tag:") {
+		return "", false
+	}
+	return t.text[len("tag:"):], true
+}
+
+// parseTag parses the rest of a "tag:key" shorthand: alone it matches the
+// transactions with the tag, followed by an operator it compares the value of
+// the tag.
+func (p *filterParser) parseTag(key string) (func(Transaction) bool, error) {
+	if key == "" {
+		return nil, fmt.Errorf("missing tag after \"tag:\" in filter")
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted || !isFilterOperator(p.tokens[p.pos].text) {
+		return func(tx Transaction) bool { return tx.Tags().Has(key) }, nil
+	}
+	op, _ := p.next()
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return compareStrings(op.text, value.text, func(tx Transaction) []string {
+		if v, ok := tx.Tags()[strings.ToLower(key)]; ok {
+			return []string{v}
+		}
+		return nil
+	})
+}
+
+func isFilterOperator(s string) bool {
+	for _, op := range filterOperators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// newComparison returns the predicate for "field op value".
+func newComparison(field, op, value string) (func(Transaction) bool, error) {
+	switch field {
+	case "command", "type":
+		return compareStrings(op, value, func(tx Transaction) []string { return []string{string(tx.What())} })
+	case "security", "ticker":
+		return compareStrings(op, value, securitiesOf)
+	case "currency":
+		return compareStrings(op, value, currenciesOf)
+	case "venue", "broker":
+		return compareStrings(op, value, venuesOf)
+	case "memo":
+		return compareStrings(op, value, func(tx Transaction) []string {
+			if m, ok := tx.(interface{ Rationale() string }); ok {
+				return []string{m.Rationale()}
+			}
+			return nil
+		})
+	case "tag":
+		return compareStrings(op, value, func(tx Transaction) []string { return slices.Collect(maps.Keys(tx.Tags())) })
+	case "date":
+		day, err := ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in filter: %w", err)
+		}
+		return compareOrdered(op, func(tx Transaction) (int, bool) { return tx.When().Compare(day), true })
+	case "amount":
+		v, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q in filter", value)
+		}
+		return compareOrdered(op, func(tx Transaction) (int, bool) {
+			m, ok := amountOf(tx)
+			return m.value.Cmp(v), ok
+		})
+	case "quantity":
+		v, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q in filter", value)
+		}
+		return compareOrdered(op, func(tx Transaction) (int, bool) {
+			q, ok := quantityOf(tx)
+			return q.value.Cmp(v), ok
+		})
+	}
+	return nil, fmt.Errorf("unknown field %q in filter, valid fields are: command, date, security, currency, amount, quantity, memo, venue, tag", field)
+}
+
+// compareStrings returns a predicate that matches when any of the values of a
+// transaction compares to value. Only =, != and ~ are supported.
+func compareStrings(op, value string, values func(Transaction) []string) (func(Transaction) bool, error) {
+	var match func(string) bool
+	switch op {
+	case "=":
+		match = func(s string) bool { return strings.EqualFold(s, value) }
+	case "!=":
+		match = func(s string) bool { return !strings.EqualFold(s, value) }
+	case "~":
+		lower := strings.ToLower(value)
+		match = func(s string) bool { return strings.Contains(strings.ToLower(s), lower) }
+	default:
+		return nil, fmt.Errorf("operator %q is not supported on text fields", op)
+	}
+	return func(tx Transaction) bool {
+		for _, s := range values(tx) {
+			if match(s) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// compareOrdered returns a predicate from a comparison function that returns
+// the sign of the transaction field minus the value, and false if the
+// transaction has no such field.
+func compareOrdered(op string, cmp func(Transaction) (int, bool)) (func(Transaction) bool, error) {
+	var match func(int) bool
+	switch op {
+	case "=":
+		match = func(c int) bool { return c == 0 }
+	case "!=":
+		match = func(c int) bool { return c != 0 }
+	case "<":
+		match = func(c int) bool { return c < 0 }
+	case "<=":
+		match = func(c int) bool { return c <= 0 }
+	case ">":
+		match = func(c int) bool { return c > 0 }
+	case ">=":
+		match = func(c int) bool { return c >= 0 }
+	default:
+		return nil, fmt.Errorf("operator %q is not supported on dates and numbers", op)
+	}
+	return func(tx Transaction) bool {
+		c, ok := cmp(tx)
+		return ok && match(c)
+	}, nil
+}
+
+// securitiesOf returns the tickers of the securities a transaction is about.
+func securitiesOf(tx Transaction) []string {
+	switch v := tx.(type) {
+	case Buy:
+		return []string{v.Security}
+	case Sell:
+		return []string{v.Security}
+	case Dividend:
+		return []string{v.Security}
+	case Split:
+		return []string{v.Security}
+	case Expire:
+		return []string{v.Security}
+	case Assign:
+		return []string{v.Security}
+	case Lend:
+		return []string{v.Security}
+	case Recall:
+		return []string{v.Security}
+	case LendingFee:
+		return []string{v.Security}
+	case Grant:
+		return []string{v.Security}
+	case Vest:
+		return []string{v.Security}
+	case Note:
+		return []string{v.Security}
+	case SetPriceAlert:
+		return []string{v.Security}
+	case ClearPriceAlert:
+		return []string{v.Security}
+	case Declare:
+		return []string{v.Ticker}
+	case UpdatePrice:
+		return slices.Sorted(maps.Keys(v.Prices))
+	case Custom:
+		var tickers []string
+		for _, p := range v.Positions {
+			tickers = append(tickers, p.Security)
+		}
+		return tickers
+	}
+	return nil
+}
+
+// currenciesOf returns the currencies of a transaction.
+func currenciesOf(tx Transaction) []string {
+	switch v := tx.(type) {
+	case Init:
+		return []string{v.Currency}
+	case Declare:
+		return []string{v.Currency}
+	case Convert:
+		return []string{v.FromAmount.Currency(), v.ToAmount.Currency()}
+	}
+	if m, ok := amountOf(tx); ok {
+		return []string{m.Currency()}
+	}
+	return nil
+}
+
+// venuesOf returns the venue of a trade, if known.
+func venuesOf(tx Transaction) []string {
+	var venue string
+	switch v := tx.(type) {
+	case Buy:
+		venue = v.Venue
+	case Sell:
+		venue = v.Venue
+	}
+	if venue == "" {
+		return nil
+	}
+	return []string{venue}
+}
+
+// amountOf returns the amount of a transaction, the amount sold for a conversion.
+func amountOf(tx Transaction) (Money, bool) {
+	switch v := tx.(type) {
+	case Buy:
+		return v.Amount, true
+	case Sell:
+		return v.Amount, true
+	case Dividend:
+		return v.Amount, true
+	case Deposit:
+		return v.Amount, true
+	case Withdraw:
+		return v.Amount, true
+	case Accrue:
+		return v.Amount, true
+	case Convert:
+		return v.FromAmount, true
+	case LendingFee:
+		return v.Amount, true
+	case Vest:
+		return v.Amount, true
+	}
+	return Money{}, false
+}
+
+// quantityOf returns the number of shares or contracts of a transaction.
+func quantityOf(tx Transaction) (Quantity, bool) {
+	switch v := tx.(type) {
+	case Buy:
+		return v.Quantity, true
+	case Sell:
+		return v.Quantity, true
+	case Assign:
+		return v.Quantity, true
+	case Lend:
+		return v.Quantity, true
+	case Recall:
+		return v.Quantity, true
+	case Vest:
+		return v.Quantity, true
+	}
+	return Quantity{}, false
+}
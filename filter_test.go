@@ -0,0 +1,72 @@
+package portfolio
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	sell := NewSell(NewDate(2024, 3, 1), "Rebalance portfolio", "AAPL", Q(8), USD(1600))
+	sell.Venue = "Xetra"
+	txs := []Transaction{
+		NewDeclare(NewDate(2023, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2023, 1, 2), "salary", USD(5000), ""),
+		NewBuy(NewDate(2023, 6, 1), "souvenir #vacation trip=japan", "AAPL", Q(10), USD(1500)),
+		NewBuy(NewDate(2024, 2, 1), "rebalance", "AAPL", Q(5), USD(900)),
+		sell,
+		NewDividend(NewDate(2024, 4, 1), "#Vacation.", "AAPL", USD(0.25)),
+	}
+
+	tests := []struct {
+		filter string
+		want   []int // indexes of the matching transactions.
+	}{
+		{"security=AAPL and date>=2024-01-01 and amount>1000", []int{4}},
+		{"security=aapl", []int{0, 2, 3, 4, 5}},
+		{"(command=buy or command=sell) and memo~rebalance", []int{3, 4}},
+		{"not command=buy and quantity>0", []int{4}},
+		{"memo='salary' or currency!=USD", []int{1}},
+		{"quantity<=5", []int{3}},
+		{"venue=xetra or broker~ib", []int{4}},
+		{"tag:vacation", []int{2, 5}},
+		{"tag:trip=japan", []int{2}},
+		{"tag=trip or tag:trip~jap", []int{2}},
+		{"not tag:vacation and command=buy", []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			accept, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			var got []int
+			for i, tx := range txs {
+				if accept(tx) {
+					got = append(got, i)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseFilter(%q) matches %v, want %v", tt.filter, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseFilter(%q) matches %v, want %v", tt.filter, got, tt.want)
+				}
+			}
+		})
+	}
+
+	for _, filter := range []string{
+		"",
+		"price>10",
+		"amount>abc",
+		"security>AAPL",
+		"(security=AAPL",
+		"security=AAPL and",
+		"memo='unterminated",
+		"security AAPL",
+		"tag:",
+		"tag:trip=",
+	} {
+		if _, err := ParseFilter(filter); err == nil {
+			t.Errorf("ParseFilter(%q) expected an error", filter)
+		}
+	}
+}
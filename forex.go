@@ -0,0 +1,105 @@
+package portfolio
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Currency pairs.
+//
+// Exchange rates are the prices of currency pairs, declared as securities whose
+// ID is the pair, e.g. USDEUR for the price of one US Dollar in Euros. The
+// canonical orientation of a pair prices the foreign currency in the reporting
+// currency of the ledger, but a pair declared in the other orientation (EURUSD
+// in a EUR ledger) is also used to convert amounts.
+
+// CurrencyPair returns the security declared for the exchange rate between two
+// currencies, in either orientation, or nil if there is none. A pair declared
+// in the given orientation, base then quote, is preferred.
+func (l *Ledger) CurrencyPair(base, quote string) *Security {
+	l = l.view()
+	var inverse *Security
+	for _, sec := range l.securities {
+		id := sec.ID()
+		if !id.IsCurrencyPair() {
+			continue
+		}
+		switch {
+		case id.Base() == base && id.Quote() == quote:
+			return &sec
+		case id.Base() == quote && id.Quote() == base && (inverse == nil || sec.Ticker() < inverse.Ticker()):
+			inverse = &sec
+		}
+	}
+	return inverse
+}
+
+// MissingCurrencyPairs returns the declarations of the currency pairs needed to
+// convert every currency of the ledger into its reporting currency, but not
+// declared yet.
+//
+// A currency is needed from its first appearance in a cash movement, or in the
+// declaration of a security or a counterparty account. Its pair is declared on
+// that date, in the canonical orientation, with the pair as ticker.
+func (l *Ledger) MissingCurrencyPairs() []Transaction {
+	l = l.view()
+	var txs []Transaction
+	var seen []string
+	for _, e := range l.journal.events {
+		var currency string
+		switch v := e.(type) {
+		case creditCash:
+			currency = v.currency()
+		case debitCash:
+			currency = v.currency()
+		case declareSecurity:
+			currency = v.currency
+		case declareCounterparty:
+			currency = v.currency
+		}
+		if currency == "" || currency == l.currency || slices.Contains(seen, currency) {
+			continue
+		}
+		seen = append(seen, currency)
+		if l.CurrencyPair(currency, l.currency) != nil {
+			continue
+		}
+		id, err := NewCurrencyPair(currency, l.currency)
+		if err != nil {
+			// Currencies are validated by their transactions.
+			continue
+		}
+		txs = append(txs, NewDeclare(e.date(), "", id.String(), id, l.currency))
+	}
+	return txs
+}
+
+// DeclareCurrencyPairs appends the declarations of the missing currency pairs
+// to the ledger, and returns them.
+func (l *Ledger) DeclareCurrencyPairs() ([]Transaction, error) {
+	txs := l.MissingCurrencyPairs()
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	return txs, l.Append(txs...)
+}
+
+// validateCurrencyPair checks the declaration of a currency pair: its
+// currencies must be valid and different, and the pair must not already be
+// declared in any orientation.
+func validateCurrencyPair(ledger *Ledger, t Declare) error {
+	base, quote, err := t.ID.CurrencyPair()
+	if err != nil {
+		return err
+	}
+	if _, err := NewCurrencyPair(base, quote); err != nil {
+		return err
+	}
+	if base == quote {
+		return fmt.Errorf("currency pair %s must have two different currencies", t.ID)
+	}
+	if sec := ledger.CurrencyPair(base, quote); sec != nil {
+		return fmt.Errorf("currency pair %s already declared as %q", t.ID, sec.Ticker())
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLedger_MissingCurrencyPairs(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, 1, 1), "", "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""),
+		NewDeclare(NewDate(2025, 1, 3), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, 1, 4), "", USD(1000), ""),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	txs, err := ledger.DeclareCurrencyPairs()
+	if err != nil {
+		t.Fatalf("DeclareCurrencyPairs() error = %v", err)
+	}
+	want := NewDeclare(NewDate(2025, 1, 3), "", "USDEUR", USDEUR, "EUR")
+	if len(txs) != 1 || !txs[0].Equal(want) {
+		t.Fatalf("DeclareCurrencyPairs() = %v, want %v", txs, want)
+	}
+	if got := ledger.MissingCurrencyPairs(); len(got) != 0 {
+		t.Errorf("MissingCurrencyPairs() after declaration = %v, want none", got)
+	}
+
+	// A pair in the other orientation is also valid, and can't be declared twice.
+	if sec := ledger.CurrencyPair("EUR", "USD"); sec == nil || sec.Ticker() != "USDEUR" {
+		t.Errorf("CurrencyPair(EUR, USD) = %v, want USDEUR", sec)
+	}
+	EURUSD, _ := NewCurrencyPair("EUR", "USD")
+	if _, err := ledger.Validate(NewDeclare(NewDate(2025, 1, 5), "", "EURUSD", EURUSD, "USD")); err == nil {
+		t.Errorf("Validate() of a pair already declared in the other orientation: expected an error")
+	}
+}
+
+func TestDeclare_ValidateCurrencyPair(t *testing.T) {
+	ledger := NewLedger()
+	tests := []struct {
+		id       ID
+		currency string
+		wantErr  bool
+	}{
+		{"USDEUR", "EUR", false},
+		{"USDEUR", "USD", false},
+		{"EUREUR", "EUR", true},
+	}
+	for _, tt := range tests {
+		tx, err := ledger.Validate(NewDeclare(NewDate(2025, 1, 1), "", tt.id.String(), tt.id, tt.currency))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(declare %s in %s) error = %v, wantErr %v", tt.id, tt.currency, err, tt.wantErr)
+		}
+		// A pair is always priced in its quote currency.
+		if got := tx.(Declare).Currency; err == nil && got != tt.id.Quote() {
+			t.Errorf("Validate(declare %s in %s) currency = %s, want %s", tt.id, tt.currency, got, tt.id.Quote())
+		}
+	}
+}
+
+func TestSnapshot_ExchangeRate_Orientation(t *testing.T) {
+	EURUSD, _ := NewCurrencyPair("EUR", "USD")
+	rate := func(id ID, price Money) Money {
+		ledger := NewLedger()
+		err := ledger.Append(
+			NewInit(NewDate(2025, 1, 1), "", "EUR"),
+			NewDeclare(NewDate(2025, 1, 1), "", id.String(), id, price.Currency()),
+			NewUpdatePrice(NewDate(2025, 1, 2), id.String(), price),
+		)
+		if err != nil {
+			t.Fatalf("ledger.Append() error = %v", err)
+		}
+		return ledger.NewSnapshot(NewDate(2025, 1, 2)).ExchangeRate("USD")
+	}
+	direct, inverse := rate(USDEUR, EUR(0.8)), rate(EURUSD, USD(1.25))
+	if !direct.Equal(EUR(0.8)) || !inverse.Equal(EUR(0.8)) {
+		t.Errorf("ExchangeRate(USD) = %v with USDEUR, %v with EURUSD, want 0.8 EUR", direct, inverse)
+	}
+}
+
+func TestSnapshot_CrossRate(t *testing.T) {
+	pair := func(base, quote string) ID {
+		id, _ := NewCurrencyPair(base, quote)
+		return id
+	}
+	newLedger := func(prices map[ID]float64) *Ledger {
+		ledger := NewLedger()
+		txs := []Transaction{NewInit(NewDate(2025, 1, 1), "", "GBP")}
+		for id, price := range prices {
+			txs = append(txs,
+				NewDeclare(NewDate(2025, 1, 1), "", id.String(), id, id.Quote()),
+				NewUpdatePrice(NewDate(2025, 1, 2), id.String(), M(price, id.Quote())),
+			)
+		}
+		if err := ledger.Append(txs...); err != nil {
+			t.Fatalf("ledger.Append() error = %v", err)
+		}
+		return ledger
+	}
+	on := NewDate(2025, 1, 2)
+
+	// USD in GBP is derived through EUR, whatever the orientation of the pairs.
+	ledger := newLedger(map[ID]float64{pair("EUR", "USD"): 1.1, pair("EUR", "GBP"): 0.85})
+	got := ledger.NewSnapshot(on).ExchangeRate("USD")
+	if want := 0.85 / 1.1; got.Currency() != "GBP" || math.Abs(got.AsFloat()-want) > 1e-12 {
+		t.Errorf("ExchangeRate(USD) = %v, want %v GBP", got, want)
+	}
+	ledger = newLedger(map[ID]float64{pair("USD", "EUR"): 0.9, pair("EUR", "GBP"): 0.85})
+	if got, want := ledger.NewSnapshot(on).ExchangeRate("USD"), M(0.765, "GBP"); !got.Equal(want) {
+		t.Errorf("ExchangeRate(USD) = %v, want %v", got, want)
+	}
+	if got, want := ledger.NewSnapshot(on).CrossRate("EUR", "USD"), 1/0.9; math.Abs(got.AsFloat()-want) > 1e-12 {
+		t.Errorf("CrossRate(EUR, USD) = %v, want %v", got, want)
+	}
+
+	// The pivot selects the path when several are possible.
+	ledger = newLedger(map[ID]float64{
+		pair("EUR", "USD"): 1.1, pair("EUR", "GBP"): 0.85,
+		pair("CHF", "USD"): 1.2, pair("CHF", "GBP"): 0.9,
+	})
+	if got, want := ledger.NewSnapshot(on).WithPivot("CHF").ExchangeRate("USD"), M(0.75, "GBP"); !got.Equal(want) {
+		t.Errorf("ExchangeRate(USD) through CHF = %v, want %v", got, want)
+	}
+	if got := ledger.NewSnapshot(on).CrossRate("JPY", "USD"); !got.IsZero() {
+		t.Errorf("CrossRate(JPY, USD) = %v, want 0", got)
+	}
+}
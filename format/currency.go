@@ -0,0 +1,51 @@
+package format
+
+import (
+	"sync"
+
+	"github.com/Rhymond/go-money"
+)
+
+// declared holds the currencies registered with RegisterCurrency, by code. The
+// currencies of go-money are never modified, so that they can be read
+// concurrently.
+var declared = struct {
+	sync.RWMutex
+	currencies map[string]*money.Currency
+}{currencies: make(map[string]*money.Currency)}
+
+// RegisterCurrency registers a currency that is not an ISO 4217 currency, so
+// that its amounts are formatted with its symbol and decimals.
+func RegisterCurrency(code, symbol string, decimals int) {
+	declared.Lock()
+	defer declared.Unlock()
+	declared.currencies[code] = &money.Currency{Code: code, Grapheme: symbol, Template: "1 $", Decimal: ".", Thousand: ",", Fraction: decimals}
+}
+
+// UnregisterCurrency removes a currency registered with RegisterCurrency.
+func UnregisterCurrency(code string) {
+	declared.Lock()
+	defer declared.Unlock()
+	delete(declared.currencies, code)
+}
+
+// LookupCurrency returns the ISO 4217 currency of a code, or the currency
+// registered with RegisterCurrency, and whether it is known.
+func LookupCurrency(code string) (*money.Currency, bool) {
+	if c := money.GetCurrency(code); c != nil {
+		return c, true
+	}
+	declared.RLock()
+	defer declared.RUnlock()
+	c, ok := declared.currencies[code]
+	return c, ok
+}
+
+// currencyOf returns the currency of a code, the default currency of go-money,
+// with 2 decimals and the code as symbol, if it is unknown.
+func currencyOf(code string) *money.Currency {
+	if c, ok := LookupCurrency(code); ok {
+		return c
+	}
+	return money.New(0, code).Currency()
+}
@@ -0,0 +1,159 @@
+// Package format implements the locale aware formatting of the amounts,
+// numbers, percentages, dates and headings used in reports.
+//
+// The locale is global to the application, it is selected once with SetLocale
+// and defaults to English.
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Locale holds the formatting conventions of a language.
+type Locale struct {
+	Name     string // Name is the locale code, e.g. "fr".
+	Decimal  string // Decimal is the decimal separator.
+	Group    string // Group is the thousands separator.
+	Percent  string // Percent is the suffix of percentages, e.g. " %".
+	Date     string // Date is the layout of dates, as in time.Format.
+	Headings map[string]string
+
+	// native is true when amounts use the currency's own notation (e.g. €1,234.56)
+	// instead of the locale's, with the symbol after the amount (e.g. 1.234,56 €).
+	// Non-breaking spaces are used so that amounts are never wrapped.
+	native bool
+}
+
+var (
+	// EN is the English locale, the default.
+	EN = &Locale{Name: "en", Decimal: ".", Group: ",", Percent: "%", Date: "2006-01-02", native: true}
+	// FR is the French locale.
+	FR = &Locale{Name: "fr", Decimal: ",", Group: "\u202f", Percent: "\u00a0%", Date: "02/01/2006", Headings: fr}
+	// DE is the German locale.
+	DE = &Locale{Name: "de", Decimal: ",", Group: ".", Percent: "\u00a0%", Date: "02.01.2006", Headings: de}
+)
+
+// Locales lists the available locales by name.
+var Locales = map[string]*Locale{"en": EN, "fr": FR, "de": DE}
+
+// current is the locale used by the package level functions.
+var current = EN
+
+// Current returns the current locale.
+func Current() *Locale { return current }
+
+// SetLocale sets the current locale by name (e.g. "fr"). Region suffixes are
+// ignored, so "fr_FR.UTF-8" selects French.
+func SetLocale(name string) error {
+	lang := strings.ToLower(name)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	l, ok := Locales[lang]
+	if !ok {
+		names := make([]string, 0, len(Locales))
+		for n := range Locales {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown locale %q, valid locales are: %s", name, strings.Join(names, ", "))
+	}
+	current = l
+	return nil
+}
+
+// Money formats an amount in a currency using the current locale.
+func Money(value decimal.Decimal, currency string) string { return current.Money(value, currency) }
+
+// SignedMoney formats an amount with an explicit sign using the current locale. Zero is "-".
+func SignedMoney(value decimal.Decimal, currency string) string {
+	return current.SignedMoney(value, currency)
+}
+
+// Number formats a decimal number using the current locale.
+func Number(value decimal.Decimal) string { return current.Number(value) }
+
+// Percent formats a percentage using the current locale.
+func Percent(p float64) string { return current.FormatPercent(p) }
+
+// SignedPercent formats a percentage with an explicit sign using the current locale. Zero is "-".
+func SignedPercent(p float64) string { return current.SignedPercent(p) }
+
+// Date formats a date using the current locale.
+func Date(t time.Time) string { return t.Format(current.Date) }
+
+// T translates a section heading into the current locale.
+func T(heading string) string { return current.T(heading) }
+
+// Money formats an amount in a currency, rounded to the currency's minor unit.
+func (l *Locale) Money(value decimal.Decimal, currency string) string {
+	cur := currencyOf(currency)
+	if l.native {
+		return cur.Formatter().Format(value.Shift(int32(cur.Fraction)).Round(0).IntPart())
+	}
+	s := l.group(value.Abs().StringFixed(int32(cur.Fraction)))
+	if value.IsNegative() && !value.Round(int32(cur.Fraction)).IsZero() {
+		s = "-" + s
+	}
+	return s + "\u00a0" + cur.Grapheme
+}
+
+// SignedMoney formats an amount with an explicit sign. Zero is "-".
+func (l *Locale) SignedMoney(value decimal.Decimal, currency string) string {
+	if value.IsZero() {
+		return "-"
+	}
+	if value.IsPositive() {
+		return "+" + l.Money(value, currency)
+	}
+	return l.Money(value, currency)
+}
+
+// Number formats a decimal number, without grouping the thousands.
+func (l *Locale) Number(value decimal.Decimal) string {
+	return strings.Replace(value.String(), ".", l.Decimal, 1)
+}
+
+// FormatPercent formats a percentage with two decimals.
+func (l *Locale) FormatPercent(p float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", p), ".", l.Decimal, 1) + l.Percent
+}
+
+// SignedPercent formats a percentage with two decimals and an explicit sign. Zero is "-".
+func (l *Locale) SignedPercent(p float64) string {
+	s := fmt.Sprintf("%+.2f", p)
+	if s == "+0.00" {
+		return "-"
+	}
+	return strings.Replace(s, ".", l.Decimal, 1) + l.Percent
+}
+
+// T translates a section heading, or returns it unchanged if there is no translation.
+func (l *Locale) T(heading string) string {
+	if t, ok := l.Headings[heading]; ok {
+		return t
+	}
+	return heading
+}
+
+// group formats the fixed point number s with the locale's separators.
+func (l *Locale) group(s string) string {
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(r)
+	}
+	if hasFraction {
+		b.WriteString(l.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
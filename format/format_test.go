@@ -0,0 +1,67 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLocale_Money(t *testing.T) {
+	tests := []struct {
+		locale   *Locale
+		value    string
+		currency string
+		want     string
+	}{
+		{EN, "1234.56", "EUR", "€1,234.56"},
+		{EN, "-1234.56", "USD", "-$1,234.56"},
+		{FR, "1234.56", "EUR", "1\u202f234,56\u00a0€"},
+		{FR, "-0.5", "USD", "-0,50\u00a0$"},
+		{DE, "1234567.891", "EUR", "1.234.567,89\u00a0€"},
+		{DE, "12", "JPY", "12\u00a0¥"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale.Name+"/"+tt.value, func(t *testing.T) {
+			if got := tt.locale.Money(decimal.RequireFromString(tt.value), tt.currency); got != tt.want {
+				t.Errorf("Money() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_Percent(t *testing.T) {
+	if got, want := FR.SignedPercent(12.5), "+12,50\u00a0%"; got != want {
+		t.Errorf("SignedPercent() = %q, want %q", got, want)
+	}
+	if got, want := EN.FormatPercent(-3.256), "-3.26%"; got != want {
+		t.Errorf("FormatPercent() = %q, want %q", got, want)
+	}
+	if got, want := DE.SignedPercent(0), "-"; got != want {
+		t.Errorf("SignedPercent(0) = %q, want %q", got, want)
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	defer func() { current = EN }()
+
+	if err := SetLocale("fr_FR.UTF-8"); err != nil {
+		t.Fatalf("SetLocale() error = %v", err)
+	}
+	if got, want := T("Securities"), "Titres"; got != want {
+		t.Errorf("T() = %q, want %q", got, want)
+	}
+	if got, want := T("Untranslated"), "Untranslated"; got != want {
+		t.Errorf("T() = %q, want %q", got, want)
+	}
+	if got, want := Date(time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)), "14/03/2025"; got != want {
+		t.Errorf("Date() = %q, want %q", got, want)
+	}
+	if got, want := Number(decimal.RequireFromString("1.5")), "1,5"; got != want {
+		t.Errorf("Number() = %q, want %q", got, want)
+	}
+
+	if err := SetLocale("xx"); err == nil {
+		t.Errorf("SetLocale(xx) expected an error")
+	}
+}
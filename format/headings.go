@@ -0,0 +1,115 @@
+package format
+
+// fr holds the French translation of the report headings.
+var fr = map[string]string{
+	"Accounts":                       "Comptes",
+	"Asset Performance":              "Performance des actifs",
+	"Attribution":                    "Attribution",
+	"Cash":                           "Liquidités",
+	"Cash Accounts":                  "Comptes espèces",
+	"Changes for":                    "Modifications de",
+	"Closed Positions on":            "Positions clôturées au",
+	"Comparison with":                "Comparaison avec",
+	"Contributions":                  "Versements",
+	"Consolidated Asset Performance": "Performance consolidée des actifs",
+	"Consolidated Asset Report":      "Rapport consolidé des actifs",
+	"Consolidated Holding Report on": "Rapport consolidé des positions au",
+	"Consolidated Review for":        "Revue consolidée pour",
+	"Counterparties":                 "Contreparties",
+	"Counterparty Accounts":          "Comptes de contreparties",
+	"Counterparty Interest":          "Intérêts des contreparties",
+	"Currency Breakdown":             "Effet de change",
+	"Currency Review":                "Revue par devise",
+	"Dividends":                      "Dividendes",
+	"Estimated Fees on":              "Frais estimés au",
+	"Financial Independence":         "Indépendance financière",
+	"History for":                    "Historique de",
+	"Intraday Prices on":             "Cours intrajournaliers du",
+	"Log":                            "Journal",
+	"Negative Cash":                  "Découverts",
+	"Non-trading day":                "Marchés fermés",
+	"Holding Report on":              "Rapport des positions au",
+	"Portfolio Summary":              "Synthèse du portefeuille",
+	"Prices for":                     "Cours de",
+	"Projected Crossover":            "Objectif atteint",
+	"Position":                       "Position",
+	"Portfolio Summary on":           "Synthèse du portefeuille au",
+	"Price Freshness":                "Fraîcheur des cours",
+	"Real Terms":                     "En termes réels",
+	"Reconciliation":                 "Rapprochement",
+	"Return Scenarios":               "Scénarios de rendement",
+	"Review for":                     "Revue pour",
+	"Sales":                          "Cessions",
+	"Scores on":                      "Scores au",
+	"Securities":                     "Titres",
+	"Securities Lending":             "Prêt de titres",
+	"Statement for":                  "Relevé de",
+	"Tax Estimate":                   "Estimation de l'impôt",
+	"Tax Report":                     "Rapport fiscal",
+	"Taxable Income":                 "Revenus imposables",
+	"Top Movers":                     "Plus fortes variations",
+	"Transactions":                   "Opérations",
+	"Upcoming Vests":                 "Prochaines acquisitions",
+	"Vesting on":                     "Acquisitions au",
+	"for":                            "pour",
+	"from":                           "du",
+	"on":                             "au",
+	"to":                             "au",
+}
+
+// de holds the German translation of the report headings.
+var de = map[string]string{
+	"Accounts":                       "Konten",
+	"Asset Performance":              "Wertentwicklung der Anlagen",
+	"Attribution":                    "Attribution",
+	"Cash":                           "Barmittel",
+	"Cash Accounts":                  "Geldkonten",
+	"Changes for":                    "Änderungen an",
+	"Closed Positions on":            "Geschlossene Positionen zum",
+	"Comparison with":                "Vergleich mit",
+	"Contributions":                  "Einzahlungen",
+	"Consolidated Asset Performance": "Konsolidierte Wertentwicklung der Anlagen",
+	"Consolidated Asset Report":      "Konsolidierter Anlagebericht",
+	"Consolidated Holding Report on": "Konsolidierter Bestandsbericht zum",
+	"Consolidated Review for":        "Konsolidierter Rückblick für",
+	"Counterparties":                 "Gegenparteien",
+	"Counterparty Accounts":          "Gegenparteikonten",
+	"Counterparty Interest":          "Zinsen der Gegenparteien",
+	"Currency Breakdown":             "Währungseffekt",
+	"Currency Review":                "Rückblick nach Währung",
+	"Dividends":                      "Dividenden",
+	"Estimated Fees on":              "Geschätzte Kosten zum",
+	"Financial Independence":         "Finanzielle Unabhängigkeit",
+	"History for":                    "Verlauf für",
+	"Intraday Prices on":             "Intraday-Kurse vom",
+	"Log":                            "Verlauf",
+	"Negative Cash":                  "Kontoüberziehungen",
+	"Non-trading day":                "Börsen geschlossen",
+	"Holding Report on":              "Bestandsbericht zum",
+	"Portfolio Summary":              "Portfolioübersicht",
+	"Position":                       "Position",
+	"Prices for":                     "Kurse für",
+	"Projected Crossover":            "Voraussichtliches Erreichen",
+	"Portfolio Summary on":           "Portfolioübersicht zum",
+	"Price Freshness":                "Aktualität der Kurse",
+	"Real Terms":                     "Real betrachtet",
+	"Reconciliation":                 "Abstimmung",
+	"Return Scenarios":               "Renditeszenarien",
+	"Review for":                     "Rückblick für",
+	"Sales":                          "Verkäufe",
+	"Scores on":                      "Bewertungen zum",
+	"Securities":                     "Wertpapiere",
+	"Securities Lending":             "Wertpapierleihe",
+	"Statement for":                  "Kontoauszug für",
+	"Tax Estimate":                   "Steuerschätzung",
+	"Tax Report":                     "Steuerbericht",
+	"Taxable Income":                 "Steuerpflichtige Erträge",
+	"Top Movers":                     "Größte Kursbewegungen",
+	"Transactions":                   "Transaktionen",
+	"Upcoming Vests":                 "Anstehende Zuteilungen",
+	"Vesting on":                     "Zuteilungen zum",
+	"for":                            "für",
+	"from":                           "vom",
+	"on":                             "am",
+	"to":                             "bis",
+}
@@ -0,0 +1,353 @@
+// Package frbroker converts the operation exports of French brokers, for PEA
+// and CTO accounts, into transactions.
+//
+// These exports do not fit a csvmap mapping: they are often encoded in
+// Latin-1, the operation names vary ("Achat Comptant", "ACHAT ETRANGER"), the
+// securities are identified by their ISIN, the dividends are paid as a total,
+// and the French financial transaction tax (TTF) is debited as an operation of
+// its own, days after the purchase it applies to.
+//
+// The operations are mapped as follows:
+//
+//   - achat: buy, for the net amount debited, brokerage fees included;
+//   - vente: sell, for the net amount credited;
+//   - coupon, dividende: dividend, per share;
+//   - taxe, TTF: added to the amount of the last purchase of the security, as
+//     the tax is part of its cost;
+//   - versement, virement, dépôt: deposit;
+//   - retrait, frais, droits de garde: withdraw.
+//
+// Other operations are errors.
+package frbroker
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/etnz/portfolio"
+	"github.com/shopspring/decimal"
+)
+
+// Broker describes the operation export of a broker: the header of each
+// column. Optional columns are empty.
+type Broker struct {
+	Name      string
+	Date      string
+	Operation string
+	Label     string // Label is the description of the operation, used as memo.
+	ISIN      string
+	Quantity  string
+	Amount    string // Amount is the net amount of the operation.
+	Currency  string // Currency is optional, EUR by default.
+	Reference string // Reference is optional, used as the key of the transactions.
+}
+
+// Brokers lists the supported brokers.
+var Brokers = []Broker{
+	{
+		Name:      "boursedirect",
+		Date:      "Date",
+		Operation: "Opération",
+		Label:     "Libellé",
+		ISIN:      "Code ISIN",
+		Quantity:  "Quantité",
+		Amount:    "Montant",
+		Currency:  "Devise",
+		Reference: "Référence",
+	},
+	{
+		Name:      "boursorama",
+		Date:      "Date opération",
+		Operation: "Type",
+		Label:     "Libellé",
+		ISIN:      "ISIN",
+		Quantity:  "Quantité",
+		Amount:    "Montant net",
+	},
+}
+
+// Lookup returns the broker with that name.
+func Lookup(name string) (Broker, error) {
+	for _, b := range Brokers {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	names := make([]string, len(Brokers))
+	for i, b := range Brokers {
+		names[i] = b.Name
+	}
+	return Broker{}, fmt.Errorf("unknown broker %q, valid brokers are: %s", name, strings.Join(names, ", "))
+}
+
+// operation is the kind of an operation of an export.
+type operation int
+
+const (
+	opUnknown operation = iota
+	opBuy
+	opSell
+	opDividend
+	opTax
+	opDeposit
+	opWithdraw
+)
+
+// operations maps the first word of the operation names, in lower case and
+// without accents, to their kind.
+var operations = map[string]operation{
+	"achat":     opBuy,
+	"vente":     opSell,
+	"coupon":    opDividend,
+	"coupons":   opDividend,
+	"dividende": opDividend,
+	"taxe":      opTax,
+	"ttf":       opTax,
+	"versement": opDeposit,
+	"virement":  opDeposit,
+	"depot":     opDeposit,
+	"retrait":   opWithdraw,
+	"frais":     opWithdraw,
+	"droits":    opWithdraw,
+}
+
+// parseOperation returns the kind of an operation name.
+func parseOperation(name string) operation {
+	word, _, _ := strings.Cut(strings.TrimSpace(fold(name)), " ")
+	return operations[word]
+}
+
+// accents removes the accents of the French letters in lower case.
+var accents = strings.NewReplacer("à", "a", "â", "a", "ä", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "ô", "o", "ö", "o", "ù", "u", "û", "u", "ü", "u")
+
+// fold returns s in lower case, without accents.
+func fold(s string) string { return accents.Replace(strings.ToLower(s)) }
+
+// Convert reads an export of the broker and converts its operations into
+// transactions for the ledger, in date order. The securities are the
+// securities of the ledger with the same ISIN. Errors are reported with their
+// line in the file, as a portfolio.LineError, all at once.
+func (b Broker) Convert(r io.Reader, ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		data = latin1(data)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing csv header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[fold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, name := range []string{b.Date, b.Operation, b.Amount} {
+		if _, ok := columns[fold(name)]; !ok {
+			return nil, fmt.Errorf("column %q not found in the csv header, is it a %s export?", name, b.Name)
+		}
+	}
+
+	// ISIN to ticker.
+	tickers := make(map[string]string)
+	for sec := range ledger.AllSecurities() {
+		isin, _, err := sec.ID().MSSI()
+		if err != nil {
+			isin, _ = sec.ID().ISIN()
+		}
+		if _, ok := tickers[isin]; isin != "" && !ok {
+			tickers[isin] = sec.Ticker()
+		}
+	}
+
+	var rows []row
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		cell := func(column string) string {
+			if i, ok := columns[fold(column)]; ok && column != "" && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		row, err := b.parse(cell, tickers)
+		if err != nil {
+			errs = append(errs, &portfolio.LineError{Line: line, Err: err})
+			continue
+		}
+		row.line = line
+		rows = append(rows, row)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Exports list the latest operations first. Taxes come after the purchases
+	// of the day they apply to.
+	isTax := func(r row) int {
+		if r.op == opTax {
+			return 1
+		}
+		return 0
+	}
+	slices.SortStableFunc(rows, func(a, b row) int {
+		return cmp.Or(a.date.Compare(b.date), isTax(a)-isTax(b))
+	})
+	return convert(rows)
+}
+
+// row is an operation of an export.
+type row struct {
+	line      int
+	date      portfolio.Date
+	op        operation
+	label     string
+	ticker    string
+	quantity  decimal.Decimal
+	amount    decimal.Decimal // amount is positive.
+	currency  string
+	reference string
+}
+
+// parse parses an operation, given by the value of its cells.
+func (b Broker) parse(cell func(column string) string, tickers map[string]string) (row, error) {
+	r := row{label: cell(b.Label), reference: cell(b.Reference), currency: cell(b.Currency)}
+	if r.currency == "" {
+		r.currency = "EUR"
+	}
+	t, err := time.Parse("02/01/2006", cell(b.Date))
+	if err != nil {
+		return r, fmt.Errorf("invalid date %q, expected dd/mm/yyyy", cell(b.Date))
+	}
+	r.date = portfolio.NewDate(t.Date())
+
+	if r.op = parseOperation(cell(b.Operation)); r.op == opUnknown {
+		return r, fmt.Errorf("unknown operation %q", cell(b.Operation))
+	}
+	if r.amount, err = parseNumber(cell(b.Amount)); err != nil {
+		return r, fmt.Errorf("invalid amount: %w", err)
+	}
+	r.amount = r.amount.Abs()
+
+	switch r.op {
+	case opDeposit, opWithdraw:
+		return r, nil
+	}
+	isin := cell(b.ISIN)
+	if isin == "" {
+		return r, fmt.Errorf("missing the ISIN of the %q operation", cell(b.Operation))
+	}
+	ticker, ok := tickers[isin]
+	if !ok {
+		return r, fmt.Errorf("no security with the ISIN %s in the ledger, declare it first", isin)
+	}
+	r.ticker = ticker
+	if r.op == opTax {
+		return r, nil
+	}
+	if r.quantity, err = parseNumber(cell(b.Quantity)); err != nil {
+		return r, fmt.Errorf("invalid quantity: %w", err)
+	}
+	r.quantity = r.quantity.Abs()
+	if r.quantity.IsZero() {
+		return r, fmt.Errorf("missing the quantity of the %q operation", cell(b.Operation))
+	}
+	return r, nil
+}
+
+// convert converts the operations, in date order, into transactions. Taxes
+// are added to the last purchase of their security.
+func convert(rows []row) ([]portfolio.Transaction, error) {
+	var txs []portfolio.Transaction
+	lastBuy := make(map[string]int) // index in txs of the last purchase of a ticker.
+	var errs []error
+	for _, r := range rows {
+		var tx portfolio.Transaction
+		switch r.op {
+		case opBuy:
+			buy := portfolio.NewBuy(r.date, r.label, r.ticker, portfolio.Q(r.quantity), portfolio.M(r.amount, r.currency))
+			buy.Key = r.reference
+			lastBuy[r.ticker] = len(txs)
+			tx = buy
+		case opSell:
+			sell := portfolio.NewSell(r.date, r.label, r.ticker, portfolio.Q(r.quantity), portfolio.M(r.amount, r.currency))
+			sell.Key = r.reference
+			tx = sell
+		case opDividend:
+			div := portfolio.NewDividend(r.date, r.label, r.ticker, portfolio.M(r.amount.Div(r.quantity), r.currency))
+			div.Key = r.reference
+			tx = div
+		case opDeposit:
+			deposit := portfolio.NewDeposit(r.date, r.label, portfolio.M(r.amount, r.currency), "")
+			deposit.Key = r.reference
+			tx = deposit
+		case opWithdraw:
+			withdraw := portfolio.NewWithdraw(r.date, r.label, portfolio.M(r.amount, r.currency))
+			withdraw.Key = r.reference
+			tx = withdraw
+		case opTax:
+			i, ok := lastBuy[r.ticker]
+			if !ok {
+				errs = append(errs, &portfolio.LineError{Line: r.line, Err: fmt.Errorf("no purchase of %s before the tax", r.ticker)})
+				continue
+			}
+			buy := txs[i].(portfolio.Buy)
+			buy.Amount = buy.Amount.Add(portfolio.M(r.amount, r.currency))
+			buy.Memo = strings.TrimSpace(fmt.Sprintf("%s (%s %s)", buy.Memo, cmp.Or(r.label, "TTF"), portfolio.M(r.amount, r.currency)))
+			txs[i] = buy
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return txs, nil
+}
+
+// parseNumber parses a French number, e.g. "-1 234,56". Without a comma, the
+// point is the decimal separator, e.g. "-1234.56".
+func parseNumber(s string) (decimal.Decimal, error) {
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "€", "").Replace(s)
+	if strings.Contains(s, ",") {
+		s = strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return d, fmt.Errorf("invalid number %q", s)
+	}
+	return d, nil
+}
+
+// latin1 converts Latin-1 text to UTF-8.
+func latin1(data []byte) []byte {
+	var b bytes.Buffer
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	return b.Bytes()
+}
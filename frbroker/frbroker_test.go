@@ -0,0 +1,61 @@
+package frbroker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+func TestBroker_Convert(t *testing.T) {
+	ledger := portfolio.NewLedger()
+	if err := ledger.Append(portfolio.NewDeclare(portfolio.NewDate(2025, 1, 1), "", "AI", "FR0000120073.XPAR", "EUR")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	broker, err := Lookup("boursedirect")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	// Latest operations first, in Latin-1.
+	export := strings.Join([]string{
+		"Date;Opération;Libellé;Code ISIN;Quantité;Montant;Devise;Référence",
+		"15/05/2025;COUPON;COUPON AIR LIQUIDE;FR0000120073;10;32,00;EUR;R4",
+		"06/01/2025;TAXE TRANSACT FINANCIERES;TTF;FR0000120073;;-5,40;EUR;R3",
+		"03/01/2025;Achat Comptant;ACHAT AIR LIQUIDE;FR0000120073;10;-1 805,90;EUR;R2",
+		"02/01/2025;Versement;VERSEMENT PEA;;;2 000,00;EUR;R1",
+		"",
+	}, "\r\n")
+	latin1 := make([]byte, 0, len(export))
+	for _, r := range export {
+		latin1 = append(latin1, byte(r))
+	}
+
+	txs, err := broker.Convert(strings.NewReader(string(latin1)), ledger)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("Convert() = %v, want 3 transactions", txs)
+	}
+	if d, ok := txs[0].(portfolio.Deposit); !ok || !d.Amount.Equal(portfolio.M(2000, "EUR")) || d.Key != "R1" {
+		t.Errorf("Convert()[0] = %v, want a deposit of 2000 EUR", txs[0])
+	}
+	buy, ok := txs[1].(portfolio.Buy)
+	if !ok || buy.Security != "AI" || !buy.Quantity.Equal(portfolio.Q(10)) || !buy.Amount.Equal(portfolio.M(1811.30, "EUR")) {
+		t.Errorf("Convert()[1] = %v, want a buy of 10 AI for 1811.30 EUR, tax included", txs[1])
+	}
+	if d, ok := txs[2].(portfolio.Dividend); !ok || !d.Amount.Equal(portfolio.M(3.2, "EUR")) {
+		t.Errorf("Convert()[2] = %v, want a dividend of 3.20 EUR per share", txs[2])
+	}
+
+	// Unknown operations and securities are reported with their line.
+	_, err = broker.Convert(strings.NewReader(strings.Join([]string{
+		"Date;Opération;Libellé;Code ISIN;Quantité;Montant;Devise",
+		"03/01/2025;Souscription;;FR0000120073;1;-10,00;EUR",
+		"03/01/2025;Achat;;FR0000131104;1;-10,00;EUR",
+	}, "\n")), ledger)
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "FR0000131104") {
+		t.Errorf("Convert() error = %v, want the invalid lines", err)
+	}
+}
@@ -0,0 +1,56 @@
+package portfolio
+
+// PriceFreshness is the freshness of the price of a security held on the date
+// of a snapshot, to tell the positions valued with an old price, or with no
+// price at all.
+type PriceFreshness struct {
+	Ticker string
+	// Date is the date of the last price of the security on or before the date
+	// of the snapshot, zero if there is none.
+	Date Date
+	// Age is the number of days from the last price to the date of the
+	// snapshot.
+	Age int
+	// Holidays is the number of days of Age the exchange of the security was
+	// closed for a holiday, see Exchange.IsHoliday. No price is expected on
+	// those days.
+	Holidays int
+}
+
+// Missing reports whether the security has no price: it is valued at zero.
+func (f PriceFreshness) Missing() bool { return f.Date.IsZero() }
+
+// Stale reports whether the price is missing or older than maxAge days, not
+// counting the holidays of the exchange.
+func (f PriceFreshness) Stale(maxAge int) bool { return f.Missing() || f.Age-f.Holidays > maxAge }
+
+// PriceFreshness returns the freshness of the price of each security held on
+// the date of the snapshot, in the order of Securities.
+func (s *Snapshot) PriceFreshness() []PriceFreshness {
+	last := make(map[string]Date)
+	for e := range s.events() {
+		if u, ok := e.(updatePrice); ok {
+			last[u.security] = u.date()
+		}
+	}
+	var freshness []PriceFreshness
+	for ticker := range s.Securities() {
+		if s.Position(ticker).IsZero() {
+			continue
+		}
+		f := PriceFreshness{Ticker: ticker, Date: last[ticker]}
+		if !f.Missing() {
+			f.Age = s.On().Sub(f.Date)
+			if sec, ok := s.SecurityDetails(ticker); ok {
+				e, _ := sec.Exchange()
+				for day := f.Date.Add(1); !day.After(s.On()); day = day.Add(1) {
+					if e.IsHoliday(day) {
+						f.Holidays++
+					}
+				}
+			}
+		}
+		freshness = append(freshness, f)
+	}
+	return freshness
+}
@@ -0,0 +1,42 @@
+package portfolio
+
+import "testing"
+
+func TestSnapshot_PriceFreshness(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "MSFT", MSFT, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "GOOG", GOOG, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(1000), ""),
+		NewBuy(NewDate(2025, 1, 2), "", "AAPL", Q(1), USD(100)),
+		NewBuy(NewDate(2025, 1, 2), "", "MSFT", Q(1), USD(100)),
+		NewUpdatePrice(NewDate(2025, 1, 10), "AAPL", USD(110)),
+		NewUpdatePrice(NewDate(2025, 1, 20), "AAPL", USD(120)),
+		NewUpdatePrice(NewDate(2025, 1, 10), "GOOG", USD(200)), // not held
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	got := ledger.NewSnapshot(NewDate(2025, 1, 31)).PriceFreshness()
+	want := []PriceFreshness{
+		{Ticker: "AAPL", Date: NewDate(2025, 1, 20), Age: 11},
+		{Ticker: "MSFT"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PriceFreshness() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("PriceFreshness()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if aapl := got[0]; aapl.Missing() || aapl.Stale(11) || !aapl.Stale(10) {
+		t.Errorf("%v: Missing() = %v, Stale(11) = %v, Stale(10) = %v, want false, false, true", aapl, aapl.Missing(), aapl.Stale(11), aapl.Stale(10))
+	}
+	if msft := got[1]; !msft.Missing() || !msft.Stale(1000) {
+		t.Errorf("%v: Missing() = %v, Stale(1000) = %v, want true, true", msft, msft.Missing(), msft.Stale(1000))
+	}
+}
@@ -0,0 +1,296 @@
+// Package fundplatform implements a configurable provider of the net asset
+// values (NAV) of the funds of fund administrator portals, e.g. the employer
+// savings plans.
+//
+// Platforms are configured in the "platforms" section of the config.json file
+// of the portfolio, without Go code:
+//
+//	{
+//	  "platforms": [{
+//	    "name": "Natixis",
+//	    "url": "https://epargnants.example.com/api/funds?date={date}",
+//	    "funds": "$.funds",
+//	    "code": "$.code",
+//	    "date": "$.navDate",
+//	    "nav": "$.nav"
+//	  }]
+//	}
+//
+// The URL returns the NAVs of the funds on a date, in JSON. The funds are
+// selected with a JSONPath in the response, then their code, date and NAV with
+// a JSONPath in each fund. A fund is declared in the ledger with the ID
+// "<name>-<code>", e.g. "Natixis-1234".
+//
+// Portals require an authenticated session: the headers of a request copied
+// from a browser are stored per platform, see SaveSession.
+package fundplatform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/etnz/portfolio"
+	"github.com/shopspring/decimal"
+)
+
+// Platform is the configuration of a fund administrator portal.
+type Platform struct {
+	Name string `json:"name"` // Name of the platform, the prefix of the IDs of its funds.
+	// URL of the NAVs on a date, "{date}" is replaced by the date formatted with
+	// DateFormat.
+	URL        string `json:"url"`
+	DateFormat string `json:"dateFormat,omitempty"` // DateFormat is a Go time layout, 2006-01-02 by default.
+	Funds      string `json:"funds"`                // Funds is the JSONPath of the funds in the response.
+	Code       string `json:"code"`                 // Code is the JSONPath of the code in a fund.
+	Date       string `json:"date,omitempty"`       // Date is the JSONPath of the NAV date in a fund, the date requested by default.
+	NAV        string `json:"nav"`                  // NAV is the JSONPath of the NAV in a fund.
+}
+
+// Source returns the source of the market data fetched from the platform, see
+// portfolio.UpdatePrice.
+func (p Platform) Source() string { return account(p.Name) }
+
+// FundID returns the ID of a fund of the platform.
+func (p Platform) FundID(code string) portfolio.ID { return portfolio.ID(p.Name + "-" + code) }
+
+// FundCode returns the code of a fund of the platform from its ID.
+func (p Platform) FundCode(id portfolio.ID) (code string, ok bool) {
+	return strings.CutPrefix(string(id), p.Name+"-")
+}
+
+func (p Platform) dateFormat() string {
+	if p.DateFormat == "" {
+		return time.DateOnly
+	}
+	return p.DateFormat
+}
+
+// validate checks that the platform is fully configured.
+func (p Platform) validate() error {
+	if p.Name == "" || strings.ContainsAny(p.Name, " .") {
+		return fmt.Errorf("invalid platform name %q: want a single word", p.Name)
+	}
+	if !strings.Contains(p.URL, "{date}") {
+		return fmt.Errorf("platform %s: the url must contain {date}", p.Name)
+	}
+	if p.Funds == "" || p.Code == "" || p.NAV == "" {
+		return fmt.Errorf("platform %s: funds, code and nav are required", p.Name)
+	}
+	return nil
+}
+
+// LoadConfig reads the "platforms" section of the configuration file at path,
+// and registers the session of each platform in the credential store.
+//
+// A missing file is not an error, it results in no platforms.
+func LoadConfig(path string) ([]Platform, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Platforms []Platform `json:"platforms"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse %q: %w", path, err)
+	}
+	for _, p := range file.Platforms {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		register(p.Name)
+	}
+	return file.Platforms, nil
+}
+
+// NAV is the net asset value of a fund on a day.
+type NAV struct {
+	Date  portfolio.Date
+	ID    portfolio.ID
+	Value decimal.Decimal
+}
+
+// NAVs fetches the NAVs of the funds of the platform on a day. NAVs are not
+// necessarily on the day requested, e.g. on week-ends. The funds without a
+// valid code, NAV or date are ignored.
+func (p Platform) NAVs(ctx context.Context, headers http.Header, day portfolio.Date) ([]NAV, error) {
+	uri := strings.ReplaceAll(p.URL, "{date}", day.Format(p.dateFormat()))
+	data, err := Get(ctx, p.Name, uri, headers)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var content any
+	if err := dec.Decode(&content); err != nil {
+		return nil, portfolio.ProviderError(fmt.Errorf("could not decode %s json: %w", p.Name, err))
+	}
+	funds, err := jsonpath.Get(p.Funds, content)
+	if err != nil {
+		return nil, fmt.Errorf("platform %s: funds %q: %w", p.Name, p.Funds, err)
+	}
+	list, ok := funds.([]any)
+	if !ok {
+		return nil, fmt.Errorf("platform %s: funds %q is not a list", p.Name, p.Funds)
+	}
+
+	navs := make([]NAV, 0, len(list))
+	for _, fund := range list {
+		nav, err := p.nav(fund, day)
+		if err != nil {
+			// Portals list funds without NAV, e.g. closed funds.
+			log.Printf("fund ignored: %v", err)
+			continue
+		}
+		navs = append(navs, nav)
+	}
+	return navs, nil
+}
+
+// nav returns the NAV of a fund in the response of a day.
+func (p Platform) nav(fund any, day portfolio.Date) (NAV, error) {
+	nav := NAV{Date: day}
+	code, err := p.field(fund, p.Code)
+	if err != nil {
+		return nav, err
+	}
+	nav.ID = p.FundID(code)
+	value, err := p.field(fund, p.NAV)
+	if err != nil {
+		return nav, err
+	}
+	if nav.Value, err = decimal.NewFromString(value); err != nil {
+		return nav, fmt.Errorf("platform %s: invalid nav of fund %s: %w", p.Name, code, err)
+	}
+	if p.Date != "" {
+		date, err := p.field(fund, p.Date)
+		if err != nil {
+			return nav, err
+		}
+		if nav.Date, err = p.parseDate(date); err != nil {
+			return nav, fmt.Errorf("platform %s: invalid nav date of fund %s: %w", p.Name, code, err)
+		}
+	}
+	return nav, nil
+}
+
+// field returns the value at a JSONPath in a fund, as a string.
+func (p Platform) field(fund any, path string) (string, error) {
+	v, err := jsonpath.Get(path, fund)
+	if err != nil {
+		return "", fmt.Errorf("platform %s: %q: %w", p.Name, path, err)
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("platform %s: %q is not a string or a number: %v", p.Name, path, v)
+}
+
+// parseDate parses a NAV date in the date format of the platform, or in RFC
+// 3339.
+func (p Platform) parseDate(s string) (portfolio.Date, error) {
+	for _, layout := range []string{p.dateFormat(), time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return portfolio.NewDate(t.Date()), nil
+		}
+	}
+	return portfolio.Date{}, fmt.Errorf("unknown date format %q, want %s", s, p.dateFormat())
+}
+
+// Fetch fetches the NAVs of the funds of the platform declared in the ledger
+// and returns the price updates. It fetches the NAVs from today going
+// backwards, until the inception of the funds. If eager is false, it stops as
+// soon as a day brings no new prices.
+func (p Platform) Fetch(ctx context.Context, headers http.Header, ledger *portfolio.Ledger, eager bool) ([]portfolio.Transaction, error) {
+	// Securities of the platform, by ID, and the prices already known.
+	securities := make(map[portfolio.ID][]portfolio.Security)
+	for sec := range ledger.AllSecurities() {
+		if _, ok := p.FundCode(sec.ID()); ok {
+			securities[sec.ID()] = append(securities[sec.ID()], sec)
+		}
+	}
+	if len(securities) == 0 {
+		return nil, nil
+	}
+	type point struct {
+		Date portfolio.Date
+		ID   portfolio.ID
+	}
+	known := make(map[point]decimal.Decimal)
+	inception := ledger.Today()
+	for _, tx := range ledger.Query().All() {
+		switch v := tx.(type) {
+		case portfolio.UpdatePrice:
+			for ticker, price := range v.PricesIter() {
+				if sec := ledger.Security(ticker); sec != nil && len(securities[sec.ID()]) > 0 {
+					known[point{v.Date, sec.ID()}] = price
+				}
+			}
+		case portfolio.Declare:
+			if _, ok := p.FundCode(v.ID); ok && v.Date.Before(inception) {
+				inception = v.Date
+			}
+		}
+	}
+
+	updates := make(map[portfolio.Date]portfolio.UpdatePrice)
+	for d := ledger.Today(); !d.Before(inception); d = d.Add(-1) {
+		navs, err := p.NAVs(ctx, headers, d)
+		if err != nil {
+			// Fail all, otherwise it would create gaps in the data.
+			return nil, err
+		}
+		fresh := false
+		for _, nav := range navs {
+			if len(securities[nav.ID]) == 0 {
+				continue // Not declared in the ledger.
+			}
+			pt := point{nav.Date, nav.ID}
+			if price, ok := known[pt]; ok && price.Equal(nav.Value) {
+				continue
+			}
+			// The NAVs are not added to the known prices: the NAVs of a week-end
+			// day are the NAVs of the Friday, and would stop the fetch before the
+			// Thursday.
+			fresh = true
+			u, ok := updates[nav.Date]
+			if !ok {
+				u = portfolio.NewUpdatePrices(nav.Date, nil)
+				u.Source = p.Source()
+				updates[nav.Date] = u
+			}
+			for _, sec := range securities[nav.ID] {
+				u.Prices[sec.Ticker()] = nav.Value
+			}
+		}
+		if !fresh && !eager {
+			break
+		}
+	}
+
+	transactions := make([]portfolio.Transaction, 0, len(updates))
+	for _, date := range slices.SortedFunc(maps.Keys(updates), portfolio.Date.Compare) {
+		tx, err := ledger.Validate(updates[date])
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,68 @@
+package fundplatform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/etnz/portfolio"
+	"github.com/shopspring/decimal"
+)
+
+func TestPlatform_Fetch(t *testing.T) {
+	t.Setenv("PCS_CACHE_DIR", t.TempDir())
+
+	ledger := portfolio.NewLedger()
+	today := ledger.Today()
+	inception := today.Add(-3)
+	if err := ledger.Append(portfolio.NewDeclare(inception, "", "PEE", "Natixis-1234", "EUR")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// The NAV of a day is 10 plus its distance to the inception.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		day, err := portfolio.ParseDate(r.URL.Query().Get("date"))
+		if err != nil {
+			t.Errorf("invalid date %q", r.URL.Query().Get("date"))
+		}
+		nav := 10 + day.Sub(inception)
+		fmt.Fprintf(w, `{"funds": [{"code": "1234", "navDate": "%s", "nav": %d}, {"code": "9999", "nav": "1"}]}`, day, nav)
+	}))
+	defer srv.Close()
+
+	p := Platform{Name: "Natixis", URL: srv.URL + "/navs?date={date}", Funds: "$.funds", Code: "$.code", Date: "$.navDate", NAV: "$.nav"}
+	if err := p.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	if _, err := p.Fetch(context.Background(), http.Header{}, ledger, false); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Fetch() without session error = %v, want %v", err, ErrSessionExpired)
+	}
+
+	headers := http.Header{"Authorization": []string{"Bearer token"}}
+	txs, err := p.Fetch(context.Background(), headers, ledger, false)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(txs) != 4 {
+		t.Fatalf("Fetch() = %v, want the 4 NAVs since inception", txs)
+	}
+	first := txs[0].(portfolio.UpdatePrice)
+	if first.Date != inception || !first.Prices["PEE"].Equal(decimal.NewFromInt(10)) || first.Source != "natixis" {
+		t.Errorf("Fetch()[0] = %v, want the NAV of 10 on %s from natixis", first, inception)
+	}
+	if _, err := ledger.UpdateMarketData(txs...); err != nil {
+		t.Fatalf("UpdateMarketData() error = %v", err)
+	}
+
+	if txs, err = p.Fetch(context.Background(), headers, ledger, false); err != nil || len(txs) != 0 {
+		t.Errorf("Fetch() = %v, %v, want no new NAVs", txs, err)
+	}
+}
@@ -0,0 +1,111 @@
+package fundplatform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/auth"
+	"github.com/etnz/portfolio/network"
+)
+
+// ErrSessionExpired is returned when a platform rejects the session headers.
+var ErrSessionExpired = errors.New("session expired or invalid")
+
+// account returns the credential store account of the session of a platform.
+func account(platform string) string { return strings.ToLower(platform) }
+
+// register registers the session of a platform in the credential store, see
+// auth.Register.
+func register(platform string) {
+	auth.Register(auth.Provider{
+		Name:        account(platform),
+		Env:         "PCS_" + strings.ToUpper(platform) + "_SESSION",
+		Description: platform + " session headers, see 'pcs platform login'",
+	})
+}
+
+// sessionFile returns the temporary file of the session of a platform, used
+// when no keyring is available.
+func sessionFile(platform string) string {
+	return filepath.Join(os.TempDir(), "pcs-"+account(platform)+"-session")
+}
+
+// SaveSession stores the session headers of a platform, one "Name: value" per
+// line, in the credential store. When no keyring is available, they are stored
+// in a temporary file instead.
+func SaveSession(platform string, headers []string) error {
+	register(platform)
+	session := strings.Join(headers, "\n")
+	if err := auth.Set(account(platform), session); err == nil {
+		return nil
+	}
+	return os.WriteFile(sessionFile(platform), []byte(session), 0600)
+}
+
+// LoadSession loads the session headers of a platform from the credential
+// store, or from the temporary file written by SaveSession.
+func LoadSession(platform string) (http.Header, error) {
+	register(platform)
+	session, err := auth.Get(account(platform))
+	if err != nil {
+		data, ferr := os.ReadFile(sessionFile(platform))
+		if ferr != nil {
+			return nil, fmt.Errorf("%s session not found: %w", platform, err)
+		}
+		session = string(data)
+	}
+
+	headers := make(http.Header)
+	scanner := bufio.NewScanner(strings.NewReader(session))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return headers, nil
+}
+
+// Get retrieves a payload from a platform with the session headers. Responses
+// are not cached: they are the private data of the account of the session.
+//
+// Errors are portfolio.ProviderError, a 401 or 403 status is reported as
+// ErrSessionExpired.
+func Get(ctx context.Context, platform, uri string, headers http.Header) ([]byte, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create http request %q: %w", uri, err)
+	}
+	r.Header = headers
+
+	resp, err := network.NewClient().Do(r)
+	if err != nil {
+		log.Printf("URI=%s", uri)
+		return nil, portfolio.ProviderError(fmt.Errorf("cannot execute http request: %w", err))
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, portfolio.ProviderError(fmt.Errorf("%s: %w (%s), log in again", platform, ErrSessionExpired, resp.Status))
+	case resp.StatusCode >= 300:
+		log.Printf("URI=%s", uri)
+		return nil, portfolio.ProviderError(fmt.Errorf("cannot http GET %s: %s", r.URL.Host+r.URL.Path, resp.Status))
+	}
+
+	// reading in a buffer to be able to print the json in debug mode
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		return nil, fmt.Errorf("cannot read receiving http body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
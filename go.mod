@@ -7,9 +7,11 @@ require (
 	github.com/Rhymond/go-money v1.0.15
 	github.com/charmbracelet/glamour v0.10.0
 	github.com/google/subcommands v1.2.0
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/posener/complete/v2 v2.1.0
 	github.com/shopspring/decimal v1.4.0
 	github.com/yuin/goldmark v1.7.13
+	golang.org/x/term v0.31.0
 	google.golang.org/genai v1.25.0
 )
 
@@ -48,7 +50,6 @@ require (
 	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/term v0.31.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
 	google.golang.org/grpc v1.66.2 // indirect
@@ -0,0 +1,90 @@
+package portfolio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GrepMatch is a ledger line whose transaction matches a search.
+type GrepMatch struct {
+	Ledger      string // Ledger is the ledger name.
+	File        string // File is the path of the ledger file.
+	Line        int    // Line is the 1-based line number in the file.
+	Transaction Transaction
+}
+
+// Grep searches the memos, counterparty names and tickers of the transactions of
+// the ledgers in path. The query selects the ledgers like FindLedgers does.
+//
+// Matches are reported in file order, with their line number, so that they
+// can be located and edited in the ledger files.
+func Grep(path, query string, re *regexp.Regexp) ([]GrepMatch, error) {
+	files, err := findLedgerPaths(path, query)
+	if err != nil {
+		return nil, err
+	}
+	var matches []GrepMatch
+	for _, file := range files {
+		m, err := grepFile(path, file, re)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m...)
+	}
+	return matches, nil
+}
+
+// grepFile searches a single ledger file.
+func grepFile(portfolioPath, file string, re *regexp.Regexp) ([]GrepMatch, error) {
+	relPath, err := filepath.Rel(portfolioPath, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine relative path for %q: %w", file, err)
+	}
+	ledger := strings.TrimSuffix(relPath, ".jsonl")
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open ledger file %q: %w", file, err)
+	}
+	defer f.Close()
+
+	var matches []GrepMatch
+	d := NewDecoder(f)
+	for {
+		tx, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, s := range searchableText(tx) {
+			if re.MatchString(s) {
+				matches = append(matches, GrepMatch{Ledger: ledger, File: file, Line: d.Line(), Transaction: tx})
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// searchableText returns the memo, counterparty names and tickers of a transaction.
+func searchableText(tx Transaction) []string {
+	var texts []string
+	if m, ok := tx.(interface{ Rationale() string }); ok && m.Rationale() != "" {
+		texts = append(texts, m.Rationale())
+	}
+	switch v := tx.(type) {
+	case Accrue:
+		texts = append(texts, v.Counterparty)
+	case Deposit:
+		texts = append(texts, v.Settles)
+	case Withdraw:
+		texts = append(texts, v.Settles)
+	}
+	return append(texts, securitiesOf(tx)...)
+}
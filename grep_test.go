@@ -0,0 +1,48 @@
+package portfolio
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"command":"init","date":"2025-01-01","currency":"EUR"}
+{"command":"declare","date":"2025-01-01","ticker":"AAPL","id":"US0378331005.XNAS","currency":"USD"}
+
+{"command":"accrue","date":"2025-01-02","counterparty":"Landlord","currency":"EUR","amount":-800,"create":true}
+{"command":"deposit","date":"2025-01-03","memo":"Salary from ACME","currency":"EUR","amount":3000}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "john"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "john", "bnp.jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		lines   []int
+	}{
+		{"(?i)acme", []int{5}},
+		{"^AAPL$", []int{2}},
+		{"Land", []int{4}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		matches, err := Grep(dir, "", regexp.MustCompile(tt.pattern))
+		if err != nil {
+			t.Fatalf("Grep(%q) error = %v", tt.pattern, err)
+		}
+		if len(matches) != len(tt.lines) {
+			t.Fatalf("Grep(%q) = %v, want lines %v", tt.pattern, matches, tt.lines)
+		}
+		for i, m := range matches {
+			if m.Line != tt.lines[i] || m.Ledger != "john/bnp" {
+				t.Errorf("Grep(%q)[%d] = %s:%d, want john/bnp:%d", tt.pattern, i, m.Ledger, m.Line, tt.lines[i])
+			}
+		}
+	}
+}
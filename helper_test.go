@@ -1,8 +1,14 @@
 package portfolio
 
+func init() {
+	// Catch amounts that are summed without being converted first.
+	StrictCurrency = true
+}
+
 var (
 	AAPL, _   = NewMSSI("US0378331005", "XNAS")
 	GOOG, _   = NewMSSI("US38259P5089", "XNAS")
+	MSFT, _   = NewMSSI("US5949181045", "XNAS")
 	USDEUR, _ = NewCurrencyPair("USD", "EUR")
 )
 
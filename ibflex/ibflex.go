@@ -0,0 +1,321 @@
+// Package ibflex pulls the activity of Interactive Brokers accounts from the
+// Flex Web Service and converts it into transactions.
+//
+// A Flex Query is defined once in the IB Client Portal (Performance & Reports >
+// Flex Queries), with the Trades and Cash Transactions sections, in XML. The
+// Flex Web Service gives a token to run it: the statement is requested with the
+// token and the query ID, then downloaded once generated.
+//
+// Trades of stocks and funds are buys and sells for their net cash, fees
+// included; trades of currencies are conversions. Cash transactions are
+// dividends, per share, deposits and withdrawals; withholding taxes, fees and
+// interest are withdrawals or deposits of their amount. Each transaction is
+// keyed by its IB identifier, to recognize the transactions already recorded.
+package ibflex
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/network"
+	"github.com/shopspring/decimal"
+)
+
+// SendRequestURL is the address of the Flex Web Service requesting a
+// statement.
+var SendRequestURL = "https://ndcdyn.interactivebrokers.com/AccountManagement/FlexWebService/SendRequest"
+
+// Poll is the delay between two attempts to download a statement being
+// generated.
+var Poll = 5 * time.Second
+
+// errInProgress is the error code of a statement being generated.
+const errInProgress = "1019"
+
+// response is the response of the Flex Web Service to a request, or to a
+// download before the statement is generated.
+type response struct {
+	XMLName       xml.Name `xml:"FlexStatementResponse"`
+	Status        string   `xml:"Status"`
+	ReferenceCode string   `xml:"ReferenceCode"`
+	URL           string   `xml:"Url"`
+	ErrorCode     string   `xml:"ErrorCode"`
+	ErrorMessage  string   `xml:"ErrorMessage"`
+}
+
+// Fetch runs a Flex Query and returns its statement, in XML. It waits for the
+// statement to be generated, until the context is cancelled.
+func Fetch(ctx context.Context, token, query string) ([]byte, error) {
+	params := url.Values{"t": {token}, "q": {query}, "v": {"3"}}
+	data, err := get(ctx, SendRequestURL+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var r response
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, portfolio.ProviderError(fmt.Errorf("invalid flex response: %w", err))
+	}
+	if r.Status != "Success" {
+		return nil, portfolio.ProviderError(fmt.Errorf("flex request failed: %s (code %s)", r.ErrorMessage, r.ErrorCode))
+	}
+
+	params.Set("q", r.ReferenceCode)
+	for {
+		data, err := get(ctx, r.URL+"?"+params.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var pending response
+		if xml.Unmarshal(data, &pending) != nil {
+			return data, nil // The statement.
+		}
+		if pending.ErrorCode != errInProgress {
+			return nil, portfolio.ProviderError(fmt.Errorf("flex statement failed: %s (code %s)", pending.ErrorMessage, pending.ErrorCode))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(Poll):
+		}
+	}
+}
+
+// get retrieves a payload.
+func get(ctx context.Context, uri string) ([]byte, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The Flex Web Service rejects requests without a user agent.
+	r.Header.Set("User-Agent", "pcs")
+	resp, err := network.NewClient().Do(r)
+	if err != nil {
+		return nil, portfolio.ProviderError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, portfolio.ProviderError(fmt.Errorf("cannot http GET %s: %s", r.URL.Host+r.URL.Path, resp.Status))
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Statement is the content of a Flex Query statement.
+type Statement struct {
+	Trades           []Trade           `xml:"FlexStatements>FlexStatement>Trades>Trade"`
+	CashTransactions []CashTransaction `xml:"FlexStatements>FlexStatement>CashTransactions>CashTransaction"`
+}
+
+// Trade is a trade of a statement.
+type Trade struct {
+	ID            string `xml:"tradeID,attr"`
+	AssetCategory string `xml:"assetCategory,attr"` // STK, FUND, CASH...
+	Symbol        string `xml:"symbol,attr"`
+	ISIN          string `xml:"isin,attr"`
+	Currency      string `xml:"currency,attr"`
+	TradeDate     string `xml:"tradeDate,attr"` // yyyyMMdd
+	BuySell       string `xml:"buySell,attr"`   // BUY or SELL
+	Quantity      string `xml:"quantity,attr"`
+	NetCash       string `xml:"netCash,attr"` // NetCash is the cash paid or received, commissions included.
+	Description   string `xml:"description,attr"`
+}
+
+// CashTransaction is a cash transaction of a statement.
+type CashTransaction struct {
+	ID          string `xml:"transactionID,attr"`
+	Type        string `xml:"type,attr"`
+	Symbol      string `xml:"symbol,attr"`
+	ISIN        string `xml:"isin,attr"`
+	Currency    string `xml:"currency,attr"`
+	DateTime    string `xml:"dateTime,attr"` // yyyyMMdd, followed by the time.
+	Amount      string `xml:"amount,attr"`
+	Description string `xml:"description,attr"`
+}
+
+// Parse parses a statement in XML.
+func Parse(data []byte) (*Statement, error) {
+	var s Statement
+	if err := xml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid flex statement: %w", err)
+	}
+	return &s, nil
+}
+
+// perShare extracts the dividend per share of the description of a dividend,
+// e.g. "AAPL(US0378331005) CASH DIVIDEND USD 0.25 PER SHARE (Ordinary Dividend)".
+var perShare = regexp.MustCompile(`([A-Z]{3}) ([0-9.]+) PER SHARE`)
+
+// Convert converts the trades and cash transactions of a statement into
+// transactions for the ledger, in date order. The securities are the
+// securities of the ledger with the same ISIN, or the same ticker as the IB
+// symbol. Errors are reported all at once.
+func (s *Statement) Convert(ledger *portfolio.Ledger) ([]portfolio.Transaction, error) {
+	tickers := make(map[string]string) // ISIN or symbol to ticker.
+	for sec := range ledger.AllSecurities() {
+		tickers[sec.Ticker()] = sec.Ticker()
+		isin, _, err := sec.ID().MSSI()
+		if err != nil {
+			isin, _ = sec.ID().ISIN()
+		}
+		if _, ok := tickers[isin]; isin != "" && !ok {
+			tickers[isin] = sec.Ticker()
+		}
+	}
+	ticker := func(isin, symbol string) (string, error) {
+		if t, ok := tickers[isin]; ok && isin != "" {
+			return t, nil
+		}
+		if t, ok := tickers[symbol]; ok {
+			return t, nil
+		}
+		return "", fmt.Errorf("no security with the ISIN %q or the ticker %q in the ledger, declare it first", isin, symbol)
+	}
+
+	var txs []portfolio.Transaction
+	var errs []error
+	// Cash transactions first: deposits fund the trades of the day.
+	for _, c := range s.CashTransactions {
+		tx, err := c.convert(ticker)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cash transaction %s: %w", c.ID, err))
+			continue
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	for _, t := range s.Trades {
+		tx, err := t.convert(ticker)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("trade %s: %w", t.ID, err))
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	slices.SortStableFunc(txs, func(a, b portfolio.Transaction) int { return a.When().Compare(b.When()) })
+	return txs, nil
+}
+
+// convert converts a trade into a transaction.
+func (t Trade) convert(ticker func(isin, symbol string) (string, error)) (portfolio.Transaction, error) {
+	date, err := parseDate(t.TradeDate)
+	if err != nil {
+		return nil, err
+	}
+	quantity, err := decimal.NewFromString(t.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity %q", t.Quantity)
+	}
+	cash, err := decimal.NewFromString(t.NetCash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid net cash %q", t.NetCash)
+	}
+	quantity, cash = quantity.Abs(), cash.Abs()
+
+	switch t.AssetCategory {
+	case "STK", "FUND":
+	case "CASH":
+		// The symbol is the currency pair, e.g. EUR.USD.
+		base, quote, ok := strings.Cut(t.Symbol, ".")
+		if !ok {
+			return nil, fmt.Errorf("invalid currency pair %q", t.Symbol)
+		}
+		tx := portfolio.NewConvert(date, t.Description, portfolio.M(cash, quote), portfolio.M(quantity, base))
+		if t.BuySell == "SELL" {
+			tx = portfolio.NewConvert(date, t.Description, portfolio.M(quantity, base), portfolio.M(cash, quote))
+		}
+		tx.Key = "ib:" + t.ID
+		return tx, nil
+	default:
+		return nil, fmt.Errorf("unsupported asset category %q", t.AssetCategory)
+	}
+
+	security, err := ticker(t.ISIN, t.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	switch t.BuySell {
+	case "BUY":
+		tx := portfolio.NewBuy(date, t.Description, security, portfolio.Q(quantity), portfolio.M(cash, t.Currency))
+		tx.Key = "ib:" + t.ID
+		return tx, nil
+	case "SELL":
+		tx := portfolio.NewSell(date, t.Description, security, portfolio.Q(quantity), portfolio.M(cash, t.Currency))
+		tx.Key = "ib:" + t.ID
+		return tx, nil
+	}
+	return nil, fmt.Errorf("unknown side %q", t.BuySell)
+}
+
+// convert converts a cash transaction into a transaction, nil for the
+// transactions without cash movement.
+func (c CashTransaction) convert(ticker func(isin, symbol string) (string, error)) (portfolio.Transaction, error) {
+	day, _, _ := strings.Cut(c.DateTime, ";")
+	date, err := parseDate(day)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := decimal.NewFromString(c.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", c.Amount)
+	}
+	if amount.IsZero() {
+		return nil, nil
+	}
+	key := "ib:" + c.ID
+
+	if c.Type == "Dividends" || c.Type == "Payment In Lieu Of Dividends" {
+		security, err := ticker(c.ISIN, c.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		m := perShare.FindStringSubmatch(c.Description)
+		if m == nil {
+			return nil, fmt.Errorf("no dividend per share in %q", c.Description)
+		}
+		value, err := decimal.NewFromString(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid dividend per share in %q", c.Description)
+		}
+		tx := portfolio.NewDividend(date, c.Description, security, portfolio.M(value, m[1]))
+		tx.Key = key
+		return tx, nil
+	}
+
+	// Deposits, withdrawals, withholding taxes, fees and interest.
+	if amount.IsPositive() {
+		tx := portfolio.NewDeposit(date, c.Description, portfolio.M(amount, c.Currency), "")
+		tx.Key = key
+		return tx, nil
+	}
+	tx := portfolio.NewWithdraw(date, c.Description, portfolio.M(amount.Abs(), c.Currency))
+	tx.Key = key
+	return tx, nil
+}
+
+// parseDate parses a date of a statement, in the yyyyMMdd or yyyy-MM-dd format.
+func parseDate(s string) (portfolio.Date, error) {
+	for _, layout := range []string{"20060102", time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return portfolio.NewDate(t.Date()), nil
+		}
+	}
+	return portfolio.Date{}, fmt.Errorf("invalid date %q", s)
+}
@@ -0,0 +1,94 @@
+package ibflex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etnz/portfolio"
+)
+
+const statement = `<FlexQueryResponse queryName="pcs" type="AF">
+<FlexStatements count="1">
+<FlexStatement accountId="U1234567" fromDate="20250102" toDate="20250331">
+<Trades>
+<Trade tradeID="11" assetCategory="CASH" symbol="EUR.USD" currency="USD" tradeDate="20250102" buySell="SELL" quantity="-1000" netCash="1030" description="EUR.USD"/>
+<Trade tradeID="12" assetCategory="STK" symbol="AAPL" isin="US0378331005" currency="USD" tradeDate="20250103" buySell="BUY" quantity="4" netCash="-1001" description="APPLE INC"/>
+</Trades>
+<CashTransactions>
+<CashTransaction transactionID="21" type="Deposits/Withdrawals" currency="EUR" dateTime="20250102" amount="1000" description="CASH RECEIPTS"/>
+<CashTransaction transactionID="22" type="Dividends" symbol="AAPL" isin="US0378331005" currency="USD" dateTime="20250213;202000" amount="1" description="AAPL(US0378331005) CASH DIVIDEND USD 0.25 PER SHARE (Ordinary Dividend)"/>
+<CashTransaction transactionID="23" type="Withholding Tax" symbol="AAPL" isin="US0378331005" currency="USD" dateTime="20250213;202000" amount="-0.15" description="AAPL(US0378331005) CASH DIVIDEND USD 0.25 PER SHARE - US TAX"/>
+</CashTransactions>
+</FlexStatement>
+</FlexStatements>
+</FlexQueryResponse>`
+
+func TestFetch(t *testing.T) {
+	Poll = time.Millisecond
+	downloads := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "token" {
+			t.Errorf("request without the token: %s", r.URL)
+		}
+		switch r.URL.Path {
+		case "/SendRequest":
+			fmt.Fprintf(w, `<FlexStatementResponse><Status>Success</Status><ReferenceCode>42</ReferenceCode><Url>%s/GetStatement</Url></FlexStatementResponse>`, srv.URL)
+		case "/GetStatement":
+			if downloads++; downloads == 1 {
+				fmt.Fprint(w, `<FlexStatementResponse><Status>Warn</Status><ErrorCode>1019</ErrorCode><ErrorMessage>Statement generation in progress.</ErrorMessage></FlexStatementResponse>`)
+				return
+			}
+			fmt.Fprint(w, statement)
+		}
+	}))
+	defer srv.Close()
+	SendRequestURL = srv.URL + "/SendRequest"
+
+	data, err := Fetch(context.Background(), "token", "123")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(data) != statement || downloads != 2 {
+		t.Errorf("Fetch() = %q after %d downloads, want the statement after 2", data, downloads)
+	}
+}
+
+func TestStatement_Convert(t *testing.T) {
+	s, err := Parse([]byte(statement))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ledger := portfolio.NewLedger()
+	if err := ledger.Append(portfolio.NewDeclare(portfolio.NewDate(2025, 1, 1), "", "AAPL", "US0378331005.XNAS", "USD")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	txs, err := s.Convert(ledger)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := []string{
+		`{"command":"deposit","date":"2025-01-02","memo":"CASH RECEIPTS","key":"ib:21","currency":"EUR","amount":1000}`,
+		`{"command":"convert","date":"2025-01-02","memo":"EUR.USD","key":"ib:11","fromCurrency":"EUR","fromAmount":1000,"toCurrency":"USD","toAmount":1030}`,
+		`{"command":"buy","date":"2025-01-03","memo":"APPLE INC","key":"ib:12","security":"AAPL","quantity":4,"currency":"USD","amount":1001}`,
+		`{"command":"dividend","date":"2025-02-13","memo":"AAPL(US0378331005) CASH DIVIDEND USD 0.25 PER SHARE (Ordinary Dividend)","key":"ib:22","security":"AAPL","currency":"USD","amount":0.25}`,
+		`{"command":"withdraw","date":"2025-02-13","memo":"AAPL(US0378331005) CASH DIVIDEND USD 0.25 PER SHARE - US TAX","key":"ib:23","currency":"USD","amount":0.15}`,
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("Convert() = %v, want %d transactions", txs, len(want))
+	}
+	for i, tx := range txs {
+		var got strings.Builder
+		if err := portfolio.EncodeTransaction(&got, tx); err != nil {
+			t.Fatalf("EncodeTransaction() error = %v", err)
+		}
+		if strings.TrimSpace(got.String()) != want[i] {
+			t.Errorf("Convert()[%d] = %s\nwant %s", i, got.String(), want[i])
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package portfolio
+
+import "fmt"
+
+// Inflation returns the change, in percent, of a consumer price index (CPI)
+// over the review period.
+//
+// The CPI is a security declared in the ledger whose prices are the values of
+// the index, e.g. a series fetched from INSEE. Its value at the start and at
+// the end of the period is the last one known on these dates, even before its
+// declaration.
+func (r *Review) Inflation(cpi string) (Percent, error) {
+	if _, ok := r.end.SecurityDetails(cpi); !ok {
+		return 0, fmt.Errorf("consumer price index %q is not declared in ledger %q", cpi, r.Name())
+	}
+	start, end := indexValue(r.start, cpi), indexValue(r.end, cpi)
+	if start == 0 {
+		return 0, fmt.Errorf("no value of consumer price index %q on %s", cpi, r.start.On())
+	}
+	return Percent(100 * (end/start - 1)), nil
+}
+
+// indexValue returns the last known price of a security on the snapshot date.
+func indexValue(s *Snapshot, ticker string) float64 {
+	var value float64
+	for e := range s.events() {
+		if u, ok := e.(updatePrice); ok && u.security == ticker {
+			value = u.price.AsFloat()
+		}
+	}
+	return value
+}
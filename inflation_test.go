@@ -0,0 +1,38 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReview_Inflation(t *testing.T) {
+	cpi, _ := NewPrivate("INSEE-001759970")
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewUpdatePrice(NewDate(2024, 12, 31), "CPI", M(120, "EUR")),
+		NewInit(NewDate(2025, 1, 1), "", "EUR"),
+		NewDeclare(NewDate(2025, 1, 1), "", "CPI", cpi, "EUR"),
+		NewUpdatePrice(NewDate(2025, 5, 31), "CPI", M(121.8, "EUR")),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// The value at the start of the period precedes the declaration of the CPI.
+	r := ledger.NewReview(NewRange(NewDate(2025, 1, 1), NewDate(2025, 6, 30)))
+	got, err := r.Inflation("CPI")
+	if err != nil {
+		t.Fatalf("Inflation() error = %v", err)
+	}
+	if math.Abs(float64(got)-1.5) > 1e-9 {
+		t.Errorf("Inflation() = %v, want 1.5%%", got)
+	}
+
+	if _, err := r.Inflation("HICP"); err == nil {
+		t.Errorf("Inflation() of an undeclared index: expected an error")
+	}
+	r = ledger.NewReview(NewRange(NewDate(2024, 12, 1), NewDate(2024, 12, 30)))
+	if _, err := r.Inflation("CPI"); err == nil {
+		t.Errorf("Inflation() without a start value: expected an error")
+	}
+}
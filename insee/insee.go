@@ -3,28 +3,44 @@ package insee
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/cache"
 	"github.com/shopspring/decimal"
 )
 
 const inseePrefix = "INSEE-"
 
+// Source is the source of the market data fetched from insee.fr, see
+// portfolio.UpdatePrice.
+const Source = "insee"
+
+// CPI is the ID of the monthly consumer price index of all households in
+// France (base 2015). Declared as a security, it deflates reviews in real
+// terms.
+const CPI portfolio.ID = inseePrefix + "001759970"
+
 // Fetch retrieves market data from INSEE for the requested securities and date ranges.
-func Fetch(ledger *portfolio.Ledger, inception bool) ([]portfolio.Transaction, error) {
+func Fetch(ctx context.Context, ledger *portfolio.Ledger, inception bool) ([]portfolio.Transaction, error) {
 	var updates []portfolio.Transaction
 	var errs error
 
 	for sec := range ledger.AllSecurities() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		id := sec.ID()
 		idStr := string(id)
 		if !strings.HasPrefix(idStr, inseePrefix) {
@@ -45,14 +61,16 @@ func Fetch(ledger *portfolio.Ledger, inception bool) ([]portfolio.Transaction, e
 
 		idBank := strings.TrimPrefix(idStr, inseePrefix)
 
-		series, err := getSeries(idBank, from, to)
+		series, err := getSeries(ctx, idBank, from, to)
 		if err != nil {
 			errs = errors.Join(errs, fmt.Errorf("failed to get series for INSEE ID %s: %w", id, err))
 			continue
 		}
 
-		for date, price := range series.Values {
+		for _, date := range slices.SortedFunc(maps.Keys(series.Values), portfolio.Date.Compare) {
+			price := series.Values[date]
 			tx := portfolio.NewUpdatePrice(date, sec.Ticker(), portfolio.M(decimal.NewFromFloat(price), sec.Currency()))
+			tx.Source = Source
 			updates = append(updates, tx)
 		}
 	}
@@ -60,7 +78,7 @@ func Fetch(ledger *portfolio.Ledger, inception bool) ([]portfolio.Transaction, e
 }
 
 // getSeries constructs the URL, downloads, and parses an INSEE time series.
-func getSeries(idBank string, from, to portfolio.Date) (*Series, error) {
+func getSeries(ctx context.Context, idBank string, from, to portfolio.Date) (*Series, error) {
 	startYear, startPeriod := from.Year(), from.Quarter()
 	endYear, endPeriod := to.Year(), to.Quarter()
 
@@ -71,26 +89,19 @@ func getSeries(idBank string, from, to portfolio.Date) (*Series, error) {
 	)
 	log.Println("Downloading from INSEE:", url)
 
-	var resp *http.Response
-	var err error
-	const retries = 3
-	for i := 1; i <= retries; i++ {
-		var e error
-		resp, e = http.Get(url)
-		if e != nil {
-			log.Println("Error downloading from INSEE Retrying.", i)
-			err = errors.Join(err, e)
-			continue
-		}
-		break
+	// Transient failures are retried by the shared network middleware.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
+	resp, err := cache.NewClient("insee", portfolio.Daily).Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download from INSEE for ID %s after %d retries: %w", idBank, retries, err)
+		return nil, portfolio.ProviderError(fmt.Errorf("failed to download from INSEE for ID %s: %w", idBank, err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download from INSEE for ID %s: received status %s", idBank, resp.Status)
+		return nil, portfolio.ProviderError(fmt.Errorf("failed to download from INSEE for ID %s: received status %s", idBank, resp.Status))
 	}
 
 	body, err := io.ReadAll(resp.Body)
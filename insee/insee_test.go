@@ -1,6 +1,7 @@
 package insee
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -121,7 +122,7 @@ func TestGetSeries(t *testing.T) {
 	from := portfolio.NewDate(2025, 1, 1)
 	to := portfolio.NewDate(2023, 12, 31)
 
-	series, err := getSeries(idBank, from, to)
+	series, err := getSeries(context.Background(), idBank, from, to)
 	if err != nil {
 		t.Fatalf("getSeries() failed: %v", err)
 	}
@@ -0,0 +1,104 @@
+package portfolio
+
+import "github.com/shopspring/decimal"
+
+// counterpartyRate is the annual interest rate of a counterparty account from
+// a date on, declared by an accrue transaction.
+type counterpartyRate struct {
+	on      Date
+	account string
+	rate    Percent
+}
+
+// counterpartyRate returns the interest rate of a counterparty account on a
+// day.
+func (j *Journal) counterpartyRate(account string, day Date) Percent {
+	var rate Percent
+	for _, r := range j.rates {
+		if r.on.After(day) {
+			break
+		}
+		if r.account == account {
+			rate = r.rate
+		}
+	}
+	return rate
+}
+
+// CounterpartyRate returns the annual interest rate of a counterparty account
+// on the snapshot's date, in percent, zero if none was declared.
+func (s *Snapshot) CounterpartyRate(account string) Percent {
+	return s.journal.counterpartyRate(account, s.on)
+}
+
+// CounterpartyInterest is the interest of a counterparty account over a
+// period, e.g. a loan.
+type CounterpartyInterest struct {
+	Account string
+	Rate    Percent // Rate is the annual interest rate at the end of the period.
+	// Interest is the interest earned on a receivable, positive, or paid on a
+	// payable, negative, in the currency of the account.
+	Interest Money
+}
+
+// CounterpartyInterest returns the interest of the counterparty accounts with
+// an interest rate during the review period, in the order of Counterparties.
+//
+// The interest accrues daily on the end-of-day balance, at the rate of the day
+// over 365 days. It is not recorded in the ledger: it is the effective interest
+// of the loan, to compare with the accruals actually recorded.
+func (r *Review) CounterpartyInterest() []CounterpartyInterest {
+	var interests []CounterpartyInterest
+	from, to := r.start.on.Add(1), r.end.on
+	events := r.end.journal.events
+	first := 0
+	for first < len(events) && !events[first].date().After(r.start.on) {
+		first++
+	}
+
+	for account := range r.end.Counterparties() {
+		if !r.hasCounterpartyRate(account) {
+			continue
+		}
+		balance := r.start.Counterparty(account)
+		interest := M(0, balance.Currency())
+		i := first
+		for day := from; !day.After(to); day = day.Add(1) {
+			for ; i < len(events) && !events[i].date().After(day); i++ {
+				switch v := events[i].(type) {
+				case creditCounterparty:
+					if v.account == account {
+						balance = balance.Add(v.amount)
+					}
+				case debitCounterparty:
+					if v.account == account {
+						balance = balance.Sub(v.amount)
+					}
+				}
+			}
+			rate := r.end.journal.counterpartyRate(account, day)
+			daily := decimal.NewFromFloat(float64(rate)).Div(decimal.NewFromInt(100 * 365))
+			interest = interest.Add(balance.Mul(Quantity{value: daily}))
+		}
+		interests = append(interests, CounterpartyInterest{
+			Account:  account,
+			Rate:     r.end.CounterpartyRate(account),
+			Interest: interest,
+		})
+	}
+	return interests
+}
+
+// hasCounterpartyRate reports whether a counterparty account has an interest
+// rate during the review period.
+func (r *Review) hasCounterpartyRate(account string) bool {
+	if r.start.CounterpartyRate(account) != 0 {
+		return true
+	}
+	for _, cr := range r.end.journal.rates {
+		if cr.account == account && r.Range().Contains(cr.on) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package portfolio
+
+import "testing"
+
+func TestReview_CounterpartyInterest(t *testing.T) {
+	loan := NewCreatedAccrue(NewDate(2025, 1, 1), "", "Loan", EUR(36500))
+	loan.Rate = 10
+	raise := NewAccrue(NewDate(2025, 1, 6), "", "Loan", EUR(0))
+	raise.Rate = 20
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, 1, 1), "", "EUR"),
+		loan,
+		NewCreatedAccrue(NewDate(2025, 1, 1), "", "Tax", EUR(-1000)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	tx, err := ledger.Validate(raise)
+	if err != nil {
+		t.Fatalf("Validate(rate) error = %v", err)
+	}
+	if err := ledger.Append(tx); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	rateOnly := NewAccrue(NewDate(2025, 1, 6), "", "Unknown", EUR(0))
+	rateOnly.Rate = 5
+	if _, err := ledger.Validate(rateOnly); err == nil {
+		t.Errorf("Validate(rate) of an unknown account: expected an error")
+	}
+
+	// 36500 EUR at 10% is 10 EUR a day from the 1st to the 5th, then 20 EUR a
+	// day at 20% from the 6th to the 10th.
+	review := ledger.NewReview(NewRange(NewDate(2025, 1, 1), NewDate(2025, 1, 10)))
+	got := review.CounterpartyInterest()
+	if len(got) != 1 {
+		t.Fatalf("CounterpartyInterest() = %v, want the loan only", got)
+	}
+	if got[0].Account != "Loan" || got[0].Rate != 20 || !got[0].Interest.Round().Equal(EUR(150)) {
+		t.Errorf("CounterpartyInterest() = %s at %v: %v, want Loan at 20: 150 EUR", got[0].Account, got[0].Rate, got[0].Interest)
+	}
+	if got := review.End().Counterparty("Loan"); !got.Equal(EUR(36500)) {
+		t.Errorf("Counterparty(Loan) = %v, want 36500 EUR, unchanged by the rate", got)
+	}
+}
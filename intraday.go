@@ -0,0 +1,154 @@
+package portfolio
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Intraday quotes.
+//
+// Price updates record a single price per day: successive intraday updates
+// overwrite each other. The quotes behind them are kept apart from the
+// transactions, in a file "<name>.intraday.csv" next to the ledger file, for
+// the last IntradayRetention days only:
+//
+//	time,ticker,price
+//	2025-01-02T15:04:05+01:00,AAPL,185.64
+//	2025-01-02T15:34:05+01:00,AAPL,185.90
+//
+// Quotes never change the snapshots, which are valued with the end-of-day
+// prices of the price updates.
+
+// IntradayRetention is the number of days the intraday quotes are kept,
+// including today.
+const IntradayRetention = 5
+
+// Quote is the price of a security at a point in time.
+type Quote struct {
+	Time   time.Time
+	Ticker string
+	Price  Money
+}
+
+// intradayFile returns the file of the intraday quotes of a ledger file.
+func intradayFile(ledgerFile string) string {
+	return strings.TrimSuffix(ledgerFile, ".jsonl") + ".intraday.csv"
+}
+
+// RecordQuote records the price of a security at a point in time, and drops
+// the quotes older than IntradayRetention days.
+//
+// It does not update the price of the day: see UpdateMarketData.
+func (l *Ledger) RecordQuote(t time.Time, ticker string, price Money) {
+	l.update(func(w *Ledger) error {
+		w.intraday = append(w.intraday, Quote{Time: t, Ticker: ticker, Price: price})
+		oldest := w.Today().Add(1 - IntradayRetention)
+		w.intraday = slices.DeleteFunc(w.intraday, func(q Quote) bool {
+			return w.dayOf(q.Time).Before(oldest)
+		})
+		return nil
+	})
+}
+
+// Intraday returns the quotes of a day, in the time zone of the ledger, by
+// ticker then time. If tickers are given, only their quotes are returned.
+func (l *Ledger) Intraday(day Date, tickers ...string) []Quote {
+	l = l.view()
+	var quotes []Quote
+	for _, q := range l.intraday {
+		if l.dayOf(q.Time) != day || len(tickers) > 0 && !slices.Contains(tickers, q.Ticker) {
+			continue
+		}
+		q.Time = q.Time.In(l.location())
+		quotes = append(quotes, q)
+	}
+	slices.SortStableFunc(quotes, func(a, b Quote) int {
+		return cmp.Or(strings.Compare(a.Ticker, b.Ticker), a.Time.Compare(b.Time))
+	})
+	return quotes
+}
+
+// location returns the time zone of the trading day of the ledger.
+func (l *Ledger) location() *time.Location {
+	if l.timeZone == nil {
+		return timeZone
+	}
+	return l.timeZone
+}
+
+// dayOf returns the date of a time in the time zone of the ledger.
+func (l *Ledger) dayOf(t time.Time) Date {
+	return NewDate(t.In(l.location()).Date())
+}
+
+// encodeIntradayFile writes the quotes in a CSV file, or removes it if there
+// are no quotes.
+func encodeIntradayFile(file string, quotes []Quote) error {
+	if len(quotes) == 0 {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove intraday file %q: %w", file, err)
+		}
+		return nil
+	}
+	err := writeFile(file, func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		w.Write([]string{"time", "ticker", "price"})
+		for _, q := range quotes {
+			w.Write([]string{q.Time.Format(time.RFC3339), q.Ticker, q.Price.value.String()})
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		return fmt.Errorf("could not write intraday file %q: %w", file, err)
+	}
+	return nil
+}
+
+// decodeIntradayFile reads the quotes of a CSV file, one per row. The
+// currency of the prices is the currency of their security, the quotes of
+// undeclared securities are dropped.
+func (l *Ledger) decodeIntradayFile(r io.Reader) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(header, []string{"time", "ticker", "price"}) {
+		return errors.New(`the columns must be "time", "ticker" and "price"`)
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return err
+		}
+		price, err := decimal.NewFromString(record[2])
+		if err != nil {
+			return fmt.Errorf("invalid price of %s at %s: %w", record[1], record[0], err)
+		}
+		sec := l.Security(record[1])
+		if sec == nil {
+			continue
+		}
+		l.intraday = append(l.intraday, Quote{Time: t, Ticker: record[1], Price: M(price, sec.Currency())})
+	}
+}
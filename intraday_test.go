@@ -0,0 +1,62 @@
+package portfolio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedger_Intraday(t *testing.T) {
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	init := NewInit(NewDate(2025, time.January, 1), "", "USD")
+	init.TimeZone = "America/New_York"
+	if err := ledger.Append(init, NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	today := ledger.Today()
+	noon := time.Date(today.Year(), today.Month(), today.Day(), 12, 0, 0, 0, ledger.TimeZone())
+	ledger.RecordQuote(noon.Add(-time.Minute), "AAPL", USD(101))
+	ledger.RecordQuote(noon.Add(-2*time.Minute), "AAPL", USD(100))
+	ledger.RecordQuote(noon.AddDate(0, 0, -IntradayRetention), "AAPL", USD(90)) // Dropped, too old.
+	ledger.RecordQuote(noon.AddDate(0, 0, -1), "AAPL", USD(95))
+
+	quotes := ledger.Intraday(today, "AAPL")
+	if len(quotes) != 2 {
+		t.Fatalf("Intraday() = %v, want 2 quotes", quotes)
+	}
+	if !quotes[0].Price.Equal(USD(100)) || !quotes[1].Price.Equal(USD(101)) {
+		t.Errorf("Intraday() = %v, want the quotes in time order", quotes)
+	}
+	if got := ledger.Intraday(today.Add(-1)); len(got) != 1 {
+		t.Errorf("Intraday(yesterday) = %v, want 1 quote", got)
+	}
+	if got := ledger.Intraday(today.Add(-IntradayRetention)); len(got) != 0 {
+		t.Errorf("Intraday(%d days ago) = %v, want none", IntradayRetention, got)
+	}
+
+	// Quotes do not change the price of the day.
+	if price := ledger.NewSnapshot(today).Price("AAPL"); !price.IsZero() {
+		t.Errorf("Price() = %v, want no price", price)
+	}
+
+	// Quotes are saved apart and loaded back.
+	dir := t.TempDir()
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ledger.intraday.csv")); err != nil {
+		t.Fatalf("intraday file not saved: %v", err)
+	}
+	loaded, err := FindLedger(context.Background(), dir, "ledger")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	got := loaded.Intraday(today, "AAPL")
+	if len(got) != 2 || !got[1].Price.Equal(USD(101)) || !got[1].Time.Equal(quotes[1].Time.Truncate(time.Second)) {
+		t.Errorf("loaded Intraday() = %v, want %v", got, quotes)
+	}
+}
@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"fmt"
+	"iter"
 
 	"github.com/shopspring/decimal"
 )
@@ -15,9 +16,11 @@ type event interface {
 
 // Journal holds a chronologically sorted list of all atomic events.
 type Journal struct {
-	cur    string  // the reporting currency.
-	events []event // sorted by date
-	txs    []Transaction
+	cur        string             // the reporting currency.
+	overdrafts []Overdraft        // the negative cash balances allowed.
+	rates      []counterpartyRate // the interest rates of counterparty accounts, sorted by date.
+	events     []event            // sorted by date
+	txs        []Transaction
 }
 
 type baseEvent struct {
@@ -34,19 +37,23 @@ func (e baseEvent) source() int { return e.src }
 type creditCash struct {
 	baseEvent
 	amount   Money
-	external bool // true when cash comes from outside.
+	external bool   // true when cash comes from outside.
+	account  string // the cash account, the default account if empty.
 }
 
-func (e creditCash) currency() string { return e.amount.Currency() }
+func (e creditCash) currency() string    { return e.amount.Currency() }
+func (e creditCash) cashAccount() string { return cashAccount(e.account) }
 
 // debitCash decreases the balance of a cash account.
 type debitCash struct {
 	baseEvent
 	amount   Money
-	external bool // true when cash goes outside.
+	external bool   // true when cash goes outside.
+	account  string // the cash account, the default account if empty.
 }
 
-func (e debitCash) currency() string { return e.amount.Currency() }
+func (e debitCash) currency() string    { return e.amount.Currency() }
+func (e debitCash) cashAccount() string { return cashAccount(e.account) }
 
 // --- Security Events ---
 
@@ -56,6 +63,7 @@ type acquireLot struct {
 	security string
 	quantity Quantity
 	cost     Money
+	rate     Money // the exchange rate of the cost currency on the trade date, zero if unknown.
 }
 
 // disposeLot removes a quantity of a security.
@@ -74,6 +82,45 @@ type receiveDividend struct {
 	amount   Money // per share.
 }
 
+// lendShares moves a quantity of a security out of the account to a borrower.
+// The shares are still owned, hence still in the position.
+type lendShares struct {
+	baseEvent
+	security string
+	quantity Quantity
+}
+
+// recallShares moves a quantity of lent shares back into the account.
+type recallShares struct {
+	baseEvent
+	security string
+	quantity Quantity
+}
+
+// receiveLendingFee logs the fee earned for lending a security.
+// Like dividends, this is treated as income to the owner, not a cash flow into the portfolio.
+type receiveLendingFee struct {
+	baseEvent
+	security string
+	amount   Money // total.
+}
+
+// declareGrant records the vesting schedule of an employee stock plan grant.
+type declareGrant struct {
+	baseEvent
+	grant    string
+	security string
+	plan     string
+	schedule []VestingTranche
+}
+
+// vestGrant records shares of a grant that vested.
+type vestGrant struct {
+	baseEvent
+	grant    string
+	quantity Quantity
+}
+
 // --- Counterparty Events ---
 
 // declareCounterparty maps a ticker to a security ID and currency.
@@ -117,10 +164,15 @@ type splitShare struct {
 // declareSecurity maps a ticker to a security ID and currency.
 type declareSecurity struct {
 	baseEvent
-	ticker   string
-	id       ID
-	currency string
-	memo     string
+	ticker    string
+	id        ID
+	currency  string
+	memo      string
+	precision *int
+	ter       Percent
+	watch     bool
+	scores    map[string]float64
+	cash      bool
 }
 
 // updatePrice sets the price of a security on a given date.
@@ -134,7 +186,7 @@ type updatePrice struct {
 type updateForex struct {
 	baseEvent
 	currency string // the foreign currency (USD in USDEUR)
-	rate     Money  //(the cost of 1 USD in EUR (for USDEUR forex))
+	rate     Money  // the cost of 1 USD in EUR (for USDEUR forex), usually in the reporting currency
 }
 
 // NewJournal converts a Ledger of high-level transactions and market data events
@@ -147,43 +199,150 @@ func (ledger *Ledger) newJournal() error {
 		cur:    ledger.currency,
 	}
 
+	// options tracks the positions in option contracts, which can be short.
+	// A short position is opened by acquiring a lot of negative quantity and cost
+	// (the premium received), and closed by disposing of negative quantities.
+	options := make(map[string]Quantity)
+
 	for src, tx := range ledger.transactions {
 		b := baseEvent{on: tx.When(), src: src}
 		switch v := tx.(type) {
 		case Buy:
 			sec := ledger.Security(v.Security)
 			if sec == nil {
-				return fmt.Errorf("security %q not declared for buy transaction on %s", v.Security, v.When())
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for buy transaction on %s", v.Security, v.When()))
+			}
+			if sec.ID().IsOption() {
+				if options[v.Security].IsNegative() { // buy to close
+					journal.events = append(journal.events,
+						disposeLot{baseEvent: b, security: v.Security, quantity: v.Quantity.Neg(), proceeds: v.Amount.Neg()},
+						debitCash{baseEvent: b, amount: v.Amount, external: false, account: v.Account},
+					)
+					options[v.Security] = options[v.Security].Add(v.Quantity)
+					continue
+				}
+				options[v.Security] = options[v.Security].Add(v.Quantity)
 			}
 
 			journal.events = append(journal.events,
 				acquireLot{baseEvent: b, security: v.Security, quantity: v.Quantity, cost: v.Amount},
-				debitCash{baseEvent: b, amount: v.Amount, external: false},
+				debitCash{baseEvent: b, amount: v.Amount, external: false, account: v.Account},
 			)
 		case Sell:
 			sec := ledger.Security(v.Security)
 			if sec == nil {
-				return fmt.Errorf("security %q not declared for sell transaction on %s", v.Security, v.When())
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for sell transaction on %s", v.Security, v.When()))
+			}
+			if sec.ID().IsOption() {
+				if !options[v.Security].IsPositive() { // sell to open
+					journal.events = append(journal.events,
+						acquireLot{baseEvent: b, security: v.Security, quantity: v.Quantity.Neg(), cost: v.Amount.Neg()},
+						creditCash{baseEvent: b, amount: v.Amount, external: false, account: v.Account},
+					)
+					options[v.Security] = options[v.Security].Sub(v.Quantity)
+					continue
+				}
+				options[v.Security] = options[v.Security].Sub(v.Quantity)
 			}
 			journal.events = append(journal.events,
 				disposeLot{baseEvent: b, security: v.Security, quantity: v.Quantity, proceeds: v.Amount},
-				creditCash{baseEvent: b, amount: v.Amount, external: false},
+				creditCash{baseEvent: b, amount: v.Amount, external: false, account: v.Account},
 			)
+		case Expire:
+			sec := ledger.Security(v.Security)
+			if sec == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for expire transaction on %s", v.Security, v.When()))
+			}
+			journal.events = append(journal.events,
+				disposeLot{baseEvent: b, security: v.Security, quantity: options[v.Security], proceeds: M(0, sec.Currency())},
+			)
+			options[v.Security] = Quantity{}
+		case Assign:
+			sec := ledger.Security(v.Security)
+			if sec == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for assign transaction on %s", v.Security, v.When()))
+			}
+			c, err := sec.ID().Option()
+			if err != nil {
+				return fmt.Errorf("security %q is not an option for assign transaction on %s", v.Security, v.When())
+			}
+			contracts := v.Quantity
+			if contracts.IsZero() {
+				contracts = options[v.Security].Abs()
+			}
+			if options[v.Security].IsNegative() {
+				contracts = contracts.Neg()
+			}
+			shares, amount := delivery(c, sec.Currency(), contracts)
+			journal.events = append(journal.events,
+				disposeLot{baseEvent: b, security: v.Security, quantity: contracts, proceeds: M(0, sec.Currency())},
+			)
+			options[v.Security] = options[v.Security].Sub(contracts)
+			if shares.IsPositive() {
+				journal.events = append(journal.events,
+					acquireLot{baseEvent: b, security: c.Underlying, quantity: shares, cost: amount},
+					debitCash{baseEvent: b, amount: amount, external: false},
+				)
+			} else {
+				journal.events = append(journal.events,
+					disposeLot{baseEvent: b, security: c.Underlying, quantity: shares.Neg(), proceeds: amount},
+					creditCash{baseEvent: b, amount: amount, external: false},
+				)
+			}
 		case Dividend:
 			sec := ledger.Security(v.Security)
 			if sec == nil {
-				return fmt.Errorf("security %q not declared for dividend transaction on %s", v.Security, v.When())
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for dividend transaction on %s", v.Security, v.When()))
 			}
 			journal.events = append(journal.events,
 				receiveDividend{baseEvent: b, security: v.Security, amount: v.Amount},
 			)
+		case Grant:
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for grant transaction on %s", v.Security, v.When()))
+			}
+			journal.events = append(journal.events,
+				declareGrant{baseEvent: b, grant: v.Grant, security: v.Security, plan: v.Plan, schedule: v.Schedule},
+			)
+		case Vest:
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for vest transaction on %s", v.Security, v.When()))
+			}
+			// The vested shares are contributed to the portfolio at their fair market value.
+			journal.events = append(journal.events,
+				vestGrant{baseEvent: b, grant: v.Grant, quantity: v.Quantity},
+				creditCash{baseEvent: b, amount: v.Amount, external: true},
+				acquireLot{baseEvent: b, security: v.Security, quantity: v.Quantity, cost: v.Amount},
+				debitCash{baseEvent: b, amount: v.Amount, external: false},
+			)
+		case Lend:
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for lend transaction on %s", v.Security, v.When()))
+			}
+			journal.events = append(journal.events,
+				lendShares{baseEvent: b, security: v.Security, quantity: v.Quantity},
+			)
+		case Recall:
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for recall transaction on %s", v.Security, v.When()))
+			}
+			journal.events = append(journal.events,
+				recallShares{baseEvent: b, security: v.Security, quantity: v.Quantity},
+			)
+		case LendingFee:
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for lending fee transaction on %s", v.Security, v.When()))
+			}
+			journal.events = append(journal.events,
+				receiveLendingFee{baseEvent: b, security: v.Security, amount: v.Amount},
+			)
 		case Deposit:
 			amount := v.Amount
 			// A deposit that settles a receivable is not considered as external (since the amount)
 			// was taken into account when accruing the receivable
 			ext := v.Settles == ""
 			journal.events = append(journal.events,
-				creditCash{baseEvent: b, amount: amount, external: ext},
+				creditCash{baseEvent: b, amount: amount, external: ext, account: v.Account},
 			)
 			if v.Settles != "" {
 				// A deposit settling an account means a counterparty paid us back, reducing what they owe us (asset).
@@ -197,7 +356,7 @@ func (ledger *Ledger) newJournal() error {
 			// was taken into account when accruing the receivable
 			ext := v.Settles == ""
 			journal.events = append(journal.events,
-				debitCash{baseEvent: b, amount: amount, external: ext},
+				debitCash{baseEvent: b, amount: amount, external: ext, account: v.Account},
 			)
 			if v.Settles != "" {
 				// A withdrawal settling an account means we paid a counterparty back, reducing what we owe them (liability).
@@ -216,13 +375,19 @@ func (ledger *Ledger) newJournal() error {
 			}
 
 			journal.events = append(journal.events,
-				declareSecurity{baseEvent: b, ticker: v.Ticker, id: v.ID, currency: v.Currency, memo: v.Memo},
+				declareSecurity{baseEvent: b, ticker: v.Ticker, id: v.ID, currency: v.Currency, memo: v.Memo, precision: v.Precision, ter: v.TER, watch: v.Watch, scores: v.Scores, cash: v.CashEquivalent},
 			)
 		case Accrue:
 			if v.Create {
 				journal.events = append(journal.events, declareCounterparty{baseEvent: b, account: v.Counterparty, currency: v.Currency()})
 			}
+			if v.Rate != 0 {
+				journal.rates = append(journal.rates, counterpartyRate{on: v.When(), account: v.Counterparty, rate: v.Rate})
+			}
 			amount := v.Amount
+			if amount.IsZero() { // Only declares a rate.
+				continue
+			}
 			if amount.IsPositive() { // Receivable: counterparty owes us (asset) -> increase asset
 				journal.events = append(journal.events,
 					creditCounterparty{baseEvent: b, account: v.Counterparty, amount: amount, external: true},
@@ -237,12 +402,13 @@ func (ledger *Ledger) newJournal() error {
 				sec := ledger.Security(ticker)
 				if sec == nil {
 					// This should have been caught by validation, but we check again.
-					return fmt.Errorf("security %q from update-price not declared", ticker)
+					return withKind(ErrUnknownSecurity, fmt.Errorf("security %q from update-price not declared", ticker))
 				}
 				price := M(priceDecimal, sec.Currency())
 
 				// Handle forex updates
-				if base, quote, err := sec.ID().CurrencyPair(); err == nil {
+				if id := sec.ID(); id.IsCurrencyPair() {
+					base, quote := id.Base(), id.Quote()
 					if quote == journal.cur {
 						journal.events = append(journal.events,
 							updateForex{baseEvent: b, currency: base, rate: price},
@@ -255,6 +421,12 @@ func (ledger *Ledger) newJournal() error {
 							updateForex{baseEvent: b, currency: quote, rate: p},
 						)
 					}
+					if base != journal.cur && quote != journal.cur {
+						// Cross rates are kept to derive missing rates.
+						journal.events = append(journal.events,
+							updateForex{baseEvent: b, currency: base, rate: price},
+						)
+					}
 					continue
 				}
 
@@ -268,12 +440,118 @@ func (ledger *Ledger) newJournal() error {
 			journal.events = append(journal.events,
 				splitShare{baseEvent: b, security: v.Security, numerator: v.Numerator, denominator: v.Denominator},
 			)
+		case Custom:
+			for _, c := range v.Cash {
+				if c.Amount.IsPositive() {
+					journal.events = append(journal.events, creditCash{baseEvent: b, amount: c.Amount, external: c.External})
+				} else {
+					journal.events = append(journal.events, debitCash{baseEvent: b, amount: c.Amount.Neg(), external: c.External})
+				}
+			}
+			for _, p := range v.Positions {
+				if ledger.Security(p.Security) == nil {
+					return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for %s transaction on %s", p.Security, v.What(), v.When()))
+				}
+				if ledger.Security(p.Security).ID().IsOption() {
+					options[p.Security] = options[p.Security].Add(p.Quantity)
+				}
+				if p.Quantity.IsPositive() {
+					journal.events = append(journal.events, acquireLot{baseEvent: b, security: p.Security, quantity: p.Quantity, cost: p.Amount})
+				} else {
+					journal.events = append(journal.events, disposeLot{baseEvent: b, security: p.Security, quantity: p.Quantity.Neg(), proceeds: p.Amount})
+				}
+			}
+		case Note:
+			// Notes are commentaries, without effect on the portfolio.
+			if ledger.Security(v.Security) == nil {
+				return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared for note transaction on %s", v.Security, v.When()))
+			}
+		case SetPriceAlert, ClearPriceAlert:
+			// Price alerts are read from the ledger, without effect on the portfolio.
+		case DeclareCurrency:
+			// Currencies are registered when the transaction is appended, see processTx.
 		case Init:
 			journal.cur = v.Currency
+			journal.overdrafts = v.Overdrafts
 		default:
 			return fmt.Errorf("cannot create the journal entry for transaction of unknown type: %T", tx)
 		}
 	}
 	ledger.journal = journal
+	ledger.recordTradeRates()
 	return nil
 }
+
+// recordTradeRates records in each acquired lot the exchange rate of its cost
+// currency on the trade date, so that its cost in the reporting currency is
+// historical, and does not vary with exchange rates.
+func (ledger *Ledger) recordTradeRates() {
+	type key struct {
+		on       Date
+		currency string
+	}
+	rates := make(map[key]Money)
+	for i, e := range ledger.journal.events {
+		v, ok := e.(acquireLot)
+		if !ok {
+			continue
+		}
+		k := key{v.on, v.cost.Currency()}
+		rate, ok := rates[k]
+		if !ok {
+			rate = ledger.NewSnapshot(v.on).ExchangeRate(k.currency)
+			rates[k] = rate
+		}
+		v.rate = rate
+		ledger.journal.events[i] = v
+	}
+}
+
+// CashEntry is a single movement of a cash account.
+type CashEntry struct {
+	Date        Date
+	Transaction Transaction // Transaction is the transaction that moved the cash.
+	Amount      Money       // Amount is positive for credits and negative for debits.
+	Balance     Money       // Balance is the account balance after the movement.
+}
+
+// CashEntries returns the movements of the cash account in currency during the period,
+// in chronological order.
+//
+// The running balance accounts for all the movements since the inception of the
+// journal, so that the balance of the last entry is the account balance at the end
+// of the period. Dividends are not cash movements: the cash received is recorded
+// by the deposit that pays them.
+func (j *Journal) CashEntries(currency string, period Range) iter.Seq[CashEntry] {
+	return func(yield func(CashEntry) bool) {
+		balance := M(0, currency)
+		for _, e := range j.events {
+			if e.date().After(period.To) {
+				return
+			}
+			var amount Money
+			switch v := e.(type) {
+			case creditCash:
+				if v.currency() != currency {
+					continue
+				}
+				amount = v.amount
+			case debitCash:
+				if v.currency() != currency {
+					continue
+				}
+				amount = v.amount.Neg()
+			default:
+				continue
+			}
+			balance = balance.Add(amount)
+			if !period.Contains(e.date()) {
+				continue
+			}
+			entry := CashEntry{Date: e.date(), Transaction: j.txs[e.source()], Amount: amount, Balance: balance}
+			if !yield(entry) {
+				return
+			}
+		}
+	}
+}
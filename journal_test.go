@@ -0,0 +1,95 @@
+package portfolio
+
+import (
+	"testing"
+)
+
+func TestJournal_CashEntries(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(500), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(5), EUR(600)),
+		NewDividend(NewDate(2025, 1, 4), "", "AAPL", EUR(1)),
+		NewSell(NewDate(2025, 1, 5), "", "AAPL", Q(2), EUR(300)),
+		NewConvert(NewDate(2025, 1, 6), "", USD(100), EUR(90)),
+		NewWithdraw(NewDate(2025, 1, 7), "", EUR(50)),
+		NewDeposit(NewDate(2025, 1, 8), "", EUR(20), ""),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	type entry struct {
+		date    Date
+		amount  Money
+		balance Money
+	}
+	want := []entry{
+		{NewDate(2025, 1, 3), EUR(-600), EUR(400)},
+		{NewDate(2025, 1, 5), EUR(300), EUR(700)},
+		{NewDate(2025, 1, 6), EUR(90), EUR(790)},
+		{NewDate(2025, 1, 7), EUR(-50), EUR(740)},
+	}
+
+	var got []entry
+	for e := range ledger.Journal().CashEntries("EUR", NewRange(NewDate(2025, 1, 3), NewDate(2025, 1, 7))) {
+		got = append(got, entry{e.Date, e.Amount, e.Balance})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CashEntries() returned %d entries, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].date != want[i].date || !got[i].amount.Equal(want[i].amount) || !got[i].balance.Equal(want[i].balance) {
+			t.Errorf("CashEntries()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJournal_Events(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(5), EUR(600)),
+		NewSell(NewDate(2025, 1, 5), "", "AAPL", Q(2), EUR(300)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	type event struct {
+		kind     EventKind
+		security string
+		quantity Quantity
+		amount   Money
+		external bool
+	}
+	want := []event{
+		{EventCreditCash, "", Quantity{}, EUR(1000), true},
+		{EventAcquireLot, "AAPL", Q(5), EUR(600), false},
+		{EventDebitCash, "", Quantity{}, EUR(600), false},
+	}
+
+	var got []event
+	for e := range ledger.Journal().Events(NewRange(NewDate(2025, 1, 2), NewDate(2025, 1, 4))) {
+		if e.Transaction() == nil {
+			t.Errorf("Events() %s has no transaction", e.Kind())
+		}
+		got = append(got, event{e.Kind(), e.Security(), e.Quantity(), e.Amount(), e.External()})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Events() returned %d events, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.kind != w.kind || g.security != w.security || !g.quantity.Equal(w.quantity) || !g.amount.Equal(w.amount) || g.external != w.external {
+			t.Errorf("Events()[%d] = %v, want %v", i, g, w)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package portfolio
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"log"
 	"maps"
 	"slices"
+	"sync"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -14,24 +17,96 @@ import (
 // Ledger represents a list of transactions.
 //
 // In a Ledger transactions are always in chronological order.
+//
+// A Ledger is safe for concurrent use. Its state is never modified in place:
+// writers, like Append, apply their change to a copy of the state and publish
+// it once done, and readers work on the state current when they started.
 type Ledger struct {
+	mu      sync.RWMutex // guards the published state.
+	writing sync.Mutex   // serializes the writers, see update.
+	batch   bool         // the ledger is the private clone of a Batch, changed in place.
+	ledgerState
+}
+
+// ledgerState is the state of a ledger, published as a whole by update.
+type ledgerState struct {
 	name           string
 	currency       string // ledger currency
 	transactions   []Transaction
 	securities     map[string]Security // index securities by ticker
 	counterparties map[string]string   // index counterparties currency by counterparty name
 	journal        *Journal
+	splitPrices    bool            // price updates are stored in per-year price files
+	pivot          string          // currency preferred to derive missing exchange rates
+	settlementLag  int             // business days between the trade and the settlement of buys and sells
+	timeZone       *time.Location  // time zone of the trading day, see Init.TimeZone
+	intraday       []Quote         // quotes of the last days, see RecordQuote
+	conflictPolicy ConflictPolicy  // policy when providers disagree on a price
+	conflicts      []PriceConflict // conflicts queued for review, see ReviewConflicts
 }
 
 // NewLedger creates an empty ledger.
 func NewLedger() *Ledger {
-	return &Ledger{
+	return &Ledger{ledgerState: ledgerState{
 		currency:       "EUR",
 		transactions:   make([]Transaction, 0),
 		securities:     make(map[string]Security),
 		counterparties: make(map[string]string),
 		journal:        &Journal{},
+	}}
+}
+
+// view returns a ledger sharing the current state of l. The state is never
+// modified in place, so the readers of a ledger work on a view of it, unaffected
+// by the writers running meanwhile.
+func (l *Ledger) view() *Ledger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &Ledger{ledgerState: l.ledgerState}
+}
+
+// clone returns a copy of the ledger that can be appended to without
+// modifying the original.
+func (l *Ledger) clone() *Ledger {
+	c := l.view()
+	c.transactions = slices.Clone(c.transactions)
+	c.securities = maps.Clone(c.securities)
+	c.counterparties = maps.Clone(c.counterparties)
+	c.intraday = slices.Clone(c.intraday)
+	c.conflicts = slices.Clone(c.conflicts)
+	return c
+}
+
+// update applies a change to a clone of the ledger, and publishes the state of
+// the clone if the change succeeds: a failed change leaves the ledger
+// unchanged. Writers are serialized, so that no change is lost.
+//
+// The ledger of a Batch is private to its writer, it is changed in place.
+func (l *Ledger) update(change func(w *Ledger) error) error {
+	if l.batch {
+		return change(l)
+	}
+	l.writing.Lock()
+	defer l.writing.Unlock()
+	w := l.clone()
+	if err := change(w); err != nil {
+		return err
 	}
+	l.mu.Lock()
+	l.ledgerState = w.ledgerState
+	l.mu.Unlock()
+	return nil
+}
+
+// Batch applies a series of changes to the ledger, e.g. the transactions of a
+// file validated and appended one by one, and publishes them at once if f
+// succeeds. The ledger is cloned once for the whole batch, instead of once per
+// change: b is that clone, it must not be used once f returns.
+func (l *Ledger) Batch(f func(b *Ledger) error) error {
+	return l.update(func(w *Ledger) error {
+		w.batch = true
+		return f(w)
+	})
 }
 
 // Fmt creates a new, formatted ledger from the current one.
@@ -39,21 +114,28 @@ func NewLedger() *Ledger {
 // This produces a canonical version of the ledger.
 func (l *Ledger) Fmt() (*Ledger, error) {
 	// The source ledger needs to be sorted to process transactions in order for validation.
+	l = l.clone()
 	l.stableSort()
 
 	// Create a new empty ledger, preserving the name and currency from the original.
 	newLedger := NewLedger()
 	newLedger.name = l.name
 	newLedger.currency = l.currency
-
-	// Append transactions one by one to the new ledger. The Append method
-	// will handle validation and re-building the internal state (journal).
+	newLedger.splitPrices = l.splitPrices
+	newLedger.pivot = l.pivot
+	newLedger.settlementLag = l.settlementLag
+	newLedger.intraday = l.intraday
+	newLedger.conflictPolicy = l.conflictPolicy
+	newLedger.conflicts = l.conflicts
+
+	// Append transactions one by one to the new ledger, private to Fmt, so
+	// in place. The append method re-builds the internal state (journal).
 	for _, tx := range l.transactions {
 		validatedTx, err := newLedger.Validate(tx)
 		if err != nil {
 			return nil, fmt.Errorf("validation failed for transaction on %s (%T): %w", tx.When(), tx, err)
 		}
-		if err := newLedger.Append(validatedTx); err != nil {
+		if err := newLedger.append(validatedTx); err != nil {
 			return nil, fmt.Errorf("failed to append transaction on %s: %w", tx.When(), err)
 		}
 	}
@@ -62,15 +144,62 @@ func (l *Ledger) Fmt() (*Ledger, error) {
 
 // Currencies returns a sequence of all currencies used in the ledger as of today.
 func (ledger *Ledger) Currencies() iter.Seq[string] {
-	return ledger.NewSnapshot(Today()).Currencies()
+	return ledger.NewSnapshot(ledger.Today()).Currencies()
 }
 
-func (ledger *Ledger) Currency() string { return ledger.currency }
+func (ledger *Ledger) Currency() string { return ledger.view().currency }
 
 // Name returns the name of the ledger, which is its relative path from the portfolio root.
-func (ledger *Ledger) Name() string { return ledger.name }
+func (ledger *Ledger) Name() string { return ledger.view().name }
+
+// SetPivotCurrency sets the currency preferred to derive the exchange rates
+// missing in the ledger, for the snapshots of the ledger. See Snapshot.CrossRate.
+func (ledger *Ledger) SetPivotCurrency(currency string) {
+	ledger.update(func(w *Ledger) error { w.pivot = currency; return nil })
+}
+
+// SettlementLag returns the number of business days between the trade date
+// and the settlement date of buys and sells, 0 by default.
+func (ledger *Ledger) SettlementLag() int { return ledger.view().settlementLag }
+
+// SetSettlementLag sets the number of business days between the trade date and
+// the settlement date of buys and sells, e.g. 2 for T+2. It only changes the
+// cash considered settled and available for new transactions, cash balances
+// still account for trades on their trade date.
+func (ledger *Ledger) SetSettlementLag(days int) {
+	ledger.update(func(w *Ledger) error { w.settlementLag = max(days, 0); return nil })
+}
+
+// SettlementDate returns the settlement date of a trade on that date.
+func (ledger *Ledger) SettlementDate(trade Date) Date {
+	ledger = ledger.view()
+	return settlementDate(trade, ledger.settlementLag)
+}
+
+// settlementDate returns the date lag business days after trade.
+func settlementDate(trade Date, lag int) Date {
+	day := trade
+	for lag > 0 {
+		day = day.Add(1)
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			lag--
+		}
+	}
+	return day
+}
+
+// SplitPrices reports whether the price updates of the ledger are saved in
+// per-year price files, apart from the ledger file.
+func (ledger *Ledger) SplitPrices() bool { return ledger.view().splitPrices }
+
+// SetSplitPrices sets whether the price updates of the ledger are saved in
+// per-year price files, apart from the ledger file.
+func (ledger *Ledger) SetSplitPrices(split bool) {
+	ledger.update(func(w *Ledger) error { w.splitPrices = split; return nil })
+}
 
 func (l *Ledger) CounterPartyCurrency(account string) (cur string, exists bool) {
+	l = l.view()
 	// TODO: ledger should not hold index, this should be the journal.
 	cur, ok := l.counterparties[account]
 	return cur, ok
@@ -78,6 +207,7 @@ func (l *Ledger) CounterPartyCurrency(account string) (cur string, exists bool)
 
 // Security return the security declared with this ticker, or nil if unknown.
 func (l *Ledger) Security(ticker string) *Security {
+	l = l.view()
 	// TODO: ledger should not hold index, this should be the journal.
 	sec, ok := l.securities[ticker]
 	if !ok {
@@ -86,46 +216,149 @@ func (l *Ledger) Security(ticker string) *Security {
 	return &sec
 }
 
+// Grant returns the employee stock plan grant with that name, or nil if there is none.
+func (l *Ledger) Grant(name string) *Grant {
+	l = l.view()
+	for _, tx := range l.transactions {
+		if g, ok := tx.(Grant); ok && g.Grant == name {
+			return &g
+		}
+	}
+	return nil
+}
+
 // Validate checks a transaction for correctness and applies quick fixes where
 // applicable (e.g., resolving "sell all"). It returns the validated (and
 // potentially modified) transaction or an error detailing any validation failures.
 func (l *Ledger) Validate(tx Transaction) (Transaction, error) {
+	l = l.view()
+	if key := dedupKey(tx); key != "" {
+		if dup := l.Duplicate(tx); dup != nil {
+			return tx, withKind(ErrValidation, fmt.Errorf("%w: %q is already the key of the %s of %s", ErrDuplicateKey, key, dup.What(), dup.When()))
+		}
+	}
 	// For validations that need the state of the portfolio, we compute the balance
 	// on the transaction date.
-	return tx.Validate(l)
+	tx, err := tx.Validate(l)
+	return tx, withKind(ErrValidation, err)
+}
+
+// ErrDuplicateKey is returned when validating a transaction whose dedup key is
+// already used in the ledger.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// Duplicate returns the transaction of the ledger that tx duplicates, or nil if
+// there is none. When tx has a dedup key, it duplicates the transaction with the
+// same key. Otherwise it duplicates any transaction it is Equal to.
+//
+// Importers re-running on the same data can skip the duplicates to append each
+// transaction only once.
+func (l *Ledger) Duplicate(tx Transaction) Transaction {
+	l = l.view()
+	key := dedupKey(tx)
+	for _, t := range l.transactions {
+		if key != "" && dedupKey(t) == key || key == "" && tx.Equal(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// dedupKey returns the dedup key of a transaction, or "" if it has none.
+func dedupKey(tx Transaction) string {
+	if k, ok := tx.(interface{ DedupKey() string }); ok {
+		return k.DedupKey()
+	}
+	return ""
+}
+
+// SchemaVersion returns the schema version of the ledger file, recorded by its
+// init transaction, or zero if it has none.
+func (l *Ledger) SchemaVersion() int {
+	l = l.view()
+	if len(l.transactions) == 0 {
+		return 0
+	}
+	init, _ := l.transactions[0].(Init)
+	return init.Version
+}
+
+// Migrate records the current schema version in the init transaction of the
+// ledger, once its lines have been upgraded by the decoder, and returns the
+// previous version. The ledger file is upgraded when saved.
+func (l *Ledger) Migrate() (version int, err error) {
+	err = l.update(func(w *Ledger) error {
+		version, err = w.migrate()
+		return err
+	})
+	return version, err
+}
+
+// migrate records the current schema version, see Migrate.
+func (l *Ledger) migrate() (int, error) {
+	if len(l.transactions) == 0 {
+		return 0, errors.New("the ledger is empty")
+	}
+	init, ok := l.transactions[0].(Init)
+	if !ok {
+		return 0, errors.New("the ledger has no init transaction to record its schema version, record one with 'pcs init'")
+	}
+	version := init.Version
+	init.Version = SchemaVersion
+	l.transactions[0] = init
+	return version, nil
 }
 
+// TimeZone returns the time zone of the trading day of the ledger, declared by
+// its init transaction, or nil if it has none.
+func (l *Ledger) TimeZone() *time.Location { return l.view().timeZone }
+
+// Today returns the current date in the time zone of the ledger if it has one,
+// otherwise it is Today.
+func (l *Ledger) Today() Date { return l.view().dayOf(time.Now()) }
+
 // UpdateIntraday fetches the latest intraday prices for all securities in the ledger
-// from the tradegate provider and updates the ledger with them.
-func (l *Ledger) UpdateIntraday() error {
+// from the tradegate provider and updates the ledger with them: they are the
+// prices of the day, and they are recorded as quotes, see RecordQuote. The
+// requests are cancelled with the context.
+func (l *Ledger) UpdateIntraday(ctx context.Context) error {
 	// TODO: Update Intraday should be done differently.
 	// a provider should be made, that can fetch data, and the UpdateMarketData should be used instead.
 	var newTxs []Transaction
 	var errs error
-	today := Today()
+	now := time.Now()
+	today := l.view().dayOf(now)
 
-	// Update the EURUSD ticker
-	val, err := tradegateLatestEURperUSD()
+	// Tradegate quotes in EUR, the USD/EUR rate converts quotes of USD securities.
+	val, err := tradegateLatestEURperUSD(ctx)
 	if err != nil {
 		errs = errors.Join(errs, fmt.Errorf("could not fetch EUR/USD rate: %w", err))
-	} else {
-		// Tradegate gives EUR per USD, we want USD per EUR for conversion.
-		// We create a fake ticker to store this.
-		// TODO: This is a hack. Forex rates should be handled more elegantly.
-		newTxs = append(newTxs, NewUpdatePrice(Today(), "USDEUR", M(1/val, "EUR")))
+	} else if pair := l.CurrencyPair("USD", "EUR"); pair != nil {
+		// val is the price of one EUR in USD, the pair may be in either orientation.
+		rate := M(val, "USD")
+		if pair.ID().Base() == "USD" {
+			rate = M(1/val, "EUR")
+		}
+		update := NewUpdatePrice(today, pair.Ticker(), rate)
+		update.Source = tradegateSource
+		newTxs = append(newTxs, update)
+		l.RecordQuote(now, pair.Ticker(), rate)
 	}
 
 	// then update stocks
 	for sec := range l.AllSecurities() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		var latest float64
 		var err error
 
 		id := sec.ID()
 		if !l.Position(today, sec.Ticker()).IsZero() {
 			if isin, _, mssiErr := id.MSSI(); mssiErr == nil {
-				latest, err = tradegateLatest(sec.Ticker(), isin)
+				latest, err = tradegateLatest(ctx, sec.Ticker(), isin)
 			} else if isin, fundErr := id.ISIN(); fundErr == nil {
-				latest, err = tradegateLatest(sec.Ticker(), isin)
+				latest, err = tradegateLatest(ctx, sec.Ticker(), isin)
 			} else {
 				// Not a public stock/fund, skip.
 				continue
@@ -148,7 +381,10 @@ func (l *Ledger) UpdateIntraday() error {
 		}
 
 		if !price.IsZero() {
-			newTxs = append(newTxs, NewUpdatePrice(Today(), sec.Ticker(), price))
+			update := NewUpdatePrice(today, sec.Ticker(), price)
+			update.Source = tradegateSource
+			newTxs = append(newTxs, update)
+			l.RecordQuote(now, sec.Ticker(), price)
 		}
 	}
 	l.UpdateMarketData(newTxs...)
@@ -157,15 +393,54 @@ func (l *Ledger) UpdateIntraday() error {
 
 // Append appends transactions to this ledger and maintains the chronological order of transactions.
 func (l *Ledger) Append(txs ...Transaction) error {
+	return l.update(func(w *Ledger) error { return w.append(txs...) })
+}
+
+// append appends transactions, see Append.
+func (l *Ledger) append(txs ...Transaction) error {
 	// logic is a bit more complicated than that.
-	l.transactions = append(l.transactions, txs...)
+	for _, tx := range txs {
+		// An init validated against a ledger that has one already is its
+		// update: it replaces it.
+		if init, ok := tx.(Init); ok && len(l.transactions) > 0 {
+			if _, ok := l.transactions[0].(Init); ok {
+				l.transactions[0] = init
+				continue
+			}
+		}
+		l.transactions = append(l.transactions, tx)
+	}
 	// process security declarations and counterparty account creation.
-	l.processTx(txs...)
+	if err := l.processTx(txs...); err != nil {
+		return err
+	}
 	// The ledger is not sorted anymore, the journal is.
 	// TODO: if txs are in order, we can append to the existing journal instead of recomputing it.
 	return l.newJournal()
 }
 
+// Replace returns a copy of the ledger where the transaction of index i, as
+// yielded by Transactions, is replaced by tx. All the transactions of the copy
+// are validated again, as the change can invalidate the ones after it.
+func (l *Ledger) Replace(i int, tx Transaction) (*Ledger, error) {
+	l = l.view()
+	if i < 0 || i >= len(l.transactions) {
+		return nil, fmt.Errorf("no transaction of index %d in a ledger of %d transactions", i, len(l.transactions))
+	}
+	replaced := NewLedger()
+	replaced.name = l.name
+	replaced.currency = l.currency
+	replaced.splitPrices = l.splitPrices
+	replaced.pivot = l.pivot
+	replaced.settlementLag = l.settlementLag
+	replaced.intraday = l.intraday
+	replaced.conflictPolicy = l.conflictPolicy
+	replaced.conflicts = l.conflicts
+	replaced.transactions = slices.Clone(l.transactions)
+	replaced.transactions[i] = tx
+	return replaced.Fmt()
+}
+
 // MarketDataUpdate provides a summary of changes made during a market data update.
 type MarketDataUpdate struct {
 	newSplits, updatedSplits, addedDiv, updatedDiv, addedPrices, updatedPrices int
@@ -182,7 +457,20 @@ func (m MarketDataUpdate) Total() int {
 }
 
 // UpdateMarketData adds transactions to the ledger.
-func (l *Ledger) UpdateMarketData(txs ...Transaction) (MarketDataUpdate, error) {
+//
+// A price replaces the price of the same security on the same day from the
+// same source. When two providers disagree on a price, the conflict policy
+// tells which one to keep, see SetConflictPolicy.
+func (l *Ledger) UpdateMarketData(txs ...Transaction) (upd MarketDataUpdate, err error) {
+	err = l.update(func(w *Ledger) error {
+		upd, err = w.updateMarketData(txs...)
+		return err
+	})
+	return upd, err
+}
+
+// updateMarketData adds market data transactions, see UpdateMarketData.
+func (l *Ledger) updateMarketData(txs ...Transaction) (MarketDataUpdate, error) {
 
 	// Separate the transactions by type because we have to process
 	// them in a specific order: Splits, Dividends, UpdatePrice.
@@ -282,14 +570,40 @@ func (l *Ledger) UpdateMarketData(txs ...Transaction) (MarketDataUpdate, error)
 			continue
 		}
 
-		// Find an UpdatePrice for the same day to merge into.
+		// Find an UpdatePrice of the same source for the same day to merge
+		// into. The prices of the other providers for that day are settled by
+		// the conflict policy: the ones replaced are removed. The manual prices
+		// are kept, they override them.
 		index, updatePrice := -1, UpdatePrice{}
 		for i, tx := range l.transactions {
 			prev, isUpdatePrice := tx.(UpdatePrice)
-			if isUpdatePrice && prev.When() == nup.When() {
-				index, updatePrice = i, prev
-				break
+			if !isUpdatePrice || prev.When() != nup.When() {
+				continue
 			}
+			if prev.Source == nup.Source {
+				if index < 0 {
+					index, updatePrice = i, prev
+				}
+				continue
+			}
+			if prev.Source == "" || nup.Source == "" {
+				continue
+			}
+			replaced := make(map[string]decimal.Decimal)
+			for ticker := range prev.PricesIter() {
+				if _, ok := nup.Prices[ticker]; !ok {
+					continue
+				}
+				if l.preferIncoming(prev, nup, ticker) {
+					replaced[ticker] = decimal.Zero
+				} else {
+					nup = withoutPrices(nup, map[string]decimal.Decimal{ticker: decimal.Zero})
+				}
+			}
+			l.transactions[i] = withoutPrices(prev, replaced)
+		}
+		if len(nup.Prices) == 0 {
+			continue
 		}
 
 		if index < 0 {
@@ -311,11 +625,22 @@ func (l *Ledger) UpdateMarketData(txs ...Transaction) (MarketDataUpdate, error)
 
 			// Create a new UpdatePrice transaction with the merged prices
 			mergedTx := NewUpdatePrices(nup.When(), all)
+			mergedTx.Source = nup.Source
+			if len(updatePrice.Volumes)+len(nup.Volumes) > 0 {
+				mergedTx.Volumes = make(map[string]int64)
+				maps.Copy(mergedTx.Volumes, updatePrice.Volumes)
+				maps.Copy(mergedTx.Volumes, nup.Volumes)
+			}
 
 			// Update in place the existing UpdatePrice.
 			l.transactions[index] = mergedTx
 			updatedPrices += len(onlyNew)
 		}
+		// Drop the updates of other providers whose prices were all replaced.
+		l.transactions = slices.DeleteFunc(l.transactions, func(tx Transaction) bool {
+			u, ok := tx.(UpdatePrice)
+			return ok && len(u.Prices) == 0
+		})
 	}
 
 	upd := MarketDataUpdate{newSplits: newSplits, updatedSplits: updatedSplits, addedDiv: addedDiv, updatedDiv: updatedDiv, addedPrices: addedPrices, updatedPrices: updatedPrices}
@@ -330,6 +655,18 @@ func (l *Ledger) UpdateMarketData(txs ...Transaction) (MarketDataUpdate, error)
 	return upd, nil
 }
 
+// withoutPrices returns a copy of an update without the prices, and volumes,
+// of the tickers of prices.
+func withoutPrices(u UpdatePrice, prices map[string]decimal.Decimal) UpdatePrice {
+	u.Prices = maps.Clone(u.Prices)
+	u.Volumes = maps.Clone(u.Volumes)
+	for ticker := range prices {
+		delete(u.Prices, ticker)
+		delete(u.Volumes, ticker)
+	}
+	return u
+}
+
 // We have a bunch of newprices for some tickers and another bunch of existing prices.
 // some of the 'new' are not new (same value), and some of the existing ones need to be kept.
 // we want to count the really new ones (to actually change the ledger)
@@ -352,13 +689,21 @@ func mergePrices(updated, existing map[string]decimal.Decimal) (onlyNew, all map
 // processTx processes a slice of transactions to update the ledger's internal
 // indexes, such as declared securities and counterparty accounts.
 // This is typically called after transactions are appended to the ledger.
-func (l *Ledger) processTx(txs ...Transaction) {
+func (l *Ledger) processTx(txs ...Transaction) error {
 	for _, tx := range txs {
 		switch v := tx.(type) {
 		case Init:
 			l.currency = v.Currency
+			l.timeZone = nil
+			if v.TimeZone != "" {
+				l.timeZone, _ = time.LoadLocation(v.TimeZone) // validated by Init.Validate
+			}
+		case DeclareCurrency:
+			if err := RegisterCurrency(v.Currency()); err != nil {
+				return err
+			}
 		case Declare:
-			sec := NewSecurity(v.ID, v.Ticker, v.Currency, v.Memo)
+			sec := NewSecurity(v.ID, v.Ticker, v.Currency, v.Memo).WithPrecision(v.Precision).WithTER(v.TER).WithWatch(v.Watch).WithScores(v.Scores).WithCashEquivalent(v.CashEquivalent)
 			l.securities[sec.Ticker()] = sec
 		case Accrue:
 			if v.Create {
@@ -366,90 +711,90 @@ func (l *Ledger) processTx(txs ...Transaction) {
 			}
 		}
 	}
+	return nil
 }
 
-// Transactions returns an iterator that yields each transaction in their original order with their original index.
-func (l Ledger) Transactions(accepts ...func(Transaction) bool) iter.Seq2[int, Transaction] {
-	// TODO: there are other function to iterator over transactions, not all use the filter mechanism,
-	// we should probably unify them
-	// The returned iterator preserves the original order of transactions in the ledger.
-	return func(yield func(int, Transaction) bool) {
-		for i, tx := range l.transactions {
-			acceptOr := false
-			for _, accept := range accepts {
-				if accept(tx) {
-					acceptOr = true
-					break
-				}
-			}
-			if !acceptOr {
-				continue
-			}
-			if !yield(i, tx) {
-				return
-			}
-		}
-	}
+// Transactions returns an iterator that yields each transaction accepted by any
+// of the predicates, in their original order with their original index.
+//
+// It is a shortcut for Query().Where(accepts...).All().
+func (l *Ledger) Transactions(accepts ...func(Transaction) bool) iter.Seq2[int, Transaction] {
+	return l.Query().Where(accepts...).All()
 }
 
 // stableSort sorts the ledger by transaction date. The sort is stable, meaning
 // transactions on the same day maintain their original relative order.
 //
 // Some transactions should be put at the beginning of the day:
-//   - Declare are the very first ones
-//   - Dividend, UpdatePrice, and Splits aka Market Data transactions come second
+//   - DeclareCurrency are the very first ones, before the Declare of
+//     securities in these currencies
+//   - Declare come next
+//   - Dividend, UpdatePrice, and Splits aka Market Data transactions come second,
+//     with the UpdatePrice recorded manually after the ones of the providers
 //   - All other transactions come last.
 func (l *Ledger) stableSort() {
-	slices.SortStableFunc(l.transactions, func(a, b Transaction) int {
-		// we want to sort by Date first, by classes of transaction second.
-
-		// First compute the transactions classes.
-		const init, declare, market, ops = 0, 1, 2, 3
-		const classes = 4
-		classOf := func(t CommandType) int {
-			switch t {
-			case CmdInit:
-				return init
-			case CmdDeclare:
-				return declare
-			case CmdDividend, CmdSplit, CmdUpdatePrice:
-				return market
-			default:
-				return ops
-			}
-		}
-		classA, classB := classOf(a.What()), classOf(b.What())
-		dateA, dateB := a.When(), b.When()
+	slices.SortStableFunc(l.transactions, compareTransactions)
+}
 
-		return dateA.Compare(dateB)*classes + classA - classB
-	})
+// compareTransactions orders transactions by date first, and by classes of
+// transaction second, see stableSort.
+func compareTransactions(a, b Transaction) int {
+	// First compute the transactions classes.
+	const init, currency, declare, market, ops = 0, 1, 2, 3, 4
+	const classes = 5
+	classOf := func(t CommandType) int {
+		switch t {
+		case CmdInit:
+			return init
+		case CmdDeclareCurrency:
+			return currency
+		case CmdDeclare:
+			return declare
+		case CmdDividend, CmdSplit, CmdUpdatePrice:
+			return market
+		default:
+			return ops
+		}
+	}
+	classA, classB := classOf(a.What()), classOf(b.What())
+	dateA, dateB := a.When(), b.When()
 
+	if c := dateA.Compare(dateB)*classes + classA - classB; c != 0 {
+		return c
+	}
+	// Manual prices override the prices of the providers.
+	ua, okA := a.(UpdatePrice)
+	ub, okB := b.(UpdatePrice)
+	if okA && okB && (ua.Source == "") != (ub.Source == "") {
+		if ua.Source == "" {
+			return 1
+		}
+		return -1
+	}
+	return 0
 }
 
 // GlobalInceptionDate returns the date of the earliest transaction, which should be the Init transaction.
 func (l *Ledger) GlobalInceptionDate() Date {
-	if len(l.transactions) > 0 {
-		return l.transactions[0].When()
-	}
-	return Date{}
+	return l.OldestTransactionDate()
 }
 
 // OldestTransactionDate returns the date of the earliest transaction in the ledger.
-// It returns false if the ledger has no transactions.
+// It returns a zero date if the ledger has no transactions.
 func (l *Ledger) OldestTransactionDate() Date {
-	if len(l.transactions) == 0 { // The ledger is not sorted anymore
-		return Date{}
+	if tx, ok := l.Query().First(); ok {
+		return tx.When()
 	}
-	return l.transactions[0].When() // The ledger is not sorted anymore
+	return Date{}
 }
 
 // NewestTransactionDate returns the date of the latest transaction in the ledger.
-// It returns false if the ledger has no transactions.
+// It returns a zero date if the ledger has no transactions.
 func (l *Ledger) NewestTransactionDate() Date {
-	if len(l.transactions) == 0 { // The ledger is not sorted anymore
-		return Date{}
+	if tx, ok := l.Query().Reverse().First(); ok {
+		return tx.When()
 	}
-	return l.transactions[len(l.transactions)-1].When() // The ledger is not sorted anymore
+	return Date{}
 }
 
 // CashBalance computes the total cash in a specific currency on a specific date.
@@ -457,14 +802,42 @@ func (l *Ledger) CashBalance(currency string, on Date) Money {
 	return l.NewSnapshot(on).Cash(currency)
 }
 
+// AvailableCash returns the cash in a specific currency that can be spent on a
+// specific date: the settled cash, less the purchases pending settlement.
+// Without settlement lag, it is the cash balance.
+func (l *Ledger) AvailableCash(currency string, on Date) Money {
+	return l.availableCash("", currency, on)
+}
+
+// availableCash returns the cash in a specific currency that can be spent from
+// a cash account, or from all of them if account is empty.
+func (l *Ledger) availableCash(account, currency string, on Date) Money {
+	s := l.NewSnapshot(on)
+	cash, settled := s.cash(account, currency, false), s.cash(account, currency, true)
+	if settled.LessThan(cash) {
+		return settled
+	}
+	return cash
+}
+
 // Position computes the current holding for a ticker
 func (l *Ledger) Position(on Date, ticker string) Quantity {
+	l = l.view()
 	if l.journal == nil {
 		return Q(decimal.Zero)
 	}
 	return l.NewSnapshot(on).Position(ticker)
 }
 
+// Lent calculates the quantity of a security lent out on a specific date.
+func (l *Ledger) Lent(on Date, ticker string) Quantity {
+	l = l.view()
+	if l.journal == nil {
+		return Q(decimal.Zero)
+	}
+	return l.NewSnapshot(on).Lent(ticker)
+}
+
 // CounterpartyAccountBalance computes the balance of a counterparty account on a specific date.
 func (l *Ledger) CounterpartyAccountBalance(account string, on Date) Money {
 	s := l.NewSnapshot(on)
@@ -473,6 +846,7 @@ func (l *Ledger) CounterpartyAccountBalance(account string, on Date) Money {
 
 // AllCounterpartyAccounts returns a sequence of all unique counterparty account names.
 func (l *Ledger) AllCounterpartyAccounts() iter.Seq[string] {
+	l = l.view()
 	return func(yield func(string) bool) {
 		visited := make(map[string]struct{})
 		for _, tx := range l.transactions {
@@ -507,8 +881,9 @@ func (l *Ledger) AllCounterpartyAccounts() iter.Seq[string] {
 	}
 }
 
-// AllSecurities iterates over securities declared in this ledger.
+// AllSecurities iterates over securities declared in this ledger, by ticker.
 func (l *Ledger) AllSecurities() iter.Seq[Security] {
+	l = l.view()
 	return func(yield func(Security) bool) {
 		tickers := slices.Collect(maps.Keys(l.securities))
 		slices.Sort(tickers)
@@ -533,8 +908,30 @@ func BySecurity(ticker string) func(Transaction) bool {
 			return v.Security == ticker
 		case Dividend:
 			return v.Security == ticker
+		case Expire:
+			return v.Security == ticker
+		case Assign:
+			return v.Security == ticker
+		case Lend:
+			return v.Security == ticker
+		case Recall:
+			return v.Security == ticker
+		case LendingFee:
+			return v.Security == ticker
+		case Grant:
+			return v.Security == ticker
+		case Vest:
+			return v.Security == ticker
+		case Note:
+			return v.Security == ticker
+		case SetPriceAlert:
+			return v.Security == ticker
+		case ClearPriceAlert:
+			return v.Security == ticker
 		case Declare:
 			return v.Ticker == ticker
+		case Custom:
+			return slices.ContainsFunc(v.Positions, func(p PositionEffect) bool { return p.Security == ticker })
 		default:
 			return false
 		}
@@ -554,6 +951,19 @@ func (l *Ledger) ByCurrency(currency string) func(Transaction) bool {
 		case Dividend:
 			sec := l.Security(v.Security)
 			return sec != nil && sec.Currency() == currency
+		case Expire:
+			sec := l.Security(v.Security)
+			return sec != nil && sec.Currency() == currency
+		case Assign:
+			sec := l.Security(v.Security)
+			return sec != nil && sec.Currency() == currency
+		case LendingFee:
+			return v.Amount.Currency() == currency
+		case Grant:
+			sec := l.Security(v.Security)
+			return sec != nil && sec.Currency() == currency
+		case Vest:
+			return v.Currency() == currency
 		case Deposit:
 			return v.Currency() == currency
 		case Withdraw:
@@ -562,6 +972,11 @@ func (l *Ledger) ByCurrency(currency string) func(Transaction) bool {
 			return v.FromCurrency() == currency || v.ToCurrency() == currency
 		case Declare:
 			return v.Currency == currency
+		case DeclareCurrency:
+			return v.Code == currency
+		case Custom:
+			return slices.ContainsFunc(v.Cash, func(c CashEffect) bool { return c.Amount.Currency() == currency }) ||
+				slices.ContainsFunc(v.Positions, func(p PositionEffect) bool { return p.Amount.Currency() == currency })
 		default:
 			return false
 		}
@@ -581,8 +996,9 @@ func ByUpdatePrice() func(Transaction) bool {
 }
 
 // HeldSecuritiesInRange returns an iterator for all securities that had a non-zero
-// position at any point within the given date range.
+// position at any point within the given date range, by ticker.
 func (l *Ledger) HeldSecuritiesInRange(period Range) iter.Seq[Security] {
+	l = l.view()
 	return func(yield func(Security) bool) {
 		heldTickers := make(map[string]struct{})
 
@@ -606,8 +1022,8 @@ func (l *Ledger) HeldSecuritiesInRange(period Range) iter.Seq[Security] {
 			}
 		}
 
-		// Yield the full security info for each held ticker.
-		for ticker := range heldTickers {
+		// Yield the full security info for each held ticker, by ticker.
+		for _, ticker := range slices.Sorted(maps.Keys(heldTickers)) {
 			if sec, ok := l.securities[ticker]; ok {
 				if !yield(sec) {
 					return
@@ -619,6 +1035,7 @@ func (l *Ledger) HeldSecuritiesInRange(period Range) iter.Seq[Security] {
 
 // LastOperationDate returns the date of the last operation for a given security ticker.
 func (ledger *Ledger) LastOperationDate(s string) Date {
+	ledger = ledger.view()
 	// Iterate backwards for efficiency, as we want the most recent date.
 	for i := len(ledger.transactions) - 1; i >= 0; i-- {
 		tx := ledger.transactions[i]
@@ -641,22 +1058,8 @@ func (ledger *Ledger) LastOperationDate(s string) Date {
 // recent `update-price` or `split` transaction for the given security ticker.
 // Deprecated: use Ledger.LastMarketDataDate instead.
 func (l *Ledger) LastKnownMarketDataDate(security string) Date {
-	// Iterate backwards for efficiency, as we want the most recent date.
-	for i := len(l.transactions) - 1; i >= 0; i-- {
-		tx := l.transactions[i]
-
-		switch v := tx.(type) {
-		case UpdatePrice:
-			if _, ok := v.Prices[security]; ok {
-				return v.Date
-			}
-		case Split:
-			if v.Security == security {
-				return v.When()
-			}
-		default:
-			// to avoid lint warning
-		}
+	if tx, ok := l.Query().Command(CmdUpdatePrice, CmdSplit).Security(security).Reverse().First(); ok {
+		return tx.When()
 	}
 	return Date{}
 }
@@ -664,62 +1067,46 @@ func (l *Ledger) LastKnownMarketDataDate(security string) Date {
 // InceptionDate scans the ledger and returns the date of the very first
 // transaction of any kind for the given security ticker.
 func (l *Ledger) InceptionDate(security string) Date {
-	for _, tx := range l.transactions {
-		switch v := tx.(type) {
-		case Buy:
-			if security == v.Security {
-				return tx.When()
-			}
-		case Sell:
-			if security == v.Security {
-				return tx.When()
-			}
-		case Dividend:
-			if security == v.Security {
-				return tx.When()
-			}
-		case Declare:
-			if security == v.Ticker {
-				return tx.When()
-			}
-		case UpdatePrice:
-			// An UpdatePrice can have multiple tickers, so we need to check them all.
-			for t := range v.Prices {
-				if t == security {
-					return tx.When()
-				}
-			}
-			continue // Skip to next transaction
-		case Split:
-			if security == v.Security {
-				return tx.When()
-			}
-		default:
-			continue
-		}
+	if tx, ok := l.Query().Security(security).First(); ok {
+		return tx.When()
 	}
 	return Date{}
 }
 
 // NewSnapshot creates a new portfolio snapshot for a given date.
 func (l *Ledger) NewSnapshot(on Date) *Snapshot {
+	l = l.view()
 	return &Snapshot{
 		name:    l.name,
 		journal: l.journal,
 		on:      on,
+		pivot:   l.pivot,
+		lag:     l.settlementLag,
 	}
 }
 
 // NewReview creates a new portfolio review for a given period.
 func (l *Ledger) NewReview(period Range) *Review {
+	l = l.view()
 	return &Review{
 		start: l.NewSnapshot(period.From.Add(-1)),
 		end:   l.NewSnapshot(period.To),
+		label: period.Label,
 	}
 }
 
+// NewComparativeReview creates a review of the period that is also compared to
+// the immediately preceding period, available with Review.Previous.
+func (l *Ledger) NewComparativeReview(period Range) *Review {
+	l = l.view()
+	r := l.NewReview(period)
+	r.previous = l.NewReview(period.Previous())
+	return r
+}
+
 // GenerateLog generates a log of reviews for each sub-period within a given date range.
 func (l *Ledger) GenerateLog(r Range, period Period) ([]*Review, error) {
+	l = l.view()
 	var result []*Review
 	if l.journal == nil {
 		return nil, errors.New("empty ledger")
@@ -734,5 +1121,6 @@ func (l *Ledger) GenerateLog(r Range, period Period) ([]*Review, error) {
 
 // Journal returns the ledger's journal.
 func (l *Ledger) Journal() *Journal {
+	l = l.view()
 	return l.journal
 }
@@ -1,6 +1,10 @@
 package portfolio
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 )
@@ -113,3 +117,352 @@ func TestLedger_CashBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestLedger_QuantityPrecision(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	declare := NewDeclare(NewDate(2025, 1, 1), "", "FUND", AAPL, "EUR")
+	precision := 3
+	declare.Precision = &precision
+	if err := ledger.Append(declare, NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if _, err := ledger.Validate(NewBuy(NewDate(2025, 1, 3), "", "FUND", Q(1.12345678), EUR(100))); err == nil {
+		t.Errorf("Validate() of a buy with 8 decimals: expected an error")
+	}
+	sec := ledger.Security("FUND")
+	q := sec.RoundQuantity(Q(1.12345678))
+	if want := Q(1.123); !q.Equal(want) {
+		t.Errorf("RoundQuantity() = %v, want %v", q, want)
+	}
+	buy, err := ledger.Validate(NewBuy(NewDate(2025, 1, 3), "", "FUND", q, EUR(100)))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := ledger.Append(buy); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if _, err := ledger.Validate(NewSell(NewDate(2025, 1, 4), "", "FUND", Q(0.0005), EUR(1))); err == nil {
+		t.Errorf("Validate() of a sell with 4 decimals: expected an error")
+	}
+
+	var invalid = -1
+	declare.Ticker, declare.Precision = "OTHER", &invalid
+	if _, err := ledger.Validate(declare); err == nil {
+		t.Errorf("Validate() of a negative precision: expected an error")
+	}
+}
+
+func TestLedger_ExpenseRatio(t *testing.T) {
+	ledger := NewLedger()
+	declare := NewDeclare(NewDate(2025, 1, 1), "", "FUND", AAPL, "EUR")
+	declare.TER = 0.22
+	if err := ledger.Append(declare); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got := ledger.Security("FUND").TER(); got != 0.22 {
+		t.Errorf("Security().TER() = %v, want 0.22%%", got)
+	}
+	if sec, _ := ledger.NewSnapshot(NewDate(2025, 1, 2)).SecurityDetails("FUND"); sec.TER() != 0.22 {
+		t.Errorf("SecurityDetails().TER() = %v, want 0.22%%", sec.TER())
+	}
+
+	declare.Ticker, declare.TER = "OTHER", -1
+	if _, err := ledger.Validate(declare); err == nil {
+		t.Errorf("Validate() of a negative expense ratio: expected an error")
+	}
+}
+
+func TestLedger_Watchlist(t *testing.T) {
+	ledger := NewLedger()
+	declare := NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR")
+	declare.Watch = true
+	if err := ledger.Append(declare, NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if !ledger.Security("AAPL").Watched() {
+		t.Errorf("Security().Watched() = false, want true")
+	}
+
+	if _, err := ledger.Validate(NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(1), EUR(100))); err == nil {
+		t.Errorf("Validate() of a buy of a watched security: expected an error")
+	}
+	for _, tx := range []Transaction{
+		NewUpdatePrice(NewDate(2025, 1, 3), "AAPL", EUR(100)),
+		NewSplit(NewDate(2025, 1, 4), "AAPL", 2, 1),
+	} {
+		if _, err := ledger.Validate(tx); err != nil {
+			t.Errorf("Validate(%v) of market data of a watched security error = %v", tx, err)
+		}
+	}
+}
+
+func TestLedger_Scores(t *testing.T) {
+	ledger := NewLedger()
+	declare := NewDeclare(NewDate(2025, 1, 1), "", "FUND", AAPL, "EUR")
+	declare.Scores = map[string]float64{"esg": 62, "conviction": 8}
+	if err := ledger.Append(declare); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got, ok := ledger.Security("FUND").Score("esg"); !ok || got != 62 {
+		t.Errorf("Security().Score(esg) = %v, %v, want 62", got, ok)
+	}
+	sec, _ := ledger.NewSnapshot(NewDate(2025, 1, 2)).SecurityDetails("FUND")
+	if got, ok := sec.Score("conviction"); !ok || got != 8 {
+		t.Errorf("SecurityDetails().Score(conviction) = %v, %v, want 8", got, ok)
+	}
+	if _, ok := sec.Score("other"); ok {
+		t.Errorf("SecurityDetails().Score(other) found, want no score")
+	}
+
+	var b bytes.Buffer
+	if err := EncodeTransaction(&b, declare); err != nil {
+		t.Fatalf("EncodeTransaction() error = %v", err)
+	}
+	decoded, err := NewDecoder(&b).Decode()
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !decoded.Equal(declare) {
+		t.Errorf("Decode() = %v, want %v", decoded, declare)
+	}
+}
+
+func TestLedger_Journal(t *testing.T) {
+	ledger := NewLedger()
+	buy := NewBuy(NewDate(2025, 1, 3), "", "FUND", Q(10), EUR(1000))
+	buy.Strategy = "dividend"
+	note := NewNote(NewDate(2025, 2, 1), "the yield is still above 4%", "FUND")
+	if err := ledger.Append(NewDeclare(NewDate(2025, 1, 1), "", "FUND", AAPL, "EUR"), NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""), buy, note); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if got := ledger.NewSnapshot(NewDate(2025, 2, 2)).Position("FUND"); !got.Equal(Q(10)) {
+		t.Errorf("Position() after a note = %v, want 10", got)
+	}
+	if _, err := ledger.Validate(NewNote(NewDate(2025, 2, 2), " ", "FUND")); err == nil {
+		t.Errorf("Validate(note without commentary) succeeded, want an error")
+	}
+	if _, err := ledger.Validate(NewNote(NewDate(2025, 2, 2), "a thesis", "OTHER")); err == nil {
+		t.Errorf("Validate(note on an undeclared security) succeeded, want an error")
+	}
+
+	for _, tx := range []Transaction{buy, note} {
+		var b bytes.Buffer
+		if err := EncodeTransaction(&b, tx); err != nil {
+			t.Fatalf("EncodeTransaction() error = %v", err)
+		}
+		decoded, err := NewDecoder(&b).Decode()
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if !decoded.Equal(tx) {
+			t.Errorf("Decode() = %v, want %v", decoded, tx)
+		}
+	}
+}
+
+func TestLedger_Duplicate(t *testing.T) {
+	ledger := NewLedger()
+	deposit := NewDeposit(NewDate(2025, time.January, 2), "", EUR(1000), "")
+	keyed := NewDeposit(NewDate(2025, time.January, 3), "", EUR(500), "")
+	keyed.Key = "op-42"
+	if err := ledger.Append(deposit, keyed); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if got := ledger.Duplicate(NewDeposit(NewDate(2025, time.January, 2), "", EUR(1000), "")); got == nil || !got.Equal(deposit) {
+		t.Errorf("Duplicate(equal deposit) = %v, want %v", got, deposit)
+	}
+	if got := ledger.Duplicate(NewDeposit(NewDate(2025, time.January, 2), "", EUR(999), "")); got != nil {
+		t.Errorf("Duplicate(other deposit) = %v, want nil", got)
+	}
+
+	// A transaction with a key duplicates the transaction with the same key, whatever its content.
+	other := NewDeposit(NewDate(2025, time.January, 4), "", EUR(10), "")
+	other.Key = "op-42"
+	if got := ledger.Duplicate(other); got == nil || !got.Equal(keyed) {
+		t.Errorf("Duplicate(same key) = %v, want %v", got, keyed)
+	}
+	if _, err := ledger.Validate(other); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Validate(same key) error = %v, want %v", err, ErrDuplicateKey)
+	}
+	other.Key = "op-43"
+	if got := ledger.Duplicate(other); got != nil {
+		t.Errorf("Duplicate(new key) = %v, want nil", got)
+	}
+	if _, err := ledger.Validate(other); err != nil {
+		t.Errorf("Validate(new key) error = %v", err)
+	}
+}
+
+func TestLedger_SettlementLag(t *testing.T) {
+	friday, monday, wednesday := NewDate(2025, time.January, 3), NewDate(2025, time.January, 6), NewDate(2025, time.January, 8)
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, time.January, 2), "", EUR(100), ""),
+		NewBuy(NewDate(2025, time.January, 2), "", "AAPL", Q(10), EUR(100)),
+		NewSell(friday, "", "AAPL", Q(10), EUR(120)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	buy := NewBuy(friday, "", "AAPL", Q(5), EUR(60))
+
+	// Without lag, everything settles on the trade date.
+	if _, err := ledger.Validate(buy); err != nil {
+		t.Errorf("Validate(buy) without lag error = %v", err)
+	}
+	if got := ledger.AvailableCash("EUR", friday); !got.Equal(EUR(120)) {
+		t.Errorf("AvailableCash() without lag = %v, want 120 EUR", got)
+	}
+
+	ledger.SetSettlementLag(2)
+	if got := ledger.SettlementDate(friday); got != NewDate(2025, time.January, 7) {
+		t.Errorf("SettlementDate(%s) = %s, want 2025-01-07", friday, got)
+	}
+	s := ledger.NewSnapshot(monday)
+	if cash, settled, pending := s.Cash("EUR"), s.SettledCash("EUR"), s.PendingCash("EUR"); !cash.Equal(EUR(120)) || !settled.Equal(EUR(0)) || !pending.Equal(EUR(120)) {
+		t.Errorf("on %s: cash, settled, pending = %v, %v, %v, want 120, 0, 120 EUR", monday, cash, settled, pending)
+	}
+	if got := ledger.NewSnapshot(wednesday).SettledCash("EUR"); !got.Equal(EUR(120)) {
+		t.Errorf("SettledCash() on %s = %v, want 120 EUR", wednesday, got)
+	}
+
+	// The proceeds of the sell fund a buy of the same day, but cannot be withdrawn before settlement.
+	if _, err := ledger.Validate(buy); err != nil {
+		t.Errorf("Validate(buy) with lag error = %v", err)
+	}
+	if _, err := ledger.Validate(NewWithdraw(monday, "", EUR(50))); err == nil {
+		t.Errorf("Validate(withdraw) of unsettled cash: expected an error")
+	}
+	if _, err := ledger.Validate(NewWithdraw(wednesday, "", EUR(50))); err != nil {
+		t.Errorf("Validate(withdraw) of settled cash error = %v", err)
+	}
+}
+
+func TestLedger_Replace(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2025, time.January, 1), "", "EUR"),
+		NewDeposit(NewDate(2025, time.January, 2), "", EUR(1000), ""),
+		NewWithdraw(NewDate(2025, time.January, 3), "", EUR(800)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	replaced, err := ledger.Replace(1, NewDeposit(NewDate(2025, time.January, 2), "", EUR(1200), ""))
+	if err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if got := replaced.CashBalance("EUR", NewDate(2025, time.January, 3)); !got.Equal(EUR(400)) {
+		t.Errorf("CashBalance() after Replace() = %v, want 400", got)
+	}
+	if got := ledger.CashBalance("EUR", NewDate(2025, time.January, 3)); !got.Equal(EUR(200)) {
+		t.Errorf("CashBalance() of the original ledger = %v, want 200", got)
+	}
+
+	// The withdrawal after the replaced deposit is validated again.
+	if _, err := ledger.Replace(1, NewDeposit(NewDate(2025, time.January, 2), "", EUR(500), "")); !errors.Is(err, ErrInsufficientCash) {
+		t.Errorf("Replace() invalidating a later withdrawal error = %v, want %v", err, ErrInsufficientCash)
+	}
+	if _, err := ledger.Replace(3, NewDeposit(NewDate(2025, time.January, 2), "", EUR(500), "")); err == nil {
+		t.Error("Replace() of an index out of range succeeded, want an error")
+	}
+}
+
+func TestLedger_TimeZone(t *testing.T) {
+	ledger := NewLedger()
+	if ledger.TimeZone() != nil {
+		t.Errorf("TimeZone() of a new ledger = %v, want nil", ledger.TimeZone())
+	}
+	init := NewInit(NewDate(2025, time.January, 1), "", "USD")
+	init.TimeZone = "America/New_York"
+	if err := ledger.Append(init); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if got := ledger.TimeZone(); got == nil || got.String() != "America/New_York" {
+		t.Errorf("TimeZone() = %v, want America/New_York", got)
+	}
+	if want := NewDate(time.Now().In(ledger.TimeZone()).Date()); ledger.Today() != want {
+		t.Errorf("Today() = %s, want %s", ledger.Today(), want)
+	}
+
+	// Updating the init replaces it.
+	update := Init{Currency: "USD", TimeZone: "Asia/Tokyo"}
+	validated, err := ledger.Validate(update)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := ledger.Append(validated); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if n := len(ledger.transactions); n != 1 {
+		t.Errorf("len(transactions) = %d after an init update, want 1", n)
+	}
+	if got := ledger.TimeZone(); got == nil || got.String() != "Asia/Tokyo" {
+		t.Errorf("TimeZone() = %v, want Asia/Tokyo", got)
+	}
+
+	init.TimeZone = "Europe/Nowhere"
+	if _, err := NewLedger().Validate(init); err == nil {
+		t.Error("Validate() of an init with an unknown time zone expected an error")
+	}
+}
+
+func TestLedger_PriceSources(t *testing.T) {
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	day1, day2 := NewDate(2025, time.January, 2), NewDate(2025, time.January, 3)
+	if err := ledger.Append(NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD"), NewUpdatePrice(day1, "AAPL", USD(100))); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	sourced := func(day Date, price Money, source string) UpdatePrice {
+		u := NewUpdatePrice(day, "AAPL", price)
+		u.Source = source
+		return u
+	}
+	if _, err := ledger.UpdateMarketData(sourced(day1, USD(101), "eodhd"), sourced(day2, USD(102), "tradegate")); err != nil {
+		t.Fatalf("UpdateMarketData() error = %v", err)
+	}
+	// The last provider replaces the price of the others.
+	if _, err := ledger.UpdateMarketData(sourced(day2, USD(103), "eodhd")); err != nil {
+		t.Fatalf("UpdateMarketData() error = %v", err)
+	}
+
+	// The manual price overrides the price of the provider.
+	if got := ledger.NewSnapshot(day1).Price("AAPL"); !got.Equal(USD(100)) {
+		t.Errorf("Price(%s) = %v, want the manual price", day1, got)
+	}
+	bars := ledger.OHLC("AAPL", NewRange(day1, day2), Daily)
+	if len(bars) != 2 || bars[0].Source != "" || bars[1].Source != "eodhd" || !bars[1].Close.Equal(USD(103)) {
+		t.Errorf("OHLC() = %v, want a manual price then the eodhd price", bars)
+	}
+	n := 0
+	for _, tx := range ledger.transactions {
+		if u, ok := tx.(UpdatePrice); ok && u.Source == "tradegate" {
+			n++
+		}
+	}
+	if n != 0 {
+		t.Errorf("UpdateMarketData() kept %d tradegate updates, want them replaced", n)
+	}
+
+	// Sources are kept in the split price files.
+	ledger.SetSplitPrices(true)
+	dir := t.TempDir()
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+	loaded, err := FindLedger(context.Background(), dir, "ledger")
+	if err != nil {
+		t.Fatalf("FindLedger() error = %v", err)
+	}
+	if got := loaded.OHLC("AAPL", NewRange(day1, day2), Daily); !slices.EqualFunc(got, bars, func(a, b OHLC) bool {
+		return a.Source == b.Source && a.Close.Equal(b.Close)
+	}) {
+		t.Errorf("loaded OHLC() = %v, want %v", got, bars)
+	}
+}
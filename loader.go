@@ -1,18 +1,22 @@
 package portfolio
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // FindLedger return the unique ledger corresponding with the name.
 // If there is only one ledger found, returns it.
 // If the query is meant to match all ledgers and the list is empty returns an empty default ledger.
 // In any other cases it returns an error.
-func FindLedger(path, query string) (*Ledger, error) {
+// Loading stops with the error of the context if it is done.
+func FindLedger(ctx context.Context, path, query string) (*Ledger, error) {
 
 	ledgerPaths, err := findLedgerPaths(path, query)
 	if err != nil {
@@ -29,7 +33,7 @@ func FindLedger(path, query string) (*Ledger, error) {
 		}
 		return nil, fmt.Errorf("could not find ledger %q", query)
 	case 1:
-		return loadLedgerFile(path, ledgerPaths[0])
+		return loadLedgerFile(ctx, path, ledgerPaths[0])
 	default:
 		return nil, fmt.Errorf("multiple ledgers found for %q", query)
 	}
@@ -40,7 +44,8 @@ func FindLedger(path, query string) (*Ledger, error) {
 // If query is empty, all ledgers (.jsonl files) in the path are loaded.
 // If query specifies a ledger name (e.g., "john/bnp"), only that ledger is loaded.
 // A ledger name is its relative path from the portfolio path, without the .jsonl extension.
-func FindLedgers(path, query string) ([]*Ledger, error) {
+// Loading stops with the error of the context if it is done.
+func FindLedgers(ctx context.Context, path, query string) ([]*Ledger, error) {
 	ledgerPaths, err := findLedgerPaths(path, query)
 	if err != nil {
 		return nil, err
@@ -48,7 +53,7 @@ func FindLedgers(path, query string) ([]*Ledger, error) {
 
 	var loadedLedgers []*Ledger
 	for _, fullPath := range ledgerPaths {
-		ledger, err := loadLedgerFile(path, fullPath)
+		ledger, err := loadLedgerFile(ctx, path, fullPath)
 		if err != nil {
 			// In a multi-file load, it's better to return a partial result with an error
 			// or just the error, depending on the desired behavior. Here we fail fast.
@@ -62,7 +67,35 @@ func FindLedgers(path, query string) ([]*Ledger, error) {
 
 // loadLedgerFile opens, decodes, and initializes a ledger from a given file path.
 // It sets the ledger's name based on its relative path to the portfolio root.
-func loadLedgerFile(portfolioPath, fullPath string) (*Ledger, error) {
+// LedgerTimeZone returns the time zone of the unique ledger corresponding
+// with the query, see Init.TimeZone, without loading the whole ledger: it is
+// read from the init transaction, the first one of the ledger file. It returns
+// nil if there is no unique ledger, or if it has no time zone.
+func LedgerTimeZone(path, query string) (*time.Location, error) {
+	ledgerPaths, err := findLedgerPaths(path, query)
+	if err != nil || len(ledgerPaths) != 1 {
+		return nil, err
+	}
+	f, err := os.Open(ledgerPaths[0])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tx, err := NewDecoder(f).Decode()
+	if err != nil {
+		return nil, nil // the ledger is empty, or the error is reported when it is loaded.
+	}
+	init, ok := tx.(Init)
+	if !ok || init.TimeZone == "" {
+		return nil, nil
+	}
+	return time.LoadLocation(init.TimeZone)
+}
+
+func loadLedgerFile(ctx context.Context, portfolioPath, fullPath string) (*Ledger, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	relPath, err := filepath.Rel(portfolioPath, fullPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not determine relative path for %q: %w", fullPath, err)
@@ -75,18 +108,64 @@ func loadLedgerFile(portfolioPath, fullPath string) (*Ledger, error) {
 	}
 	defer f.Close()
 
-	ledger, err := DecodeLedger(f)
+	ledger, err := NewDecoder(f).decodeLedger()
 	if err != nil {
 		return nil, fmt.Errorf("could not decode ledger file %q: %w", fullPath, err)
 	}
 	ledger.name = ledgerName
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Merge the prices stored apart, if any. They are appended after the
+	// ledger transactions, so the stable sort puts them at the end of their day.
+	if info, err := os.Stat(pricesDir(fullPath)); err == nil && info.IsDir() {
+		prices, err := decodePriceFiles(pricesDir(fullPath))
+		if err != nil {
+			return nil, err
+		}
+		ledger.transactions = append(ledger.transactions, prices...)
+		ledger.splitPrices = true
+	}
+	if err := ledger.index(); err != nil {
+		return nil, fmt.Errorf("could not decode ledger file %q: %w", fullPath, err)
+	}
+
+	if f, err := os.Open(intradayFile(fullPath)); err == nil {
+		defer f.Close()
+		if err := ledger.decodeIntradayFile(f); err != nil {
+			return nil, fmt.Errorf("could not decode intraday file %q: %w", intradayFile(fullPath), err)
+		}
+	}
+	if f, err := os.Open(conflictsFile(fullPath)); err == nil {
+		defer f.Close()
+		if err := ledger.decodeConflictsFile(f); err != nil {
+			return nil, fmt.Errorf("could not decode conflicts file %q: %w", conflictsFile(fullPath), err)
+		}
+	}
 	return ledger, nil
 }
 
 // SaveLedger saves a single ledger to its corresponding file within the portfolio path.
 // It uses the ledger's name to construct the file path (e.g., a ledger named "john/bnp"
 // will be saved to "<path>/john/bnp.jsonl").
+//
+// If the ledger splits its prices, price updates are saved in per-year files
+// in the directory "<path>/john/bnp.prices" instead. Otherwise, that directory
+// is removed.
+//
+// The intraday quotes are saved in "<path>/john/bnp.intraday.csv", see
+// RecordQuote, and the price conflicts queued for review in
+// "<path>/john/bnp.conflicts.jsonl", see ReviewConflicts.
+//
+// The transactions added and removed since the last save are appended to the
+// audit log "<path>/john/bnp.audit.jsonl", see ReadAuditLog, once the ledger is
+// saved.
+//
+// The ledger and price files are replaced atomically: each is either the
+// previous or the new one, even if saving fails.
 func SaveLedger(path string, ledger *Ledger) error {
+	ledger = ledger.view()
 	ledgerName := ledger.Name()
 	if ledgerName == "" {
 		return fmt.Errorf("cannot save ledger with an empty name")
@@ -99,13 +178,69 @@ func SaveLedger(path string, ledger *Ledger) error {
 		return fmt.Errorf("could not create directory for ledger %q: %w", filePath, err)
 	}
 
-	file, err := os.Create(filePath)
+	// The changes are computed against the previous ledger file, and logged
+	// once the new one is saved.
+	changes, err := ledgerChanges(filePath, ledger)
 	if err != nil {
-		return fmt.Errorf("error opening ledger file %q for writing: %w", filePath, err)
+		return err
+	}
+
+	// In the split layout, the ledger file only holds the other transactions.
+	main := ledger
+	if ledger.splitPrices {
+		main = NewLedger()
+		var prices []Transaction
+		for _, tx := range ledger.transactions {
+			if isSplitPrice(tx) {
+				prices = append(prices, tx)
+			} else {
+				main.transactions = append(main.transactions, tx)
+			}
+		}
+		if err := encodePriceFiles(pricesDir(filePath), prices); err != nil {
+			return err
+		}
+	} else if err := os.RemoveAll(pricesDir(filePath)); err != nil {
+		return fmt.Errorf("could not remove price directory of %q: %w", filePath, err)
 	}
-	defer file.Close()
 
-	return EncodeLedger(file, ledger)
+	if err := encodeIntradayFile(intradayFile(filePath), ledger.intraday); err != nil {
+		return err
+	}
+	if err := encodeConflictsFile(conflictsFile(filePath), ledger.conflicts); err != nil {
+		return err
+	}
+
+	if err := writeFile(filePath, func(w io.Writer) error { return EncodeLedger(w, main) }); err != nil {
+		return fmt.Errorf("error writing ledger file %q: %w", filePath, err)
+	}
+	return appendAuditEntry(filePath, changes)
+}
+
+// writeFile replaces a file atomically with the content written by write: the
+// content is written to a temporary file, renamed to the file once complete.
+// The file keeps its permissions if it exists.
+func writeFile(file string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(file), ".pcs-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(file); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
 }
 
 // findLedgerPaths scans a directory and returns a map of ledger names to their full file paths.
@@ -116,7 +251,7 @@ func findLedgerPaths(path, query string) ([]string, error) {
 		if err != nil {
 			return err
 		}
-		if !d.IsDir() && strings.HasSuffix(p, ".jsonl") {
+		if !d.IsDir() && strings.HasSuffix(p, ".jsonl") && !isAuditFile(p) && !isConflictsFile(p) {
 
 			relPath, err := filepath.Rel(path, p)
 			if err != nil {
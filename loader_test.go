@@ -0,0 +1,28 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFindLedger_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewLedger()
+	ledger.name = "ledger"
+	if err := ledger.Append(NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if err := SaveLedger(dir, ledger); err != nil {
+		t.Fatalf("SaveLedger() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FindLedger(ctx, dir, "ledger"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindLedger() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := FindLedgers(ctx, dir, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindLedgers() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,189 @@
+// Package lookthrough decomposes the funds held in a portfolio into their
+// constituents, to aggregate the true exposure to the underlying companies,
+// sectors and regions across overlapping funds.
+//
+// The constituents of a security are read from a CSV file named after its
+// ticker, e.g. "VWCE.csv", with a header and one row per constituent:
+//
+//	name,weight,sector,region
+//	Apple,4.8,Technology,United States
+//	Microsoft,4.3,Technology,United States
+//
+// The weight is in percent of the fund. The sector and the region are
+// optional. The file is written by hand from the fund factsheet, or by a
+// provider. Constituents weighing less than 100% in total leave the remainder
+// unknown. A stock can have a single constituent, itself, to attach its sector
+// and region.
+package lookthrough
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Unknown is the name of the exposure of the values without constituents, or
+// without sector or region.
+const Unknown = "Unknown"
+
+// Constituent is a component of a fund.
+type Constituent struct {
+	Name   string
+	Weight float64 // Weight is the share of the fund, in percent.
+	Sector string
+	Region string
+}
+
+// ReadCSV reads the constituents of a fund from CSV.
+func ReadCSV(r io.Reader) ([]Constituent, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing csv header")
+	}
+	columns := make(map[string]int)
+	for i, h := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"name", "weight"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q in csv header", required)
+		}
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var constituents []Constituent
+	var total float64
+	for line, record := range records[1:] {
+		weight, err := strconv.ParseFloat(strings.TrimSuffix(field(record, "weight"), "%"), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("line %d: invalid weight %q", line+2, field(record, "weight"))
+		}
+		c := Constituent{
+			Name:   field(record, "name"),
+			Weight: weight,
+			Sector: field(record, "sector"),
+			Region: field(record, "region"),
+		}
+		if c.Name == "" {
+			return nil, fmt.Errorf("line %d: missing constituent name", line+2)
+		}
+		total += weight
+		constituents = append(constituents, c)
+	}
+	if total > 100.5 {
+		return nil, fmt.Errorf("constituents weigh %g%% in total, more than 100%%", total)
+	}
+	return constituents, nil
+}
+
+// Load reads the constituents of securities from the CSV files named after
+// their tickers in a directory. Securities without a file are not in the
+// result.
+func Load(dir string, tickers ...string) (map[string][]Constituent, error) {
+	breakdowns := make(map[string][]Constituent)
+	for _, ticker := range tickers {
+		f, err := os.Open(filepath.Join(dir, ticker+".csv"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		constituents, err := ReadCSV(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid constituents of %q: %w", ticker, err)
+		}
+		breakdowns[ticker] = constituents
+	}
+	return breakdowns, nil
+}
+
+// Exposure is the value exposed to a company, a sector or a region.
+type Exposure struct {
+	Name   string
+	Value  float64
+	Weight float64 // Weight is the share of the total value, in percent.
+}
+
+// Report is the exposure of a portfolio, by decreasing value.
+type Report struct {
+	Total     float64
+	Companies []Exposure
+	Sectors   []Exposure
+	Regions   []Exposure
+}
+
+// Aggregate returns the exposure of the values of securities, decomposed into
+// their constituents. The exposures to the same company, sector or region
+// through several securities are added up. A security without constituents
+// is exposed to itself, in unknown sector and region.
+func Aggregate(values map[string]float64, breakdowns map[string][]Constituent) Report {
+	companies := make(map[string]float64)
+	sectors := make(map[string]float64)
+	regions := make(map[string]float64)
+	var r Report
+	for _, ticker := range slices.Sorted(maps.Keys(values)) {
+		value := values[ticker]
+		r.Total += value
+		constituents, ok := breakdowns[ticker]
+		if !ok {
+			companies[ticker] += value
+			sectors[Unknown] += value
+			regions[Unknown] += value
+			continue
+		}
+		rest := value
+		for _, c := range constituents {
+			v := value * c.Weight / 100
+			rest -= v
+			companies[c.Name] += v
+			sectors[cmp.Or(c.Sector, Unknown)] += v
+			regions[cmp.Or(c.Region, Unknown)] += v
+		}
+		if rest > 1e-9*value {
+			companies[Unknown] += rest
+			sectors[Unknown] += rest
+			regions[Unknown] += rest
+		}
+	}
+	r.Companies = exposures(companies, r.Total)
+	r.Sectors = exposures(sectors, r.Total)
+	r.Regions = exposures(regions, r.Total)
+	return r
+}
+
+// exposures returns the values by name as exposures, by decreasing value.
+func exposures(values map[string]float64, total float64) []Exposure {
+	var list []Exposure
+	for name, v := range values {
+		e := Exposure{Name: name, Value: v}
+		if total != 0 {
+			e.Weight = 100 * v / total
+		}
+		list = append(list, e)
+	}
+	slices.SortFunc(list, func(a, b Exposure) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), cmp.Compare(a.Name, b.Name))
+	})
+	return list
+}
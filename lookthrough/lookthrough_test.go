@@ -0,0 +1,81 @@
+package lookthrough
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	got, err := ReadCSV(strings.NewReader("Name,Weight,Region\nApple,60%,United States\nASML,30,Netherlands\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	want := []Constituent{{Name: "Apple", Weight: 60, Region: "United States"}, {Name: "ASML", Weight: 30, Region: "Netherlands"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ReadCSV() = %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"", "name,sector\nApple,Technology\n", "name,weight\nApple,many\n", "name,weight\nApple,60\nASML,50\n", "name,weight\n,10\n"} {
+		if _, err := ReadCSV(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ReadCSV(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "FUND.csv"), []byte("name,weight\nApple,100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir, "FUND", "AAPL")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 1 || len(got["FUND"]) != 1 {
+		t.Errorf("Load() = %v, want the constituents of FUND only", got)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	// Two overlapping funds, and a stock held directly.
+	values := map[string]float64{"WORLD": 1000, "TECH": 500, "AAPL": 100}
+	breakdowns := map[string][]Constituent{
+		"WORLD": {{Name: "Apple", Weight: 5, Sector: "Technology", Region: "United States"}, {Name: "Nestle", Weight: 2, Sector: "Consumer", Region: "Switzerland"}},
+		"TECH":  {{Name: "Apple", Weight: 20, Sector: "Technology", Region: "United States"}, {Name: "ASML", Weight: 80, Sector: "Technology"}},
+	}
+	r := Aggregate(values, breakdowns)
+	if r.Total != 1600 {
+		t.Errorf("Aggregate().Total = %v, want 1600", r.Total)
+	}
+	find := func(list []Exposure, name string) float64 {
+		for _, e := range list {
+			if e.Name == name {
+				return e.Value
+			}
+		}
+		return math.NaN()
+	}
+	for _, tc := range []struct {
+		list []Exposure
+		name string
+		want float64
+	}{
+		{r.Companies, "Apple", 150},
+		{r.Companies, "AAPL", 100},
+		{r.Companies, Unknown, 930},
+		{r.Sectors, "Technology", 550},
+		{r.Sectors, Unknown, 1030},
+		{r.Regions, "United States", 150},
+		{r.Regions, Unknown, 1430},
+	} {
+		if got := find(tc.list, tc.name); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Aggregate() exposure to %q = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if r.Companies[0].Name != Unknown || math.Abs(r.Companies[0].Weight-100*930.0/1600) > 1e-9 {
+		t.Errorf("Aggregate().Companies[0] = %v, want the unknown remainder first", r.Companies[0])
+	}
+}
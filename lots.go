@@ -10,8 +10,11 @@ type lot struct {
 	Date     Date
 	Quantity Quantity
 	Cost     Money // Total cost of the lot (quantity * price)
+	BaseCost Money // Cost in the reporting currency, at the exchange rate of the purchase date.
 }
 
+// lots are either all long, or all short: the lots of a short option position have
+// negative quantity and cost, and are closed by selling negative quantities.
 type lots []lot
 
 // fifoCostOfSelling calculates the cost of selling a quantity of shares using FIFO.
@@ -19,7 +22,7 @@ func (l lots) fifoCostOfSelling(quantityToSell Quantity) Money {
 	var costOfSoldShares Money
 
 	for _, currentLot := range l {
-		if currentLot.Quantity.GreaterThan(quantityToSell) {
+		if currentLot.Quantity.Abs().GreaterThan(quantityToSell.Abs()) {
 			// Partial sale from this lot
 			costOfSoldPortion := currentLot.Cost.Mul(quantityToSell).Div(currentLot.Quantity)
 			costOfSoldShares = costOfSoldShares.Add(costOfSoldPortion)
@@ -43,13 +46,15 @@ func (l lots) sell(quantityToSell Quantity) lots {
 			continue
 		}
 
-		if currentLot.Quantity.GreaterThan(quantityToSell) {
+		if currentLot.Quantity.Abs().GreaterThan(quantityToSell.Abs()) {
 			// Partial sale from this lot
 			costOfSoldPortion := currentLot.Cost.Mul(quantityToSell).Div(currentLot.Quantity)
+			baseCostOfSoldPortion := currentLot.BaseCost.Mul(quantityToSell).Div(currentLot.Quantity)
 			newLot := lot{
 				Date:     currentLot.Date,
 				Quantity: currentLot.Quantity.Sub(quantityToSell),
 				Cost:     currentLot.Cost.Sub(costOfSoldPortion),
+				BaseCost: currentLot.BaseCost.Sub(baseCostOfSoldPortion),
 			}
 			remainingLots = append(remainingLots, newLot)
 			quantityToSell = Q(decimal.Zero)
@@ -60,3 +65,55 @@ func (l lots) sell(quantityToSell Quantity) lots {
 	}
 	return remainingLots
 }
+
+// OpenLot is a purchase of a security still held, after the sales matched
+// against the lots in FIFO order.
+type OpenLot struct {
+	Security string
+	Acquired Date
+	Quantity Quantity
+	Cost     Money // Cost is the cost of the shares still held, fees included.
+}
+
+// HoldingDays returns the number of days the lot has been held on a date.
+func (l OpenLot) HoldingDays(on Date) int { return on.Sub(l.Acquired) }
+
+// LongTermDate returns the first day the lot is held long enough for its gain
+// to be long-term under the rules.
+func (l OpenLot) LongTermDate(rules TaxRules) Date { return l.Acquired.Add(rules.LongTermDays + 1) }
+
+// LongTerm returns true if a sale of the lot on a date is long-term under the rules.
+func (l OpenLot) LongTerm(on Date, rules TaxRules) bool {
+	return l.HoldingDays(on) > rules.LongTermDays
+}
+
+// OpenLots returns the lots of a security held on the snapshot's date, oldest
+// first. Sales are matched against the lots in FIFO order, like in the tax
+// report, to know the acquisition date of each share held.
+func (s *Snapshot) OpenLots(ticker string) []OpenLot {
+	var held lots
+	for e := range s.events() {
+		switch v := e.(type) {
+		case acquireLot:
+			if v.security == ticker {
+				held = append(held, lot{Date: v.on, Quantity: v.quantity, Cost: v.cost})
+			}
+		case splitShare:
+			if v.security == ticker {
+				num, den := Q(v.numerator), Q(v.denominator)
+				for i := range held {
+					held[i].Quantity = held[i].Quantity.Mul(num).Div(den)
+				}
+			}
+		case disposeLot:
+			if v.security == ticker {
+				held = held.sell(v.quantity)
+			}
+		}
+	}
+	open := make([]OpenLot, 0, len(held))
+	for _, l := range held {
+		open = append(open, OpenLot{Security: ticker, Acquired: l.Date, Quantity: l.Quantity, Cost: l.Cost})
+	}
+	return open
+}
@@ -0,0 +1,38 @@
+package portfolio
+
+import "testing"
+
+func TestSnapshot_OpenLots(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2024, 1, 1), "", "EUR"),
+		NewDeclare(NewDate(2024, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2024, 1, 1), "", EUR(10000), ""),
+		NewBuy(NewDate(2024, 1, 2), "", "AAPL", Q(10), EUR(1000)),
+		NewBuy(NewDate(2024, 6, 3), "", "AAPL", Q(10), EUR(1500)),
+		NewSell(NewDate(2024, 9, 2), "", "AAPL", Q(15), EUR(2000)),
+		NewSplit(NewDate(2024, 10, 1), "AAPL", 2, 1),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	on := NewDate(2025, 1, 2)
+	lots := ledger.NewSnapshot(on).OpenLots("AAPL")
+	if len(lots) != 1 {
+		t.Fatalf("OpenLots() = %v, want the second lot only", lots)
+	}
+	l := lots[0]
+	if l.Acquired != NewDate(2024, 6, 3) || !l.Quantity.Equal(Q(10)) || !l.Cost.Equal(EUR(750)) {
+		t.Errorf("OpenLots() = %v, want 10 shares acquired on 2024-06-03 for 750 EUR", l)
+	}
+	if got := l.HoldingDays(on); got != 213 {
+		t.Errorf("HoldingDays() = %d, want 213", got)
+	}
+	if got := l.LongTermDate(DefaultTaxRules); got != NewDate(2025, 6, 4) {
+		t.Errorf("LongTermDate() = %s, want 2025-06-04", got)
+	}
+	if l.LongTerm(NewDate(2025, 6, 3), DefaultTaxRules) || !l.LongTerm(NewDate(2025, 6, 4), DefaultTaxRules) {
+		t.Errorf("LongTerm() must be true from 2025-06-04 only")
+	}
+}
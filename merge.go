@@ -0,0 +1,229 @@
+package portfolio
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Conflict is a ticker declared for different securities in two merged ledgers.
+type Conflict struct {
+	Ticker        string
+	First, Second Declare // First and Second are the declarations of the ticker in each ledger.
+}
+
+// Resolution is the resolution of a conflict when merging ledgers.
+type Resolution int
+
+const (
+	// KeepFirst keeps the declaration of the first ledger: the transactions of
+	// both ledgers are about the same security.
+	KeepFirst Resolution = iota
+	// KeepSecond keeps the declaration of the second ledger: the transactions
+	// of both ledgers are about the same security.
+	KeepSecond
+	// RenameSecond keeps both securities: the ticker of the second ledger is
+	// renamed, see Conflict.Renamed.
+	RenameSecond
+)
+
+// Renamed returns the ticker of the security of the second ledger, renamed by
+// the RenameSecond resolution.
+func (c Conflict) Renamed() string { return c.Ticker + "-2" }
+
+// Conflicts returns the tickers declared for different securities in two
+// ledgers, in the order of the second ledger.
+func Conflicts(first, second *Ledger) []Conflict {
+	declared := make(map[string]Declare)
+	for _, tx := range first.Query().Command(CmdDeclare).All() {
+		declared[tx.(Declare).Ticker] = tx.(Declare)
+	}
+	var conflicts []Conflict
+	for _, tx := range second.Query().Command(CmdDeclare).All() {
+		d := tx.(Declare)
+		if f, ok := declared[d.Ticker]; ok && f.ID != d.ID {
+			conflicts = append(conflicts, Conflict{Ticker: d.Ticker, First: f, Second: d})
+		}
+	}
+	return conflicts
+}
+
+// Merge returns a new ledger with the transactions of two ledgers, validated in
+// chronological order. The transactions of the second ledger already in the
+// first one (equal, or with the same dedup key) are skipped.
+//
+// A ticker declared for the same security in both ledgers is declared once, on
+// the earliest date. A ticker declared for different securities is a conflict,
+// resolved by resolve. The ledgers must have the same reporting currency.
+func Merge(first, second *Ledger, resolve func(Conflict) Resolution) (*Ledger, error) {
+	if first.Currency() != second.Currency() {
+		return nil, fmt.Errorf("cannot merge ledgers with different reporting currencies %s and %s", first.Currency(), second.Currency())
+	}
+	resolutions := make(map[string]Resolution)
+	for _, c := range Conflicts(first, second) {
+		resolutions[c.Ticker] = resolve(c)
+	}
+	declared := make(map[string]Declare)
+	for _, tx := range second.Query().Command(CmdDeclare).All() {
+		declared[tx.(Declare).Ticker] = tx.(Declare)
+	}
+
+	merged := NewLedger()
+	first = first.view()
+	merged.name = first.name
+	merged.currency = first.currency
+	merged.splitPrices = first.splitPrices
+	merged.pivot = first.pivot
+	merged.settlementLag = first.settlementLag
+	var init *Init
+	for _, tx := range first.Query().All() {
+		switch v := tx.(type) {
+		case Init:
+			init = &v
+			continue
+		case Declare:
+			// The declaration of the second ledger is kept instead if the
+			// conflict is resolved so, or if it is the earliest.
+			if d, ok := declared[v.Ticker]; ok {
+				if r, conflict := resolutions[v.Ticker]; conflict && r == KeepSecond || !conflict && d.Date.Before(v.Date) {
+					continue
+				}
+			}
+		}
+		merged.transactions = append(merged.transactions, tx)
+	}
+	for _, tx := range second.Query().All() {
+		switch v := tx.(type) {
+		case Init:
+			if init == nil || v.Date.Before(init.Date) {
+				init = &v
+			}
+			continue
+		case Declare:
+			// The declaration of the first ledger is kept instead if the
+			// conflict is resolved so, or if it is not later.
+			if first.Security(v.Ticker) != nil {
+				if r, conflict := resolutions[v.Ticker]; conflict && r == KeepFirst || !conflict && !v.Date.Before(first.declarationDate(v.Ticker)) {
+					continue
+				}
+			}
+		}
+		for _, ticker := range slices.Sorted(maps.Keys(resolutions)) {
+			if resolutions[ticker] == RenameSecond {
+				tx = renameSecurity(tx, ticker, Conflict{Ticker: ticker}.Renamed())
+			}
+		}
+		if first.Duplicate(tx) == nil {
+			merged.transactions = append(merged.transactions, tx)
+		}
+	}
+	if init != nil {
+		merged.transactions = append(merged.transactions, *init)
+	}
+	merged.stableSort()
+
+	// Validate the transactions in chronological order, like Fmt.
+	validated := NewLedger()
+	validated.name = merged.name
+	validated.currency = merged.currency
+	validated.splitPrices = merged.splitPrices
+	validated.pivot = merged.pivot
+	validated.settlementLag = merged.settlementLag
+	for _, tx := range merged.transactions {
+		valid, err := validated.Validate(tx)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed for transaction on %s (%T): %w", tx.When(), tx, err)
+		}
+		if err := validated.append(valid); err != nil {
+			return nil, fmt.Errorf("failed to append transaction on %s: %w", tx.When(), err)
+		}
+	}
+	return validated, nil
+}
+
+// declarationDate returns the date of the declaration of a ticker.
+func (l *Ledger) declarationDate(ticker string) Date {
+	for _, tx := range l.Query().Command(CmdDeclare).All() {
+		if tx.(Declare).Ticker == ticker {
+			return tx.When()
+		}
+	}
+	return Date{}
+}
+
+// renameSecurity returns the transaction with the ticker of a security renamed.
+func renameSecurity(tx Transaction, from, to string) Transaction {
+	rename := func(sc *secCmd) {
+		if sc.Security == from {
+			sc.Security = to
+		}
+	}
+	switch v := tx.(type) {
+	case Declare:
+		if v.Ticker == from {
+			v.Ticker = to
+		}
+		return v
+	case Buy:
+		rename(&v.secCmd)
+		return v
+	case Sell:
+		rename(&v.secCmd)
+		return v
+	case Dividend:
+		rename(&v.secCmd)
+		return v
+	case Split:
+		rename(&v.secCmd)
+		return v
+	case Expire:
+		rename(&v.secCmd)
+		return v
+	case Assign:
+		rename(&v.secCmd)
+		return v
+	case Lend:
+		rename(&v.secCmd)
+		return v
+	case Recall:
+		rename(&v.secCmd)
+		return v
+	case LendingFee:
+		rename(&v.secCmd)
+		return v
+	case Grant:
+		rename(&v.secCmd)
+		return v
+	case Vest:
+		rename(&v.secCmd)
+		return v
+	case Note:
+		rename(&v.secCmd)
+		return v
+	case SetPriceAlert:
+		rename(&v.secCmd)
+		return v
+	case ClearPriceAlert:
+		rename(&v.secCmd)
+		return v
+	case UpdatePrice:
+		if price, ok := v.Prices[from]; ok {
+			prices := maps.Clone(v.Prices)
+			delete(prices, from)
+			prices[to] = price
+			v.Prices = prices
+		}
+		return v
+	case Custom:
+		positions := make([]PositionEffect, len(v.Positions))
+		for i, p := range v.Positions {
+			if p.Security == from {
+				p.Security = to
+			}
+			positions[i] = p
+		}
+		v.Positions = positions
+		return v
+	}
+	return tx
+}
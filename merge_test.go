@@ -0,0 +1,73 @@
+package portfolio
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	newLedger := func(txs ...Transaction) *Ledger {
+		t.Helper()
+		l := NewLedger()
+		if err := l.Append(txs...); err != nil {
+			t.Fatalf("ledger.Append() error = %v", err)
+		}
+		return l
+	}
+	first := newLedger(
+		NewInit(NewDate(2025, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "X", GOOG, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(1000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(2), USD(400)),
+		NewBuy(NewDate(2025, 1, 3), "", "X", Q(1), USD(100)),
+	)
+	second := newLedger(
+		NewInit(NewDate(2024, 12, 1), "", "USD"),
+		NewDeclare(NewDate(2024, 12, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2024, 12, 1), "", "X", MSFT, "USD"),
+		NewDeposit(NewDate(2024, 12, 2), "", USD(500), ""),
+		NewBuy(NewDate(2024, 12, 3), "", "X", Q(1), USD(300)),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(1000), ""), // already in the first ledger.
+		NewBuy(NewDate(2025, 1, 4), "", "AAPL", Q(1), USD(210)),
+	)
+
+	conflicts := Conflicts(first, second)
+	if len(conflicts) != 1 || conflicts[0].Ticker != "X" || conflicts[0].First.ID != GOOG || conflicts[0].Second.ID != MSFT {
+		t.Fatalf("Conflicts() = %v, want X declared as GOOG and MSFT", conflicts)
+	}
+
+	merged, err := Merge(first, second, func(Conflict) Resolution { return RenameSecond })
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if got := len(merged.Query().Command(CmdDeposit).Collect()); got != 2 {
+		t.Errorf("Merge() has %d deposits, want 2 once the duplicate is skipped", got)
+	}
+	if got := merged.OldestTransactionDate(); got != NewDate(2024, 12, 1) {
+		t.Errorf("Merge() inception = %s, want 2024-12-01", got)
+	}
+	s := merged.NewSnapshot(NewDate(2025, 1, 31))
+	if got := s.Position("AAPL"); !got.Equal(Q(3)) {
+		t.Errorf("Position(AAPL) = %v, want 3", got)
+	}
+	if got, renamed := s.Position("X"), s.Position("X-2"); !got.Equal(Q(1)) || !renamed.Equal(Q(1)) {
+		t.Errorf("Position(X), Position(X-2) = %v, %v, want 1, 1", got, renamed)
+	}
+	if sec := merged.Security("X-2"); sec == nil || sec.ID() != MSFT {
+		t.Errorf("Security(X-2) = %v, want MSFT", sec)
+	}
+	if got := s.Cash("USD"); !got.Equal(USD(490)) {
+		t.Errorf("Cash() = %v, want 490 USD", got)
+	}
+
+	merged, err = Merge(first, second, func(Conflict) Resolution { return KeepSecond })
+	if err != nil {
+		t.Fatalf("Merge() keeping the second declaration error = %v", err)
+	}
+	if sec := merged.Security("X"); sec == nil || sec.ID() != MSFT || merged.Security("X-2") != nil {
+		t.Errorf("Security(X) = %v, want MSFT", sec)
+	}
+
+	other := newLedger(NewInit(NewDate(2025, 1, 1), "", "EUR"))
+	if _, err := Merge(first, other, nil); err == nil {
+		t.Errorf("Merge() with different reporting currencies: expected an error")
+	}
+}
@@ -0,0 +1,83 @@
+// Package migrations upgrades the lines of ledger files written with an older
+// schema version of pcs.
+//
+// The schema version of a ledger file is recorded by its init transaction, the
+// files without it are of version 0. Each change of the format of a
+// transaction, a renamed field or a new required field, is a new schema
+// version, with the migration upgrading the lines of the previous version.
+//
+// The package works on the raw JSON lines, without depending on the
+// transactions of package portfolio: the old lines cannot be decoded by the
+// current transactions. Migrations must be idempotent, as the lines of a file
+// are only rewritten once the file is saved: a line already in the format of
+// the version must be left unchanged.
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Current is the current schema version. No change of the format has required
+// a migration yet: the ledger files of version 0 are the current ones.
+const Current = 0
+
+// Line is a ledger line, as its JSON fields.
+type Line map[string]json.RawMessage
+
+// Migration upgrades the lines of the previous schema version to its version.
+type Migration struct {
+	Version     int    // Version is the schema version of the upgraded lines.
+	Description string // Description is the change of the format, for the users.
+	// Commands are the commands of the lines to upgrade, all if empty.
+	Commands []string
+	Upgrade  func(Line) error
+}
+
+// Migrations are the migrations of the schema, in version order: the
+// migration to version v is Migrations[v-1]. A new schema version adds its
+// migration here, and increments Current.
+var Migrations []Migration
+
+// Since returns the migrations of the lines of a schema version to the current
+// version.
+func Since(version int) []Migration { return since(Migrations, version) }
+
+func since(migrations []Migration, version int) []Migration {
+	if version < 0 || version >= len(migrations) {
+		return nil
+	}
+	return migrations[version:]
+}
+
+// Upgrade upgrades a line of a command, written with a schema version, to the
+// current version. It returns the line itself when no migration applies.
+func Upgrade(version int, command string, line []byte) ([]byte, error) {
+	return upgrade(Migrations, version, command, line)
+}
+
+// upgrade upgrades a line with the migrations of a schema, see Upgrade.
+func upgrade(migrations []Migration, version int, command string, line []byte) ([]byte, error) {
+	if version > len(migrations) {
+		return nil, fmt.Errorf("schema version %d is newer than the version %d of pcs, upgrade pcs", version, len(migrations))
+	}
+	var fields Line
+	for _, m := range since(migrations, version) {
+		if len(m.Commands) > 0 && !slices.Contains(m.Commands, command) {
+			continue
+		}
+		if fields == nil {
+			if err := json.Unmarshal(line, &fields); err != nil {
+				return nil, err
+			}
+		}
+		if err := m.Upgrade(fields); err != nil {
+			return nil, fmt.Errorf("migration to schema version %d: %w", m.Version, err)
+		}
+	}
+	if fields == nil {
+		return line, nil
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	if len(Migrations) != Current {
+		t.Fatalf("len(Migrations) = %d, want the Current version %d", len(Migrations), Current)
+	}
+	for i, m := range Migrations {
+		if m.Version != i+1 {
+			t.Errorf("Migrations[%d].Version = %d, want %d", i, m.Version, i+1)
+		}
+	}
+}
+
+// rename returns the upgrade of a line renaming one of its fields.
+func rename(from, to string) func(Line) error {
+	return func(l Line) error {
+		v, ok := l[from]
+		if !ok {
+			return nil
+		}
+		if _, ok := l[to]; ok {
+			return errors.New("both " + from + " and " + to + " fields")
+		}
+		l[to] = v
+		delete(l, from)
+		return nil
+	}
+}
+
+// testMigrations are the migrations of a test schema, as pcs has no migration
+// yet.
+var testMigrations = []Migration{
+	{Version: 1, Description: "buy renames amount to cost", Commands: []string{"buy"}, Upgrade: rename("amount", "cost")},
+	{Version: 2, Description: "all commands rename memo to note", Upgrade: rename("memo", "note")},
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		command string
+		line    string
+		want    string
+	}{
+		{
+			name:    "all migrations",
+			command: "buy",
+			line:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"memo":"first"}`,
+			want:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"cost":1800,"note":"first"}`,
+		},
+		{
+			name:    "other command",
+			command: "sell",
+			line:    `{"command":"sell","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"memo":"first"}`,
+			want:    `{"command":"sell","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"note":"first"}`,
+		},
+		{
+			name:    "upgraded line unchanged",
+			command: "buy",
+			line:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"cost":1800}`,
+			want:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"cost":1800}`,
+		},
+		{
+			name:    "from version 1",
+			version: 1,
+			command: "buy",
+			line:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"memo":"first"}`,
+			want:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"note":"first"}`,
+		},
+		{
+			name:    "current version unchanged",
+			version: len(testMigrations),
+			command: "buy",
+			line:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"memo":"first"}`,
+			want:    `{"command":"buy","date":"2025-01-02","security":"AI","quantity":10,"amount":1800,"memo":"first"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := upgrade(testMigrations, tt.version, tt.command, []byte(tt.line))
+			if err != nil {
+				t.Fatalf("upgrade() error = %v", err)
+			}
+			var gotFields, wantFields map[string]any
+			if err := json.Unmarshal(got, &gotFields); err != nil {
+				t.Fatalf("upgrade() = %s, invalid JSON: %v", got, err)
+			}
+			json.Unmarshal([]byte(tt.want), &wantFields)
+			if !reflect.DeepEqual(gotFields, wantFields) {
+				t.Errorf("upgrade() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := upgrade(testMigrations, 0, "buy", []byte(`{"command":"buy","amount":1800,"cost":1800}`)); err == nil {
+		t.Error("upgrade() of a line failing its migration succeeded, want an error")
+	}
+	if _, err := upgrade(testMigrations, len(testMigrations)+1, "buy", []byte(`{"command":"buy"}`)); err == nil {
+		t.Error("upgrade() of a newer version succeeded, want an error")
+	}
+
+	// With no migration, the lines are read as they are.
+	line := `{"command":"buy","amount":1800}`
+	if got, err := Upgrade(Current, "buy", []byte(line)); err != nil || string(got) != line {
+		t.Errorf("Upgrade() = %s, %v, want %s, nil", got, err, line)
+	}
+	if _, err := Upgrade(Current+1, "buy", []byte(line)); err == nil {
+		t.Error("Upgrade() of a newer version succeeded, want an error")
+	}
+}
@@ -0,0 +1,240 @@
+// Package network implements the HTTP middleware shared by all market data providers.
+//
+// Every request made through Transport is:
+//   - rejected immediately when Offline is set, so that commands fail fast
+//     instead of waiting for network timeouts;
+//   - rate limited per host, see SetRateLimit;
+//   - retried with exponential backoff on transport errors, 429 (Too Many Requests)
+//     and 5xx responses, honoring the Retry-After header when present, if its
+//     method is idempotent;
+//   - accounted in per-host metrics, that are logged in verbose mode.
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrOffline is returned for any request made while Offline is set.
+var ErrOffline = errors.New("network access disabled in offline mode")
+
+var (
+	// Offline disables all network access.
+	Offline bool
+
+	// MaxRetries is the maximum number of retries of a failed request.
+	MaxRetries = 3
+
+	// Backoff is the delay before the first retry, it doubles for each subsequent retry.
+	Backoff = 500 * time.Millisecond
+
+	// MaxDelay is the maximum delay before a retry, whatever the backoff or
+	// the Retry-After header of the response.
+	MaxDelay = 30 * time.Second
+
+	// DefaultRate is the default maximum number of requests per second to a single host.
+	DefaultRate = 5.0
+)
+
+// DefaultTransport is the middleware to be used by all providers.
+var DefaultTransport http.RoundTripper = &Transport{}
+
+// NewClient returns an http.Client using DefaultTransport.
+func NewClient() *http.Client { return &http.Client{Transport: DefaultTransport} }
+
+// Transport is an http.RoundTripper implementing offline mode, rate limiting,
+// retries and metrics on top of a base transport.
+type Transport struct {
+	Base http.RoundTripper // Base is the underlying transport, http.DefaultTransport if nil.
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Offline {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Host, ErrOffline)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	host := req.URL.Host
+
+	delay := Backoff
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			// The request belongs to the caller, it is cloned to rewind its body.
+			r = req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+		if err := wait(req.Context(), host); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		resp, err := base.RoundTrip(r)
+		elapsed := time.Since(start)
+		record(host, elapsed, attempt > 0, err != nil || (resp != nil && resp.StatusCode >= 400))
+
+		retryable := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= MaxRetries || !replayable(req) {
+			return resp, err
+		}
+
+		next := delay
+		if err != nil {
+			log.Printf("%v %v/%v failed (attempt %d/%d, retrying in %v): %v", req.Method, host, req.URL.Path, attempt+1, MaxRetries+1, next, err)
+		} else {
+			if d, ok := retryAfter(resp); ok {
+				next = d
+			}
+			next = min(next, MaxDelay)
+			log.Printf("%v %v/%v %v (attempt %d/%d, retrying in %v)", req.Method, host, req.URL.Path, resp.Status, attempt+1, MaxRetries+1, next)
+			resp.Body.Close()
+		}
+		if err := sleep(req.Context(), next); err != nil {
+			return nil, err
+		}
+		delay = min(2*delay, MaxDelay)
+	}
+}
+
+// replayable reports whether req can be sent again: its method must be
+// idempotent, a POST could be processed twice, and its body must be rewindable.
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryAfter parses the Retry-After header of resp, if any.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(h); err == nil {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// limiter spaces out requests to a host.
+type limiter struct {
+	rate float64   // requests per second, no limit if <= 0
+	next time.Time // earliest time for the next request
+}
+
+var (
+	mu       sync.Mutex
+	limiters = make(map[string]*limiter)
+	metrics  = make(map[string]*HostMetrics)
+)
+
+// SetRateLimit sets the maximum number of requests per second to host.
+// A rate <= 0 disables rate limiting for this host.
+func SetRateLimit(host string, rate float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	limiters[host] = &limiter{rate: rate}
+}
+
+// wait blocks until a request to host is allowed, or until ctx is done.
+func wait(ctx context.Context, host string) error {
+	mu.Lock()
+	l, ok := limiters[host]
+	if !ok {
+		l = &limiter{rate: DefaultRate}
+		limiters[host] = l
+	}
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	if l.rate > 0 {
+		l.next = at.Add(time.Duration(float64(time.Second) / l.rate))
+	}
+	mu.Unlock()
+	return sleep(ctx, time.Until(at))
+}
+
+// HostMetrics holds the request metrics for a single host.
+type HostMetrics struct {
+	Host     string
+	Requests int           // number of requests sent, including retries
+	Retries  int           // number of retried requests
+	Errors   int           // number of failed requests (transport errors or status >= 400)
+	Duration time.Duration // total time spent waiting for responses
+}
+
+// record accounts for a request to host.
+func record(host string, d time.Duration, retry, failed bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	m, ok := metrics[host]
+	if !ok {
+		m = &HostMetrics{Host: host}
+		metrics[host] = m
+	}
+	m.Requests++
+	m.Duration += d
+	if retry {
+		m.Retries++
+	}
+	if failed {
+		m.Errors++
+	}
+}
+
+// Metrics returns the request metrics per host, sorted by host.
+func Metrics() []HostMetrics {
+	mu.Lock()
+	defer mu.Unlock()
+	res := make([]HostMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		res = append(res, *m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Host < res[j].Host })
+	return res
+}
+
+// LogMetrics logs the request metrics per host.
+func LogMetrics() {
+	for _, m := range Metrics() {
+		log.Printf("network %s: %d requests, %d retries, %d errors, %v", m.Host, m.Requests, m.Retries, m.Errors, m.Duration.Round(time.Millisecond))
+	}
+}
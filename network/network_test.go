@@ -0,0 +1,161 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport_Retry(t *testing.T) {
+	defer func(b time.Duration) { Backoff = b }(Backoff)
+	Backoff = time.Millisecond
+
+	tests := []struct {
+		name     string
+		statuses []int // statuses returned by successive calls
+		want     int   // final status
+		calls    int   // expected number of calls
+	}{
+		{"ok", []int{200}, 200, 1},
+		{"not found is not retried", []int{404}, 404, 1},
+		{"retry on 503", []int{503, 503, 200}, 200, 3},
+		{"retry on 429", []int{429, 200}, 200, 2},
+		{"give up", []int{500, 500, 500, 500, 500}, 500, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer srv.Close()
+
+			resp, err := NewClient().Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("Get() status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if calls != tt.calls {
+				t.Errorf("server calls = %d, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestTransport_Offline(t *testing.T) {
+	defer func() { Offline = false }()
+	Offline = true
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+	defer srv.Close()
+
+	_, err := NewClient().Get(srv.URL)
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("Get() error = %v, want %v", err, ErrOffline)
+	}
+	if calls != 0 {
+		t.Errorf("server calls = %d, want 0", calls)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	SetRateLimit(srv.Listener.Addr().String(), 20) // one request every 50ms
+
+	start := time.Now()
+	for range 3 {
+		resp, err := NewClient().Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestTransport_PostNotRetried(t *testing.T) {
+	defer func(b time.Duration) { Backoff = b }(Backoff)
+	Backoff = time.Millisecond
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient().Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("server calls = %d, want 1", calls)
+	}
+}
+
+func TestTransport_RetryAfterCanceled(t *testing.T) {
+	defer func(d time.Duration) { MaxDelay = d }(MaxDelay)
+	MaxDelay = time.Minute
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := NewClient().Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Do() took %v, want it to stop with its context", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("server calls = %d, want 1", calls)
+	}
+}
+
+func TestTransport_RetryAfterCapped(t *testing.T) {
+	defer func(d time.Duration) { MaxDelay = d }(MaxDelay)
+	MaxDelay = time.Millisecond
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("Get() status = %d after %d calls, want 200 after 2", resp.StatusCode, calls)
+	}
+}
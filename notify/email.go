@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envSMTPPassword is the environment variable holding the SMTP password, when not
+// set in the configuration file.
+const envSMTPPassword = "PCS_SMTP_PASSWORD"
+
+// Email sends messages through an SMTP server.
+type Email struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port,omitempty"` // Port defaults to 587.
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"` // Password defaults to the PCS_SMTP_PASSWORD environment variable.
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// Name implements the Sender interface.
+func (e *Email) Name() string { return "email" }
+
+// Send implements the Sender interface.
+func (e *Email) Send(ctx context.Context, m Message) error {
+	if e.Host == "" || e.From == "" || len(e.To) == 0 {
+		return fmt.Errorf("host, from and to are required")
+	}
+	port := e.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		password := e.Password
+		if password == "" {
+			password = os.Getenv(envSMTPPassword)
+		}
+		auth = smtp.PlainAuth("", e.Username, password, e.Host)
+	}
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(port))
+	return smtp.SendMail(addr, auth, e.From, e.To, e.message(m))
+}
+
+// message formats m as an RFC 5322 message. With an HTML page, the message
+// is a multipart/alternative one, with the plain text body and the page.
+func (e *Email) message(m Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	if m.HTML == "" {
+		fmt.Fprintf(&b, "Content-Type: text/plain; charset=UTF-8\r\n")
+		fmt.Fprintf(&b, "\r\n")
+		b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+		return b.Bytes()
+	}
+	w := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n", w.Boundary())
+	fmt.Fprintf(&b, "\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Body},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		pw, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		// Writing to a bytes.Buffer cannot fail.
+		qw := quotedprintable.NewWriter(pw)
+		qw.Write([]byte(part.content))
+		qw.Close()
+	}
+	w.Close()
+	return b.Bytes()
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/etnz/portfolio/network"
+)
+
+// Webhook POSTs the JSON encoded message payload to a URL.
+type Webhook struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // Headers are added to the request (e.g. authorization).
+}
+
+// Name implements the Sender interface.
+func (w *Webhook) Name() string { return "webhook" }
+
+// Send implements the Sender interface.
+func (w *Webhook) Send(ctx context.Context, m Message) error {
+	if w.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	body, err := json.Marshal(m.Payload)
+	if err != nil {
+		return fmt.Errorf("cannot encode payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	return do(req)
+}
+
+// defaultNtfyServer is the public ntfy server.
+const defaultNtfyServer = "https://ntfy.sh"
+
+// Ntfy pushes messages to a ntfy topic.
+type Ntfy struct {
+	Server string `json:"server,omitempty"` // Server defaults to https://ntfy.sh.
+	Topic  string `json:"topic"`
+	Token  string `json:"token,omitempty"` // Token is the access token for protected topics.
+}
+
+// Name implements the Sender interface.
+func (n *Ntfy) Name() string { return "ntfy" }
+
+// Send implements the Sender interface.
+func (n *Ntfy) Send(ctx context.Context, m Message) error {
+	if n.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	server := n.Server
+	if server == "" {
+		server = defaultNtfyServer
+	}
+	url := strings.TrimSuffix(server, "/") + "/" + n.Topic
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(m.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", m.Subject)
+	req.Header.Set("Markdown", "yes")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+	return do(req)
+}
+
+// do sends req and checks the response status.
+func do(req *http.Request) error {
+	resp, err := network.NewClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", req.Method, req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
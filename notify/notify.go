@@ -0,0 +1,99 @@
+// Package notify delivers reports to the user through external channels.
+//
+// Three backends are available:
+//   - Email sends the report as a plain text, or HTML, email through an SMTP server;
+//   - Webhook POSTs the JSON encoded report data to a URL;
+//   - Ntfy pushes the report to a ntfy.sh topic.
+//
+// Backends are configured in the "notifications" section of the configuration
+// file (see LoadConfig):
+//
+//	{
+//	  "notifications": {
+//	    "email": {"host": "smtp.example.com", "port": 587, "username": "me", "from": "pcs@example.com", "to": ["me@example.com"]},
+//	    "webhook": {"url": "https://example.com/hook"},
+//	    "ntfy": {"topic": "my-portfolio"}
+//	  }
+//	}
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ConfigFile is the name of the configuration file in the portfolio directory.
+const ConfigFile = "config.json"
+
+// Message is a report to be delivered.
+type Message struct {
+	Subject string // Subject is a short title for the message.
+	Body    string // Body is the markdown report.
+	HTML    string // HTML is the report as an HTML page, sent by emails next to the body when set.
+	Payload any    // Payload is the structured report data, encoded as JSON by webhooks.
+}
+
+// Sender delivers messages through a single channel.
+type Sender interface {
+	// Name returns the name of the channel (e.g. "email").
+	Name() string
+	// Send delivers the message.
+	Send(ctx context.Context, m Message) error
+}
+
+// Config is the "notifications" section of the configuration file.
+type Config struct {
+	Email   *Email   `json:"email,omitempty"`
+	Webhook *Webhook `json:"webhook,omitempty"`
+	Ntfy    *Ntfy    `json:"ntfy,omitempty"`
+}
+
+// LoadConfig reads the "notifications" section of the configuration file at path.
+//
+// A missing file is not an error, it results in an empty configuration.
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Notifications Config `json:"notifications"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse %q: %w", path, err)
+	}
+	return &file.Notifications, nil
+}
+
+// Senders returns the senders configured in c.
+func (c *Config) Senders() []Sender {
+	var senders []Sender
+	if c.Email != nil {
+		senders = append(senders, c.Email)
+	}
+	if c.Webhook != nil {
+		senders = append(senders, c.Webhook)
+	}
+	if c.Ntfy != nil {
+		senders = append(senders, c.Ntfy)
+	}
+	return senders
+}
+
+// Send delivers the message through all senders. A failing sender does not
+// prevent delivery through the others, all errors are returned.
+func Send(ctx context.Context, senders []Sender, m Message) error {
+	var errs []error
+	for _, s := range senders {
+		if err := s.Send(ctx, m); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFile)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() on missing file error = %v", err)
+	}
+	if got := len(cfg.Senders()); got != 0 {
+		t.Errorf("len(Senders()) on missing file = %d, want 0", got)
+	}
+
+	content := `{"notifications": {"webhook": {"url": "http://example.com"}, "ntfy": {"topic": "pcs"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	var names []string
+	for _, s := range cfg.Senders() {
+		names = append(names, s.Name())
+	}
+	if got, want := strings.Join(names, ","), "webhook,ntfy"; got != want {
+		t.Errorf("Senders() = %q, want %q", got, want)
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("cannot decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL, Headers: map[string]string{"X-Token": "secret"}}
+	if err := w.Send(context.Background(), Message{Subject: "review", Payload: map[string]string{"name": "main"}}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got["name"] != "main" {
+		t.Errorf("payload = %v, want name=main", got)
+	}
+
+	w.Headers = nil
+	if err := w.Send(context.Background(), Message{}); err == nil {
+		t.Errorf("Send() without token: expected an error")
+	}
+}
+
+func TestNtfy_Send(t *testing.T) {
+	var path, title, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, title = r.URL.Path, r.Header.Get("Title")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	n := &Ntfy{Server: srv.URL, Topic: "pcs"}
+	if err := n.Send(context.Background(), Message{Subject: "Daily Review", Body: "# Review"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if path != "/pcs" || title != "Daily Review" || body != "# Review" {
+		t.Errorf("Send() posted path=%q title=%q body=%q", path, title, body)
+	}
+}
+
+func TestEmail_message(t *testing.T) {
+	e := &Email{From: "pcs@example.com", To: []string{"a@example.com", "b@example.com"}}
+	got := string(e.message(Message{Subject: "Review", Body: "line1\nline2"}))
+	for _, want := range []string{"To: a@example.com, b@example.com\r\n", "Subject: Review\r\n", "\r\n\r\nline1\r\nline2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestEmail_message_HTML(t *testing.T) {
+	e := &Email{From: "pcs@example.com", To: []string{"a@example.com"}}
+	raw := e.message(Message{Subject: "Review", Body: "# Review", HTML: "<h1>Review</h1>"})
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", "# Review"},
+		{"text/html; charset=UTF-8", "<h1>Review</h1>"},
+	} {
+		part, err := r.NextPart() // decodes the quoted-printable content.
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Type"); got != want.contentType || string(content) != want.content {
+			t.Errorf("part = %q %q, want %q %q", got, content, want.contentType, want.content)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package portfolio
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Overdraft is the negative cash balance allowed in a currency, e.g. by a
+// margin account, and the annual interest rate charged on it. Overdrafts are
+// declared by the init transaction of the ledger.
+type Overdraft struct {
+	Currency string          `json:"currency"`
+	Limit    decimal.Decimal `json:"limit"`          // Limit is the largest negative balance allowed, as a positive amount.
+	Rate     Percent         `json:"rate,omitempty"` // Rate is the annual interest rate on negative balances, in percent.
+}
+
+// Overdraft returns the overdraft allowed in a currency, with a zero limit if
+// negative balances are not allowed.
+func (l *Ledger) Overdraft(currency string) Overdraft {
+	l = l.view()
+	return l.journal.overdraft(currency)
+}
+
+// overdraft returns the overdraft allowed in a currency.
+func (j *Journal) overdraft(currency string) Overdraft {
+	for _, o := range j.overdrafts {
+		if o.Currency == currency {
+			return o
+		}
+	}
+	return Overdraft{Currency: currency}
+}
+
+// overdraftLimit returns the largest negative cash balance allowed in a
+// currency, as a positive amount.
+func (l *Ledger) overdraftLimit(currency string) Money {
+	return M(l.Overdraft(currency).Limit, currency)
+}
+
+// overdraftNote returns the mention of an overdraft limit in validation
+// errors, if any.
+func overdraftNote(limit Money) string {
+	if limit.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(" with an overdraft of %s", limit)
+}
+
+// NegativeCash is a period of consecutive days with a negative balance of a
+// cash account.
+type NegativeCash struct {
+	Currency string
+	From, To Date    // From and To are the first and last days of negative balance.
+	Lowest   Money   // Lowest is the most negative balance of the period.
+	Rate     Percent // Rate is the annual interest rate of the overdraft.
+	Interest Money   // Interest is the implied interest cost, at the overdraft rate.
+}
+
+// Days returns the number of days of negative balance.
+func (n NegativeCash) Days() int { return n.To.Sub(n.From) + 1 }
+
+// NegativeCash returns the periods of negative cash balances within the review
+// period, by currency then in chronological order.
+//
+// The implied interest cost accrues daily on the negative end-of-day balance,
+// at the overdraft rate of the currency over 365 days.
+func (r *Review) NegativeCash() []NegativeCash {
+	var periods []NegativeCash
+	from, to := r.start.on.Add(1), r.end.on
+	events := r.end.journal.events
+	first := 0
+	for first < len(events) && !events[first].date().After(r.start.on) {
+		first++
+	}
+
+	for cur := range r.end.Currencies() {
+		od := r.end.journal.overdraft(cur)
+		daily := decimal.NewFromFloat(float64(od.Rate)).Div(decimal.NewFromInt(100 * 365))
+		balance := r.start.Cash(cur)
+		var current *NegativeCash
+		i := first
+		for day := from; !day.After(to); day = day.Add(1) {
+			for ; i < len(events) && !events[i].date().After(day); i++ {
+				switch v := events[i].(type) {
+				case creditCash:
+					if v.currency() == cur {
+						balance = balance.Add(v.amount)
+					}
+				case debitCash:
+					if v.currency() == cur {
+						balance = balance.Sub(v.amount)
+					}
+				}
+			}
+			if !balance.IsNegative() {
+				if current != nil {
+					periods = append(periods, *current)
+					current = nil
+				}
+				continue
+			}
+			if current == nil {
+				current = &NegativeCash{Currency: cur, From: day, Lowest: balance, Rate: od.Rate, Interest: M(0, cur)}
+			}
+			current.To = day
+			if balance.LessThan(current.Lowest) {
+				current.Lowest = balance
+			}
+			current.Interest = current.Interest.Add(balance.Neg().Mul(Quantity{value: daily}))
+		}
+		if current != nil {
+			periods = append(periods, *current)
+		}
+	}
+	return periods
+}
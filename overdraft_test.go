@@ -0,0 +1,49 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLedger_Overdraft(t *testing.T) {
+	inception := NewInit(NewDate(2025, 1, 1), "", "USD")
+	inception.Overdrafts = []Overdraft{{Currency: "USD", Limit: decimal.NewFromInt(1000), Rate: 7.3}}
+	ledger := NewLedger()
+	err := ledger.Append(
+		inception,
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(500), ""),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if _, err := ledger.Validate(NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1600))); err == nil {
+		t.Errorf("Validate(buy) beyond the overdraft: expected an error")
+	}
+	buy, err := ledger.Validate(NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1500)))
+	if err != nil {
+		t.Fatalf("Validate(buy) within the overdraft error = %v", err)
+	}
+	if err := ledger.Append(buy, NewDeposit(NewDate(2025, 1, 8), "", USD(1000), "")); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	if _, err := ledger.Validate(NewWithdraw(NewDate(2025, 1, 9), "", EUR(10))); err == nil {
+		t.Errorf("Validate(withdraw) without overdraft in EUR: expected an error")
+	}
+
+	// USD cash is -1000 from the 3rd to the 7th: 5 days at 7.3% is 1 USD.
+	review := ledger.NewReview(NewRange(NewDate(2025, 1, 1), NewDate(2025, 1, 31)))
+	got := review.NegativeCash()
+	if len(got) != 1 {
+		t.Fatalf("NegativeCash() = %v, want 1 period", got)
+	}
+	n := got[0]
+	if n.Currency != "USD" || n.From != NewDate(2025, 1, 3) || n.To != NewDate(2025, 1, 7) || n.Days() != 5 {
+		t.Errorf("NegativeCash() period = %s %s to %s, want USD 2025-01-03 to 2025-01-07", n.Currency, n.From, n.To)
+	}
+	if !n.Lowest.Equal(USD(-1000)) || !n.Interest.Equal(USD(1)) {
+		t.Errorf("NegativeCash() lowest, interest = %v, %v, want -1000, 1 USD", n.Lowest, n.Interest)
+	}
+}
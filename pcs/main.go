@@ -6,14 +6,18 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"maps"
 	"os"
 	"path"
 	"slices"
+	"strings"
 
+	"github.com/etnz/portfolio"
 	"github.com/etnz/portfolio/cmd"
+	"github.com/etnz/portfolio/network"
 	"github.com/google/subcommands"
 	"github.com/posener/complete/v2"
 	"github.com/posener/complete/v2/predict"
@@ -39,6 +43,24 @@ func main() {
 	if !*cmd.Verbose {
 		log.SetOutput(io.Discard)
 	}
+	network.Offline = *cmd.Offline
+	portfolio.AuditCommand = strings.Join(append([]string{"pcs"}, flag.Args()...), " ")
+	if err := cmd.LoadLocale(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading locale: %v\n", err)
+		os.Exit(int(subcommands.ExitFailure))
+	}
+	if err := cmd.LoadTimeZone(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading time zone: %v\n", err)
+		os.Exit(int(subcommands.ExitFailure))
+	}
+	if err := cmd.LoadConflictPolicy(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading conflict policy: %v\n", err)
+		os.Exit(int(subcommands.ExitFailure))
+	}
+	if err := cmd.LoadTemplates(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading user templates: %v\n", err)
+		os.Exit(int(subcommands.ExitFailure))
+	}
 
 	// Check if a subcommand is provided
 	if flag.NArg() > 0 {
@@ -63,7 +85,15 @@ func main() {
 
 	// If no extension was executed (either not found, or it was a built-in command),
 	// proceed with built-in commands execution.
-	os.Exit(int(commander.Execute(context.Background())))
+	// The context is cancelled explicitly: os.Exit does not run deferred calls.
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if *cmd.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *cmd.Timeout)
+	}
+	status := commander.Execute(ctx)
+	cancel()
+	network.LogMetrics()
+	os.Exit(int(status))
 }
 
 func NewCommanderCompleter(cmd *subcommands.Commander) complete.Completer {
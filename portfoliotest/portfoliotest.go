@@ -0,0 +1,215 @@
+// Package portfoliotest provides helpers to test code built on the portfolio
+// package: a generator of random valid ledgers, and checks of the properties
+// every ledger must have.
+//
+// Generated ledgers are deterministic for a given seed, so that a failing case
+// can be reproduced.
+package portfoliotest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/etnz/portfolio"
+)
+
+// Generator generates random sequences of valid transactions.
+type Generator struct {
+	rand *rand.Rand
+	// Currencies are the cash currencies used by the transactions. The
+	// securities are declared in the first one.
+	Currencies []string
+	// Tickers are the securities declared by the generated ledgers.
+	Tickers []string
+	// Start is the date of the first transaction.
+	Start portfolio.Date
+}
+
+// NewGenerator returns a generator of ledgers in EUR and USD with a few
+// securities, seeded for reproducibility.
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{
+		rand:       rand.New(rand.NewPCG(seed, seed)),
+		Currencies: []string{"EUR", "USD"},
+		Tickers:    []string{"AAA", "BBB", "CCC"},
+		Start:      portfolio.NewDate(2020, time.January, 1),
+	}
+}
+
+// Ledger returns a new ledger of n valid transactions, after the declaration
+// of the securities.
+func (g *Generator) Ledger(n int) (*portfolio.Ledger, error) {
+	txs, err := g.Transactions(n)
+	if err != nil {
+		return nil, err
+	}
+	ledger := portfolio.NewLedger()
+	if err := ledger.Append(txs...); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// Transactions returns the declaration of the securities followed by n valid
+// transactions, in chronological order.
+//
+// The generator tracks the cash balances and the positions itself, instead of
+// validating each transaction against a ledger, so that large sequences are
+// cheap to generate.
+func (g *Generator) Transactions(n int) ([]portfolio.Transaction, error) {
+	var txs []portfolio.Transaction
+	day := g.Start
+	for _, ticker := range g.Tickers {
+		id, err := portfolio.NewPrivate("Private " + ticker)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, portfolio.NewDeclare(day, "", ticker, id, g.Currencies[0]))
+	}
+	s := &state{cash: make(map[string]portfolio.Money), positions: make(map[string]portfolio.Quantity)}
+	for range n {
+		day = day.Add(g.rand.IntN(4))
+		// Some random transactions are not possible in the current state (e.g.
+		// selling a security not held), try until one is.
+		tx := g.transaction(s, day)
+		for tx == nil {
+			tx = g.transaction(s, day)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// state is the cash balances and the positions of a generated sequence.
+type state struct {
+	cash      map[string]portfolio.Money
+	positions map[string]portfolio.Quantity
+}
+
+func (s *state) balance(currency string) portfolio.Money {
+	if m, ok := s.cash[currency]; ok {
+		return m
+	}
+	return portfolio.M(0, currency)
+}
+
+// transaction returns a random transaction on day and applies it to the state,
+// or returns nil if the random transaction is not possible.
+func (g *Generator) transaction(s *state, day portfolio.Date) portfolio.Transaction {
+	ticker := g.Tickers[g.rand.IntN(len(g.Tickers))]
+	currency := g.Currencies[g.rand.IntN(len(g.Currencies))]
+	secCurrency := g.Currencies[0]
+	position := s.positions[ticker]
+	switch g.rand.IntN(8) {
+	case 0:
+		amount := g.amount(currency, 100000)
+		s.cash[currency] = s.balance(currency).Add(amount)
+		return portfolio.NewDeposit(day, "", amount, "")
+	case 1:
+		amount := g.amount(currency, 1000)
+		if s.balance(currency).LessThan(amount) {
+			return nil
+		}
+		s.cash[currency] = s.balance(currency).Sub(amount)
+		return portfolio.NewWithdraw(day, "", amount)
+	case 2, 3:
+		quantity, cost := g.quantity(), g.amount(secCurrency, 10000)
+		if s.balance(secCurrency).LessThan(cost) {
+			return nil
+		}
+		s.cash[secCurrency] = s.balance(secCurrency).Sub(cost)
+		s.positions[ticker] = position.Add(quantity)
+		return portfolio.NewBuy(day, "", ticker, quantity, cost)
+	case 4:
+		if !position.IsPositive() {
+			return nil
+		}
+		// Sell a part, or all of the position.
+		quantity, proceeds := g.quantity(), g.amount(secCurrency, 10000)
+		if quantity.GreaterThan(position) || g.rand.IntN(2) == 0 {
+			quantity = position
+		}
+		s.cash[secCurrency] = s.balance(secCurrency).Add(proceeds)
+		s.positions[ticker] = position.Sub(quantity)
+		return portfolio.NewSell(day, "", ticker, quantity, proceeds)
+	case 5:
+		// The journal records dividends as income, not as cash movements.
+		return portfolio.NewDividend(day, "", ticker, g.amount(secCurrency, 5))
+	case 6:
+		return portfolio.NewUpdatePrice(day, ticker, g.amount(secCurrency, 500))
+	default:
+		to := g.Currencies[g.rand.IntN(len(g.Currencies))]
+		from := g.amount(currency, 1000)
+		if to == currency || s.balance(currency).LessThan(from) {
+			return nil
+		}
+		toAmount := portfolio.M(from.AsFloat()*(0.5+g.rand.Float64()), to)
+		s.cash[currency] = s.balance(currency).Sub(from)
+		s.cash[to] = s.balance(to).Add(toAmount)
+		return portfolio.NewConvert(day, "", from, toAmount)
+	}
+}
+
+// amount returns a random positive amount up to max, with cents.
+func (g *Generator) amount(currency string, max int) portfolio.Money {
+	return portfolio.M(float64(1+g.rand.IntN(100*max))/100, currency)
+}
+
+// quantity returns a random positive number of shares.
+func (g *Generator) quantity() portfolio.Quantity {
+	return portfolio.Q(1 + g.rand.IntN(100))
+}
+
+// CheckInvariants checks the properties every valid ledger must have, and
+// returns an error describing the first violation:
+//   - the cash balances are never negative;
+//   - the positions in securities that are not options are never negative;
+//   - encoding, decoding and encoding again the ledger is stable.
+func CheckInvariants(ledger *portfolio.Ledger) error {
+	var days []portfolio.Date
+	for _, tx := range ledger.Query().All() {
+		if !slices.Contains(days, tx.When()) {
+			days = append(days, tx.When())
+		}
+	}
+	for _, day := range days {
+		for currency := range ledger.Currencies() {
+			if balance := ledger.CashBalance(currency, day); balance.IsNegative() {
+				return fmt.Errorf("on %s, %s cash balance is negative: %s", day, currency, balance)
+			}
+		}
+		for sec := range ledger.AllSecurities() {
+			if sec.ID().IsOption() {
+				continue
+			}
+			if position := ledger.Position(day, sec.Ticker()); position.IsNegative() {
+				return fmt.Errorf("on %s, %s position is negative: %s", day, sec.Ticker(), position)
+			}
+		}
+	}
+	return CheckRoundTrip(ledger)
+}
+
+// CheckRoundTrip checks that the ledger decodes back to the same transactions,
+// by comparing its encoding with the encoding of the decoded ledger.
+func CheckRoundTrip(ledger *portfolio.Ledger) error {
+	var first bytes.Buffer
+	if err := portfolio.EncodeLedger(&first, ledger); err != nil {
+		return fmt.Errorf("could not encode ledger: %w", err)
+	}
+	decoded, err := portfolio.DecodeLedger(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		return fmt.Errorf("could not decode encoded ledger: %w", err)
+	}
+	var second bytes.Buffer
+	if err := portfolio.EncodeLedger(&second, decoded); err != nil {
+		return fmt.Errorf("could not encode decoded ledger: %w", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		return fmt.Errorf("encoding is not stable:\n%s\nthen:\n%s", first.Bytes(), second.Bytes())
+	}
+	return nil
+}
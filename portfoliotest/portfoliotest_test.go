@@ -0,0 +1,80 @@
+package portfoliotest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+func TestGenerator_Invariants(t *testing.T) {
+	for seed := range uint64(20) {
+		ledger, err := NewGenerator(seed).Ledger(200)
+		if err != nil {
+			t.Fatalf("seed %d: Ledger() error = %v", seed, err)
+		}
+		if err := CheckInvariants(ledger); err != nil {
+			t.Errorf("seed %d: %v", seed, err)
+		}
+		// A generated ledger is valid, so it must survive a strict decoding.
+		var buf bytes.Buffer
+		if err := portfolio.EncodeLedger(&buf, ledger); err != nil {
+			t.Fatalf("seed %d: EncodeLedger() error = %v", seed, err)
+		}
+		if _, err := portfolio.DecodeValidateLedger(&buf); err != nil {
+			t.Errorf("seed %d: DecodeValidateLedger() error = %v", seed, err)
+		}
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	encode := func() string {
+		ledger, err := NewGenerator(42).Ledger(50)
+		if err != nil {
+			t.Fatalf("Ledger() error = %v", err)
+		}
+		var buf bytes.Buffer
+		if err := portfolio.EncodeLedger(&buf, ledger); err != nil {
+			t.Fatalf("EncodeLedger() error = %v", err)
+		}
+		return buf.String()
+	}
+	if a, b := encode(), encode(); a != b {
+		t.Errorf("ledgers generated with the same seed differ:\n%s\nand:\n%s", a, b)
+	}
+}
+
+// FuzzDecodeLedger checks that decoding and validating arbitrary JSONL never
+// panics, and that whatever passes validation has the ledger invariants.
+func FuzzDecodeLedger(f *testing.F) {
+	for seed := range uint64(5) {
+		ledger, err := NewGenerator(seed).Ledger(20)
+		if err != nil {
+			f.Fatalf("seed %d: Ledger() error = %v", seed, err)
+		}
+		var buf bytes.Buffer
+		if err := portfolio.EncodeLedger(&buf, ledger); err != nil {
+			f.Fatalf("seed %d: EncodeLedger() error = %v", seed, err)
+		}
+		f.Add(buf.String())
+	}
+	f.Add(`{"command":"buy","date":"2025-01-01","security":"X","quantity":-1,"amount":1}`)
+	f.Add(`{"command":"sell"}`)
+	f.Add(`{"command":"split","date":"2025-01-01","security":"X","num":1,"den":0}`)
+	f.Add(`{"command":"update-price","date":"2025-01-01","prices":{"X":"NaN"}}`)
+	f.Add("not json\n{}")
+
+	f.Fuzz(func(t *testing.T, jsonl string) {
+		// Decoding without validation may fail, but must not panic.
+		portfolio.DecodeLedger(strings.NewReader(jsonl))
+
+		ledger, err := portfolio.DecodeValidateLedger(strings.NewReader(jsonl))
+		if err != nil {
+			return
+		}
+		if err := CheckInvariants(ledger); err != nil {
+			t.Errorf("valid ledger breaks an invariant: %v\nledger:\n%s", err, jsonl)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package portfolio
+
+import "iter"
+
+// OHLC summarizes the prices of a security recorded during a period.
+type OHLC struct {
+	// Period is the range the prices are summarized over.
+	Period Range
+	Open   Money
+	High   Money
+	Low    Money
+	Close  Money
+	// Missing is the number of trading days without a price since the
+	// previous recorded price, up to the last price of the period.
+	Missing int
+	// Source is the market data provider of the close price, empty if it was
+	// recorded manually, see UpdatePrice.Source.
+	Source string
+}
+
+// PriceStats summarizes the prices of a security recorded during a range.
+type PriceStats struct {
+	Count int // Count is the number of prices recorded.
+	Low   Money
+	High  Money
+	Mean  Money
+	// FromHigh is the change from the highest price to the current price, in
+	// percent: zero at the high, negative below.
+	FromHigh Percent
+}
+
+// PriceStats returns the statistics of the prices of a security recorded
+// during the range, up to the snapshot date. The current price is the price
+// on the snapshot date.
+func (s *Snapshot) PriceStats(ticker string, r Range) PriceStats {
+	var stats PriceStats
+	var sum float64
+	for e := range s.events() {
+		u, ok := e.(updatePrice)
+		if !ok || u.security != ticker || !r.Contains(u.date()) {
+			continue
+		}
+		if stats.Count == 0 || u.price.LessThan(stats.Low) {
+			stats.Low = u.price
+		}
+		if stats.Count == 0 || u.price.GreaterThan(stats.High) {
+			stats.High = u.price
+		}
+		sum += u.price.AsFloat()
+		stats.Count++
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+	stats.Mean = M(sum/float64(stats.Count), stats.High.Currency())
+	if price := s.Price(ticker); !stats.High.IsZero() {
+		stats.FromHigh = Percent(100 * (price.AsFloat()/stats.High.AsFloat() - 1))
+	}
+	return stats
+}
+
+// Last52Weeks returns the range of the 52 weeks ending on a date.
+func Last52Weeks(on Date) Range { return NewRange(on.Add(-7*52+1), on) }
+
+// PriceHistory iterates over the prices of a security recorded by update-price
+// transactions during the period, in chronological order.
+func (l *Ledger) PriceHistory(ticker string, period Range) iter.Seq2[Date, Money] {
+	return func(yield func(Date, Money) bool) {
+		for u, price := range l.priceUpdates(ticker, period) {
+			if !yield(u.When(), price) {
+				return
+			}
+		}
+	}
+}
+
+// priceUpdates iterates over the update-price transactions of a security
+// during the period, with its price, in chronological order.
+func (l *Ledger) priceUpdates(ticker string, period Range) iter.Seq2[UpdatePrice, Money] {
+	return func(yield func(UpdatePrice, Money) bool) {
+		sec := l.Security(ticker)
+		if sec == nil {
+			return
+		}
+		for _, tx := range l.Query().Command(CmdUpdatePrice).During(period).All() {
+			u := tx.(UpdatePrice)
+			price, ok := u.Prices[ticker]
+			if !ok {
+				continue
+			}
+			if !yield(u, M(price, sec.Currency())) {
+				return
+			}
+		}
+	}
+}
+
+// OHLC summarizes the price history of a security during the range, with one
+// entry per period that has at least one recorded price.
+func (l *Ledger) OHLC(ticker string, r Range, p Period) []OHLC {
+	var bars []OHLC
+	var previous Date
+	isTradingDay := l.tradingDay(ticker)
+	for u, price := range l.priceUpdates(ticker, r) {
+		day := u.When()
+		missing := 0
+		if !previous.IsZero() {
+			missing = tradingDaysBetween(isTradingDay, previous, day)
+		}
+		previous = day
+
+		if n := len(bars); n > 0 && bars[n-1].Period.Contains(day) {
+			bar := &bars[n-1]
+			if price.GreaterThan(bar.High) {
+				bar.High = price
+			}
+			if price.LessThan(bar.Low) {
+				bar.Low = price
+			}
+			bar.Close = price
+			bar.Missing += missing
+			bar.Source = u.Source
+			continue
+		}
+		bars = append(bars, OHLC{Period: p.Range(day), Open: price, High: price, Low: price, Close: price, Missing: missing, Source: u.Source})
+	}
+	return bars
+}
+
+// tradingDaysBetween returns the number of trading days strictly between from
+// and to.
+func tradingDaysBetween(isTradingDay func(Date) bool, from, to Date) int {
+	n := 0
+	for day := from.Add(1); day.Before(to); day = day.Add(1) {
+		if isTradingDay(day) {
+			n++
+		}
+	}
+	return n
+}
+
+// PriceGaps returns the ranges of consecutive trading days during r without a
+// recorded price of the security, while it was held. Trading days follow the
+// calendar of the exchange of the security if known, see Exchange, and are the
+// weekdays otherwise. Currency pairs are not
+// held, their gaps are searched from their first transaction.
+func (l *Ledger) PriceGaps(ticker string, r Range) []Range {
+	sec := l.Security(ticker)
+	if sec == nil {
+		return nil
+	}
+	if inception := l.InceptionDate(ticker); r.From.Before(inception) {
+		r.From = inception
+	}
+	priced := make(map[Date]bool)
+	for day := range l.PriceHistory(ticker, r) {
+		priced[day] = true
+	}
+
+	// The position only changes on the dates of the other transactions of the
+	// security, so it is only computed on those dates.
+	isHeld := func(day Date) bool {
+		return sec.ID().IsCurrencyPair() || !l.Position(day, ticker).IsZero()
+	}
+	changes := make(map[Date]bool)
+	for _, tx := range l.Query().Security(ticker).All() {
+		if tx.What() != CmdUpdatePrice {
+			changes[tx.When()] = true
+		}
+	}
+
+	var gaps []Range
+	var gap *Range
+	isTradingDay := l.tradingDay(ticker)
+	held := isHeld(r.From)
+	for day := range r.Days() {
+		if changes[day] {
+			held = isHeld(day)
+		}
+		switch {
+		case !held || priced[day]:
+			gap = nil
+		case !isTradingDay(day):
+			// Weekends and holidays neither start nor end a gap.
+		case gap == nil:
+			gaps = append(gaps, NewRange(day, day))
+			gap = &gaps[len(gaps)-1]
+		default:
+			gap.To = day
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,91 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshot_PriceStats(t *testing.T) {
+	ledger := NewLedger()
+	ledger.Append(
+		NewDeclare(NewDate(2024, time.January, 1), "", "AAPL", AAPL, "USD"),
+		NewUpdatePrice(NewDate(2024, time.January, 2), "AAPL", USD(300)), // older than 52 weeks
+		NewUpdatePrice(NewDate(2025, time.January, 6), "AAPL", USD(100)),
+		NewUpdatePrice(NewDate(2025, time.January, 7), "AAPL", USD(120)),
+		NewUpdatePrice(NewDate(2025, time.January, 8), "AAPL", USD(90)),
+		NewUpdatePrice(NewDate(2025, time.January, 9), "AAPL", USD(200)), // after the snapshot
+	)
+	on := NewDate(2025, time.January, 8)
+	got := ledger.NewSnapshot(on).PriceStats("AAPL", Last52Weeks(on))
+	if got.Count != 3 || !got.Low.Equal(USD(90)) || !got.High.Equal(USD(120)) || got.Mean.String() != "$103.33" || !got.FromHigh.Equal(-25) {
+		t.Errorf("PriceStats() = %+v, want 3 prices from 90 to 120, mean 103.33, 25%% below the high", got)
+	}
+	if got := ledger.NewSnapshot(on).PriceStats("MSFT", Last52Weeks(on)); got.Count != 0 || got.FromHigh != 0 {
+		t.Errorf("PriceStats() of an unknown security = %+v, want none", got)
+	}
+}
+
+func TestLedger_OHLC(t *testing.T) {
+	ledger := NewLedger()
+	ledger.Append(
+		NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD"),
+		// Monday to Wednesday of the first week.
+		NewUpdatePrice(NewDate(2025, time.January, 6), "AAPL", USD(100)),
+		NewUpdatePrice(NewDate(2025, time.January, 7), "AAPL", USD(110)),
+		NewUpdatePrice(NewDate(2025, time.January, 8), "AAPL", USD(95)),
+		// Thursday and Friday are missing, and the weekend is not a gap.
+		NewUpdatePrice(NewDate(2025, time.January, 13), "AAPL", USD(105)),
+	)
+	r := NewRange(NewDate(2025, time.January, 1), NewDate(2025, time.January, 31))
+
+	daily := ledger.OHLC("AAPL", r, Daily)
+	if len(daily) != 4 {
+		t.Fatalf("OHLC(Daily) returned %d bars, want 4", len(daily))
+	}
+	if got := daily[3].Missing; got != 2 {
+		t.Errorf("OHLC(Daily)[3].Missing = %d, want 2", got)
+	}
+
+	weekly := ledger.OHLC("AAPL", r, Weekly)
+	if len(weekly) != 2 {
+		t.Fatalf("OHLC(Weekly) returned %d bars, want 2", len(weekly))
+	}
+	want := OHLC{Period: Weekly.Range(NewDate(2025, time.January, 6)), Open: USD(100), High: USD(110), Low: USD(95), Close: USD(95)}
+	if got := weekly[0]; got.Period != want.Period || !got.Open.Equal(want.Open) || !got.High.Equal(want.High) || !got.Low.Equal(want.Low) || !got.Close.Equal(want.Close) || got.Missing != 0 {
+		t.Errorf("OHLC(Weekly)[0] = %+v, want %+v", got, want)
+	}
+	if got := weekly[1].Missing; got != 2 {
+		t.Errorf("OHLC(Weekly)[1].Missing = %d, want 2", got)
+	}
+
+	if got := len(ledger.OHLC("MSFT", r, Daily)); got != 0 {
+		t.Errorf("OHLC of an undeclared security returned %d bars, want 0", got)
+	}
+}
+
+func TestLedger_PriceGaps(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2025, time.January, 1), "", USD(1000), ""),
+		// Prices before the security is held are not needed.
+		NewBuy(NewDate(2025, time.January, 6), "", "AAPL", Q(1), USD(100)),
+		NewUpdatePrice(NewDate(2025, time.January, 6), "AAPL", USD(100)),
+		NewUpdatePrice(NewDate(2025, time.January, 7), "AAPL", USD(110)),
+		NewUpdatePrice(NewDate(2025, time.January, 8), "AAPL", USD(95)),
+		// Thursday to Monday are missing, across the weekend.
+		NewUpdatePrice(NewDate(2025, time.January, 14), "AAPL", USD(105)),
+		// Prices after the security is sold are not needed.
+		NewSell(NewDate(2025, time.January, 15), "", "AAPL", Q(1), USD(105)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	r := NewRange(NewDate(2024, time.December, 1), NewDate(2025, time.January, 31))
+
+	gaps := ledger.PriceGaps("AAPL", r)
+	want := []Range{NewRange(NewDate(2025, time.January, 9), NewDate(2025, time.January, 13))}
+	if len(gaps) != len(want) || gaps[0] != want[0] {
+		t.Errorf("PriceGaps() = %v, want %v", gaps, want)
+	}
+}
@@ -0,0 +1,348 @@
+// Package projection estimates the distribution of the future value of a
+// portfolio by Monte Carlo simulation.
+//
+// The simulation draws monthly returns from a lognormal distribution, whose
+// mean and volatility are estimated from the historical monthly returns of the
+// portfolio currently held (see History), or resamples the historical returns.
+// Each simulated path compounds the returns and adds the monthly contributions;
+// the distribution of the values of all paths at each horizon is summarized by
+// percentiles.
+//
+// Decumulate simulates a withdrawal plan instead: the probability that the
+// portfolio is depleted, and how much it depends on the returns of the first
+// years (the sequence-of-returns risk).
+//
+// Projections are estimates based on past prices, not predictions: they do not
+// account for correlation changes, fees or taxes.
+package projection
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/etnz/portfolio"
+)
+
+// Percentiles are the percentiles of the simulated values reported by Run.
+var Percentiles = []float64{5, 25, 50, 75, 95}
+
+// Params are the parameters of a projection.
+type Params struct {
+	Value        float64 // Value is the initial value of the portfolio.
+	Contribution float64 // Contribution is added at the end of every month.
+	Mean         float64 // Mean is the mean of the monthly log returns.
+	Volatility   float64 // Volatility is the standard deviation of the monthly log returns.
+	Paths        int     // Paths is the number of simulated paths.
+	Seed         uint64  // Seed makes the simulation reproducible.
+	// History are monthly returns. If not empty, the simulated returns are
+	// resampled from them, instead of drawn from the lognormal distribution.
+	History []float64
+}
+
+// draw returns a simulated monthly log return.
+func (p Params) draw(rng *rand.Rand) float64 {
+	if len(p.History) > 0 {
+		return math.Log1p(p.History[rng.IntN(len(p.History))])
+	}
+	return p.Mean + p.Volatility*rng.NormFloat64()
+}
+
+// Band is the distribution of the simulated values at a horizon.
+type Band struct {
+	Months      int       // Months is the horizon of the band.
+	Contributed float64   // Contributed is the initial value plus the contributions.
+	Values      []float64 // Values are the simulated values at the Percentiles.
+}
+
+// Estimate returns the mean and the standard deviation of the log of the
+// monthly returns. It needs at least two returns.
+func Estimate(returns []float64) (mean, volatility float64, err error) {
+	if len(returns) < 2 {
+		return 0, 0, errors.New("not enough price history to estimate returns")
+	}
+	logs := make([]float64, len(returns))
+	for i, r := range returns {
+		logs[i] = math.Log1p(r)
+		mean += logs[i]
+	}
+	mean /= float64(len(logs))
+	for _, l := range logs {
+		volatility += (l - mean) * (l - mean)
+	}
+	volatility = math.Sqrt(volatility / float64(len(logs)-1))
+	return mean, volatility, nil
+}
+
+// Run simulates the paths of the portfolio value, and returns a band for each
+// horizon, in months.
+func Run(p Params, horizons ...int) []Band {
+	months := slices.Max(append([]int{0}, horizons...))
+	paths := max(p.Paths, 1)
+	values := make([][]float64, len(horizons)) // values[i] are the values of all paths at horizons[i]
+	for i := range values {
+		values[i] = make([]float64, 0, paths)
+	}
+
+	rng := rand.New(rand.NewPCG(p.Seed, p.Seed))
+	for range paths {
+		v := p.Value
+		for m := 1; m <= months; m++ {
+			v = v*math.Exp(p.draw(rng)) + p.Contribution
+			for i, h := range horizons {
+				if h == m {
+					values[i] = append(values[i], v)
+				}
+			}
+		}
+	}
+
+	bands := make([]Band, len(horizons))
+	for i, h := range horizons {
+		slices.Sort(values[i])
+		b := Band{Months: h, Contributed: p.Value + p.Contribution*float64(h)}
+		for _, q := range Percentiles {
+			b.Values = append(b.Values, percentile(values[i], q))
+		}
+		bands[i] = b
+	}
+	return bands
+}
+
+// percentile returns the q-th percentile of sorted values, by linear
+// interpolation.
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// History returns the monthly returns, in the reporting currency, of the
+// portfolio held on a date, as if it had been held over the price history of
+// its securities during the range: each month, the returns of the securities
+// are weighted by their share of the portfolio value on that date. Cash has a
+// zero return. Months without prices for all the securities held are skipped.
+//
+// It returns the error of the context if it is done before the end.
+func History(ctx context.Context, ledger *portfolio.Ledger, on portfolio.Date, r portfolio.Range) ([]float64, error) {
+	s := ledger.NewSnapshot(on)
+	total := s.TotalPortfolio().AsFloat()
+	if total == 0 {
+		return nil, nil
+	}
+
+	// rate returns the exchange rate of a currency at the end of a month.
+	rates := make(map[portfolio.Date]*portfolio.Snapshot)
+	rate := func(cur string, day portfolio.Date) float64 {
+		if cur == s.ReportingCurrency() {
+			return 1
+		}
+		if _, ok := rates[day]; !ok {
+			rates[day] = ledger.NewSnapshot(day)
+		}
+		return rates[day].ExchangeRate(cur).AsFloat()
+	}
+
+	weights := make(map[string]float64)
+	for ticker := range s.Securities() {
+		if w := s.Convert(s.MarketValue(ticker)).AsFloat() / total; w != 0 {
+			weights[ticker] = w
+		}
+	}
+
+	// returns[month][ticker] is the return of the security in the month.
+	// The tickers are sorted, so that the returns are summed in the same order
+	// on each run.
+	tickers := slices.Sorted(maps.Keys(weights))
+	returns := make(map[portfolio.Date]map[string]float64)
+	for _, ticker := range tickers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var prev portfolio.OHLC
+		for _, bar := range ledger.OHLC(ticker, r, portfolio.Monthly) {
+			if prev.Period.To.IsZero() {
+				prev = bar
+				continue
+			}
+			price := bar.Close.AsFloat() * rate(bar.Close.Currency(), bar.Period.To)
+			prevPrice := prev.Close.AsFloat() * rate(prev.Close.Currency(), prev.Period.To)
+			for _, tx := range ledger.Query().Command(portfolio.CmdSplit).Security(ticker).During(portfolio.NewRange(prev.Period.To.Add(1), bar.Period.To)).All() {
+				split := tx.(portfolio.Split)
+				price *= float64(split.Numerator) / float64(split.Denominator)
+			}
+			if prevPrice != 0 && price != 0 && bar.Period.From == prev.Period.To.Add(1) {
+				if returns[bar.Period.To] == nil {
+					returns[bar.Period.To] = make(map[string]float64)
+				}
+				returns[bar.Period.To][ticker] = price/prevPrice - 1
+			}
+			prev = bar
+		}
+	}
+
+	var history []float64
+	for _, month := range slices.SortedFunc(maps.Keys(returns), portfolio.Date.Compare) {
+		if len(returns[month]) != len(weights) {
+			continue
+		}
+		var ret float64
+		for _, ticker := range tickers {
+			ret += weights[ticker] * returns[month][ticker]
+		}
+		history = append(history, ret)
+	}
+	return history, nil
+}
+
+// Crossover simulates the paths of the portfolio value, and returns the number
+// of months for the value to reach the target at each of the Percentiles: 5% of
+// the paths reach it within the first number of months. A path that does not
+// reach the target within months never crosses over, and a percentile with too
+// few paths crossing over is -1.
+func Crossover(p Params, target float64, months int) []int {
+	paths := max(p.Paths, 1)
+	crossings := make([]int, 0, paths)
+	rng := rand.New(rand.NewPCG(p.Seed, p.Seed))
+	for range paths {
+		v, m := p.Value, 0
+		for ; v < target && m <= months; m++ {
+			v = v*math.Exp(p.draw(rng)) + p.Contribution
+		}
+		crossings = append(crossings, m)
+	}
+	slices.Sort(crossings)
+
+	result := make([]int, len(Percentiles))
+	for i, q := range Percentiles {
+		m := crossings[int(math.Ceil(q/100*float64(paths)))-1]
+		if m > months {
+			m = -1
+		}
+		result[i] = m
+	}
+	return result
+}
+
+// MonthsTo returns the number of months for the value to reach the target, with
+// a constant monthly log return and monthly contributions, or -1 if it does not
+// within months.
+func MonthsTo(value, contribution, mean, target float64, months int) int {
+	for m := 0; m <= months; m++ {
+		if value >= target {
+			return m
+		}
+		value = value*math.Exp(mean) + contribution
+	}
+	return -1
+}
+
+// Depletion is the distribution of the simulated values at a horizon of a
+// withdrawal plan.
+type Depletion struct {
+	Months    int       // Months is the horizon.
+	Withdrawn float64   // Withdrawn is the total of the scheduled withdrawals.
+	Depleted  float64   // Depleted is the share of the paths depleted, in percent.
+	Values    []float64 // Values are the simulated values at the Percentiles.
+}
+
+// SequenceRisk compares the depletion at the last horizon of a withdrawal plan
+// between the paths with the worst and the best returns in the first months.
+type SequenceRisk struct {
+	Months int // Months is the length of the first period.
+	// WorstReturn and BestReturn are the annualized returns of the first period
+	// at the 25th and 75th percentiles.
+	WorstReturn, BestReturn float64
+	// Worst and Best are the shares of the paths depleted, in percent, among
+	// the quarter of the paths with the worst and the best first returns.
+	Worst, Best float64
+}
+
+// Decumulate simulates the withdrawal of an amount every month from the paths
+// of the portfolio value. The withdrawal grows by inflation every month, zero
+// for a fixed withdrawal. A path is depleted when its value falls to zero, and
+// then stays at zero.
+//
+// The sequence-of-returns risk is measured over the first 5 years, or half the
+// longest horizon if shorter.
+func Decumulate(p Params, withdrawal, inflation float64, horizons ...int) ([]Depletion, SequenceRisk) {
+	months := slices.Max(append([]int{0}, horizons...))
+	early := min(60, months/2)
+	paths := max(p.Paths, 1)
+	values := make([][]float64, len(horizons)) // values[i] are the values of all paths at horizons[i]
+	type path struct {
+		early    float64 // early is the cumulated log return of the first period.
+		depleted bool    // depleted at the last horizon.
+	}
+	all := make([]path, 0, paths)
+
+	rng := rand.New(rand.NewPCG(p.Seed, p.Seed))
+	for range paths {
+		v, w := p.Value, withdrawal
+		var pa path
+		for m := 1; m <= months; m++ {
+			r := p.draw(rng)
+			if m <= early {
+				pa.early += r
+			}
+			v = max(0, v*math.Exp(r)+p.Contribution-w)
+			w *= 1 + inflation
+			for i, h := range horizons {
+				if h == m {
+					values[i] = append(values[i], v)
+				}
+			}
+		}
+		pa.depleted = v == 0
+		all = append(all, pa)
+	}
+
+	depletions := make([]Depletion, len(horizons))
+	for i, h := range horizons {
+		slices.Sort(values[i])
+		d := Depletion{Months: h}
+		for w, m := withdrawal, 0; m < h; m++ {
+			d.Withdrawn += w
+			w *= 1 + inflation
+		}
+		depleted := 0
+		for _, v := range values[i] {
+			if v == 0 {
+				depleted++
+			}
+		}
+		d.Depleted = 100 * float64(depleted) / float64(len(values[i]))
+		for _, q := range Percentiles {
+			d.Values = append(d.Values, percentile(values[i], q))
+		}
+		depletions[i] = d
+	}
+
+	risk := SequenceRisk{Months: early}
+	if early == 0 {
+		return depletions, risk
+	}
+	slices.SortFunc(all, func(a, b path) int { return cmp.Compare(a.early, b.early) })
+	quarter := max(paths/4, 1)
+	share := func(paths []path) float64 {
+		n := 0
+		for _, pa := range paths {
+			if pa.depleted {
+				n++
+			}
+		}
+		return 100 * float64(n) / float64(len(paths))
+	}
+	annualized := func(pa path) float64 { return math.Expm1(pa.early * 12 / float64(early)) }
+	risk.Worst, risk.Best = share(all[:quarter]), share(all[paths-quarter:])
+	risk.WorstReturn, risk.BestReturn = annualized(all[quarter-1]), annualized(all[paths-quarter])
+	return depletions, risk
+}
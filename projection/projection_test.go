@@ -0,0 +1,153 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+func TestEstimate(t *testing.T) {
+	mean, volatility, err := Estimate([]float64{0.01, 0.01, 0.01})
+	if err != nil {
+		t.Fatalf("Estimate() error = %v", err)
+	}
+	if math.Abs(mean-math.Log(1.01)) > 1e-12 || volatility > 1e-12 {
+		t.Errorf("Estimate() = %v, %v, want %v, 0", mean, volatility, math.Log(1.01))
+	}
+	if _, _, err := Estimate([]float64{0.01}); err == nil {
+		t.Errorf("Estimate() of a single return: expected an error")
+	}
+}
+
+func TestRun(t *testing.T) {
+	// Without volatility, all paths compound the mean return.
+	bands := Run(Params{Value: 1000, Contribution: 100, Mean: math.Log(1.01), Paths: 10}, 12)
+	want := 1000.0
+	for range 12 {
+		want = want*1.01 + 100
+	}
+	if len(bands) != 1 || bands[0].Months != 12 || bands[0].Contributed != 2200 {
+		t.Fatalf("Run() = %v, want a band at 12 months with 2200 contributed", bands)
+	}
+	for _, v := range bands[0].Values {
+		if math.Abs(v-want) > 1e-6 {
+			t.Errorf("Run() value = %v, want %v", v, want)
+		}
+	}
+
+	// With volatility, percentiles spread around the median, and are reproducible.
+	p := Params{Value: 1000, Mean: 0.005, Volatility: 0.04, Paths: 1000, Seed: 42}
+	bands = Run(p, 12, 60)
+	for _, b := range bands {
+		if !slices.IsSorted(b.Values) || b.Values[0] == b.Values[len(b.Values)-1] {
+			t.Errorf("Run() values at %d months = %v, want increasing percentiles", b.Months, b.Values)
+		}
+	}
+	if again := Run(p, 12, 60); !slices.Equal(again[1].Values, bands[1].Values) {
+		t.Errorf("Run() is not reproducible with the same seed: %v != %v", again[1].Values, bands[1].Values)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	d := portfolio.NewDate
+	id, _ := portfolio.NewMSSI("US0378331005", "XNAS")
+	ledger := portfolio.NewLedger()
+	err := ledger.Append(
+		portfolio.NewInit(d(2025, 1, 1), "", "EUR"),
+		portfolio.NewDeclare(d(2025, 1, 1), "", "AAPL", id, "EUR"),
+		portfolio.NewDeposit(d(2025, 1, 1), "", portfolio.M(2000, "EUR"), ""),
+		portfolio.NewBuy(d(2025, 1, 2), "", "AAPL", portfolio.Q(10), portfolio.M(1000, "EUR")),
+		portfolio.NewUpdatePrice(d(2025, 1, 31), "AAPL", portfolio.M(100, "EUR")),
+		portfolio.NewUpdatePrice(d(2025, 2, 28), "AAPL", portfolio.M(110, "EUR")),
+		portfolio.NewSplit(d(2025, 3, 10), "AAPL", 2, 1),
+		portfolio.NewUpdatePrice(d(2025, 3, 31), "AAPL", portfolio.M(55, "EUR")),
+		portfolio.NewUpdatePrice(d(2025, 4, 30), "AAPL", portfolio.M(66, "EUR")),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// On March 31st, AAPL is 1100 EUR out of 2100 EUR.
+	got, err := History(context.Background(), ledger, d(2025, 3, 31), portfolio.NewRange(d(2025, 1, 1), d(2025, 4, 30)))
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	w := 1100.0 / 2100
+	want := []float64{w * 0.1, 0, w * 0.2}
+	if len(got) != len(want) {
+		t.Fatalf("History() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("History()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMonthsTo(t *testing.T) {
+	if got := MonthsTo(1000, 100, 0, 1500, 600); got != 5 {
+		t.Errorf("MonthsTo() without returns = %d, want 5", got)
+	}
+	if got := MonthsTo(2000, 0, 0, 1500, 600); got != 0 {
+		t.Errorf("MonthsTo() above the target = %d, want 0", got)
+	}
+	if got := MonthsTo(1000, 0, 0, 1500, 600); got != -1 {
+		t.Errorf("MonthsTo() never reaching the target = %d, want -1", got)
+	}
+}
+
+func TestCrossover(t *testing.T) {
+	// Without volatility, all paths cross over at the same month.
+	got := Crossover(Params{Value: 1000, Contribution: 100, Paths: 10}, 1500, 600)
+	for _, m := range got {
+		if m != 5 {
+			t.Errorf("Crossover() = %v, want 5 months at all percentiles", got)
+			break
+		}
+	}
+
+	// With volatility, the later percentiles cross over later, or never.
+	got = Crossover(Params{Value: 1000, Contribution: 10, Mean: 0.002, Volatility: 0.05, Paths: 1000, Seed: 42}, 2000, 120)
+	if got[0] < 0 || got[len(got)-1] != -1 {
+		t.Fatalf("Crossover() = %v, want an early crossover and one not within the horizon", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] >= 0 && got[i] < got[i-1] {
+			t.Errorf("Crossover() = %v, want increasing months", got)
+		}
+	}
+}
+
+func TestDecumulate(t *testing.T) {
+	// Without returns, 1000 lasts 10 months of 100, or less if it grows.
+	depletions, _ := Decumulate(Params{Value: 1000, Paths: 10}, 100, 0, 9, 10)
+	if depletions[0].Depleted != 0 || depletions[1].Depleted != 100 || depletions[1].Withdrawn != 1000 {
+		t.Errorf("Decumulate() = %v, want depleted at 10 months only, with 1000 withdrawn", depletions)
+	}
+	depletions, _ = Decumulate(Params{Value: 1000, Paths: 10}, 100, 0.25, 6)
+	if depletions[0].Depleted != 100 {
+		t.Errorf("Decumulate() with inflation = %v, want depleted at 6 months", depletions)
+	}
+
+	// With volatility, the worst first returns deplete more often.
+	p := Params{Value: 1000, Mean: 0.003, Volatility: 0.05, Paths: 2000, Seed: 42}
+	depletions, risk := Decumulate(p, 6, 0, 120, 360)
+	if d := depletions[1].Depleted; d == 0 || d == 100 {
+		t.Fatalf("Decumulate() depleted = %v%%, want a partial depletion", d)
+	}
+	if risk.Months != 60 || risk.WorstReturn >= risk.BestReturn || risk.Worst <= risk.Best {
+		t.Errorf("Decumulate() risk = %+v, want more depletion after the worst first 60 months", risk)
+	}
+
+	// Resampling the history reproduces a constant return.
+	depletions, _ = Decumulate(Params{Value: 1000, Paths: 10, History: []float64{0.01}}, 5, 0, 12)
+	for _, v := range depletions[0].Values {
+		if v <= 1000 {
+			t.Errorf("Decumulate() with history = %v, want values above 1000", depletions[0].Values)
+			break
+		}
+	}
+}
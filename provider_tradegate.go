@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,18 +13,26 @@ import (
 	"strings"
 
 	"github.com/PaesslerAG/jsonpath"
+	"github.com/etnz/portfolio/network"
 )
 
+// tradegateSource is the source of the intraday prices, see UpdatePrice.
+const tradegateSource = "tradegate"
+
 // jwget performs an HTTP GET request to the given address and unmarshals the
 // JSON response body into the provided data structure. It uses the provided
-// http.Client for the request.
-func jwget(client *http.Client, addr string, data interface{}) error {
-	resp, err := client.Get(addr)
+// http.Client for the request, cancelled with the context.
+func jwget(ctx context.Context, client *http.Client, addr string, data interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
 	if err != nil {
 		return err
 	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ProviderError(err)
+	}
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("cannot http GET %v/%v: %v", resp.Request.URL.Host, resp.Request.URL.Path, resp.Status)
+		return ProviderError(fmt.Errorf("cannot http GET %v/%v: %v", resp.Request.URL.Host, resp.Request.URL.Path, resp.Status))
 	}
 	var buf bytes.Buffer
 	_, err = io.Copy(&buf, resp.Body)
@@ -31,7 +40,7 @@ func jwget(client *http.Client, addr string, data interface{}) error {
 		return err
 	}
 	resp.Body.Close()
-	return json.Unmarshal(buf.Bytes(), data)
+	return ProviderError(json.Unmarshal(buf.Bytes(), data))
 }
 
 /*
@@ -53,11 +62,11 @@ func jwget(client *http.Client, addr string, data interface{}) error {
 	        ]
 	    },
 */
-func tradegateLatestEURperUSD() (float64, error) {
+func tradegateLatestEURperUSD(ctx context.Context) (float64, error) {
 	// this is not tradegate ;-)
 	addr := "https://www.ls-tc.de/_rpc/json/instrument/chart/dataForInstrument?instrumentId=349938&series=intraday&type=mini"
 	var jobj any
-	err := jwget(new(http.Client), addr, &jobj)
+	err := jwget(ctx, network.NewClient(), addr, &jobj)
 	if err != nil {
 		return math.NaN(), fmt.Errorf("error in wget %q: %w", "EUR/USD", err)
 	}
@@ -82,14 +91,14 @@ func tradegateLatestEURperUSD() (float64, error) {
 // tradegateLatest update all stocks from latest value exchanged in TrageGate.
 // They are all in Eur, so they are converted back to their currency if there is
 // a currency attribute in the metadata
-func tradegateLatest(name, isin string) (float64, error) {
+func tradegateLatest(ctx context.Context, name, isin string) (float64, error) {
 
 	base := "https://www.tradegate.de/refresh.php?isin="
 	addr := base + isin
 
 	var jobj map[string]any
 
-	err := jwget(new(http.Client), addr, &jobj)
+	err := jwget(ctx, network.NewClient(), addr, &jobj)
 	if err != nil {
 		return math.NaN(), fmt.Errorf("error retrieving %q: %w", name, err)
 	}
@@ -0,0 +1,158 @@
+package portfolio
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Query selects transactions of a ledger.
+//
+// Each filter method restricts the selection: a transaction must match all the
+// filters (AND), and within a filter method, any of its values (OR). For
+// instance, Command(CmdBuy, CmdSell).Security("AAPL") selects the buys and the
+// sells of AAPL. Arbitrary predicates, like the ones returned by ParseFilter,
+// can be added with Where.
+//
+// Transactions are returned in chronological order, unless Reverse is used.
+type Query struct {
+	ledger  *Ledger
+	accepts []func(Transaction) bool
+	reverse bool
+	offset  int
+	limit   int
+}
+
+// Query returns a new query that selects all the transactions of the ledger.
+func (l *Ledger) Query() *Query {
+	return &Query{ledger: l.view()}
+}
+
+// Where restricts the selection to transactions accepted by any of the predicates.
+func (q *Query) Where(accepts ...func(Transaction) bool) *Query {
+	q.accepts = append(q.accepts, func(tx Transaction) bool {
+		return slices.ContainsFunc(accepts, func(accept func(Transaction) bool) bool { return accept(tx) })
+	})
+	return q
+}
+
+// Command restricts the selection to transactions of any of the commands.
+func (q *Query) Command(cmds ...CommandType) *Query {
+	return q.Where(func(tx Transaction) bool { return slices.Contains(cmds, tx.What()) })
+}
+
+// Security restricts the selection to transactions about any of the securities,
+// including their declaration and market data.
+func (q *Query) Security(tickers ...string) *Query {
+	return q.Where(func(tx Transaction) bool {
+		return slices.ContainsFunc(securitiesOf(tx), func(ticker string) bool { return slices.Contains(tickers, ticker) })
+	})
+}
+
+// Currency restricts the selection to transactions in any of the currencies.
+func (q *Query) Currency(currencies ...string) *Query {
+	accepts := make([]func(Transaction) bool, len(currencies))
+	for i, currency := range currencies {
+		accepts[i] = q.ledger.ByCurrency(currency)
+	}
+	return q.Where(accepts...)
+}
+
+// Venue restricts the selection to the trades on any of the venues.
+func (q *Query) Venue(venues ...string) *Query {
+	return q.Where(func(tx Transaction) bool {
+		return slices.ContainsFunc(venuesOf(tx), func(venue string) bool { return slices.Contains(venues, venue) })
+	})
+}
+
+// During restricts the selection to transactions in the period.
+func (q *Query) During(period Range) *Query {
+	return q.Where(func(tx Transaction) bool { return period.Contains(tx.When()) })
+}
+
+// Memo restricts the selection to transactions whose memo contains any of the
+// texts, regardless of case.
+func (q *Query) Memo(texts ...string) *Query {
+	return q.Where(func(tx Transaction) bool {
+		m, ok := tx.(interface{ Rationale() string })
+		if !ok {
+			return false
+		}
+		memo := strings.ToLower(m.Rationale())
+		return slices.ContainsFunc(texts, func(text string) bool { return strings.Contains(memo, strings.ToLower(text)) })
+	})
+}
+
+// Reverse returns the transactions from the newest to the oldest.
+func (q *Query) Reverse() *Query {
+	q.reverse = true
+	return q
+}
+
+// Offset skips the first n selected transactions.
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
+
+// Limit returns at most n transactions, zero means no limit.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// All iterates over the selected transactions, with their index in the ledger.
+func (q *Query) All() iter.Seq2[int, Transaction] {
+	return func(yield func(int, Transaction) bool) {
+		txs := q.ledger.transactions
+		skipped, returned := 0, 0
+		for k := range txs {
+			i := k
+			if q.reverse {
+				i = len(txs) - 1 - k
+			}
+			tx := txs[i]
+			if !q.accept(tx) {
+				continue
+			}
+			if skipped < q.offset {
+				skipped++
+				continue
+			}
+			if q.limit > 0 && returned >= q.limit {
+				return
+			}
+			returned++
+			if !yield(i, tx) {
+				return
+			}
+		}
+	}
+}
+
+// Collect returns the selected transactions.
+func (q *Query) Collect() []Transaction {
+	var txs []Transaction
+	for _, tx := range q.All() {
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// First returns the first selected transaction, and false if there is none.
+func (q *Query) First() (Transaction, bool) {
+	for _, tx := range q.All() {
+		return tx, true
+	}
+	return nil, false
+}
+
+// accept reports whether tx matches all the filters.
+func (q *Query) accept(tx Transaction) bool {
+	for _, accept := range q.accepts {
+		if !accept(tx) {
+			return false
+		}
+	}
+	return true
+}
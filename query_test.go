@@ -0,0 +1,73 @@
+package portfolio
+
+import "testing"
+
+func TestLedger_Query(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	goog := NewBuy(NewDate(2024, 2, 3), "", "GOOG", Q(10), USD(1500))
+	goog.Venue = "ib"
+	txs := []Transaction{
+		NewDeclare(NewDate(2024, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2024, 1, 1), "", "GOOG", GOOG, "USD"),
+		NewDeposit(NewDate(2024, 1, 2), "", USD(10000), ""),
+		NewBuy(NewDate(2024, 1, 3), "first", "AAPL", Q(10), USD(1500)),
+		goog,
+		NewUpdatePrice(NewDate(2024, 3, 1), "AAPL", USD(160)),
+		NewSell(NewDate(2024, 4, 3), "Rebalance", "AAPL", Q(5), USD(800)),
+		NewDeposit(NewDate(2024, 5, 2), "rebalance", EUR(100), ""),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	dates := func(q *Query) []Date {
+		var got []Date
+		for _, tx := range q.All() {
+			got = append(got, tx.When())
+		}
+		return got
+	}
+	tests := []struct {
+		name  string
+		query *Query
+		want  []Date
+	}{
+		{"all", ledger.Query(), []Date{
+			NewDate(2024, 1, 1), NewDate(2024, 1, 1), NewDate(2024, 1, 2), NewDate(2024, 1, 3),
+			NewDate(2024, 2, 3), NewDate(2024, 3, 1), NewDate(2024, 4, 3), NewDate(2024, 5, 2),
+		}},
+		{"trades", ledger.Query().Command(CmdBuy, CmdSell).Security("AAPL"), []Date{NewDate(2024, 1, 3), NewDate(2024, 4, 3)}},
+		{"market data", ledger.Query().Security("AAPL").Command(CmdUpdatePrice), []Date{NewDate(2024, 3, 1)}},
+		{"currency", ledger.Query().Command(CmdDeposit).Currency("EUR"), []Date{NewDate(2024, 5, 2)}},
+		{"venue", ledger.Query().Venue("ib", "xetra"), []Date{NewDate(2024, 2, 3)}},
+		{"memo", ledger.Query().Memo("rebalance"), []Date{NewDate(2024, 4, 3), NewDate(2024, 5, 2)}},
+		{"period", ledger.Query().During(NewRange(NewDate(2024, 2, 1), NewDate(2024, 4, 30))), []Date{NewDate(2024, 2, 3), NewDate(2024, 3, 1), NewDate(2024, 4, 3)}},
+		{"where", ledger.Query().Where(BySecurity("GOOG"), ByUpdatePrice()), []Date{NewDate(2024, 1, 1), NewDate(2024, 2, 3), NewDate(2024, 3, 1)}},
+		{"paginated", ledger.Query().Offset(2).Limit(2), []Date{NewDate(2024, 1, 2), NewDate(2024, 1, 3)}},
+		{"reversed", ledger.Query().Command(CmdBuy).Reverse(), []Date{NewDate(2024, 2, 3), NewDate(2024, 1, 3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dates(tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Query() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if got, want := ledger.InceptionDate("GOOG"), NewDate(2024, 1, 1); got != want {
+		t.Errorf("InceptionDate(GOOG) = %v, want %v", got, want)
+	}
+	if got, want := ledger.LastKnownMarketDataDate("AAPL"), NewDate(2024, 3, 1); got != want {
+		t.Errorf("LastKnownMarketDataDate(AAPL) = %v, want %v", got, want)
+	}
+	if got, want := ledger.NewestTransactionDate(), NewDate(2024, 5, 2); got != want {
+		t.Errorf("NewestTransactionDate() = %v, want %v", got, want)
+	}
+}
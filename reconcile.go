@@ -0,0 +1,99 @@
+package portfolio
+
+import (
+	"fmt"
+)
+
+// BrokerBalance is a balance reported by a broker or a bank on a given date.
+//
+// It is either the position of a security (Security is set) or the balance of a
+// cash account (Security is empty).
+type BrokerBalance struct {
+	On       Date
+	Security string   // Security is the ticker of the reported position.
+	Quantity Quantity // Quantity is the reported position of Security.
+	Cash     Money    // Cash is the reported cash balance, when Security is empty.
+}
+
+// Asset returns the name of the balance's asset: the security ticker or the cash currency.
+func (b BrokerBalance) Asset() string {
+	if b.Security != "" {
+		return b.Security
+	}
+	return b.Cash.Currency()
+}
+
+// Discrepancy is a broker balance that does not match the ledger.
+type Discrepancy struct {
+	Reported BrokerBalance
+	Quantity Quantity // Quantity is the ledger's position, for a security.
+	Cash     Money    // Cash is the ledger's cash balance, for a cash account.
+}
+
+// QuantityDiff returns the missing quantity in the ledger, negative if the ledger holds too much.
+func (d Discrepancy) QuantityDiff() Quantity { return d.Reported.Quantity.Sub(d.Quantity) }
+
+// CashDiff returns the missing cash in the ledger, negative if the ledger holds too much.
+func (d Discrepancy) CashDiff() Money { return d.Reported.Cash.Sub(d.Cash) }
+
+// Reconcile compares broker balances against the ledger state on their date, and
+// returns the balances that do not match.
+func (l *Ledger) Reconcile(balances ...BrokerBalance) []Discrepancy {
+	var res []Discrepancy
+	for _, b := range balances {
+		if b.Security != "" {
+			pos := l.Position(b.On, b.Security)
+			if !pos.Equal(b.Quantity) {
+				res = append(res, Discrepancy{Reported: b, Quantity: pos})
+			}
+			continue
+		}
+		cash := l.CashBalance(b.Cash.Currency(), b.On)
+		if !cash.Equal(b.Cash) {
+			res = append(res, Discrepancy{Reported: b, Cash: cash})
+		}
+	}
+	return res
+}
+
+// Adjustments returns the transactions that fix the discrepancy in the ledger.
+//
+// A cash discrepancy is fixed by a deposit or a withdrawal. A position discrepancy is
+// fixed by a transfer of shares valued at the ledger's price on that date: a deposit
+// followed by a buy for missing shares, or a sell followed by a withdrawal for
+// extra shares, so that the cash balance is unchanged.
+//
+// All transactions carry a memo to keep an audit trail of the reconciliation.
+func (d Discrepancy) Adjustments(ledger *Ledger) ([]Transaction, error) {
+	on := d.Reported.On
+	if d.Reported.Security == "" {
+		diff := d.CashDiff()
+		memo := fmt.Sprintf("reconcile: broker reports %s cash, ledger had %s", d.Reported.Cash, d.Cash)
+		if diff.IsPositive() {
+			return []Transaction{NewDeposit(on, memo, diff, "")}, nil
+		}
+		return []Transaction{NewWithdraw(on, memo, diff.Neg())}, nil
+	}
+
+	ticker := d.Reported.Security
+	sec := ledger.Security(ticker)
+	if sec == nil {
+		return nil, withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared", ticker))
+	}
+	price := ledger.NewSnapshot(on).UnitValue(ticker)
+	if price.IsZero() {
+		return nil, fmt.Errorf("no price for %q on %s to value the adjustment", ticker, on)
+	}
+	// Brokers may report more decimals than the security is traded with.
+	diff := sec.RoundQuantity(d.QuantityDiff())
+	if diff.IsZero() {
+		return nil, nil
+	}
+	memo := fmt.Sprintf("reconcile: broker reports %s %s, ledger had %s", d.Reported.Quantity, ticker, d.Quantity)
+	if diff.IsPositive() {
+		amount := price.Mul(diff)
+		return []Transaction{NewDeposit(on, memo, amount, ""), NewBuy(on, memo, ticker, diff, amount)}, nil
+	}
+	amount := price.Mul(diff.Neg())
+	return []Transaction{NewSell(on, memo, ticker, diff.Neg(), amount), NewWithdraw(on, memo, amount)}, nil
+}
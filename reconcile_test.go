@@ -0,0 +1,60 @@
+package portfolio
+
+import (
+	"testing"
+)
+
+func TestLedger_Reconcile(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(5), EUR(500)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "AAPL", EUR(110)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	on := NewDate(2025, 1, 5)
+	discrepancies := ledger.Reconcile(
+		BrokerBalance{On: on, Security: "AAPL", Quantity: Q(7)},
+		BrokerBalance{On: on, Cash: EUR(500)}, // matches
+		BrokerBalance{On: on, Cash: EUR(480)},
+	)
+	if len(discrepancies) != 2 {
+		t.Fatalf("Reconcile() returned %d discrepancies, want 2", len(discrepancies))
+	}
+	if got, want := discrepancies[0].QuantityDiff(), Q(2); !got.Equal(want) {
+		t.Errorf("QuantityDiff() = %v, want %v", got, want)
+	}
+	if got, want := discrepancies[1].CashDiff(), EUR(-20); !got.Equal(want) {
+		t.Errorf("CashDiff() = %v, want %v", got, want)
+	}
+
+	for _, d := range discrepancies {
+		adjustments, err := d.Adjustments(ledger)
+		if err != nil {
+			t.Fatalf("Adjustments() error = %v", err)
+		}
+		for _, tx := range adjustments {
+			if memo := tx.(interface{ Rationale() string }).Rationale(); memo == "" {
+				t.Errorf("adjustment %v has no audit memo", tx)
+			}
+			valid, err := ledger.Validate(tx)
+			if err != nil {
+				t.Fatalf("Validate(%v) error = %v", tx, err)
+			}
+			if err := ledger.Append(valid); err != nil {
+				t.Fatalf("Append(%v) error = %v", tx, err)
+			}
+		}
+	}
+
+	// Once adjusted, the ledger matches the broker.
+	if got := ledger.Reconcile(BrokerBalance{On: on, Security: "AAPL", Quantity: Q(7)}, BrokerBalance{On: on, Cash: EUR(480)}); len(got) != 0 {
+		t.Errorf("Reconcile() after adjustments = %v, want none", got)
+	}
+}
@@ -0,0 +1,46 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+)
+
+// AuditMarkdown renders the audit log of a ledger, one row per transaction
+// added (+) or removed (-) by each command.
+func AuditMarkdown(name string, entries []portfolio.AuditEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n\n", format.T("Changes for"), name)
+	if len(entries) == 0 {
+		fmt.Fprintln(&b, "No changes recorded.")
+		return b.String()
+	}
+	fmt.Fprintln(&b, "| Time | Command | | Date | Transaction |")
+	fmt.Fprintln(&b, "|:---|:---|:---:|:---|:---|")
+	for _, e := range entries {
+		when, command := e.Time.Local().Format("2006-01-02 15:04:05"), "-"
+		if e.Command != "" {
+			command = "`" + e.Command + "`"
+		}
+		row := func(change string, raw json.RawMessage) {
+			date, detail := "", string(raw)
+			if tx, err := portfolio.NewDecoder(bytes.NewReader(raw)).Decode(); err == nil {
+				date, detail = tx.When().String(), Transaction(tx)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", when, command, change, date, detail)
+			// The time and command are only printed on the first row of the entry.
+			when, command = "", ""
+		}
+		for _, raw := range e.Removed {
+			row("-", raw)
+		}
+		for _, raw := range e.Added {
+			row("+", raw)
+		}
+	}
+	return b.String()
+}
@@ -3,6 +3,8 @@ package renderer
 import (
 	"bytes"
 	"io"
+	"slices"
+	"strings"
 )
 
 // SectionPrinter is a helper to conditionally print a header and a footer for a section
@@ -69,3 +71,24 @@ func AllAreZero(items ...Zeroable) bool {
 	}
 	return true
 }
+
+// sparkBlocks are the characters of a sparkline, from the lowest to the highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as a line of block characters, scaled from the
+// lowest to the highest value.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if high > low {
+			i = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
@@ -5,13 +5,14 @@ import (
 	"strings"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
 )
 
 func HistoryMarkdown(snapshots []*portfolio.Snapshot, security, currency string) string {
 	var b strings.Builder
 
 	if security != "" {
-		fmt.Fprintf(&b, "# History for %s\n\n", security)
+		fmt.Fprintf(&b, "# %s %s\n\n", format.T("History for"), security)
 		fmt.Fprintln(&b, "| Date | Position | Price | Value |")
 		fmt.Fprintln(&b, "|:---|---:|---:|---:|")
 		for _, s := range snapshots {
@@ -23,7 +24,7 @@ func HistoryMarkdown(snapshots []*portfolio.Snapshot, security, currency string)
 			)
 		}
 	} else {
-		fmt.Fprintf(&b, "# History for %s\n\n", currency)
+		fmt.Fprintf(&b, "# %s %s\n\n", format.T("History for"), currency)
 		fmt.Fprintln(&b, "| Date | Value |")
 		fmt.Fprintln(&b, "|:---|---:|")
 		for _, s := range snapshots {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
 )
 
 // Still used in `assist` because that is the only way to let Gemini know the tickers (ID and description are key)
@@ -14,7 +15,7 @@ import (
 func DeclarationMarkdown(s *portfolio.Snapshot) string {
 	// use the snaphost to mark the asset as currently held or not
 	var b strings.Builder
-	fmt.Fprintf(&b, "# Securities\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", format.T("Securities"))
 	fmt.Fprintln(&b, "| Ticker | Held | Security ID | Currency | Description |")
 	fmt.Fprintln(&b, "|:---|:---:|:---|:---|:---|")
 
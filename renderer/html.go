@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Link is an entry of the navigation bar of an HTML page.
+type Link struct {
+	Title string
+	Href  string
+}
+
+// htmlPage is the layout of the HTML pages, styled to be readable without any
+// other resource.
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 1100px; margin: 0 auto; padding: 1em; color: #222; }
+nav { border-bottom: 1px solid #ddd; padding-bottom: .5em; margin-bottom: 1em; }
+nav a { margin-right: 1em; }
+table { border-collapse: collapse; margin: 1em 0; font-size: .9em; }
+th, td { border-bottom: 1px solid #ddd; padding: .3em .6em; }
+th { background: #f3f3f3; }
+a { color: #0550ae; text-decoration: none; }
+a:hover { text-decoration: underline; }
+</style>
+</head>
+<body>
+{{- if .Nav}}
+<nav>{{range .Nav}}<a href="{{.Href}}">{{.Title}}</a>{{end}}</nav>
+{{- end}}
+{{.Body}}
+</body>
+</html>
+`))
+
+// HTML writes a markdown document, as rendered by this package, as a
+// standalone HTML page with a navigation bar.
+func HTML(w io.Writer, title string, nav []Link, md string) error {
+	var body bytes.Buffer
+	if err := goldmark.New(goldmark.WithExtensions(extension.Table)).Convert([]byte(md), &body); err != nil {
+		return err
+	}
+	return htmlPage.Execute(w, struct {
+		Title string
+		Nav   []Link
+		Body  template.HTML
+	}{title, nav, template.HTML(body.String())})
+}
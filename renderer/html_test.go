@@ -0,0 +1,27 @@
+package renderer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	md := "# Title\n\n| Ticker | Value |\n|:---|---:|\n| [AAPL](security-AAPL.html) | **$1.00** |\n"
+	var b bytes.Buffer
+	if err := HTML(&b, "A & B", []Link{{Title: "Dashboard", Href: "index.html"}}, md); err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"<title>A &amp; B</title>",
+		`<nav><a href="index.html">Dashboard</a></nav>`,
+		"<h1>Title</h1>",
+		`<td style="text-align:left"><a href="security-AAPL.html">AAPL</a></td>`,
+		`<td style="text-align:right"><strong>$1.00</strong></td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML() does not contain %q:\n%s", want, got)
+		}
+	}
+}
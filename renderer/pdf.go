@@ -0,0 +1,225 @@
+package renderer
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// PDF page layout, in millimeters and points.
+const (
+	pdfMargin     = 15.0
+	pdfLineHeight = 4.5
+	pdfFontSize   = 9.0
+	pdfTableSize  = 8.0
+	pdfMinSize    = 5.0
+	pdfCellPad    = 2.0
+)
+
+// HoldingPDF writes the Holding report as a PDF document.
+func HoldingPDF(w io.Writer, h *Holding) error {
+	return PDF(w, RenderHolding(h))
+}
+
+// ReviewPDF writes the Review report as a PDF document.
+func ReviewPDF(w io.Writer, r *Review, opts ReviewRenderOptions) error {
+	return PDF(w, RenderReview(r, opts))
+}
+
+// PDF writes markdown documents, as rendered by this package, as a PDF
+// document, each one starting on a new page.
+//
+// Headings, paragraphs, lists and tables are laid out on A4 pages, and wide
+// tables are shrunk to fit the page width. The text is written with the
+// standard PDF fonts: characters outside of the Windows-1252 encoding, like
+// emojis, are dropped.
+func PDF(w io.Writer, documents ...string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	p := &pdfWriter{Fpdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	md := goldmark.New(goldmark.WithExtensions(extension.Table))
+	for _, document := range documents {
+		pdf.AddPage()
+		source := []byte(document)
+		doc := md.Parser().Parse(text.NewReader(source))
+		for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+			p.block(n, source)
+		}
+	}
+	return pdf.Output(w)
+}
+
+// pdfWriter lays out markdown blocks on the pages of a PDF document.
+type pdfWriter struct {
+	*gofpdf.Fpdf
+	tr func(string) string // tr translates UTF-8 to the encoding of the fonts.
+}
+
+// block writes a markdown block.
+func (p *pdfWriter) block(n ast.Node, source []byte) {
+	switch v := n.(type) {
+	case *ast.Heading:
+		sizes := map[int]float64{1: 16, 2: 13}
+		size, ok := sizes[v.Level]
+		if !ok {
+			size = 11
+		}
+		p.Ln(2)
+		p.SetFont("Helvetica", "B", size)
+		p.MultiCell(0, size/2, p.tr(inlineText(v, source)), "", "L", false)
+		p.Ln(1)
+	case *ast.Paragraph, *ast.TextBlock:
+		p.SetFont("Helvetica", "", pdfFontSize)
+		p.MultiCell(0, pdfLineHeight, p.tr(inlineText(v, source)), "", "L", false)
+		p.Ln(1)
+	case *ast.List:
+		p.SetFont("Helvetica", "", pdfFontSize)
+		for item := v.FirstChild(); item != nil; item = item.NextSibling() {
+			p.MultiCell(0, pdfLineHeight, p.tr("• "+inlineText(item, source)), "", "L", false)
+		}
+		p.Ln(1)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		p.SetFont("Courier", "", pdfTableSize)
+		var b strings.Builder
+		lines := v.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			b.Write(line.Value(source))
+		}
+		p.MultiCell(0, pdfLineHeight, p.tr(strings.TrimRight(b.String(), "\n")), "", "L", false)
+		p.Ln(1)
+	case *ast.ThematicBreak:
+		x, y := p.GetXY()
+		width, _ := p.GetPageSize()
+		p.Line(x, y+1, width-pdfMargin, y+1)
+		p.Ln(3)
+	case *east.Table:
+		p.table(v, source)
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			p.block(c, source)
+		}
+	}
+}
+
+// table writes a markdown table, with its font shrunk for the table to fit the
+// page width.
+func (p *pdfWriter) table(t *east.Table, source []byte) {
+	var rows [][]string
+	var bold [][]bool
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		var strong []bool
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, p.tr(inlineText(cell, source)))
+			strong = append(strong, isStrong(cell))
+		}
+		rows = append(rows, cells)
+		bold = append(bold, strong)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	// Columns are as wide as their widest cell, at the table font size.
+	size := pdfTableSize
+	widths := make([]float64, len(t.Alignments))
+	for i, cells := range rows {
+		for j, cell := range cells {
+			if j >= len(widths) {
+				continue
+			}
+			style := ""
+			if i == 0 || bold[i][j] {
+				style = "B"
+			}
+			p.SetFont("Helvetica", style, size)
+			widths[j] = max(widths[j], p.GetStringWidth(cell)+2*pdfCellPad)
+		}
+	}
+	pageWidth, _ := p.GetPageSize()
+	available := pageWidth - 2*pdfMargin
+	var total float64
+	for _, w := range widths {
+		total += w
+	}
+	if total > available {
+		scale := available / total
+		size = max(pdfMinSize, size*scale)
+		for j := range widths {
+			widths[j] *= scale
+		}
+	}
+
+	height := size / 2
+	aligns := map[east.Alignment]string{east.AlignRight: "R", east.AlignCenter: "C"}
+	for i, cells := range rows {
+		header := i == 0
+		if header {
+			p.SetFillColor(230, 230, 230)
+		}
+		for j, w := range widths {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			style := ""
+			if header || j < len(cells) && bold[i][j] {
+				style = "B"
+			}
+			align, ok := aligns[t.Alignments[j]]
+			if !ok {
+				align = "L"
+			}
+			p.SetFont("Helvetica", style, size)
+			p.CellFormat(w, height, cell, "B", 0, align, header, 0, "")
+		}
+		p.Ln(-1)
+	}
+	p.Ln(2)
+}
+
+// inlineText returns the text of the inline content of a markdown node,
+// without its formatting.
+func inlineText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	var walk func(ast.Node)
+	walk = func(n ast.Node) {
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			switch v := c.(type) {
+			case *ast.Text:
+				b.Write(v.Segment.Value(source))
+				if v.SoftLineBreak() || v.HardLineBreak() {
+					b.WriteByte(' ')
+				}
+			case *ast.String:
+				b.Write(v.Value)
+			case *ast.RawHTML:
+				// HTML is not rendered.
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+// isStrong reports whether all the content of a markdown node is strongly
+// emphasized, like the totals in tables.
+func isStrong(n ast.Node) bool {
+	c := n.FirstChild()
+	if c == nil || c.NextSibling() != nil {
+		return false
+	}
+	e, ok := c.(*ast.Emphasis)
+	return ok && e.Level == 2
+}
@@ -0,0 +1,33 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	var h Holding
+	var r Review
+	for file, v := range map[string]any{"testdata/holding.json": &h, "testdata/review.json": &r} {
+		data, err := testcasesFS.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", file, err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := PDF(&b, RenderReview(&r, ReviewRenderOptions{TopMovers: 5}), RenderHolding(&h)); err != nil {
+		t.Fatalf("PDF() error = %v", err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte("%PDF-")) {
+		t.Errorf("PDF() = %q..., want a PDF document", b.Bytes()[:min(b.Len(), 8)])
+	}
+	// Each document starts on a new page.
+	if pages := bytes.Count(b.Bytes(), []byte("/Type /Page\n")); pages < 2 {
+		t.Errorf("PDF() has %d pages, want at least 2", pages)
+	}
+}
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+)
+
+// PricesMarkdown renders the price history of a security, one row per period.
+// Daily histories list the prices and their source, longer periods list the
+// open, high, low and close prices. Periods following business days without a price are
+// highlighted.
+//
+// If compare is not empty, the closing prices of compared are listed side by
+// side, aligned on the periods.
+func PricesMarkdown(ticker string, p portfolio.Period, bars []portfolio.OHLC, compare string, compared []portfolio.OHLC) string {
+	var b strings.Builder
+
+	closes := make(map[portfolio.Date]portfolio.Money)
+	for _, bar := range compared {
+		closes[bar.Period.From] = bar.Close
+	}
+
+	fmt.Fprintf(&b, "# %s %s\n\n", format.T("Prices for"), ticker)
+	if len(bars) == 0 {
+		fmt.Fprintln(&b, "No prices recorded.")
+		return b.String()
+	}
+
+	header, align := "| Date | Price | Change | Source |", "|:---|---:|---:|:---|"
+	if p != portfolio.Daily {
+		header, align = "| Period | Open | High | Low | Close | Change |", "|:---|---:|---:|---:|---:|---:|"
+	}
+	if compare != "" {
+		header, align = header+" "+compare+" |", align+"---:|"
+	}
+	fmt.Fprintln(&b, header+" Gap |")
+	fmt.Fprintln(&b, align+":---|")
+
+	for i, bar := range bars {
+		change := ""
+		if i > 0 {
+			previous := bars[i-1].Close.AsFloat()
+			change = portfolio.Percent(100 * (bar.Close.AsFloat() - previous) / previous).SignedString()
+		}
+		if p == portfolio.Daily {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |", bar.Period.From, bar.Close, change, bar.Source)
+		} else {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |", bar.Period.Identifier(), bar.Open, bar.High, bar.Low, bar.Close, change)
+		}
+		if compare != "" {
+			if c, ok := closes[bar.Period.From]; ok {
+				fmt.Fprintf(&b, " %s |", c)
+			} else {
+				fmt.Fprint(&b, " - |")
+			}
+		}
+		if bar.Missing > 0 {
+			fmt.Fprintf(&b, " **%d missing days** |\n", bar.Missing)
+		} else {
+			fmt.Fprint(&b, "  |\n")
+		}
+	}
+	return b.String()
+}
+
+// IntradayMarkdown renders the price path of securities during a day, one
+// table per security with the change since its previous quote. The quotes are
+// grouped by ticker, as returned by portfolio.Ledger.Intraday.
+func IntradayMarkdown(day portfolio.Date, quotes []portfolio.Quote) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n", format.T("Intraday Prices on"), day)
+	if len(quotes) == 0 {
+		fmt.Fprintln(&b, "\nNo quotes recorded.")
+		return b.String()
+	}
+	for i, q := range quotes {
+		if i == 0 || quotes[i-1].Ticker != q.Ticker {
+			fmt.Fprintf(&b, "\n## %s\n\n", q.Ticker)
+			fmt.Fprintln(&b, "| Time | Price | Change |")
+			fmt.Fprintln(&b, "|:---|---:|---:|")
+			fmt.Fprintf(&b, "| %s | %s |  |\n", q.Time.Format(time.TimeOnly), q.Price)
+			continue
+		}
+		previous := quotes[i-1].Price.AsFloat()
+		change := portfolio.Percent(100 * (q.Price.AsFloat() - previous) / previous).SignedString()
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", q.Time.Format(time.TimeOnly), q.Price, change)
+	}
+	return b.String()
+}
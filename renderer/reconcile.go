@@ -0,0 +1,31 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+)
+
+// ReconcileMarkdown renders the discrepancies between broker balances and the ledger.
+func ReconcileMarkdown(checked int, discrepancies []portfolio.Discrepancy) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", format.T("Reconciliation"))
+	if len(discrepancies) == 0 {
+		fmt.Fprintf(&b, "All %d balances match the ledger.\n", checked)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d of %d balances do not match the ledger.\n\n", len(discrepancies), checked)
+	fmt.Fprintln(&b, "| Date | Asset | Ledger | Broker | Difference |")
+	fmt.Fprintln(&b, "|:---|:---|---:|---:|---:|")
+	for _, d := range discrepancies {
+		if d.Reported.Security != "" {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", d.Reported.On, d.Reported.Asset(), d.Quantity, d.Reported.Quantity, d.QuantityDiff())
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", d.Reported.On, d.Reported.Asset(), d.Cash, d.Reported.Cash, d.CashDiff().SignedString())
+	}
+	return b.String()
+}
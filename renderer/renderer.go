@@ -3,14 +3,83 @@ package renderer
 import (
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
+
+	"github.com/etnz/portfolio/format"
 )
 
 // ReviewRenderOptions holds configuration for rendering a review report.
 type ReviewRenderOptions struct {
 	SimplifiedView   bool // Use the simplified asset view instead of the consolidated one.
 	SkipTransactions bool // Do not render the transactions section.
+	TopMovers        int  // Number of top movers to render, zero skips the section.
+	ByCurrency       bool // Render the review of each currency, before conversion.
+
+	// Sections are the sections to render, in that order, among ReviewSections.
+	// All the sections are rendered in their default order if empty.
+	Sections []string
+}
+
+// ReviewSections are the names of the sections of a review report, in their
+// default order.
+var ReviewSections = []string{"summary", "accounts", "assets", "attribution", "movers", "transactions"}
+
+// reviewSections maps the sections of a review report to the partials that
+// render them, as laid out in review.md.
+var reviewSections = map[string]string{
+	"summary":      `{{template "review_summary" . }}` + "\n\n" + `{{template "review_comparison" . }}{{template "review_real" . }}`,
+	"accounts":     `{{template "review_accounts" . }}{{template "review_overdraft" . }}`,
+	"assets":       `{{template "asset_view" . }}`,
+	"attribution":  `{{template "review_attribution" . }}{{template "review_currency" . }}{{template "review_currencies" . }}`,
+	"movers":       `{{template "review_movers" . }}`,
+	"transactions": `{{template "review_transactions" . }}`,
+}
+
+// consolidatedReviewSections maps the sections of a consolidated review report
+// to the partials that render them, as laid out in consolidated_review.md.
+// The other sections are not available in a consolidated review.
+var consolidatedReviewSections = map[string]string{
+	"summary":      `{{template "consolidated_review_summary" . }}`,
+	"accounts":     `{{template "consolidated_review_accounts" . }}`,
+	"assets":       `{{template "consolidated_asset_view" . }}`,
+	"transactions": `{{template "consolidated_review_transactions" . }}`,
+}
+
+// ParseReviewSections parses a comma separated list of review sections, e.g.
+// "summary,movers,transactions", see ReviewSections. It returns nil for an
+// empty list, to render all the sections.
+func ParseReviewSections(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var sections []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(ReviewSections, name) {
+			return nil, fmt.Errorf("unknown review section %q, want one of %s", name, strings.Join(ReviewSections, ", "))
+		}
+		if slices.Contains(sections, name) {
+			return nil, fmt.Errorf("review section %q is listed twice", name)
+		}
+		sections = append(sections, name)
+	}
+	return sections, nil
+}
+
+// assembleSections returns the main template of a report with a title and
+// sections, the ones available among layouts, in that order.
+func assembleSections(title string, sections []string, layouts map[string]string) []byte {
+	parts := []string{title}
+	for _, name := range sections {
+		if layout, ok := layouts[name]; ok {
+			parts = append(parts, layout)
+		}
+	}
+	return []byte(strings.Join(parts, "\n\n"))
 }
 
 // RenderConsolidatedHolding renders the ConsolidatedHolding struct to a markdown string.
@@ -29,20 +98,105 @@ func RenderHolding(h *Holding) string {
 	partials := map[string]string{
 		"holding_title":          "holding_title.md",
 		"holding_securities":     "holding_securities.md",
+		"holding_lending":        "holding_lending.md",
 		"holding_cash":           "holding_cash.md",
 		"holding_counterparties": "holding_counterparties.md",
+		"holding_prices":         "holding_prices.md",
 	}
 	return renderTemplate("holding", "holding.md", partials, h)
 }
 
+// RenderTaxes renders the Taxes struct to a markdown string.
+func RenderTaxes(t *Taxes) string {
+	partials := map[string]string{
+		"taxes_title":       "taxes_title.md",
+		"taxes_sales":       "taxes_sales.md",
+		"taxes_totals":      "taxes_totals.md",
+		"taxes_assessments": "taxes_assessments.md",
+	}
+	return renderTemplate("taxes", "taxes.md", partials, t)
+}
+
+// RenderLog renders the Log struct to a markdown string.
+func RenderLog(l *Log) string {
+	partials := map[string]string{
+		"log_title": "log_title.md",
+		"log_table": "log_table.md",
+	}
+	return renderTemplate("log", "log.md", partials, l)
+}
+
+// RenderFees renders the Fees struct to a markdown string.
+func RenderFees(f *Fees) string {
+	partials := map[string]string{
+		"fees_title": "fees_title.md",
+		"fees_table": "fees_table.md",
+	}
+	return renderTemplate("fees", "fees.md", partials, f)
+}
+
+// RenderScores renders the Scores struct to a markdown string.
+func RenderScores(r *Scores) string {
+	partials := map[string]string{
+		"scores_title": "scores_title.md",
+		"scores_table": "scores_table.md",
+	}
+	return renderTemplate("scores", "scores.md", partials, r)
+}
+
+// RenderClosedPositions renders the ClosedPositions struct to a markdown string.
+func RenderClosedPositions(c *ClosedPositions) string {
+	partials := map[string]string{
+		"closed_positions_title": "closed_positions_title.md",
+		"closed_positions_table": "closed_positions_table.md",
+	}
+	return renderTemplate("closedPositions", "closed_positions.md", partials, c)
+}
+
+// RenderContributions renders the Contributions struct to a markdown string.
+func RenderContributions(c *Contributions) string {
+	partials := map[string]string{
+		"contributions_title": "contributions_title.md",
+		"contributions_table": "contributions_table.md",
+	}
+	return renderTemplate("contributions", "contributions.md", partials, c)
+}
+
+// RenderFire renders the Fire struct to a markdown string.
+func RenderFire(f *Fire) string {
+	partials := map[string]string{
+		"fire_title":      "fire_title.md",
+		"fire_progress":   "fire_progress.md",
+		"fire_crossovers": "fire_crossovers.md",
+		"fire_scenarios":  "fire_scenarios.md",
+	}
+	return renderTemplate("fire", "fire.md", partials, f)
+}
+
+// RenderSecurityReport renders the SecurityReport struct to a markdown string.
+func RenderSecurityReport(r *SecurityReport) string {
+	partials := map[string]string{
+		"security_title":        "security_title.md",
+		"security_position":     "security_position.md",
+		"security_dividends":    "security_dividends.md",
+		"security_transactions": "security_transactions.md",
+	}
+	return renderTemplate("security", "security.md", partials, r)
+}
+
 // RenderReview renders the Review struct to a markdown string.
 func RenderReview(r *Review, opts ReviewRenderOptions) string {
 	// Phase 1: Declare template dependencies.
 	// We define which partials are needed and how they are aliased in the main template.
 	partials := map[string]string{
-		"review_title":    "review_title.md",
-		"review_summary":  "review_summary.md",
-		"review_accounts": "review_accounts.md",
+		"review_title":       "review_title.md",
+		"review_summary":     "review_summary.md",
+		"review_comparison":  "review_comparison.md",
+		"review_real":        "review_real.md",
+		"review_accounts":    "review_accounts.md",
+		"review_attribution": "review_attribution.md",
+		"review_currency":    "review_currency.md",
+		"review_overdraft":   "review_overdraft.md",
 	}
 
 	// Conditionally select the asset view template.
@@ -59,7 +213,27 @@ func RenderReview(r *Review, opts ReviewRenderOptions) string {
 		partials["review_transactions"] = "review_transaction_skipped.md"
 	}
 
+	partials["review_currencies"] = ""
+	if opts.ByCurrency {
+		partials["review_currencies"] = "review_currencies.md"
+	}
+
+	// Keep only the top movers, on a copy not to alter the caller's review.
+	partials["review_movers"] = ""
+	if opts.TopMovers > 0 {
+		partials["review_movers"] = "review_movers.md"
+		if len(r.Movers) > opts.TopMovers {
+			top := *r
+			top.Movers = r.Movers[:opts.TopMovers]
+			r = &top
+		}
+	}
+
 	// Phase 2: Execute rendering with the generic utility.
+	if len(opts.Sections) > 0 {
+		main := assembleSections(`{{template "review_title" . }}`, opts.Sections, reviewSections)
+		return renderContent("review", "review.md", main, partials, r)
+	}
 	return renderTemplate("review", "review.md", partials, r)
 }
 
@@ -84,17 +258,73 @@ func RenderConsolidatedReview(cr *ConsolidatedReview, opts ReviewRenderOptions)
 		partials["consolidated_review_transactions"] = "review_transaction_skipped.md" // Reuse the skipped template
 	}
 
+	if len(opts.Sections) > 0 {
+		main := assembleSections(`{{template "consolidated_review_title" . }}`, opts.Sections, consolidatedReviewSections)
+		return renderContent("consolidatedReview", "consolidated_review.md", main, partials, cr)
+	}
 	return renderTemplate("consolidatedReview", "consolidated_review.md", partials, cr)
 }
 
+// funcs are the functions available in templates.
+var funcs = template.FuncMap{
+	"tr": format.T, // tr translates a section heading into the current locale.
+}
+
+// userTemplates holds the content of the user templates by file name. They
+// override the embedded templates with the same name.
+var userTemplates map[string][]byte
+
+// LoadTemplates loads the user templates (*.md files) from dir, to override the
+// embedded templates with the same name. Templates are validated: each must
+// override an embedded template and parse without errors, otherwise none is loaded.
+func LoadTemplates(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read templates directory: %w", err)
+	}
+	loaded := make(map[string][]byte)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+			continue
+		}
+		if _, err := fs.Stat(templates, e.Name()); err != nil {
+			return fmt.Errorf("template %q does not match any built-in template", e.Name())
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("cannot read template %q: %w", e.Name(), err)
+		}
+		if _, err := template.New(e.Name()).Funcs(funcs).Parse(string(content)); err != nil {
+			return fmt.Errorf("invalid template %q: %w", e.Name(), err)
+		}
+		loaded[e.Name()] = content
+	}
+	userTemplates = loaded
+	return nil
+}
+
+// readTemplate returns the content of a template file, the user's one if loaded,
+// or the embedded one.
+func readTemplate(name string) ([]byte, error) {
+	if content, ok := userTemplates[name]; ok {
+		return content, nil
+	}
+	return fs.ReadFile(templates, name)
+}
+
 // renderTemplate is a generic utility to render a main template that depends on several partials.
 func renderTemplate(templateName, mainFile string, partials map[string]string, data any) string {
-	mainContent, err := fs.ReadFile(templates, mainFile)
+	mainContent, err := readTemplate(mainFile)
 	if err != nil {
 		return fmt.Sprintf("error reading main template %q: %v", mainFile, err)
 	}
+	return renderContent(templateName, mainFile, mainContent, partials, data)
+}
 
-	tmpl, err := template.New(templateName).Parse(string(mainContent))
+// renderContent renders the content of a main template, in place of the one
+// of mainFile, with its partials.
+func renderContent(templateName, mainFile string, mainContent []byte, partials map[string]string, data any) string {
+	tmpl, err := template.New(templateName).Funcs(funcs).Parse(string(mainContent))
 	if err != nil {
 		return fmt.Sprintf("error parsing main template %q: %v", mainFile, err)
 	}
@@ -104,7 +334,7 @@ func renderTemplate(templateName, mainFile string, partials map[string]string, d
 		// An empty file name is a valid case, resulting in an empty template.
 		if file != "" {
 			var readErr error
-			content, readErr = fs.ReadFile(templates, file)
+			content, readErr = readTemplate(file)
 			if readErr != nil {
 				return fmt.Sprintf("error reading partial template %q: %v", file, err)
 			}
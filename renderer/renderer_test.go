@@ -7,8 +7,10 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"text/template"
@@ -47,12 +49,54 @@ func TestTemplatePartials(t *testing.T) {
 			goldenFile: "testdata/review_summary.md",
 			dataType:   &Review{},
 		},
+		{
+			name:       "review_comparison",
+			structFile: "testdata/review_comparison.json",
+			goldenFile: "testdata/review_comparison.md",
+			dataType:   &Review{},
+		},
+		{
+			name:       "review_real",
+			structFile: "testdata/review_real.json",
+			goldenFile: "testdata/review_real.md",
+			dataType:   &Review{},
+		},
 		{
 			name:       "review_accounts",
 			structFile: "testdata/review_accounts.json",
 			goldenFile: "testdata/review_accounts.md",
 			dataType:   &Review{},
 		},
+		{
+			name:       "review_attribution",
+			structFile: "testdata/review_attribution.json",
+			goldenFile: "testdata/review_attribution.md",
+			dataType:   &Review{},
+		},
+		{
+			name:       "review_currency",
+			structFile: "testdata/review_currency.json",
+			goldenFile: "testdata/review_currency.md",
+			dataType:   &Review{},
+		},
+		{
+			name:       "review_currencies",
+			structFile: "testdata/review_currencies.json",
+			goldenFile: "testdata/review_currencies.md",
+			dataType:   &Review{},
+		},
+		{
+			name:       "review_overdraft",
+			structFile: "testdata/review_overdraft.json",
+			goldenFile: "testdata/review_overdraft.md",
+			dataType:   &Review{},
+		},
+		{
+			name:       "review_movers",
+			structFile: "testdata/review_movers.json",
+			goldenFile: "testdata/review_movers.md",
+			dataType:   &Review{},
+		},
 		{
 			name:       "review_transactions",
 			structFile: "testdata/review_transactions.json",
@@ -77,6 +121,90 @@ func TestTemplatePartials(t *testing.T) {
 			goldenFile: "testdata/review_transaction_skipped.md",
 			dataType:   &Review{},
 		},
+		{
+			name:       "log_title",
+			structFile: "testdata/log_title.json",
+			goldenFile: "testdata/log_title.md",
+			dataType:   &Log{},
+		},
+		{
+			name:       "log_table",
+			structFile: "testdata/log_table.json",
+			goldenFile: "testdata/log_table.md",
+			dataType:   &Log{},
+		},
+		{
+			name:       "fees_title",
+			structFile: "testdata/fees_title.json",
+			goldenFile: "testdata/fees_title.md",
+			dataType:   &Fees{},
+		},
+		{
+			name:       "fees_table",
+			structFile: "testdata/fees_table.json",
+			goldenFile: "testdata/fees_table.md",
+			dataType:   &Fees{},
+		},
+		{
+			name:       "scores_title",
+			structFile: "testdata/scores_title.json",
+			goldenFile: "testdata/scores_title.md",
+			dataType:   &Scores{},
+		},
+		{
+			name:       "scores_table",
+			structFile: "testdata/scores_table.json",
+			goldenFile: "testdata/scores_table.md",
+			dataType:   &Scores{},
+		},
+		{
+			name:       "closed_positions_title",
+			structFile: "testdata/closed_positions_title.json",
+			goldenFile: "testdata/closed_positions_title.md",
+			dataType:   &ClosedPositions{},
+		},
+		{
+			name:       "closed_positions_table",
+			structFile: "testdata/closed_positions_table.json",
+			goldenFile: "testdata/closed_positions_table.md",
+			dataType:   &ClosedPositions{},
+		},
+		{
+			name:       "contributions_title",
+			structFile: "testdata/contributions_title.json",
+			goldenFile: "testdata/contributions_title.md",
+			dataType:   &Contributions{},
+		},
+		{
+			name:       "contributions_table",
+			structFile: "testdata/contributions_table.json",
+			goldenFile: "testdata/contributions_table.md",
+			dataType:   &Contributions{},
+		},
+		{
+			name:       "fire_title",
+			structFile: "testdata/fire_title.json",
+			goldenFile: "testdata/fire_title.md",
+			dataType:   &Fire{},
+		},
+		{
+			name:       "fire_progress",
+			structFile: "testdata/fire_progress.json",
+			goldenFile: "testdata/fire_progress.md",
+			dataType:   &Fire{},
+		},
+		{
+			name:       "fire_crossovers",
+			structFile: "testdata/fire_crossovers.json",
+			goldenFile: "testdata/fire_crossovers.md",
+			dataType:   &Fire{},
+		},
+		{
+			name:       "fire_scenarios",
+			structFile: "testdata/fire_scenarios.json",
+			goldenFile: "testdata/fire_scenarios.md",
+			dataType:   &Fire{},
+		},
 		{
 			name:       "consolidated_review_title",
 			structFile: "testdata/consolidated_review_title.json",
@@ -125,6 +253,12 @@ func TestTemplatePartials(t *testing.T) {
 			goldenFile: "testdata/holding_securities.md",
 			dataType:   &Holding{},
 		},
+		{
+			name:       "holding_lending",
+			structFile: "testdata/holding.json",
+			goldenFile: "testdata/holding_lending.md",
+			dataType:   &Holding{},
+		},
 		{
 			name:       "holding_cash",
 			structFile: "testdata/holding.json",
@@ -137,6 +271,12 @@ func TestTemplatePartials(t *testing.T) {
 			goldenFile: "testdata/holding_counterparties.md",
 			dataType:   &Holding{},
 		},
+		{
+			name:       "holding_prices",
+			structFile: "testdata/holding.json",
+			goldenFile: "testdata/holding_prices.md",
+			dataType:   &Holding{},
+		},
 		{
 			name:       "consolidated_holding_title",
 			structFile: "testdata/consolidated_holding.json",
@@ -161,6 +301,54 @@ func TestTemplatePartials(t *testing.T) {
 			goldenFile: "testdata/consolidated_holding_counterparties.md",
 			dataType:   &ConsolidatedHolding{},
 		},
+		{
+			name:       "security_title",
+			structFile: "testdata/security.json",
+			goldenFile: "testdata/security_title.md",
+			dataType:   &SecurityReport{},
+		},
+		{
+			name:       "security_position",
+			structFile: "testdata/security.json",
+			goldenFile: "testdata/security_position.md",
+			dataType:   &SecurityReport{},
+		},
+		{
+			name:       "security_dividends",
+			structFile: "testdata/security.json",
+			goldenFile: "testdata/security_dividends.md",
+			dataType:   &SecurityReport{},
+		},
+		{
+			name:       "security_transactions",
+			structFile: "testdata/security.json",
+			goldenFile: "testdata/security_transactions.md",
+			dataType:   &SecurityReport{},
+		},
+		{
+			name:       "taxes_title",
+			structFile: "testdata/taxes.json",
+			goldenFile: "testdata/taxes_title.md",
+			dataType:   &Taxes{},
+		},
+		{
+			name:       "taxes_sales",
+			structFile: "testdata/taxes.json",
+			goldenFile: "testdata/taxes_sales.md",
+			dataType:   &Taxes{},
+		},
+		{
+			name:       "taxes_totals",
+			structFile: "testdata/taxes.json",
+			goldenFile: "testdata/taxes_totals.md",
+			dataType:   &Taxes{},
+		},
+		{
+			name:       "taxes_assessments",
+			structFile: "testdata/taxes.json",
+			goldenFile: "testdata/taxes_assessments.md",
+			dataType:   &Taxes{},
+		},
 	}
 
 	// --- Coverage Check ---
@@ -245,7 +433,7 @@ func TestTemplatePartials(t *testing.T) {
 			}
 
 			// 3. Execute the template
-			tmpl, err := template.New(tc.name).Parse(string(templateContent))
+			tmpl, err := template.New(tc.name).Funcs(funcs).Parse(string(templateContent))
 			if err != nil {
 				t.Fatalf("failed to parse template %q: %v", templateFile, err)
 			}
@@ -310,7 +498,7 @@ func TestReportRendering(t *testing.T) {
 			goldenFile: "testdata/review_assembly.md",
 			dataType:   &Review{},
 			renderFunc: func(t *testing.T, data any) string {
-				return RenderReview(data.(*Review), ReviewRenderOptions{SimplifiedView: false, SkipTransactions: false})
+				return RenderReview(data.(*Review), ReviewRenderOptions{SimplifiedView: false, SkipTransactions: false, TopMovers: 5, ByCurrency: true})
 			},
 		},
 		{
@@ -340,6 +528,78 @@ func TestReportRendering(t *testing.T) {
 				return RenderConsolidatedHolding(data.(*ConsolidatedHolding))
 			},
 		},
+		{
+			name:       "security",
+			structFile: "testdata/security.json",
+			goldenFile: "testdata/security_assembly.md",
+			dataType:   &SecurityReport{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderSecurityReport(data.(*SecurityReport))
+			},
+		},
+		{
+			name:       "fees",
+			structFile: "testdata/fees.json",
+			goldenFile: "testdata/fees_assembly.md",
+			dataType:   &Fees{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderFees(data.(*Fees))
+			},
+		},
+		{
+			name:       "scores",
+			structFile: "testdata/scores.json",
+			goldenFile: "testdata/scores_assembly.md",
+			dataType:   &Scores{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderScores(data.(*Scores))
+			},
+		},
+		{
+			name:       "closed_positions",
+			structFile: "testdata/closed_positions.json",
+			goldenFile: "testdata/closed_positions_assembly.md",
+			dataType:   &ClosedPositions{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderClosedPositions(data.(*ClosedPositions))
+			},
+		},
+		{
+			name:       "contributions",
+			structFile: "testdata/contributions.json",
+			goldenFile: "testdata/contributions_assembly.md",
+			dataType:   &Contributions{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderContributions(data.(*Contributions))
+			},
+		},
+		{
+			name:       "fire",
+			structFile: "testdata/fire.json",
+			goldenFile: "testdata/fire_assembly.md",
+			dataType:   &Fire{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderFire(data.(*Fire))
+			},
+		},
+		{
+			name:       "log",
+			structFile: "testdata/log.json",
+			goldenFile: "testdata/log_assembly.md",
+			dataType:   &Log{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderLog(data.(*Log))
+			},
+		},
+		{
+			name:       "taxes",
+			structFile: "testdata/taxes.json",
+			goldenFile: "testdata/taxes_assembly.md",
+			dataType:   &Taxes{},
+			renderFunc: func(t *testing.T, data any) string {
+				return RenderTaxes(data.(*Taxes))
+			},
+		},
 	}
 
 	// --- Coverage Check ---
@@ -576,3 +836,94 @@ func parseTemplates(t *testing.T) templateSet {
 
 	return set
 }
+
+func TestLoadTemplates(t *testing.T) {
+	defer func() { userTemplates = nil }()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("holding_title.md", "# My Holdings on {{ .Date }}")
+	if err := LoadTemplates(dir); err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+	got := RenderHolding(&Holding{})
+	if !strings.HasPrefix(got, "# My Holdings on") {
+		t.Errorf("RenderHolding() = %q, want the user title", got)
+	}
+
+	write("holding_title.md", "{{ .Date ")
+	if err := LoadTemplates(dir); err == nil {
+		t.Errorf("LoadTemplates() with an invalid template: expected an error")
+	}
+
+	write("holding_title.md", "# Holdings")
+	write("unknown.md", "")
+	if err := LoadTemplates(dir); err == nil {
+		t.Errorf("LoadTemplates() with an unknown template: expected an error")
+	}
+}
+
+func TestRenderReview_Sections(t *testing.T) {
+	load := func() *Review {
+		jsonData, err := testcasesFS.ReadFile("testdata/review.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r Review
+		if err := json.Unmarshal(jsonData, &r); err != nil {
+			t.Fatal(err)
+		}
+		return &r
+	}
+	opts := ReviewRenderOptions{TopMovers: 5, ByCurrency: true}
+	want := RenderReview(load(), opts)
+
+	// All the sections in their default order is the layout of review.md.
+	opts.Sections = ReviewSections
+	if got := RenderReview(load(), opts); got != want {
+		t.Errorf("RenderReview() with all the sections differs from review.md:\n%s", createDiff(want, got))
+	}
+
+	opts.Sections = []string{"movers", "summary"}
+	got := RenderReview(load(), opts)
+	movers, comparison := strings.Index(got, "## Top Movers"), strings.Index(got, "## Comparison")
+	if movers < 0 || comparison < 0 || movers > comparison {
+		t.Errorf("RenderReview() = %q, want the movers then the summary", got)
+	}
+	for _, skipped := range []string{"## Accounts", "## Transactions", "## Attribution"} {
+		if strings.Contains(got, skipped) {
+			t.Errorf("RenderReview() contains %q, want only the movers and the summary", skipped)
+		}
+	}
+}
+
+func TestParseReviewSections(t *testing.T) {
+	if got, err := ParseReviewSections(""); err != nil || got != nil {
+		t.Errorf("ParseReviewSections(\"\") = %v, %v, want nil, nil", got, err)
+	}
+	got, err := ParseReviewSections("movers, summary")
+	if err != nil {
+		t.Fatalf("ParseReviewSections() error = %v", err)
+	}
+	if want := []string{"movers", "summary"}; !slices.Equal(got, want) {
+		t.Errorf("ParseReviewSections() = %v, want %v", got, want)
+	}
+	for _, invalid := range []string{"summary,unknown", "summary,summary", "summary,"} {
+		if _, err := ParseReviewSections(invalid); err == nil {
+			t.Errorf("ParseReviewSections(%q): expected an error", invalid)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := Sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}), "▁▂▃▄▅▆▇█"; got != want {
+		t.Errorf("Sparkline() = %q, want %q", got, want)
+	}
+	if got, want := Sparkline([]float64{math.NaN(), 2, 2}), " ▅▅"; got != want {
+		t.Errorf("Sparkline() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+// TestReports renders the reports of the ledger of testdata/reports, and checks
+// that they are byte-identical to their golden files, run after run: the maps
+// behind the reports must not change their output.
+//
+// The golden files are updated with -fix-partials.
+func TestReports(t *testing.T) {
+	t.Setenv("PORTFOLIO_TESTING_NOW", "2025-07-01 09:00:00")
+	f, err := os.Open("testdata/reports/ledger.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ledger, err := portfolio.DecodeValidateLedger(f)
+	if err != nil {
+		t.Fatalf("DecodeValidateLedger() error = %v", err)
+	}
+	on := portfolio.NewDate(2025, 6, 30)
+	period := portfolio.NewRange(portfolio.NewDate(2025, 1, 1), on)
+
+	reports := []struct {
+		name   string
+		render func() (string, error)
+	}{
+		{"holding", func() (string, error) {
+			return RenderHolding(NewHolding(ledger.NewSnapshot(on))), nil
+		}},
+		{"review", func() (string, error) {
+			r := NewReview(ledger.NewReview(period), portfolio.AverageCost)
+			return RenderReview(r, ReviewRenderOptions{TopMovers: 3, ByCurrency: true}), nil
+		}},
+		{"log", func() (string, error) {
+			reviews, err := ledger.GenerateLog(period, portfolio.Monthly)
+			if err != nil {
+				return "", err
+			}
+			return RenderLog(NewLog(reviews, portfolio.Monthly, true)), nil
+		}},
+		{"fees", func() (string, error) {
+			return RenderFees(NewFees(ledger.NewSnapshot(on))), nil
+		}},
+		{"scores", func() (string, error) {
+			return RenderScores(NewScores(ledger.NewSnapshot(on), "conviction", 0, nil)), nil
+		}},
+		{"closed_positions", func() (string, error) {
+			return RenderClosedPositions(NewClosedPositions(ledger.NewSnapshot(on), portfolio.Yearly)), nil
+		}},
+		{"contributions", func() (string, error) {
+			return RenderContributions(NewContributions(ledger)), nil
+		}},
+		{"security", func() (string, error) {
+			r, err := NewSecurityReport(ledger, on, "AAPL")
+			if err != nil {
+				return "", err
+			}
+			return RenderSecurityReport(r), nil
+		}},
+	}
+	for _, tc := range reports {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.render()
+			if err != nil {
+				t.Fatalf("render error = %v", err)
+			}
+			for range 10 {
+				again, err := tc.render()
+				if err != nil {
+					t.Fatalf("render error = %v", err)
+				}
+				if again != got {
+					t.Fatalf("output changed from a run to the next:\n--- first\n+++ next\n%s", createDiff(got, again))
+				}
+			}
+
+			goldenFile := filepath.Join("testdata", "reports", tc.name+".md")
+			want, err := os.ReadFile(goldenFile)
+			if err != nil && !(os.IsNotExist(err) && *fixPartials) {
+				t.Fatalf("failed to read golden file %q: %v", goldenFile, err)
+			}
+			if got == string(want) {
+				return
+			}
+			if *fixPartials {
+				if err := os.WriteFile(goldenFile, []byte(got), 0644); err != nil {
+					t.Fatalf("failed to write updated golden file %q: %v", goldenFile, err)
+				}
+				t.Logf("updated golden file %s", goldenFile)
+				return
+			}
+			t.Errorf("output mismatch for %s:\n--- want\n+++ got\n%s", tc.name, createDiff(string(want), got))
+		})
+	}
+}
@@ -3,8 +3,10 @@ package renderer
 import (
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
 )
 
 func getPeriodRanges(p portfolio.Period, on portfolio.Date) (current portfolio.Range, previous portfolio.Range) {
@@ -41,7 +43,7 @@ func RenderMultiPeriodSummary(w io.Writer, on portfolio.Date, ledger *portfolio.
 		reviews = append(reviews, currentReview)
 	}
 
-	fmt.Fprintf(w, "# Portfolio Summary on %s\n\n", on)
+	fmt.Fprintf(w, "# %s %s\n\n", format.T("Portfolio Summary on"), on)
 	fmt.Fprintf(w, "*As of %s*\n\n", Now().Format("2006-01-02 15:04:05"))
 
 	// Header
@@ -114,13 +116,16 @@ func RenderMultiPeriodSummary(w io.Writer, on portfolio.Date, ledger *portfolio.
 
 	printLine()
 	printRow("\u00A0\u00A0Dividends", func(r *portfolio.Review) string { return r.Dividends().SignedString() })
+	if slices.ContainsFunc(reviews, func(r *portfolio.Review) bool { return !r.LendingFees().IsZero() }) {
+		printRow("+ Lending Fees", func(r *portfolio.Review) string { return r.LendingFees().SignedString() })
+	}
 	printRow("+ Market Gains", func(r *portfolio.Review) string { return r.MarketGain().SignedString() })
 	printRow("+ Forex Gains", func(r *portfolio.Review) string {
 		return r.PortfolioChange().Sub(r.CashFlow()).Sub(r.MarketGain()).SignedString()
 	})
 	printRowBold("= Total Gains", func(r *portfolio.Review) string {
 		forexGain := r.PortfolioChange().Sub(r.CashFlow()).Sub(r.MarketGain())
-		return r.MarketGain().Add(forexGain).Add(r.Dividends()).SignedString()
+		return r.MarketGain().Add(forexGain).Add(r.Dividends()).Add(r.LendingFees()).SignedString()
 	})
 
 	return true
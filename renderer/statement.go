@@ -0,0 +1,28 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+)
+
+// StatementMarkdown renders the statement of a cash account: the opening balance,
+// every movement of the period with the running balance, and the closing balance.
+func StatementMarkdown(currency string, period portfolio.Range, opening portfolio.Money, entries []portfolio.CashEntry) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s %s %s %s %s %s\n\n", format.T("Statement for"), currency, format.T("from"), period.From, format.T("to"), period.To)
+	fmt.Fprintln(&b, "| Date | Transaction | Amount | Balance |")
+	fmt.Fprintln(&b, "|:---|:---|---:|---:|")
+	fmt.Fprintf(&b, "| %s | **Opening Balance** | | **%s** |\n", period.From, opening)
+	closing := opening
+	for _, e := range entries {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", e.Date, Transaction(e.Transaction), e.Amount.SignedString(), e.Balance)
+		closing = e.Balance
+	}
+	fmt.Fprintf(&b, "| %s | **Closing Balance** | | **%s** |\n", period.To, closing)
+
+	return b.String()
+}
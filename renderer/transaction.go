@@ -26,33 +26,90 @@ func Transactions(txs []portfolio.Transaction) string {
 	return b.String()
 }
 
+// TagTotals renders the totals of transactions by tag as a markdown table.
+func TagTotals(totals []portfolio.TagTotal) string {
+	if len(totals) == 0 {
+		return "No tagged transactions.\n"
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, "| Tag | Transactions | Amount |")
+	fmt.Fprintln(&b, "|:---|---:|---:|")
+	for _, t := range totals {
+		amounts := make([]string, len(t.Amounts))
+		for i, m := range t.Amounts {
+			amounts[i] = m.String()
+		}
+		amount := strings.Join(amounts, ", ")
+		if amount == "" {
+			amount = "-"
+		}
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", t.Tag, t.Transactions, amount)
+	}
+	return b.String()
+}
+
 // Transaction renders a transaction to a string.
 func Transaction(tx portfolio.Transaction) string {
 	switch v := tx.(type) {
 	case portfolio.Buy:
-		return fmt.Sprintf("Buy %v of %q for %v", v.Quantity, v.Security, v.Amount)
+		return fmt.Sprintf("Buy %v of %q for %v%s%s%s", v.Quantity, v.Security, v.Amount, account(v.Account), venue(v.Venue), strategy(v.Strategy))
 	case portfolio.Sell:
-		return fmt.Sprintf("Sell %v of %q for %v", v.Quantity, v.Security, v.Amount)
+		return fmt.Sprintf("Sell %v of %q for %v%s%s%s", v.Quantity, v.Security, v.Amount, account(v.Account), venue(v.Venue), strategy(v.Strategy))
 	case portfolio.Dividend:
 		return fmt.Sprintf("Receive dividend of %v per share for %q", v.Amount, v.Security)
+	case portfolio.Expire:
+		return fmt.Sprintf("Expire %q", v.Security)
+	case portfolio.Assign:
+		return fmt.Sprintf("Assign %v of %q", v.Quantity, v.Security)
+	case portfolio.Lend:
+		return fmt.Sprintf("Lend %v of %q", v.Quantity, v.Security)
+	case portfolio.Recall:
+		return fmt.Sprintf("Recall %v of %q", v.Quantity, v.Security)
+	case portfolio.LendingFee:
+		return fmt.Sprintf("Receive lending fee of %v for %q", v.Amount, v.Security)
+	case portfolio.Grant:
+		return fmt.Sprintf("Grant %v of %q as %s %q", v.Quantity(), v.Security, strings.ToUpper(v.Plan), v.Grant)
+	case portfolio.Vest:
+		return fmt.Sprintf("Vest %v of %q from %q at %v", v.Quantity, v.Security, v.Grant, v.Amount)
+	case portfolio.Note:
+		return fmt.Sprintf("Note on %q: %s", v.Security, v.Memo)
+	case portfolio.SetPriceAlert:
+		if !v.Expiry.IsZero() {
+			return fmt.Sprintf("Alert when %q is %s %v until %s", v.Security, v.Direction, v.Threshold, v.Expiry)
+		}
+		return fmt.Sprintf("Alert when %q is %s %v", v.Security, v.Direction, v.Threshold)
+	case portfolio.ClearPriceAlert:
+		return fmt.Sprintf("Clear alert when %q is %s %v", v.Security, v.Direction, v.Threshold)
 	case portfolio.Deposit:
 		m := v.Amount
-		return fmt.Sprintf("Deposit %v", m)
+		if v.Source != "" {
+			return fmt.Sprintf("Deposit %v%s from %s", m, account(v.Account), v.Source)
+		}
+		return fmt.Sprintf("Deposit %v%s", m, account(v.Account))
 	case portfolio.Withdraw:
 		m := v.Amount
-		return fmt.Sprintf("Withdraw %v", m)
+		return fmt.Sprintf("Withdraw %v%s", m, account(v.Account))
 	case portfolio.Accrue:
-
-		if v.Amount.IsPositive() {
+		var rate string
+		if v.Rate != 0 {
+			rate = fmt.Sprintf(" at %v a year", v.Rate)
+		}
+		switch {
+		case v.Amount.IsZero():
+			return fmt.Sprintf("Set the interest rate of %q to %v a year", v.Counterparty, v.Rate)
+		case v.Amount.IsPositive():
 			m := v.Amount
-			return fmt.Sprintf("Accrue receivable %v from %q", m, v.Counterparty)
+			return fmt.Sprintf("Accrue receivable %v from %q%s", m, v.Counterparty, rate)
 		}
 		m := v.Amount.Neg()
-		return fmt.Sprintf("Accrue payable %v to %q", m, v.Counterparty)
+		return fmt.Sprintf("Accrue payable %v to %q%s", m, v.Counterparty, rate)
 	case portfolio.Convert:
 		return fmt.Sprintf("Convert %v to %v", v.FromAmount, v.ToAmount)
 	case portfolio.Declare:
 		return fmt.Sprintf("Declare %q as %q in %s", v.Ticker, v.ID, v.Currency)
+	case portfolio.DeclareCurrency:
+		c := v.Currency()
+		return fmt.Sprintf("Declare currency %q with symbol %q and %d decimals", c.Code, c.Symbol, c.Decimals)
 	case portfolio.UpdatePrice:
 		var buf strings.Builder
 		keys := slices.Collect(maps.Keys(v.Prices))
@@ -67,7 +124,51 @@ func Transaction(tx portfolio.Transaction) string {
 			}
 		}
 		return buf.String()
+	case portfolio.Custom:
+		var effects []string
+		for _, c := range v.Cash {
+			effects = append(effects, c.Amount.SignedString())
+		}
+		for _, p := range v.Positions {
+			effects = append(effects, fmt.Sprintf("%s%v of %q", sign(p.Quantity.IsNegative()), p.Quantity, p.Security))
+		}
+		if len(effects) == 0 {
+			return string(v.What())
+		}
+		return fmt.Sprintf("%s: %s", v.What(), strings.Join(effects, ", "))
 	default:
 		return string(tx.What())
 	}
 }
+
+// sign returns the "+" prefix of positive values, negative values already print their sign.
+func sign(negative bool) string {
+	if negative {
+		return ""
+	}
+	return "+"
+}
+
+// account returns the mention of the cash account of a transaction, if any.
+func account(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in %q", name)
+}
+
+// venue returns the mention of the venue of a trade, if any.
+func venue(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" on %q", name)
+}
+
+// strategy returns the mention of the strategy of a trade, if any.
+func strategy(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", name)
+}
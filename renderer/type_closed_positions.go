@@ -0,0 +1,76 @@
+package renderer
+
+import (
+	"github.com/etnz/portfolio"
+)
+
+// ClosedPositions is a struct to represent the performance of the positions
+// fully sold, grouped by the period they were closed in.
+type ClosedPositions struct {
+	// Name of the ledger.
+	Name string         `json:"name,omitempty"`
+	Date portfolio.Date `json:"date"`
+	// Period is the name of the periods of the groups, e.g. yearly.
+	Period string                 `json:"period"`
+	Groups []ClosedPositionsGroup `json:"groups"`
+}
+
+// ClosedPositionsGroup holds the positions closed in a period, and their
+// totals in the reporting currency.
+type ClosedPositionsGroup struct {
+	Period       string           `json:"period"`
+	Positions    []ClosedPosition `json:"positions"`
+	RealizedGain portfolio.Money  `json:"realizedGain"`
+	Dividends    portfolio.Money  `json:"dividends"`
+}
+
+// ClosedPosition holds the performance of a closed position, in the currency
+// of its security.
+type ClosedPosition struct {
+	Ticker           string            `json:"ticker"`
+	Opened           portfolio.Date    `json:"opened"`
+	Closed           portfolio.Date    `json:"closed"`
+	Days             int               `json:"days"`
+	Invested         portfolio.Money   `json:"invested"`
+	Proceeds         portfolio.Money   `json:"proceeds"`
+	Dividends        portfolio.Money   `json:"dividends"`
+	RealizedGain     portfolio.Money   `json:"realizedGain"`
+	AnnualizedReturn portfolio.Percent `json:"annualizedReturn"`
+}
+
+// NewClosedPositions creates a new ClosedPositions struct from a portfolio
+// snapshot, with the positions closed up to its date grouped by period. The
+// totals are converted at the rates of the snapshot.
+func NewClosedPositions(s *portfolio.Snapshot, period portfolio.Period) *ClosedPositions {
+	r := &ClosedPositions{
+		Name:   s.Name(),
+		Date:   s.On(),
+		Period: period.String(),
+		Groups: make([]ClosedPositionsGroup, 0),
+	}
+	for _, p := range s.ClosedPositions() {
+		id := period.Range(p.Closed).Identifier()
+		if len(r.Groups) == 0 || r.Groups[len(r.Groups)-1].Period != id {
+			r.Groups = append(r.Groups, ClosedPositionsGroup{
+				Period:       id,
+				RealizedGain: portfolio.M(0, s.ReportingCurrency()),
+				Dividends:    portfolio.M(0, s.ReportingCurrency()),
+			})
+		}
+		g := &r.Groups[len(r.Groups)-1]
+		g.Positions = append(g.Positions, ClosedPosition{
+			Ticker:           p.Security,
+			Opened:           p.Opened,
+			Closed:           p.Closed,
+			Days:             p.Days(),
+			Invested:         p.Invested,
+			Proceeds:         p.Proceeds,
+			Dividends:        p.Dividends,
+			RealizedGain:     p.RealizedGain(),
+			AnnualizedReturn: p.AnnualizedReturn(),
+		})
+		g.RealizedGain = g.RealizedGain.Add(s.Convert(p.RealizedGain()))
+		g.Dividends = g.Dividends.Add(s.Convert(p.Dividends))
+	}
+	return r
+}
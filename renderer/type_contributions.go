@@ -0,0 +1,54 @@
+package renderer
+
+import (
+	"github.com/etnz/portfolio"
+)
+
+// Contributions is a struct to represent the cash deposited in the portfolio
+// each year, by source.
+type Contributions struct {
+	// Name of the ledger.
+	Name  string             `json:"name,omitempty"`
+	Years []ContributionYear `json:"years"`
+	// Total is the cash deposited over all the years.
+	Total ContributionYear `json:"total"`
+}
+
+// ContributionYear holds the cash deposited during a year from each source, in
+// the reporting currency.
+type ContributionYear struct {
+	Year      int             `json:"year,omitempty"`
+	Salary    portfolio.Money `json:"salary"`
+	Bonus     portfolio.Money `json:"bonus"`
+	Gift      portfolio.Money `json:"gift"`
+	Transfert portfolio.Money `json:"transfert"`
+	// Other is the cash deposited without a source.
+	Other portfolio.Money `json:"other"`
+	Total portfolio.Money `json:"total"`
+}
+
+// NewContributions creates a new Contributions struct from a ledger.
+func NewContributions(l *portfolio.Ledger) *Contributions {
+	c := &Contributions{Name: l.Name(), Years: make([]ContributionYear, 0)}
+	for _, y := range l.Contributions() {
+		year := ContributionYear{
+			Year:      y.Year,
+			Salary:    y.Source(portfolio.SourceSalary),
+			Bonus:     y.Source(portfolio.SourceBonus),
+			Gift:      y.Source(portfolio.SourceGift),
+			Transfert: y.Source(portfolio.SourceTransfert),
+			Other:     y.Source(""),
+			Total:     y.Total,
+		}
+		c.Years = append(c.Years, year)
+		c.Total = ContributionYear{
+			Salary:    c.Total.Salary.Add(year.Salary),
+			Bonus:     c.Total.Bonus.Add(year.Bonus),
+			Gift:      c.Total.Gift.Add(year.Gift),
+			Transfert: c.Total.Transfert.Add(year.Transfert),
+			Other:     c.Total.Other.Add(year.Other),
+			Total:     c.Total.Total.Add(year.Total),
+		}
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package renderer
+
+import (
+	"github.com/etnz/portfolio"
+)
+
+// Fees is a struct to represent the estimated annual fees of the funds held,
+// from their total expense ratio (TER).
+type Fees struct {
+	// Name of the ledger.
+	Name string         `json:"name,omitempty"`
+	Date portfolio.Date `json:"date"`
+	// Securities are the securities held, with their estimated annual fees.
+	Securities []FeesSecurity `json:"securities"`
+	// TotalMarketValue is the value of the securities held, in the reporting currency.
+	TotalMarketValue portfolio.Money `json:"totalMarketValue"`
+	// TotalFees are the estimated annual fees, in the reporting currency.
+	TotalFees portfolio.Money `json:"totalFees"`
+	// WeightedTER is the expense ratio of the securities held, weighted by
+	// their value: the securities without a TER count as free.
+	WeightedTER portfolio.Percent `json:"weightedTer"`
+}
+
+// FeesSecurity holds the estimated annual fees of a security, in the reporting currency.
+type FeesSecurity struct {
+	Ticker      string            `json:"ticker"`
+	MarketValue portfolio.Money   `json:"marketValue"`
+	TER         portfolio.Percent `json:"ter"`
+	Fees        portfolio.Money   `json:"fees"`
+}
+
+// NewFees creates a new Fees struct from a portfolio snapshot. The fees are
+// estimated as the expense ratio of each security applied to its current value
+// for a year.
+func NewFees(s *portfolio.Snapshot) *Fees {
+	f := &Fees{
+		Name:             s.Name(),
+		Date:             s.On(),
+		Securities:       make([]FeesSecurity, 0),
+		TotalMarketValue: portfolio.M(0, s.ReportingCurrency()),
+		TotalFees:        portfolio.M(0, s.ReportingCurrency()),
+	}
+	for ticker := range s.Securities() {
+		if s.Position(ticker).IsZero() || s.IsDust(ticker) {
+			continue
+		}
+		sec, _ := s.SecurityDetails(ticker)
+		value := s.Convert(s.MarketValue(ticker))
+		fees := portfolio.M(value.AsFloat()*float64(sec.TER())/100, value.Currency())
+		f.Securities = append(f.Securities, FeesSecurity{
+			Ticker:      ticker,
+			MarketValue: value,
+			TER:         sec.TER(),
+			Fees:        fees,
+		})
+		f.TotalMarketValue = f.TotalMarketValue.Add(value)
+		f.TotalFees = f.TotalFees.Add(fees)
+	}
+	if !f.TotalMarketValue.IsZero() {
+		f.WeightedTER = portfolio.Percent(100 * f.TotalFees.AsFloat() / f.TotalMarketValue.AsFloat())
+	}
+	return f
+}
@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/etnz/portfolio"
+)
+
+// Fire is a struct to represent the progress of a portfolio toward financial
+// independence: a target value large enough to fund the annual spending at a
+// safe withdrawal rate.
+type Fire struct {
+	// Name of the ledger.
+	Name string         `json:"name,omitempty"`
+	Date portfolio.Date `json:"date"`
+	// Spend is the annual spending to fund.
+	Spend portfolio.Money `json:"spend"`
+	// WithdrawalRate is the share of the portfolio withdrawn every year.
+	WithdrawalRate portfolio.Percent `json:"withdrawalRate"`
+	// Target is the value funding the spending at the withdrawal rate.
+	Target portfolio.Money `json:"target"`
+	// Value is the current value of the portfolio.
+	Value portfolio.Money `json:"value"`
+	// Progress is the value as a share of the target.
+	Progress portfolio.Percent `json:"progress"`
+	// Contribution is the amount invested every month.
+	Contribution portfolio.Money `json:"contribution"`
+	// Horizon is the number of years beyond which the target is not reached.
+	Horizon int `json:"horizon"`
+	// Crossovers are the dates of the simulated crossovers, by chance of having
+	// reached the target. They are empty without enough price history.
+	Crossovers []FireCrossover `json:"crossovers,omitempty"`
+	// Scenarios are the crossovers with constant annual returns.
+	Scenarios []FireScenario `json:"scenarios"`
+}
+
+// FireCrossover is the date by which a share of the simulations reached the
+// target.
+type FireCrossover struct {
+	Chance portfolio.Percent `json:"chance"`
+	// Date is the crossover date, zero if the target is not reached within the
+	// horizon.
+	Date   portfolio.Date `json:"date"`
+	Months int            `json:"months"`
+}
+
+// Years returns the time to the crossover, in years.
+func (c FireCrossover) Years() string { return fireYears(c.Date, c.Months) }
+
+// FireScenario is the crossover date with a constant annual return.
+type FireScenario struct {
+	// Name of the scenario, e.g. "Historical".
+	Name   string            `json:"name"`
+	Return portfolio.Percent `json:"return"`
+	// Date is the crossover date, zero if the target is not reached within the
+	// horizon.
+	Date   portfolio.Date `json:"date"`
+	Months int            `json:"months"`
+}
+
+// Years returns the time to the crossover, in years.
+func (s FireScenario) Years() string { return fireYears(s.Date, s.Months) }
+
+// fireYears formats a number of months to a crossover as years.
+func fireYears(on portfolio.Date, months int) string {
+	if on.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(months)/12)
+}
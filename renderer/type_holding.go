@@ -1,6 +1,9 @@
 package renderer
 
 import (
+	"math"
+	"slices"
+
 	"github.com/etnz/portfolio"
 )
 
@@ -19,14 +22,27 @@ type Holding struct {
 	TotalSecuritiesValue portfolio.Money `json:"totalSecuritiesValue"`
 	// TotalCashValue is the total value of all cash balances in the reporting currency.
 	TotalCashValue portfolio.Money `json:"totalCashValue"`
+	// TotalCashEquivalentsValue is the total value of the securities held as
+	// cash, like money-market funds, in the reporting currency.
+	TotalCashEquivalentsValue portfolio.Money `json:"totalCashEquivalentsValue"`
 	// TotalCounterpartiesValue is the total value of all counterparty accounts in the reporting currency.
 	TotalCounterpartiesValue portfolio.Money `json:"totalCounterpartiesValue"`
 	// Securities is a list of all securities held.
 	Securities []HoldingSecurity `json:"securities"`
+	// Lending is a list of the securities partly or fully lent out.
+	Lending []HoldingLending `json:"lending,omitempty"`
 	// Cash is a list of all cash balances by currency.
 	Cash []HoldingCash `json:"cash"`
+	// ByAccount is true when the cash is held in several accounts, and the
+	// balances are broken down by account.
+	ByAccount bool `json:"byAccount,omitempty"`
 	// Counterparties is a list of all counterparties balances.
 	Counterparties []HoldingCounterparty `json:"counterparties"`
+	// StalePrices is a list of the securities held valued with a price older
+	// than MaxPriceAge days, or without price. See CheckPrices.
+	StalePrices []HoldingPrice `json:"stalePrices,omitempty"`
+	// MaxPriceAge is the age in days after which a price is stale.
+	MaxPriceAge int `json:"maxPriceAge,omitempty"`
 }
 
 // HoldingSecurity represents a single security holding.
@@ -35,17 +51,37 @@ type HoldingSecurity struct {
 	Quantity    portfolio.Quantity `json:"quantity"`
 	Price       portfolio.Money    `json:"price"`
 	MarketValue portfolio.Money    `json:"marketValue"`
+	AverageCost portfolio.Money    `json:"averageCost"` // AverageCost is the average price paid per share.
+	BreakEven   portfolio.Money    `json:"breakEven"`   // BreakEven is the price to recover the cash invested.
+	Gain        portfolio.Percent  `json:"gain"`        // Gain is the unrealized gain relative to the cost basis.
+	FromHigh    portfolio.Percent  `json:"fromHigh"`    // FromHigh is the change from the 52-week high to the price.
 	ID          portfolio.ID       `json:"id"`
 	LastUpdate  portfolio.Date     `json:"lastUpdate"`
 	Description string             `json:"description,omitempty"`
 }
 
+// HoldingLending represents the shares of a security lent out.
+type HoldingLending struct {
+	Ticker string             `json:"ticker"`
+	Held   portfolio.Quantity `json:"held"`
+	Lent   portfolio.Quantity `json:"lent"`
+	Total  portfolio.Quantity `json:"total"`
+}
+
 // HoldingCash represents a single cash balance.
 type HoldingCash struct {
+	Account  string          `json:"account,omitempty"`
 	Currency string          `json:"currency"`
 	Balance  portfolio.Money `json:"balance"`
 }
 
+// HoldingPrice represents the last price of a security held.
+type HoldingPrice struct {
+	Ticker     string         `json:"ticker"`
+	LastUpdate portfolio.Date `json:"lastUpdate"` // LastUpdate is zero if the security has no price.
+	Age        int            `json:"age"`        // Age is the number of days since the last update.
+}
+
 // HoldingCounterparty represents a single counterparty balance.
 type HoldingCounterparty struct {
 	Name    string          `json:"name"`
@@ -56,45 +92,73 @@ type HoldingCounterparty struct {
 // It populates the struct with all the necessary data for rendering a holding report.
 func NewHolding(s *portfolio.Snapshot) *Holding {
 	h := &Holding{
-		Name:                     s.Name(),
-		Date:                     s.On(),
-		TotalPortfolioValue:      s.TotalPortfolio(),
-		TotalSecuritiesValue:     s.TotalMarket(),
-		TotalCashValue:           s.TotalCash(),
-		TotalCounterpartiesValue: s.TotalCounterparty(),
-		Securities:               make([]HoldingSecurity, 0),
-		Cash:                     make([]HoldingCash, 0),
-		Counterparties:           make([]HoldingCounterparty, 0),
+		Name:                      s.Name(),
+		Date:                      s.On(),
+		TotalPortfolioValue:       s.TotalPortfolio(),
+		TotalSecuritiesValue:      s.TotalMarket(),
+		TotalCashValue:            s.TotalCash(),
+		TotalCashEquivalentsValue: s.TotalCashEquivalents(),
+		TotalCounterpartiesValue:  s.TotalCounterparty(),
+		Securities:                make([]HoldingSecurity, 0),
+		Cash:                      make([]HoldingCash, 0),
+		Counterparties:            make([]HoldingCounterparty, 0),
 	}
 
 	// Populate Securities
 	for ticker := range s.Securities() {
 		pos := s.Position(ticker)
-		if pos.IsZero() {
+		if pos.IsZero() || s.IsDust(ticker) {
 			continue
 		}
 		sec, _ := s.SecurityDetails(ticker)
+		var gain portfolio.Percent
+		if cost := s.CostBasis(ticker, portfolio.AverageCost); !cost.IsZero() {
+			gain = portfolio.Percent(100 * s.UnrealizedGains(ticker, portfolio.AverageCost).AsFloat() / math.Abs(cost.AsFloat()))
+		}
 		h.Securities = append(h.Securities, HoldingSecurity{
 			Ticker:      ticker,
 			Quantity:    pos,
 			Price:       s.Price(ticker),
 			MarketValue: s.MarketValue(ticker),
+			AverageCost: s.AverageCost(ticker),
+			BreakEven:   s.BreakEven(ticker),
+			Gain:        gain,
+			FromHigh:    s.PriceStats(ticker, portfolio.Last52Weeks(s.On())).FromHigh,
 			ID:          sec.ID(),
 			LastUpdate:  s.LastMarketDataDate(ticker),
 			Description: sec.Description(),
 		})
+		if lent := s.Lent(ticker); !lent.IsZero() {
+			h.Lending = append(h.Lending, HoldingLending{
+				Ticker: ticker,
+				Held:   s.Held(ticker),
+				Lent:   lent,
+				Total:  pos,
+			})
+		}
 	}
 
-	// Populate Cash
-	for cur := range s.Currencies() {
-		bal := s.Cash(cur)
-		if bal.IsZero() {
-			continue
+	// Populate Cash, by account if there are several.
+	accounts := slices.Collect(s.Accounts())
+	h.ByAccount = len(accounts) > 1 || len(accounts) == 1 && accounts[0] != portfolio.DefaultAccount
+	if !h.ByAccount {
+		accounts = []string{""}
+	}
+	for _, acc := range accounts {
+		for cur := range s.Currencies() {
+			bal := s.Cash(cur)
+			if acc != "" {
+				bal = s.AccountCash(acc, cur)
+			}
+			if bal.IsZero() {
+				continue
+			}
+			h.Cash = append(h.Cash, HoldingCash{
+				Account:  acc,
+				Currency: cur,
+				Balance:  bal,
+			})
 		}
-		h.Cash = append(h.Cash, HoldingCash{
-			Currency: cur,
-			Balance:  bal,
-		})
 	}
 
 	// Populate Counterparties
@@ -111,3 +175,17 @@ func NewHolding(s *portfolio.Snapshot) *Holding {
 
 	return h
 }
+
+// CheckPrices lists the securities held valued with a price older than maxAge
+// days on the date of the snapshot, or without price, as historical holdings
+// are often valued with the last price known long before.
+func (h *Holding) CheckPrices(s *portfolio.Snapshot, maxAge int) {
+	h.MaxPriceAge = maxAge
+	h.StalePrices = nil
+	for _, f := range s.PriceFreshness() {
+		if s.IsDust(f.Ticker) || !f.Stale(maxAge) {
+			continue
+		}
+		h.StalePrices = append(h.StalePrices, HoldingPrice{Ticker: f.Ticker, LastUpdate: f.Date, Age: f.Age})
+	}
+}
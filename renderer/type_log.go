@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"math"
+	"strings"
+
+	"github.com/etnz/portfolio"
+)
+
+// Log is a struct to represent a compact log of consecutive reviews, one row
+// per period.
+type Log struct {
+	// Name of the ledger.
+	Name string `json:"name,omitempty"`
+	// Period is the name of the periods of the log, e.g. monthly.
+	Period string   `json:"period"`
+	Rows   []LogRow `json:"rows"`
+	// Sparkline is the unicode sparkline of the returns, if requested.
+	Sparkline string `json:"sparkline,omitempty"`
+}
+
+// LogRow holds the summary of a single period of the log.
+type LogRow struct {
+	Period     string            `json:"period"`
+	StartValue portfolio.Money   `json:"startValue"`
+	Flows      portfolio.Money   `json:"flows"`
+	Gain       portfolio.Money   `json:"gain"`
+	Return     portfolio.Percent `json:"return"`
+	EndValue   portfolio.Money   `json:"endValue"`
+}
+
+// NewLog creates a new Log from consecutive reviews. With sparkline, the
+// returns are also summarized as a unicode sparkline.
+func NewLog(reviews []*portfolio.Review, period portfolio.Period, sparkline bool) *Log {
+	l := &Log{Period: period.String()}
+	returns := make([]float64, 0, len(reviews))
+	for _, pr := range reviews {
+		l.Name = pr.Name()
+		row := LogRow{
+			Period:     pr.Range().Identifier(),
+			StartValue: pr.Start().TotalPortfolio(),
+			Flows:      pr.CashFlow(),
+			Gain:       totalGains(pr),
+			Return:     pr.TimeWeightedReturn(),
+			EndValue:   pr.End().TotalPortfolio(),
+		}
+		l.Rows = append(l.Rows, row)
+		returns = append(returns, float64(row.Return))
+	}
+	if sparkline {
+		l.Sparkline = Sparkline(returns)
+	}
+	return l
+}
+
+// sparks are the unicode blocks of a sparkline, from the lowest to the highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a unicode sparkline, scaled between their minimum
+// and maximum. Values that are not numbers are rendered as spaces.
+func Sparkline(values []float64) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v) || math.IsInf(v, 0):
+			b.WriteRune(' ')
+		case hi == lo:
+			b.WriteRune(sparks[len(sparks)/2])
+		default:
+			b.WriteRune(sparks[int((v-lo)/(hi-lo)*float64(len(sparks)-1)+0.5)])
+		}
+	}
+	return b.String()
+}
@@ -2,7 +2,9 @@ package renderer
 
 import (
 	"embed"
+	"math"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,14 +24,17 @@ func Now() time.Time {
 		}
 		return t
 	}
-	return time.Now()
+	return portfolio.Now()
 }
 
 // Review is a struct to represent the review data for rendering.
 type Review struct {
-	Name                     string          `json:"name,omitempty"`
-	AsOf                     string          `json:"asOf"`
-	Range                    portfolio.Range `json:"range"`
+	Name  string          `json:"name,omitempty"`
+	AsOf  string          `json:"asOf"`
+	Range portfolio.Range `json:"range"`
+	// ClosedExchanges are the names of the exchanges of the securities held
+	// that did not trade during the period, e.g. on a holiday.
+	ClosedExchanges          []string        `json:"closedExchanges,omitempty"`
 	TotalPortfolioValue      portfolio.Money `json:"totalPortfolioValue"`
 	TotalCashValue           portfolio.Money `json:"totalCashValue"`
 	TotalCounterpartiesValue portfolio.Money `json:"totalCounterpartiesValue"`
@@ -42,6 +47,7 @@ type Review struct {
 	CounterpartiesChange     portfolio.Money `json:"counterpartiesChange"`
 	MarketValueChange        portfolio.Money `json:"marketValueChange"`
 	Dividends                portfolio.Money `json:"dividends"`
+	LendingFees              portfolio.Money `json:"lendingFees"`
 	TotalGains               portfolio.Money `json:"totalGains"`
 	// Totals for the asset report
 	TotalStartMarketValue portfolio.Money   `json:"totalStartMarketValue"`
@@ -54,6 +60,140 @@ type Review struct {
 	Accounts     Accounts      `json:"accounts"`
 	Assets       []AssetReview `json:"assets"`
 	Transactions []RenderableTransaction
+
+	// Attribution of the period's gains.
+	Contributors    []Contribution   `json:"contributors"`
+	Detractors      []Contribution   `json:"detractors"`
+	CurrencyEffects []CurrencyEffect `json:"currencyEffects"`
+
+	// UnrealizedBreakdown splits the unrealized gains of foreign securities into
+	// local-currency and currency gains.
+	UnrealizedBreakdown []UnrealizedBreakdown `json:"unrealizedBreakdown"`
+
+	// Currencies reviews the holdings of each currency in local currency,
+	// before their conversion to the reporting currency.
+	Currencies []CurrencyReview `json:"currencies"`
+
+	// NegativeCash lists the periods of negative cash balances, with their
+	// implied interest cost at the overdraft rate.
+	NegativeCash []NegativeCash `json:"negativeCash"`
+
+	// Movers lists the securities by decreasing magnitude of their price change.
+	Movers []Mover `json:"movers"`
+
+	// Comparison holds the previous period's metrics, for comparative reviews.
+	Comparison *Comparison `json:"comparison,omitempty"`
+
+	// Real holds the period's metrics in real terms, for reviews deflated by a
+	// consumer price index.
+	Real *RealTerms `json:"real,omitempty"`
+}
+
+// RealTerms holds the metrics of the period deflated by a consumer price index,
+// in money of the end of the period.
+type RealTerms struct {
+	CPI       string            `json:"cpi"`
+	Inflation portfolio.Percent `json:"inflation"`
+	// PreviousValue is the value at the start of the period, in money of the end of the period.
+	PreviousValue portfolio.Money `json:"previousValue"`
+	NetChange     portfolio.Money `json:"netChange"`
+	// TotalGains are the gains less the loss of purchasing power of the previous value.
+	TotalGains portfolio.Money   `json:"totalGains"`
+	TWR        portfolio.Percent `json:"twr"`
+}
+
+// NewRealTerms deflates the metrics of a review by the inflation of a consumer
+// price index over the period.
+func NewRealTerms(r *Review, cpi string, inflation portfolio.Percent) *RealTerms {
+	i := float64(inflation) / 100
+	cur := r.PreviousValue.Currency()
+	erosion := portfolio.M(r.PreviousValue.AsFloat()*i, cur)
+	return &RealTerms{
+		CPI:           cpi,
+		Inflation:     inflation,
+		PreviousValue: r.PreviousValue.Add(erosion),
+		NetChange:     r.NetChange.Sub(erosion),
+		TotalGains:    r.TotalGains.Sub(erosion),
+		TWR:           portfolio.Percent(100 * ((1+float64(r.TotalTWR)/100)/(1+i) - 1)),
+	}
+}
+
+// Comparison holds the metrics of the previous period, and the change of this
+// period's metrics relative to them.
+type Comparison struct {
+	Range           portfolio.Range   `json:"range"`
+	NetChange       portfolio.Money   `json:"netChange"`
+	TotalGains      portfolio.Money   `json:"totalGains"`
+	TWR             portfolio.Percent `json:"twr"`
+	NetChangeDelta  portfolio.Money   `json:"netChangeDelta"`
+	TotalGainsDelta portfolio.Money   `json:"totalGainsDelta"`
+	TWRDelta        portfolio.Percent `json:"twrDelta"` // TWRDelta is in percentage points.
+}
+
+// TopContributors is the maximum number of contributors and detractors listed in a review.
+const TopContributors = 5
+
+// Contribution holds the contribution of a single asset to the period's gains.
+type Contribution struct {
+	Ticker   string          `json:"ticker"`
+	Price    portfolio.Money `json:"price"`
+	Trading  portfolio.Money `json:"trading"`
+	Dividend portfolio.Money `json:"dividend"`
+	Total    portfolio.Money `json:"total"`
+}
+
+// CurrencyEffect holds the gain or loss due to a foreign currency's exchange rate variation.
+type CurrencyEffect struct {
+	Currency string          `json:"currency"`
+	Effect   portfolio.Money `json:"effect"`
+}
+
+// UnrealizedBreakdown holds the unrealized gain of a foreign security, split
+// into the gain due to its price and the gain due to the exchange rate since
+// its purchase. Amounts are in the reporting currency.
+type UnrealizedBreakdown struct {
+	Ticker       string          `json:"ticker"`
+	Cost         portfolio.Money `json:"cost"`         // Cost at the current exchange rate.
+	HistoricCost portfolio.Money `json:"historicCost"` // Cost at the exchange rates of the trade dates.
+	LocalGain    portfolio.Money `json:"localGain"`
+	CurrencyGain portfolio.Money `json:"currencyGain"`
+	Total        portfolio.Money `json:"total"`
+}
+
+// CurrencyReview holds the review of the holdings (cash, securities and
+// counterparties) denominated in a single currency. Local amounts are in that
+// currency, converted ones in the reporting currency.
+type CurrencyReview struct {
+	Currency      string            `json:"currency"`
+	Start         portfolio.Money   `json:"start"`
+	End           portfolio.Money   `json:"end"`
+	Flow          portfolio.Money   `json:"flow"`
+	MarketGain    portfolio.Money   `json:"marketGain"`
+	Dividends     portfolio.Money   `json:"dividends"`
+	Gain          portfolio.Money   `json:"gain"`
+	TWR           portfolio.Percent `json:"twr"`
+	ConvertedEnd  portfolio.Money   `json:"convertedEnd"`
+	ConvertedGain portfolio.Money   `json:"convertedGain"`
+}
+
+// NegativeCash holds a period of negative balance of a cash account.
+type NegativeCash struct {
+	Currency string            `json:"currency"`
+	From     portfolio.Date    `json:"from"`
+	To       portfolio.Date    `json:"to"`
+	Days     int               `json:"days"`
+	Lowest   portfolio.Money   `json:"lowest"`
+	Rate     portfolio.Percent `json:"rate"`
+	Interest portfolio.Money   `json:"interest"`
+}
+
+// Mover holds the price change of a single security over the period.
+type Mover struct {
+	Ticker string            `json:"ticker"`
+	Start  portfolio.Money   `json:"start"`
+	End    portfolio.Money   `json:"end"`
+	Change portfolio.Money   `json:"change"`
+	Return portfolio.Percent `json:"return"`
 }
 
 // RenderableTransaction holds the data for a single transaction line in a report.
@@ -66,6 +206,9 @@ type RenderableTransaction struct {
 type Accounts struct {
 	Cash         []CashAccount         `json:"cash"`
 	Counterparty []CounterpartyAccount `json:"counterparty"`
+	// Interest lists the counterparty accounts with an interest rate, and
+	// their interest over the period.
+	Interest []CounterpartyInterest `json:"interest"`
 }
 
 // CashAccount represents a single cash account's state in a review.
@@ -81,6 +224,14 @@ type CounterpartyAccount struct {
 	Value portfolio.Money `json:"value"`
 }
 
+// CounterpartyInterest represents the interest of a counterparty account over
+// the period, in the currency of the account.
+type CounterpartyInterest struct {
+	Name     string            `json:"name"`
+	Rate     portfolio.Percent `json:"rate"`
+	Interest portfolio.Money   `json:"interest"`
+}
+
 // AssetReview holds all the period metrics for a single asset.
 type AssetReview struct {
 	Ticker         string            `json:"ticker"`
@@ -120,7 +271,8 @@ func NewReview(pr *portfolio.Review, method portfolio.CostBasisMethod) *Review {
 		CounterpartiesChange:     pr.CounterpartyChange(),
 		MarketValueChange:        pr.TotalMarketChange(),
 		Dividends:                pr.Dividends(),
-		TotalGains:               pr.MarketGain().Add(forexGain).Add(pr.Dividends()),
+		LendingFees:              pr.LendingFees(),
+		TotalGains:               totalGains(pr),
 
 		TotalStartMarketValue: pr.Start().TotalMarket(),
 		TotalEndMarketValue:   pr.End().TotalMarket(),
@@ -130,6 +282,10 @@ func NewReview(pr *portfolio.Review, method portfolio.CostBasisMethod) *Review {
 		TotalTWR:              pr.TimeWeightedReturn(),
 	}
 
+	for _, e := range pr.ClosedExchanges() {
+		r.ClosedExchanges = append(r.ClosedExchanges, e.Name)
+	}
+
 	// Populate Accounts
 	for cur := range end.Currencies() {
 		r.Accounts.Cash = append(r.Accounts.Cash, CashAccount{
@@ -148,6 +304,26 @@ func NewReview(pr *portfolio.Review, method portfolio.CostBasisMethod) *Review {
 		})
 	}
 
+	for _, i := range pr.CounterpartyInterest() {
+		r.Accounts.Interest = append(r.Accounts.Interest, CounterpartyInterest{
+			Name:     i.Account,
+			Rate:     i.Rate,
+			Interest: i.Interest,
+		})
+	}
+
+	for _, n := range pr.NegativeCash() {
+		r.NegativeCash = append(r.NegativeCash, NegativeCash{
+			Currency: n.Currency,
+			From:     n.From,
+			To:       n.To,
+			Days:     n.Days(),
+			Lowest:   n.Lowest,
+			Rate:     n.Rate,
+			Interest: n.Interest,
+		})
+	}
+
 	// Populate Assets
 	for ticker := range end.Securities() {
 		r.Assets = append(r.Assets, AssetReview{
@@ -163,6 +339,79 @@ func NewReview(pr *portfolio.Review, method portfolio.CostBasisMethod) *Review {
 		})
 	}
 
+	// Populate Attribution
+	att := pr.Attribution()
+	for _, a := range att.Securities {
+		c := Contribution{Ticker: a.Ticker, Price: a.Price, Trading: a.Trading, Dividend: a.Dividend, Total: a.Total}
+		if a.Total.IsPositive() && len(r.Contributors) < TopContributors {
+			r.Contributors = append(r.Contributors, c)
+		}
+	}
+	// Securities are sorted by decreasing contribution, detractors are the last ones.
+	for _, a := range slices.Backward(att.Securities) {
+		c := Contribution{Ticker: a.Ticker, Price: a.Price, Trading: a.Trading, Dividend: a.Dividend, Total: a.Total}
+		if a.Total.IsNegative() && len(r.Detractors) < TopContributors {
+			r.Detractors = append(r.Detractors, c)
+		}
+	}
+	for _, c := range att.Currencies {
+		r.CurrencyEffects = append(r.CurrencyEffects, CurrencyEffect{Currency: c.Currency, Effect: c.Effect})
+	}
+
+	// Populate the breakdown of unrealized gains of foreign securities.
+	for ticker := range end.Securities() {
+		sec, ok := end.SecurityDetails(ticker)
+		if !ok || sec.Currency() == end.ReportingCurrency() || end.Position(ticker).IsZero() {
+			continue
+		}
+		b := UnrealizedBreakdown{
+			Ticker:       ticker,
+			Cost:         end.Convert(end.CostBasis(ticker, method)),
+			HistoricCost: end.CostBasisInBase(ticker, method),
+			LocalGain:    end.UnrealizedLocalGains(ticker, method),
+			CurrencyGain: end.UnrealizedCurrencyGains(ticker, method),
+		}
+		b.Total = b.LocalGain.Add(b.CurrencyGain)
+		r.UnrealizedBreakdown = append(r.UnrealizedBreakdown, b)
+	}
+
+	for _, c := range pr.ByCurrency() {
+		if math.IsNaN(float64(c.TWR)) {
+			c.TWR = 0 // nothing held at the start, rendered as "-".
+		}
+		r.Currencies = append(r.Currencies, CurrencyReview{
+			Currency:      c.Currency,
+			Start:         c.Start,
+			End:           c.End,
+			Flow:          c.Flow,
+			MarketGain:    c.MarketGain,
+			Dividends:     c.Dividends,
+			Gain:          c.Gain,
+			TWR:           c.TWR,
+			ConvertedEnd:  c.ConvertedEnd,
+			ConvertedGain: c.ConvertedGain,
+		})
+	}
+
+	// Populate Comparison
+	if prev := pr.Previous(); prev != nil {
+		c := &Comparison{
+			Range:      prev.Range(),
+			NetChange:  prev.PortfolioChange(),
+			TotalGains: totalGains(prev),
+			TWR:        prev.TimeWeightedReturn(),
+		}
+		c.NetChangeDelta = r.NetChange.Sub(c.NetChange)
+		c.TotalGainsDelta = r.TotalGains.Sub(c.TotalGains)
+		c.TWRDelta = r.TotalTWR - c.TWR
+		r.Comparison = c
+	}
+
+	// Populate Movers
+	for _, m := range pr.Movers() {
+		r.Movers = append(r.Movers, Mover{Ticker: m.Ticker, Start: m.Start, End: m.End, Change: m.Change, Return: m.Return})
+	}
+
 	// Populate Transactions
 	txs := pr.Transactions()
 	r.Transactions = make([]RenderableTransaction, len(txs))
@@ -180,6 +429,13 @@ func NewReview(pr *portfolio.Review, method portfolio.CostBasisMethod) *Review {
 	return r
 }
 
+// totalGains returns the total gains of a review: market and forex gains, plus
+// dividends and lending fees.
+func totalGains(pr *portfolio.Review) portfolio.Money {
+	forexGain := pr.PortfolioChange().Sub(pr.CashFlow()).Sub(pr.MarketGain())
+	return pr.MarketGain().Add(forexGain).Add(pr.Dividends()).Add(pr.LendingFees())
+}
+
 // --- Template Definitions ---
 
 // TODO: template should be clearly matched to a single type in this package, and unit test should be provided for each.
@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+	"github.com/shopspring/decimal"
+)
+
+// Scores is a struct to represent the score of the portfolio, e.g. an ESG
+// rating, weighted by the value of the securities held.
+type Scores struct {
+	// Name of the ledger.
+	Name string         `json:"name,omitempty"`
+	Date portfolio.Date `json:"date"`
+	// Score is the name of the score, e.g. "esg".
+	Score string `json:"score"`
+	// Threshold is the score below which the securities are flagged.
+	Threshold Score `json:"threshold"`
+	// Securities are the securities held, with their score.
+	Securities []ScoresSecurity `json:"securities"`
+	// TotalMarketValue is the value of the securities held, in the reporting currency.
+	TotalMarketValue portfolio.Money `json:"totalMarketValue"`
+	// Coverage is the share of the value of the securities held that has a score.
+	Coverage portfolio.Percent `json:"coverage"`
+	// WeightedScore is the score of the securities held, weighted by their
+	// value: the securities without a score are left out.
+	WeightedScore Score `json:"weightedScore"`
+	// Below is the number of securities scoring below the threshold.
+	Below int `json:"below"`
+}
+
+// ScoresSecurity holds the score of a security, and its value in the reporting currency.
+type ScoresSecurity struct {
+	Ticker      string            `json:"ticker"`
+	MarketValue portfolio.Money   `json:"marketValue"`
+	Weight      portfolio.Percent `json:"weight"`
+	Scored      bool              `json:"scored"`
+	Score       Score             `json:"score"`
+	Below       bool              `json:"below"`
+}
+
+// Score is a score value, e.g. an ESG rating.
+type Score float64
+
+// String returns the score with at most two decimals.
+func (s Score) String() string { return format.Number(decimal.NewFromFloat(float64(s)).Round(2)) }
+
+// NewScores creates a new Scores struct from a portfolio snapshot, for the
+// score with that name. The scores of the securities are their declared
+// scores, or the overrides by ticker. The securities scoring below the
+// threshold are flagged.
+func NewScores(s *portfolio.Snapshot, name string, threshold float64, overrides map[string]float64) *Scores {
+	r := &Scores{
+		Name:             s.Name(),
+		Date:             s.On(),
+		Score:            name,
+		Threshold:        Score(threshold),
+		Securities:       make([]ScoresSecurity, 0),
+		TotalMarketValue: portfolio.M(0, s.ReportingCurrency()),
+	}
+	var scored, weighted float64
+	for ticker := range s.Securities() {
+		if s.Position(ticker).IsZero() || s.IsDust(ticker) {
+			continue
+		}
+		sec, _ := s.SecurityDetails(ticker)
+		score, ok := sec.Score(name)
+		if v, found := overrides[ticker]; found {
+			score, ok = v, true
+		}
+		value := s.Convert(s.MarketValue(ticker))
+		r.Securities = append(r.Securities, ScoresSecurity{
+			Ticker:      ticker,
+			MarketValue: value,
+			Scored:      ok,
+			Score:       Score(score),
+			Below:       ok && score < threshold,
+		})
+		r.TotalMarketValue = r.TotalMarketValue.Add(value)
+		if ok {
+			scored += value.AsFloat()
+			weighted += value.AsFloat() * score
+			if score < threshold {
+				r.Below++
+			}
+		}
+	}
+	if total := r.TotalMarketValue.AsFloat(); total != 0 {
+		for i := range r.Securities {
+			r.Securities[i].Weight = portfolio.Percent(100 * r.Securities[i].MarketValue.AsFloat() / total)
+		}
+		r.Coverage = portfolio.Percent(100 * scored / total)
+	}
+	if scored != 0 {
+		r.WeightedScore = Score(weighted / scored)
+	}
+	return r
+}
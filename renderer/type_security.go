@@ -0,0 +1,116 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/etnz/portfolio"
+)
+
+// SecurityReport is a one-security dossier: its declaration, position, cost
+// basis, gains, dividends, transactions and recent price history.
+type SecurityReport struct {
+	// Date of the report.
+	Date        portfolio.Date     `json:"date"`
+	Ticker      string             `json:"ticker"`
+	ID          portfolio.ID       `json:"id"`
+	Currency    string             `json:"currency"`
+	Description string             `json:"description,omitempty"`
+	Position    portfolio.Quantity `json:"position"`
+	Price       portfolio.Money    `json:"price"`
+	LastUpdate  portfolio.Date     `json:"lastUpdate"`
+	MarketValue portfolio.Money    `json:"marketValue"`
+	// Dividends is the total income received from dividends since inception.
+	Dividends portfolio.Money `json:"dividends"`
+	// CostBasis lists the cost basis and gains for each cost basis method.
+	CostBasis []SecurityCostBasis `json:"costBasis"`
+	// DividendHistory lists the dividends received, in chronological order.
+	DividendHistory []SecurityDividend `json:"dividendHistory,omitempty"`
+	// Transactions lists the transactions about the security, in chronological order.
+	Transactions []SecurityTransaction `json:"transactions,omitempty"`
+	// PriceHistory is a sparkline of the weekly prices over the last year.
+	PriceHistory string `json:"priceHistory,omitempty"`
+	// PriceLow, PriceHigh and PriceMean are the statistics of the prices over
+	// the last 52 weeks.
+	PriceLow  portfolio.Money `json:"priceLow"`
+	PriceHigh portfolio.Money `json:"priceHigh"`
+	PriceMean portfolio.Money `json:"priceMean"`
+	// FromHigh is the change from the 52-week high to the price.
+	FromHigh portfolio.Percent `json:"fromHigh"`
+}
+
+// SecurityCostBasis holds the cost basis and gains computed with a method.
+type SecurityCostBasis struct {
+	Method          string          `json:"method"`
+	CostBasis       portfolio.Money `json:"costBasis"`
+	RealizedGains   portfolio.Money `json:"realizedGains"`
+	UnrealizedGains portfolio.Money `json:"unrealizedGains"`
+}
+
+// SecurityDividend is a dividend payment.
+type SecurityDividend struct {
+	Date     portfolio.Date  `json:"date"`
+	PerShare portfolio.Money `json:"perShare"`
+	Total    portfolio.Money `json:"total"`
+}
+
+// SecurityTransaction is a transaction about the security.
+type SecurityTransaction struct {
+	Date   portfolio.Date `json:"date"`
+	Detail string         `json:"detail"`
+}
+
+// NewSecurityReport creates the report of a security on a date.
+func NewSecurityReport(ledger *portfolio.Ledger, on portfolio.Date, ticker string) (*SecurityReport, error) {
+	sec := ledger.Security(ticker)
+	if sec == nil {
+		return nil, fmt.Errorf("security %q not declared", ticker)
+	}
+	s := ledger.NewSnapshot(on)
+	r := &SecurityReport{
+		Date:        on,
+		Ticker:      ticker,
+		ID:          sec.ID(),
+		Currency:    sec.Currency(),
+		Description: sec.Description(),
+		Position:    s.Position(ticker),
+		Price:       s.Price(ticker),
+		LastUpdate:  s.LastMarketDataDate(ticker),
+		MarketValue: s.MarketValue(ticker),
+		Dividends:   s.Dividends(ticker),
+	}
+	for _, method := range []portfolio.CostBasisMethod{portfolio.AverageCost, portfolio.FIFO} {
+		r.CostBasis = append(r.CostBasis, SecurityCostBasis{
+			Method:          method.String(),
+			CostBasis:       s.CostBasis(ticker, method),
+			RealizedGains:   s.RealizedGains(ticker, method),
+			UnrealizedGains: s.UnrealizedGains(ticker, method),
+		})
+	}
+
+	upToDate := portfolio.NewRange(portfolio.Date{}, on)
+	for _, tx := range ledger.Query().Security(ticker).During(upToDate).All() {
+		if _, ok := tx.(portfolio.UpdatePrice); ok {
+			continue // market data is summarized by the price history.
+		}
+		r.Transactions = append(r.Transactions, SecurityTransaction{Date: tx.When(), Detail: Transaction(tx)})
+		if div, ok := tx.(portfolio.Dividend); ok {
+			// The dividend received is the difference in the cumulated dividends.
+			total := ledger.NewSnapshot(div.When()).Dividends(ticker).Sub(ledger.NewSnapshot(div.When().Add(-1)).Dividends(ticker))
+			r.DividendHistory = append(r.DividendHistory, SecurityDividend{Date: div.When(), PerShare: div.Amount, Total: total})
+		}
+	}
+
+	// Sample the price every week over the last year.
+	var prices []float64
+	for week := 52; week >= 0; week-- {
+		price := ledger.NewSnapshot(on.Add(-7 * week)).Price(ticker)
+		if price.IsZero() {
+			continue
+		}
+		prices = append(prices, price.AsFloat())
+	}
+	r.PriceHistory = sparkline(prices)
+	stats := s.PriceStats(ticker, portfolio.Last52Weeks(on))
+	r.PriceLow, r.PriceHigh, r.PriceMean, r.FromHigh = stats.Low, stats.High, stats.Mean, stats.FromHigh
+	return r, nil
+}
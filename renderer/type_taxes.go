@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"github.com/etnz/portfolio"
+)
+
+// Taxes is a struct to represent the yearly tax report data in json.
+type Taxes struct {
+	// Name of the ledger.
+	Name string `json:"name,omitempty"`
+	// Year is the fiscal year of the report.
+	Year int `json:"year"`
+	// Method is the cost basis method used to compute the cost of sales.
+	Method string `json:"method"`
+	// LongTermDays is the holding period in days above which a gain is long-term.
+	LongTermDays int `json:"longTermDays"`
+	// Sales lists each sale matched against its acquisition lot.
+	Sales []TaxesSale `json:"sales"`
+	// Totals lists the taxable income per currency.
+	Totals []TaxesTotal `json:"totals"`
+	// Jurisdiction is the name of the jurisdiction used to estimate taxes, if any.
+	Jurisdiction string `json:"jurisdiction,omitempty"`
+	// Assessments lists the jurisdiction's tax estimate.
+	Assessments []TaxesAssessment `json:"assessments,omitempty"`
+	// TotalTax is the sum of all assessed taxes.
+	TotalTax portfolio.Money `json:"totalTax"`
+}
+
+// TaxesSale represents a part of a sale matched against a single acquisition lot.
+type TaxesSale struct {
+	Ticker      string             `json:"ticker"`
+	Acquired    portfolio.Date     `json:"acquired"`
+	Sold        portfolio.Date     `json:"sold"`
+	Quantity    portfolio.Quantity `json:"quantity"`
+	Proceeds    portfolio.Money    `json:"proceeds"`
+	Cost        portfolio.Money    `json:"cost"`
+	Gain        portfolio.Money    `json:"gain"`
+	HoldingDays int                `json:"holdingDays"`
+	LongTerm    bool               `json:"longTerm"`
+}
+
+// TaxesTotal represents the taxable income in a single currency.
+type TaxesTotal struct {
+	Currency      string          `json:"currency"`
+	Proceeds      portfolio.Money `json:"proceeds"`
+	ShortTermGain portfolio.Money `json:"shortTermGain"`
+	LongTermGain  portfolio.Money `json:"longTermGain"`
+	Dividends     portfolio.Money `json:"dividends"`
+}
+
+// TaxesAssessment represents a line of the jurisdiction's tax estimate.
+type TaxesAssessment struct {
+	Label string            `json:"label"`
+	Base  portfolio.Money   `json:"base"`
+	Rate  portfolio.Percent `json:"rate"`
+	Tax   portfolio.Money   `json:"tax"`
+}
+
+// NewTaxes creates a new Taxes struct from a tax statement, and the optional
+// assessments of a jurisdiction.
+func NewTaxes(name string, st *portfolio.TaxStatement, jurisdiction string, assessments []portfolio.TaxAssessment) *Taxes {
+	t := &Taxes{
+		Name:         name,
+		Year:         st.Year,
+		Method:       st.Rules.Method.String(),
+		LongTermDays: st.Rules.LongTermDays,
+		Sales:        make([]TaxesSale, 0, len(st.Sales)),
+		Totals:       make([]TaxesTotal, 0, len(st.Totals)),
+	}
+	for _, s := range st.Sales {
+		t.Sales = append(t.Sales, TaxesSale{
+			Ticker:      s.Security,
+			Acquired:    s.Acquired,
+			Sold:        s.Sold,
+			Quantity:    s.Quantity,
+			Proceeds:    s.Proceeds,
+			Cost:        s.Cost,
+			Gain:        s.Gain,
+			HoldingDays: s.HoldingDays,
+			LongTerm:    s.LongTerm,
+		})
+	}
+	for _, s := range st.Totals {
+		t.Totals = append(t.Totals, TaxesTotal{
+			Currency:      s.Currency,
+			Proceeds:      s.Proceeds,
+			ShortTermGain: s.ShortTermGain,
+			LongTermGain:  s.LongTermGain,
+			Dividends:     s.Dividends,
+		})
+	}
+	for _, a := range assessments {
+		t.Assessments = append(t.Assessments, TaxesAssessment{Label: a.Label, Base: a.Base, Rate: a.Rate, Tax: a.Tax})
+		t.TotalTax = t.TotalTax.Add(a.Tax)
+	}
+	if len(assessments) > 0 {
+		t.Jurisdiction = jurisdiction
+	}
+	return t
+}
@@ -0,0 +1,54 @@
+package renderer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/format"
+)
+
+// VestingMarkdown renders the vesting status of the employee stock plan grants on
+// the snapshot's date: the vested and unvested shares of each grant, and the
+// upcoming vests valued at the last known price.
+func VestingMarkdown(s *portfolio.Snapshot) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s %s\n\n", format.T("Vesting on"), s.On())
+	grants := s.Grants()
+	if len(grants) == 0 {
+		fmt.Fprintln(&b, "No grants.")
+		return b.String()
+	}
+
+	fmt.Fprintln(&b, "| Grant | Security | Plan | Granted | Vested | Unvested | Unvested Value |")
+	fmt.Fprintln(&b, "|:---|:---|:---|---:|---:|---:|---:|")
+	total := portfolio.M(0, s.ReportingCurrency())
+	type upcoming struct {
+		portfolio.VestingTranche
+		grant, security string
+	}
+	var vests []upcoming
+	for _, g := range grants {
+		value := s.UnitValue(g.Security).Mul(g.Unvested())
+		total = total.Add(s.Convert(value))
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", g.Grant, g.Security, strings.ToUpper(g.Plan), g.Granted, g.Vested, g.Unvested(), value)
+		for _, t := range g.Upcoming {
+			vests = append(vests, upcoming{t, g.Grant, g.Security})
+		}
+	}
+	fmt.Fprintf(&b, "| **Total** | | | | | | **%s** |\n", total)
+
+	if len(vests) == 0 {
+		return b.String()
+	}
+	slices.SortStableFunc(vests, func(a, b upcoming) int { return a.Date.Compare(b.Date) })
+	fmt.Fprintf(&b, "\n## %s\n\n", format.T("Upcoming Vests"))
+	fmt.Fprintln(&b, "| Date | Grant | Security | Quantity | Value |")
+	fmt.Fprintln(&b, "|:---|:---|:---|---:|---:|")
+	for _, v := range vests {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", v.Date, v.grant, v.security, v.Quantity, s.UnitValue(v.security).Mul(v.Quantity))
+	}
+	return b.String()
+}
@@ -1,27 +1,44 @@
 package portfolio
 
 import (
+	"cmp"
+	"errors"
 	"maps"
 	"math"
+	"slices"
 )
 
 // Review represents an analysis of the portfolio over a specific period (Range).
 // It calculates period-based metrics by comparing two Snapshots: one at the
 // start of the period and one at the end.
 type Review struct {
-	start *Snapshot // Snapshot at period.From - 1 day
-	end   *Snapshot // Snapshot at period.To
+	start    *Snapshot // Snapshot at period.From - 1 day
+	end      *Snapshot // Snapshot at period.To
+	previous *Review   // Review of the preceding period, for comparative reviews.
+	label    string    // Label of a custom range.
 }
 
 func (r *Review) Name() string {
 	return r.end.Name()
 }
 
+// Previous returns the review of the period immediately preceding this one, or
+// nil if this is not a comparative review.
+func (r *Review) Previous() *Review {
+	return r.previous
+}
+
 // Start returns the snapshot at the beginning of the review period (taken on `period.From - 1`).
 func (r *Review) Start() *Snapshot {
 	return r.start
 }
 
+// CheckTotals checks the totals of the snapshots at the start and end of the
+// review period, see Snapshot.CheckTotals.
+func (r *Review) CheckTotals() error {
+	return errors.Join(r.start.CheckTotals(), r.end.CheckTotals())
+}
+
 // End returns the snapshot at the end of the review period (taken on `period.To`).
 func (r *Review) End() *Snapshot {
 	return r.end
@@ -29,7 +46,9 @@ func (r *Review) End() *Snapshot {
 
 // Range returns the period range of the review.
 func (r *Review) Range() Range {
-	return NewRange(r.start.On().Add(1), r.end.On())
+	rng := NewRange(r.start.On().Add(1), r.end.On())
+	rng.Label = r.label
+	return rng
 }
 
 // CashFlow calculates the total net cash that has moved into or out of the
@@ -71,7 +90,7 @@ func (r *Review) CashFlow() Money {
 		}
 	}
 
-	return r.end.sum(maps.Keys(flowsByCurrency), func(cur string) Money { return flowsByCurrency[cur] })
+	return r.end.sum(slices.Values(slices.Sorted(maps.Keys(flowsByCurrency))), func(cur string) Money { return flowsByCurrency[cur] })
 }
 
 // NetTradingFlow calculates the total net cash invested into or divested from
@@ -107,6 +126,17 @@ func (r *Review) Dividends() Money {
 	return total
 }
 
+// LendingFees calculates the total income earned from lending securities
+// during the review period.
+func (r *Review) LendingFees() Money {
+	total := M(0, r.end.journal.cur)
+	for ticker := range r.end.Securities() {
+		fee := r.AssetLendingFees(ticker)
+		total = total.Add(r.end.Convert(fee))
+	}
+	return total
+}
+
 // TimeWeightedReturn calculates the compound rate of growth for a security
 // over the review period, eliminating the distorting effects of cash flows.
 func (r *Review) TimeWeightedReturn() Percent {
@@ -131,11 +161,11 @@ func (r *Review) MarketGain() Money {
 }
 
 // TotalReturn calculates the total economic benefit from the portfolio over a period,
-// combining market gains/losses and dividend income.
+// combining market gains/losses, dividend and securities lending income.
 func (r *Review) TotalReturn() Money {
 	marketGainLoss := r.MarketGain()
 	dividends := r.Dividends()
-	return marketGainLoss.Add(dividends)
+	return marketGainLoss.Add(dividends).Add(r.LendingFees())
 }
 
 // DividendReturn calculates the return from dividends as a percentage of the starting portfolio value.
@@ -223,6 +253,11 @@ func (r *Review) AssetDividends(ticker string) Money {
 	return endDividends.Sub(startDividends)
 }
 
+// AssetLendingFees calculates the lending fees earned for a single security during the period.
+func (r *Review) AssetLendingFees(ticker string) Money {
+	return r.end.LendingFees(ticker).Sub(r.start.LendingFees(ticker))
+}
+
 // AssetMarketGain calculates the change in a security's value due to price movements during the period.
 func (r *Review) AssetMarketGain(ticker string) Money {
 	valueChange := r.end.MarketValue(ticker).Sub(r.start.MarketValue(ticker))
@@ -231,11 +266,11 @@ func (r *Review) AssetMarketGain(ticker string) Money {
 }
 
 // AssetTotalReturn calculates the total return for a single security during the period,
-// combining market gains/losses and dividend income.
+// combining market gains/losses, dividend and securities lending income.
 func (r *Review) AssetTotalReturn(ticker string) Money {
 	marketGain := r.AssetMarketGain(ticker)
 	dividends := r.AssetDividends(ticker)
-	return marketGain.Add(dividends)
+	return marketGain.Add(dividends).Add(r.AssetLendingFees(ticker))
 }
 
 // UnrealizedGains calculates the change in unrealized gains for a single security during the period.
@@ -264,3 +299,207 @@ func (r *Review) TotalCostBasis(method CostBasisMethod) Money {
 	}
 	return total
 }
+
+// SecurityAttribution decomposes the contribution of a single security to the period's gain.
+// All amounts are in the reporting currency, converted at the end of the period.
+type SecurityAttribution struct {
+	Ticker string
+	// Price is the gain due to price movements on the position held at the start of the period.
+	Price Money
+	// Trading is the gain on shares bought or sold during the period, relative to the end price.
+	Trading Money
+	// Dividend is the income from dividends received during the period.
+	Dividend Money
+	// Total is the sum of the price, trading and dividend effects.
+	Total Money
+}
+
+// CurrencyAttribution is the gain or loss due to the exchange rate variation of a
+// foreign currency, on the holdings (cash, securities and counterparties) denominated
+// in that currency at the start of the period.
+type CurrencyAttribution struct {
+	Currency string
+	Effect   Money
+}
+
+// Attribution decomposes the period's total gain into contributions.
+type Attribution struct {
+	// Securities lists the contribution of each security, by decreasing total contribution.
+	Securities []SecurityAttribution
+	// Currencies lists the effect of each foreign currency, by decreasing effect.
+	Currencies []CurrencyAttribution
+	// Total is the sum of all contributions, in the reporting currency.
+	Total Money
+}
+
+// Attribution decomposes the period's total gain into the contribution of each
+// security (price, trading and dividend effects), and the currency effect of each
+// foreign currency.
+func (r *Review) Attribution() Attribution {
+	att := Attribution{Total: M(0, r.end.journal.cur)}
+	for ticker := range r.end.Securities() {
+		start := r.start.Position(ticker).Mul(r.splitFactor(ticker))
+		price := r.end.UnitValue(ticker).Mul(start).Sub(r.start.MarketValue(ticker))
+		trading := r.AssetMarketGain(ticker).Sub(price)
+		a := SecurityAttribution{
+			Ticker:   ticker,
+			Price:    r.end.Convert(price),
+			Trading:  r.end.Convert(trading),
+			Dividend: r.end.Convert(r.AssetDividends(ticker)),
+		}
+		a.Total = a.Price.Add(a.Trading).Add(a.Dividend)
+		if a.Total.IsZero() && a.Price.IsZero() && a.Trading.IsZero() {
+			continue
+		}
+		att.Securities = append(att.Securities, a)
+		att.Total = att.Total.Add(a.Total)
+	}
+	for cur := range r.start.Currencies() {
+		if cur == r.end.journal.cur {
+			continue
+		}
+		held := r.start.Cash(cur).Add(r.start.TotalMarketIn(cur)).Add(r.start.TotalCounterpartyIn(cur))
+		delta := r.end.ExchangeRate(cur).Sub(r.start.ExchangeRate(cur))
+		effect := delta.Mul(Q(held.value))
+		if effect.IsZero() {
+			continue
+		}
+		att.Currencies = append(att.Currencies, CurrencyAttribution{Currency: cur, Effect: effect})
+		att.Total = att.Total.Add(effect)
+	}
+	slices.SortStableFunc(att.Securities, func(a, b SecurityAttribution) int { return b.Total.value.Cmp(a.Total.value) })
+	slices.SortStableFunc(att.Currencies, func(a, b CurrencyAttribution) int { return b.Effect.value.Cmp(a.Effect.value) })
+	return att
+}
+
+// Mover is the price change of a security over the review period.
+// Prices are in the security's currency, the start price is adjusted for splits
+// that occurred during the period.
+type Mover struct {
+	Ticker string
+	Start  Money   // Start is the price at the start of the period.
+	End    Money   // End is the price at the end of the period.
+	Change Money   // Change is the price change per share.
+	Return Percent // Return is the price change relative to the start price.
+}
+
+// Movers returns the price change of the securities held at the start or at the
+// end of the period, by decreasing magnitude of their percentage change.
+func (r *Review) Movers() []Mover {
+	var movers []Mover
+	for ticker := range r.end.Securities() {
+		if r.start.Position(ticker).IsZero() && r.end.Position(ticker).IsZero() {
+			continue
+		}
+		start := r.start.Price(ticker).Div(r.splitFactor(ticker))
+		if start.IsZero() {
+			continue
+		}
+		end := r.end.Price(ticker)
+		movers = append(movers, Mover{
+			Ticker: ticker,
+			Start:  start,
+			End:    end,
+			Change: end.Sub(start),
+			Return: Percent(100 * (end.AsFloat()/start.AsFloat() - 1)),
+		})
+	}
+	slices.SortStableFunc(movers, func(a, b Mover) int {
+		return cmp.Compare(math.Abs(float64(b.Return)), math.Abs(float64(a.Return)))
+	})
+	return movers
+}
+
+// splitFactor returns the cumulated split ratio of a security during the review period.
+func (r *Review) splitFactor(ticker string) Quantity {
+	factor := Q(1)
+	periodRange := r.Range()
+	for _, e := range r.end.journal.events {
+		if e.date().After(periodRange.To) {
+			break
+		}
+		if v, ok := e.(splitShare); ok && v.security == ticker && periodRange.Contains(v.on) {
+			factor = factor.Mul(Q(v.numerator)).Div(Q(v.denominator))
+		}
+	}
+	return factor
+}
+
+// CurrencyReview is the review of the holdings denominated in a single
+// currency: its cash, securities and counterparties. Amounts are in that
+// currency, before their conversion to the reporting currency, unless stated
+// otherwise.
+type CurrencyReview struct {
+	Currency string
+	Start    Money // Start is the value at the start of the period.
+	End      Money // End is the value at the end of the period.
+	// Flow is the net cash moved into the currency: external flows, and
+	// conversions from or to other currencies.
+	Flow       Money
+	MarketGain Money // MarketGain is the market gain of the securities.
+	Dividends  Money // Dividends is the income from dividends.
+	// Gain is the change of value not due to flows.
+	Gain Money
+	TWR  Percent // TWR is the time-weighted return in local currency.
+
+	// ConvertedStart and ConvertedEnd are the start and end values in the
+	// reporting currency, at the exchange rates of the start and end of the
+	// period.
+	ConvertedStart, ConvertedEnd Money
+	// ConvertedFlow is the flow in the reporting currency, at the exchange rate
+	// of the end of the period.
+	ConvertedFlow Money
+	// ConvertedGain is the change of value in the reporting currency not due to
+	// flows: the local gain plus the currency effect.
+	ConvertedGain Money
+}
+
+// ByCurrency returns the review of the holdings of each currency, before their
+// conversion to the reporting currency, in the order of Currencies. Currencies
+// with nothing held and no flow during the period are skipped.
+func (r *Review) ByCurrency() []CurrencyReview {
+	valueIn := func(s *Snapshot, cur string) Money {
+		return s.Cash(cur).Add(s.TotalMarketIn(cur)).Add(s.TotalCounterpartyIn(cur))
+	}
+	flows := make(map[string]Money)
+	for _, tx := range r.Transactions() {
+		if v, ok := tx.(Convert); ok {
+			from, to := v.FromAmount.Currency(), v.ToAmount.Currency()
+			flows[from] = flows[from].Sub(v.FromAmount)
+			flows[to] = flows[to].Add(v.ToAmount)
+		}
+	}
+
+	var reviews []CurrencyReview
+	for cur := range r.end.Currencies() {
+		c := CurrencyReview{
+			Currency:   cur,
+			Start:      valueIn(r.start, cur),
+			End:        valueIn(r.end, cur),
+			Flow:       r.end.CashFlow(cur).Sub(r.start.CashFlow(cur)).Add(flows[cur]),
+			MarketGain: M(0, cur),
+			Dividends:  M(0, cur),
+		}
+		for ticker := range r.end.Securities() {
+			if sec, ok := r.end.SecurityDetails(ticker); !ok || sec.Currency() != cur {
+				continue
+			}
+			c.MarketGain = c.MarketGain.Add(r.AssetMarketGain(ticker))
+			c.Dividends = c.Dividends.Add(r.AssetDividends(ticker))
+		}
+		if c.Start.IsZero() && c.End.IsZero() && c.Flow.IsZero() {
+			continue
+		}
+		c.Gain = c.End.Sub(c.Start).Sub(c.Flow)
+		c.TWR = Percent(math.NaN())
+		if !c.Start.IsZero() {
+			c.TWR = Percent(100 * (c.End.Sub(c.Flow).AsFloat()/c.Start.AsFloat() - 1))
+		}
+		c.ConvertedStart = r.start.Convert(c.Start)
+		c.ConvertedEnd = r.end.Convert(c.End)
+		c.ConvertedFlow = r.end.Convert(c.Flow)
+		c.ConvertedGain = c.ConvertedEnd.Sub(c.ConvertedStart).Sub(c.ConvertedFlow)
+		reviews = append(reviews, c)
+	}
+	return reviews
+}
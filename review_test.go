@@ -99,3 +99,202 @@ func TestReview_PeriodWithInvestmentAndGains(t *testing.T) {
 		}
 	})
 }
+
+func TestReview_Attribution(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		// --- BEFORE Period ---
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "USDEUR", USDEUR, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(3000), ""),
+		NewUpdatePrice(NewDate(2025, 1, 2), "USDEUR", EUR(0.9)),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1000)), // Price: 100
+		NewUpdatePrice(NewDate(2025, 1, 4), "AAPL", USD(100)),
+
+		// --- DURING Period ---
+		NewSplit(NewDate(2025, 1, 5), "AAPL", 2, 1),              // 20 shares @ 50
+		NewBuy(NewDate(2025, 1, 6), "", "AAPL", Q(10), USD(500)), // 30 shares
+		NewDividend(NewDate(2025, 1, 7), "", "AAPL", USD(1)),     // 30 USD
+		NewUpdatePrice(NewDate(2025, 1, 8), "AAPL", USD(60)),     // 30 shares @ 60 = 1800
+		NewUpdatePrice(NewDate(2025, 1, 8), "USDEUR", EUR(1)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	review := ledger.NewReview(NewRange(NewDate(2025, 1, 5), NewDate(2025, 1, 8)))
+	att := review.Attribution()
+
+	if len(att.Securities) != 1 {
+		t.Fatalf("len(Attribution().Securities) = %d, want 1", len(att.Securities))
+	}
+	a := att.Securities[0]
+	// Price: the 20 split-adjusted shares held at start went from 50 to 60.
+	if got, want := a.Price, EUR(200); !got.Equal(want) {
+		t.Errorf("Price = %v, want %v", got, want)
+	}
+	// Trading: the 10 shares bought at 50 are now worth 60.
+	if got, want := a.Trading, EUR(100); !got.Equal(want) {
+		t.Errorf("Trading = %v, want %v", got, want)
+	}
+	if got, want := a.Dividend, EUR(30); !got.Equal(want) {
+		t.Errorf("Dividend = %v, want %v", got, want)
+	}
+
+	// Currency: 2000 USD cash + 1000 USD of AAPL held at start, USD gained 0.1 EUR.
+	if len(att.Currencies) != 1 {
+		t.Fatalf("len(Attribution().Currencies) = %d, want 1", len(att.Currencies))
+	}
+	if got, want := att.Currencies[0].Effect, EUR(300); !got.Equal(want) {
+		t.Errorf("Currencies[USD] = %v, want %v", got, want)
+	}
+
+	if got, want := att.Total, EUR(630); !got.Equal(want) {
+		t.Errorf("Total = %v, want %v", got, want)
+	}
+}
+
+func TestReview_Movers(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeclare(NewDate(2025, 1, 1), "", "GOOG", GOOG, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(10000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), EUR(1000)),
+		NewBuy(NewDate(2025, 1, 3), "", "GOOG", Q(10), EUR(2000)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "AAPL", EUR(100)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "GOOG", EUR(200)),
+
+		// --- DURING Period ---
+		NewSplit(NewDate(2025, 1, 5), "AAPL", 2, 1),           // start price is 50 after the split
+		NewUpdatePrice(NewDate(2025, 1, 6), "AAPL", EUR(55)),  // +10%
+		NewUpdatePrice(NewDate(2025, 1, 6), "GOOG", EUR(170)), // -15%
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	movers := ledger.NewReview(NewRange(NewDate(2025, 1, 5), NewDate(2025, 1, 6))).Movers()
+	if len(movers) != 2 {
+		t.Fatalf("len(Movers()) = %d, want 2", len(movers))
+	}
+	// GOOG moved the most, although down.
+	if got, want := movers[0].Ticker, "GOOG"; got != want {
+		t.Errorf("Movers()[0].Ticker = %q, want %q", got, want)
+	}
+	if got, want := movers[0].Return, Percent(-15); !got.Equal(want) {
+		t.Errorf("Movers()[0].Return = %v, want %v", got, want)
+	}
+	if got, want := movers[1].Change, EUR(5); !got.Equal(want) {
+		t.Errorf("Movers()[1].Change = %v, want %v", got, want)
+	}
+	if got, want := movers[1].Return, Percent(10); !got.Equal(want) {
+		t.Errorf("Movers()[1].Return = %v, want %v", got, want)
+	}
+}
+
+func TestReview_Comparative(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(10000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), EUR(1000)),
+		NewUpdatePrice(NewDate(2025, 1, 31), "AAPL", EUR(110)), // +100 in January
+		NewUpdatePrice(NewDate(2025, 2, 28), "AAPL", EUR(130)), // +200 in February
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	review := ledger.NewComparativeReview(Monthly.Range(NewDate(2025, 2, 1)))
+	prev := review.Previous()
+	if prev == nil {
+		t.Fatalf("Previous() = nil, want the January review")
+	}
+	if got, want := prev.Range(), Monthly.Range(NewDate(2025, 1, 1)); got != want {
+		t.Errorf("Previous().Range() = %v, want %v", got, want)
+	}
+	if got, want := review.MarketGain(), EUR(200); !got.Equal(want) {
+		t.Errorf("MarketGain() = %v, want %v", got, want)
+	}
+	if got, want := prev.MarketGain(), EUR(100); !got.Equal(want) {
+		t.Errorf("Previous().MarketGain() = %v, want %v", got, want)
+	}
+	if ledger.NewReview(review.Range()).Previous() != nil {
+		t.Errorf("NewReview().Previous() != nil, want nil")
+	}
+}
+
+func TestReview_ByCurrency(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+
+	txs := []Transaction{
+		// --- BEFORE Period ---
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "USDEUR", USDEUR, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(3000), ""),
+		NewUpdatePrice(NewDate(2025, 1, 2), "USDEUR", EUR(0.9)),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), USD(1000)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "AAPL", USD(100)),
+
+		// --- DURING Period ---
+		NewDeposit(NewDate(2025, 1, 5), "", EUR(1000), ""),
+		NewConvert(NewDate(2025, 1, 6), "", EUR(450), USD(500)),
+		NewBuy(NewDate(2025, 1, 6), "", "AAPL", Q(5), USD(500)),
+		NewUpdatePrice(NewDate(2025, 1, 8), "AAPL", USD(120)),
+		NewUpdatePrice(NewDate(2025, 1, 8), "USDEUR", EUR(1)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	review := ledger.NewReview(NewRange(NewDate(2025, 1, 5), NewDate(2025, 1, 8)))
+	byCurrency := review.ByCurrency()
+	if len(byCurrency) != 2 {
+		t.Fatalf("len(ByCurrency()) = %d, want 2", len(byCurrency))
+	}
+
+	// EUR: deposited 1000, 450 converted to USD.
+	eur := byCurrency[0]
+	if eur.Currency != "EUR" {
+		t.Fatalf("ByCurrency()[0].Currency = %q, want EUR", eur.Currency)
+	}
+	if got, want := eur.Flow, EUR(550); !got.Equal(want) {
+		t.Errorf("EUR Flow = %v, want %v", got, want)
+	}
+	if !eur.Gain.IsZero() {
+		t.Errorf("EUR Gain = %v, want 0", eur.Gain)
+	}
+
+	// USD: 2000 cash + 10 AAPL @ 100 at start, 2000 cash + 15 AAPL @ 120 at end.
+	usd := byCurrency[1]
+	for _, tc := range []struct {
+		name      string
+		got, want Money
+	}{
+		{"Start", usd.Start, USD(3000)},
+		{"End", usd.End, USD(3800)},
+		{"Flow", usd.Flow, USD(500)},
+		{"MarketGain", usd.MarketGain, USD(300)},
+		{"Gain", usd.Gain, USD(300)},
+		{"ConvertedStart", usd.ConvertedStart, EUR(2700)},
+		{"ConvertedEnd", usd.ConvertedEnd, EUR(3800)},
+		{"ConvertedFlow", usd.ConvertedFlow, EUR(500)},
+		// The local gain of 300, plus 0.1 EUR per USD on the 3000 USD at start.
+		{"ConvertedGain", usd.ConvertedGain, EUR(600)},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("USD %s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+	if got, want := usd.TWR, Percent(10); !got.Equal(want) {
+		t.Errorf("USD TWR = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	netrpc "net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+)
+
+// Client is a client of the service.
+type Client struct {
+	c *netrpc.Client
+}
+
+// Dial connects to the service on a unix socket.
+func Dial(socket string) (*Client, error) {
+	c, err := jsonrpc.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c: c}, nil
+}
+
+// Close closes the connection to the service.
+func (c *Client) Close() error { return c.c.Close() }
+
+// Holding returns the holdings of a ledger on a date.
+func (c *Client) Holding(args HoldingArgs) (*renderer.Holding, error) {
+	var reply renderer.Holding
+	if err := c.c.Call(ServiceName+".Holding", args, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// Review returns the review of a ledger over a period.
+func (c *Client) Review(args ReviewArgs) (*renderer.Review, error) {
+	var reply renderer.Review
+	if err := c.c.Call(ServiceName+".Review", args, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// Transactions returns the transactions of a ledger matching a filter, in
+// chronological order.
+func (c *Client) Transactions(args TransactionsArgs) ([]portfolio.Transaction, error) {
+	var reply []json.RawMessage
+	if err := c.c.Call(ServiceName+".Transactions", args, &reply); err != nil {
+		return nil, err
+	}
+	txs := make([]portfolio.Transaction, 0, len(reply))
+	for _, line := range reply {
+		tx, err := decodeTransaction(line)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// Alerts returns the active price alerts of a ledger on a date, with the last
+// known prices of their securities.
+func (c *Client) Alerts(args AlertsArgs) ([]Alert, error) {
+	var reply []Alert
+	if err := c.c.Call(ServiceName+".Alerts", args, &reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Validate validates a transaction against a ledger, without recording it,
+// and returns it with the quick fixes applied.
+func (c *Client) Validate(ledger string, tx portfolio.Transaction) (portfolio.Transaction, error) {
+	line, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	var reply json.RawMessage
+	if err := c.c.Call(ServiceName+".Validate", ValidateArgs{Ledger: ledger, Transaction: line}, &reply); err != nil {
+		return nil, err
+	}
+	return decodeTransaction(reply)
+}
+
+// decodeTransaction decodes a transaction from a ledger line.
+func decodeTransaction(line []byte) (portfolio.Transaction, error) {
+	return portfolio.NewDecoder(bytes.NewReader(line)).Decode()
+}
@@ -0,0 +1,210 @@
+// Package rpc exposes the query API of the ledgers as a JSON-RPC service, to
+// embed pcs in editors and graphical interfaces without running a command per
+// query, and provides the Go client of the service.
+//
+// The service is served on a local unix socket, with the JSON-RPC 1.0 protocol
+// of the standard net/rpc/jsonrpc package. Its methods are:
+//
+//	Portfolio.Holding       the holdings of a ledger on a date;
+//	Portfolio.Review        the review of a ledger over a period;
+//	Portfolio.Transactions  the transactions of a ledger, optionally filtered;
+//	Portfolio.Validate      the validation of a transaction against a ledger;
+//	Portfolio.Alerts        the active price alerts of a ledger on a date.
+//
+// The ledgers are loaded on each call, so that the service always answers with
+// the current content of the ledger files. The calls fail once the context of
+// the service is done.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	netrpc "net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/etnz/portfolio"
+	"github.com/etnz/portfolio/renderer"
+)
+
+// ServiceName is the name of the service, the prefix of its method names.
+const ServiceName = "Portfolio"
+
+// Loader loads a ledger by name, the only ledger if the name is empty.
+type Loader func(ctx context.Context, name string) (*portfolio.Ledger, error)
+
+// Service is the JSON-RPC service of the ledgers.
+type Service struct {
+	ctx  context.Context
+	load Loader
+}
+
+// NewService creates a service of the ledgers returned by load, until the
+// context is done.
+func NewService(ctx context.Context, load Loader) *Service { return &Service{ctx: ctx, load: load} }
+
+// HoldingArgs are the arguments of Portfolio.Holding.
+type HoldingArgs struct {
+	Ledger string         `json:"ledger,omitempty"`
+	Date   portfolio.Date `json:"date"` // Date of the holdings, today if zero.
+}
+
+// ReviewArgs are the arguments of Portfolio.Review.
+type ReviewArgs struct {
+	Ledger string         `json:"ledger,omitempty"`
+	Period string         `json:"period,omitempty"` // Period ending on the date, see portfolio.ParseRange. A day by default.
+	Date   portfolio.Date `json:"date"`             // Date of the end of the period, today if zero.
+	Method string         `json:"method,omitempty"` // Cost basis method, fifo by default.
+}
+
+// TransactionsArgs are the arguments of Portfolio.Transactions.
+type TransactionsArgs struct {
+	Ledger string `json:"ledger,omitempty"`
+	Filter string `json:"filter,omitempty"` // Filter expression, see portfolio.ParseFilter. All transactions by default.
+}
+
+// ValidateArgs are the arguments of Portfolio.Validate.
+type ValidateArgs struct {
+	Ledger      string          `json:"ledger,omitempty"`
+	Transaction json.RawMessage `json:"transaction"` // Transaction as a ledger line.
+}
+
+// AlertsArgs are the arguments of Portfolio.Alerts.
+type AlertsArgs struct {
+	Ledger string         `json:"ledger,omitempty"`
+	Date   portfolio.Date `json:"date"` // Date of the prices, today if zero.
+}
+
+// Alert is an active price alert, with the last known price of its security.
+type Alert struct {
+	portfolio.PriceAlert
+	Price     portfolio.Money `json:"price"`
+	Triggered bool            `json:"triggered"`
+}
+
+// Holding returns the holdings of a ledger on a date.
+func (s *Service) Holding(args HoldingArgs, reply *renderer.Holding) error {
+	ledger, err := s.load(s.ctx, args.Ledger)
+	if err != nil {
+		return err
+	}
+	*reply = *renderer.NewHolding(ledger.NewSnapshot(orToday(args.Date)))
+	return nil
+}
+
+// Review returns the review of a ledger over a period.
+func (s *Service) Review(args ReviewArgs, reply *renderer.Review) error {
+	ledger, err := s.load(s.ctx, args.Ledger)
+	if err != nil {
+		return err
+	}
+	if args.Period == "" {
+		args.Period = portfolio.Daily.String()
+	}
+	if args.Method == "" {
+		args.Method = "fifo"
+	}
+	rng, err := portfolio.ParseRange(args.Period, orToday(args.Date), ledger.GlobalInceptionDate())
+	if err != nil {
+		return err
+	}
+	method, err := portfolio.ParseCostBasisMethod(args.Method)
+	if err != nil {
+		return err
+	}
+	*reply = *renderer.NewReview(ledger.NewReview(rng), method)
+	return nil
+}
+
+// Transactions returns the transactions of a ledger matching a filter, as
+// ledger lines, in chronological order.
+func (s *Service) Transactions(args TransactionsArgs, reply *[]json.RawMessage) error {
+	ledger, err := s.load(s.ctx, args.Ledger)
+	if err != nil {
+		return err
+	}
+	q := ledger.Query()
+	if args.Filter != "" {
+		filter, err := portfolio.ParseFilter(args.Filter)
+		if err != nil {
+			return err
+		}
+		q = q.Where(filter)
+	}
+	*reply = []json.RawMessage{}
+	for _, tx := range q.All() {
+		line, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		*reply = append(*reply, line)
+	}
+	return nil
+}
+
+// Validate validates a transaction against a ledger, without recording it,
+// and returns it with the quick fixes applied.
+func (s *Service) Validate(args ValidateArgs, reply *json.RawMessage) error {
+	ledger, err := s.load(s.ctx, args.Ledger)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeTransaction(args.Transaction)
+	if err != nil {
+		return err
+	}
+	valid, err := ledger.Validate(tx)
+	if err != nil {
+		return err
+	}
+	*reply, err = json.Marshal(valid)
+	return err
+}
+
+// Alerts returns the active price alerts of a ledger on a date, with the last
+// known prices of their securities. The triggered alerts are not marked done:
+// the ledger is only changed by 'pcs alerts'.
+func (s *Service) Alerts(args AlertsArgs, reply *[]Alert) error {
+	ledger, err := s.load(s.ctx, args.Ledger)
+	if err != nil {
+		return err
+	}
+	on := orToday(args.Date)
+	snapshot := ledger.NewSnapshot(on)
+	*reply = []Alert{}
+	for _, a := range ledger.PriceAlerts(on) {
+		price := snapshot.Price(a.Security)
+		*reply = append(*reply, Alert{PriceAlert: a, Price: price, Triggered: a.Triggered(price)})
+	}
+	return nil
+}
+
+// Serve serves the service on the connections accepted by a listener, until
+// it is closed or the context of the service is done.
+func Serve(l net.Listener, s *Service) error {
+	server := netrpc.NewServer()
+	if err := server.RegisterName(ServiceName, s); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(s.ctx, func() { l.Close() })
+	defer stop()
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// orToday returns the date, or today if it is zero.
+func orToday(on portfolio.Date) portfolio.Date {
+	if on.IsZero() {
+		return portfolio.Today()
+	}
+	return on
+}
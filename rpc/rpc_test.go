@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/etnz/portfolio"
+	"github.com/shopspring/decimal"
+)
+
+func TestService(t *testing.T) {
+	aapl, _ := portfolio.NewMSSI("US0378331005", "XNAS")
+	ledger := portfolio.NewLedger()
+	err := ledger.Append(
+		portfolio.NewInit(portfolio.NewDate(2025, 1, 1), "", "USD"),
+		portfolio.NewDeclare(portfolio.NewDate(2025, 1, 1), "", "AAPL", aapl, "USD"),
+		portfolio.NewDeposit(portfolio.NewDate(2025, 1, 2), "", portfolio.M(1000, "USD"), ""),
+		portfolio.NewBuy(portfolio.NewDate(2025, 1, 3), "", "AAPL", portfolio.Q(5), portfolio.M(750, "USD")),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	load := func(_ context.Context, name string) (*portfolio.Ledger, error) {
+		if name != "" {
+			return nil, fmt.Errorf("could not find ledger %q", name)
+		}
+		return ledger, nil
+	}
+
+	socket := filepath.Join(t.TempDir(), "pcs.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer l.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Serve(l, NewService(ctx, load))
+	c, err := Dial(socket)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	h, err := c.Holding(HoldingArgs{Date: portfolio.NewDate(2025, 1, 31)})
+	if err != nil {
+		t.Fatalf("Holding() error = %v", err)
+	}
+	if len(h.Securities) != 1 || h.Securities[0].Ticker != "AAPL" {
+		t.Errorf("Holding().Securities = %v, want AAPL only", h.Securities)
+	}
+	if _, err := c.Holding(HoldingArgs{Ledger: "unknown"}); err == nil {
+		t.Error("Holding() of an unknown ledger succeeded, want an error")
+	}
+
+	txs, err := c.Transactions(TransactionsArgs{Filter: "security = AAPL"})
+	if err != nil {
+		t.Fatalf("Transactions() error = %v", err)
+	}
+	if len(txs) != 2 {
+		t.Errorf("Transactions() = %d transactions, want the declaration and the buy", len(txs))
+	}
+
+	// The service reads the ledger on each call.
+	err = ledger.Append(
+		portfolio.NewUpdatePrice(portfolio.NewDate(2025, 1, 3), "AAPL", portfolio.M(150, "USD")),
+		portfolio.NewSetPriceAlert(portfolio.NewDate(2025, 1, 3), "", "AAPL", portfolio.AlertAbove, decimal.NewFromInt(140), portfolio.Date{}),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	alerts, err := c.Alerts(AlertsArgs{Date: portfolio.NewDate(2025, 1, 31)})
+	if err != nil {
+		t.Fatalf("Alerts() error = %v", err)
+	}
+	if len(alerts) != 1 || !alerts[0].Triggered || alerts[0].Security != "AAPL" || !alerts[0].Price.Equal(portfolio.M(150, "USD")) {
+		t.Errorf("Alerts() = %v, want the AAPL alert triggered at 150", alerts)
+	}
+
+	valid, err := c.Validate("", portfolio.NewSell(portfolio.NewDate(2025, 1, 4), "", "AAPL", portfolio.Q(2), portfolio.M(320, "USD")))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if valid.What() != portfolio.CmdSell {
+		t.Errorf("Validate() = %v, want the sell", valid)
+	}
+	if _, err := c.Validate("", portfolio.NewSell(portfolio.NewDate(2025, 1, 4), "", "AAPL", portfolio.Q(20), portfolio.M(3200, "USD"))); err == nil {
+		t.Error("Validate() of a sell of more than the position succeeded, want an error")
+	}
+}
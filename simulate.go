@@ -0,0 +1,30 @@
+package portfolio
+
+import "fmt"
+
+// Simulate returns the snapshot of the ledger as if the transactions were
+// recorded, without modifying the ledger. Transactions are validated in order,
+// each against the state resulting from the previous ones.
+//
+// The snapshot is taken on the date of the latest transaction, so that it can
+// be compared with the snapshot of the ledger itself on that date.
+func Simulate(ledger *Ledger, txs ...Transaction) (*Snapshot, error) {
+	sim := ledger.clone()
+	var on Date
+	for _, tx := range txs {
+		valid, err := sim.Validate(tx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", tx.What(), err)
+		}
+		if err := sim.append(valid); err != nil {
+			return nil, err
+		}
+		if valid.When().After(on) {
+			on = valid.When()
+		}
+	}
+	if on.IsZero() {
+		on = ledger.Today()
+	}
+	return sim.NewSnapshot(on), nil
+}
@@ -0,0 +1,32 @@
+package portfolio
+
+import "testing"
+
+func TestSimulate(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(1000), ""),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	on := NewDate(2025, 1, 3)
+	s, err := Simulate(ledger, NewBuy(on, "", "AAPL", Q(4), EUR(800)))
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if s.On() != on || !s.Position("AAPL").Equal(Q(4)) || !s.Cash("EUR").Equal(EUR(200)) {
+		t.Errorf("Simulate() on %s: position %v, cash %v, want 4 AAPL and 200 EUR on %s", s.On(), s.Position("AAPL"), s.Cash("EUR"), on)
+	}
+	// The ledger is unchanged.
+	if got := ledger.NewSnapshot(on); !got.Position("AAPL").IsZero() || !got.Cash("EUR").Equal(EUR(1000)) {
+		t.Errorf("ledger after Simulate(): position %v, cash %v, want 0 AAPL and 1000 EUR", got.Position("AAPL"), got.Cash("EUR"))
+	}
+
+	// Transactions are validated against the simulated state.
+	if _, err := Simulate(ledger, NewBuy(on, "", "AAPL", Q(4), EUR(800)), NewBuy(on, "", "AAPL", Q(4), EUR(800))); err == nil {
+		t.Errorf("Simulate() of buys beyond the cash: expected an error")
+	}
+}
@@ -1,8 +1,11 @@
 package portfolio
 
 import (
+	"fmt"
 	"iter"
-	"log"
+	"slices"
+
+	"github.com/shopspring/decimal"
 )
 
 // Snapshot represents a view of the portfolio at a single point in time.
@@ -12,6 +15,9 @@ type Snapshot struct {
 	name    string // the ledger's name
 	journal *Journal
 	on      Date
+	dust    Quantity // positions up to this quantity are ignored in reports.
+	pivot   string   // currency preferred to derive missing exchange rates.
+	lag     int      // business days between the trade and the settlement of buys and sells.
 }
 
 func (s *Snapshot) Name() string {
@@ -53,6 +59,42 @@ func (s *Snapshot) sum(iterator iter.Seq[string], metricFunc func(string) Money)
 	return total
 }
 
+// checkedSum is sum with checked arithmetic: it returns an error wrapping
+// ErrCurrencyMismatch, with the key of the amount, instead of mixing
+// currencies.
+func (s *Snapshot) checkedSum(iterator iter.Seq[string], metricFunc func(string) Money) (Money, error) {
+	total := M(0, s.journal.cur)
+	for key := range iterator {
+		var err error
+		if total, err = total.CheckedAdd(s.Convert(metricFunc(key))); err != nil {
+			return Money{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return total, nil
+}
+
+// CheckTotals checks that the market values, cash balances and counterparty
+// balances of the snapshot are all converted to the reporting currency before
+// being summed in its totals. It returns an error wrapping ErrCurrencyMismatch
+// otherwise.
+func (s *Snapshot) CheckTotals() error {
+	totals := []struct {
+		name   string
+		keys   iter.Seq[string]
+		metric func(string) Money
+	}{
+		{"market value", s.Securities(), s.MarketValue},
+		{"cash", s.Currencies(), s.Cash},
+		{"counterparty", s.Counterparties(), s.Counterparty},
+	}
+	for _, t := range totals {
+		if _, err := s.checkedSum(t.keys, t.metric); err != nil {
+			return fmt.Errorf("total %s on %s: %w", t.name, s.on, err)
+		}
+	}
+	return nil
+}
+
 // VirtualAssetValue simulates the growth of a 1-unit investment in a security.
 // This is the core of the Time-Weighted Return calculation. It tracks a "virtual"
 // portfolio that buys into a security when the actual position goes from zero to
@@ -66,12 +108,14 @@ func (s *Snapshot) VirtualAssetValue(ticker string) Money {
 	// These track the state of the *actual* portfolio.
 	var actualPosition Quantity
 	var lastPrice Money
+	multiplier := Q(1) // prices are per share, options trade per contract.
 	for e := range s.events() {
 		switch v := e.(type) {
 		case declareSecurity:
 			if v.ticker == ticker {
 				lastPrice = M(0, v.currency)
 				virtualCash = M(1, v.currency)
+				multiplier = NewSecurity(v.id, v.ticker, v.currency, v.memo).Multiplier()
 			}
 		case acquireLot:
 			if v.security == ticker {
@@ -94,7 +138,7 @@ func (s *Snapshot) VirtualAssetValue(ticker string) Money {
 			}
 		case updatePrice:
 			if v.security == ticker {
-				lastPrice = v.price
+				lastPrice = v.price.Mul(multiplier)
 			}
 		case splitShare:
 			if v.security == ticker {
@@ -176,43 +220,116 @@ func (s *Snapshot) Position(ticker string) Quantity {
 	return position
 }
 
+// Lent calculates the quantity of a security lent out on the snapshot's date.
+// Lent shares are part of the Position, but are not held in the account.
+func (s *Snapshot) Lent(ticker string) Quantity {
+	var lent Quantity
+	for e := range s.events() {
+		switch v := e.(type) {
+		case lendShares:
+			if v.security == ticker {
+				lent = lent.Add(v.quantity)
+			}
+		case recallShares:
+			if v.security == ticker {
+				lent = lent.Sub(v.quantity)
+			}
+		case splitShare:
+			if v.security == ticker {
+				num, den := Q(v.numerator), Q(v.denominator)
+				lent = lent.Mul(num).Div(den)
+			}
+		}
+	}
+	return lent
+}
+
+// Held calculates the quantity of a security held in the account on the snapshot's
+// date, that is the position less the lent shares.
+func (s *Snapshot) Held(ticker string) Quantity {
+	return s.Position(ticker).Sub(s.Lent(ticker))
+}
+
 // SecurityDetails finds the declaration for a given ticker.
 func (s *Snapshot) SecurityDetails(ticker string) (Security, bool) {
 	for e := range s.events() {
 		if d, ok := e.(declareSecurity); ok && d.ticker == ticker {
-			return NewSecurity(d.id, d.ticker, d.currency, d.memo), true
+			return NewSecurity(d.id, d.ticker, d.currency, d.memo).WithPrecision(d.precision).WithTER(d.ter).WithWatch(d.watch).WithScores(d.scores).WithCashEquivalent(d.cash), true
 		}
 	}
 	return Security{}, false
 }
 
+// UnitValue returns the market value of one unit of a security on the snapshot's
+// date: its price, times the contract multiplier for options.
+func (s *Snapshot) UnitValue(ticker string) Money {
+	sec, ok := s.SecurityDetails(ticker)
+	if !ok {
+		return Money{}
+	}
+	return s.Price(ticker).Mul(sec.Multiplier())
+}
+
 // MarketValue calculates the market value of a single security on the snapshot's date.
+// Short option positions have a negative market value.
 func (s *Snapshot) MarketValue(ticker string) Money {
 	pos := s.Position(ticker)
-	price := s.Price(ticker)
-	return price.Mul(pos)
+	return s.UnitValue(ticker).Mul(pos)
 }
 
-// Cash returns the balance of a specific cash account on the snapshot's date.
+// Cash returns the balance of a specific currency, in all the cash accounts, on
+// the snapshot's date.
 func (s *Snapshot) Cash(currency string) Money {
+	return s.cash("", currency, false)
+}
+
+// SettledCash returns the balance of a specific currency, in all the cash
+// accounts, on the snapshot's date, counting buys and sells only once settled.
+// See Ledger.SetSettlementLag.
+func (s *Snapshot) SettledCash(currency string) Money {
+	return s.cash("", currency, true)
+}
+
+// cash returns the balance of a specific currency in a cash account, or in all
+// of them if account is empty, counting buys and sells only once settled if
+// settled is true.
+func (s *Snapshot) cash(account, currency string, settled bool) Money {
 	balance := M(0, currency)
 	for e := range s.events() {
 		switch v := e.(type) {
 		case creditCash:
-			if v.currency() == currency {
+			if v.currency() == currency && (account == "" || v.cashAccount() == account) && (!settled || s.settled(v)) {
 				balance = balance.Add(v.amount)
-				log.Printf("%s New Balance: %s", v.on, balance.value.String())
 			}
 		case debitCash:
-			if v.currency() == currency {
+			if v.currency() == currency && (account == "" || v.cashAccount() == account) && (!settled || s.settled(v)) {
 				balance = balance.Sub(v.amount)
-				log.Printf("%s New Balance: %s", v.on, balance.value.String())
 			}
 		}
 	}
 	return balance
 }
 
+// PendingCash returns the cash of buys and sells in a specific currency, traded
+// but not settled on the snapshot's date: the proceeds of sells less the cost
+// of buys.
+func (s *Snapshot) PendingCash(currency string) Money {
+	return s.Cash(currency).Sub(s.SettledCash(currency))
+}
+
+// settled reports whether the cash event is settled on the snapshot's date.
+// Only the cash of buys and sells settles after the trade date.
+func (s *Snapshot) settled(e event) bool {
+	if s.lag == 0 {
+		return true
+	}
+	switch s.journal.txs[e.source()].(type) {
+	case Buy, Sell:
+		return !settlementDate(e.date(), s.lag).After(s.on)
+	}
+	return true
+}
+
 // Dividends calculates the total income received from
 // dividends for a specific security since inception.
 func (s *Snapshot) Dividends(ticker string) Money {
@@ -244,18 +361,47 @@ func (s *Snapshot) Dividends(ticker string) Money {
 	return totalDividends
 }
 
+// LendingFees calculates the total income earned from lending a security since inception.
+func (s *Snapshot) LendingFees(ticker string) Money {
+	var total Money
+	for e := range s.events() {
+		if v, ok := e.(receiveLendingFee); ok && v.security == ticker {
+			total = total.Add(v.amount)
+		}
+	}
+	return total
+}
+
 // CostBasis calculates the total cost basis of a security held on the snapshot's date.
 func (s *Snapshot) CostBasis(ticker string, method CostBasisMethod) Money {
+	cost, _ := s.costBasis(ticker, method)
+	return cost
+}
+
+// CostBasisInBase calculates the total cost basis of a security held on the
+// snapshot's date, in the reporting currency. Unlike the converted CostBasis,
+// each purchase is converted at the exchange rate of its trade date, so that
+// the cost basis does not vary with exchange rates.
+func (s *Snapshot) CostBasisInBase(ticker string, method CostBasisMethod) Money {
+	_, base := s.costBasis(ticker, method)
+	return base
+}
+
+// costBasis calculates the total cost basis of a security, both in the
+// security's currency, and in the reporting currency at the exchange rates of
+// the trade dates.
+func (s *Snapshot) costBasis(ticker string, method CostBasisMethod) (cost, base Money) {
 	switch method {
 	case AverageCost:
 		var totalQuantity Quantity
-		var totalCost Money
+		var totalCost, totalBase Money
 		for e := range s.events() {
 			switch v := e.(type) {
 			case acquireLot:
 				if v.security == ticker {
 					totalQuantity = totalQuantity.Add(v.quantity)
 					totalCost = totalCost.Add(v.cost)
+					totalBase = totalBase.Add(s.tradeCost(v))
 				}
 			case splitShare:
 				if v.security == ticker {
@@ -267,19 +413,21 @@ func (s *Snapshot) CostBasis(ticker string, method CostBasisMethod) Money {
 					if !totalQuantity.IsZero() {
 						costOfSale := totalCost.Mul(v.quantity).Div(totalQuantity)
 						totalCost = totalCost.Sub(costOfSale)
+						baseCostOfSale := totalBase.Mul(v.quantity).Div(totalQuantity)
+						totalBase = totalBase.Sub(baseCostOfSale)
 					}
 					totalQuantity = totalQuantity.Sub(v.quantity)
 				}
 			}
 		}
-		return totalCost
+		return totalCost, totalBase
 	case FIFO:
 		var securityLots lots
 		for e := range s.events() {
 			switch v := e.(type) {
 			case acquireLot:
 				if v.security == ticker {
-					newLot := lot{Date: v.on, Quantity: v.quantity, Cost: v.cost}
+					newLot := lot{Date: v.on, Quantity: v.quantity, Cost: v.cost, BaseCost: s.tradeCost(v)}
 					securityLots = append(securityLots, newLot)
 				}
 			case splitShare:
@@ -296,14 +444,24 @@ func (s *Snapshot) CostBasis(ticker string, method CostBasisMethod) Money {
 				}
 			}
 		}
-		var totalCost Money
+		var totalCost, totalBase Money
 		for _, l := range securityLots {
 			totalCost = totalCost.Add(l.Cost)
+			totalBase = totalBase.Add(l.BaseCost)
 		}
-		return totalCost
+		return totalCost, totalBase
 	default:
-		return Money{} // Or handle error
+		return Money{}, Money{} // Or handle error
+	}
+}
+
+// tradeCost returns the cost of an acquired lot in the reporting currency, at
+// the exchange rate of its trade date, or at the snapshot's rate if unknown.
+func (s *Snapshot) tradeCost(v acquireLot) Money {
+	if v.rate.IsZero() {
+		return s.Convert(v.cost)
 	}
+	return v.rate.Mul(Q(v.cost.value))
 }
 
 // RealizedGains calculates the sum of all profits and losses 'locked in'
@@ -531,22 +689,81 @@ func (s *Snapshot) Convert(amount Money) Money {
 
 // ExchangeRate finds the last known exchange rate for a given currency on or before the snapshot's date.
 // The rate is the value of 1 unit of the foreign currency in the portfolio's reporting currency.
+//
+// If no currency pair relates the currency to the reporting currency, the rate
+// is derived through a pivot currency, see CrossRate.
 func (s *Snapshot) ExchangeRate(currency string) Money {
 	if currency == s.journal.cur {
 		return M(1, s.journal.cur)
 	}
 	var lastRate Money
 	for e := range s.events() {
-		if u, ok := e.(updateForex); ok && u.currency == currency {
+		if u, ok := e.(updateForex); ok && u.currency == currency && u.rate.cur == s.journal.cur {
 			lastRate = u.rate
 		}
 	}
 	if lastRate.IsZero() {
-		return M(0, s.journal.cur)
+		return s.CrossRate(currency, s.journal.cur)
 	}
 	return lastRate
 }
 
+// WithPivot sets the currency preferred to derive a missing exchange rate, and
+// returns the snapshot.
+func (s *Snapshot) WithPivot(currency string) *Snapshot {
+	s.pivot = currency
+	return s
+}
+
+// CrossRate returns the last known value of 1 unit of base in quote, on or
+// before the snapshot's date, or zero if it is unknown.
+//
+// The rate is the price of a currency pair of both currencies, in either
+// orientation. Otherwise, it is triangulated through a pivot currency with a
+// known rate to both: the pivot set with WithPivot, then the reporting
+// currency, then any other currency in order of appearance. For instance, the
+// USDGBP rate is derived from the EURUSD and EURGBP rates.
+func (s *Snapshot) CrossRate(base, quote string) Money {
+	if base == quote {
+		return M(1, quote)
+	}
+	type pair struct{ base, quote string }
+	// A rate is kept as a fraction, so that inverse rates are only divided once.
+	type fraction struct{ num, den decimal.Decimal }
+	one := decimal.NewFromInt(1)
+	rates := make(map[pair]fraction)
+	var currencies []string
+	for e := range s.events() {
+		u, ok := e.(updateForex)
+		if !ok || u.rate.IsZero() {
+			continue
+		}
+		// The most recent rate wins, whatever its orientation.
+		rates[pair{u.currency, u.rate.cur}] = fraction{u.rate.value, one}
+		rates[pair{u.rate.cur, u.currency}] = fraction{one, u.rate.value}
+		for _, c := range []string{u.currency, u.rate.cur} {
+			if !slices.Contains(currencies, c) {
+				currencies = append(currencies, c)
+			}
+		}
+	}
+	if rate, ok := rates[pair{base, quote}]; ok {
+		return M(rate.num.Div(rate.den), quote)
+	}
+	pivots := append([]string{s.pivot, s.journal.cur}, currencies...)
+	for _, pivot := range pivots {
+		if pivot == "" || pivot == base || pivot == quote {
+			continue
+		}
+		toPivot, ok1 := rates[pair{base, pivot}]
+		fromPivot, ok2 := rates[pair{pivot, quote}]
+		if ok1 && ok2 {
+			return M(toPivot.num.Mul(fromPivot.num).Div(toPivot.den.Mul(fromPivot.den)), quote)
+		}
+	}
+	return M(0, quote)
+}
+
 // TotalMarket returns the total market value of all securities in the portfolio.
 func (s *Snapshot) TotalMarket() Money {
 	return s.sum(s.Securities(), s.MarketValue)
@@ -569,17 +786,13 @@ func (s *Snapshot) TotalMarketIn(currency string) Money {
 // TotalCounterpartyIn returns the total balance across all counterparty accounts in a specific currency.
 // The returned value is in that currency, not the reporting currency.
 func (s *Snapshot) TotalCounterpartyIn(currency string) Money {
-	m := s.sum(s.Counterparties(), func(account string) Money {
-		bal := s.Counterparty(account)
-		if bal.Currency() == currency {
-			return bal
+	total := M(0, currency)
+	for account := range s.Counterparties() {
+		if bal := s.Counterparty(account); bal.Currency() == currency {
+			total = total.Add(bal)
 		}
-		return M(0, currency)
-	})
-	if m.Currency() != currency {
-		return M(0, currency)
 	}
-	return m
+	return total
 }
 
 // TotalCash returns the total cash balance across all currencies, converted to the reporting currency.
@@ -587,6 +800,21 @@ func (s *Snapshot) TotalCash() Money {
 	return s.sum(s.Currencies(), s.Cash)
 }
 
+// TotalCashEquivalents returns the total market value of the securities
+// declared as cash equivalents, converted to the reporting currency.
+//
+// It is not part of TotalCash: cash equivalents are securities, and their
+// market value is part of TotalMarket.
+func (s *Snapshot) TotalCashEquivalents() Money {
+	total := M(0, s.journal.cur)
+	for ticker := range s.Securities() {
+		if sec, ok := s.SecurityDetails(ticker); ok && sec.CashEquivalent() {
+			total = total.Add(s.Convert(s.MarketValue(ticker)))
+		}
+	}
+	return total
+}
+
 // TotalCounterparty returns the total balance across all counterparty accounts, converted to the reporting currency.
 func (s *Snapshot) TotalCounterparty() Money {
 	return s.sum(s.Counterparties(), s.Counterparty)
@@ -607,6 +835,47 @@ func (s *Snapshot) UnrealizedGains(ticker string, method CostBasisMethod) Money
 	return marketValue.Sub(costBasis)
 }
 
+// UnrealizedLocalGains returns the part of the unrealized gains of a security
+// due to its price, in its own currency, converted at the snapshot's rate.
+func (s *Snapshot) UnrealizedLocalGains(ticker string, method CostBasisMethod) Money {
+	return s.Convert(s.UnrealizedGains(ticker, method))
+}
+
+// UnrealizedCurrencyGains returns the part of the unrealized gains of a
+// security due to the variation of the exchange rate of its currency since
+// its purchase, in the reporting currency.
+func (s *Snapshot) UnrealizedCurrencyGains(ticker string, method CostBasisMethod) Money {
+	cost, base := s.costBasis(ticker, method)
+	return s.Convert(cost).Sub(base)
+}
+
+// AverageCost returns the average price paid per share of a security held,
+// fees included, using the average cost basis method.
+func (s *Snapshot) AverageCost(ticker string) Money {
+	return s.perUnit(ticker, s.CostBasis(ticker, AverageCost))
+}
+
+// BreakEven returns the price at which selling the whole position would recover
+// all the cash invested in the security, fees included. Unlike the average cost,
+// it accounts for the gains or losses already realized on previous sales.
+func (s *Snapshot) BreakEven(ticker string) Money {
+	return s.perUnit(ticker, s.NetTradingFlow(ticker))
+}
+
+// perUnit divides amount by the number of shares in the position, taking the
+// contract multiplier of options into account.
+func (s *Snapshot) perUnit(ticker string, amount Money) Money {
+	sec, ok := s.SecurityDetails(ticker)
+	if !ok {
+		return Money{}
+	}
+	units := s.Position(ticker).Mul(sec.Multiplier())
+	if units.IsZero() {
+		return M(0, sec.Currency())
+	}
+	return amount.Div(units)
+}
+
 // TotalCashFlow returns the total cash flow across all currencies, converted to the reporting currency.
 func (s *Snapshot) TotalCashFlow() Money {
 	return s.sum(s.Currencies(), s.CashFlow)
@@ -172,6 +172,52 @@ func TestSnapshot_MultiCurrency(t *testing.T) {
 	if got := s.TotalPortfolio(); !got.Equal(expectedTotalPortfolio) {
 		t.Errorf("TotalPortfolio() = %v, want %v", got, expectedTotalPortfolio)
 	}
+	if err := s.CheckTotals(); err != nil {
+		t.Errorf("CheckTotals() = %v, want nil", err)
+	}
+}
+
+func TestSnapshot_CostBasisInBase(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "MSFT", "US5949181045.XNAS", "USD"),
+		NewDeclare(NewDate(2025, 1, 1), "", "USDEUR", USDEUR, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", USD(10000), ""),
+		NewUpdatePrice(NewDate(2025, 1, 2), "USDEUR", EUR(0.9)),
+		NewBuy(NewDate(2025, 1, 3), "", "MSFT", Q(10), USD(4000)), // 3600 EUR
+		NewUpdatePrice(NewDate(2025, 1, 4), "USDEUR", EUR(0.92)),
+		NewBuy(NewDate(2025, 1, 4), "", "MSFT", Q(10), USD(4200)), // 3864 EUR
+		NewSell(NewDate(2025, 1, 5), "", "MSFT", Q(10), USD(4300)),
+		NewUpdatePrice(NewDate(2025, 1, 5), "USDEUR", EUR(1)),
+		NewUpdatePrice(NewDate(2025, 1, 5), "MSFT", USD(430)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+	s := ledger.NewSnapshot(NewDate(2025, 1, 5))
+
+	tests := []struct {
+		method                              CostBasisMethod
+		cost, base, localGain, currencyGain Money
+	}{
+		{FIFO, USD(4200), EUR(3864), EUR(100), EUR(336)},
+		{AverageCost, USD(4100), EUR(3732), EUR(200), EUR(368)},
+	}
+	for _, tt := range tests {
+		if got := s.CostBasis("MSFT", tt.method); !got.Equal(tt.cost) {
+			t.Errorf("CostBasis(%v) = %v, want %v", tt.method, got, tt.cost)
+		}
+		if got := s.CostBasisInBase("MSFT", tt.method); !got.Equal(tt.base) {
+			t.Errorf("CostBasisInBase(%v) = %v, want %v", tt.method, got, tt.base)
+		}
+		if got := s.UnrealizedLocalGains("MSFT", tt.method); !got.Equal(tt.localGain) {
+			t.Errorf("UnrealizedLocalGains(%v) = %v, want %v", tt.method, got, tt.localGain)
+		}
+		if got := s.UnrealizedCurrencyGains("MSFT", tt.method); !got.Equal(tt.currencyGain) {
+			t.Errorf("UnrealizedCurrencyGains(%v) = %v, want %v", tt.method, got, tt.currencyGain)
+		}
+	}
 }
 
 func TestSnapshot_RealizedGains(t *testing.T) {
@@ -433,3 +479,245 @@ func TestSnapshot_TimeWeightedReturn_VirtualAssetValue(t *testing.T) {
 		}
 	})
 }
+
+func TestSnapshot_Options(t *testing.T) {
+	call := ID("AAPL:2025-03-21:C:150:100")
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL-C", call, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(20000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(100), EUR(14000)),
+		NewSell(NewDate(2025, 1, 4), "", "AAPL-C", Q(2), EUR(600)), // sell to open, 3 per share
+		NewUpdatePrice(NewDate(2025, 1, 10), "AAPL-C", EUR(2)),
+		NewBuy(NewDate(2025, 1, 15), "", "AAPL-C", Q(1), EUR(200)), // buy to close
+		NewAssign(NewDate(2025, 3, 21), "", "AAPL-C", Q(0)),        // deliver 100 AAPL at 150
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	t.Run("ShortPosition", func(t *testing.T) {
+		s := ledger.NewSnapshot(NewDate(2025, 1, 10))
+		if got, want := s.Position("AAPL-C"), Q(-2); !got.Equal(want) {
+			t.Errorf("Position() = %v, want %v", got, want)
+		}
+		// 2 contracts short * 100 shares * 2 EUR
+		if got, want := s.MarketValue("AAPL-C"), EUR(-400); !got.Equal(want) {
+			t.Errorf("MarketValue() = %v, want %v", got, want)
+		}
+		if got, want := s.Cash("EUR"), EUR(6600); !got.Equal(want) { // 20000 - 14000 + 600
+			t.Errorf("Cash() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("BuyToClose", func(t *testing.T) {
+		s := ledger.NewSnapshot(NewDate(2025, 1, 15))
+		for _, method := range []CostBasisMethod{AverageCost, FIFO} {
+			if got, want := s.CostBasis("AAPL-C", method), EUR(-300); !got.Equal(want) {
+				t.Errorf("CostBasis(%v) = %v, want %v", method, got, want)
+			}
+			if got, want := s.RealizedGains("AAPL-C", method), EUR(100); !got.Equal(want) {
+				t.Errorf("RealizedGains(%v) = %v, want %v", method, got, want)
+			}
+		}
+	})
+
+	t.Run("Assign", func(t *testing.T) {
+		s := ledger.NewSnapshot(NewDate(2025, 3, 21))
+		if got := s.Position("AAPL-C"); !got.IsZero() {
+			t.Errorf("Position(AAPL-C) = %v, want 0", got)
+		}
+		if got := s.Position("AAPL"); !got.IsZero() {
+			t.Errorf("Position(AAPL) = %v, want 0", got)
+		}
+		if got, want := s.RealizedGains("AAPL-C", FIFO), EUR(400); !got.Equal(want) {
+			t.Errorf("RealizedGains(AAPL-C) = %v, want %v", got, want)
+		}
+		if got, want := s.RealizedGains("AAPL", FIFO), EUR(1000); !got.Equal(want) {
+			t.Errorf("RealizedGains(AAPL) = %v, want %v", got, want)
+		}
+		if got, want := s.Cash("EUR"), EUR(21400); !got.Equal(want) { // 6600 - 200 + 15000
+			t.Errorf("Cash() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		if _, err := NewExpire(NewDate(2025, 3, 20), "", "AAPL-C").Validate(ledger); err == nil {
+			t.Errorf("Expire before expiry: expected an error")
+		}
+		if _, err := NewSell(NewDate(2025, 1, 5), "", "AAPL", Q(200), EUR(1)).Validate(ledger); err == nil {
+			t.Errorf("Sell short of a share: expected an error")
+		}
+		if _, err := NewDeclare(NewDate(2025, 1, 1), "", "MSFT-C", "MSFT:2025-03-21:C:150:100", "EUR").Validate(ledger); err == nil {
+			t.Errorf("Declare option on undeclared underlying: expected an error")
+		}
+	})
+}
+
+func TestSnapshot_Lending(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(10000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), EUR(1500)),
+		NewLend(NewDate(2025, 1, 4), "", "AAPL", Q(6)),
+		NewSplit(NewDate(2025, 1, 5), "AAPL", 2, 1),
+		NewLendingFee(NewDate(2025, 1, 31), "", "AAPL", EUR(3)),
+		NewRecall(NewDate(2025, 2, 1), "", "AAPL", Q(4)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2025, 2, 1))
+	// Lent shares are split too: 6*2 - 4
+	if got, want := s.Lent("AAPL"), Q(8); !got.Equal(want) {
+		t.Errorf("Lent() = %v, want %v", got, want)
+	}
+	if got, want := s.Held("AAPL"), Q(12); !got.Equal(want) {
+		t.Errorf("Held() = %v, want %v", got, want)
+	}
+	if got, want := s.Position("AAPL"), Q(20); !got.Equal(want) {
+		t.Errorf("Position() = %v, want %v", got, want)
+	}
+	if got, want := s.LendingFees("AAPL"), EUR(3); !got.Equal(want) {
+		t.Errorf("LendingFees() = %v, want %v", got, want)
+	}
+	// Lending fees are income, not cash.
+	if got, want := s.Cash("EUR"), EUR(8500); !got.Equal(want) {
+		t.Errorf("Cash() = %v, want %v", got, want)
+	}
+
+	if _, err := NewSell(NewDate(2025, 2, 1), "", "AAPL", Q(15), EUR(100)).Validate(ledger); err == nil {
+		t.Errorf("Sell of lent shares: expected an error")
+	}
+	if _, err := NewRecall(NewDate(2025, 2, 1), "", "AAPL", Q(9)).Validate(ledger); err == nil {
+		t.Errorf("Recall more than lent: expected an error")
+	}
+}
+
+func TestSnapshot_Vesting(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	schedule := []VestingTranche{
+		{Date: NewDate(2026, 3, 15), Quantity: Q(100)},
+		{Date: NewDate(2026, 9, 15), Quantity: Q(100)},
+		{Date: NewDate(2027, 3, 15), Quantity: Q(100)},
+	}
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewGrant(NewDate(2025, 3, 15), "", "AAPL", "RSU-2025", PlanRSU, schedule),
+		NewVest(NewDate(2026, 3, 15), "", "AAPL", "RSU-2025", Q(100), EUR(15000)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2026, 4, 1))
+	grants := s.Grants()
+	if len(grants) != 1 {
+		t.Fatalf("len(Grants()) = %d, want 1", len(grants))
+	}
+	g := grants[0]
+	if got, want := g.Vested, Q(100); !got.Equal(want) {
+		t.Errorf("Vested = %v, want %v", got, want)
+	}
+	if got, want := g.Unvested(), Q(200); !got.Equal(want) {
+		t.Errorf("Unvested() = %v, want %v", got, want)
+	}
+	if got, want := len(g.Upcoming), 2; got != want {
+		t.Fatalf("len(Upcoming) = %d, want %d", got, want)
+	}
+	if got, want := g.Upcoming[0].Date, NewDate(2026, 9, 15); got != want {
+		t.Errorf("Upcoming[0].Date = %v, want %v", got, want)
+	}
+
+	// Vested shares are contributed at their fair market value, without cash.
+	if got, want := s.Position("AAPL"), Q(100); !got.Equal(want) {
+		t.Errorf("Position() = %v, want %v", got, want)
+	}
+	if got, want := s.CostBasis("AAPL", FIFO), EUR(15000); !got.Equal(want) {
+		t.Errorf("CostBasis() = %v, want %v", got, want)
+	}
+	if got, want := s.Cash("EUR"), EUR(0); !got.Equal(want) {
+		t.Errorf("Cash() = %v, want %v", got, want)
+	}
+
+	if _, err := NewVest(NewDate(2026, 9, 15), "", "AAPL", "RSU-2025", Q(201), EUR(1)).Validate(ledger); err == nil {
+		t.Errorf("Vest more than unvested: expected an error")
+	}
+	if _, err := NewVest(NewDate(2026, 9, 15), "", "AAPL", "unknown", Q(1), EUR(1)).Validate(ledger); err == nil {
+		t.Errorf("Vest of unknown grant: expected an error")
+	}
+}
+
+func TestSnapshot_AverageCost(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(10000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), EUR(1005)), // 5 EUR of fees
+		NewBuy(NewDate(2025, 1, 4), "", "AAPL", Q(10), EUR(1205)),
+		NewSell(NewDate(2025, 1, 5), "", "AAPL", Q(10), EUR(1500)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2025, 1, 5))
+	// (1005 + 1205) / 20
+	if got, want := s.AverageCost("AAPL"), EUR(110.5); !got.Equal(want) {
+		t.Errorf("AverageCost() = %v, want %v", got, want)
+	}
+	// (1005 + 1205 - 1500) / 10
+	if got, want := s.BreakEven("AAPL"), EUR(71); !got.Equal(want) {
+		t.Errorf("BreakEven() = %v, want %v", got, want)
+	}
+	if got, want := s.AverageCost("unknown"), (Money{}); !got.Equal(want) {
+		t.Errorf("AverageCost(unknown) = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshot_TotalCashEquivalents(t *testing.T) {
+	ledger := NewLedger()
+	ledger.currency = "EUR"
+	mmf := NewDeclare(NewDate(2025, 1, 1), "", "MMF", MSFT, "EUR")
+	mmf.CashEquivalent = true
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, 1, 1), "", "AAPL", AAPL, "EUR"),
+		mmf,
+		NewDeposit(NewDate(2025, 1, 2), "", EUR(10000), ""),
+		NewBuy(NewDate(2025, 1, 3), "", "AAPL", Q(10), EUR(1500)),
+		NewBuy(NewDate(2025, 1, 3), "", "MMF", Q(50), EUR(5000)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "AAPL", EUR(160)),
+		NewUpdatePrice(NewDate(2025, 1, 4), "MMF", EUR(101)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	s := ledger.NewSnapshot(NewDate(2025, 1, 4))
+	if got, want := s.TotalCashEquivalents(), EUR(5050); !got.Equal(want) {
+		t.Errorf("TotalCashEquivalents() = %v, want %v", got, want)
+	}
+	// Cash equivalents are still securities, with their yield.
+	if got, want := s.TotalCash(), EUR(3500); !got.Equal(want) {
+		t.Errorf("TotalCash() = %v, want %v", got, want)
+	}
+	if got, want := s.TotalMarket(), EUR(6650); !got.Equal(want) {
+		t.Errorf("TotalMarket() = %v, want %v", got, want)
+	}
+	if got, want := s.UnrealizedGains("MMF", AverageCost), EUR(50); !got.Equal(want) {
+		t.Errorf("UnrealizedGains(MMF) = %v, want %v", got, want)
+	}
+
+	watched := NewDeclare(NewDate(2025, 1, 1), "", "GOOG", GOOG, "EUR")
+	watched.Watch, watched.CashEquivalent = true, true
+	if _, err := watched.Validate(ledger); err == nil {
+		t.Errorf("Validate() of a cash equivalent on the watchlist = nil, want an error")
+	}
+}
@@ -0,0 +1,62 @@
+package portfolio
+
+import "math"
+
+// splitWindow is the number of days around a split searched for prices.
+const splitWindow = 10
+
+// SplitAnomaly is a split whose surrounding prices do not show the expected
+// discontinuity.
+//
+// The ledger stores raw prices: on the day of a 2:1 split, the price halves. A
+// data provider that returns prices adjusted for splits erases that
+// discontinuity, and mixing both kinds of prices distorts valuations.
+type SplitAnomaly struct {
+	Split Split
+	// Before and After are the last price before the split, and the first price
+	// on or after it.
+	Before, After         Money
+	BeforeDate, AfterDate Date
+	// Adjusted is true if the prices show no discontinuity, as if they were
+	// adjusted for the split.
+	Adjusted bool
+}
+
+// Ratio returns the observed ratio of the price after the split to the price before.
+func (a SplitAnomaly) Ratio() float64 { return a.After.AsFloat() / a.Before.AsFloat() }
+
+// ExpectedRatio returns the ratio of raw prices expected from the split.
+func (a SplitAnomaly) ExpectedRatio() float64 {
+	return float64(a.Split.Denominator) / float64(a.Split.Numerator)
+}
+
+// AuditSplits cross-checks the prices around each split for the discontinuity of
+// raw prices, and returns the splits where it is missing.
+//
+// The price ratio across a split is expected to be the inverse of the split
+// ratio, within the relative tolerance (e.g. 0.2 for the price moves of a day).
+// Splits without prices within 10 days on both sides cannot be checked, and are
+// not reported.
+func (l *Ledger) AuditSplits(tolerance float64) []SplitAnomaly {
+	var anomalies []SplitAnomaly
+	for _, tx := range l.Query().Command(CmdSplit).All() {
+		split := tx.(Split)
+		a := SplitAnomaly{Split: split}
+		for day, price := range l.PriceHistory(split.Security, NewRange(split.Date.Add(-splitWindow), split.Date.Add(splitWindow))) {
+			if day.Before(split.Date) {
+				a.Before, a.BeforeDate = price, day
+			} else if a.AfterDate.IsZero() {
+				a.After, a.AfterDate = price, day
+			}
+		}
+		if a.BeforeDate.IsZero() || a.AfterDate.IsZero() || a.Before.IsZero() {
+			continue
+		}
+		if math.Abs(a.Ratio()/a.ExpectedRatio()-1) <= tolerance {
+			continue
+		}
+		a.Adjusted = math.Abs(a.Ratio()-1) <= tolerance
+		anomalies = append(anomalies, a)
+	}
+	return anomalies
+}
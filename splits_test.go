@@ -0,0 +1,39 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLedger_AuditSplits(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "USD"),
+		NewDeclare(NewDate(2025, time.January, 1), "", "GOOG", GOOG, "USD"),
+		// Raw prices halve on a 2:1 split.
+		NewUpdatePrice(NewDate(2025, time.January, 9), "AAPL", USD(200)),
+		NewSplit(NewDate(2025, time.January, 10), "AAPL", 2, 1),
+		NewUpdatePrice(NewDate(2025, time.January, 10), "AAPL", USD(102)),
+		// Adjusted prices do not move.
+		NewUpdatePrice(NewDate(2025, time.January, 9), "GOOG", USD(100)),
+		NewSplit(NewDate(2025, time.January, 10), "GOOG", 3, 1),
+		NewUpdatePrice(NewDate(2025, time.January, 13), "GOOG", USD(101)),
+		// Splits without prices around cannot be checked.
+		NewSplit(NewDate(2025, time.March, 3), "GOOG", 2, 1),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	anomalies := ledger.AuditSplits(0.2)
+	if len(anomalies) != 1 {
+		t.Fatalf("AuditSplits() = %+v, want 1 anomaly", anomalies)
+	}
+	a := anomalies[0]
+	if a.Split.Security != "GOOG" || !a.Adjusted || a.AfterDate != NewDate(2025, time.January, 13) {
+		t.Errorf("AuditSplits()[0] = %+v, want the adjusted GOOG prices", a)
+	}
+	if got, want := a.ExpectedRatio(), 1.0/3; got != want {
+		t.Errorf("ExpectedRatio() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package portfolio
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SubPortfolioTag is the tag of the memos labelling the transactions of a
+// sub-portfolio, e.g. "portfolio=kids-savings", see ParseTags.
+const SubPortfolioTag = "portfolio"
+
+// SubPortfolioLabel returns the sub-portfolio label of a transaction, in lower
+// case, empty if it is not labelled.
+func SubPortfolioLabel(tx Transaction) string {
+	return strings.ToLower(tx.Tags()[SubPortfolioTag])
+}
+
+// SubPortfolios returns the labels of the sub-portfolios of the ledger, sorted.
+func (l *Ledger) SubPortfolios() []string {
+	l = l.view()
+	labels := make(map[string]bool)
+	for _, tx := range l.transactions {
+		if label := SubPortfolioLabel(tx); label != "" {
+			labels[label] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(labels))
+}
+
+// SubPortfolio returns a virtual ledger of the transactions labelled with a
+// sub-portfolio, e.g. the ones whose memo holds "portfolio=kids-savings", to
+// report on a conceptual bucket of the ledger without a ledger file of its own.
+//
+// The declarations and market data of the ledger (prices, dividends and
+// splits) are shared by all its sub-portfolios, the other transactions are
+// kept only with the label. The cash balances of the sub-portfolio are
+// virtual: they are the sum of the cash of its transactions only, e.g. the
+// deposits labelled "portfolio=kids-savings" fund the buys with that label.
+func (l *Ledger) SubPortfolio(label string) (*Ledger, error) {
+	l = l.clone()
+	label = strings.ToLower(label)
+	sub := NewLedger()
+	sub.name = l.name + " " + label
+	sub.currency = l.currency
+	sub.pivot = l.pivot
+	sub.settlementLag = l.settlementLag
+	sub.timeZone = l.timeZone
+	sub.intraday = l.intraday
+	labelled := false
+	for _, tx := range l.transactions {
+		switch tx.What() {
+		case CmdInit, CmdDeclareCurrency, CmdDeclare, CmdDividend, CmdSplit, CmdUpdatePrice:
+			sub.transactions = append(sub.transactions, tx)
+			continue
+		}
+		if SubPortfolioLabel(tx) == label {
+			sub.transactions = append(sub.transactions, tx)
+			labelled = true
+		}
+	}
+	if !labelled {
+		return nil, fmt.Errorf("no transaction labelled with sub-portfolio %q, label them with %q in their memo", label, SubPortfolioTag+"="+label)
+	}
+	return sub, sub.index()
+}
@@ -0,0 +1,58 @@
+package portfolio
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLedger_SubPortfolio(t *testing.T) {
+	ledger := NewLedger()
+	ledger.name = "family"
+	txs := []Transaction{
+		NewDeclare(NewDate(2025, time.January, 1), "", "AAPL", AAPL, "EUR"),
+		NewDeposit(NewDate(2025, time.January, 2), "", EUR(10000), ""),
+		NewDeposit(NewDate(2025, time.January, 2), "birthday portfolio=kids-savings", EUR(1000), ""),
+		NewBuy(NewDate(2025, time.January, 3), "", "AAPL", Q(10), EUR(1500)),
+		NewBuy(NewDate(2025, time.January, 3), "portfolio=Kids-Savings", "AAPL", Q(5), EUR(750)),
+		NewDividend(NewDate(2025, time.January, 4), "", "AAPL", EUR(2)),
+		NewUpdatePrice(NewDate(2025, time.January, 4), "AAPL", EUR(160)),
+		NewWithdraw(NewDate(2025, time.January, 5), "portfolio=retirement", EUR(100)),
+	}
+	if err := ledger.Append(txs...); err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	if got, want := ledger.SubPortfolios(), []string{"kids-savings", "retirement"}; !slices.Equal(got, want) {
+		t.Errorf("SubPortfolios() = %v, want %v", got, want)
+	}
+
+	sub, err := ledger.SubPortfolio("kids-savings")
+	if err != nil {
+		t.Fatalf("SubPortfolio() error = %v", err)
+	}
+	on := NewDate(2025, time.January, 5)
+	s := sub.NewSnapshot(on)
+	if got, want := s.Position("AAPL"), Q(5); !got.Equal(want) {
+		t.Errorf("Position() = %v, want %v", got, want)
+	}
+	// 1000 deposited, 750 invested.
+	if got, want := s.Cash("EUR"), EUR(250); !got.Equal(want) {
+		t.Errorf("Cash() = %v, want %v", got, want)
+	}
+	if got, want := s.Dividends("AAPL"), EUR(10); !got.Equal(want) {
+		t.Errorf("Dividends() = %v, want %v", got, want)
+	}
+	if got, want := s.TotalPortfolio(), EUR(1050); !got.Equal(want) {
+		t.Errorf("TotalPortfolio() = %v, want %v", got, want)
+	}
+
+	// The ledger is not affected.
+	if got, want := ledger.NewSnapshot(on).Position("AAPL"), Q(15); !got.Equal(want) {
+		t.Errorf("Position() of the ledger = %v, want %v", got, want)
+	}
+
+	if _, err := ledger.SubPortfolio("unknown"); err == nil {
+		t.Errorf("SubPortfolio(unknown): expected an error")
+	}
+}
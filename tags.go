@@ -0,0 +1,113 @@
+package portfolio
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Tags are the structured metadata of a transaction memo: each "#label" maps
+// to an empty value, and each "key=value" maps the key to its value. Labels
+// and keys are in lower case.
+//
+// For instance, the memo "flight to Tokyo #vacation trip=japan" has the tags
+// vacation and trip, whose value is japan.
+type Tags map[string]string
+
+// ParseTags parses the tags of a memo.
+func ParseTags(memo string) Tags {
+	var tags Tags
+	for _, word := range strings.Fields(memo) {
+		word = strings.TrimRightFunc(word, unicode.IsPunct)
+		var key, value string
+		if label, ok := strings.CutPrefix(word, "#"); ok {
+			key = label
+		} else if k, v, ok := strings.Cut(word, "="); ok && v != "" {
+			key, value = k, v
+		}
+		if !isTagKey(key) {
+			continue
+		}
+		if tags == nil {
+			tags = make(Tags)
+		}
+		tags[strings.ToLower(key)] = value
+	}
+	return tags
+}
+
+// isTagKey reports whether s is a valid label or key: letters, digits, '_'
+// and '-'.
+func isTagKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Has reports whether the tags contain a label or a key, case insensitive.
+func (t Tags) Has(key string) bool {
+	_, ok := t[strings.ToLower(key)]
+	return ok
+}
+
+// Strings returns the tags as "label" or "key=value", sorted.
+func (t Tags) Strings() []string {
+	var s []string
+	for _, k := range slices.Sorted(maps.Keys(t)) {
+		if v := t[k]; v != "" {
+			k += "=" + v
+		}
+		s = append(s, k)
+	}
+	return s
+}
+
+// Tags returns the tags of the transaction memo.
+func (t baseCmd) Tags() Tags {
+	return ParseTags(t.Memo)
+}
+
+// TagTotal aggregates the transactions with the same tag.
+type TagTotal struct {
+	Tag          string  // Tag is the label, or "key=value".
+	Transactions int     // Transactions is the number of transactions with the tag.
+	Amounts      []Money // Amounts are the sums of the transaction amounts, regardless of their direction, one per currency.
+}
+
+// TotalsByTag aggregates transactions by tag, sorted by tag. A transaction
+// counts once in each of its tags.
+func TotalsByTag(txs []Transaction) []TagTotal {
+	totals := make(map[string]*TagTotal)
+	for _, tx := range txs {
+		for _, tag := range tx.Tags().Strings() {
+			total, ok := totals[tag]
+			if !ok {
+				total = &TagTotal{Tag: tag}
+				totals[tag] = total
+			}
+			total.Transactions++
+			m, ok := amountOf(tx)
+			if !ok {
+				continue
+			}
+			i := slices.IndexFunc(total.Amounts, func(a Money) bool { return a.Currency() == m.Currency() })
+			if i < 0 {
+				total.Amounts = append(total.Amounts, m)
+			} else {
+				total.Amounts[i] = total.Amounts[i].Add(m)
+			}
+		}
+	}
+	var list []TagTotal
+	for _, tag := range slices.Sorted(maps.Keys(totals)) {
+		list = append(list, *totals[tag])
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package portfolio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		memo string
+		want []string
+	}{
+		{"", nil},
+		{"no tags here", nil},
+		{"flight to Tokyo #Vacation, trip=japan", []string{"trip=japan", "vacation"}},
+		{"# alone, = alone, a=, =b, #not/a/tag", nil},
+		{"#tax-2024 #tax_free", []string{"tax-2024", "tax_free"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.memo).Strings(); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %v, want %v", tt.memo, got, tt.want)
+		}
+	}
+
+	tx := NewWithdraw(NewDate(2025, 1, 1), "hotel #vacation", EUR(100))
+	if !tx.Tags().Has("VACATION") {
+		t.Errorf("Tags().Has(VACATION) = false, want true")
+	}
+}
+
+func TestTotalsByTag(t *testing.T) {
+	txs := []Transaction{
+		NewWithdraw(NewDate(2025, 1, 1), "hotel #vacation trip=japan", EUR(100)),
+		NewWithdraw(NewDate(2025, 1, 2), "train #vacation", EUR(50)),
+		NewWithdraw(NewDate(2025, 1, 3), "souvenir #vacation", USD(20)),
+		NewDeclare(NewDate(2025, 1, 3), "#vacation", "AAPL", AAPL, "USD"),
+		NewWithdraw(NewDate(2025, 1, 4), "no tag", EUR(10)),
+	}
+	got := TotalsByTag(txs)
+	if len(got) != 2 {
+		t.Fatalf("TotalsByTag() = %v, want 2 tags", got)
+	}
+	if got[0].Tag != "trip=japan" || got[0].Transactions != 1 {
+		t.Errorf("TotalsByTag()[0] = %v, want trip=japan in 1 transaction", got[0])
+	}
+	v := got[1]
+	if v.Tag != "vacation" || v.Transactions != 4 || len(v.Amounts) != 2 || !v.Amounts[0].Equal(EUR(150)) || !v.Amounts[1].Equal(USD(20)) {
+		t.Errorf("TotalsByTag()[1] = %v, want vacation in 4 transactions for 150 EUR and 20 USD", v)
+	}
+}
@@ -0,0 +1,235 @@
+package portfolio
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TaxRules holds the jurisdiction rules used to compute a TaxStatement.
+type TaxRules struct {
+	// Method is the cost basis method used to compute the cost of each sale.
+	Method CostBasisMethod
+	// LongTermDays is the holding period, in days, above which a gain is long-term.
+	// A lot held for strictly more than LongTermDays is long-term.
+	LongTermDays int
+}
+
+// DefaultTaxRules are the rules used when none are specified: FIFO cost basis
+// and a one year holding period for long-term gains.
+var DefaultTaxRules = TaxRules{Method: FIFO, LongTermDays: 365}
+
+// TaxSale is the part of a sale matched against a single acquisition lot.
+//
+// A single sell transaction can consume several lots, and therefore produce
+// several TaxSale, each with its own acquisition date and holding period.
+type TaxSale struct {
+	Security    string
+	Acquired    Date
+	Sold        Date
+	Quantity    Quantity
+	Proceeds    Money
+	Cost        Money
+	Gain        Money
+	HoldingDays int
+	LongTerm    bool
+}
+
+// TaxDividend is a dividend payment received during the year.
+type TaxDividend struct {
+	Security string
+	Date     Date
+	Amount   Money // total amount received
+}
+
+// TaxTotal holds the taxable income of a year in a single currency.
+type TaxTotal struct {
+	Currency      string
+	Proceeds      Money
+	ShortTermGain Money
+	LongTermGain  Money
+	Dividends     Money
+}
+
+// TaxStatement is the capital gains and dividend income of a fiscal year.
+type TaxStatement struct {
+	Year  int
+	Rules TaxRules
+	// Sales lists all sales of the year, in chronological order.
+	Sales []TaxSale
+	// Dividends lists all dividends received during the year, in chronological order.
+	Dividends []TaxDividend
+	// Totals lists the taxable income per currency, sorted by currency.
+	Totals []TaxTotal
+}
+
+// TaxReport computes the TaxStatement for the calendar year of a journal.
+//
+// Sales are matched against acquisition lots in FIFO order to determine the
+// acquisition date, hence the holding period, of each sold share. The cost of
+// the sold shares depends on the rules' cost basis method.
+func TaxReport(journal *Journal, year int, rules TaxRules) *TaxStatement {
+	fiscalYear := NewRange(NewDate(year, 1, 1), NewDate(year, 12, 31))
+	st := &TaxStatement{Year: year, Rules: rules}
+	totals := make(map[string]*TaxTotal)
+	total := func(cur string) *TaxTotal {
+		t, ok := totals[cur]
+		if !ok {
+			t = &TaxTotal{Currency: cur, Proceeds: M(0, cur), ShortTermGain: M(0, cur), LongTermGain: M(0, cur), Dividends: M(0, cur)}
+			totals[cur] = t
+		}
+		return t
+	}
+
+	securityLots := make(map[string]lots)
+	// averages holds the position of each security with its total cost, for the
+	// average cost method: the lots only tell the acquisition dates.
+	averages := make(map[string]averagePosition)
+	for _, e := range journal.events {
+		if e.date().After(fiscalYear.To) {
+			break
+		}
+		switch v := e.(type) {
+		case acquireLot:
+			securityLots[v.security] = append(securityLots[v.security], lot{Date: v.on, Quantity: v.quantity, Cost: v.cost})
+			averages[v.security] = averages[v.security].acquire(v.quantity, v.cost)
+		case splitShare:
+			num, den := Q(v.numerator), Q(v.denominator)
+			for i, l := range securityLots[v.security] {
+				l.Quantity = l.Quantity.Mul(num).Div(den)
+				securityLots[v.security][i] = l
+			}
+			avg := averages[v.security]
+			avg.quantity = avg.quantity.Mul(num).Div(den)
+			averages[v.security] = avg
+		case receiveDividend:
+			if !fiscalYear.Contains(v.on) {
+				continue
+			}
+			var position Quantity
+			for _, l := range securityLots[v.security] {
+				position = position.Add(l.Quantity)
+			}
+			amount := v.amount.Mul(position)
+			st.Dividends = append(st.Dividends, TaxDividend{Security: v.security, Date: v.on, Amount: amount})
+			t := total(amount.Currency())
+			t.Dividends = t.Dividends.Add(amount)
+		case disposeLot:
+			held := securityLots[v.security]
+			if fiscalYear.Contains(v.on) {
+				for _, sale := range matchLots(held, averages[v.security], v, rules) {
+					st.Sales = append(st.Sales, sale)
+					t := total(sale.Proceeds.Currency())
+					t.Proceeds = t.Proceeds.Add(sale.Proceeds)
+					if sale.LongTerm {
+						t.LongTermGain = t.LongTermGain.Add(sale.Gain)
+					} else {
+						t.ShortTermGain = t.ShortTermGain.Add(sale.Gain)
+					}
+				}
+			}
+			securityLots[v.security] = held.sell(v.quantity)
+			averages[v.security] = averages[v.security].dispose(v.quantity)
+		}
+	}
+
+	for _, t := range totals {
+		st.Totals = append(st.Totals, *t)
+	}
+	slices.SortFunc(st.Totals, func(a, b TaxTotal) int { return strings.Compare(a.Currency, b.Currency) })
+	return st
+}
+
+// averagePosition is the position of a security with its total cost, for the
+// average cost method.
+type averagePosition struct {
+	quantity Quantity
+	cost     Money
+}
+
+// acquire returns the position after the acquisition of quantity for cost.
+func (p averagePosition) acquire(quantity Quantity, cost Money) averagePosition {
+	return averagePosition{quantity: p.quantity.Add(quantity), cost: p.cost.Add(cost)}
+}
+
+// dispose returns the position after the disposal of quantity: the cost is
+// reduced in proportion, so that the average cost is unchanged.
+func (p averagePosition) dispose(quantity Quantity) averagePosition {
+	if p.quantity.IsZero() {
+		return p
+	}
+	left := p.quantity.Sub(quantity)
+	return averagePosition{quantity: left, cost: p.cost.Mul(left).Div(p.quantity)}
+}
+
+// matchLots splits a disposal into one TaxSale per lot consumed in FIFO order.
+//
+// With the average cost method, all shares have the average unit cost of the
+// position avg, whatever their lot.
+func matchLots(held lots, avg averagePosition, d disposeLot, rules TaxRules) []TaxSale {
+	var sales []TaxSale
+	remaining := d.quantity
+	for _, l := range held {
+		if remaining.IsZero() {
+			break
+		}
+		q := l.Quantity
+		if q.Abs().GreaterThan(remaining.Abs()) {
+			q = remaining
+		}
+		remaining = remaining.Sub(q)
+
+		var cost Money
+		switch rules.Method {
+		case AverageCost:
+			cost = avg.cost.Mul(q).Div(avg.quantity)
+		default:
+			cost = l.Cost.Mul(q).Div(l.Quantity)
+		}
+		proceeds := d.proceeds.Mul(q).Div(d.quantity)
+		days := d.on.Sub(l.Date)
+		sales = append(sales, TaxSale{
+			Security:    d.security,
+			Acquired:    l.Date,
+			Sold:        d.on,
+			Quantity:    q,
+			Proceeds:    proceeds,
+			Cost:        cost,
+			Gain:        proceeds.Sub(cost),
+			HoldingDays: days,
+			LongTerm:    days > rules.LongTermDays,
+		})
+	}
+	return sales
+}
+
+// TaxJurisdiction implements country-specific tax rules on top of a TaxStatement.
+type TaxJurisdiction interface {
+	// Name returns the identifier of the jurisdiction (e.g. "fr").
+	Name() string
+	// Rules returns the rules used to compute the TaxStatement.
+	Rules() TaxRules
+	// Assess estimates the taxes due for the statement, using the ledger for
+	// any additional data (e.g. prices and exchange rates).
+	Assess(ledger *Ledger, st *TaxStatement) ([]TaxAssessment, error)
+}
+
+// TaxAssessment is a line of a jurisdiction's tax estimate: a taxable base, and
+// the tax due on it.
+type TaxAssessment struct {
+	Label string
+	Base  Money
+	Rate  Percent
+	Tax   Money
+}
+
+// AssessTaxes computes the TaxStatement of a year using the jurisdiction rules, and
+// the jurisdiction's tax estimate.
+func AssessTaxes(ledger *Ledger, year int, j TaxJurisdiction) (*TaxStatement, []TaxAssessment, error) {
+	st := TaxReport(ledger.Journal(), year, j.Rules())
+	assessments, err := j.Assess(ledger, st)
+	if err != nil {
+		return st, nil, fmt.Errorf("cannot assess %s taxes for %d: %w", j.Name(), year, err)
+	}
+	return st, assessments, nil
+}
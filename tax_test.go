@@ -0,0 +1,118 @@
+package portfolio
+
+import (
+	"testing"
+)
+
+func TestTaxReport(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2023, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2023, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2023, 1, 1), "", USD(10000), ""),
+		NewBuy(NewDate(2023, 1, 10), "", "AAPL", Q(10), USD(1000)),
+		NewBuy(NewDate(2024, 3, 1), "", "AAPL", Q(10), USD(2000)),
+		NewDividend(NewDate(2024, 4, 1), "", "AAPL", USD(1)),
+		NewSell(NewDate(2024, 6, 1), "", "AAPL", Q(15), USD(3000)),
+		NewSell(NewDate(2025, 1, 10), "", "AAPL", Q(5), USD(1500)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		rules     TaxRules
+		wantSales []TaxSale
+		wantTotal TaxTotal
+	}{
+		{
+			name:  "FIFO",
+			rules: TaxRules{Method: FIFO, LongTermDays: 365},
+			wantSales: []TaxSale{
+				{Security: "AAPL", Acquired: NewDate(2023, 1, 10), Sold: NewDate(2024, 6, 1), Quantity: Q(10), Proceeds: USD(2000), Cost: USD(1000), Gain: USD(1000), HoldingDays: 508, LongTerm: true},
+				{Security: "AAPL", Acquired: NewDate(2024, 3, 1), Sold: NewDate(2024, 6, 1), Quantity: Q(5), Proceeds: USD(1000), Cost: USD(1000), Gain: USD(0), HoldingDays: 92, LongTerm: false},
+			},
+			wantTotal: TaxTotal{Currency: "USD", Proceeds: USD(3000), LongTermGain: USD(1000), ShortTermGain: USD(0), Dividends: USD(20)},
+		},
+		{
+			name:  "AverageCost",
+			rules: TaxRules{Method: AverageCost, LongTermDays: 365},
+			wantSales: []TaxSale{
+				{Security: "AAPL", Acquired: NewDate(2023, 1, 10), Sold: NewDate(2024, 6, 1), Quantity: Q(10), Proceeds: USD(2000), Cost: USD(1500), Gain: USD(500), HoldingDays: 508, LongTerm: true},
+				{Security: "AAPL", Acquired: NewDate(2024, 3, 1), Sold: NewDate(2024, 6, 1), Quantity: Q(5), Proceeds: USD(1000), Cost: USD(750), Gain: USD(250), HoldingDays: 92, LongTerm: false},
+			},
+			wantTotal: TaxTotal{Currency: "USD", Proceeds: USD(3000), LongTermGain: USD(500), ShortTermGain: USD(250), Dividends: USD(20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := TaxReport(ledger.Journal(), 2024, tt.rules)
+			if got, want := len(st.Sales), len(tt.wantSales); got != want {
+				t.Fatalf("len(Sales) = %d, want %d: %v", got, want, st.Sales)
+			}
+			for i, want := range tt.wantSales {
+				got := st.Sales[i]
+				if got.Acquired != want.Acquired || got.Sold != want.Sold || !got.Quantity.Equal(want.Quantity) ||
+					!got.Proceeds.Equal(want.Proceeds) || !got.Cost.Equal(want.Cost) || !got.Gain.Equal(want.Gain) ||
+					got.HoldingDays != want.HoldingDays || got.LongTerm != want.LongTerm {
+					t.Errorf("Sales[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+			if len(st.Totals) != 1 {
+				t.Fatalf("len(Totals) = %d, want 1", len(st.Totals))
+			}
+			got := st.Totals[0]
+			if got.Currency != tt.wantTotal.Currency || !got.Proceeds.Equal(tt.wantTotal.Proceeds) ||
+				!got.LongTermGain.Equal(tt.wantTotal.LongTermGain) || !got.ShortTermGain.Equal(tt.wantTotal.ShortTermGain) ||
+				!got.Dividends.Equal(tt.wantTotal.Dividends) {
+				t.Errorf("Totals[0] = %+v, want %+v", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// TestTaxReport_SecondYear checks the cost of a sale after an earlier partial
+// sale of the position, in a previous fiscal year.
+func TestTaxReport_SecondYear(t *testing.T) {
+	ledger := NewLedger()
+	err := ledger.Append(
+		NewInit(NewDate(2023, 1, 1), "", "USD"),
+		NewDeclare(NewDate(2023, 1, 1), "", "AAPL", AAPL, "USD"),
+		NewDeposit(NewDate(2023, 1, 1), "", USD(10000), ""),
+		NewBuy(NewDate(2023, 1, 10), "", "AAPL", Q(10), USD(1000)),
+		NewBuy(NewDate(2024, 3, 1), "", "AAPL", Q(10), USD(2000)),
+		NewSell(NewDate(2024, 6, 1), "", "AAPL", Q(15), USD(3000)),
+		NewSell(NewDate(2025, 1, 10), "", "AAPL", Q(5), USD(1500)),
+	)
+	if err != nil {
+		t.Fatalf("ledger.Append() error = %v", err)
+	}
+
+	tests := []struct {
+		method   CostBasisMethod
+		wantCost Money
+	}{
+		{FIFO, USD(1000)},       // the rest of the second lot.
+		{AverageCost, USD(750)}, // the average cost of 150 per share is unchanged by the first sale.
+	}
+	for _, tt := range tests {
+		t.Run(tt.method.String(), func(t *testing.T) {
+			st := TaxReport(ledger.Journal(), 2025, TaxRules{Method: tt.method, LongTermDays: 365})
+			if len(st.Sales) != 1 {
+				t.Fatalf("len(Sales) = %d, want 1: %v", len(st.Sales), st.Sales)
+			}
+			got := st.Sales[0]
+			wantGain := USD(1500).Sub(tt.wantCost)
+			if got.Acquired != NewDate(2024, 3, 1) || !got.Quantity.Equal(Q(5)) || !got.Cost.Equal(tt.wantCost) || !got.Gain.Equal(wantGain) {
+				t.Errorf("Sales[0] = %+v, want 5 shares acquired on 2024-03-01 with cost %v and gain %v", got, tt.wantCost, wantGain)
+			}
+			// The gain matches the realized gains of the snapshot.
+			s := ledger.NewSnapshot(NewDate(2025, 1, 10))
+			realized := s.RealizedGains("AAPL", tt.method).Sub(ledger.NewSnapshot(NewDate(2024, 12, 31)).RealizedGains("AAPL", tt.method))
+			if !realized.Equal(got.Gain) {
+				t.Errorf("Gain = %v, want the realized gain of the snapshots %v", got.Gain, realized)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package taxrules
+
+import (
+	"github.com/etnz/portfolio"
+)
+
+// France implements the French "prélèvement forfaitaire unique" (PFU), a flat tax
+// of 30% (12.8% income tax plus 17.2% social contributions) on the net capital
+// gains and the gross dividends of the year.
+//
+// Capital gains are computed using the weighted average cost ("prix moyen
+// pondéré"), and converted into the reporting currency at the rate of the sale
+// date. A net loss is not taxed, and can be carried forward.
+type France struct {
+	// Rate is the flat tax rate.
+	Rate portfolio.Percent
+}
+
+// NewFrance returns the French jurisdiction with the current flat tax rate.
+func NewFrance() *France { return &France{Rate: 30} }
+
+func (*France) Name() string { return "fr" }
+
+func (*France) Rules() portfolio.TaxRules {
+	return portfolio.TaxRules{Method: portfolio.AverageCost, LongTermDays: portfolio.DefaultTaxRules.LongTermDays}
+}
+
+// Assess implements portfolio.TaxJurisdiction.
+func (j *France) Assess(ledger *portfolio.Ledger, st *portfolio.TaxStatement) ([]portfolio.TaxAssessment, error) {
+	gains, dividends := zero(ledger), zero(ledger)
+	for _, s := range st.Sales {
+		g, err := convert(ledger, s.Sold, s.Gain)
+		if err != nil {
+			return nil, err
+		}
+		gains = gains.Add(g)
+	}
+	for _, d := range st.Dividends {
+		a, err := convert(ledger, d.Date, d.Amount)
+		if err != nil {
+			return nil, err
+		}
+		dividends = dividends.Add(a)
+	}
+
+	rate := percent(j.Rate)
+	var res []portfolio.TaxAssessment
+	if gains.IsNegative() {
+		res = append(res, portfolio.TaxAssessment{Label: "Capital losses carried forward", Base: gains, Tax: zero(ledger)})
+	} else {
+		res = append(res, portfolio.TaxAssessment{Label: "Net capital gains (PFU)", Base: gains, Rate: j.Rate, Tax: gains.Mul(rate)})
+	}
+	res = append(res, portfolio.TaxAssessment{Label: "Dividends (PFU)", Base: dividends, Rate: j.Rate, Tax: dividends.Mul(rate)})
+	return res, nil
+}
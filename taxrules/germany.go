@@ -0,0 +1,193 @@
+package taxrules
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/etnz/portfolio"
+)
+
+// Germany implements the German flat tax on capital income ("Abgeltungsteuer"
+// plus solidarity surcharge) and estimates the "Vorabpauschale", the advance lump
+// sum taxed each year on accumulating investment funds.
+//
+// Income from investment funds (gains, distributions and Vorabpauschale) is
+// partially exempted ("Teilfreistellung"). Capital income above the saver's
+// allowance ("Sparerpauschbetrag") is taxed.
+//
+// The Vorabpauschale of a year is deemed received on the first working day of the
+// following year. For each fund held at the end of the year it is the base return
+// (fund value at the start of the year times 70% of the "Basiszins"), capped by the
+// actual increase in value plus distributions, minus distributions. Shares bought
+// during the year reduce the base return by one twelfth for each full month
+// preceding the purchase.
+type Germany struct {
+	// Rate is the tax rate on capital income, including the solidarity surcharge.
+	Rate portfolio.Percent
+	// Allowance is the yearly saver's allowance, in the reporting currency.
+	Allowance float64
+	// PartialExemption is the part of fund income exempted from tax (30% for equity funds).
+	PartialExemption portfolio.Percent
+	// Funds lists the tickers of investment funds. Other securities are considered
+	// direct holdings, neither exempted nor subject to the Vorabpauschale.
+	Funds []string
+	// BaseRates lists the "Basiszins" published yearly by the Federal Ministry of Finance.
+	BaseRates map[int]portfolio.Percent
+}
+
+// NewGermany returns the German jurisdiction with current rates, for equity funds.
+func NewGermany() *Germany {
+	return &Germany{
+		Rate:             26.375,
+		Allowance:        1000,
+		PartialExemption: 30,
+		BaseRates: map[int]portfolio.Percent{
+			2018: 0.87,
+			2019: 0.52,
+			2020: 0.07,
+			2021: -0.45,
+			2022: -0.05,
+			2023: 2.55,
+			2024: 2.29,
+			2025: 2.53,
+		},
+	}
+}
+
+func (*Germany) Name() string { return "de" }
+
+func (*Germany) Rules() portfolio.TaxRules {
+	return portfolio.TaxRules{Method: portfolio.FIFO, LongTermDays: portfolio.DefaultTaxRules.LongTermDays}
+}
+
+// isFund returns true if ticker is an investment fund.
+func (j *Germany) isFund(ticker string) bool { return slices.Contains(j.Funds, ticker) }
+
+// taxable returns the taxable part of an income from ticker.
+func (j *Germany) taxable(ticker string, income portfolio.Money) portfolio.Money {
+	if !j.isFund(ticker) {
+		return income
+	}
+	return income.Mul(percent(100 - j.PartialExemption))
+}
+
+// Assess implements portfolio.TaxJurisdiction.
+func (j *Germany) Assess(ledger *portfolio.Ledger, st *portfolio.TaxStatement) ([]portfolio.TaxAssessment, error) {
+	gains, dividends := zero(ledger), zero(ledger)
+	for _, s := range st.Sales {
+		g, err := convert(ledger, s.Sold, s.Gain)
+		if err != nil {
+			return nil, err
+		}
+		gains = gains.Add(j.taxable(s.Security, g))
+	}
+	for _, d := range st.Dividends {
+		a, err := convert(ledger, d.Date, d.Amount)
+		if err != nil {
+			return nil, err
+		}
+		dividends = dividends.Add(j.taxable(d.Security, a))
+	}
+
+	rate := percent(j.Rate)
+	income := gains.Add(dividends)
+	allowance := portfolio.M(j.Allowance, income.Currency())
+	if income.LessThan(allowance) {
+		allowance = income
+	}
+	if allowance.IsNegative() {
+		allowance = zero(ledger)
+	}
+	base := income.Sub(allowance)
+
+	res := []portfolio.TaxAssessment{
+		{Label: "Capital gains", Base: gains, Tax: zero(ledger)},
+		{Label: "Dividends", Base: dividends, Tax: zero(ledger)},
+		{Label: "Sparerpauschbetrag", Base: allowance.Neg(), Tax: zero(ledger)},
+		{Label: "Abgeltungsteuer and Soli", Base: base, Rate: j.Rate, Tax: base.Mul(rate)},
+	}
+
+	if len(j.Funds) > 0 {
+		vp, err := j.vorabpauschale(ledger, st.Year, st.Dividends)
+		if err != nil {
+			return nil, err
+		}
+		label := fmt.Sprintf("Vorabpauschale %d (taxed on %d-01-02)", st.Year, st.Year+1)
+		res = append(res, portfolio.TaxAssessment{Label: label, Base: vp, Rate: j.Rate, Tax: vp.Mul(rate)})
+	}
+	return res, nil
+}
+
+// vorabpauschale returns the taxable Vorabpauschale of a year for all funds, in
+// the reporting currency.
+func (j *Germany) vorabpauschale(ledger *portfolio.Ledger, year int, dividends []portfolio.TaxDividend) (portfolio.Money, error) {
+	baseRate, ok := j.BaseRates[year]
+	if !ok {
+		return portfolio.Money{}, fmt.Errorf("unknown Basiszins for %d", year)
+	}
+	if baseRate < 0 {
+		baseRate = 0
+	}
+	factor := percent(baseRate).Mul(percent(70))
+
+	start := ledger.NewSnapshot(portfolio.NewDate(year-1, 12, 31))
+	endDate := portfolio.NewDate(year, 12, 31)
+	end := ledger.NewSnapshot(endDate)
+
+	total := zero(ledger)
+	for _, ticker := range j.Funds {
+		held := end.Position(ticker)
+		if !held.IsPositive() {
+			continue
+		}
+		startPrice, endPrice := start.Price(ticker), end.Price(ticker)
+		basis := portfolio.M(0, endPrice.Currency())
+		increase := portfolio.M(0, endPrice.Currency())
+
+		// Shares held at the end of the year are the most recently bought ones.
+		remaining := held
+		var bought []portfolio.Buy
+		buys := ledger.Query().Command(portfolio.CmdBuy).Security(ticker).During(portfolio.NewRange(portfolio.NewDate(year, 1, 1), portfolio.NewDate(year, 12, 31)))
+		for _, tx := range buys.All() {
+			bought = append(bought, tx.(portfolio.Buy))
+		}
+		for i := len(bought) - 1; i >= 0 && remaining.IsPositive(); i-- {
+			b := bought[i]
+			q := b.Quantity
+			if q.GreaterThan(remaining) {
+				q = remaining
+			}
+			remaining = remaining.Sub(q)
+			price := b.Amount.Div(b.Quantity)
+			months := 12 - int(b.When().Month()-1)
+			basis = basis.Add(price.Mul(q).Mul(factor).Mul(portfolio.Q(months)).Div(portfolio.Q(12)))
+			increase = increase.Add(endPrice.Sub(price).Mul(q))
+		}
+		if remaining.IsPositive() {
+			basis = basis.Add(startPrice.Mul(remaining).Mul(factor))
+			increase = increase.Add(endPrice.Sub(startPrice).Mul(remaining))
+		}
+
+		distributions := portfolio.M(0, endPrice.Currency())
+		for _, d := range dividends {
+			if d.Security == ticker {
+				distributions = distributions.Add(d.Amount)
+			}
+		}
+
+		vp := basis
+		if ceiling := increase.Add(distributions); ceiling.LessThan(vp) {
+			vp = ceiling
+		}
+		vp = vp.Sub(distributions)
+		if !vp.IsPositive() {
+			continue
+		}
+		converted, err := convert(ledger, endDate, vp)
+		if err != nil {
+			return portfolio.Money{}, err
+		}
+		total = total.Add(j.taxable(ticker, converted))
+	}
+	return total, nil
+}
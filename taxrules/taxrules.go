@@ -0,0 +1,60 @@
+// Package taxrules provides country-specific implementations of portfolio.TaxJurisdiction.
+//
+// Each jurisdiction estimates the taxes due on the capital gains and dividends of a
+// portfolio.TaxStatement. These are estimates meant to prepare year-end numbers,
+// not a substitute for the official tax computation.
+package taxrules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/etnz/portfolio"
+)
+
+// jurisdictions lists the built-in jurisdictions by name.
+var jurisdictions = map[string]func() portfolio.TaxJurisdiction{
+	"de": func() portfolio.TaxJurisdiction { return NewGermany() },
+	"fr": func() portfolio.TaxJurisdiction { return NewFrance() },
+}
+
+// Names returns the names of the built-in jurisdictions, sorted.
+func Names() []string {
+	names := make([]string, 0, len(jurisdictions))
+	for name := range jurisdictions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns a new instance of the built-in jurisdiction with this name.
+func Lookup(name string) (portfolio.TaxJurisdiction, error) {
+	j, ok := jurisdictions[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown tax jurisdiction %q, valid jurisdictions are %s", name, strings.Join(Names(), ", "))
+	}
+	return j(), nil
+}
+
+// convert converts an amount into the ledger's reporting currency at the exchange
+// rate of a given day.
+func convert(ledger *portfolio.Ledger, on portfolio.Date, amount portfolio.Money) (portfolio.Money, error) {
+	s := ledger.NewSnapshot(on)
+	converted := s.Convert(amount)
+	if converted.IsZero() && !amount.IsZero() {
+		return converted, fmt.Errorf("missing %s exchange rate on %s", amount.Currency(), on)
+	}
+	return converted, nil
+}
+
+// zero returns zero in the ledger's reporting currency.
+func zero(ledger *portfolio.Ledger) portfolio.Money {
+	return portfolio.M(0, ledger.NewSnapshot(portfolio.Today()).ReportingCurrency())
+}
+
+// percent returns p as a ratio.
+func percent(p portfolio.Percent) portfolio.Quantity {
+	return portfolio.Q(float64(p)).Div(portfolio.Q(100))
+}
@@ -0,0 +1,137 @@
+package taxrules
+
+import (
+	"testing"
+
+	"github.com/etnz/portfolio"
+)
+
+var (
+	eur       = func(v float64) portfolio.Money { return portfolio.M(v, "EUR") }
+	q         = func(v float64) portfolio.Quantity { return portfolio.Q(v) }
+	d         = portfolio.NewDate
+	fundID, _ = portfolio.NewMSSI("IE00B4L5Y983", "XETR")
+)
+
+// newLedger returns a EUR ledger holding a fund bought in 2023, and partially sold in 2024.
+func newLedger(t *testing.T) *portfolio.Ledger {
+	t.Helper()
+	ledger := portfolio.NewLedger()
+	err := ledger.Append(
+		portfolio.NewInit(d(2023, 1, 1), "", "EUR"),
+		portfolio.NewDeclare(d(2023, 1, 1), "", "IWDA", fundID, "EUR"),
+		portfolio.NewDeposit(d(2023, 1, 1), "", eur(10000), ""),
+		portfolio.NewBuy(d(2023, 1, 10), "", "IWDA", q(100), eur(5000)),
+		portfolio.NewUpdatePrice(d(2023, 12, 29), "IWDA", eur(60)),
+		portfolio.NewDividend(d(2024, 4, 1), "", "IWDA", eur(0.5)),
+		portfolio.NewSell(d(2024, 6, 1), "", "IWDA", q(50), eur(3500)),
+		portfolio.NewUpdatePrice(d(2024, 12, 30), "IWDA", eur(80)),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	return ledger
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range Names() {
+		j, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error = %v", name, err)
+		}
+		if j.Name() != name {
+			t.Errorf("Lookup(%q).Name() = %q", name, j.Name())
+		}
+	}
+	if _, err := Lookup("xx"); err == nil {
+		t.Errorf("Lookup(xx) succeeded, want error")
+	}
+}
+
+func TestFrance(t *testing.T) {
+	_, got, err := portfolio.AssessTaxes(newLedger(t), 2024, NewFrance())
+	if err != nil {
+		t.Fatalf("AssessTaxes() error = %v", err)
+	}
+	// Average cost is 50, 50 shares sold at 70: 1000 gain, 100 shares * 0.5 = 50 of dividends.
+	want := []portfolio.TaxAssessment{
+		{Label: "Net capital gains (PFU)", Base: eur(1000), Rate: 30, Tax: eur(300)},
+		{Label: "Dividends (PFU)", Base: eur(50), Rate: 30, Tax: eur(15)},
+	}
+	assertAssessments(t, got, want)
+}
+
+// TestFrance_SecondYear checks the gains on the rest of a position, sold the
+// year after a partial sale: the weighted average cost is unchanged by a sale.
+func TestFrance_SecondYear(t *testing.T) {
+	ledger := portfolio.NewLedger()
+	err := ledger.Append(
+		portfolio.NewInit(d(2023, 1, 1), "", "EUR"),
+		portfolio.NewDeclare(d(2023, 1, 1), "", "IWDA", fundID, "EUR"),
+		portfolio.NewDeposit(d(2023, 1, 1), "", eur(20000), ""),
+		portfolio.NewBuy(d(2023, 1, 10), "", "IWDA", q(100), eur(5000)),
+		portfolio.NewBuy(d(2024, 2, 1), "", "IWDA", q(100), eur(7000)),
+		portfolio.NewSell(d(2024, 6, 1), "", "IWDA", q(100), eur(8000)),
+		portfolio.NewSell(d(2025, 3, 1), "", "IWDA", q(100), eur(9000)),
+	)
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	// Average cost is 60: 100 shares sold at 80 in 2024, and at 90 in 2025.
+	for year, gain := range map[int]float64{2024: 2000, 2025: 3000} {
+		_, got, err := portfolio.AssessTaxes(ledger, year, NewFrance())
+		if err != nil {
+			t.Fatalf("AssessTaxes(%d) error = %v", year, err)
+		}
+		want := []portfolio.TaxAssessment{
+			{Label: "Net capital gains (PFU)", Base: eur(gain), Rate: 30, Tax: eur(gain * 0.3)},
+			{Label: "Dividends (PFU)", Base: eur(0), Rate: 30, Tax: eur(0)},
+		}
+		assertAssessments(t, got, want)
+	}
+}
+
+func TestGermany(t *testing.T) {
+	j := NewGermany()
+	j.Funds = []string{"IWDA"}
+	_, got, err := portfolio.AssessTaxes(newLedger(t), 2024, j)
+	if err != nil {
+		t.Fatalf("AssessTaxes() error = %v", err)
+	}
+	// Gains: 1000 * 70% = 700, dividends 50 * 70% = 35.
+	// Vorabpauschale: 50 shares * 60 * 2.29% * 70% = 48.09, below the increase (1000 + 50),
+	// minus 100 shares * 0.5 = 50 of distributions: none.
+	want := []portfolio.TaxAssessment{
+		{Label: "Capital gains", Base: eur(700), Tax: eur(0)},
+		{Label: "Dividends", Base: eur(35), Tax: eur(0)},
+		{Label: "Sparerpauschbetrag", Base: eur(-735), Tax: eur(0)},
+		{Label: "Abgeltungsteuer and Soli", Base: eur(0), Rate: 26.375, Tax: eur(0)},
+		{Label: "Vorabpauschale 2024 (taxed on 2025-01-02)", Base: eur(0), Rate: 26.375, Tax: eur(0)},
+	}
+	assertAssessments(t, got, want)
+
+	// With a higher Basiszins, the base return exceeds the distributions.
+	j.BaseRates[2024] = 5
+	_, got, err = portfolio.AssessTaxes(newLedger(t), 2024, j)
+	if err != nil {
+		t.Fatalf("AssessTaxes() error = %v", err)
+	}
+	// 50 shares * 60 * 5% * 70% = 105, minus 50 of distributions = 55, taxable 38.5.
+	vp := got[len(got)-1]
+	if !vp.Base.Equal(eur(38.5)) {
+		t.Errorf("Vorabpauschale = %v, want %v", vp.Base, eur(38.5))
+	}
+}
+
+func assertAssessments(t *testing.T, got, want []portfolio.TaxAssessment) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d assessments, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Label != w.Label || !g.Base.Equal(w.Base) || !g.Rate.Equal(w.Rate) || !g.Tax.Equal(w.Tax) {
+			t.Errorf("assessment[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+}
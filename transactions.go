@@ -1,13 +1,17 @@
 package portfolio
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
 	"maps"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/etnz/portfolio/migrations"
 	"github.com/shopspring/decimal"
 )
 
@@ -27,6 +31,18 @@ const (
 	CmdDeclare     CommandType = "declare"
 	CmdUpdatePrice CommandType = "update-price"
 	CmdSplit       CommandType = "split"
+	CmdExpire      CommandType = "expire"
+	CmdAssign      CommandType = "assign"
+	CmdLend        CommandType = "lend"
+	CmdRecall      CommandType = "recall"
+	CmdLendingFee  CommandType = "lending-fee"
+	CmdGrant       CommandType = "grant"
+	CmdVest        CommandType = "vest"
+	CmdNote        CommandType = "note"
+
+	CmdSetPriceAlert   CommandType = "set-price-alert"
+	CmdClearPriceAlert CommandType = "clear-price-alert"
+	CmdDeclareCurrency CommandType = "declare-currency"
 )
 
 // Transaction defines the common interface for all types of financial transactions
@@ -36,12 +52,14 @@ type Transaction interface {
 	When() Date        // When returns the date on which the transaction occurred.
 	Equal(Transaction) bool
 	Validate(ledger *Ledger) (Transaction, error)
+	Tags() Tags // Tags returns the tags of the memo, see ParseTags.
 }
 
 type baseCmd struct {
 	Command CommandType `json:"command"`        // Command specifies the type of transaction (e.g., "buy", "sell").
 	Date    Date        `json:"date"`           // Date is the date when the transaction took place.
 	Memo    string      `json:"memo,omitempty"` // Memo provides an optional rationale or note for the transaction.
+	Key     string      `json:"key,omitempty"`  // Key is an optional identifier supplied by importers to recognize a transaction already recorded.
 }
 
 // What returns the command name for the transaction, which is used to identify the type of transaction.
@@ -59,12 +77,19 @@ func (t baseCmd) Rationale() string {
 	return t.Memo
 }
 
+// DedupKey returns the key identifying the transaction among the transactions
+// of a ledger, if any.
+func (t baseCmd) DedupKey() string {
+	return t.Key
+}
+
 // MarshalJSON implements the json.Marshaler interface for baseCmd.
 func (t baseCmd) MarshalJSON() ([]byte, error) {
 	var w jsonObjectWriter
 	w.Append("command", t.Command)
 	w.Append("date", t.Date)
 	w.Optional("memo", t.Memo)
+	w.Optional("key", t.Key)
 	return w.MarshalJSON()
 }
 
@@ -95,7 +120,11 @@ func (t *secCmd) Validate(ledger *Ledger) error {
 	// use ticker to resolve the ledger security
 	ledgerSec := ledger.Security(t.Security)
 	if ledgerSec == nil {
-		return fmt.Errorf("security %q not declared in ledger", t.Security)
+		return withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared in ledger", t.Security))
+	}
+	// Splits are market data, the other commands change the position.
+	if ledgerSec.Watched() && t.Command != CmdSplit {
+		return fmt.Errorf("security %q is on the watchlist, it cannot be held", t.Security)
 	}
 
 	return nil
@@ -116,6 +145,9 @@ type Buy struct {
 	secCmd
 	Quantity Quantity // Quantity is the number of shares or units bought.
 	Amount   Money    // Amount is the total cost of the purchase.
+	Account  string   // Account is the cash account paying the purchase, the default account if empty.
+	Venue    string   // Venue is the broker or the exchange of the trade, if known.
+	Strategy string   // Strategy is the investment strategy of the trade, e.g. "dividend" or "momentum", if any.
 }
 
 // NewBuy creates a new Buy transaction.
@@ -133,6 +165,9 @@ func (t Buy) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.secCmd)
 	w.Append("quantity", t.Quantity)
 	w.EmbedFrom(t.Amount)
+	w.Optional("account", t.Account)
+	w.Optional("venue", t.Venue)
+	w.Optional("strategy", t.Strategy)
 	return w.MarshalJSON()
 }
 
@@ -144,6 +179,9 @@ func (t *Buy) UnmarshalJSON(data []byte) error {
 		secCmd
 		amountCmd
 		Quantity Quantity `json:"quantity"`
+		Account  string   `json:"account,omitempty"`
+		Venue    string   `json:"venue,omitempty"`
+		Strategy string   `json:"strategy,omitempty"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -152,12 +190,15 @@ func (t *Buy) UnmarshalJSON(data []byte) error {
 	t.secCmd = temp.secCmd
 	t.Quantity = temp.Quantity
 	t.Amount = temp.Money()
+	t.Account = temp.Account
+	t.Venue = temp.Venue
+	t.Strategy = temp.Strategy
 	return nil
 }
 
 func (t Buy) Equal(other Transaction) bool {
 	o, ok := other.(Buy)
-	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity) && t.Amount.Equal(o.Amount)
+	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity) && t.Amount.Equal(o.Amount) && t.Account == o.Account && t.Venue == o.Venue && t.Strategy == o.Strategy
 }
 
 func (t *Buy) Currency() string { return t.Amount.Currency() }
@@ -179,6 +220,9 @@ func (t Buy) Validate(ledger *Ledger) (Transaction, error) {
 	}
 
 	ledgerSec := ledger.Security(t.Security) // We know this is not nil from secCmd.Validate
+	if err := ledgerSec.ValidateQuantity(t.Quantity); err != nil {
+		return t, fmt.Errorf("invalid buy transaction: %w", err)
+	}
 	currency := ledgerSec.Currency()
 	// first the quick fix
 	if t.Currency() == "" {
@@ -187,9 +231,20 @@ func (t Buy) Validate(ledger *Ledger) (Transaction, error) {
 		return t, fmt.Errorf("buy transaction currency %s does not match security currency %s", t.Currency(), currency)
 	}
 
-	cash, cost := ledger.CashBalance(t.Currency(), t.Date), t.Amount
-	if cash.LessThan(cost) {
-		return t, fmt.Errorf("on %s, cannot buy for %s cash balance is %s", t.When(), cost, cash)
+	// Buying back a short option position cannot exceed it.
+	if pos := ledger.Position(t.When(), t.Security); pos.IsNegative() && t.Quantity.GreaterThan(pos.Neg()) {
+		return t, fmt.Errorf("on %s, cannot buy %v of %s to close, short position is only %v", t.When(), t.Quantity, t.Security, pos.Neg())
+	}
+
+	// The purchase is paid on its settlement date, with the cash settled by then,
+	// including the proceeds of sells on the same day.
+	settles := ledger.SettlementDate(t.Date)
+	cash, cost := ledger.NewSnapshot(settles).cash(cashAccount(t.Account), t.Currency(), true), t.Amount
+	if limit := ledger.overdraftLimit(t.Currency()); cash.Add(limit).LessThan(cost) {
+		if settles != t.Date {
+			return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot buy for %s settled cash balance%s on %s is %s%s", t.When(), cost, accountNote(t.Account), settles, cash, overdraftNote(limit)))
+		}
+		return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot buy for %s cash balance%s is %s%s", t.When(), cost, accountNote(t.Account), cash, overdraftNote(limit)))
 	}
 	return t, nil
 }
@@ -201,6 +256,9 @@ type Sell struct {
 	secCmd
 	Quantity Quantity // Quantity is the number of shares or units sold.
 	Amount   Money    // Amount is the total proceeds from the sale.
+	Account  string   // Account is the cash account receiving the proceeds, the default account if empty.
+	Venue    string   // Venue is the broker or the exchange of the trade, if known.
+	Strategy string   // Strategy is the investment strategy of the trade, e.g. "dividend" or "momentum", if any.
 }
 
 // MarshalJSON implements the json.Marshaler interface for Sell.
@@ -209,6 +267,9 @@ func (t Sell) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.secCmd)
 	w.Append("quantity", t.Quantity)
 	w.EmbedFrom(t.Amount)
+	w.Optional("account", t.Account)
+	w.Optional("venue", t.Venue)
+	w.Optional("strategy", t.Strategy)
 	return w.MarshalJSON()
 }
 
@@ -220,6 +281,9 @@ func (t *Sell) UnmarshalJSON(data []byte) error {
 		secCmd
 		amountCmd
 		Quantity Quantity `json:"quantity"`
+		Account  string   `json:"account,omitempty"`
+		Venue    string   `json:"venue,omitempty"`
+		Strategy string   `json:"strategy,omitempty"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -228,12 +292,15 @@ func (t *Sell) UnmarshalJSON(data []byte) error {
 	t.secCmd = temp.secCmd
 	t.Quantity = temp.Quantity
 	t.Amount = temp.Money()
+	t.Account = temp.Account
+	t.Venue = temp.Venue
+	t.Strategy = temp.Strategy
 	return nil
 }
 
 func (t Sell) Equal(other Transaction) bool {
 	o, ok := other.(Sell)
-	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity) && t.Amount.Equal(o.Amount)
+	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity) && t.Amount.Equal(o.Amount) && t.Account == o.Account && t.Venue == o.Venue && t.Strategy == o.Strategy
 }
 
 // NewSell creates a new Sell transaction.
@@ -269,7 +336,7 @@ func (t Sell) Validate(ledger *Ledger) (Transaction, error) {
 	} else if currency != t.Currency() {
 		return t, fmt.Errorf("sell transaction currency %s does not match security currency %s", t.Currency(), currency)
 	}
-	if !t.Amount.IsPositive() {
+	if t.Amount.IsNegative() {
 		return t, fmt.Errorf("sell transaction amount must be positive, got %v", t.Amount)
 	}
 
@@ -282,25 +349,67 @@ func (t Sell) Validate(ledger *Ledger) (Transaction, error) {
 	if !t.Quantity.IsPositive() {
 		return t, fmt.Errorf("sell transaction quantity must be positive, got %s", t.Quantity.String())
 	}
+	// Closing a position is always possible, even for no proceeds (e.g. to
+	// dispose of a worthless or dust position).
+	closing := t.Quantity.Equal(pos)
+	if t.Amount.IsZero() && !closing {
+		return t, fmt.Errorf("sell transaction amount must be positive, got %v", t.Amount)
+	}
+	if err := ledgerSec.ValidateQuantity(t.Quantity); err != nil && !closing {
+		return t, fmt.Errorf("invalid sell transaction: %w", err)
+	}
 
+	// Options can be sold to open a short position, but a single sale cannot
+	// both close a long position and open a short one.
+	if ledgerSec.ID().IsOption() && !pos.IsPositive() {
+		return t, nil
+	}
 	if pos.LessThan(t.Quantity) {
 		return t, fmt.Errorf("on %s, cannot sell %v of %s, position is only %v", t.When(), t.Quantity, t.Security, pos)
 	}
+	if held := pos.Sub(ledger.Lent(t.When(), t.Security)); held.LessThan(t.Quantity) {
+		return t, fmt.Errorf("on %s, cannot sell %v of %s, only %v held, recall the lent shares first", t.When(), t.Quantity, t.Security, held)
+	}
 
 	return t, nil
 }
 
 // --- Declare Command ---
 
+// maxPrecision is the maximum number of decimals a security can declare for its quantities.
+const maxPrecision = 12
+
 // Declare represents a transaction to declare a security for use in the ledger.
 // This maps a ledger-internal ticker to a globally unique security ID and its currency.
 // Declare represents a transaction to declare a security for use in the ledger.
 // This maps a ledger-internal ticker to a globally unique security ID and its currency.
+//
+// Precision optionally sets the number of decimals allowed in quantities of the
+// security (e.g. 6 for funds, 0 for stocks that cannot be fractioned).
+//
+// TER optionally sets the total expense ratio of a fund: its annual fees, in
+// percent of its value.
+//
+// Watch declares a security of the watchlist: its market data is fetched, but
+// it cannot be held.
+//
+// Scores optionally attach named numeric scores to the security, e.g. an ESG
+// rating or a personal conviction.
+//
+// CashEquivalent declares a security held as cash, like a money-market fund:
+// allocation reports count it with the cash, while its yield is still tracked
+// like the one of any other security.
 type Declare struct {
 	baseCmd
-	Ticker   string `json:"ticker"`
-	ID       ID     `json:"id"`
-	Currency string `json:"currency"`
+	Ticker    string             `json:"ticker"`
+	ID        ID                 `json:"id"`
+	Currency  string             `json:"currency"`
+	Precision *int               `json:"precision,omitempty"`
+	TER       Percent            `json:"ter,omitempty"`
+	Watch     bool               `json:"watch,omitempty"`
+	Scores    map[string]float64 `json:"scores,omitempty"`
+
+	CashEquivalent bool `json:"cash-equivalent,omitempty"`
 }
 
 // NewDeclare creates a new Declare transaction.
@@ -320,12 +429,18 @@ func (t Declare) MarshalJSON() ([]byte, error) {
 	w.Append("ticker", t.Ticker)
 	w.Append("id", t.ID)
 	w.Append("currency", t.Currency)
+	w.Optional("precision", t.Precision)
+	w.Optional("ter", t.TER)
+	w.Optional("watch", t.Watch)
+	w.Optional("scores", t.Scores)
+	w.Optional("cash-equivalent", t.CashEquivalent)
 	return w.MarshalJSON()
 }
 
 func (t Declare) Equal(other Transaction) bool {
 	o, ok := other.(Declare)
-	return ok && t.baseCmd == o.baseCmd && t.Ticker == o.Ticker && t.ID == o.ID && t.Currency == o.Currency
+	samePrecision := (t.Precision == nil) == (o.Precision == nil) && (t.Precision == nil || *t.Precision == *o.Precision)
+	return ok && t.baseCmd == o.baseCmd && t.Ticker == o.Ticker && t.ID == o.ID && t.Currency == o.Currency && samePrecision && t.TER == o.TER && t.Watch == o.Watch && maps.Equal(t.Scores, o.Scores) && t.CashEquivalent == o.CashEquivalent
 }
 
 // Validate checks the Declare transaction's fields.
@@ -344,12 +459,115 @@ func (t Declare) Validate(ledger *Ledger) (Transaction, error) {
 	if err := ValidateCurrency(t.Currency); err != nil {
 		return t, fmt.Errorf("invalid currency for declaration: %w", err)
 	}
+	if t.Precision != nil && (*t.Precision < 0 || *t.Precision > maxPrecision) {
+		return t, fmt.Errorf("invalid precision %d for declaration, must be between 0 and %d", *t.Precision, maxPrecision)
+	}
+	if t.TER < 0 || t.TER >= 100 {
+		return t, fmt.Errorf("invalid expense ratio %s for declaration, must be between 0%% and 100%%", t.TER)
+	}
+	for name := range t.Scores {
+		if name == "" {
+			return t, errors.New("declaration score name is missing")
+		}
+	}
 
 	ledgerSec := ledger.Security(t.Ticker)
 	if ledgerSec != nil {
 		return t, fmt.Errorf("security %q already declared in ledger", t.Ticker)
 	}
 
+	if t.CashEquivalent && t.Watch {
+		return t, fmt.Errorf("security %q on the watchlist cannot be a cash equivalent, it cannot be held", t.Ticker)
+	}
+
+	if t.ID.IsCurrencyPair() {
+		// Quick fix: a currency pair is always priced in its quote currency.
+		t.Currency = t.ID.Quote()
+		if err := validateCurrencyPair(ledger, t); err != nil {
+			return t, err
+		}
+	}
+
+	if c, err := t.ID.Option(); err == nil {
+		underlying := ledger.Security(c.Underlying)
+		if underlying == nil {
+			return t, withKind(ErrUnknownSecurity, fmt.Errorf("underlying %q of option %q is not declared", c.Underlying, t.Ticker))
+		}
+		if underlying.Currency() != t.Currency {
+			return t, fmt.Errorf("option %q currency %s does not match underlying currency %s", t.Ticker, t.Currency, underlying.Currency())
+		}
+	}
+
+	return t, nil
+}
+
+// --- Declare Currency Command ---
+
+// DeclareCurrency represents a transaction to declare a currency that is not
+// an ISO 4217 currency, like loyalty points or a company scrip, so that it can
+// be used by the transactions of the ledger.
+//
+// Symbol is the symbol of the amounts in reports, the code by default. Decimals
+// is the number of decimals of the amounts.
+type DeclareCurrency struct {
+	baseCmd
+	Code     string `json:"code"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals int    `json:"decimals"`
+}
+
+// NewDeclareCurrency creates a new DeclareCurrency transaction.
+func NewDeclareCurrency(day Date, memo, code, symbol string, decimals int) DeclareCurrency {
+	return DeclareCurrency{
+		baseCmd:  baseCmd{Command: CmdDeclareCurrency, Date: day, Memo: memo},
+		Code:     code,
+		Symbol:   symbol,
+		Decimals: decimals,
+	}
+}
+
+// Currency returns the currency declared.
+func (t DeclareCurrency) Currency() Currency {
+	return Currency{Code: t.Code, Symbol: cmp.Or(t.Symbol, t.Code), Decimals: t.Decimals}
+}
+
+// MarshalJSON implements the json.Marshaler interface for DeclareCurrency.
+func (t DeclareCurrency) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.baseCmd)
+	w.Append("code", t.Code)
+	w.Optional("symbol", t.Symbol)
+	w.Append("decimals", t.Decimals)
+	return w.MarshalJSON()
+}
+
+func (t DeclareCurrency) Equal(other Transaction) bool {
+	o, ok := other.(DeclareCurrency)
+	return ok && t == o
+}
+
+// Validate checks the DeclareCurrency transaction's fields. It ensures the code
+// is neither an ISO 4217 currency nor a currency already declared differently.
+func (t DeclareCurrency) Validate(ledger *Ledger) (Transaction, error) {
+	t.baseCmd.Validate()
+	if !currencyCodeRegex.MatchString(t.Code) {
+		return t, fmt.Errorf("invalid currency code %q, must be 3 uppercase letters", t.Code)
+	}
+	if t.Decimals < 0 || t.Decimals > maxPrecision {
+		return t, fmt.Errorf("invalid decimals %d for currency %q, must be between 0 and %d", t.Decimals, t.Code, maxPrecision)
+	}
+	for _, tx := range ledger.Query().Command(CmdDeclareCurrency).All() {
+		if tx.(DeclareCurrency).Code == t.Code {
+			return t, fmt.Errorf("currency %q already declared in ledger", t.Code)
+		}
+	}
+	if prev, ok := DeclaredCurrency(t.Code); ok {
+		if prev != t.Currency() {
+			return t, fmt.Errorf("currency %q is already declared by another ledger with symbol %q and %d decimals", t.Code, prev.Symbol, prev.Decimals)
+		}
+	} else if knownCurrency(t.Code) {
+		return t, fmt.Errorf("currency %q is an ISO 4217 currency, it cannot be declared", t.Code)
+	}
 	return t, nil
 }
 
@@ -357,28 +575,60 @@ func (t Declare) Validate(ledger *Ledger) (Transaction, error) {
 
 // Init represents the initialization of the ledger.
 // It sets the base currency for the ledger. It has a date and must be the first transaction.
+// It also declares the overdrafts allowed, if any, and records the schema
+// version of the ledger file.
 type Init struct {
 	baseCmd
-	Currency string `json:"currency"`
+	Currency   string      `json:"currency"`
+	Overdrafts []Overdraft `json:"overdrafts,omitempty"`
+	// TimeZone is the IANA name of the time zone of the trading day of the
+	// ledger, e.g. "America/New_York", see SetTimeZone. Empty for the local
+	// time zone.
+	TimeZone string `json:"timezone,omitempty"`
+	// Version is the schema version of the lines of the ledger file, zero for
+	// the files written before it was recorded, see package migrations.
+	Version int `json:"version,omitempty"`
 }
 
-// NewInit creates a new Init transaction.
+// SchemaVersion is the schema version of the ledger files written by pcs.
+const SchemaVersion = migrations.Current
+
+// NewInit creates a new Init transaction, of the current schema version.
 func NewInit(date Date, memo string, currency string) Init {
 	return Init{
 		baseCmd:  baseCmd{Command: CmdInit, Date: date, Memo: memo},
 		Currency: currency,
+		Version:  SchemaVersion,
 	}
 }
 
 func (t Init) Equal(other Transaction) bool {
 	o, ok := other.(Init)
-	return ok && t.baseCmd == o.baseCmd && t.Currency == o.Currency
+	return ok && t.baseCmd == o.baseCmd && t.Currency == o.Currency && t.TimeZone == o.TimeZone && t.Version == o.Version && slices.EqualFunc(t.Overdrafts, o.Overdrafts, func(a, b Overdraft) bool {
+		return a.Currency == b.Currency && a.Limit.Equal(b.Limit) && a.Rate.Equal(b.Rate)
+	})
 }
 
 func (t Init) Validate(ledger *Ledger) (Transaction, error) {
 	if err := ValidateCurrency(t.Currency); err != nil {
 		return t, fmt.Errorf("invalid currency for init: %w", err)
 	}
+	for i, o := range t.Overdrafts {
+		if err := ValidateCurrency(o.Currency); err != nil {
+			return t, fmt.Errorf("invalid currency for overdraft: %w", err)
+		}
+		if o.Limit.IsNegative() || o.Rate < 0 {
+			return t, fmt.Errorf("overdraft limit and rate must not be negative, got %s and %v in %s", o.Limit, o.Rate, o.Currency)
+		}
+		if slices.ContainsFunc(t.Overdrafts[:i], func(p Overdraft) bool { return p.Currency == o.Currency }) {
+			return t, fmt.Errorf("overdraft declared twice in %s", o.Currency)
+		}
+	}
+	if t.TimeZone != "" {
+		if _, err := time.LoadLocation(t.TimeZone); err != nil {
+			return t, fmt.Errorf("invalid time zone %q, want an IANA name like America/New_York: %w", t.TimeZone, err)
+		}
+	}
 
 	if len(ledger.transactions) > 0 {
 		// Case 1: Ledger is not empty.
@@ -393,6 +643,12 @@ func (t Init) Validate(ledger *Ledger) (Transaction, error) {
 			if t.Memo != "" {
 				existingInit.Memo = t.Memo
 			}
+			if t.Overdrafts != nil {
+				existingInit.Overdrafts = t.Overdrafts
+			}
+			if t.TimeZone != "" {
+				existingInit.TimeZone = t.TimeZone
+			}
 			return existingInit, nil
 		}
 
@@ -418,6 +674,9 @@ func (t Init) MarshalJSON() ([]byte, error) {
 	var w jsonObjectWriter
 	w.EmbedFrom(t.baseCmd)
 	w.Append("currency", t.Currency)
+	w.Optional("overdrafts", t.Overdrafts)
+	w.Optional("timezone", t.TimeZone)
+	w.Optional("version", t.Version)
 	return w.MarshalJSON()
 }
 
@@ -428,7 +687,8 @@ func (t Init) MarshalJSON() ([]byte, error) {
 // for a held security.
 type Dividend struct {
 	secCmd
-	Amount Money // Amount is the dividend paid per share.
+	Amount Money  // Amount is the dividend paid per share.
+	Source string // Source is the market data provider of the dividend, empty if recorded manually.
 }
 
 // NewDividend creates a new Dividend transaction.
@@ -446,6 +706,7 @@ func (t Dividend) MarshalJSON() ([]byte, error) {
 	// by default money is persisted in its minor unit.
 	// so we must call exact() to persist the dps.
 	w.EmbedFrom(t.Amount.exact())
+	w.Optional("source", t.Source)
 	return w.MarshalJSON()
 }
 
@@ -455,6 +716,7 @@ func (t *Dividend) UnmarshalJSON(data []byte) error {
 	var temp struct {
 		secCmd
 		amountCmd
+		Source string `json:"source"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -462,12 +724,13 @@ func (t *Dividend) UnmarshalJSON(data []byte) error {
 	// Create the final transaction struct
 	t.secCmd = temp.secCmd
 	t.Amount = temp.Money()
+	t.Source = temp.Source
 	return nil
 }
 
 func (t Dividend) Equal(other Transaction) bool {
 	o, ok := other.(Dividend)
-	return ok && t.secCmd == o.secCmd && t.Amount.Equal(o.Amount)
+	return ok && t.secCmd == o.secCmd && t.Amount.Equal(o.Amount) && t.Source == o.Source
 }
 
 // Validate checks the Dividend transaction's fields. It ensures the dividend
@@ -500,8 +763,21 @@ type Deposit struct {
 	baseCmd
 	Amount  Money  // Amount is the quantity of cash deposited.
 	Settles string // Settles is an optional counterparty account that this deposit settles.
+	Account string // Account is the cash account credited, the default account if empty.
+	Source  string // Source is the optional origin of the cash, one of DepositSources.
 }
 
+// The sources of deposits, to tell savings from windfalls in contributions.
+const (
+	SourceSalary    = "salary"
+	SourceBonus     = "bonus"
+	SourceGift      = "gift"
+	SourceTransfert = "transfert"
+)
+
+// DepositSources lists the valid sources of a deposit.
+var DepositSources = []string{SourceSalary, SourceBonus, SourceGift, SourceTransfert}
+
 func (t Deposit) Currency() string {
 	return t.Amount.Currency()
 }
@@ -512,6 +788,8 @@ func (t Deposit) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.baseCmd)
 	w.EmbedFrom(t.Amount)
 	w.Optional("settles", t.Settles)
+	w.Optional("account", t.Account)
+	w.Optional("source", t.Source)
 	return w.MarshalJSON()
 }
 
@@ -522,6 +800,8 @@ func (t *Deposit) UnmarshalJSON(data []byte) error {
 		baseCmd
 		amountCmd
 		Settles string `json:"settles,omitempty"`
+		Account string `json:"account,omitempty"`
+		Source  string `json:"source,omitempty"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -529,12 +809,14 @@ func (t *Deposit) UnmarshalJSON(data []byte) error {
 	t.baseCmd = temp.baseCmd
 	t.Amount = temp.Money()
 	t.Settles = temp.Settles
+	t.Account = temp.Account
+	t.Source = temp.Source
 	return nil
 }
 
 func (t Deposit) Equal(other Transaction) bool {
 	o, ok := other.(Deposit)
-	return ok && t.baseCmd == o.baseCmd && t.Amount.Equal(o.Amount) && t.Settles == o.Settles
+	return ok && t.baseCmd == o.baseCmd && t.Amount.Equal(o.Amount) && t.Settles == o.Settles && t.Account == o.Account && t.Source == o.Source
 }
 
 // NewDeposit creates a new Deposit transaction.
@@ -557,6 +839,9 @@ func (t Deposit) Validate(ledger *Ledger) (Transaction, error) {
 	if err := ValidateCurrency(t.Amount.Currency()); err != nil {
 		return t, fmt.Errorf("invalid currency for deposit: %w", err)
 	}
+	if t.Source != "" && !slices.Contains(DepositSources, t.Source) {
+		return t, fmt.Errorf("invalid deposit source %q, expected one of %s", t.Source, strings.Join(DepositSources, ", "))
+	}
 
 	if t.Settles != "" {
 		cur, exists := ledger.CounterPartyCurrency(t.Settles)
@@ -577,6 +862,7 @@ type Withdraw struct {
 	baseCmd
 	Amount  Money  // Amount is the quantity of cash withdrawn.
 	Settles string // Settles is an optional counterparty account that this withdrawal settles.
+	Account string // Account is the cash account debited, the default account if empty.
 }
 
 // MarshalJSON implements the json.Marshaler interface for Withdraw.
@@ -585,6 +871,7 @@ func (t Withdraw) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.baseCmd)
 	w.EmbedFrom(t.Amount)
 	w.Optional("settles", t.Settles)
+	w.Optional("account", t.Account)
 	return w.MarshalJSON()
 }
 
@@ -595,6 +882,7 @@ func (t *Withdraw) UnmarshalJSON(data []byte) error {
 		baseCmd
 		amountCmd
 		Settles string `json:"settles,omitempty"`
+		Account string `json:"account,omitempty"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -602,12 +890,13 @@ func (t *Withdraw) UnmarshalJSON(data []byte) error {
 	t.baseCmd = temp.baseCmd
 	t.Amount = temp.Money()
 	t.Settles = temp.Settles
+	t.Account = temp.Account
 	return nil
 }
 
 func (t Withdraw) Equal(other Transaction) bool {
 	o, ok := other.(Withdraw)
-	return ok && t.baseCmd == o.baseCmd && t.Amount.Equal(o.Amount) && t.Settles == o.Settles
+	return ok && t.baseCmd == o.baseCmd && t.Amount.Equal(o.Amount) && t.Settles == o.Settles && t.Account == o.Account
 }
 
 // NewWithdraw creates a new Withdraw transaction.
@@ -631,16 +920,16 @@ func (t Withdraw) Validate(ledger *Ledger) (Transaction, error) {
 	}
 
 	if t.Amount.IsZero() {
-		t.Amount = ledger.CashBalance(t.Amount.Currency(), t.Date)
+		t.Amount = ledger.availableCash(cashAccount(t.Account), t.Amount.Currency(), t.Date)
 	}
 
 	if !t.Amount.IsPositive() {
 		return t, fmt.Errorf("withdraw amount must be positive, got %s", t.Amount.String())
 	}
 
-	cash := ledger.CashBalance(t.Amount.Currency(), t.Date)
-	if cash.LessThan(t.Amount) {
-		return t, fmt.Errorf("on %s, cannot withdraw for %s cash balance is %s", t.When(), t.Amount.String(), cash.String())
+	cash := ledger.availableCash(cashAccount(t.Account), t.Amount.Currency(), t.Date)
+	if limit := ledger.overdraftLimit(t.Currency()); cash.Add(limit).LessThan(t.Amount) {
+		return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot withdraw for %s cash balance%s is %s%s", t.When(), t.Amount.String(), accountNote(t.Account), cash.String(), overdraftNote(limit)))
 	}
 	if t.Settles != "" {
 		accounts := slices.Collect(ledger.AllCounterpartyAccounts())
@@ -666,6 +955,9 @@ type Accrue struct {
 	Counterparty string // Counterparty is the name of the entity with whom the accrual is made.
 	Amount       Money  // Amount is the value of the accrual. Positive for receivables, negative for payables.
 	Create       bool   // Create is true if this accrual creates a new counterparty account.
+	// Rate is the annual interest rate of the counterparty account from the
+	// date of the accrual on, in percent. Zero leaves the rate unchanged.
+	Rate Percent
 }
 
 // MarshalJSON implements the json.Marshaler interface for Accrue.
@@ -674,6 +966,7 @@ func (t Accrue) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.baseCmd)
 	w.Append("counterparty", t.Counterparty)
 	w.Optional("create", t.Create)
+	w.Optional("rate", t.Rate)
 	w.EmbedFrom(t.Amount)
 	return w.MarshalJSON()
 }
@@ -684,8 +977,9 @@ func (t *Accrue) UnmarshalJSON(data []byte) error {
 	var temp struct {
 		baseCmd
 		amountCmd
-		Counterparty string `json:"counterparty"`
-		Create       bool   `json:"create,omitempty"`
+		Counterparty string  `json:"counterparty"`
+		Create       bool    `json:"create,omitempty"`
+		Rate         Percent `json:"rate,omitempty"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -694,12 +988,13 @@ func (t *Accrue) UnmarshalJSON(data []byte) error {
 	t.Amount = temp.Money()
 	t.Counterparty = temp.Counterparty
 	t.Create = temp.Create
+	t.Rate = temp.Rate
 	return nil
 }
 
 func (t Accrue) Equal(other Transaction) bool {
 	o, ok := other.(Accrue)
-	return ok && t.baseCmd == o.baseCmd && t.Counterparty == o.Counterparty && t.Amount.Equal(o.Amount) && t.Create == o.Create
+	return ok && t.baseCmd == o.baseCmd && t.Counterparty == o.Counterparty && t.Amount.Equal(o.Amount) && t.Create == o.Create && t.Rate == o.Rate
 }
 
 // NewAccrue creates a new Accrue transaction.
@@ -734,9 +1029,12 @@ func (t Accrue) Validate(ledger *Ledger) (Transaction, error) {
 	if t.Counterparty == "" {
 		return t, errors.New("accrue transaction counterparty is missing")
 	}
-	if t.Amount.IsZero() {
+	if t.Amount.IsZero() && t.Rate == 0 {
 		return t, errors.New("accrue transaction amount cannot be zero")
 	}
+	if t.Rate < 0 {
+		return t, fmt.Errorf("accrue transaction rate must not be negative, got %v", t.Rate)
+	}
 	if err := ValidateCurrency(t.Currency()); err != nil {
 		return t, fmt.Errorf("invalid currency for accrue: %w", err)
 	}
@@ -747,6 +1045,9 @@ func (t Accrue) Validate(ledger *Ledger) (Transaction, error) {
 	if !exists {
 		t.Create = true
 	}
+	if t.Create && t.Amount.IsZero() {
+		return t, fmt.Errorf("cannot set the rate of counterparty account %q before it is created by an accrual", t.Counterparty)
+	}
 
 	if !t.Create && currency != t.Currency() {
 		return t, fmt.Errorf("new accrue currency %s does not match counterparty account currency %s", t.Currency(), currency)
@@ -825,16 +1126,16 @@ func (t Convert) Validate(ledger *Ledger) (Transaction, error) {
 	}
 
 	if t.FromAmount.IsZero() && t.FromAmount.Currency() != "" {
-		t.FromAmount = ledger.CashBalance(t.FromCurrency(), t.Date)
+		t.FromAmount = ledger.availableCash(DefaultAccount, t.FromCurrency(), t.Date)
 	}
 	if !t.FromAmount.IsPositive() {
 		// fromAmount == 0 is interpreted as "convert all".
 		return t, fmt.Errorf("convert 'from' amount must be positive, got %v", t.FromAmount)
 	}
 
-	cash, cost := ledger.CashBalance(t.FromCurrency(), t.Date), t.FromAmount
-	if cash.LessThan(cost) {
-		return t, fmt.Errorf("on %s, cannot convert for %v cash balance is %v", t.When(), cost, cash)
+	cash, cost := ledger.availableCash(DefaultAccount, t.FromCurrency(), t.Date), t.FromAmount
+	if limit := ledger.overdraftLimit(t.FromCurrency()); cash.Add(limit).LessThan(cost) {
+		return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot convert for %v cash balance is %v%s", t.When(), cost, cash, overdraftNote(limit)))
 	}
 
 	return t, nil
@@ -846,6 +1147,13 @@ func (t Convert) Validate(ledger *Ledger) (Transaction, error) {
 type UpdatePrice struct {
 	baseCmd
 	Prices map[string]decimal.Decimal
+	// Source is the market data provider of the prices, e.g. "eodhd", empty if
+	// they are recorded manually. Manual prices override the prices of the
+	// providers on the same day.
+	Source string
+	// Volumes are the numbers of shares traded on the day, by ticker, when the
+	// provider reports them, see PreferVolume.
+	Volumes map[string]int64
 }
 
 // NewUpdatePrice creates a new UpdatePrice transaction for a single security.
@@ -872,6 +1180,7 @@ func NewUpdatePrices(date Date, prices map[string]decimal.Decimal) UpdatePrice {
 func (t UpdatePrice) MarshalJSON() ([]byte, error) {
 	var w jsonObjectWriter
 	w.EmbedFrom(t.baseCmd)
+	w.Optional("source", t.Source)
 
 	// Custom marshaling for the 'prices' map to ensure stable key order.
 	var pricesObject jsonObjectWriter
@@ -887,6 +1196,9 @@ func (t UpdatePrice) MarshalJSON() ([]byte, error) {
 	w.WriteString(`"prices":`)
 	w.Write(pricesBytes)
 	w.WriteString(",")
+	if len(t.Volumes) > 0 {
+		w.Append("volumes", t.Volumes)
+	}
 
 	return w.MarshalJSON()
 }
@@ -895,13 +1207,17 @@ func (t UpdatePrice) MarshalJSON() ([]byte, error) {
 func (t *UpdatePrice) UnmarshalJSON(data []byte) error {
 	var temp struct {
 		baseCmd
-		Prices map[string]decimal.Decimal `json:"prices"`
+		Prices  map[string]decimal.Decimal `json:"prices"`
+		Source  string                     `json:"source"`
+		Volumes map[string]int64           `json:"volumes"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
 	}
 	t.baseCmd = temp.baseCmd
 	t.Prices = temp.Prices
+	t.Source = temp.Source
+	t.Volumes = temp.Volumes
 	return nil
 }
 
@@ -921,7 +1237,7 @@ func (t UpdatePrice) PricesIter() iter.Seq2[string, decimal.Decimal] {
 
 func (t UpdatePrice) Equal(other Transaction) bool {
 	o, ok := other.(UpdatePrice)
-	if !ok || t.baseCmd != o.baseCmd || len(t.Prices) != len(o.Prices) {
+	if !ok || t.baseCmd != o.baseCmd || t.Source != o.Source || !maps.Equal(t.Volumes, o.Volumes) || len(t.Prices) != len(o.Prices) {
 		return false
 	}
 	for k, v := range t.Prices {
@@ -935,9 +1251,9 @@ func (t UpdatePrice) Equal(other Transaction) bool {
 // Validate checks the UpdatePrice transaction's fields.
 func (t UpdatePrice) Validate(ledger *Ledger) (Transaction, error) {
 	t.baseCmd.Validate()
-	for ticker, price := range t.Prices {
+	for ticker, price := range t.PricesIter() {
 		if ledger.Security(ticker) == nil {
-			return t, fmt.Errorf("security %q not declared in ledger", ticker)
+			return t, withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared in ledger", ticker))
 		}
 		if !price.IsPositive() {
 			return t, fmt.Errorf("price for %s must be positive, got %v", ticker, price)
@@ -951,8 +1267,9 @@ func (t UpdatePrice) Validate(ledger *Ledger) (Transaction, error) {
 // Split represents a stock split event for a security.
 type Split struct {
 	secCmd
-	Numerator   int64 `json:"num"`
-	Denominator int64 `json:"den"`
+	Numerator   int64  `json:"num"`
+	Denominator int64  `json:"den"`
+	Source      string `json:"source,omitempty"` // Source is the market data provider of the split, empty if recorded manually.
 }
 
 // NewSplit creates a new Split transaction.
@@ -969,7 +1286,7 @@ func NewSplit(date Date, ticker string, num, den int64) Split {
 
 func (t Split) Equal(other Transaction) bool {
 	o, ok := other.(Split)
-	return ok && t.secCmd == o.secCmd && t.Numerator == o.Numerator && t.Denominator == o.Denominator
+	return ok && t.secCmd == o.secCmd && t.Numerator == o.Numerator && t.Denominator == o.Denominator && t.Source == o.Source
 }
 
 // Validate checks the Split transaction's fields.
@@ -992,6 +1309,7 @@ func (t Split) MarshalJSON() ([]byte, error) {
 	w.EmbedFrom(t.secCmd)
 	w.Append("num", t.Numerator)
 	w.Append("den", t.Denominator)
+	w.Optional("source", t.Source)
 	return w.MarshalJSON()
 }
 
@@ -999,8 +1317,9 @@ func (t Split) MarshalJSON() ([]byte, error) {
 func (t *Split) UnmarshalJSON(data []byte) error {
 	var temp struct {
 		secCmd
-		Numerator   int64 `json:"num"`
-		Denominator int64 `json:"den"`
+		Numerator   int64  `json:"num"`
+		Denominator int64  `json:"den"`
+		Source      string `json:"source"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -1013,5 +1332,919 @@ func (t *Split) UnmarshalJSON(data []byte) error {
 	t.secCmd = temp.secCmd
 	t.Numerator = temp.Numerator
 	t.Denominator = temp.Denominator
+	t.Source = temp.Source
+	return nil
+}
+
+// --- Option Commands ---
+
+// Expire represents the expiry of an option position without exercise.
+//
+// The whole position is closed at a zero value: a long position loses the premium
+// paid, a short position keeps the premium received.
+type Expire struct {
+	secCmd
+}
+
+// NewExpire creates a new Expire transaction.
+func NewExpire(day Date, memo, security string) Expire {
+	return Expire{
+		secCmd: secCmd{baseCmd: baseCmd{Command: CmdExpire, Date: day, Memo: memo}, Security: security},
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Expire.
+func (t Expire) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	return w.MarshalJSON()
+}
+
+func (t Expire) Equal(other Transaction) bool {
+	o, ok := other.(Expire)
+	return ok && t.secCmd == o.secCmd
+}
+
+// Validate checks the Expire transaction's fields.
+// It ensures the security is an option, that it has expired and that there is a
+// position to close.
+func (t Expire) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	c, err := ledger.Security(t.Security).ID().Option()
+	if err != nil {
+		return t, fmt.Errorf("cannot expire %q, it is not an option", t.Security)
+	}
+	if t.When().Before(c.Expiry) {
+		return t, fmt.Errorf("on %s, cannot expire %q before its expiry date %s", t.When(), t.Security, c.Expiry)
+	}
+	if ledger.Position(t.When(), t.Security).IsZero() {
+		return t, fmt.Errorf("on %s, cannot expire %q, there is no position", t.When(), t.Security)
+	}
+	return t, nil
+}
+
+// Assign represents the settlement of option contracts by delivery of the
+// underlying at the strike price, either when the holder of a long position
+// exercises it, or when the writer of a short position is assigned.
+//
+// The contracts are closed at a zero value, and the underlying is bought (long
+// call or short put) or sold (long put or short call) at the strike price.
+type Assign struct {
+	secCmd
+	Quantity Quantity // Quantity is the number of contracts settled, 0 means the whole position.
+}
+
+// NewAssign creates a new Assign transaction.
+// If the quantity is set to 0, the whole position is settled.
+func NewAssign(day Date, memo, security string, quantity Quantity) Assign {
+	return Assign{
+		secCmd:   secCmd{baseCmd: baseCmd{Command: CmdAssign, Date: day, Memo: memo}, Security: security},
+		Quantity: quantity,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Assign.
+func (t Assign) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Optional("quantity", t.Quantity)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Assign.
+func (t *Assign) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Quantity = temp.Quantity
+	return nil
+}
+
+func (t Assign) Equal(other Transaction) bool {
+	o, ok := other.(Assign)
+	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity)
+}
+
+// Validate checks the Assign transaction's fields.
+// It resolves a quantity of 0 to the whole position, and ensures that the option
+// has not expired and that the underlying can be delivered.
+func (t Assign) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	sec := ledger.Security(t.Security)
+	c, err := sec.ID().Option()
+	if err != nil {
+		return t, fmt.Errorf("cannot assign %q, it is not an option", t.Security)
+	}
+	if t.When().After(c.Expiry) {
+		return t, fmt.Errorf("on %s, cannot assign %q, it expired on %s", t.When(), t.Security, c.Expiry)
+	}
+	pos := ledger.Position(t.When(), t.Security)
+	if t.Quantity.IsZero() {
+		// quick fix, assign all.
+		t.Quantity = pos.Abs()
+	}
+	if !t.Quantity.IsPositive() {
+		return t, fmt.Errorf("assign transaction quantity must be positive, got %s", t.Quantity.String())
+	}
+	if pos.Abs().LessThan(t.Quantity) {
+		return t, fmt.Errorf("on %s, cannot assign %v of %s, position is only %v", t.When(), t.Quantity, t.Security, pos)
+	}
+
+	contracts := t.Quantity
+	if pos.IsNegative() {
+		contracts = contracts.Neg()
+	}
+	shares, amount := delivery(c, sec.Currency(), contracts)
+	if shares.IsPositive() {
+		if cash := ledger.CashBalance(amount.Currency(), t.When()); cash.LessThan(amount) {
+			return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot buy %v of %s for %s cash balance is %s", t.When(), shares, c.Underlying, amount, cash))
+		}
+	} else if held := ledger.Position(t.When(), c.Underlying); held.LessThan(shares.Neg()) {
+		return t, fmt.Errorf("on %s, cannot deliver %v of %s, position is only %v", t.When(), shares.Neg(), c.Underlying, held)
+	}
+	return t, nil
+}
+
+// delivery returns the quantity of the underlying received (positive) or delivered
+// (negative) when settling contracts (negative for a short position), and the
+// amount paid or received for it at the strike price.
+func delivery(c OptionContract, currency string, contracts Quantity) (shares Quantity, amount Money) {
+	shares = contracts.Mul(Q(c.Multiplier))
+	if !c.Call {
+		shares = shares.Neg()
+	}
+	return shares, M(c.Strike, currency).Mul(shares.Abs())
+}
+
+// --- Securities Lending Commands ---
+
+// Lend represents shares of a security lent out to a borrower.
+//
+// Lent shares are still owned: they count in the position and its market value,
+// but they are not held in the account until they are recalled.
+type Lend struct {
+	secCmd
+	Quantity Quantity // Quantity is the number of shares lent.
+}
+
+// NewLend creates a new Lend transaction.
+func NewLend(day Date, memo, security string, quantity Quantity) Lend {
+	return Lend{
+		secCmd:   secCmd{baseCmd: baseCmd{Command: CmdLend, Date: day, Memo: memo}, Security: security},
+		Quantity: quantity,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Lend.
+func (t Lend) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("quantity", t.Quantity)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Lend.
+func (t *Lend) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Quantity = temp.Quantity
+	return nil
+}
+
+func (t Lend) Equal(other Transaction) bool {
+	o, ok := other.(Lend)
+	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity)
+}
+
+// Validate checks the Lend transaction's fields.
+// It ensures that the quantity is positive and that enough shares are held.
+func (t Lend) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	if !t.Quantity.IsPositive() {
+		return t, fmt.Errorf("lend transaction quantity must be positive, got %s", t.Quantity.String())
+	}
+	held := ledger.Position(t.When(), t.Security).Sub(ledger.Lent(t.When(), t.Security))
+	if held.LessThan(t.Quantity) {
+		return t, fmt.Errorf("on %s, cannot lend %v of %s, only %v held", t.When(), t.Quantity, t.Security, held)
+	}
+	return t, nil
+}
+
+// Recall represents lent shares of a security returned by the borrower.
+type Recall struct {
+	secCmd
+	Quantity Quantity // Quantity is the number of shares returned, 0 means all lent shares.
+}
+
+// NewRecall creates a new Recall transaction.
+// If the quantity is set to 0, all lent shares are returned.
+func NewRecall(day Date, memo, security string, quantity Quantity) Recall {
+	return Recall{
+		secCmd:   secCmd{baseCmd: baseCmd{Command: CmdRecall, Date: day, Memo: memo}, Security: security},
+		Quantity: quantity,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Recall.
+func (t Recall) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("quantity", t.Quantity)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Recall.
+func (t *Recall) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Quantity = temp.Quantity
+	return nil
+}
+
+func (t Recall) Equal(other Transaction) bool {
+	o, ok := other.(Recall)
+	return ok && t.secCmd == o.secCmd && t.Quantity.Equal(o.Quantity)
+}
+
+// Validate checks the Recall transaction's fields.
+// It resolves a quantity of 0 to all the lent shares, and ensures that no more
+// shares are returned than were lent.
+func (t Recall) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	lent := ledger.Lent(t.When(), t.Security)
+	if t.Quantity.IsZero() {
+		// quick fix, recall all.
+		t.Quantity = lent
+	}
+	if !t.Quantity.IsPositive() {
+		return t, fmt.Errorf("recall transaction quantity must be positive, got %s", t.Quantity.String())
+	}
+	if lent.LessThan(t.Quantity) {
+		return t, fmt.Errorf("on %s, cannot recall %v of %s, only %v lent", t.When(), t.Quantity, t.Security, lent)
+	}
+	return t, nil
+}
+
+// LendingFee represents the fee earned for lending shares of a security.
+//
+// Like dividends, the fee is an income to the owner and does not affect the cash
+// balance: the cash received is recorded by a deposit.
+type LendingFee struct {
+	secCmd
+	Amount Money // Amount is the total fee earned.
+}
+
+// NewLendingFee creates a new LendingFee transaction.
+func NewLendingFee(day Date, memo, security string, amount Money) LendingFee {
+	return LendingFee{
+		secCmd: secCmd{baseCmd: baseCmd{Command: CmdLendingFee, Date: day, Memo: memo}, Security: security},
+		Amount: amount,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for LendingFee.
+func (t LendingFee) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.EmbedFrom(t.Amount)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for LendingFee.
+func (t *LendingFee) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		amountCmd
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Amount = temp.Money()
 	return nil
 }
+
+func (t LendingFee) Equal(other Transaction) bool {
+	o, ok := other.(LendingFee)
+	return ok && t.secCmd == o.secCmd && t.Amount.Equal(o.Amount)
+}
+
+// Validate checks the LendingFee transaction's fields. It ensures the fee is positive.
+func (t LendingFee) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	if !t.Amount.IsPositive() {
+		return t, errors.New("lending fee must have a positive amount")
+	}
+	// Quick fix currency if not provided
+	if t.Amount.Currency() == "" {
+		ledgerSec := ledger.Security(t.Security) // Not nil, checked in secCmd.Validate
+		t.Amount = M(t.Amount.value, ledgerSec.Currency())
+	} else if err := ValidateCurrency(t.Amount.Currency()); err != nil {
+		return t, fmt.Errorf("invalid currency for lending fee: %w", err)
+	}
+	return t, nil
+}
+
+// Note represents a dated commentary on a security, in its memo, to review
+// the decisions taken on it. It has no effect on the portfolio.
+type Note struct {
+	secCmd
+}
+
+// NewNote creates a new Note transaction.
+func NewNote(day Date, memo, security string) Note {
+	return Note{
+		secCmd: secCmd{baseCmd: baseCmd{Command: CmdNote, Date: day, Memo: memo}, Security: security},
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Note.
+func (t Note) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	return w.MarshalJSON()
+}
+
+func (t Note) Equal(other Transaction) bool {
+	o, ok := other.(Note)
+	return ok && t.secCmd == o.secCmd
+}
+
+// Validate checks the Note transaction's fields. It ensures the commentary is
+// not empty.
+func (t Note) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	if strings.TrimSpace(t.Memo) == "" {
+		return t, errors.New("note is missing its commentary")
+	}
+	return t, nil
+}
+
+// --- Price Alert Commands ---
+
+// The directions of the price alerts.
+const (
+	AlertAbove = "above" // the price rises to or above the threshold.
+	AlertBelow = "below" // the price falls to or below the threshold.
+)
+
+// SetPriceAlert represents an alert on the price of a security reaching a
+// threshold, until its expiry. Once triggered, the alert is marked done by a
+// ClearPriceAlert, so that the ledger tells the alerts still active.
+type SetPriceAlert struct {
+	secCmd
+	Direction string          // Direction is AlertAbove or AlertBelow.
+	Threshold decimal.Decimal // Threshold is the price, in the currency of the security.
+	Expiry    Date            // Expiry is the last day of the alert, none if zero.
+}
+
+// NewSetPriceAlert creates a new SetPriceAlert transaction.
+func NewSetPriceAlert(day Date, memo, security, direction string, threshold decimal.Decimal, expiry Date) SetPriceAlert {
+	return SetPriceAlert{
+		secCmd:    secCmd{baseCmd: baseCmd{Command: CmdSetPriceAlert, Date: day, Memo: memo}, Security: security},
+		Direction: direction,
+		Threshold: threshold,
+		Expiry:    expiry,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for SetPriceAlert.
+func (t SetPriceAlert) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("direction", t.Direction)
+	w.Append("threshold", t.Threshold)
+	w.Optional("expiry", t.Expiry)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for SetPriceAlert.
+func (t *SetPriceAlert) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Direction string          `json:"direction"`
+		Threshold decimal.Decimal `json:"threshold"`
+		Expiry    Date            `json:"expiry"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Direction = temp.Direction
+	t.Threshold = temp.Threshold
+	t.Expiry = temp.Expiry
+	return nil
+}
+
+func (t SetPriceAlert) Equal(other Transaction) bool {
+	o, ok := other.(SetPriceAlert)
+	return ok && t.secCmd == o.secCmd && t.Direction == o.Direction && t.Threshold.Equal(o.Threshold) && t.Expiry == o.Expiry
+}
+
+// Validate checks the SetPriceAlert transaction's fields. It ensures the
+// direction is known, the threshold positive, and the expiry not before the
+// alert.
+func (t SetPriceAlert) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	if t.Direction != AlertAbove && t.Direction != AlertBelow {
+		return t, fmt.Errorf("invalid alert direction %q, want %q or %q", t.Direction, AlertAbove, AlertBelow)
+	}
+	if !t.Threshold.IsPositive() {
+		return t, errors.New("price alert must have a positive threshold")
+	}
+	if !t.Expiry.IsZero() && t.Expiry.Before(t.Date) {
+		return t, fmt.Errorf("price alert expires on %s, before it is set", t.Expiry)
+	}
+	return t, nil
+}
+
+// ClearPriceAlert represents the end of a price alert, triggered or cancelled.
+// The alert is the active alert of the security with the same direction and
+// threshold.
+type ClearPriceAlert struct {
+	secCmd
+	Direction string
+	Threshold decimal.Decimal
+}
+
+// NewClearPriceAlert creates a new ClearPriceAlert transaction.
+func NewClearPriceAlert(day Date, memo, security, direction string, threshold decimal.Decimal) ClearPriceAlert {
+	return ClearPriceAlert{
+		secCmd:    secCmd{baseCmd: baseCmd{Command: CmdClearPriceAlert, Date: day, Memo: memo}, Security: security},
+		Direction: direction,
+		Threshold: threshold,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for ClearPriceAlert.
+func (t ClearPriceAlert) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("direction", t.Direction)
+	w.Append("threshold", t.Threshold)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ClearPriceAlert.
+func (t *ClearPriceAlert) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Direction string          `json:"direction"`
+		Threshold decimal.Decimal `json:"threshold"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Direction = temp.Direction
+	t.Threshold = temp.Threshold
+	return nil
+}
+
+func (t ClearPriceAlert) Equal(other Transaction) bool {
+	o, ok := other.(ClearPriceAlert)
+	return ok && t.secCmd == o.secCmd && t.Direction == o.Direction && t.Threshold.Equal(o.Threshold)
+}
+
+// Validate checks the ClearPriceAlert transaction's fields. It ensures the
+// alert is active on the date.
+func (t ClearPriceAlert) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	for _, a := range ledger.PriceAlerts(t.Date) {
+		if a.Security == t.Security && a.Direction == t.Direction && a.Threshold.Equal(t.Threshold) {
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("no active price alert on %q %s %s on %s", t.Security, t.Direction, t.Threshold, t.Date)
+}
+
+// --- Employee Stock Plan Commands ---
+
+// Employee stock plans.
+const (
+	PlanRSU  = "rsu"  // Restricted Stock Units.
+	PlanESPP = "espp" // Employee Stock Purchase Plan.
+)
+
+// VestingTranche is a quantity of shares of a grant that vests on a date.
+type VestingTranche struct {
+	Date     Date     `json:"date"`
+	Quantity Quantity `json:"quantity"`
+}
+
+// Grant represents shares of a security granted by an employer stock plan, vesting
+// according to a schedule.
+//
+// A grant has no effect on the portfolio until its shares vest: unvested shares
+// are neither owned nor valued.
+type Grant struct {
+	secCmd
+	Grant    string           // Grant is the unique name of the grant (e.g. "RSU-2025").
+	Plan     string           // Plan is the stock plan: "rsu" or "espp".
+	Schedule []VestingTranche // Schedule lists the vesting tranches in chronological order.
+}
+
+// NewGrant creates a new Grant transaction.
+func NewGrant(day Date, memo, security, grant, plan string, schedule []VestingTranche) Grant {
+	return Grant{
+		secCmd:   secCmd{baseCmd: baseCmd{Command: CmdGrant, Date: day, Memo: memo}, Security: security},
+		Grant:    grant,
+		Plan:     plan,
+		Schedule: schedule,
+	}
+}
+
+// Quantity returns the total quantity of shares granted.
+func (t Grant) Quantity() Quantity {
+	var total Quantity
+	for _, v := range t.Schedule {
+		total = total.Add(v.Quantity)
+	}
+	return total
+}
+
+// MarshalJSON implements the json.Marshaler interface for Grant.
+func (t Grant) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("grant", t.Grant)
+	w.Append("plan", t.Plan)
+	w.Append("schedule", t.Schedule)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Grant.
+func (t *Grant) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		Grant    string           `json:"grant"`
+		Plan     string           `json:"plan"`
+		Schedule []VestingTranche `json:"schedule"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Grant = temp.Grant
+	t.Plan = temp.Plan
+	t.Schedule = temp.Schedule
+	return nil
+}
+
+func (t Grant) Equal(other Transaction) bool {
+	o, ok := other.(Grant)
+	return ok && t.secCmd == o.secCmd && t.Grant == o.Grant && t.Plan == o.Plan &&
+		slices.EqualFunc(t.Schedule, o.Schedule, func(a, b VestingTranche) bool {
+			return a.Date == b.Date && a.Quantity.Equal(b.Quantity)
+		})
+}
+
+// Validate checks the Grant transaction's fields.
+// It ensures the grant name is unique, the plan is known, and the schedule is
+// chronological, with positive quantities.
+func (t Grant) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	if t.Grant == "" {
+		return t, errors.New("grant name is missing")
+	}
+	if ledger.Grant(t.Grant) != nil {
+		return t, fmt.Errorf("grant %q already exists in ledger", t.Grant)
+	}
+	if t.Plan != PlanRSU && t.Plan != PlanESPP {
+		return t, fmt.Errorf("unknown stock plan %q, valid plans are: %s, %s", t.Plan, PlanRSU, PlanESPP)
+	}
+	if len(t.Schedule) == 0 {
+		return t, fmt.Errorf("grant %q has no vesting schedule", t.Grant)
+	}
+	for i, v := range t.Schedule {
+		if !v.Quantity.IsPositive() {
+			return t, fmt.Errorf("grant %q vesting quantity must be positive, got %s on %s", t.Grant, v.Quantity, v.Date)
+		}
+		if v.Date.Before(t.When()) {
+			return t, fmt.Errorf("grant %q vests on %s, before the grant date %s", t.Grant, v.Date, t.When())
+		}
+		if i > 0 && v.Date.Before(t.Schedule[i-1].Date) {
+			return t, fmt.Errorf("grant %q vesting schedule is not in chronological order", t.Grant)
+		}
+	}
+	return t, nil
+}
+
+// Vest represents shares of a grant materialized in the portfolio.
+//
+// The shares are acquired with their fair market value as cost basis, which is
+// also recorded as an external contribution to the portfolio: the value of the
+// shares comes from the employment, not from the portfolio's cash.
+type Vest struct {
+	secCmd
+	Grant    string   // Grant is the name of the vesting grant.
+	Quantity Quantity // Quantity is the number of shares vested.
+	Amount   Money    // Amount is the fair market value of the vested shares, used as cost basis.
+}
+
+// NewVest creates a new Vest transaction.
+func NewVest(day Date, memo, security, grant string, quantity Quantity, amount Money) Vest {
+	return Vest{
+		secCmd:   secCmd{baseCmd: baseCmd{Command: CmdVest, Date: day, Memo: memo}, Security: security},
+		Grant:    grant,
+		Quantity: quantity,
+		Amount:   amount,
+	}
+}
+
+func (t *Vest) Currency() string { return t.Amount.Currency() }
+
+// MarshalJSON implements the json.Marshaler interface for Vest.
+func (t Vest) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.secCmd)
+	w.Append("grant", t.Grant)
+	w.Append("quantity", t.Quantity)
+	w.EmbedFrom(t.Amount)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Vest.
+func (t *Vest) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		secCmd
+		amountCmd
+		Grant    string   `json:"grant"`
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.secCmd = temp.secCmd
+	t.Grant = temp.Grant
+	t.Quantity = temp.Quantity
+	t.Amount = temp.Money()
+	return nil
+}
+
+func (t Vest) Equal(other Transaction) bool {
+	o, ok := other.(Vest)
+	return ok && t.secCmd == o.secCmd && t.Grant == o.Grant && t.Quantity.Equal(o.Quantity) && t.Amount.Equal(o.Amount)
+}
+
+// Validate checks the Vest transaction's fields.
+// It ensures the grant exists for the security, and that no more shares vest
+// than remain unvested.
+func (t Vest) Validate(ledger *Ledger) (Transaction, error) {
+	if err := t.secCmd.Validate(ledger); err != nil {
+		return t, err
+	}
+	grant := ledger.Grant(t.Grant)
+	if grant == nil {
+		return t, fmt.Errorf("grant %q not found in ledger", t.Grant)
+	}
+	if grant.Security != t.Security {
+		return t, fmt.Errorf("grant %q is for %s, not %s", t.Grant, grant.Security, t.Security)
+	}
+	if !t.Quantity.IsPositive() {
+		return t, fmt.Errorf("vest transaction quantity must be positive, got %s", t.Quantity.String())
+	}
+	if !t.Amount.IsPositive() {
+		return t, fmt.Errorf("vest transaction amount must be positive, got %s", t.Amount.String())
+	}
+	currency := ledger.Security(t.Security).Currency()
+	if t.Currency() == "" {
+		t.Amount = M(t.Amount.value, currency)
+	} else if currency != t.Currency() {
+		return t, fmt.Errorf("vest transaction currency %s does not match security currency %s", t.Currency(), currency)
+	}
+	for _, g := range ledger.NewSnapshot(t.When()).Grants() {
+		if g.Grant == t.Grant && g.Unvested().LessThan(t.Quantity) {
+			return t, fmt.Errorf("on %s, cannot vest %v of grant %q, only %v unvested", t.When(), t.Quantity, t.Grant, g.Unvested())
+		}
+	}
+	return t, nil
+}
+
+// --- Custom Command ---
+
+// CmdCustomPrefix is the prefix of custom transaction commands (e.g. "x-option-premium").
+//
+// Custom transactions let extensions record domain-specific events without
+// changing the core: the ledger does not interpret the transaction itself, only
+// its declared cash and position effects.
+const CmdCustomPrefix = "x-"
+
+// IsCustom returns true if the command is a custom transaction command.
+func (c CommandType) IsCustom() bool {
+	return strings.HasPrefix(string(c), CmdCustomPrefix) && len(c) > len(CmdCustomPrefix)
+}
+
+// CashEffect is a cash movement declared by a custom transaction.
+type CashEffect struct {
+	Amount   Money // Amount is positive for credits and negative for debits.
+	External bool  // External is true when cash comes from, or goes to, outside the portfolio.
+}
+
+// MarshalJSON implements the json.Marshaler interface for CashEffect.
+func (e CashEffect) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(e.Amount)
+	w.Optional("external", e.External)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for CashEffect.
+func (e *CashEffect) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		amountCmd
+		External bool `json:"external,omitempty"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	e.Amount = temp.Money()
+	e.External = temp.External
+	return nil
+}
+
+// PositionEffect is a change of a security position declared by a custom transaction.
+type PositionEffect struct {
+	Security string
+	Quantity Quantity // Quantity is positive for acquisitions and negative for disposals.
+	Amount   Money    // Amount is the cost of an acquisition, or the proceeds of a disposal.
+}
+
+// MarshalJSON implements the json.Marshaler interface for PositionEffect.
+func (e PositionEffect) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.Append("security", e.Security)
+	w.Append("quantity", e.Quantity)
+	w.EmbedFrom(e.Amount)
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for PositionEffect.
+func (e *PositionEffect) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		amountCmd
+		Security string   `json:"security"`
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	e.Security = temp.Security
+	e.Quantity = temp.Quantity
+	e.Amount = temp.Money()
+	return nil
+}
+
+// Custom is a transaction defined by an extension. Its command starts with "x-".
+//
+// The journal applies its declared effects generically, and the payload is kept
+// untouched for the extension.
+type Custom struct {
+	baseCmd
+	Cash      []CashEffect     // Cash lists the cash movements.
+	Positions []PositionEffect // Positions lists the position changes.
+	Payload   json.RawMessage  // Payload is the extension's opaque data.
+}
+
+// NewCustom creates a new Custom transaction. The name is the command without the "x-" prefix.
+func NewCustom(day Date, memo, name string, cash []CashEffect, positions []PositionEffect, payload json.RawMessage) Custom {
+	return Custom{
+		baseCmd:   baseCmd{Command: CommandType(CmdCustomPrefix + name), Date: day, Memo: memo},
+		Cash:      cash,
+		Positions: positions,
+		Payload:   payload,
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for Custom.
+func (t Custom) MarshalJSON() ([]byte, error) {
+	var w jsonObjectWriter
+	w.EmbedFrom(t.baseCmd)
+	w.Optional("cash", t.Cash)
+	w.Optional("positions", t.Positions)
+	if len(t.Payload) > 0 {
+		w.Append("payload", t.Payload)
+	}
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Custom.
+func (t *Custom) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		baseCmd
+		Cash      []CashEffect     `json:"cash,omitempty"`
+		Positions []PositionEffect `json:"positions,omitempty"`
+		Payload   json.RawMessage  `json:"payload,omitempty"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	t.baseCmd = temp.baseCmd
+	t.Cash = temp.Cash
+	t.Positions = temp.Positions
+	t.Payload = temp.Payload
+	return nil
+}
+
+func (t Custom) Equal(other Transaction) bool {
+	o, ok := other.(Custom)
+	return ok && t.baseCmd == o.baseCmd &&
+		slices.EqualFunc(t.Cash, o.Cash, func(a, b CashEffect) bool { return a.Amount.Equal(b.Amount) && a.External == b.External }) &&
+		slices.EqualFunc(t.Positions, o.Positions, func(a, b PositionEffect) bool {
+			return a.Security == b.Security && a.Quantity.Equal(b.Quantity) && a.Amount.Equal(b.Amount)
+		}) &&
+		string(t.Payload) == string(o.Payload)
+}
+
+// Validate checks the Custom transaction's effects. Cash effects must be in a valid
+// currency and debits must not exceed the cash balance. Position effects must
+// refer to declared securities, and disposals must not exceed the position.
+// The currency of a position effect defaults to the security's currency.
+func (t Custom) Validate(ledger *Ledger) (Transaction, error) {
+	t.baseCmd.Validate()
+	if !t.Command.IsCustom() {
+		return t, fmt.Errorf("custom transaction command must start with %q, got %q", CmdCustomPrefix, t.Command)
+	}
+
+	cash := slices.Clone(t.Cash)
+	for i, e := range cash {
+		if err := ValidateCurrency(e.Amount.Currency()); err != nil {
+			return t, fmt.Errorf("invalid currency for %s cash effect: %w", t.Command, err)
+		}
+		if e.Amount.IsZero() {
+			return t, fmt.Errorf("%s cash effect #%d amount must not be zero", t.Command, i+1)
+		}
+		if e.Amount.IsNegative() {
+			if balance := ledger.CashBalance(e.Amount.Currency(), t.Date); balance.LessThan(e.Amount.Neg()) {
+				return t, withKind(ErrInsufficientCash, fmt.Errorf("on %s, cannot debit %s cash balance is %s", t.When(), e.Amount.Neg(), balance))
+			}
+		}
+	}
+	t.Cash = cash
+
+	positions := slices.Clone(t.Positions)
+	for i, e := range positions {
+		sec := ledger.Security(e.Security)
+		if sec == nil {
+			return t, withKind(ErrUnknownSecurity, fmt.Errorf("security %q not declared in ledger", e.Security))
+		}
+		if sec.Watched() {
+			return t, fmt.Errorf("security %q is on the watchlist, it cannot be held", e.Security)
+		}
+		if e.Amount.Currency() == "" {
+			e.Amount = M(e.Amount.value, sec.Currency())
+		} else if e.Amount.Currency() != sec.Currency() {
+			return t, fmt.Errorf("%s position effect currency %s does not match security currency %s", t.Command, e.Amount.Currency(), sec.Currency())
+		}
+		if e.Quantity.IsZero() {
+			return t, fmt.Errorf("%s position effect #%d quantity must not be zero", t.Command, i+1)
+		}
+		if e.Quantity.IsNegative() {
+			if pos := ledger.Position(t.Date, e.Security); pos.LessThan(e.Quantity.Neg()) {
+				return t, fmt.Errorf("on %s, cannot dispose %v of %s, position is only %v", t.When(), e.Quantity.Neg(), e.Security, pos)
+			}
+		}
+		positions[i] = e
+	}
+	t.Positions = positions
+
+	if len(t.Payload) > 0 && !json.Valid(t.Payload) {
+		return t, fmt.Errorf("%s payload is not valid JSON", t.Command)
+	}
+	return t, nil
+}
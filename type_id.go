@@ -6,12 +6,14 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 // IsCurrencyPair returns true if the ID represents a currency pair.
 func (id ID) IsCurrencyPair() bool {
-	_, _, err := id.CurrencyPair()
-	return err == nil
+	// Checked without building an error, as it is called for every price.
+	return len(id) == 6 && currencyPairRegex.MatchString(string(id))
 }
 
 // IsMSSI returns true if the ID represents a MSSI.
@@ -20,6 +22,16 @@ func (id ID) IsMSSI() bool {
 	return err == nil
 }
 
+// IsOption returns true if the ID represents an option contract.
+func (id ID) IsOption() bool {
+	// Most IDs are not options, rule them out without building an error.
+	if strings.Count(string(id), ":") != 4 {
+		return false
+	}
+	_, err := id.Option()
+	return err == nil
+}
+
 // IsISIN returns true if the ID represents a ISIN.
 func (id ID) IsISIN() bool {
 	_, err := id.ISIN()
@@ -85,6 +97,19 @@ var idCharRegex = regexp.MustCompile(`^[a-zA-Z0-9 -]+$`)
 // Example: The pair "EURUSD" represents the price of one Euro (EUR) in terms of
 // US Dollars (USD).
 //
+// # Option
+//
+// Option represents an option contract on a security declared in the ledger.
+//
+// The format is the concatenation of the underlying's ticker, the expiry date,
+// the type ('C' for a call, 'P' for a put), the strike price and the contract
+// multiplier, separated by a COLON character (':').
+//
+// Formal Definition: ID = Underlying ":" Expiry ":" ("C" | "P") ":" Strike ":" Multiplier
+//
+// Example: "AAPL:2025-12-19:C:190:100" for an Apple call, strike 190, expiring
+// on 2025-12-19, each contract covering 100 shares.
+//
 // # Private
 //
 // Private represents a generic, non-standard identifier.
@@ -125,6 +150,15 @@ func NewCurrencyPair(base, quote string) (ID, error) {
 	return ID(base + quote), nil
 }
 
+// NewOption creates a new option ID from its contract terms after validation.
+func NewOption(c OptionContract) (ID, error) {
+	id := ID(c.String())
+	if _, err := id.Option(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
 // NewPrivate creates a new private ID after validating its format.
 func NewISIN(isin string) (ID, error) {
 	if err := ValidateISIN(isin); err != nil {
@@ -181,6 +215,12 @@ func ParseID(s string) (ID, error) {
 		return id, nil
 	}
 
+	// Try parsing as Option
+	_, errOption := id.Option()
+	if errOption == nil {
+		return id, nil
+	}
+
 	// Try parsing as Private
 	_, errPrivate := id.Private()
 	if errPrivate == nil {
@@ -188,7 +228,7 @@ func ParseID(s string) (ID, error) {
 	}
 
 	// If all fail, return a compound error.
-	return "", errors.Join(errMSSI, errCP, errIsin, errPrivate)
+	return "", errors.Join(errMSSI, errCP, errIsin, errOption, errPrivate)
 }
 
 // ValidateISIN checks if a string is a validly formatted ISIN.
@@ -259,11 +299,15 @@ func ValidateMIC(mic string) error {
 	return nil
 }
 
-// ValidateCurrency checks if a string is a validly formatted ISO 4217 currency code.
+// ValidateCurrency checks if a string is an ISO 4217 currency code, or the code
+// of a currency declared by a ledger, see RegisterCurrency.
 func ValidateCurrency(code string) error {
 	if !currencyCodeRegex.MatchString(code) {
 		return fmt.Errorf("invalid currency format: must be 3 uppercase letters, got %q", code)
 	}
+	if !knownCurrency(code) {
+		return fmt.Errorf("unknown currency %q, declare it with 'pcs declare-currency'", code)
+	}
 	return nil
 }
 
@@ -336,6 +380,54 @@ func (id ID) Quote() string {
 	return quote
 }
 
+// OptionContract holds the terms of an option contract.
+type OptionContract struct {
+	Underlying string          // Underlying is the ticker of the underlying security.
+	Expiry     Date            // Expiry is the last day the option can be exercised.
+	Call       bool            // Call is true for a call, false for a put.
+	Strike     decimal.Decimal // Strike is the price per share of the underlying, in the option's currency.
+	Multiplier decimal.Decimal // Multiplier is the number of shares covered by one contract.
+}
+
+// String returns the option ID of the contract.
+func (c OptionContract) String() string {
+	kind := "P"
+	if c.Call {
+		kind = "C"
+	}
+	return strings.Join([]string{c.Underlying, c.Expiry.String(), kind, c.Strike.String(), c.Multiplier.String()}, ":")
+}
+
+// Option validates the "Underlying:Expiry:Type:Strike:Multiplier" format and
+// returns the contract terms.
+func (id ID) Option() (c OptionContract, err error) {
+	parts := strings.Split(string(id), ":")
+	if len(parts) != 5 {
+		return c, fmt.Errorf("invalid format: option must contain exactly four ':', got %q", id)
+	}
+	if c.Underlying = parts[0]; c.Underlying == "" {
+		return c, fmt.Errorf("invalid option: underlying cannot be empty")
+	}
+	if c.Expiry, err = ParseDate(parts[1]); err != nil {
+		return c, fmt.Errorf("invalid option expiry: %w", err)
+	}
+	switch parts[2] {
+	case "C":
+		c.Call = true
+	case "P":
+		c.Call = false
+	default:
+		return c, fmt.Errorf("invalid option type: must be 'C' or 'P', got %q", parts[2])
+	}
+	if c.Strike, err = decimal.NewFromString(parts[3]); err != nil || !c.Strike.IsPositive() {
+		return c, fmt.Errorf("invalid option strike: must be a positive number, got %q", parts[3])
+	}
+	if c.Multiplier, err = decimal.NewFromString(parts[4]); err != nil || !c.Multiplier.IsPositive() {
+		return c, fmt.Errorf("invalid option multiplier: must be a positive number, got %q", parts[4])
+	}
+	return c, nil
+}
+
 // Private validates that a string is a valid Private ID.
 func (id ID) Private() (private string, err error) {
 	_, err = NewPrivate(string(id))
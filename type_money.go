@@ -1,7 +1,13 @@
 package portfolio
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/Rhymond/go-money"
+	"github.com/etnz/portfolio/format"
 	"github.com/shopspring/decimal"
 )
 
@@ -16,21 +22,57 @@ func M[T float32 | float64 | int | int32 | int64 | uint | uint32 | uint64 | deci
 	return Money{value: newDecimal(value), cur: currency}
 }
 
+// FromMinorUnits returns the money for an amount expressed in the currency's
+// minor unit, e.g. cents for EUR or yens for JPY.
+func FromMinorUnits(units int64, currency string) Money {
+	m := Money{cur: currency}
+	m.value = decimal.NewFromInt(units).Shift(-int32(m.Fraction()))
+	return m
+}
+
+// ParseMoney parses a decimal amount in a currency. The amount cannot have more
+// decimals than the currency's minor unit (e.g. none for JPY, 3 for BHD).
+func ParseMoney(amount, currency string) (Money, error) {
+	value, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	m := M(value, currency)
+	if !m.Round().Equal(m) {
+		return Money{}, fmt.Errorf("invalid amount %q: %s has %d decimals", amount, currency, m.Fraction())
+	}
+	return m, nil
+}
+
 // functions that requires the full currency
 
 // currency returns the money's currency
 func (m Money) currency() money.Currency {
+	if c, ok := format.LookupCurrency(m.cur); ok {
+		return *c
+	}
 	// to get a never nil currency I need to call the Money constructor
 	return *money.New(0, m.cur).Currency()
 }
 
-// String returns the string representation of the money value.
-func (m Money) String() string {
-	cur := m.currency()
-	dec := m.value.Shift(int32(cur.Fraction))
-	return cur.Formatter().Format(dec.IntPart())
+// Fraction returns the number of decimals of the currency's minor unit (ISO 4217),
+// e.g. 2 for EUR, 0 for JPY, 3 for BHD. Unknown currencies have 2 decimals.
+func (m Money) Fraction() int { return m.currency().Fraction }
+
+// Round returns the money rounded to the currency's minor unit.
+func (m Money) Round() Money {
+	return Money{value: m.value.Round(int32(m.Fraction())), cur: m.cur}
 }
 
+// MinorUnits returns the amount rounded to the currency's minor unit, expressed
+// in that unit, e.g. cents for EUR.
+func (m Money) MinorUnits() int64 {
+	return m.value.Shift(int32(m.Fraction())).Round(0).IntPart()
+}
+
+// String returns the string representation of the money value in the current locale.
+func (m Money) String() string { return format.Money(m.value, m.cur) }
+
 // Simple wrapper around money.Money
 
 func (m Money) Currency() string                { return m.cur }
@@ -47,20 +89,65 @@ func (m Money) Mul(n Quantity) Money            { return Money{value: m.value.Mu
 func (m Money) Div(n Quantity) Money            { return Money{value: m.value.Div(n.value), cur: m.cur} }
 func (m Money) DivPrice(n Money) Quantity       { return Quantity{value: m.value.Div(n.value)} }
 
+// ErrCurrencyMismatch is returned by checked arithmetic on amounts in different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// StrictCurrency makes Add and Sub panic when an amount without currency, but
+// not zero, is combined with an amount in a currency, instead of silently
+// adopting that currency. It is meant for tests and debugging, to catch amounts
+// that were not converted before being summed.
+var StrictCurrency = false
+
 // binary operators.
 func (m Money) Add(n Money) Money { return Money{value: m.value.Add(n.value), cur: cur(m, n)} }
 func (m Money) Sub(n Money) Money { return Money{value: m.value.Sub(n.value), cur: cur(m, n)} }
 
-// makes the "" currency totally weak.
+// CheckedAdd returns m+n, or ErrCurrencyMismatch if they are in different
+// currencies. A zero amount without currency can be added to any amount.
+func (m Money) CheckedAdd(n Money) (Money, error) {
+	c, err := checkedCur(m, n)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{value: m.value.Add(n.value), cur: c}, nil
+}
+
+// CheckedSub returns m-n, or ErrCurrencyMismatch if they are in different
+// currencies. A zero amount without currency can be subtracted from any amount.
+func (m Money) CheckedSub(n Money) (Money, error) {
+	c, err := checkedCur(m, n)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{value: m.value.Sub(n.value), cur: c}, nil
+}
+
+// checkedCur returns the currency of an operation on A and B. Only a zero
+// amount without currency is neutral.
+func checkedCur(A, B Money) (string, error) {
+	switch {
+	case A.cur == B.cur:
+		return A.cur, nil
+	case A.cur == "" && A.value.IsZero():
+		return B.cur, nil
+	case B.cur == "" && B.value.IsZero():
+		return A.cur, nil
+	}
+	return "", fmt.Errorf("%w: %q != %q", ErrCurrencyMismatch, A.cur, B.cur)
+}
+
+// cur returns the currency of an operation on A and B. Unless in StrictCurrency
+// mode, the "" currency is totally weak. It panics on currency mismatch.
 func cur(A, B Money) string {
-	if A.cur == "" {
-		return B.cur
+	c, err := checkedCur(A, B)
+	if err == nil {
+		return c
 	}
-	if B.cur == "" {
-		return A.cur
+	if StrictCurrency || (A.cur != "" && B.cur != "") {
+		panic(err.Error())
 	}
-	if A.cur != B.cur {
-		panic("currency mismatch" + A.cur + "!=" + B.cur)
+	if A.cur == "" {
+		return B.cur
 	}
 	return A.cur
 }
@@ -69,16 +156,8 @@ func cur(A, B Money) string {
 func (m Money) AsFloat() float64 { return m.value.InexactFloat64() }
 
 // SignedString returns the string representation of the money value with a sign.
-// 0 is represented as a ""
-func (m Money) SignedString() string {
-	if m.value.IsZero() {
-		return "-"
-	}
-	if m.value.IsPositive() {
-		return "+" + m.String()
-	}
-	return m.String()
-}
+// 0 is represented as a "-"
+func (m Money) SignedString() string { return format.SignedMoney(m.value, m.cur) }
 
 // exact return a copy of money that will be persisted with all the digits.
 func (m Money) exact() Money {
@@ -86,15 +165,48 @@ func (m Money) exact() Money {
 	return m
 }
 
+// MarshalJSON implements the json.Marshaler interface. The amount is written
+// as a decimal rounded to the currency's minor unit, as in the ledger files,
+// unless the money is exact, e.g. a dividend per share.
 func (m Money) MarshalJSON() ([]byte, error) {
 	var w jsonObjectWriter
 	w.Optional("currency", m.cur)
-	// it was rounded to 2, which is ok in USD and EUR but should at the very least use cur.Fraction.
-	// however, money is used for dividend per share that can be fractional.
 	rounded := m.value // no rounding by default
 	if !m.fractional {
-		rounded = m.value.Round(int32(m.currency().Fraction))
+		rounded = m.Round().value
 	}
 	w.Append("amount", rounded)
 	return w.MarshalJSON()
 }
+
+// MarshalMinorUnits returns the JSON encoding of the money in the currency's
+// minor unit, e.g. {"currency":"EUR","units":123457} for €1,234.57. Unlike the
+// decimal amount of MarshalJSON, it is an integer whatever the currency.
+func (m Money) MarshalMinorUnits() ([]byte, error) {
+	var w jsonObjectWriter
+	w.Optional("currency", m.cur)
+	w.Append("units", m.MinorUnits())
+	return w.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, it reads the format
+// written by MarshalJSON, or by MarshalMinorUnits.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Currency string          `json:"currency"`
+		Amount   decimal.Decimal `json:"amount"`
+		Units    *int64          `json:"units"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Units != nil {
+		if !v.Amount.IsZero() {
+			return fmt.Errorf("invalid money %s: both an amount and minor units", data)
+		}
+		*m = FromMinorUnits(*v.Units, v.Currency)
+		return nil
+	}
+	*m = M(v.Amount, v.Currency)
+	return nil
+}
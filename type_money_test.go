@@ -0,0 +1,145 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoney_MinorUnits(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		fraction int
+		minor    int64
+		str      string
+		json     string
+		units    string
+	}{
+		{"EUR", 1234.567, 2, 123457, "€1,234.57", `{"currency":"EUR","amount":1234.57}`, `{"currency":"EUR","units":123457}`},
+		{"JPY", 1234.5, 0, 1235, "¥1,235", `{"currency":"JPY","amount":1235}`, `{"currency":"JPY","units":1235}`},
+		{"BHD", 1.2345, 3, 1235, "1.235 .د.ب", `{"currency":"BHD","amount":1.235}`, `{"currency":"BHD","units":1235}`},
+		{"XYZ", 1.005, 2, 101, "1.01XYZ", `{"currency":"XYZ","amount":1.01}`, `{"currency":"XYZ","units":101}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			m := M(tt.amount, tt.currency)
+			if got := m.Fraction(); got != tt.fraction {
+				t.Errorf("Fraction() = %d, want %d", got, tt.fraction)
+			}
+			if got := m.MinorUnits(); got != tt.minor {
+				t.Errorf("MinorUnits() = %d, want %d", got, tt.minor)
+			}
+			if got, want := FromMinorUnits(tt.minor, tt.currency), m.Round(); !got.Equal(want) {
+				t.Errorf("FromMinorUnits() = %v, want %v", got, want)
+			}
+			if got := m.String(); got != tt.str {
+				t.Errorf("String() = %q, want %q", got, tt.str)
+			}
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if got := string(data); got != tt.json {
+				t.Errorf("json.Marshal() = %s, want %s", got, tt.json)
+			}
+			var back Money
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !back.Equal(m.Round()) {
+				t.Errorf("json.Unmarshal() = %v, want %v", back, m.Round())
+			}
+
+			data, err = m.MarshalMinorUnits()
+			if err != nil {
+				t.Fatalf("MarshalMinorUnits() error = %v", err)
+			}
+			if got := string(data); got != tt.units {
+				t.Errorf("MarshalMinorUnits() = %s, want %s", got, tt.units)
+			}
+			back = Money{}
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+			}
+			if !back.Equal(m.Round()) {
+				t.Errorf("json.Unmarshal(%s) = %v, want %v", data, back, m.Round())
+			}
+		})
+	}
+}
+
+func TestMoney_UnmarshalJSON_AmountAndUnits(t *testing.T) {
+	var m Money
+	if err := json.Unmarshal([]byte(`{"currency":"EUR","amount":1.5,"units":150}`), &m); err == nil {
+		t.Errorf("json.Unmarshal() = %v, expected an error", m)
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	if got, err := ParseMoney("1234.5", "EUR"); err != nil || !got.Equal(EUR(1234.5)) {
+		t.Errorf("ParseMoney(1234.5, EUR) = %v, %v, want €1,234.50", got, err)
+	}
+	if got, err := ParseMoney("1.125", "BHD"); err != nil || !got.Equal(M(1.125, "BHD")) {
+		t.Errorf("ParseMoney(1.125, BHD) = %v, %v", got, err)
+	}
+	for _, tt := range []struct{ amount, currency string }{
+		{"100.5", "JPY"},
+		{"1.2345", "BHD"},
+		{"1.001", "EUR"},
+		{"abc", "EUR"},
+	} {
+		if _, err := ParseMoney(tt.amount, tt.currency); err == nil {
+			t.Errorf("ParseMoney(%s, %s) expected an error", tt.amount, tt.currency)
+		}
+	}
+}
+
+func TestMoney_CheckedAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr bool
+	}{
+		{"same currency", EUR(1), EUR(2), EUR(3), false},
+		{"zero without currency", Money{}, USD(2), USD(2), false},
+		{"zero without currency on the right", USD(2), NO(0), USD(2), false},
+		{"different currencies", EUR(1), USD(2), Money{}, true},
+		{"amount without currency", NO(1), USD(2), Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.CheckedAdd(tt.b)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCurrencyMismatch) {
+					t.Errorf("CheckedAdd() error = %v, want ErrCurrencyMismatch", err)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("CheckedAdd() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := EUR(1).CheckedSub(USD(1)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("CheckedSub() error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoney_StrictCurrency(t *testing.T) {
+	defer func(strict bool) { StrictCurrency = strict }(StrictCurrency)
+
+	StrictCurrency = false
+	if got := NO(1).Add(USD(2)); !got.Equal(USD(3)) {
+		t.Errorf("Add() = %v, want $3.00", got)
+	}
+
+	StrictCurrency = true
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Add() in strict mode: expected a panic")
+		}
+	}()
+	NO(1).Add(USD(2))
+}
@@ -1,6 +1,6 @@
 package portfolio
 
-import "fmt"
+import "github.com/etnz/portfolio/format"
 
 type Percent float64
 
@@ -14,14 +14,8 @@ func (p Percent) Equal(q Percent) bool {
 	return diff < precision
 }
 
-func (p Percent) String() string {
-	return fmt.Sprintf("%.2f%%", p)
-}
+// String returns the percentage with two decimals in the current locale.
+func (p Percent) String() string { return format.Percent(float64(p)) }
 
-func (p Percent) SignedString() string {
-	res := fmt.Sprintf("%+.2f%%", p)
-	if res == "+0.00%" {
-		return "-"
-	}
-	return res
-}
+// SignedString returns the percentage with a sign, 0 is represented as a "-".
+func (p Percent) SignedString() string { return format.SignedPercent(float64(p)) }
@@ -1,6 +1,9 @@
 package portfolio
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/etnz/portfolio/format"
+	"github.com/shopspring/decimal"
+)
 
 // newDecimal is a convenient factory for decimal.Decimal
 func newDecimal[T float32 | float64 | int | int32 | int64 | uint | uint32 | uint64 | decimal.Decimal](value T) decimal.Decimal {
@@ -50,7 +53,19 @@ func (t Quantity) GreaterThan(p Quantity) bool     { return t.value.GreaterThan(
 func (t Quantity) IsNegative() bool                { return t.value.IsNegative() }
 func (t Quantity) IsPositive() bool                { return t.value.IsPositive() }
 func (t Quantity) IsZero() bool                    { return t.value.IsZero() }
-func (q Quantity) String() string                  { return q.value.String() }
+func (t Quantity) Neg() Quantity                   { return Quantity{value: t.value.Neg()} }
+func (t Quantity) Abs() Quantity                   { return Quantity{value: t.value.Abs()} }
+func (q Quantity) String() string                  { return format.Number(q.value) }
+
+// Round rounds the quantity to the given number of decimals.
+func (t Quantity) Round(decimals int) Quantity {
+	return Quantity{value: t.value.Round(int32(decimals))}
+}
+
+// Truncate truncates the quantity to the given number of decimals, rounding towards zero.
+func (t Quantity) Truncate(decimals int) Quantity {
+	return Quantity{value: t.value.Truncate(int32(decimals))}
+}
 
 // MarshalJSON implements the json.Marshaler interface for baseCmd.
 func (t Quantity) MarshalJSON() ([]byte, error) {
@@ -3,11 +3,17 @@ package portfolio
 import (
 	"fmt"
 	"iter"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Range represents a range of dates.
-type Range struct{ From, To Date }
+type Range struct {
+	From, To Date
+	// Label names a custom range in reports, e.g. "Trailing 90 Days".
+	Label string `json:",omitempty"`
+}
 
 // NewRange creates a new date range. If 'from' is after 'to', they are swapped.
 func NewRange(from, to Date) Range {
@@ -17,6 +23,56 @@ func NewRange(from, to Date) Range {
 	return Range{From: from, To: to}
 }
 
+// NewTrailingRange returns the range of the given number of days ending on 'to'.
+func NewTrailingRange(days int, to Date) Range {
+	return Range{From: to.Add(1 - days), To: to, Label: fmt.Sprintf("Trailing %d Days", days)}
+}
+
+// NewToDateRange returns the range from the start of the period containing
+// 'to', up to 'to', e.g. the year-to-date range.
+func NewToDateRange(p Period, to Date) Range {
+	r := Range{From: to.StartOf(p), To: to}
+	if p != Daily {
+		r.Label = p.ToDateName()
+	}
+	return r
+}
+
+// NewInceptionRange returns the range from the inception of a portfolio up to 'to'.
+func NewInceptionRange(inception, to Date) Range {
+	return Range{From: inception, To: to, Label: "Since Inception"}
+}
+
+// ParseRange parses a range ending on 'to' from its specification: a standard
+// period (day, week, month, quarter, year) for the whole period containing
+// 'to', a period to date (wtd, mtd, qtd, ytd), trailing days (e.g. 90d), or
+// "inception" for the range since the given inception date.
+func ParseRange(spec string, to, inception Date) (Range, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch spec {
+	case "wtd":
+		return NewToDateRange(Weekly, to), nil
+	case "mtd":
+		return NewToDateRange(Monthly, to), nil
+	case "qtd":
+		return NewToDateRange(Quarterly, to), nil
+	case "ytd":
+		return NewToDateRange(Yearly, to), nil
+	case "inception":
+		return NewInceptionRange(inception, to), nil
+	}
+	if days, ok := strings.CutSuffix(spec, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return NewTrailingRange(n, to), nil
+		}
+	}
+	p, err := ParsePeriod(spec)
+	if err != nil {
+		return Range{}, fmt.Errorf("unknown range %q", spec)
+	}
+	return p.Range(to), nil
+}
+
 // Contains return true date is included in the range (boundaries included)
 func (r Range) Contains(date Date) bool { return (!date.Before(r.From) && !date.After(r.To)) }
 
@@ -48,6 +104,16 @@ func (r Range) Periods(p Period) iter.Seq[Range] {
 	}
 }
 
+// Previous returns the range immediately preceding r: the previous period for a
+// standard period, or the range of the same number of days otherwise.
+func (r Range) Previous() Range {
+	if p, ok := r.Period(); ok {
+		return r.From.Add(-1).Range(p)
+	}
+	days := r.To.Sub(r.From) + 1
+	return NewRange(r.From.Add(-days), r.From.Add(-1))
+}
+
 // return the period of this range if it's a standard one.
 func (r Range) Period() (p Period, ok bool) {
 	switch {
@@ -68,13 +134,33 @@ func (r Range) Period() (p Period, ok bool) {
 
 // Name the period range
 func (r Range) Name() string {
+	if r.Label != "" {
+		return r.Label
+	}
 	p, _ := r.Period()
 	return p.Name()
 }
 
+// Title returns the title of the range in reports: its identifier for a
+// standard period, its label and dates for a custom range.
+func (r Range) Title() string {
+	if _, ok := r.Period(); ok && r.Label == "" {
+		return r.Identifier()
+	}
+	dates := fmt.Sprintf("%s to %s", r.From, r.To)
+	if r.Label == "" {
+		return dates
+	}
+	return fmt.Sprintf("%s (%s)", r.Label, dates)
+}
+
 // Identifier compute a unique identifier for the Range.
 // If the period is defined, use a short insighful name
 func (r Range) Identifier() string {
+	if r.Label != "" {
+		// e.g. trailing-90-days_2025-10-16
+		return fmt.Sprintf("%s_%s", strings.ReplaceAll(strings.ToLower(r.Label), " ", "-"), r.To)
+	}
 
 	p, ok := r.Period()
 	if !ok {
@@ -85,8 +171,8 @@ func (r Range) Identifier() string {
 	case Daily:
 		return r.From.String()
 	case Weekly:
-		_, week := r.From.ISOWeek()
-		return fmt.Sprintf("%d-W%02d", r.From.Year(), week)
+		year, week := r.From.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
 	case Monthly:
 		return r.From.Format("2006-January")
 	case Quarterly:
@@ -53,3 +53,70 @@ func TestRange_Periods(t *testing.T) {
 		})
 	}
 }
+
+func TestRange_Previous(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        Range
+		expected Range
+	}{
+		{
+			name:     "Month",
+			r:        NewRange(NewDate(2024, 3, 1), NewDate(2024, 3, 31)),
+			expected: NewRange(NewDate(2024, 2, 1), NewDate(2024, 2, 29)),
+		},
+		{
+			name:     "Quarter",
+			r:        NewRange(NewDate(2024, 1, 1), NewDate(2024, 3, 31)),
+			expected: NewRange(NewDate(2023, 10, 1), NewDate(2023, 12, 31)),
+		},
+		{
+			name:     "Custom range",
+			r:        NewRange(NewDate(2024, 3, 10), NewDate(2024, 3, 19)),
+			expected: NewRange(NewDate(2024, 2, 29), NewDate(2024, 3, 9)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Previous(); got != tt.expected {
+				t.Errorf("Range.Previous() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	to, inception := NewDate(2025, 10, 16), NewDate(2020, 5, 4)
+	tests := []struct {
+		spec       string
+		expected   Range
+		title      string
+		identifier string
+	}{
+		{"month", NewRange(NewDate(2025, 10, 1), NewDate(2025, 10, 31)), "2025-October", "2025-October"},
+		{"ytd", NewToDateRange(Yearly, to), "Year-to-Date (2025-01-01 to 2025-10-16)", "year-to-date_2025-10-16"},
+		{"90d", NewTrailingRange(90, to), "Trailing 90 Days (2025-07-19 to 2025-10-16)", "trailing-90-days_2025-10-16"},
+		{"inception", NewInceptionRange(inception, to), "Since Inception (2020-05-04 to 2025-10-16)", "since-inception_2025-10-16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRange(tt.spec, to, inception)
+			if err != nil {
+				t.Fatalf("ParseRange(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseRange(%q) = %v, want %v", tt.spec, got, tt.expected)
+			}
+			if got.Title() != tt.title {
+				t.Errorf("Title() = %q, want %q", got.Title(), tt.title)
+			}
+			if got.Identifier() != tt.identifier {
+				t.Errorf("Identifier() = %q, want %q", got.Identifier(), tt.identifier)
+			}
+		})
+	}
+	if _, err := ParseRange("0d", to, inception); err == nil {
+		t.Errorf("ParseRange(0d) expected an error")
+	}
+}
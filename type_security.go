@@ -1,11 +1,18 @@
 package portfolio
 
+import "fmt"
+
 // Security represents a publicly or privately tradeable asset, such as a stock, ETF, or currency pair.
 type Security struct {
-	id          ID     // The unique, standardized identifier (e.g., MSSI, CurrencyPair).
-	ticker      string // The human-friendly ticker used in the portfolio.
-	currency    string // The currency in which the security is traded.
-	description string // A user-provided description for the security.
+	id          ID                 // The unique, standardized identifier (e.g., MSSI, CurrencyPair).
+	ticker      string             // The human-friendly ticker used in the portfolio.
+	currency    string             // The currency in which the security is traded.
+	description string             // A user-provided description for the security.
+	precision   *int               // The number of decimals allowed in quantities, nil if not declared.
+	ter         Percent            // The total expense ratio of a fund, zero if not declared.
+	watch       bool               // The security is on the watchlist, it cannot be held.
+	scores      map[string]float64 // The named scores of the security, e.g. "esg".
+	cash        bool               // The security is a cash equivalent, e.g. a money-market fund.
 }
 
 func NewSecurity(id ID, ticker, currency, description string) Security {
@@ -35,3 +42,97 @@ func (s Security) Currency() string {
 func (s Security) Description() string {
 	return s.description
 }
+
+// WithPrecision returns a copy of the security with the number of decimals
+// allowed in its quantities. A nil precision means any quantity is allowed.
+func (s Security) WithPrecision(precision *int) Security {
+	s.precision = precision
+	return s
+}
+
+// Precision returns the number of decimals allowed in quantities of the
+// security, and false if it was not declared.
+func (s Security) Precision() (int, bool) {
+	if s.precision == nil {
+		return 0, false
+	}
+	return *s.precision, true
+}
+
+// WithTER returns a copy of the security with its total expense ratio.
+func (s Security) WithTER(ter Percent) Security {
+	s.ter = ter
+	return s
+}
+
+// TER returns the total expense ratio of a fund: its annual fees, in percent
+// of its value. It is zero if not declared.
+func (s Security) TER() Percent {
+	return s.ter
+}
+
+// WithWatch returns a copy of the security, on the watchlist or not.
+func (s Security) WithWatch(watch bool) Security {
+	s.watch = watch
+	return s
+}
+
+// Watched returns true if the security is on the watchlist: its market data
+// is tracked, but it cannot be held.
+func (s Security) Watched() bool {
+	return s.watch
+}
+
+// WithCashEquivalent returns a copy of the security, a cash equivalent or not.
+func (s Security) WithCashEquivalent(cash bool) Security {
+	s.cash = cash
+	return s
+}
+
+// CashEquivalent returns true if the security is held as cash, like a
+// money-market fund: allocations count it with the cash.
+func (s Security) CashEquivalent() bool {
+	return s.cash
+}
+
+// WithScores returns a copy of the security with its named scores.
+func (s Security) WithScores(scores map[string]float64) Security {
+	s.scores = scores
+	return s
+}
+
+// Score returns the score of the security with that name, e.g. "esg", and
+// false if it was not declared.
+func (s Security) Score(name string) (float64, bool) {
+	v, ok := s.scores[name]
+	return v, ok
+}
+
+// RoundQuantity rounds q to the security's precision, if declared.
+//
+// It is meant for imports from brokers that report more decimals than the
+// security can actually be traded with.
+func (s Security) RoundQuantity(q Quantity) Quantity {
+	if p, ok := s.Precision(); ok {
+		return q.Round(p)
+	}
+	return q
+}
+
+// ValidateQuantity checks that q does not have more decimals than the
+// security's precision.
+func (s Security) ValidateQuantity(q Quantity) error {
+	if p, ok := s.Precision(); ok && !q.Round(p).Equal(q) {
+		return fmt.Errorf("quantity %s of %s has more than %d decimals", q, s.ticker, p)
+	}
+	return nil
+}
+
+// Multiplier returns the number of units of the underlying covered by one unit
+// of the security: the contract multiplier for options, 1 otherwise.
+func (s Security) Multiplier() Quantity {
+	if c, err := s.id.Option(); err == nil {
+		return Q(c.Multiplier)
+	}
+	return Q(1)
+}
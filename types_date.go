@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/etnz/portfolio/format"
 )
 
 const readDateFormat = "2006-1-2" // Permissive read date format (allows single-digit month/day).
@@ -46,12 +48,12 @@ func (d Date) Day() int { return d.d }
 // String format the date in date RFC3339
 func (d Date) String() string { return d.time().Format(DateFormat) }
 
-// DayString return d.String() + if the day is today the hh:mm:ss time.
+// DayString return the date in the current locale + if the day is today the hh:mm:ss time.
 func (d Date) DayString() string {
 	if d.IsToday() {
-		return d.String() + " " + time.Now().Format("15:04:05")
+		return format.Date(d.time()) + " " + Now().Format("15:04:05")
 	}
-	return d.String()
+	return format.Date(d.time())
 }
 
 // Full format the date in date-time RFC3339
@@ -86,12 +88,29 @@ func (d Date) Before(x Date) bool { return d.time().Before(x.time()) }
 // After reports whether the day d is after x.
 func (d Date) After(x Date) bool { return d.time().After(x.time()) }
 
-// Today returns the current date.
-func Today() Date { return NewDate(time.Now().Date()) }
+// timeZone is the time zone of the current date and time, see SetTimeZone.
+var timeZone = time.Local
+
+// SetTimeZone sets the time zone of the current date and time, the local time
+// zone by default. It is the time zone of the trading day of the portfolio,
+// e.g. the time zone of New York for a user recording US trades from Europe,
+// so that a trade recorded late at night is on the day it was traded.
+//
+// It applies to Today, Now and the relative dates of ParseDate.
+func SetTimeZone(loc *time.Location) { timeZone = loc }
+
+// Now returns the current time in the time zone set by SetTimeZone.
+func Now() time.Time { return time.Now().In(timeZone) }
+
+// Today returns the current date in the time zone set by SetTimeZone.
+func Today() Date { return NewDate(Now().Date()) }
 
 // Add returns a new Date with the given number of days added.
 func (d Date) Add(i int) Date { return NewDate(d.y, d.m, d.d+i) }
 
+// Sub returns the number of days from e to d, negative if d is before e.
+func (d Date) Sub(e Date) int { return int(d.time().Sub(e.time()).Hours() / 24) }
+
 // AddMonth returns a new Date with the given number of days added.
 func (d Date) AddMonth(i int) Date { return NewDate(d.y, d.m+time.Month(i), d.d) }
 
@@ -145,3 +145,24 @@ func TestDate_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestSetTimeZone(t *testing.T) {
+	defer SetTimeZone(time.Local)
+	// Kiribati and Baker Island are 26 hours apart: their dates always differ.
+	ahead, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	behind := time.FixedZone("UTC-12", -12*60*60)
+
+	SetTimeZone(ahead)
+	tomorrow := Today()
+	SetTimeZone(behind)
+	today := Today()
+	if tomorrow == today {
+		t.Errorf("Today() = %s in both Kiribati and Baker Island, want different dates", today)
+	}
+	if got, err := ParseDate("0d"); err != nil || got != today {
+		t.Errorf("ParseDate(0d) = %s, %v, want %s", got, err, today)
+	}
+}
@@ -0,0 +1,56 @@
+package portfolio
+
+// GrantStatus is the vesting status of an employee stock plan grant on a date.
+type GrantStatus struct {
+	Grant    string
+	Security string
+	Plan     string
+	Date     Date     // Date is the date of the grant.
+	Granted  Quantity // Granted is the total quantity of shares granted.
+	Vested   Quantity // Vested is the quantity of shares already vested.
+	// Upcoming lists the tranches still to vest. Vested shares are deducted from
+	// the earliest tranches, so a tranche that is due but not yet recorded as
+	// vested is still listed.
+	Upcoming []VestingTranche
+}
+
+// Unvested returns the quantity of shares still to vest.
+func (g GrantStatus) Unvested() Quantity { return g.Granted.Sub(g.Vested) }
+
+// Grants returns the vesting status of all the grants on the snapshot's date,
+// in the order they were granted.
+func (s *Snapshot) Grants() []GrantStatus {
+	var grants []GrantStatus
+	var schedules [][]VestingTranche
+	index := make(map[string]int)
+	for e := range s.events() {
+		switch v := e.(type) {
+		case declareGrant:
+			g := GrantStatus{Grant: v.grant, Security: v.security, Plan: v.plan, Date: v.on}
+			for _, t := range v.schedule {
+				g.Granted = g.Granted.Add(t.Quantity)
+			}
+			index[v.grant] = len(grants)
+			grants = append(grants, g)
+			schedules = append(schedules, v.schedule)
+		case vestGrant:
+			if i, ok := index[v.grant]; ok {
+				grants[i].Vested = grants[i].Vested.Add(v.quantity)
+			}
+		}
+	}
+
+	// Deduct the vested shares from the earliest tranches to find the upcoming ones.
+	for i := range grants {
+		vested := grants[i].Vested
+		for _, t := range schedules[i] {
+			if !vested.LessThan(t.Quantity) {
+				vested = vested.Sub(t.Quantity)
+				continue
+			}
+			grants[i].Upcoming = append(grants[i].Upcoming, VestingTranche{Date: t.Date, Quantity: t.Quantity.Sub(vested)})
+			vested = Quantity{}
+		}
+	}
+	return grants
+}